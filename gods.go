@@ -0,0 +1,27 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gods reports the version of the library, the data structures are
+// in the packages under pkg (each container package reports the optional
+// features it supports with its Features function).
+package gods
+
+// version is the semantic version of the library, it's bumped with every release
+const version = "0.1.0"
+
+// Version returns the semantic version of the library (e.g. to include it in
+// bug reports together with the Features of the packages in use)
+func Version() string {
+	return version
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bench holds the helpers shared by the *_bench_test.go files of the
+// containers, so every package is benchmarked with the same sizes and data
+// (see doc/benchmarks.md and scripts/run_benchmarks.sh).
+package bench
+
+import (
+	"math/rand"
+	"strconv"
+	"testing"
+)
+
+// Sizes are the numbers of elements the containers are benchmarked with
+var Sizes = []int{1_000, 100_000, 10_000_000}
+
+// shortMax is the largest size used with go test -short
+const shortMax = 100_000
+
+// Name returns the name of the sub-benchmark for n elements (1K, 100K, 10M, ...)
+func Name(n int) string {
+	switch {
+	case n >= 1_000_000 && n%1_000_000 == 0:
+		return strconv.Itoa(n/1_000_000) + "M"
+	case n >= 1_000 && n%1_000 == 0:
+		return strconv.Itoa(n/1_000) + "K"
+	default:
+		return strconv.Itoa(n)
+	}
+}
+
+// Run runs f as a sub-benchmark for every size in Sizes, with the allocations
+// reported. The 10M run is skipped with -short
+func Run(b *testing.B, f func(b *testing.B, n int)) {
+	b.Helper()
+	for _, n := range Sizes {
+		b.Run(Name(n), func(b *testing.B) {
+			if testing.Short() && n > shortMax {
+				b.Skip("skipping the largest size in short mode")
+			}
+			b.ReportAllocs()
+			f(b, n)
+		})
+	}
+}
+
+// PerElement reports the time per element of benchmarks whose iterations
+// work on n elements each (e.g. building a container of n elements)
+func PerElement(b *testing.B, n int) {
+	b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N)/float64(n), "ns/elem")
+}
+
+// Ints returns a permutation of 0..n-1, the same one for every run
+func Ints(n int) []int {
+	return rand.New(rand.NewSource(int64(n))).Perm(n)
+}
+
+// Float64s returns n values in [0, 1), the same ones for every run
+func Float64s(n int) []float64 {
+	rng := rand.New(rand.NewSource(int64(n)))
+	values := make([]float64, n)
+	for i := range values {
+		values[i] = rng.Float64()
+	}
+	return values
+}
@@ -0,0 +1,138 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ctxtest holds the test of the context-aware methods (ForEachCtx,
+// MapCtx and FindCtx) shared by the *_test.go files of the lists, so every
+// list (plain and concurrent) is checked the same way.
+package ctxtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Size is the number of elements of the lists checked by Run
+const Size = 3000
+
+// Node is the node returned by FindCtx
+type Node interface {
+	Value() int
+}
+
+// List is the part of the API of a list checked by Run, L is the list type
+// itself (returned by MapCtx) and N its node type
+type List[L any, N Node] interface {
+	Append(value int)
+	Size() uint64
+	ForEachCtx(ctx context.Context, f func(*int)) error
+	MapCtx(ctx context.Context, f func(int) int) (L, error)
+	FindCtx(ctx context.Context, value int) (N, error)
+}
+
+// Run checks the *Ctx methods of the lists made by newList, errNotFound is the
+// error FindCtx returns for a missing value
+func Run[L List[L, N], N Node](t *testing.T, newList func() L, errNotFound error) {
+	t.Helper()
+	l := newList()
+	for i := 0; i < Size; i++ {
+		l.Append(i)
+	}
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if err := l.ForEachCtx(ctx, func(*int) { t.Error("expected no calls") }); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+		if _, err := l.MapCtx(ctx, func(v int) int { return v }); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+		if _, err := l.FindCtx(ctx, 0); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+	})
+
+	// The context is checked every common.CtxCheckInterval nodes, so the
+	// methods stop at the first check after the cancellation
+	t.Run("CanceledMidway", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		calls := 0
+		err := l.ForEachCtx(ctx, func(*int) {
+			if calls++; calls == 100 {
+				cancel()
+			}
+		})
+		if !errors.Is(err, context.Canceled) || calls != common.CtxCheckInterval {
+			t.Errorf("expected %v after %d calls, got %v after %d", context.Canceled, common.CtxCheckInterval, err, calls)
+		}
+
+		ctx, cancel = context.WithCancel(context.Background())
+		calls = 0
+		mapped, err := l.MapCtx(ctx, func(v int) int {
+			if calls++; calls == common.CtxCheckInterval+100 {
+				cancel()
+			}
+			return v
+		})
+		if !errors.Is(err, context.Canceled) || calls != 2*common.CtxCheckInterval {
+			t.Errorf("expected %v after %d calls, got %v after %d", context.Canceled, 2*common.CtxCheckInterval, err, calls)
+		}
+		var zero L
+		if any(mapped) != any(zero) {
+			t.Errorf("expected no list, got %v", mapped)
+		}
+
+		// FindCtx has no callback, the context reports the cancellation at
+		// its second check (after CtxCheckInterval nodes)
+		c := &countdown{Context: context.Background(), cancelAt: 2}
+		if _, err := l.FindCtx(c, -1); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v, got %v", context.Canceled, err)
+		}
+		if c.checks != 2 {
+			t.Errorf("expected FindCtx to stop at the second check, got %d checks", c.checks)
+		}
+	})
+
+	t.Run("Background", func(t *testing.T) {
+		mapped, err := l.MapCtx(context.Background(), func(v int) int { return v * 2 })
+		if err != nil || mapped.Size() != Size {
+			t.Fatalf("expected %d nodes, got %v (%v)", Size, mapped, err)
+		}
+		if node, err := mapped.FindCtx(context.Background(), 5000); err != nil || node.Value() != 5000 {
+			t.Errorf("expected to find 5000, got %v", err)
+		}
+		if _, err := l.FindCtx(context.Background(), -1); !errors.Is(err, errNotFound) {
+			t.Errorf("expected %v, got %v", errNotFound, err)
+		}
+	})
+}
+
+// countdown is a context canceled when its error has been checked cancelAt
+// times, to cancel a method without a callback while it's running
+type countdown struct {
+	context.Context
+	checks   int
+	cancelAt int
+}
+
+// Err counts the check and returns context.Canceled from the cancelAt-th on
+func (c *countdown) Err() error {
+	if c.checks++; c.checks >= c.cancelAt {
+		return context.Canceled
+	}
+	return nil
+}
@@ -12,16 +12,28 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package abBuffer provides a non-concurrent-safe A/B buffer.
+// Package abBuffer provides an A/B (double) buffer. Its methods take the lock of
+// the A/B buffer, as the automatic swaps (see SetSwapInterval) happen in the
+// background, so it can be shared between goroutines as long as A and B are not
+// used directly (see ABBuffer). The csAbBuffer package wraps it to add the
+// lock statistics of the other concurrent containers and batch operations.
 package abBuffer
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"sync"
+	"time"
 
 	"github.com/pzaino/gods/pkg/buffer"
+	common "github.com/pzaino/gods/pkg/common"
 )
 
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
 const (
 	ErrBufferOverflow = "buffer overflow"
 	ErrInvalidBuffer  = "invalid buffer"
@@ -29,6 +41,25 @@ const (
 	ErrValueNotFound  = "value not found"
 )
 
+// Sentinel errors returned by the ABBuffer methods (use errors.Is to check for them),
+// they are the same returned by the underlying buffers
+var (
+	ErrOverflow    = buffer.ErrOverflow
+	ErrInvalid     = buffer.ErrInvalid
+	ErrEmpty       = buffer.ErrEmpty
+	ErrNotFound    = buffer.ErrNotFound
+	ErrOutOfBounds = buffer.ErrOutOfBounds
+	// ErrNotSwapped is returned by Append when the element was appended but
+	// the automatic swap it triggered didn't fit in the inactive capacity
+	ErrNotSwapped = errors.New("buffers not swapped: the active buffer doesn't fit in the inactive capacity")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureBinaryCodec, common.FeatureDeepCopy)
+}
+
 // ABBuffer represents a double-buffered structure
 // Important notes on this A/B buffer implementation:
 //   - The A/B buffer is a double-buffered structure that allows for efficient swapping of buffers.
@@ -36,85 +67,381 @@ const (
 //   - The "active" buffer in the A/B buffer is the buffer that is currently being used for operations.
 //   - The "inactive" buffer in the A/B buffer is the buffer that is not currently being used for
 //     operations (so it's read only), and therefore can be read safely or passed to other functions.
-type ABBuffer[T comparable] struct {
+//   - Swaps can be automated using SetSwapThreshold and/or SetSwapInterval. When an automatic
+//     swap policy is set, the buffer that becomes active is cleared on every swap so it can
+//     collect new data (use OnSwap to receive the data before it's discarded, the elements
+//     discarded before they were fetched or passed to OnSwap are reported to OnDrop).
+//   - All the methods take the lock of the A/B buffer, as the SetSwapInterval ticker swaps the
+//     buffers in the background. The functions passed to them (ForEach, Map, Filter, ...) run
+//     with the lock held, so they must not call the methods of the A/B buffer. A and B must not
+//     be used directly while the ticker is running.
+type ABBuffer[T any] struct {
 	A        buffer.Buffer[T]
 	B        buffer.Buffer[T]
 	active   *buffer.Buffer[T]
-	capacity uint64
+	capacity uint64 // capacity of the active side (0 means unlimited)
+	// inactiveCapacity limits the elements a swap can move to the inactive
+	// side (0 means unlimited)
+	inactiveCapacity uint64
+	highWater        uint64              // largest size reached by the active side
+	codec            common.Codec[T]     // nil means common.GobCodec
+	equals           common.EqualFunc[T] // nil means ==
+
+	// Auto-swap policy
+	mu        sync.Mutex
+	threshold uint64
+	interval  time.Duration
+	onSwap    func([]T)
+	stop      chan struct{}
+
+	// pending is the bank holding elements that were not fetched nor passed to
+	// onSwap (nil if none), an automatic swap that makes it active drops them
+	pending     *buffer.Buffer[T]
+	dropped     uint64
+	onDrop      func([]T)
+	onSwapError func(error)
+}
+
+// Option configures an ABBuffer created by New
+type Option func(*options)
+
+// options are the settings of New that have a default
+type options struct {
+	inactiveCapacity uint64
+}
+
+// WithInactiveCapacity sets the capacity of the inactive side independently
+// from the active one (e.g. a small active side and a large staging area).
+// A swap that would move more than n elements to the inactive side is not
+// performed (see TrySwap). The default is 0, no limit
+func WithInactiveCapacity(n uint64) Option {
+	return func(o *options) {
+		o.inactiveCapacity = n
+	}
+}
+
+// New creates a new Buffer whose active side can hold capacity elements (0
+// means unlimited), the inactive side can be configured with the options
+func New[T comparable](capacity uint64, opts ...Option) *ABBuffer[T] {
+	return newABBuffer[T](capacity, nil, opts)
+}
+
+// NewWithComparator creates a new Buffer like New whose banks compare their
+// elements with the given function, so it can hold types that are not
+// comparable (slices, maps, ...)
+func NewWithComparator[T any](capacity uint64, equals func(a, b T) bool, opts ...Option) *ABBuffer[T] {
+	return newABBuffer(capacity, equals, opts)
 }
 
-// New creates a new Buffer with a given capacity
-func New[T comparable](capacity uint64) *ABBuffer[T] {
+// newABBuffer creates a new A/B buffer whose banks compare their elements with
+// equals (nil means ==)
+func newABBuffer[T any](capacity uint64, equals common.EqualFunc[T], opts []Option) *ABBuffer[T] {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	a := buffer.Buffer[T]{}
 	b := buffer.Buffer[T]{}
+	if equals != nil {
+		a = *buffer.NewWithComparator(equals)
+		b = *buffer.NewWithComparator(equals)
+	}
 	ab := &ABBuffer[T]{
-		A:        a,
-		B:        b,
-		capacity: capacity,
+		A:                a,
+		B:                b,
+		capacity:         capacity,
+		inactiveCapacity: o.inactiveCapacity,
+		equals:           equals,
 	}
 	ab.active = &ab.A
 	return ab
 }
 
+// newLike creates a new empty A/B buffer with the same capacities, codec and
+// comparator as b
+func (b *ABBuffer[T]) newLike() *ABBuffer[T] {
+	newBuffer := newABBuffer(b.capacity, b.equals, []Option{WithInactiveCapacity(b.inactiveCapacity)})
+	newBuffer.codec = b.codec
+	return newBuffer
+}
+
+// mark updates the high-water mark with the size of the active side
+func (b *ABBuffer[T]) mark() {
+	b.highWater = max(b.highWater, b.active.Size())
+}
+
 // Append adds a new element to the active buffer
+// if a swap threshold is set and the active buffer reaches it, the buffers are swapped
 func (b *ABBuffer[T]) Append(value T) error {
+	b.mu.Lock()
 	if (b.active.Size() >= b.capacity) && (b.capacity != 0) {
-		return errors.New(ErrBufferOverflow)
+		b.mu.Unlock()
+		return ErrOverflow
 	}
 	err := b.active.Append(value)
-	return err
+	b.mark()
+	if err != nil || b.threshold == 0 || b.active.Size() < b.threshold {
+		b.mu.Unlock()
+		return err
+	}
+	events, err := b.swap()
+	b.mu.Unlock()
+
+	events.fire()
+	if err != nil {
+		return ErrNotSwapped
+	}
+	return nil
 }
 
 // Clear clears the active buffer
 func (b *ABBuffer[T]) Clear() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.active.Clear()
 }
 
 // ClearAll clears both the active and inactive buffers
 func (b *ABBuffer[T]) ClearAll() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.A.Clear()
 	b.B.Clear()
 	b.active = &b.A
+	b.pending = nil
 }
 
-// Destroy clears both the active and inactive buffers and sets the active buffer to nil
+// Wipe overwrites all the elements of both buffers with the zero value and
+// releases their storage (see buffer.Buffer.Wipe), the active buffer becomes A
+func (b *ABBuffer[T]) Wipe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.A.Wipe()
+	b.B.Wipe()
+	b.active = &b.A
+	b.pending = nil
+}
+
+// Destroy wipes both the active and inactive buffers (see Wipe) and sets the
+// active buffer to nil, it also stops the automatic swap ticker (if any)
 func (b *ABBuffer[T]) Destroy() {
-	b.A.Clear()
-	b.B.Clear()
+	b.SetSwapInterval(0)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.A.Wipe()
+	b.B.Wipe()
 	b.active = nil
 	b.capacity = 0
+	b.inactiveCapacity = 0
+	b.highWater = 0
 	b = nil
 }
 
 // Swap swaps the active buffer with the inactive one
-func (b *ABBuffer[T]) Swap() {
+// if an OnSwap callback is set, it's called with the content of the newly inactive buffer.
+// It returns ErrOverflow, without swapping, if the active buffer holds more
+// elements than the inactive capacity
+func (b *ABBuffer[T]) Swap() error {
+	return b.TrySwap()
+}
+
+// TrySwap swaps the active buffer with the inactive one like Swap
+func (b *ABBuffer[T]) TrySwap() error {
+	b.mu.Lock()
+	events, err := b.swap()
+	b.mu.Unlock()
+
+	events.fire()
+	return err
+}
+
+// swapEvents holds the callbacks to call, without the lock, after a swap
+type swapEvents[T any] struct {
+	swapped []T
+	onSwap  func([]T)
+	dropped []T
+	onDrop  func([]T)
+}
+
+// fire calls the callbacks of a swap, the dropped elements first
+func (e swapEvents[T]) fire() {
+	if e.onDrop != nil && len(e.dropped) != 0 {
+		e.onDrop(e.dropped)
+	}
+	if e.onSwap != nil {
+		e.onSwap(e.swapped)
+	}
+}
+
+// swap swaps the buffers and returns the callbacks to call with the content of
+// the newly inactive buffer and the elements dropped by an automatic policy, or
+// ErrOverflow if the active buffer doesn't fit in the inactive capacity. It must
+// be called with b.mu held
+func (b *ABBuffer[T]) swap() (swapEvents[T], error) {
+	var events swapEvents[T]
+	if b.inactiveCapacity != 0 && b.active.Size() > b.inactiveCapacity {
+		return events, ErrOverflow
+	}
 	if b.active == &b.A {
 		b.active = &b.B
 	} else {
 		b.active = &b.A
 	}
+	if b.threshold != 0 || b.interval != 0 {
+		if b.pending == b.active && !b.active.IsEmpty() {
+			b.dropped += b.active.Size()
+			if b.onDrop != nil {
+				events.dropped, events.onDrop = b.active.ToSlice(), b.onDrop
+			}
+		}
+		b.active.Reset()
+	}
+
+	inactive := b.inactive()
+	b.pending = nil
+	if b.onSwap == nil && !inactive.IsEmpty() {
+		b.pending = inactive
+	}
+	if b.onSwap != nil {
+		events.swapped, events.onSwap = inactive.ToSlice(), b.onSwap
+	}
+	return events, nil
+}
+
+// SetSwapThreshold sets the number of elements in the active buffer that
+// triggers an automatic swap (0 disables it)
+func (b *ABBuffer[T]) SetSwapThreshold(n uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.threshold = n
+}
+
+// SwapThreshold returns the automatic swap threshold
+func (b *ABBuffer[T]) SwapThreshold() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.threshold
+}
+
+// SetSwapInterval starts a background ticker that swaps the buffers every d
+// (0 or a negative duration stops it), the errors of its swaps are reported to
+// OnSwapError
+func (b *ABBuffer[T]) SetSwapInterval(d time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stop != nil {
+		close(b.stop)
+		b.stop = nil
+	}
+	if d <= 0 {
+		b.interval = 0
+		return
+	}
+
+	b.interval = d
+	b.stop = make(chan struct{})
+	go b.swapEvery(d, b.stop)
+}
+
+// SwapInterval returns the automatic swap interval
+func (b *ABBuffer[T]) SwapInterval() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.interval
+}
+
+// OnSwap sets a callback that receives the content of the newly inactive
+// buffer every time the buffers are swapped (nil removes it)
+func (b *ABBuffer[T]) OnSwap(f func([]T)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onSwap = f
+}
+
+// OnDrop sets a callback that receives the elements an automatic swap
+// discards because they were still in the inactive buffer, never fetched nor
+// passed to OnSwap (nil removes it)
+func (b *ABBuffer[T]) OnDrop(f func([]T)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onDrop = f
+}
+
+// Dropped returns the number of elements discarded by the automatic swaps
+// (see OnDrop)
+func (b *ABBuffer[T]) Dropped() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// OnSwapError sets a callback that receives the errors of the swaps made by
+// the SetSwapInterval ticker (ErrOverflow when the active buffer doesn't fit
+// in the inactive capacity, the swap is retried at the next tick), nil removes it
+func (b *ABBuffer[T]) OnSwapError(f func(error)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.onSwapError = f
+}
+
+func (b *ABBuffer[T]) swapEvery(d time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(d)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			b.mu.Lock()
+			select {
+			case <-stop:
+				// Stopped while waiting for the lock
+				b.mu.Unlock()
+				return
+			default:
+			}
+			events, err := b.swap() // retried at the next tick if it doesn't fit
+			onSwapError := b.onSwapError
+			b.mu.Unlock()
+
+			events.fire()
+			if err != nil && onSwapError != nil {
+				onSwapError(err)
+			}
+		}
+	}
 }
 
 // SetActiveA sets the active buffer to A
 func (b *ABBuffer[T]) SetActiveA() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.active = &b.A
 }
 
 // SetActiveB sets the active buffer to B
 func (b *ABBuffer[T]) SetActiveB() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.active = &b.B
 }
 
-// GetActive returns the active buffer
+// GetActive returns a copy of the active buffer
 func (b *ABBuffer[T]) GetActive() []T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.Values()
 }
 
-// GetInactive returns the inactive buffer
+// GetInactive returns a copy of the inactive buffer
 func (b *ABBuffer[T]) GetInactive() []T {
 	if b == nil {
 		return nil
 	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	if b.active == &b.A {
 		return b.B.Values()
 	}
@@ -123,21 +450,54 @@ func (b *ABBuffer[T]) GetInactive() []T {
 
 // Size returns the number of elements in the active buffer
 func (b *ABBuffer[T]) Size() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.Size()
 }
 
-// Capacity returns the capacity of the buffer
+// Capacity returns the capacity of the active side of the buffer
 func (b *ABBuffer[T]) Capacity() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.capacity
 }
 
+// InactiveCapacity returns the capacity of the inactive side of the buffer
+// (0 means unlimited, see WithInactiveCapacity)
+func (b *ABBuffer[T]) InactiveCapacity() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.inactiveCapacity
+}
+
+// HighWaterMark returns the largest number of elements the active buffer has
+// held since the A/B buffer was created (or since ResetHighWaterMark), it
+// helps sizing the capacities
+func (b *ABBuffer[T]) HighWaterMark() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.highWater
+}
+
+// ResetHighWaterMark sets the high-water mark to the current size of the
+// active buffer
+func (b *ABBuffer[T]) ResetHighWaterMark() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.highWater = b.active.Size()
+}
+
 // IsEmpty checks if the active buffer is empty
 func (b *ABBuffer[T]) IsEmpty() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.IsEmpty()
 }
 
 // ToSlice returns the active buffer as a slice
 func (b *ABBuffer[T]) ToSlice() []T {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.ToSlice()
 }
 
@@ -146,70 +506,155 @@ func (b *ABBuffer[T]) ToSliceInactive() []T {
 	return b.GetInactive()
 }
 
+// String returns a string representation of the active buffer (elements are formatted with %v)
+func (b *ABBuffer[T]) String() string {
+	return b.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the active buffer with every element formatted by f
+func (b *ABBuffer[T]) StringFunc(f func(T) string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.active.StringFunc(f)
+}
+
 // FetchInactive returns the inactive buffer and clears it in the A/B buffer
 func (b *ABBuffer[T]) FetchInactive() []T {
-	var inactive *buffer.Buffer[T]
-	if b.active == &b.A {
-		inactive = &b.B
-	} else {
-		inactive = &b.A
-	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	inactive := b.inactive()
 	data := inactive.ToSlice()
 	inactive.Clear()
+	if b.pending == inactive {
+		b.pending = nil
+	}
 	return data
 }
 
+// inactive returns the inactive buffer
+func (b *ABBuffer[T]) inactive() *buffer.Buffer[T] {
+	if b.active == &b.A {
+		return &b.B
+	}
+	return &b.A
+}
+
+// drain passes the inactive buffer to fn and then resets it, keeping its
+// storage. It must be called with b.mu held
+func (b *ABBuffer[T]) drain(fn func([]T)) error {
+	inactive := b.inactive()
+	if inactive.IsEmpty() {
+		return ErrEmpty
+	}
+	fn(inactive.UnsafeSlice())
+	inactive.Reset()
+	if b.pending == inactive {
+		b.pending = nil
+	}
+	return nil
+}
+
+// DrainInactive passes the content of the inactive buffer to fn and then
+// empties it, keeping its storage so it can be refilled without reallocating.
+// The slice is not a copy: fn must not retain it after returning. fn is called
+// with the A/B buffer locked, so it must not call its methods (Append waits for
+// it to return). It returns ErrEmpty, without calling fn, if the inactive buffer is empty
+func (b *ABBuffer[T]) DrainInactive(fn func([]T)) error {
+	if b == nil {
+		return ErrInvalid
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.drain(fn)
+}
+
+// SwapAndDrain swaps the buffers and drains the newly inactive one (see
+// DrainInactive) in a single locked operation, so no Append can land in the
+// drained buffer between the two steps. If an OnSwap callback is set, it's called
+// after fn with a copy of the drained data. It returns ErrOverflow, without
+// swapping or draining, if the active buffer doesn't fit in the inactive capacity
+func (b *ABBuffer[T]) SwapAndDrain(fn func([]T)) error {
+	if b == nil {
+		return ErrInvalid
+	}
+	b.mu.Lock()
+	events, err := b.swap()
+	if err == nil {
+		err = b.drain(fn)
+	}
+	b.mu.Unlock()
+
+	events.fire()
+	return err
+}
+
 // Find returns the first index of the given value in the active buffer
 func (b *ABBuffer[T]) Find(value T) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.Find(value)
 }
 
 // Remove removes the element at the given index in the active buffer
 func (b *ABBuffer[T]) Remove(index uint64) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.Remove(index)
 }
 
 // InsertAt inserts a new element at the given index in the active buffer
 func (b *ABBuffer[T]) InsertAt(index uint64, value T) error {
-	return b.active.InsertAt(index, value)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := b.active.InsertAt(index, value)
+	b.mark()
+	return err
 }
 
 // ForEach applies the function to all elements in the active buffer
 func (b *ABBuffer[T]) ForEach(f func(*T) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.ForEach(f)
 }
 
 // ForFrom applies the function to all elements in the active buffer starting from the given index
 func (b *ABBuffer[T]) ForFrom(index uint64, f func(*T) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.ForFrom(index, f)
 }
 
 // ForRange applies the function to all elements in the active buffer in the range [start, end)
 func (b *ABBuffer[T]) ForRange(start, end uint64, f func(*T) error) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.ForRange(start, end, f)
 }
 
 // Map generates a new buffer by applying the function to all elements in the active buffer
 func (b *ABBuffer[T]) Map(f func(T) T) (*ABBuffer[T], error) {
-	newBuffer := New[T](b.capacity)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	newBuffer := b.newLike()
 	nb, err := b.active.Map(f)
 	if err != nil {
 		return nil, err
 	}
 	newBuffer.A = *nb
 	newBuffer.active = &newBuffer.A
-	fmt.Printf("New buffer: %v\n", newBuffer)
-	fmt.Printf("New buffer active: %v\n", newBuffer.active)
 	return newBuffer, nil
 }
 
 // MapFrom generates a new buffer by applying the function to all elements in the active buffer starting from the given index
 func (b *ABBuffer[T]) MapFrom(index uint64, f func(T) T) (*ABBuffer[T], error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	if index >= b.active.Size() {
-		return nil, errors.New(ErrInvalidBuffer)
+		return nil, ErrInvalid
 	}
 
-	newBuffer := New[T](b.capacity)
+	newBuffer := b.newLike()
 	nb, err := b.active.MapFrom(index, f)
 	if err != nil {
 		return nil, err
@@ -221,11 +666,13 @@ func (b *ABBuffer[T]) MapFrom(index uint64, f func(T) T) (*ABBuffer[T], error) {
 
 // MapRange generates a new buffer by applying the function to all elements in the active buffer in the range [start, end]
 func (b *ABBuffer[T]) MapRange(start, end uint64, f func(T) T) (*ABBuffer[T], error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	if start >= b.active.Size() || end > b.active.Size() {
-		return nil, errors.New(ErrInvalidBuffer)
+		return nil, ErrInvalid
 	}
 
-	newBuffer := New[T](b.capacity)
+	newBuffer := b.newLike()
 	nb, err := b.active.MapRange(start, end, f)
 	if err != nil {
 		return nil, err
@@ -237,58 +684,195 @@ func (b *ABBuffer[T]) MapRange(start, end uint64, f func(T) T) (*ABBuffer[T], er
 
 // Filter filter the active buffer by removing elements that don't match the predicate
 func (b *ABBuffer[T]) Filter(f func(T) bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	b.active.Filter(f)
 }
 
 // Reduce reduces the buffer to a single value using the given function and initial value
 func (b *ABBuffer[T]) Reduce(f func(T, T) T) (T, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.Reduce(f)
 }
 
 // ReduceFrom reduces the buffer to a single value starting from the given index using the given function and initial value
 func (b *ABBuffer[T]) ReduceFrom(index uint64, f func(T, T) T) (T, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.ReduceFrom(index, f)
 }
 
 // ReduceRange reduces the buffer to a single value in the range [start, end) using the given function and initial value
 func (b *ABBuffer[T]) ReduceRange(start, end uint64, f func(T, T) T) (T, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.ReduceRange(start, end, f)
 }
 
 // Contains checks if the active buffer contains the given value
 func (b *ABBuffer[T]) Contains(value T) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.Contains(value)
 }
 
 // Any checks if any element in the active buffer matches the predicate
 func (b *ABBuffer[T]) Any(f func(T) bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.Any(f)
 }
 
 // All checks if all elements in the active buffer match the predicate
 func (b *ABBuffer[T]) All(f func(T) bool) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.All(f)
 }
 
 // LastIndexOf returns the index of the last element with the given value in the active buffer
 func (b *ABBuffer[T]) LastIndexOf(value T) (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	return b.active.LastIndexOf(value)
 }
 
 // Copy creates a new A/B buffer with the same elements as the A/B buffer
-// this method does a deep copy of the entire A/B buffer
+// this method copies both the banks, but the elements themselves are copied by
+// assignment (use CloneWith to deep copy elements holding pointers, slices or maps)
 func (b *ABBuffer[T]) Copy() *ABBuffer[T] {
-	newBuffer := New[T](b.capacity)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	newBuffer := b.newLike()
 	newBuffer.A = *b.A.Copy()
 	newBuffer.B = *b.B.Copy()
+	newBuffer.highWater = b.highWater
+	return newBuffer
+}
+
+// CloneWith creates a new A/B buffer with a copy of every element of both the
+// banks made by copier
+func (b *ABBuffer[T]) CloneWith(copier func(T) T) *ABBuffer[T] {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	newBuffer := b.newLike()
+	newBuffer.A = *b.A.CloneWith(copier)
+	newBuffer.B = *b.B.CloneWith(copier)
+	newBuffer.highWater = b.highWater
 	return newBuffer
 }
 
+// SetCodec sets the codec used by MarshalBinary and UnmarshalBinary to encode
+// the elements (nil restores the default common.GobCodec)
+func (b *ABBuffer[T]) SetCodec(codec common.Codec[T]) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.codec = codec
+}
+
+// MarshalBinary encodes both the banks and which one is active (implements
+// encoding.BinaryMarshaler). The capacities and the swap policy are not encoded
+func (b *ABBuffer[T]) MarshalBinary() ([]byte, error) {
+	if b == nil || b.active == nil {
+		return nil, ErrInvalid
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	codec := b.elemCodec()
+	itemsA, itemsB := b.A.UnsafeSlice(), b.B.UnsafeSlice()
+	data := common.AppendBinaryHeader(nil, uint64(len(itemsA)+len(itemsB)))
+	if b.active == &b.A {
+		data = append(data, 0)
+	} else {
+		data = append(data, 1)
+	}
+	data = binary.AppendUvarint(data, uint64(len(itemsA)))
+	data, err := common.AppendElements(data, codec, itemsA)
+	if err != nil {
+		return nil, err
+	}
+	return common.AppendElements(data, codec, itemsB)
+}
+
+// UnmarshalBinary replaces both the banks with the ones encoded by
+// MarshalBinary (implements encoding.BinaryUnmarshaler). It returns
+// ErrOverflow if a bank doesn't fit in its capacity, the A/B buffer is
+// unchanged on errors
+func (b *ABBuffer[T]) UnmarshalBinary(data []byte) error {
+	if b == nil {
+		return ErrInvalid
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	count, data, err := common.ReadBinaryHeader(data)
+	if err != nil {
+		return err
+	}
+	if len(data) == 0 || data[0] > 1 {
+		return fmt.Errorf("%w: invalid active bank", common.ErrInvalidFormat)
+	}
+	activeB := data[0] == 1
+	countA, data, err := common.ReadUvarint(data[1:])
+	if err != nil {
+		return err
+	}
+	if countA > count {
+		return fmt.Errorf("%w: bank A larger than the buffer", common.ErrInvalidFormat)
+	}
+	items, data, err := common.ReadElements(data, b.elemCodec(), count)
+	if err != nil {
+		return err
+	}
+	if len(data) != 0 {
+		return fmt.Errorf("%w: %d trailing bytes", common.ErrInvalidFormat, len(data))
+	}
+
+	itemsA, itemsB := items[:countA], items[countA:]
+	active, inactive := itemsA, itemsB
+	if activeB {
+		active, inactive = itemsB, itemsA
+	}
+	if (b.capacity != 0 && uint64(len(active)) > b.capacity) ||
+		(b.inactiveCapacity != 0 && uint64(len(inactive)) > b.inactiveCapacity) {
+		return ErrOverflow
+	}
+
+	fill(&b.A, itemsA)
+	fill(&b.B, itemsB)
+	b.active = &b.A
+	if activeB {
+		b.active = &b.B
+	}
+	b.mark()
+	return nil
+}
+
+// elemCodec returns the codec used to encode the elements
+func (b *ABBuffer[T]) elemCodec() common.Codec[T] {
+	if b.codec == nil {
+		return common.GobCodec[T]{}
+	}
+	return b.codec
+}
+
+// fill replaces the elements of a bank with items
+func fill[T any](bank *buffer.Buffer[T], items []T) {
+	bank.Clear()
+	for _, v := range items {
+		_ = bank.Append(v) // the banks have no capacity of their own
+	}
+}
+
 // CopyActive creates a new buffer with the same elements as the active buffer
 // The copied buffer is placed in the A buffer on the new A/B Buffer and A
 // buffer is set as the active buffer
 func (b *ABBuffer[T]) CopyActive() *ABBuffer[T] {
-	newBuffer := New[T](b.capacity)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	newBuffer := b.newLike()
 	if b.active == &b.A {
 		newBuffer.A = *b.A.Copy()
 	} else {
@@ -303,7 +887,9 @@ func (b *ABBuffer[T]) CopyActive() *ABBuffer[T] {
 // The copied buffer is placed in the A buffer on the new A/B Buffer and A
 // buffer is set as the active buffer
 func (b *ABBuffer[T]) CopyInactive() *ABBuffer[T] {
-	newBuffer := New[T](b.capacity)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	newBuffer := b.newLike()
 	if b.active == &b.A {
 		newBuffer.A = *b.B.Copy()
 	} else {
@@ -315,11 +901,48 @@ func (b *ABBuffer[T]) CopyInactive() *ABBuffer[T] {
 }
 
 // Merge merges the active buffer with the active buffer from another A/B buffer
+// (the elements are moved, the active buffer of other is left empty). The two
+// A/B buffers are never locked at the same time, merging a buffer with itself
+// does nothing
 func (b *ABBuffer[T]) Merge(other *ABBuffer[T]) {
-	b.active.Merge(other.active)
+	if other == b {
+		return
+	}
+	other.mu.Lock()
+	moved := other.active.Copy()
+	other.active.Clear()
+	other.mu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.active.Merge(moved)
+	b.mark()
 }
 
 // Blit overwrite the values of the active buffer with the values of the other buffer using the "blitting" function
+// (the active buffer of other is copied first, so the two A/B buffers are
+// never locked at the same time)
 func (b *ABBuffer[T]) Blit(other *ABBuffer[T], f func(T, T) T) error {
-	return b.active.Blit(other.active, f)
+	if other == b {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		return b.active.Blit(b.active, f)
+	}
+
+	other.mu.Lock()
+	src := other.active.Copy()
+	other.mu.Unlock()
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.active.Blit(src, f)
+}
+
+// SetBlitParallelism sets how Blit splits the work on both the buffers (see
+// buffer.SetBlitParallelism)
+func (b *ABBuffer[T]) SetBlitParallelism(workers int, threshold uint64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.A.SetBlitParallelism(workers, threshold)
+	b.B.SetBlitParallelism(workers, threshold)
 }
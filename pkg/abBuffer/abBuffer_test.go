@@ -1,9 +1,15 @@
 package abBuffer_test
 
 import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/pzaino/gods/pkg/abBuffer"
+	common "github.com/pzaino/gods/pkg/common"
 )
 
 const (
@@ -635,3 +641,359 @@ func TestCopyActive(t *testing.T) {
 		t.Errorf(errExpectedXGotY, buf.GetActive(), newBuf.GetActive())
 	}
 }
+
+func TestOnSwap(t *testing.T) {
+	buf := abBuffer.New[int](16)
+	var got []int
+	buf.OnSwap(func(data []int) { got = data })
+
+	_ = buf.Append(1)
+	_ = buf.Append(2)
+	buf.Swap()
+	if !equal(got, []int{1, 2}) {
+		t.Errorf(errExpectedXGotY, "[1 2]", got)
+	}
+
+	// Manual swaps without an automatic policy keep the old content
+	buf.Swap()
+	if !equal(buf.GetActive(), []int{1, 2}) {
+		t.Errorf(errExpectedXGotY, "[1 2]", buf.GetActive())
+	}
+}
+
+func TestSwapThreshold(t *testing.T) {
+	buf := abBuffer.New[int](0)
+	var flushed [][]int
+	buf.OnSwap(func(data []int) { flushed = append(flushed, data) })
+	buf.SetSwapThreshold(3)
+	if buf.SwapThreshold() != 3 {
+		t.Errorf(errExpectedXGotY, 3, buf.SwapThreshold())
+	}
+
+	for i := 1; i <= 7; i++ {
+		if err := buf.Append(i); err != nil {
+			t.Fatalf(errUnexpectedError, err)
+		}
+	}
+
+	if len(flushed) != 2 {
+		t.Fatalf(errExpectedXGotY, 2, len(flushed))
+	}
+	if !equal(flushed[0], []int{1, 2, 3}) || !equal(flushed[1], []int{4, 5, 6}) {
+		t.Errorf(errExpectedXGotY, "[[1 2 3] [4 5 6]]", flushed)
+	}
+	if !equal(buf.GetActive(), []int{7}) {
+		t.Errorf(errExpectedXGotY, "[7]", buf.GetActive())
+	}
+
+	buf.SetSwapThreshold(0)
+	for i := 8; i <= 10; i++ {
+		_ = buf.Append(i)
+	}
+	if len(flushed) != 2 {
+		t.Errorf(errExpectedXGotY, 2, len(flushed))
+	}
+}
+
+func TestSwapInterval(t *testing.T) {
+	buf := abBuffer.New[int](0)
+	var mu sync.Mutex
+	total := 0
+	buf.OnSwap(func(data []int) {
+		mu.Lock()
+		total += len(data)
+		mu.Unlock()
+	})
+	buf.SetSwapInterval(time.Millisecond)
+	if buf.SwapInterval() != time.Millisecond {
+		t.Errorf(errExpectedXGotY, time.Millisecond, buf.SwapInterval())
+	}
+
+	for i := 0; i < 100; i++ {
+		_ = buf.Append(i)
+		if i%10 == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	buf.SetSwapInterval(0)
+	if buf.SwapInterval() != 0 {
+		t.Errorf(errExpectedXGotY, 0, buf.SwapInterval())
+	}
+
+	// Flush what's left
+	buf.Swap()
+	mu.Lock()
+	defer mu.Unlock()
+	if total != 100 {
+		t.Errorf(errExpectedXGotY, 100, total)
+	}
+}
+
+func TestDestroyStopsSwapInterval(t *testing.T) {
+	buf := abBuffer.New[int](0)
+	buf.SetSwapInterval(time.Millisecond)
+	buf.Destroy()
+	if buf.SwapInterval() != 0 {
+		t.Errorf(errExpectedXGotY, 0, buf.SwapInterval())
+	}
+}
+
+func TestString(t *testing.T) {
+	ab := abBuffer.New[int](0)
+	_ = ab.Append(1)
+	ab.Swap()
+	_ = ab.Append(2)
+	if s := ab.String(); s != "[2]" {
+		t.Errorf("expected the active buffer [2], got %s", s)
+	}
+}
+
+func TestDrainInactive(t *testing.T) {
+	buf := abBuffer.New[int](0)
+	if err := buf.DrainInactive(func([]int) { t.Error("fn called on an empty buffer") }); err != abBuffer.ErrEmpty {
+		t.Errorf(errExpectedXGotY, abBuffer.ErrEmpty, err)
+	}
+
+	for i := 0; i < 8; i++ {
+		_ = buf.Append(i)
+	}
+	var storage *int
+	err := buf.SwapAndDrain(func(items []int) {
+		if len(items) != 8 || items[7] != 7 {
+			t.Errorf(errExpectedXGotY, 8, items)
+		}
+		storage = &items[0]
+	})
+	if err != nil {
+		t.Fatalf(errUnexpectedError, err)
+	}
+	if len(buf.GetInactive()) != 0 {
+		t.Errorf(errExpectedXGotY, 0, len(buf.GetInactive()))
+	}
+
+	// The drained side is refilled in the same storage
+	buf.Swap()
+	_ = buf.Append(42)
+	_ = buf.DrainInactive(func([]int) {})
+	buf.Swap()
+	if err := buf.DrainInactive(func(items []int) {
+		if &items[0] != storage || items[0] != 42 {
+			t.Errorf("expected the drained storage to be reused")
+		}
+	}); err != nil {
+		t.Errorf(errUnexpectedError, err)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := abBuffer.New[*int](0)
+	_ = c.Append(&a)
+	_ = c.Append(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestInactiveCapacity(t *testing.T) {
+	buf := abBuffer.New[int](2, abBuffer.WithInactiveCapacity(3))
+	if buf.Capacity() != 2 || buf.InactiveCapacity() != 3 {
+		t.Fatalf("expected capacities 2 and 3, got %d and %d", buf.Capacity(), buf.InactiveCapacity())
+	}
+	if abBuffer.New[int](2).InactiveCapacity() != 0 {
+		t.Error("expected the inactive side to be unlimited by default")
+	}
+
+	_ = buf.Append(1)
+	_ = buf.Append(2)
+	if err := buf.Append(3); !errors.Is(err, abBuffer.ErrOverflow) {
+		t.Errorf(errExpectedXGotY, abBuffer.ErrOverflow, err)
+	}
+	if err := buf.TrySwap(); err != nil {
+		t.Fatalf(errUnexpectedError, err)
+	}
+	if got := buf.GetInactive(); len(got) != 2 {
+		t.Errorf(errExpectedXGotY, []int{1, 2}, got)
+	}
+
+	// InsertAt ignores the capacity, so the active side can outgrow the inactive one
+	for i := 0; i < 4; i++ {
+		_ = buf.InsertAt(0, i)
+	}
+	if err := buf.TrySwap(); !errors.Is(err, abBuffer.ErrOverflow) {
+		t.Errorf(errExpectedXGotY, abBuffer.ErrOverflow, err)
+	}
+	if err := buf.Swap(); !errors.Is(err, abBuffer.ErrOverflow) {
+		t.Errorf(errExpectedXGotY, abBuffer.ErrOverflow, err)
+	}
+	if buf.Size() != 4 {
+		t.Errorf("expected the buffers not to be swapped, got active size %d", buf.Size())
+	}
+	if err := buf.SwapAndDrain(func([]int) { t.Error("fn called without swapping") }); !errors.Is(err, abBuffer.ErrOverflow) {
+		t.Errorf(errExpectedXGotY, abBuffer.ErrOverflow, err)
+	}
+}
+
+func TestAutoSwapReportsDropsAndErrors(t *testing.T) {
+	buf := abBuffer.New[int](0, abBuffer.WithInactiveCapacity(2))
+	var dropped []int
+	buf.OnDrop(func(data []int) { dropped = append(dropped, data...) })
+	buf.SetSwapThreshold(2)
+
+	// Without OnSwap nor FetchInactive the swapped elements are still pending
+	_ = buf.Append(1)
+	_ = buf.Append(2)
+	_ = buf.Append(3)
+	_ = buf.Append(4)
+	if !equal(dropped, []int{1, 2}) || buf.Dropped() != 2 {
+		t.Errorf("expected [1 2] to be dropped, got %v (%d)", dropped, buf.Dropped())
+	}
+
+	// Fetched elements are not dropped
+	if got := buf.FetchInactive(); !equal(got, []int{3, 4}) {
+		t.Errorf(errExpectedXGotY, "[3 4]", got)
+	}
+	_ = buf.Append(5)
+	_ = buf.Append(6)
+	if buf.Dropped() != 2 {
+		t.Errorf(errExpectedXGotY, 2, buf.Dropped())
+	}
+
+	// The element is appended even if the swap it triggers doesn't fit
+	buf.SetSwapThreshold(3)
+	_ = buf.InsertAt(0, 0)
+	_ = buf.InsertAt(0, 0)
+	if err := buf.Append(7); !errors.Is(err, abBuffer.ErrNotSwapped) {
+		t.Errorf(errExpectedXGotY, abBuffer.ErrNotSwapped, err)
+	}
+	if buf.Size() != 3 {
+		t.Errorf(errExpectedXGotY, 3, buf.Size())
+	}
+
+	errs := make(chan error, 1)
+	buf.OnSwapError(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	})
+	buf.SetSwapInterval(time.Millisecond)
+	defer buf.SetSwapInterval(0)
+	select {
+	case err := <-errs:
+		if !errors.Is(err, abBuffer.ErrOverflow) {
+			t.Errorf(errExpectedXGotY, abBuffer.ErrOverflow, err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the ticker to report its swap error")
+	}
+}
+
+func TestSwapIntervalWithOtherMethods(t *testing.T) {
+	// Run with -race: every method is synchronized with the ticker
+	buf := abBuffer.New[int](0)
+	buf.SetSwapInterval(time.Microsecond)
+	defer buf.SetSwapInterval(0)
+	for i := 0; i < 1000; i++ {
+		_ = buf.Append(i)
+		_ = buf.Contains(i)
+		_ = buf.ToSlice()
+		if i%100 == 0 {
+			buf.Clear()
+			buf.SetActiveB()
+			buf.Filter(func(v int) bool { return v%2 == 0 })
+			_ = buf.Copy()
+		}
+	}
+}
+
+func TestHighWaterMark(t *testing.T) {
+	buf := abBuffer.New[int](0)
+	for i := 0; i < 5; i++ {
+		_ = buf.Append(i)
+	}
+	buf.Swap()
+	_ = buf.Append(5)
+	buf.Clear()
+	if buf.HighWaterMark() != 5 {
+		t.Errorf(errExpectedXGotY, 5, buf.HighWaterMark())
+	}
+	if buf.Copy().HighWaterMark() != 5 {
+		t.Errorf("expected Copy to keep the high-water mark, got %d", buf.Copy().HighWaterMark())
+	}
+
+	buf.ResetHighWaterMark()
+	if buf.HighWaterMark() != 0 {
+		t.Errorf(errExpectedXGotY, 0, buf.HighWaterMark())
+	}
+	_ = buf.InsertAt(0, 1)
+	if buf.HighWaterMark() != 1 {
+		t.Errorf(errExpectedXGotY, 1, buf.HighWaterMark())
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	buf := abBuffer.New[int](0)
+	_ = buf.Append(1)
+	_ = buf.Append(2)
+	buf.Swap()
+	_ = buf.Append(3)
+	data, err := buf.MarshalBinary()
+	if err != nil {
+		t.Fatalf(errUnexpectedError, err)
+	}
+
+	restored := abBuffer.New[int](0)
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf(errUnexpectedError, err)
+	}
+	if !reflect.DeepEqual(restored.GetActive(), []int{3}) || !reflect.DeepEqual(restored.GetInactive(), []int{1, 2}) {
+		t.Errorf("expected active [3] and inactive [1 2], got %v and %v", restored.GetActive(), restored.GetInactive())
+	}
+
+	// The inactive side doesn't fit: nothing changes
+	small := abBuffer.New[int](0, abBuffer.WithInactiveCapacity(1))
+	if err := small.UnmarshalBinary(data); !errors.Is(err, abBuffer.ErrOverflow) {
+		t.Errorf(errExpectedXGotY, abBuffer.ErrOverflow, err)
+	}
+	if small.Size() != 0 {
+		t.Errorf("expected an empty buffer, got size %d", small.Size())
+	}
+
+	data[4]++ // version
+	if err := restored.UnmarshalBinary(data); !errors.Is(err, common.ErrUnsupportedVersion) {
+		t.Errorf(errExpectedXGotY, common.ErrUnsupportedVersion, err)
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	buf := abBuffer.NewWithComparator(0, slices.Equal[[]int])
+	_ = buf.Append([]int{1, 2})
+	buf.Swap()
+	_ = buf.Append([]int{3})
+
+	if !buf.Contains([]int{3}) || buf.Contains([]int{1, 2}) {
+		t.Error("expected the active bank to contain only [3]")
+	}
+
+	// Copies keep the comparator
+	c := buf.CopyActive()
+	if index, err := c.Find([]int{3}); err != nil || index != 0 {
+		t.Errorf("expected index 0, got %d (%v)", index, err)
+	}
+}
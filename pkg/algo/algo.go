@@ -0,0 +1,143 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package algo provides algorithms that work directly on the gods containers
+// (top-k selection, k-way merge, binary search), so they don't have to be
+// exported to slices to use the standard library.
+package algo
+
+import (
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	dlinkList "github.com/pzaino/gods/pkg/dlinkList"
+	heap "github.com/pzaino/gods/pkg/heap"
+)
+
+// Container is implemented by the containers whose ForEach can be stopped
+// by returning an error (Buffer, Stack, Queue, PriorityQueue, ABBuffer and
+// their concurrent versions)
+type Container[T any] interface {
+	ForEach(fn func(*T) error) error
+}
+
+// List is implemented by the linked lists, whose ForEach visits every element
+type List[T any] interface {
+	ForEach(f func(*T))
+}
+
+// listContainer adapts a List to the Container interface
+type listContainer[T any] struct {
+	l List[T]
+}
+
+// ForEach calls fn for every element until it returns an error (the list is
+// still traversed to the end, but fn is not called anymore)
+func (lc listContainer[T]) ForEach(fn func(*T) error) error {
+	var err error
+	lc.l.ForEach(func(v *T) {
+		if err == nil {
+			err = fn(v)
+		}
+	})
+	return err
+}
+
+// FromList adapts a linked list (linkList, dlinkList, circularLinkList and
+// their concurrent versions) to a Container
+func FromList[T any](l List[T]) Container[T] {
+	return listContainer[T]{l: l}
+}
+
+// TopK returns the k largest elements of the container according to less,
+// from the largest to the smallest. It keeps a heap of k elements, so it
+// runs in O(n log k) without copying the container
+func TopK[T any](c Container[T], k uint64, less func(a, b T) bool) []T {
+	if k == 0 {
+		return nil
+	}
+
+	// The root of the min-heap is the smallest of the k largest seen so far
+	h := heap.NewWithLess(less)
+	_ = c.ForEach(func(v *T) error {
+		if h.Size() < k {
+			h.Push(*v)
+		} else {
+			h.PushPop(*v)
+		}
+		return nil
+	})
+
+	items := make([]T, h.Size())
+	for i := len(items) - 1; i >= 0; i-- {
+		items[i], _ = h.Pop()
+	}
+	return items
+}
+
+// cursor is the position of KWayMerge in one of the lists
+type cursor[T any] struct {
+	node *dlinkList.Node[T]
+	list int
+}
+
+// KWayMerge merges lists sorted according to less into a new sorted list,
+// elements that compare equal keep the order of the lists they come from.
+// The result compares its elements as equal when neither is less than the
+// other. It runs in O(n log k) for n elements in k lists
+func KWayMerge[T any](lists []*dlinkList.DLinkList[T], less func(a, b T) bool) *dlinkList.DLinkList[T] {
+	result := dlinkList.NewWithComparator(func(a, b T) bool {
+		return !less(a, b) && !less(b, a)
+	})
+
+	h := heap.NewWithLess(func(a, b cursor[T]) bool {
+		if less(a.node.Value(), b.node.Value()) {
+			return true
+		}
+		return !less(b.node.Value(), a.node.Value()) && a.list < b.list
+	})
+	for i, l := range lists {
+		if l != nil && !l.IsEmpty() {
+			h.Push(cursor[T]{node: l.GetFirst(), list: i})
+		}
+	}
+
+	for !h.IsEmpty() {
+		c, _ := h.Peek()
+		result.Append(c.node.Value())
+		if c.node.Next() == nil {
+			_, _ = h.Pop()
+			continue
+		}
+		c.node = c.node.Next()
+		_ = h.Set(0, c)
+	}
+	return result
+}
+
+// BinarySearch searches target in a buffer sorted according to less, it
+// returns the index of the first element equal to target (neither is less
+// than the other) and true, or the index where target would be inserted to
+// keep the buffer sorted and false
+func BinarySearch[T any](b *buffer.Buffer[T], target T, less func(a, b T) bool) (uint64, bool) {
+	values := b.UnsafeSlice()
+	lo, hi := uint64(0), uint64(len(values))
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if less(values[mid], target) {
+			lo = mid + 1
+		} else {
+			hi = mid
+		}
+	}
+	return lo, lo < uint64(len(values)) && !less(target, values[lo])
+}
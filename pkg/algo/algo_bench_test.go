@@ -0,0 +1,69 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package algo_test
+
+import (
+	"cmp"
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	algo "github.com/pzaino/gods/pkg/algo"
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	dlinkList "github.com/pzaino/gods/pkg/dlinkList"
+)
+
+// BenchmarkTopK finds the 10 largest of n elements
+func BenchmarkTopK(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		buf := buffer.Adopt(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = algo.TopK(buf, 10, cmp.Less[int])
+		}
+	})
+}
+
+// BenchmarkKWayMerge merges 8 sorted lists of n elements in total
+func BenchmarkKWayMerge(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		lists := make([]*dlinkList.DLinkList[int], 8)
+		for i := range lists {
+			lists[i] = dlinkList.New[int]()
+		}
+		for v := 0; v < n; v++ {
+			lists[v%len(lists)].Append(v)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = algo.KWayMerge(lists, cmp.Less[int])
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkBinarySearch looks for a value in n sorted elements
+func BenchmarkBinarySearch(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		buf := buffer.New[int]()
+		for v := 0; v < n; v++ {
+			_ = buf.Append(v)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = algo.BinarySearch(buf, values[i%n], cmp.Less[int])
+		}
+	})
+}
@@ -0,0 +1,136 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package algo_test
+
+import (
+	"cmp"
+	"math/rand"
+	"slices"
+	"testing"
+
+	algo "github.com/pzaino/gods/pkg/algo"
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	dlinkList "github.com/pzaino/gods/pkg/dlinkList"
+	linkList "github.com/pzaino/gods/pkg/linkList"
+	stack "github.com/pzaino/gods/pkg/stack"
+)
+
+func newList(items ...int) *dlinkList.DLinkList[int] {
+	l := dlinkList.New[int]()
+	for _, v := range items {
+		l.Append(v)
+	}
+	return l
+}
+
+func TestTopK(t *testing.T) {
+	b := buffer.New[int]()
+	for _, v := range rand.Perm(100) {
+		_ = b.Append(v)
+	}
+	if got := algo.TopK(b, 3, cmp.Less[int]); !slices.Equal(got, []int{99, 98, 97}) {
+		t.Errorf("expected [99 98 97], got %v", got)
+	}
+	if got := algo.TopK(b, 0, cmp.Less[int]); got != nil {
+		t.Errorf("expected nil for k = 0, got %v", got)
+	}
+
+	// Fewer elements than k, with a reversed order
+	s := stack.New[int]()
+	for _, v := range []int{5, 1, 3} {
+		_ = s.Push(v)
+	}
+	greater := func(a, b int) bool { return a > b }
+	if got := algo.TopK(s, 10, greater); !slices.Equal(got, []int{1, 3, 5}) {
+		t.Errorf("expected [1 3 5], got %v", got)
+	}
+
+	l := linkList.NewFromSlice([]int{4, 8, 8, 2, 6})
+	if got := algo.TopK(algo.FromList(l), 2, cmp.Less[int]); !slices.Equal(got, []int{8, 8}) {
+		t.Errorf("expected [8 8], got %v", got)
+	}
+}
+
+func TestKWayMerge(t *testing.T) {
+	lists := []*dlinkList.DLinkList[int]{
+		newList(1, 4, 7),
+		newList(),
+		nil,
+		newList(2, 5, 8, 9),
+		newList(0, 3, 6),
+	}
+	merged := algo.KWayMerge(lists, cmp.Less[int])
+	if got := merged.ToSlice(); !slices.Equal(got, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Errorf("expected 0..9, got %v", got)
+	}
+	if lists[0].Size() != 3 {
+		t.Error("expected the input lists not to be modified")
+	}
+	if !merged.Contains(5) {
+		t.Error("expected the merged list to compare its elements")
+	}
+
+	if algo.KWayMerge[int](nil, cmp.Less[int]).Size() != 0 {
+		t.Error("expected an empty list when there is nothing to merge")
+	}
+}
+
+type record struct {
+	key  int
+	from string
+}
+
+func TestKWayMergeStable(t *testing.T) {
+	a, b := dlinkList.NewWithComparator(func(x, y record) bool { return x == y }), dlinkList.NewWithComparator(func(x, y record) bool { return x == y })
+	a.Append(record{1, "a"})
+	a.Append(record{2, "a"})
+	b.Append(record{1, "b"})
+	b.Append(record{2, "b"})
+
+	merged := algo.KWayMerge([]*dlinkList.DLinkList[record]{b, a}, func(x, y record) bool { return x.key < y.key })
+	expected := []record{{1, "b"}, {1, "a"}, {2, "b"}, {2, "a"}}
+	if got := merged.ToSlice(); !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestBinarySearch(t *testing.T) {
+	b := buffer.New[int]()
+	for _, v := range []int{1, 3, 3, 5, 7} {
+		_ = b.Append(v)
+	}
+
+	tests := []struct {
+		target int
+		index  uint64
+		found  bool
+	}{
+		{0, 0, false},
+		{1, 0, true},
+		{3, 1, true},
+		{4, 3, false},
+		{7, 4, true},
+		{8, 5, false},
+	}
+	for _, tt := range tests {
+		if index, found := algo.BinarySearch(b, tt.target, cmp.Less[int]); index != tt.index || found != tt.found {
+			t.Errorf("BinarySearch(%d): expected %d %v, got %d %v", tt.target, tt.index, tt.found, index, found)
+		}
+	}
+
+	if index, found := algo.BinarySearch(buffer.New[int](), 1, cmp.Less[int]); index != 0 || found {
+		t.Errorf("expected 0 false on an empty buffer, got %d %v", index, found)
+	}
+}
@@ -0,0 +1,629 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package btree provides a non-concurrent-safe B-tree, an ordered map that
+// keeps many keys in every node so it stays shallow and cache friendly even
+// with tens of millions of keys.
+package btree
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"sort"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the BTree functions (use errors.Is to check for them)
+var (
+	ErrInvalidDegree = errors.New("the degree must be at least 2")
+	ErrNotEmpty      = errors.New("tree is not empty")
+	ErrNotSorted     = errors.New("keys are not sorted or not unique")
+	ErrSizeMismatch  = errors.New("keys and values have different lengths")
+	ErrCorrupted     = errors.New("tree is corrupted")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureInvariants)
+}
+
+// node is a node of the tree, keys[i] is associated to values[i] and the
+// keys of children[i] are between keys[i-1] and keys[i] (leaves have no children)
+type node[K, V any] struct {
+	keys     []K
+	values   []V
+	children []*node[K, V]
+}
+
+// leaf returns true if the node has no children
+func (n *node[K, V]) leaf() bool {
+	return len(n.children) == 0
+}
+
+// BTree is a B-tree of minimum degree t: every node but the root holds
+// between t-1 and 2t-1 keys and all the leaves are at the same depth
+type BTree[K, V any] struct {
+	root   *node[K, V]
+	degree int
+	size   uint64
+	less   func(a, b K) bool
+}
+
+// New creates a new empty BTree of the given minimum degree (at least 2)
+// ordering its keys with the < operator
+func New[K cmp.Ordered, V any](degree int) (*BTree[K, V], error) {
+	return NewWithLess[K, V](degree, cmp.Less[K])
+}
+
+// NewWithLess creates a new empty BTree of the given minimum degree (at least
+// 2) ordering its keys with less, two keys are equal if neither is less than
+// the other
+func NewWithLess[K, V any](degree int, less func(a, b K) bool) (*BTree[K, V], error) {
+	if degree < 2 {
+		return nil, ErrInvalidDegree
+	}
+	return &BTree[K, V]{degree: degree, less: less}, nil
+}
+
+// maxKeys returns the maximum number of keys of a node
+func (t *BTree[K, V]) maxKeys() int {
+	return 2*t.degree - 1
+}
+
+// search returns the index of the first key of n that is not less than key
+// and whether that key is equal to key
+func (t *BTree[K, V]) search(n *node[K, V], key K) (int, bool) {
+	i := sort.Search(len(n.keys), func(i int) bool { return !t.less(n.keys[i], key) })
+	return i, i < len(n.keys) && !t.less(key, n.keys[i])
+}
+
+// Degree returns the minimum degree of the tree
+func (t *BTree[K, V]) Degree() int {
+	return t.degree
+}
+
+// Size returns the number of keys in the tree
+func (t *BTree[K, V]) Size() uint64 {
+	return t.size
+}
+
+// IsEmpty returns true if the tree has no keys
+func (t *BTree[K, V]) IsEmpty() bool {
+	return t.size == 0
+}
+
+// Clear removes all the keys from the tree
+func (t *BTree[K, V]) Clear() {
+	t.root = nil
+	t.size = 0
+}
+
+// Height returns the number of levels of the tree (0 if it's empty)
+func (t *BTree[K, V]) Height() int {
+	height := 0
+	for n := t.root; n != nil; height++ {
+		if n.leaf() {
+			return height + 1
+		}
+		n = n.children[0]
+	}
+	return height
+}
+
+// Get returns the value associated to key and true, or the zero value and
+// false if the key is not in the tree
+func (t *BTree[K, V]) Get(key K) (V, bool) {
+	for n := t.root; n != nil; {
+		i, found := t.search(n, key)
+		if found {
+			return n.values[i], true
+		}
+		if n.leaf() {
+			break
+		}
+		n = n.children[i]
+	}
+	var rVal V
+	return rVal, false
+}
+
+// Contains returns true if key is in the tree
+func (t *BTree[K, V]) Contains(key K) bool {
+	_, found := t.Get(key)
+	return found
+}
+
+// Min returns the smallest key and its value, the boolean is false if the tree is empty
+func (t *BTree[K, V]) Min() (K, V, bool) {
+	if t.root == nil {
+		var k K
+		var v V
+		return k, v, false
+	}
+	n := t.root
+	for !n.leaf() {
+		n = n.children[0]
+	}
+	return n.keys[0], n.values[0], true
+}
+
+// Max returns the largest key and its value, the boolean is false if the tree is empty
+func (t *BTree[K, V]) Max() (K, V, bool) {
+	if t.root == nil {
+		var k K
+		var v V
+		return k, v, false
+	}
+	n := t.root
+	for !n.leaf() {
+		n = n.children[len(n.children)-1]
+	}
+	last := len(n.keys) - 1
+	return n.keys[last], n.values[last], true
+}
+
+// Insert associates value to key, it returns true if the key was added and
+// false if it was already in the tree (its value is replaced)
+func (t *BTree[K, V]) Insert(key K, value V) bool {
+	if t.root == nil {
+		t.root = &node[K, V]{keys: []K{key}, values: []V{value}}
+		t.size++
+		return true
+	}
+
+	// Full nodes are split on the way down, so there is always room for the
+	// key that a split moves up to the parent
+	if len(t.root.keys) == t.maxKeys() {
+		t.root = &node[K, V]{children: []*node[K, V]{t.root}}
+		t.splitChild(t.root, 0)
+	}
+
+	n := t.root
+	for {
+		i, found := t.search(n, key)
+		if found {
+			n.values[i] = value
+			return false
+		}
+		if n.leaf() {
+			n.keys = insertAt(n.keys, i, key)
+			n.values = insertAt(n.values, i, value)
+			t.size++
+			return true
+		}
+		if len(n.children[i].keys) == t.maxKeys() {
+			t.splitChild(n, i)
+			continue // the key moved up to n may be the one we are looking for
+		}
+		n = n.children[i]
+	}
+}
+
+// splitChild splits the full child i of n in two nodes of t-1 keys, moving
+// its median key up to n
+func (t *BTree[K, V]) splitChild(n *node[K, V], i int) {
+	child := n.children[i]
+	mid := t.degree - 1
+
+	right := &node[K, V]{
+		keys:   append([]K(nil), child.keys[mid+1:]...),
+		values: append([]V(nil), child.values[mid+1:]...),
+	}
+	if !child.leaf() {
+		right.children = append([]*node[K, V](nil), child.children[mid+1:]...)
+		clear(child.children[mid+1:])
+		child.children = child.children[:mid+1]
+	}
+
+	n.keys = insertAt(n.keys, i, child.keys[mid])
+	n.values = insertAt(n.values, i, child.values[mid])
+	n.children = insertAt(n.children, i+1, right)
+
+	clear(child.keys[mid:]) // let the GC collect the moved keys and values
+	clear(child.values[mid:])
+	child.keys = child.keys[:mid]
+	child.values = child.values[:mid]
+}
+
+// Delete removes key from the tree and returns its value and true, or the zero
+// value and false if the key is not in the tree
+func (t *BTree[K, V]) Delete(key K) (V, bool) {
+	if t.root == nil {
+		var rVal V
+		return rVal, false
+	}
+
+	value, found := t.delete(t.root, key)
+	if len(t.root.keys) == 0 {
+		// The root lost its last key, the tree shrinks by one level
+		if t.root.leaf() {
+			t.root = nil
+		} else {
+			t.root = t.root.children[0]
+		}
+	}
+	if found {
+		t.size--
+	}
+	return value, found
+}
+
+// delete removes key from the subtree of n, every node it descends to has at
+// least t keys so removing a key from it never leaves it under-full
+func (t *BTree[K, V]) delete(n *node[K, V], key K) (V, bool) {
+	for {
+		i, found := t.search(n, key)
+		if n.leaf() {
+			if !found {
+				var rVal V
+				return rVal, false
+			}
+			value := n.values[i]
+			n.keys = removeAt(n.keys, i)
+			n.values = removeAt(n.values, i)
+			return value, true
+		}
+
+		if found {
+			value := n.values[i]
+			switch {
+			case len(n.children[i].keys) >= t.degree:
+				// Replace the key with its predecessor and delete that one
+				pred := n.children[i]
+				for !pred.leaf() {
+					pred = pred.children[len(pred.children)-1]
+				}
+				last := len(pred.keys) - 1
+				n.keys[i], n.values[i] = pred.keys[last], pred.values[last]
+				t.delete(n.children[i], pred.keys[last])
+			case len(n.children[i+1].keys) >= t.degree:
+				// Replace the key with its successor and delete that one
+				succ := n.children[i+1]
+				for !succ.leaf() {
+					succ = succ.children[0]
+				}
+				n.keys[i], n.values[i] = succ.keys[0], succ.values[0]
+				t.delete(n.children[i+1], succ.keys[0])
+			default:
+				// Both children have t-1 keys, merge them around the key
+				t.merge(n, i)
+				t.delete(n.children[i], key)
+			}
+			return value, true
+		}
+
+		if len(n.children[i].keys) < t.degree {
+			i = t.fill(n, i)
+		}
+		n = n.children[i]
+	}
+}
+
+// fill makes sure the child i of n has at least t keys, borrowing a key from a
+// sibling or merging it with one, and returns the index of the child that now
+// holds its keys
+func (t *BTree[K, V]) fill(n *node[K, V], i int) int {
+	child := n.children[i]
+	switch {
+	case i > 0 && len(n.children[i-1].keys) >= t.degree:
+		// Rotate the last key of the left sibling through n
+		left := n.children[i-1]
+		last := len(left.keys) - 1
+		child.keys = insertAt(child.keys, 0, n.keys[i-1])
+		child.values = insertAt(child.values, 0, n.values[i-1])
+		n.keys[i-1], n.values[i-1] = left.keys[last], left.values[last]
+		left.keys = removeAt(left.keys, last)
+		left.values = removeAt(left.values, last)
+		if !left.leaf() {
+			child.children = insertAt(child.children, 0, left.children[last+1])
+			left.children = removeAt(left.children, last+1)
+		}
+		return i
+	case i < len(n.keys) && len(n.children[i+1].keys) >= t.degree:
+		// Rotate the first key of the right sibling through n
+		right := n.children[i+1]
+		child.keys = append(child.keys, n.keys[i])
+		child.values = append(child.values, n.values[i])
+		n.keys[i], n.values[i] = right.keys[0], right.values[0]
+		right.keys = removeAt(right.keys, 0)
+		right.values = removeAt(right.values, 0)
+		if !right.leaf() {
+			child.children = append(child.children, right.children[0])
+			right.children = removeAt(right.children, 0)
+		}
+		return i
+	case i < len(n.keys):
+		t.merge(n, i)
+		return i
+	default:
+		t.merge(n, i-1)
+		return i - 1
+	}
+}
+
+// merge moves the key i of n and all the keys of the child i+1 to the child i
+func (t *BTree[K, V]) merge(n *node[K, V], i int) {
+	left, right := n.children[i], n.children[i+1]
+	left.keys = append(append(left.keys, n.keys[i]), right.keys...)
+	left.values = append(append(left.values, n.values[i]), right.values...)
+	left.children = append(left.children, right.children...)
+
+	n.keys = removeAt(n.keys, i)
+	n.values = removeAt(n.values, i)
+	n.children = removeAt(n.children, i+1)
+}
+
+// insertAt inserts v at index i of s
+func insertAt[E any](s []E, i int, v E) []E {
+	var zero E
+	s = append(s, zero)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+// removeAt removes the element at index i of s, clearing the freed slot
+func removeAt[E any](s []E, i int) []E {
+	copy(s[i:], s[i+1:])
+	var zero E
+	s[len(s)-1] = zero
+	return s[:len(s)-1]
+}
+
+// Ascend calls fn for every key (and its value) in ascending order, until fn returns false
+func (t *BTree[K, V]) Ascend(fn func(key K, value V) bool) {
+	if t.root != nil {
+		t.ascend(t.root, nil, nil, fn)
+	}
+}
+
+// AscendRange calls fn in ascending order for every key in [from, to), until
+// fn returns false
+func (t *BTree[K, V]) AscendRange(from, to K, fn func(key K, value V) bool) {
+	if t.root != nil {
+		t.ascend(t.root, &from, &to, fn)
+	}
+}
+
+// Descend calls fn for every key (and its value) in descending order, until fn returns false
+func (t *BTree[K, V]) Descend(fn func(key K, value V) bool) {
+	if t.root != nil {
+		t.descend(t.root, nil, nil, fn)
+	}
+}
+
+// DescendRange calls fn in descending order for every key in [from, to),
+// until fn returns false
+func (t *BTree[K, V]) DescendRange(from, to K, fn func(key K, value V) bool) {
+	if t.root != nil {
+		t.descend(t.root, &from, &to, fn)
+	}
+}
+
+// ascend visits in ascending order the keys of the subtree of n in [from, to)
+// (a nil bound is unbounded), it returns false once the visit must stop
+func (t *BTree[K, V]) ascend(n *node[K, V], from, to *K, fn func(K, V) bool) bool {
+	i := 0
+	if from != nil {
+		i, _ = t.search(n, *from)
+	}
+	for ; i < len(n.keys); i++ {
+		if !n.leaf() && !t.ascend(n.children[i], from, to, fn) {
+			return false
+		}
+		if to != nil && !t.less(n.keys[i], *to) {
+			return false
+		}
+		if !fn(n.keys[i], n.values[i]) {
+			return false
+		}
+	}
+	if !n.leaf() {
+		return t.ascend(n.children[len(n.keys)], from, to, fn)
+	}
+	return true
+}
+
+// descend visits in descending order the keys of the subtree of n in [from,
+// to) (a nil bound is unbounded), it returns false once the visit must stop
+func (t *BTree[K, V]) descend(n *node[K, V], from, to *K, fn func(K, V) bool) bool {
+	i := len(n.keys)
+	if to != nil {
+		i, _ = t.search(n, *to)
+	}
+	if !n.leaf() && !t.descend(n.children[i], from, to, fn) {
+		return false
+	}
+	for i--; i >= 0; i-- {
+		if from != nil && t.less(n.keys[i], *from) {
+			return false
+		}
+		if !fn(n.keys[i], n.values[i]) {
+			return false
+		}
+		if !n.leaf() && !t.descend(n.children[i], from, to, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Keys returns all the keys of the tree in ascending order
+func (t *BTree[K, V]) Keys() []K {
+	keys := make([]K, 0, t.size)
+	t.Ascend(func(key K, _ V) bool {
+		keys = append(keys, key)
+		return true
+	})
+	return keys
+}
+
+// BulkLoad fills an empty tree with keys (sorted in ascending order without
+// duplicates) and their values, building it bottom up with nodes as full as
+// possible. It's much faster than inserting the keys one by one
+func (t *BTree[K, V]) BulkLoad(keys []K, values []V) error {
+	if t.size != 0 {
+		return ErrNotEmpty
+	}
+	if len(keys) != len(values) {
+		return ErrSizeMismatch
+	}
+	for i := 1; i < len(keys); i++ {
+		if !t.less(keys[i-1], keys[i]) {
+			return ErrNotSorted
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// The smallest height that can hold all the keys
+	n := uint64(len(keys))
+	height := 1
+	for t.maxSubtree(height) < n {
+		height++
+	}
+	t.root = t.build(keys, values, height, 2)
+	t.size = n
+	return nil
+}
+
+// minSubtree returns the minimum number of keys of a subtree of the given
+// height whose root is not the root of the tree (t^height - 1)
+func (t *BTree[K, V]) minSubtree(height int) uint64 {
+	n := uint64(1)
+	for i := 0; i < height; i++ {
+		n *= uint64(t.degree)
+	}
+	return n - 1
+}
+
+// maxSubtree returns the maximum number of keys of a subtree of the given
+// height ((2t)^height - 1)
+func (t *BTree[K, V]) maxSubtree(height int) uint64 {
+	n := uint64(1)
+	for i := 0; i < height; i++ {
+		n *= uint64(2 * t.degree)
+	}
+	return n - 1
+}
+
+// build creates a subtree of the given height with the given keys, its root
+// has at least minChildren children (2 for the root of the tree, t otherwise)
+func (t *BTree[K, V]) build(keys []K, values []V, height, minChildren int) *node[K, V] {
+	if height == 1 {
+		return &node[K, V]{
+			keys:   append(make([]K, 0, t.maxKeys()), keys...),
+			values: append(make([]V, 0, t.maxKeys()), values...),
+		}
+	}
+
+	// Use as few children as possible so the nodes are as full as possible
+	n := uint64(len(keys))
+	maxChild := t.maxSubtree(height - 1)
+	children := int((n + 1 + maxChild) / (maxChild + 1))
+	if children < minChildren {
+		children = minChildren
+	}
+
+	// Spread the keys that are not separators evenly among the children
+	perChild := (n - uint64(children-1)) / uint64(children)
+	extra := int((n - uint64(children-1)) % uint64(children))
+
+	nd := &node[K, V]{
+		keys:     make([]K, 0, t.maxKeys()),
+		values:   make([]V, 0, t.maxKeys()),
+		children: make([]*node[K, V], 0, 2*t.degree),
+	}
+	start := 0
+	for c := 0; c < children; c++ {
+		end := start + int(perChild)
+		if c < extra {
+			end++
+		}
+		nd.children = append(nd.children, t.build(keys[start:end], values[start:end], height-1, t.degree))
+		if c < children-1 {
+			nd.keys = append(nd.keys, keys[end])
+			nd.values = append(nd.values, values[end])
+		}
+		start = end + 1
+	}
+	return nd
+}
+
+// CheckInvariants verifies the internal consistency of the tree (the number
+// of keys of every node, the order of the keys, all the leaves at the same
+// depth and the size), it returns an error wrapping ErrCorrupted describing
+// the first violation found
+func (t *BTree[K, V]) CheckInvariants() error {
+	if t.root == nil {
+		if t.size != 0 {
+			return fmt.Errorf("%w: empty tree with size %d", ErrCorrupted, t.size)
+		}
+		return nil
+	}
+
+	count, err := t.check(t.root, nil, nil, 1, t.Height())
+	if err != nil {
+		return err
+	}
+	if count != t.size {
+		return fmt.Errorf("%w: %d keys but its size is %d", ErrCorrupted, count, t.size)
+	}
+	return nil
+}
+
+// check verifies the subtree of n, whose keys must be in (lo, hi), and returns its number of keys
+func (t *BTree[K, V]) check(n *node[K, V], lo, hi *K, depth, height int) (uint64, error) {
+	if len(n.keys) > t.maxKeys() || (n != t.root && len(n.keys) < t.degree-1) || len(n.keys) == 0 {
+		return 0, fmt.Errorf("%w: a node at depth %d has %d keys", ErrCorrupted, depth, len(n.keys))
+	}
+	if len(n.values) != len(n.keys) {
+		return 0, fmt.Errorf("%w: a node at depth %d has %d keys and %d values", ErrCorrupted, depth, len(n.keys), len(n.values))
+	}
+	for i, key := range n.keys {
+		if (i > 0 && !t.less(n.keys[i-1], key)) || (lo != nil && !t.less(*lo, key)) || (hi != nil && !t.less(key, *hi)) {
+			return 0, fmt.Errorf("%w: the keys of a node at depth %d are out of order", ErrCorrupted, depth)
+		}
+	}
+
+	count := uint64(len(n.keys))
+	if n.leaf() {
+		if depth != height {
+			return 0, fmt.Errorf("%w: a leaf is at depth %d instead of %d", ErrCorrupted, depth, height)
+		}
+		return count, nil
+	}
+	if len(n.children) != len(n.keys)+1 {
+		return 0, fmt.Errorf("%w: a node at depth %d has %d keys and %d children", ErrCorrupted, depth, len(n.keys), len(n.children))
+	}
+	for i, child := range n.children {
+		childLo, childHi := lo, hi
+		if i > 0 {
+			childLo = &n.keys[i-1]
+		}
+		if i < len(n.keys) {
+			childHi = &n.keys[i]
+		}
+		c, err := t.check(child, childLo, childHi, depth+1, height)
+		if err != nil {
+			return 0, err
+		}
+		count += c
+	}
+	return count, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btree_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	btree "github.com/pzaino/gods/pkg/btree"
+)
+
+// newBenchTree returns a tree holding keys
+func newBenchTree(keys []int) *btree.BTree[int, int] {
+	tree, _ := btree.New[int, int](32)
+	for _, k := range keys {
+		tree.Insert(k, k)
+	}
+	return tree
+}
+
+// BenchmarkBuild inserts n shuffled keys
+func BenchmarkBuild(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		keys := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = newBenchTree(keys)
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkDelete deletes a key from a tree of n keys (and inserts it back,
+// so the size stays n)
+func BenchmarkDelete(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		keys := bench.Ints(n)
+		tree := newBenchTree(keys)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			k := keys[i%n]
+			tree.Delete(k)
+			tree.Insert(k, k)
+		}
+	})
+}
+
+// BenchmarkFind looks for a key in a tree of n keys
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		keys := bench.Ints(n)
+		tree := newBenchTree(keys)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = tree.Get(keys[i%n])
+		}
+	})
+}
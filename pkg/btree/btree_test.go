@@ -0,0 +1,284 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package btree_test
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+
+	btree "github.com/pzaino/gods/pkg/btree"
+)
+
+const errUnexpectedErr = "unexpected error: %v"
+
+// newTree creates a tree of the given degree with the keys 0, 2, 4, ... 2*(n-1)
+func newTree(t *testing.T, degree, n int) *btree.BTree[int, string] {
+	tree, err := btree.New[int, string](degree)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	for i := 0; i < n; i++ {
+		tree.Insert(2*i, string(rune('a'+i%26)))
+	}
+	return tree
+}
+
+// collect returns an iterator callback appending the keys to keys
+func collect(keys *[]int) func(int, string) bool {
+	return func(key int, _ string) bool {
+		*keys = append(*keys, key)
+		return true
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := btree.New[int, int](1); !errors.Is(err, btree.ErrInvalidDegree) {
+		t.Errorf("expected %v, got %v", btree.ErrInvalidDegree, err)
+	}
+	tree, err := btree.New[int, int](3)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !tree.IsEmpty() || tree.Height() != 0 || tree.Degree() != 3 {
+		t.Errorf("expected an empty tree of degree 3, got size %d height %d", tree.Size(), tree.Height())
+	}
+	if _, _, ok := tree.Min(); ok {
+		t.Error("expected no minimum in an empty tree")
+	}
+	if _, ok := tree.Delete(1); ok {
+		t.Error("expected no key to delete in an empty tree")
+	}
+}
+
+func TestInsertGet(t *testing.T) {
+	tree := newTree(t, 2, 100)
+	if tree.Size() != 100 {
+		t.Errorf("expected size 100, got %d", tree.Size())
+	}
+	if v, ok := tree.Get(20); !ok || v != "k" {
+		t.Errorf("expected k, got %q (%v)", v, ok)
+	}
+	if tree.Contains(21) {
+		t.Error("expected 21 not to be in the tree")
+	}
+	if tree.Insert(20, "z") {
+		t.Error("expected Insert to return false for an existing key")
+	}
+	if v, _ := tree.Get(20); v != "z" || tree.Size() != 100 {
+		t.Errorf("expected the value to be replaced, got %q (size %d)", v, tree.Size())
+	}
+	if k, _, _ := tree.Min(); k != 0 {
+		t.Errorf("expected min 0, got %d", k)
+	}
+	if k, _, _ := tree.Max(); k != 198 {
+		t.Errorf("expected max 198, got %d", k)
+	}
+	if err := tree.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tree := newTree(t, 2, 50)
+	for i := 0; i < 50; i += 2 {
+		if _, ok := tree.Delete(2 * i); !ok {
+			t.Fatalf("expected %d to be deleted", 2*i)
+		}
+		if err := tree.CheckInvariants(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := tree.Delete(0); ok {
+		t.Error("expected 0 to be already deleted")
+	}
+	if tree.Size() != 25 {
+		t.Errorf("expected size 25, got %d", tree.Size())
+	}
+	for _, k := range tree.Keys() {
+		if k%4 == 0 {
+			t.Errorf("expected %d to be deleted", k)
+		}
+	}
+}
+
+func TestAscendDescendRange(t *testing.T) {
+	tree := newTree(t, 3, 20) // 0, 2, ..., 38
+
+	var keys []int
+	tree.AscendRange(5, 15, collect(&keys))
+	if !reflect.DeepEqual(keys, []int{6, 8, 10, 12, 14}) {
+		t.Errorf("expected [6 8 10 12 14], got %v", keys)
+	}
+
+	keys = nil
+	tree.DescendRange(6, 14, collect(&keys))
+	if !reflect.DeepEqual(keys, []int{12, 10, 8, 6}) {
+		t.Errorf("expected [12 10 8 6], got %v", keys)
+	}
+
+	keys = nil
+	tree.Descend(func(key int, _ string) bool {
+		keys = append(keys, key)
+		return len(keys) < 3
+	})
+	if !reflect.DeepEqual(keys, []int{38, 36, 34}) {
+		t.Errorf("expected [38 36 34], got %v", keys)
+	}
+
+	keys = nil
+	tree.AscendRange(100, 200, collect(&keys))
+	tree.AscendRange(10, 10, collect(&keys))
+	if len(keys) != 0 {
+		t.Errorf("expected empty ranges, got %v", keys)
+	}
+}
+
+func TestNewWithLess(t *testing.T) {
+	tree, err := btree.NewWithLess[string, int](2, func(a, b string) bool { return len(a) < len(b) })
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	tree.Insert("ccc", 3)
+	tree.Insert("a", 1)
+	tree.Insert("bb", 2)
+	if tree.Insert("zz", 4) {
+		t.Error("expected zz to be equal to bb")
+	}
+	if !reflect.DeepEqual(tree.Keys(), []string{"a", "bb", "ccc"}) {
+		t.Errorf("expected [a bb ccc], got %v", tree.Keys())
+	}
+}
+
+func TestBulkLoad(t *testing.T) {
+	for _, degree := range []int{2, 3, 16} {
+		for _, n := range []int{0, 1, 2, 3, 4, 7, 8, 15, 16, 100, 1000, 4097} {
+			keys := make([]int, n)
+			values := make([]int, n)
+			for i := range keys {
+				keys[i] = i * 3
+				values[i] = i
+			}
+			tree, _ := btree.New[int, int](degree)
+			if err := tree.BulkLoad(keys, values); err != nil {
+				t.Fatalf(errUnexpectedErr, err)
+			}
+			if err := tree.CheckInvariants(); err != nil {
+				t.Fatalf("degree %d, %d keys: %v", degree, n, err)
+			}
+			if got := tree.Keys(); n > 0 && !reflect.DeepEqual(got, keys) {
+				t.Fatalf("degree %d, %d keys: keys differ", degree, n)
+			}
+			if n > 0 {
+				if v, ok := tree.Get(keys[n/2]); !ok || v != n/2 {
+					t.Errorf("expected %d, got %d (%v)", n/2, v, ok)
+				}
+			}
+			// The tree must stay valid when modified after the load
+			tree.Insert(-1, 0)
+			tree.Delete(0)
+			if err := tree.CheckInvariants(); err != nil {
+				t.Fatalf("degree %d, %d keys after update: %v", degree, n, err)
+			}
+		}
+	}
+}
+
+func TestBulkLoadErrors(t *testing.T) {
+	tree, _ := btree.New[int, int](2)
+	if err := tree.BulkLoad([]int{1, 2}, []int{1}); !errors.Is(err, btree.ErrSizeMismatch) {
+		t.Errorf("expected %v, got %v", btree.ErrSizeMismatch, err)
+	}
+	if err := tree.BulkLoad([]int{1, 1}, []int{1, 2}); !errors.Is(err, btree.ErrNotSorted) {
+		t.Errorf("expected %v, got %v", btree.ErrNotSorted, err)
+	}
+	tree.Insert(1, 1)
+	if err := tree.BulkLoad([]int{2}, []int{2}); !errors.Is(err, btree.ErrNotEmpty) {
+		t.Errorf("expected %v, got %v", btree.ErrNotEmpty, err)
+	}
+	tree.Clear()
+	if !tree.IsEmpty() || tree.Contains(1) {
+		t.Error("expected an empty tree after Clear")
+	}
+}
+
+// TestRandomOperations checks the tree against a map with random inserts and deletes
+func TestRandomOperations(t *testing.T) {
+	for _, degree := range []int{2, 3, 5} {
+		rnd := rand.New(rand.NewSource(int64(degree)))
+		tree, _ := btree.New[int, int](degree)
+		model := make(map[int]int)
+
+		for i := 0; i < 5000; i++ {
+			key := rnd.Intn(500)
+			if rnd.Intn(3) == 0 {
+				_, inModel := model[key]
+				v, ok := tree.Delete(key)
+				if ok != inModel || (ok && v != model[key]) {
+					t.Fatalf("Delete(%d) = %d, %v, expected %d, %v", key, v, ok, model[key], inModel)
+				}
+				delete(model, key)
+			} else {
+				_, inModel := model[key]
+				if tree.Insert(key, i) == inModel {
+					t.Fatalf("Insert(%d) returned %v with the key in the tree %v", key, !inModel, inModel)
+				}
+				model[key] = i
+			}
+			if i%100 == 0 {
+				if err := tree.CheckInvariants(); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		expected := make([]int, 0, len(model))
+		for k := range model {
+			expected = append(expected, k)
+		}
+		sort.Ints(expected)
+		if !reflect.DeepEqual(tree.Keys(), expected) || tree.Size() != uint64(len(model)) {
+			t.Fatalf("degree %d: the tree keys differ from the model", degree)
+		}
+		if err := tree.CheckInvariants(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkInsert(b *testing.B) {
+	tree, _ := btree.New[int, int](32)
+	rnd := rand.New(rand.NewSource(1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Insert(rnd.Int(), i)
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	const n = 1 << 20
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = i
+	}
+	tree, _ := btree.New[int, int](32)
+	_ = tree.BulkLoad(keys, keys)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Get(i % n)
+	}
+}
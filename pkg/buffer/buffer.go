@@ -16,12 +16,25 @@
 package buffer
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/rand"
 	"runtime"
+	"slices"
+	"sort"
 	"sync"
+
+	common "github.com/pzaino/gods/pkg/common"
 )
 
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
 const (
 	ErrBufferOverflow   = "buffer overflow"
 	ErrInvalidBuffer    = "invalid buffer"
@@ -30,21 +43,62 @@ const (
 	ErrIndexOutOfBounds = "index out of bounds"
 )
 
+// Sentinel errors returned by the Buffer methods (use errors.Is to check for them)
+var (
+	ErrOverflow    = errors.New(ErrBufferOverflow)
+	ErrInvalid     = errors.New(ErrInvalidBuffer)
+	ErrEmpty       = errors.New(ErrBufferEmpty)
+	ErrNotFound    = errors.New(ErrValueNotFound)
+	ErrOutOfBounds = errors.New(ErrIndexOutOfBounds)
+	ErrCorrupted   = errors.New("buffer is corrupted")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureHasher, common.FeatureObservers, common.FeatureBinaryCodec, common.FeatureInvariants, common.FeatureDeepCopy, common.FeatureJSON)
+}
+
 // Buffer represent the Buffer structure used in an ABBuffer
-type Buffer[T comparable] struct {
+type Buffer[T any] struct {
 	data     []T
 	size     uint64
 	capacity uint64
+	equals   common.EqualFunc[T]
+	key      common.KeyFunc[T] // nil when values can only be compared with equals
+	hasher   common.Hasher[T]  // nil unless the buffer was created with NewWithHasher
+	obs      common.Observers[T]
+	codec    common.Codec[T] // nil means common.GobCodec
+
+	blitWorkers   int    // goroutines used by BlitRange, 0 means GOMAXPROCS
+	blitThreshold uint64 // minimum elements to blit in parallel, 0 means defaultBlitThreshold
 }
 
+// defaultBlitThreshold is the minimum number of elements BlitRange blits in
+// parallel when no threshold is set with SetBlitParallelism
+const defaultBlitThreshold = 1024
+
 // New creates a new Buffer
 func New[T comparable]() *Buffer[T] {
-	return &Buffer[T]{}
+	return &Buffer[T]{equals: common.Equal[T], key: common.Key[T]}
+}
+
+// NewWithComparator creates a new Buffer that compares its elements with the
+// given function, so it can hold types that are not comparable (slices, maps, ...)
+func NewWithComparator[T any](equals func(a, b T) bool) *Buffer[T] {
+	return &Buffer[T]{equals: equals}
+}
+
+// NewWithHasher creates a new Buffer that compares its elements with the given
+// hasher, which also speeds up ContainsAll, ContainsAny and Deduplicate for
+// types that are not comparable (the hashes are compared first)
+func NewWithHasher[T any](hasher common.Hasher[T]) *Buffer[T] {
+	return &Buffer[T]{equals: hasher.Equals, hasher: hasher}
 }
 
 // NewWithCapacity creates a new Buffer with the given capacity
 func NewWithCapacity[T comparable](capacity uint64) *Buffer[T] {
-	return &Buffer[T]{capacity: capacity}
+	return &Buffer[T]{capacity: capacity, equals: common.Equal[T], key: common.Key[T]}
 }
 
 // NewWithSize creates a new Buffer with the given size
@@ -83,9 +137,78 @@ func NewWithSizeAndCapacity[T comparable](size, capacity uint64) *Buffer[T] {
 	return Buffer
 }
 
+// Adopt creates a new Buffer that uses items as its backing storage, the
+// slice is not copied and the caller must not use it afterwards
+func Adopt[T comparable](items []T) *Buffer[T] {
+	b := New[T]()
+	b.data = items
+	b.size = uint64(len(items))
+	return b
+}
+
+// AdoptWithComparator is like Adopt for a Buffer that compares its elements
+// with the given function (see NewWithComparator)
+func AdoptWithComparator[T any](items []T, equals func(a, b T) bool) *Buffer[T] {
+	b := NewWithComparator(equals)
+	b.data = items
+	b.size = uint64(len(items))
+	return b
+}
+
+// newEmpty creates a new empty buffer that compares its elements like b
+func (b *Buffer[T]) newEmpty() *Buffer[T] {
+	return &Buffer[T]{
+		equals:        b.equals,
+		key:           b.key,
+		hasher:        b.hasher,
+		codec:         b.codec,
+		blitWorkers:   b.blitWorkers,
+		blitThreshold: b.blitThreshold,
+	}
+}
+
+// seen returns a new Seen set that compares the values like b
+func (b *Buffer[T]) seen() *common.Seen[T] {
+	if b.hasher != nil {
+		return common.NewHashSeen(b.hasher)
+	}
+	return common.NewSeen(b.key, b.equals)
+}
+
+// lookup returns a new Lookup for items that compares the values like b
+func (b *Buffer[T]) lookup(items []T) *common.Lookup[T] {
+	if b.hasher != nil {
+		return common.NewHashLookup(b.hasher, items)
+	}
+	return common.NewLookup(b.key, b.equals, items)
+}
+
+// equal compares two elements with the buffer comparator
+func (b *Buffer[T]) equal(x, y T) bool {
+	return common.Equals(b.equals, x, y)
+}
+
+// OnInsert registers a function called after an element is added to the buffer
+// (nil removes it). See common.Observers for the meaning of the index
+func (b *Buffer[T]) OnInsert(f func(index uint64, v T)) {
+	b.obs.Insert = f
+}
+
+// OnRemove registers a function called after an element is removed from the
+// buffer (nil removes it). Put and Set report a removal followed by an insertion
+func (b *Buffer[T]) OnRemove(f func(index uint64, v T)) {
+	b.obs.Remove = f
+}
+
+// OnClear registers a function called after all the elements are removed at
+// once by Clear, Reset, Detach or Destroy (nil removes it)
+func (b *Buffer[T]) OnClear(f func()) {
+	b.obs.Clear = f
+}
+
 // NewReference returns a new buffer with the same elements (aka elements are not copied)
 func (b *Buffer[T]) NewReference() *Buffer[T] {
-	newBuffer := New[T]()
+	newBuffer := b.newEmpty()
 	newBuffer.data = append(newBuffer.data, b.data...)
 	newBuffer.size = b.size
 	newBuffer.capacity = b.capacity
@@ -100,6 +223,22 @@ func (b *Buffer[T]) IsEmpty() bool {
 	return b.size == 0
 }
 
+// CheckInvariants verifies the internal consistency of the buffer (the size
+// matches the backing slice and doesn't exceed the capacity), it returns an
+// error wrapping ErrCorrupted describing the first violation found
+func (b *Buffer[T]) CheckInvariants() error {
+	if b == nil {
+		return ErrInvalid
+	}
+	if b.size != uint64(len(b.data)) {
+		return fmt.Errorf("%w: size is %d but it holds %d elements", ErrCorrupted, b.size, len(b.data))
+	}
+	if b.capacity != 0 && b.size > b.capacity {
+		return fmt.Errorf("%w: size %d exceeds the capacity %d", ErrCorrupted, b.size, b.capacity)
+	}
+	return nil
+}
+
 // IsFull returns true if the buffer is full
 func (b *Buffer[T]) IsFull() bool {
 	if b.IsEmpty() {
@@ -114,40 +253,88 @@ func (b *Buffer[T]) IsFull() bool {
 // Append adds an element to the end of the buffer
 func (b *Buffer[T]) Append(elem T) error {
 	if b.IsFull() {
-		return errors.New(ErrBufferOverflow)
+		return ErrOverflow
 	}
 	b.data = append(b.data, elem)
 	b.size++
+	b.obs.Inserted(b.size-1, elem)
 	return nil
 }
 
 // InsertAt adds an element at the given index
 func (b *Buffer[T]) InsertAt(index uint64, elem T) error {
 	if b.IsEmpty() && index != 0 {
-		return errors.New(ErrBufferEmpty)
+		return ErrEmpty
 	}
 	if index > b.size || b.IsFull() {
-		return errors.New(ErrBufferOverflow)
+		return ErrOverflow
 	}
 
 	// Insert the element at the given index
 	b.data = append(b.data[:index], append([]T{elem}, b.data[index:]...)...)
 	b.size++
+	b.obs.Inserted(index, elem)
 
 	return nil
 }
 
+// InsertSliceAt inserts all the given items at the given index (in order),
+// shifting the following elements only once. Either all the items are
+// inserted or none is (if they would exceed the buffer capacity)
+func (b *Buffer[T]) InsertSliceAt(index uint64, items []T) error {
+	return b.ReplaceRange(index, index, items)
+}
+
+// RemoveRange removes the elements in the range [start, end)
+func (b *Buffer[T]) RemoveRange(start, end uint64) error {
+	if b.IsEmpty() {
+		return ErrEmpty
+	}
+	return b.ReplaceRange(start, end, nil)
+}
+
+// ReplaceRange replaces the elements in the range [start, end) with the given
+// items (which can be more or less than the replaced elements) with a single
+// copy of the following elements. Either the whole range is replaced or, on
+// error, the buffer is left untouched
+func (b *Buffer[T]) ReplaceRange(start, end uint64, items []T) error {
+	if b == nil {
+		return ErrInvalid
+	}
+	if start > end || end > b.size {
+		return ErrOutOfBounds
+	}
+
+	newSize := b.size - (end - start) + uint64(len(items))
+	if b.capacity != 0 && newSize > b.capacity {
+		return ErrOverflow
+	}
+
+	var removed []T
+	if b.obs.Remove != nil {
+		removed = slices.Clone(b.data[start:end])
+	}
+	b.data = slices.Replace(b.data[:b.size], int(start), int(end), items...)
+	b.size = newSize
+	b.obs.Removed(start, removed...)
+	b.obs.Inserted(start, items...)
+	return nil
+}
+
 // Put replaces the element at the given index
 func (b *Buffer[T]) Put(index uint64, elem T) error {
 	if b.IsEmpty() {
-		return errors.New(ErrBufferEmpty)
+		return ErrEmpty
 	}
 
 	if index >= b.size {
-		return errors.New(ErrValueNotFound)
+		return ErrNotFound
 	}
 
+	old := b.data[index]
 	b.data[index] = elem
+	b.obs.Removed(index, old)
+	b.obs.Inserted(index, elem)
 	return nil
 }
 
@@ -155,10 +342,10 @@ func (b *Buffer[T]) Put(index uint64, elem T) error {
 func (b *Buffer[T]) Get(index uint64) (T, error) {
 	var rVal T
 	if b.IsEmpty() {
-		return rVal, errors.New(ErrBufferEmpty)
+		return rVal, ErrEmpty
 	}
 	if index >= b.size {
-		return rVal, errors.New(ErrValueNotFound)
+		return rVal, ErrNotFound
 	}
 	return b.data[index], nil
 }
@@ -171,15 +358,17 @@ func (b *Buffer[T]) Set(index uint64, elem T) error {
 // Remove removes the element at the given index
 func (b *Buffer[T]) Remove(index uint64) error {
 	if b.IsEmpty() {
-		return errors.New(ErrBufferEmpty)
+		return ErrEmpty
 	}
 
 	if index >= b.size {
-		return errors.New(ErrValueNotFound)
+		return ErrNotFound
 	}
 
+	elem := b.data[index]
 	b.data = append(b.data[:index], b.data[index+1:]...)
 	b.size--
+	b.obs.Removed(index, elem)
 	return nil
 }
 
@@ -187,16 +376,125 @@ func (b *Buffer[T]) Remove(index uint64) error {
 func (b *Buffer[T]) Clear() {
 	b.data = []T{}
 	b.size = 0
+	b.obs.Cleared()
+}
+
+// Reset removes all elements from the buffer but keeps the allocated storage,
+// so it can be refilled without reallocating (the old elements are zeroed)
+func (b *Buffer[T]) Reset() {
+	clear(b.data)
+	b.data = b.data[:0]
+	b.size = 0
+	b.obs.Cleared()
+}
+
+// Detach empties the buffer and returns its backing slice without copying it,
+// the buffer no longer references the returned slice
+func (b *Buffer[T]) Detach() []T {
+	if b.IsEmpty() {
+		return nil
+	}
+	items := b.data[:b.size]
+	b.data = nil
+	b.size = 0
+	b.obs.Cleared()
+	return items
 }
 
-// Destroy removes all elements from the buffer and sets the capacity to 0 and set the buffer to nil
+// DrainAll removes and returns all elements in a new slice in one O(n)
+// operation, useful for batch flushes. The buffer keeps its storage so it can
+// be refilled without reallocating (use Detach to take the storage instead)
+func (b *Buffer[T]) DrainAll() []T {
+	items := b.ToSlice()
+	if items == nil {
+		return nil
+	}
+	b.Reset()
+	return items
+}
+
+// Destroy wipes the buffer (see Wipe) and sets its capacity to 0
 func (b *Buffer[T]) Destroy() {
-	b.Clear()
+	b.Wipe()
 	b.capacity = 0
 	b = nil
 }
 
-// Values returns all elements in the buffer
+// Wipe overwrites every element of the buffer, and every unused slot of its
+// storage, with the zero value and then releases the storage, so sensitive
+// data (tokens, keys, ...) doesn't stay reachable. The copies left behind
+// when the storage was reallocated to grow are out of reach and can't be
+// wiped: create the buffer with enough capacity to avoid them
+func (b *Buffer[T]) Wipe() {
+	clear(b.data[:cap(b.data)])
+	b.data = nil
+	b.size = 0
+	b.obs.Cleared()
+}
+
+// SetCodec sets the codec used by MarshalBinary and UnmarshalBinary to encode
+// the elements (nil restores the default common.GobCodec)
+func (b *Buffer[T]) SetCodec(codec common.Codec[T]) {
+	b.codec = codec
+}
+
+// elemCodec returns the codec used to encode the elements
+func (b *Buffer[T]) elemCodec() common.Codec[T] {
+	if b.codec == nil {
+		return common.GobCodec[T]{}
+	}
+	return b.codec
+}
+
+// MarshalBinary encodes the elements of the buffer (implements
+// encoding.BinaryMarshaler). The capacity and the comparator are not encoded
+func (b *Buffer[T]) MarshalBinary() ([]byte, error) {
+	if b == nil {
+		return nil, ErrInvalid
+	}
+	return common.MarshalElements(b.elemCodec(), b.data[:b.size])
+}
+
+// UnmarshalBinary replaces the elements of the buffer with the ones encoded
+// by MarshalBinary (implements encoding.BinaryUnmarshaler). It returns
+// ErrOverflow if they don't fit in the capacity, the buffer is unchanged on
+// errors
+func (b *Buffer[T]) UnmarshalBinary(data []byte) error {
+	items, err := common.UnmarshalElements(b.elemCodec(), data)
+	if err != nil {
+		return err
+	}
+	if b.capacity != 0 && uint64(len(items)) > b.capacity {
+		return ErrOverflow
+	}
+	b.data = items
+	b.size = uint64(len(items))
+	b.obs.Cleared()
+	b.obs.Inserted(0, items...)
+	return nil
+}
+
+// EncodeJSONStream writes the elements of the buffer to w as a JSON array, one
+// element at a time (the output of json.Marshal on ToSlice, without the copy)
+func (b *Buffer[T]) EncodeJSONStream(w io.Writer) error {
+	enc := common.NewJSONArrayEncoder[T](w)
+	for i := uint64(0); i < b.size; i++ {
+		if err := enc.Encode(b.data[i]); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// DecodeJSONStream appends the elements of the JSON array read from dec, one
+// element at a time, without holding the whole array in memory (see
+// common.DecodeJSONArray). It stops with ErrOverflow when the buffer is full.
+// The elements decoded before an error stay in the buffer
+func (b *Buffer[T]) DecodeJSONStream(dec *json.Decoder) error {
+	return common.DecodeJSONArray(dec, b.Append)
+}
+
+// Values returns a copy of all elements in the buffer (same as ToSlice)
 func (b *Buffer[T]) Values() []T {
 	return b.ToSlice()
 }
@@ -214,8 +512,12 @@ func (b *Buffer[T]) Capacity() uint64 {
 	return b.capacity
 }
 
-// SetCapacity sets the capacity of the buffer
+// SetCapacity sets the capacity of the buffer (0 means unbounded). If the
+// buffer holds more elements than the new capacity, the last ones are removed
 func (b *Buffer[T]) SetCapacity(capacity uint64) {
+	if capacity != 0 && b.size > capacity {
+		_, _ = b.PopN(b.size - capacity)
+	}
 	b.capacity = capacity
 }
 
@@ -234,20 +536,46 @@ func (b *Buffer[T]) Equals(other *Buffer[T]) bool {
 	}
 
 	for i := uint64(0); i < b.Size(); i++ {
-		if b.data[i] != other.data[i] {
+		if !b.equal(b.data[i], other.data[i]) {
 			return false
 		}
 	}
 	return true
 }
 
-// ToSlice returns a slice of the buffer
+// ToSlice returns a copy of the elements of the buffer as a slice, changes
+// to the returned slice do not affect the buffer
 func (b *Buffer[T]) ToSlice() []T {
 	if b.IsEmpty() {
 		return nil
 	}
 
-	return b.data
+	values := make([]T, b.size)
+	copy(values, b.data[:b.size])
+	return values
+}
+
+// String returns a string representation of the buffer (elements are formatted with %v)
+func (b *Buffer[T]) String() string {
+	return b.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the buffer with every element formatted by f
+func (b *Buffer[T]) StringFunc(f func(T) string) string {
+	return common.FormatSlice(b.UnsafeSlice(), f)
+}
+
+// UnsafeSlice returns the internal slice of the buffer without copying it.
+// The returned slice aliases the buffer: changing its elements changes the
+// buffer and any later mutation of the buffer (Append, Remove, Clear, ...)
+// may or may not be reflected in it. Use it only for short-lived, read-mostly
+// zero-copy access and never append to it
+func (b *Buffer[T]) UnsafeSlice() []T {
+	if b.IsEmpty() {
+		return nil
+	}
+
+	return b.data[:b.size]
 }
 
 // Reverse reverses the buffer
@@ -265,15 +593,34 @@ func (b *Buffer[T]) Reverse() {
 // Find returns the index of the first element with the given value
 func (b *Buffer[T]) Find(value T) (uint64, error) {
 	if b.IsEmpty() {
-		return 0, errors.New(ErrBufferEmpty)
+		return 0, ErrEmpty
 	}
 
 	for i := uint64(0); i < b.size; i++ {
-		if b.data[i] == value {
+		if b.equal(b.data[i], value) {
 			return i, nil
 		}
 	}
-	return 0, errors.New(ErrValueNotFound)
+	return 0, ErrNotFound
+}
+
+// FindCtx returns the index of the first element with the given value like
+// Find, it checks ctx every common.CtxCheckInterval elements and returns its
+// error when it's done
+func (b *Buffer[T]) FindCtx(ctx context.Context, value T) (uint64, error) {
+	if b.IsEmpty() {
+		return 0, ErrEmpty
+	}
+
+	for i := uint64(0); i < b.size; i++ {
+		if err := common.CtxErr(ctx, i); err != nil {
+			return 0, err
+		}
+		if b.equal(b.data[i], value) {
+			return i, nil
+		}
+	}
+	return 0, ErrNotFound
 }
 
 // Contains returns true if the buffer contains the given element
@@ -283,20 +630,65 @@ func (b *Buffer[T]) Contains(value T) bool {
 	}
 
 	for i := uint64(0); i < b.size; i++ {
-		if b.data[i] == value {
+		if b.equal(b.data[i], value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsAll returns true if the buffer contains all the given elements (true
+// when none is given), they are all checked in a single pass over the buffer
+func (b *Buffer[T]) ContainsAll(elements ...T) bool {
+	if len(elements) == 0 {
+		return true
+	}
+
+	lookup := b.lookup(elements)
+	for i := uint64(0); i < b.size; i++ {
+		if lookup.Match(b.data[i]) && lookup.Remaining() == 0 {
 			return true
 		}
 	}
 	return false
 }
 
-// Copy returns a new buffer with copied elements
+// ContainsAny returns true if the buffer contains at least one of the given
+// elements, they are all checked in a single pass over the buffer
+func (b *Buffer[T]) ContainsAny(elements ...T) bool {
+	if len(elements) == 0 {
+		return false
+	}
+
+	lookup := b.lookup(elements)
+	for i := uint64(0); i < b.size; i++ {
+		if lookup.Match(b.data[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountOf returns the number of elements in the buffer equal to the given element
+func (b *Buffer[T]) CountOf(element T) uint64 {
+	var count uint64
+	for i := uint64(0); i < b.size; i++ {
+		if b.equal(b.data[i], element) {
+			count++
+		}
+	}
+	return count
+}
+
+// Copy returns a new buffer with copied elements. The copy is shallow: the elements are
+// copied by assignment, so pointers, slices and maps inside them are shared
+// with the original (use CloneWith for a deep copy)
 func (b *Buffer[T]) Copy() *Buffer[T] {
 	if b.IsEmpty() {
-		return New[T]()
+		return b.newEmpty()
 	}
 
-	newBuffer := New[T]()
+	newBuffer := b.newEmpty()
 	newBuffer.data = make([]T, b.size)
 	copy(newBuffer.data, b.data)
 	newBuffer.size = b.size
@@ -304,44 +696,164 @@ func (b *Buffer[T]) Copy() *Buffer[T] {
 	return newBuffer
 }
 
-// Merge appends all elements from another buffer
+// CloneWith returns a new buffer with a copy of every element made by copier,
+// to deep copy elements that hold pointers, slices or maps
+func (b *Buffer[T]) CloneWith(copier func(T) T) *Buffer[T] {
+	newBuffer := b.Copy()
+	for i := uint64(0); i < newBuffer.size; i++ {
+		newBuffer.data[i] = copier(newBuffer.data[i])
+	}
+	return newBuffer
+}
+
+// fromSlice returns a new unbounded buffer, comparing its elements like b,
+// with a copy of items
+func (b *Buffer[T]) fromSlice(items []T) *Buffer[T] {
+	newBuffer := b.newEmpty()
+	newBuffer.data = append([]T(nil), items...)
+	newBuffer.size = uint64(len(items))
+	return newBuffer
+}
+
+// Chunk splits the elements of the buffer in consecutive new buffers of size
+// elements (the last one may be shorter). It returns nil if size is 0 or the
+// buffer is empty
+func (b *Buffer[T]) Chunk(size uint64) []*Buffer[T] {
+	if size == 0 || b.size == 0 {
+		return nil
+	}
+	chunks := make([]*Buffer[T], 0, (b.size+size-1)/size)
+	for start := uint64(0); start < b.size; start += size {
+		chunks = append(chunks, b.fromSlice(b.data[start:min(start+size, b.size)]))
+	}
+	return chunks
+}
+
+// Windows returns all the sliding windows of size consecutive elements of the
+// buffer as new buffers, in order ([0, size), [1, size+1), ...). It returns
+// nil if size is 0 or greater than the size of the buffer
+func (b *Buffer[T]) Windows(size uint64) []*Buffer[T] {
+	if size == 0 || size > b.size {
+		return nil
+	}
+	windows := make([]*Buffer[T], 0, b.size-size+1)
+	for start := uint64(0); start+size <= b.size; start++ {
+		windows = append(windows, b.fromSlice(b.data[start:start+size]))
+	}
+	return windows
+}
+
+// Merge moves all elements from another buffer to the end of this one. If the
+// buffer is bounded only the elements that fit are moved, the others are left
+// in the other buffer
 func (b *Buffer[T]) Merge(other *Buffer[T]) {
 	if other.IsEmpty() {
 		return
 	}
 
-	b.data = append(b.data, other.data...)
-	b.size += other.size
-
-	// Clear the other buffer
-	other.Clear()
+	n := other.size
+	if b.capacity != 0 && b.size+n > b.capacity {
+		n = b.capacity - b.size
+	}
+	start := b.size
+	b.data = append(b.data, other.data[:n]...)
+	b.size += n
+	b.obs.Inserted(start, b.data[start:]...)
 
+	// Remove the moved elements from the other buffer
+	if n == other.size {
+		other.Clear()
+	} else if n > 0 {
+		_ = other.RemoveRange(0, n)
+	}
 }
 
-// PopN removes and returns the last n elements
+// PopN removes and returns the last n elements, in the order they had in the
+// buffer (the last element of the buffer is the last of the result). It fails
+// with ErrEmpty, removing nothing, if the buffer has fewer than n elements
 func (b *Buffer[T]) PopN(n uint64) ([]T, error) {
 	if b.IsEmpty() {
-		return nil, errors.New(ErrBufferEmpty)
+		return nil, ErrEmpty
 	}
 
 	if b.size < n {
-		return nil, errors.New(ErrBufferEmpty)
+		return nil, ErrEmpty
 	}
+	return b.popN(n), nil
+}
+
+// PopUpToN removes and returns the last n elements like PopN, or all the
+// elements if the buffer has fewer than n (nil if it's empty)
+func (b *Buffer[T]) PopUpToN(n uint64) []T {
+	if b.IsEmpty() {
+		return nil
+	}
+	return b.popN(min(n, b.size))
+}
+
+// popN removes and returns the last n (<= size) elements. The result is a copy,
+// so later appends reusing the freed slots don't change it
+func (b *Buffer[T]) popN(n uint64) []T {
 	start := b.size - n
-	end := b.size
-	values := b.data[start:end]
+	values := make([]T, n)
+	copy(values, b.data[start:b.size])
+	clear(b.data[start:b.size]) // let the GC collect the removed elements
 	b.data = b.data[:start]
 	b.size -= n
-	return values, nil
+	b.obs.Removed(start, values...)
+	return values
 }
 
-// PushN adds multiple elements to the end of the buffer
+// PushN adds multiple elements to the end of the buffer, in order. It fails
+// with ErrOverflow, adding nothing, if they don't all fit in the capacity
 func (b *Buffer[T]) PushN(items ...T) error {
 	if b.size+uint64(len(items)) > b.capacity && b.capacity != 0 {
-		return errors.New(ErrBufferOverflow)
+		return ErrOverflow
 	}
+	start := b.size
 	b.data = append(b.data, items...)
 	b.size += uint64(len(items))
+	b.obs.Inserted(start, items...)
+	return nil
+}
+
+// Truncate keeps the first n elements of the buffer and removes the others,
+// it does nothing if the buffer holds n elements or fewer
+func (b *Buffer[T]) Truncate(n uint64) {
+	if n >= b.size {
+		return
+	}
+
+	var removed []T
+	if b.obs.Remove != nil {
+		removed = slices.Clone(b.data[n:b.size])
+	}
+	clear(b.data[n:b.size]) // let the GC collect the removed elements
+	b.data = b.data[:n]
+	b.size = n
+	b.obs.Removed(n, removed...)
+}
+
+// Resize sets the number of elements of the buffer to n: a bigger buffer is
+// filled with copies of fill, a smaller one keeps its first n elements (like
+// Truncate). It fails with ErrOverflow, leaving the buffer untouched, if n
+// exceeds the capacity
+func (b *Buffer[T]) Resize(n uint64, fill T) error {
+	if b.capacity != 0 && n > b.capacity {
+		return ErrOverflow
+	}
+	if n <= b.size {
+		b.Truncate(n)
+		return nil
+	}
+
+	start := b.size
+	b.data = slices.Grow(b.data[:start], int(n-start))[:n]
+	for i := start; i < n; i++ {
+		b.data[i] = fill
+	}
+	b.size = n
+	b.obs.Inserted(start, b.data[start:]...)
 	return nil
 }
 
@@ -356,6 +868,7 @@ func (b *Buffer[T]) ShiftLeft(n uint64) {
 	}
 
 	// move the first n elements to the beginning of the buffer
+	dropped := b.data[:n]
 	b.data = b.data[n:]
 
 	// append n "zero" values to the end of the buffer
@@ -363,6 +876,8 @@ func (b *Buffer[T]) ShiftLeft(n uint64) {
 	for i := uint64(0); i < n; i++ {
 		b.data = append(b.data, zero)
 	}
+	b.obs.Removed(0, dropped...)
+	b.obs.Inserted(b.size-n, b.data[b.size-n:b.size]...)
 }
 
 // ShiftRight shifts all elements to the right by n positions
@@ -375,6 +890,11 @@ func (b *Buffer[T]) ShiftRight(n uint64) {
 		n = b.size
 	}
 
+	var dropped []T
+	if b.obs.Remove != nil {
+		dropped = slices.Clone(b.data[b.size-n : b.size])
+	}
+
 	// Shift elements to the right within the buffer
 	copy(b.data[n:], b.data[:b.size-n])
 
@@ -383,6 +903,8 @@ func (b *Buffer[T]) ShiftRight(n uint64) {
 	for i := uint64(0); i < n; i++ {
 		b.data[i] = zero
 	}
+	b.obs.Removed(b.size-n, dropped...)
+	b.obs.Inserted(0, b.data[:n]...)
 }
 
 // RotateLeft rotates all elements to the left by n positions
@@ -420,13 +942,43 @@ func (b *Buffer[T]) Filter(predicate func(T) bool) {
 	}
 
 	var newData []T
+	var removed []common.Removal[T]
 	for i := uint64(0); i < b.size; i++ {
 		if predicate(b.data[i]) {
 			newData = append(newData, b.data[i])
+		} else if b.obs.Remove != nil {
+			removed = append(removed, common.Removal[T]{Index: uint64(len(newData)), Value: b.data[i]})
 		}
 	}
 	b.data = newData
 	b.size = uint64(len(newData))
+	b.obs.RemovedAll(removed)
+}
+
+// RemoveIf removes the elements that match the predicate (the opposite of
+// Filter) and returns how many were removed
+func (b *Buffer[T]) RemoveIf(predicate func(T) bool) uint64 {
+	size := b.size
+	b.Filter(func(v T) bool { return !predicate(v) })
+	return size - b.size
+}
+
+// RemoveAll removes all the elements equal to value and returns how many were removed
+func (b *Buffer[T]) RemoveAll(value T) uint64 {
+	return b.RemoveIf(func(v T) bool { return b.equal(v, value) })
+}
+
+// RemoveFirstN removes the first n elements equal to value (from the start of
+// the buffer) and returns how many were removed
+func (b *Buffer[T]) RemoveFirstN(value T, n uint64) uint64 {
+	removed := uint64(0)
+	return b.RemoveIf(func(v T) bool {
+		if removed < n && b.equal(v, value) {
+			removed++
+			return true
+		}
+		return false
+	})
 }
 
 // Map creates a new buffer with the results of applying the function to each element
@@ -434,6 +986,38 @@ func (b *Buffer[T]) Map(fn func(T) T) (*Buffer[T], error) {
 	return b.MapRange(0, b.size, fn)
 }
 
+// MapCtx creates a new buffer like Map, it checks ctx every
+// common.CtxCheckInterval elements and returns its error (and no buffer) when
+// it's done
+func (b *Buffer[T]) MapCtx(ctx context.Context, fn func(T) T) (*Buffer[T], error) {
+	if b.IsEmpty() {
+		return nil, ErrEmpty
+	}
+
+	newBuffer := b.newEmpty()
+	newBuffer.data = make([]T, b.size)
+	for i := uint64(0); i < b.size; i++ {
+		if err := common.CtxErr(ctx, i); err != nil {
+			return nil, err
+		}
+		newBuffer.data[i] = fn(b.data[i])
+	}
+	newBuffer.size = b.size
+	newBuffer.capacity = b.capacity
+	return newBuffer, nil
+}
+
+// MapIndexed creates a new buffer with the results of applying the function to
+// each element and its index
+func (b *Buffer[T]) MapIndexed(fn func(uint64, T) T) (*Buffer[T], error) {
+	i := uint64(0)
+	return b.Map(func(v T) T {
+		result := fn(i, v)
+		i++
+		return result
+	})
+}
+
 // MapFrom creates a new buffer with the results of applying the function to each element starting from the specified index
 func (b *Buffer[T]) MapFrom(start uint64, fn func(T) T) (*Buffer[T], error) {
 	return b.MapRange(start, b.size, fn)
@@ -442,14 +1026,14 @@ func (b *Buffer[T]) MapFrom(start uint64, fn func(T) T) (*Buffer[T], error) {
 // MapRange creates a new buffer with the results of applying the function to each element in the range [start, end]
 func (b *Buffer[T]) MapRange(start, end uint64, fn func(T) T) (*Buffer[T], error) {
 	if b.IsEmpty() {
-		return nil, errors.New(ErrBufferEmpty)
+		return nil, ErrEmpty
 	}
 
 	if start >= b.size || end > b.size || start > end {
-		return nil, errors.New(ErrInvalidBuffer)
+		return nil, ErrInvalid
 	}
 
-	newBuffer := New[T]()
+	newBuffer := b.newEmpty()
 	var i uint64
 	for i = start; i < end; i++ {
 		err := newBuffer.Append(fn(b.data[i]))
@@ -467,6 +1051,43 @@ func (b *Buffer[T]) Reduce(fn func(T, T) T) (T, error) {
 	return b.ReduceRange(0, b.size, fn)
 }
 
+// ReduceInto reduces the buffer to a value of a different type, starting from
+// initial and applying fn to each element from the first to the last
+func ReduceInto[T, A any](b *Buffer[T], initial A, fn func(A, T) A) A {
+	result := initial
+	for i := uint64(0); i < b.size; i++ {
+		result = fn(result, b.data[i])
+	}
+	return result
+}
+
+// MapTo returns a new buffer with the result of fn applied to every element of
+// src, in the same order. Unlike Map the result can hold a different type, e.g.
+// to extract the IDs of a buffer of structs. Its elements are compared with ==
+// like the ones of New, use MapToWithComparator when U is not comparable
+func MapTo[T any, U comparable](src *Buffer[T], fn func(T) U) *Buffer[U] {
+	return mapInto(src, fn, New[U]())
+}
+
+// MapToWithComparator is like MapTo, the new buffer compares its elements with
+// the given function
+func MapToWithComparator[T, U any](src *Buffer[T], fn func(T) U, equals func(a, b U) bool) *Buffer[U] {
+	return mapInto(src, fn, NewWithComparator(equals))
+}
+
+// mapInto fills result with fn applied to every element of src
+func mapInto[T, U any](src *Buffer[T], fn func(T) U, result *Buffer[U]) *Buffer[U] {
+	if src.size == 0 {
+		return result
+	}
+	result.data = make([]U, src.size)
+	for i := uint64(0); i < src.size; i++ {
+		result.data[i] = fn(src.data[i])
+	}
+	result.size = src.size
+	return result
+}
+
 // ReduceFrom reduces the buffer to a single value starting from the specified index
 func (b *Buffer[T]) ReduceFrom(start uint64, fn func(T, T) T) (T, error) {
 	return b.ReduceRange(start, b.size, fn)
@@ -477,14 +1098,14 @@ func (b *Buffer[T]) ReduceRange(start, end uint64, fn func(T, T) T) (T, error) {
 	// If the buffer is empty there is no work to do
 	if b.IsEmpty() {
 		var rVal T
-		return rVal, errors.New(ErrBufferEmpty)
+		return rVal, ErrEmpty
 	}
 
 	// start and end must be within the bounds of the buffer
 	// and start cannot be greater than end
 	if start >= b.size || end > b.size || start > end {
 		var rVal T
-		return rVal, errors.New(ErrInvalidBuffer)
+		return rVal, ErrInvalid
 	}
 
 	result := b.data[start]
@@ -498,11 +1119,14 @@ func (b *Buffer[T]) ReduceRange(start, end uint64, fn func(T, T) T) (T, error) {
 // Swap swaps the elements at the given indices
 func (b *Buffer[T]) Swap(i, j uint64) error {
 	if b.IsEmpty() {
-		return errors.New(ErrBufferEmpty)
+		return ErrEmpty
 	}
 
-	if i >= b.size || j >= b.size {
-		return errors.New(ErrIndexOutOfBounds)
+	if i >= b.size {
+		return common.NewIndexError(ErrOutOfBounds, i, b.size)
+	}
+	if j >= b.size {
+		return common.NewIndexError(ErrOutOfBounds, j, b.size)
 	}
 
 	b.data[i], b.data[j] = b.data[j], b.data[i]
@@ -514,14 +1138,38 @@ func (b *Buffer[T]) ForEach(fn func(*T) error) error {
 	return b.ForRange(0, b.size, fn)
 }
 
+// ForEachCtx applies the function to each element in the buffer like ForEach,
+// it checks ctx every common.CtxCheckInterval elements and stops, returning its
+// error, when it's done
+func (b *Buffer[T]) ForEachCtx(ctx context.Context, fn func(*T) error) error {
+	var i uint64
+	return b.ForEach(func(v *T) error {
+		if err := common.CtxErr(ctx, i); err != nil {
+			return err
+		}
+		i++
+		return fn(v)
+	})
+}
+
+// ForEachIndexed applies the function to each element in the buffer and its index
+func (b *Buffer[T]) ForEachIndexed(fn func(uint64, *T) error) error {
+	i := uint64(0)
+	return b.ForEach(func(v *T) error {
+		err := fn(i, v)
+		i++
+		return err
+	})
+}
+
 // ForRange applies the function to each element in the buffer in the range [start, end)
 func (b *Buffer[T]) ForRange(start, end uint64, fn func(*T) error) error {
 	if b.IsEmpty() {
-		return errors.New(ErrBufferEmpty)
+		return ErrEmpty
 	}
 
 	if start >= b.size || end > b.size || start > end {
-		return errors.New(ErrInvalidBuffer)
+		return ErrInvalid
 	}
 
 	for i := start; i < end; i++ {
@@ -534,13 +1182,15 @@ func (b *Buffer[T]) ForRange(start, end uint64, fn func(*T) error) error {
 
 // ConfinedForRange applies the function to each element in the buffer in the range [start, end]
 // in a confined goroutine (i.e., the user-function is executed in parallel)
+//
+// Deprecated: ConfinedForRange starts a goroutine per element, use ParallelForRange
 func (b *Buffer[T]) ConfinedForRange(start, end uint64, fn func(*T) error) error {
 	if b.IsEmpty() {
-		return errors.New(ErrBufferEmpty)
+		return ErrEmpty
 	}
 
 	if start >= b.size || end > b.size || start > end {
-		return errors.New(ErrInvalidBuffer)
+		return ErrInvalid
 	}
 
 	numElements := end - start + 1
@@ -571,15 +1221,66 @@ func (b *Buffer[T]) ConfinedForRange(start, end uint64, fn func(*T) error) error
 }
 
 // ConfinedForEach applies the function to each element in the buffer in a confined goroutine
+//
+// Deprecated: ConfinedForEach starts a goroutine per element, use ParallelForEach
 func (b *Buffer[T]) ConfinedForEach(fn func(*T) error) error {
 	return b.ConfinedForRange(0, b.size, fn)
 }
 
 // ConfinedForFrom applies the function to each element in the buffer starting from the index
+//
+// Deprecated: ConfinedForFrom starts a goroutine per element, use ParallelForRange
 func (b *Buffer[T]) ConfinedForFrom(start uint64, fn func(*T) error) error {
 	return b.ConfinedForRange(start, b.size, fn)
 }
 
+// ParallelForEach applies the function to each element in the buffer using up
+// to workers goroutines (see ParallelForRange)
+func (b *Buffer[T]) ParallelForEach(ctx context.Context, workers int, fn func(*T) error) error {
+	return b.ParallelForRange(ctx, 0, b.size, workers, fn)
+}
+
+// ParallelForRange applies the function to each element in the buffer in the
+// range [start, end) using up to workers goroutines (GOMAXPROCS if workers <= 0),
+// each one working on a contiguous chunk of the range, so fn must be safe to
+// call at the same time on different elements. The first error returned by fn
+// stops the other goroutines and is returned, and so is the error (or cause)
+// of ctx when it's done before all the elements are processed
+func (b *Buffer[T]) ParallelForRange(ctx context.Context, start, end uint64, workers int, fn func(*T) error) error {
+	if b.IsEmpty() {
+		return ErrEmpty
+	}
+
+	if start >= b.size || end > b.size || start > end {
+		return ErrInvalid
+	}
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	done := ctx.Done()
+	inChunks(start, end, workers, func(from, to uint64) {
+		for i := from; i < to; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if err := fn(&b.data[i]); err != nil {
+				cancel(err)
+				return
+			}
+		}
+	})
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	return nil
+}
+
 // ForFrom applies the function to each element in the buffer starting from the index
 func (b *Buffer[T]) ForFrom(start uint64, fn func(*T) error) error {
 	return b.ForRange(start, b.size, fn)
@@ -616,7 +1317,7 @@ func (b *Buffer[T]) All(predicate func(T) bool) bool {
 // FindIndex returns the index of the first element that matches the predicate
 func (b *Buffer[T]) FindIndex(predicate func(T) bool) (uint64, error) {
 	if b.IsEmpty() {
-		return 0, errors.New(ErrBufferEmpty)
+		return 0, ErrEmpty
 	}
 
 	for i := uint64(0); i < b.size; i++ {
@@ -624,41 +1325,51 @@ func (b *Buffer[T]) FindIndex(predicate func(T) bool) (uint64, error) {
 			return i, nil
 		}
 	}
-	return 0, errors.New(ErrValueNotFound)
+	return 0, ErrNotFound
 }
 
-// FindLast returns the last element that matches the predicate
-func (b *Buffer[T]) FindLast(predicate func(T) bool) (*T, error) {
-	if b.IsEmpty() {
-		return nil, errors.New(ErrBufferEmpty)
-	}
-
-	for i := b.size - 1; i > 0; i-- {
+// FindFirst returns the first element that matches the predicate, or None if
+// no element matches (an empty buffer is not an error)
+func (b *Buffer[T]) FindFirst(predicate func(T) bool) common.Optional[T] {
+	for i := uint64(0); i < b.size; i++ {
 		if predicate(b.data[i]) {
-			return &b.data[i], nil
+			return common.Some(b.data[i])
 		}
 	}
-	if predicate(b.data[0]) {
-		return &b.data[0], nil
+	return common.None[T]()
+}
+
+// FindLast returns the last element that matches the predicate
+func (b *Buffer[T]) FindLast(predicate func(T) bool) (*T, error) {
+	i, err := b.lastIndex(predicate)
+	if err != nil {
+		return nil, err
 	}
-	return nil, errors.New(ErrValueNotFound)
+	return &b.data[i], nil
 }
 
 // FindLastIndex returns the index of the last element that matches the predicate
 func (b *Buffer[T]) FindLastIndex(predicate func(T) bool) (uint64, error) {
-	if b.IsEmpty() {
-		return 0, errors.New(ErrBufferEmpty)
+	return b.lastIndex(predicate)
+}
+
+// lastIndex scans the buffer from the last element to the first and returns
+// the index of the first element (in scan order) that matches the predicate.
+// It returns ErrEmpty if the buffer has no elements and ErrNotFound if none
+// matches. The loop counts down from the size to 1 and checks i-1, so it
+// never wraps around below index 0
+func (b *Buffer[T]) lastIndex(predicate func(T) bool) (uint64, error) {
+	n := min(b.size, uint64(len(b.data)))
+	if n == 0 {
+		return 0, ErrEmpty
 	}
 
-	for i := b.size - 1; i > 0; i-- {
-		if predicate(b.data[i]) {
-			return i, nil
+	for i := n; i > 0; i-- {
+		if predicate(b.data[i-1]) {
+			return i - 1, nil
 		}
 	}
-	if predicate(b.data[0]) {
-		return 0, nil
-	}
-	return 0, errors.New(ErrValueNotFound)
+	return 0, ErrNotFound
 }
 
 // FindAll returns all elements that match the predicate
@@ -667,7 +1378,7 @@ func (b *Buffer[T]) FindAll(predicate func(T) bool) *Buffer[T] {
 		return nil
 	}
 
-	newBuffer := New[T]()
+	newBuffer := b.newEmpty()
 	var items uint64
 	for i := uint64(0); i < b.size; i++ {
 		if predicate(b.data[i]) {
@@ -700,19 +1411,7 @@ func (b *Buffer[T]) FindIndices(predicate func(T) bool) []uint64 {
 
 // LastIndexOf returns the index of the last element with the given value
 func (b *Buffer[T]) LastIndexOf(value T) (uint64, error) {
-	if b.IsEmpty() {
-		return 0, errors.New(ErrBufferEmpty)
-	}
-
-	for i := b.size - 1; i > 0; i-- {
-		if b.data[i] == value {
-			return i, nil
-		}
-	}
-	if b.data[0] == value {
-		return 0, nil
-	}
-	return 0, errors.New(ErrValueNotFound)
+	return b.lastIndex(func(v T) bool { return b.equal(v, value) })
 }
 
 // Blit combine/overwrite the values of the in the buffer with the values of another buffer using a function
@@ -725,58 +1424,449 @@ func (b *Buffer[T]) BlitFrom(start uint64, other *Buffer[T], f func(T, T) T) err
 	return b.BlitRange(start, b.size, other, f)
 }
 
-// BlitRange combine/overwrite the values of the in the buffer with the values of another buffer in the range [start, end] using a function
+// SetBlitParallelism sets how Blit, BlitFrom and BlitRange split the work:
+// ranges of at least threshold elements are blitted by up to workers
+// goroutines, each one working on a contiguous chunk. workers <= 0 uses
+// GOMAXPROCS goroutines and 1 always blits in the calling goroutine, threshold
+// 0 restores the default (1024). Use a lower threshold when f is expensive
+func (b *Buffer[T]) SetBlitParallelism(workers int, threshold uint64) {
+	b.blitWorkers = max(workers, 0)
+	b.blitThreshold = threshold
+}
+
+// BlitParallelism returns the number of goroutines and the threshold used by
+// BlitRange (see SetBlitParallelism)
+func (b *Buffer[T]) BlitParallelism() (workers int, threshold uint64) {
+	workers, threshold = b.blitWorkers, b.blitThreshold
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if threshold == 0 {
+		threshold = defaultBlitThreshold
+	}
+	return workers, threshold
+}
+
+// BlitRange combine/overwrite the values of the in the buffer with the values of another buffer in the range [start, end) using a function
 func (b *Buffer[T]) BlitRange(start, end uint64, other *Buffer[T], f func(T, T) T) error {
 	if other.IsEmpty() {
 		return nil
 	}
 
 	if b == nil {
-		return errors.New(ErrInvalidBuffer)
+		return ErrInvalid
 	}
 
 	// start and end must be within the bounds of the buffer
 	// and start cannot be greater than end
 	if start >= b.size || start >= end || start >= other.size || end > b.size {
-		return errors.New(ErrIndexOutOfBounds)
+		return ErrOutOfBounds
+	}
+
+	// Blit [start, limit), limit is end or the size of other if smaller
+	limit := min(end, other.size)
+	blit := func(from, to uint64) {
+		for j := from; j < to; j++ {
+			b.data[j] = f(b.data[j], other.data[j])
+		}
 	}
 
-	var maxElements uint64
-	if end-start < other.size-start {
-		maxElements = end - start
-	} else {
-		maxElements = other.size - start
+	// Parallelize the blitting process for large ranges, every goroutine
+	// works on its own chunk of [start, limit)
+	b.BlitInChunks(start, limit, blit)
+	return nil
+}
+
+// BlitInChunks calls blit on [start, end) the way BlitRange does: split in up
+// to BlitParallelism workers contiguous chunks blitted in parallel when the
+// range has at least threshold elements, or all at once in the calling
+// goroutine otherwise (for containers that blit a layout of their own over
+// the buffer, like a region of a matrix)
+func (b *Buffer[T]) BlitInChunks(start, end uint64, blit func(from, to uint64)) {
+	if start >= end {
+		return
 	}
+	workers, threshold := b.BlitParallelism()
+	if workers == 1 || end-start < threshold {
+		blit(start, end)
+		return
+	}
+	inChunks(start, end, workers, blit)
+}
 
-	// Parallelize the blitting process for large buffers
-	const minParallelSize = 1024 // Minimum size to consider parallel execution
-	if maxElements >= minParallelSize {
-		numCPU := runtime.NumCPU()
-		var wg sync.WaitGroup
-		chunkSize := (int(maxElements) + numCPU - 1) / numCPU // Determine chunk size
+// inChunks splits [start, end) in up to workers contiguous chunks, calls f on
+// each one in its own goroutine and waits for all of them
+func inChunks(start, end uint64, workers int, f func(from, to uint64)) {
+	chunkSize := (end - start + uint64(workers) - 1) / uint64(workers)
+	var wg sync.WaitGroup
+	for from := start; from < end; from += chunkSize {
+		wg.Add(1)
+		go func(from, to uint64) {
+			defer wg.Done()
+			f(from, to)
+		}(from, min(from+chunkSize, end))
+	}
+	wg.Wait()
+}
 
-		wg.Add(numCPU)
-		for i := 0; i < numCPU; i++ {
-			start := int(start) + i*chunkSize
-			end := start + chunkSize
-			if end > int(b.size) {
-				end = int(b.size)
-			}
+// Sort sorts the buffer according to the given function
+// for example, to sort a buffer of integers in ascending order, use:
+// b.Sort(func(a, b int) bool { return a < b })
+func (b *Buffer[T]) Sort(less func(T, T) bool) {
+	if b.IsEmpty() {
+		return
+	}
+	data := b.data[:b.size]
+	sort.Slice(data, func(i, j int) bool { return less(data[i], data[j]) })
+}
+
+// SortStable sorts the buffer according to the given function keeping the
+// original order of equal elements
+func (b *Buffer[T]) SortStable(less func(T, T) bool) {
+	if b.IsEmpty() {
+		return
+	}
+	data := b.data[:b.size]
+	sort.SliceStable(data, func(i, j int) bool { return less(data[i], data[j]) })
+}
+
+// IsSorted returns true if the buffer is sorted according to the given function
+func (b *Buffer[T]) IsSorted(less func(T, T) bool) bool {
+	for i := uint64(1); i < b.Size(); i++ {
+		if less(b.data[i], b.data[i-1]) {
+			return false
+		}
+	}
+	return true
+}
 
-			go func(start, end int) {
-				defer wg.Done()
-				for j := start; j < end; j++ {
-					(*b).data[j] = f((*b).data[j], (*other).data[j])
-				}
-			}(start, end)
+// Compare compares the buffer with other lexicographically (like bytes.Compare),
+// element by element from the first one with compare, which returns a negative
+// number, zero or a positive number like cmp.Compare. It returns -1, 0 or +1,
+// a buffer that is a prefix of the other is the smaller one
+func (b *Buffer[T]) Compare(other *Buffer[T], compare func(a, b T) int) int {
+	for i := uint64(0); i < min(b.size, other.size); i++ {
+		if c := compare(b.data[i], other.data[i]); c != 0 {
+			return cmp.Compare(c, 0)
 		}
-		wg.Wait()
-	} else {
-		// Single-threaded blitting for small buffers
-		for i := uint64(0); i < maxElements; i++ {
-			(*b).data[start+i] = f((*b).data[start+i], (*other).data[start+i])
+	}
+	return cmp.Compare(b.size, other.size)
+}
+
+// SearchSorted returns the index where value would be inserted in a buffer
+// sorted according to less: the index of the first element greater than value,
+// so after the elements equal to it (the buffer size if there are none). It's
+// a binary search, the result is undefined if the buffer isn't sorted
+func (b *Buffer[T]) SearchSorted(value T, less func(T, T) bool) uint64 {
+	lo, hi := uint64(0), b.size
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if less(value, b.data[mid]) {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return lo
+}
+
+// InsertSorted inserts value in a buffer sorted according to less keeping it
+// sorted (after the elements equal to it, see SearchSorted) and returns its
+// index. It returns ErrOverflow if the buffer is full
+func (b *Buffer[T]) InsertSorted(value T, less func(T, T) bool) (uint64, error) {
+	index := b.SearchSorted(value, less)
+	if err := b.InsertAt(index, value); err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// Unique removes consecutive duplicate elements, keeping the first of each run
+func (b *Buffer[T]) Unique() {
+	if b.IsEmpty() {
+		return
+	}
+	b.compact(func(kept []T, elem T) bool { return !b.equal(kept[len(kept)-1], elem) })
+}
+
+// Deduplicate removes all duplicate elements, keeping the first occurrence of each one
+func (b *Buffer[T]) Deduplicate() {
+	if b.IsEmpty() {
+		return
+	}
+	seen := b.seen()
+	seen.Add(b.data[0])
+	b.compact(func(_ []T, elem T) bool { return seen.Add(elem) })
+}
+
+// compact keeps (in order) the first element of the buffer and all the following
+// elements for which keep(already kept elements, element) is true
+func (b *Buffer[T]) compact(keep func([]T, T) bool) {
+	data := b.data[:b.size]
+	n := 1
+	var removed []common.Removal[T]
+	for i := 1; i < len(data); i++ {
+		if keep(data[:n], data[i]) {
+			data[n] = data[i]
+			n++
+		} else if b.obs.Remove != nil {
+			removed = append(removed, common.Removal[T]{Index: uint64(n), Value: data[i]})
 		}
 	}
 
+	// Clear the removed elements so they can be garbage collected
+	var zero T
+	for i := n; i < len(data); i++ {
+		data[i] = zero
+	}
+	b.data = data[:n]
+	b.size = uint64(n)
+	b.obs.RemovedAll(removed)
+}
+
+// Shuffle randomly reorders the elements of the buffer using rng (if rng is
+// nil the global math/rand source is used)
+func (b *Buffer[T]) Shuffle(rng *rand.Rand) {
+	if b.IsEmpty() {
+		return
+	}
+	common.Shuffle(rng, b.data[:b.size])
+}
+
+// Sample returns a new buffer with n elements chosen at random (without
+// replacement) using rng (if rng is nil the global math/rand source is used).
+// If n is greater than the size of the buffer, all the elements are returned
+// in random order
+func (b *Buffer[T]) Sample(n uint64, rng *rand.Rand) *Buffer[T] {
+	if n > b.Size() {
+		n = b.Size()
+	}
+	items := b.ToSlice()
+	common.Sample(rng, items, int(n))
+
+	result := b.newEmpty()
+	result.data = items[:n:n]
+	result.size = n
+	return result
+}
+
+// Interleave returns a new buffer alternating the elements of the buffer with
+// the elements of another buffer (b0, o0, b1, o1, ...). If the two buffers have
+// different sizes, the remaining elements of the longer one are appended at the end
+func (b *Buffer[T]) Interleave(other *Buffer[T]) *Buffer[T] {
+	result := b.newEmpty()
+	n, m := b.Size(), other.Size()
+	result.data = make([]T, 0, n+m)
+	for i := uint64(0); i < n || i < m; i++ {
+		if i < n {
+			result.data = append(result.data, b.data[i])
+		}
+		if i < m {
+			result.data = append(result.data, other.data[i])
+		}
+	}
+	result.size = n + m
+	return result
+}
+
+// Zip returns a new buffer of pairs combining the elements of a and b at the
+// same index. The size of the result is the size of the smaller buffer
+func Zip[T, U comparable](a *Buffer[T], b *Buffer[U]) *Buffer[common.Pair[T, U]] {
+	n := a.Size()
+	if b.Size() < n {
+		n = b.Size()
+	}
+
+	result := New[common.Pair[T, U]]()
+	if n == 0 {
+		return result
+	}
+	result.data = make([]common.Pair[T, U], n)
+	for i := uint64(0); i < n; i++ {
+		result.data[i] = common.NewPair(a.data[i], b.data[i])
+	}
+	result.size = n
+	return result
+}
+
+// Unzip splits a buffer of pairs into two buffers, one with the first values
+// and one with the second values
+func Unzip[T, U comparable](b *Buffer[common.Pair[T, U]]) (*Buffer[T], *Buffer[U]) {
+	first, second := New[T](), New[U]()
+	n := b.Size()
+	if n == 0 {
+		return first, second
+	}
+	first.data = make([]T, n)
+	second.data = make([]U, n)
+	for i := uint64(0); i < n; i++ {
+		first.data[i], second.data[i] = b.data[i].Values()
+	}
+	first.size, second.size = n, n
+	return first, second
+}
+
+// Join returns a new buffer with the elements of all the given buffers in
+// order (nil buffers are skipped), it's the inverse of Chunk. The new buffer
+// compares its elements like the first buffer (with == if all of them are nil)
+func Join[T any](parts ...*Buffer[T]) *Buffer[T] {
+	var result *Buffer[T]
+	n := uint64(0)
+	for _, part := range parts {
+		if part != nil {
+			if result == nil {
+				result = part.newEmpty()
+			}
+			n += part.Size()
+		}
+	}
+
+	if result == nil {
+		return NewWithComparator[T](nil)
+	}
+	if n == 0 {
+		return result
+	}
+	result.data = make([]T, 0, n)
+	for _, part := range parts {
+		if part != nil {
+			result.data = append(result.data, part.UnsafeSlice()...)
+		}
+	}
+	result.size = n
+	return result
+}
+
+// Flatten returns a new buffer with the elements of all the buffers in b in
+// order, compared like the ones of the first buffer (see Join)
+func Flatten[T any](b *Buffer[*Buffer[T]]) *Buffer[T] {
+	return Join(b.UnsafeSlice()...)
+}
+
+// SelectNth rearranges the buffer so that the element at index n is the one
+// that would be there if the buffer was sorted according to the given function,
+// all the elements before it are not greater and all the elements after it are
+// not smaller (quickselect, O(n) on average). It returns the selected element
+func (b *Buffer[T]) SelectNth(n uint64, less func(T, T) bool) (T, error) {
+	if n >= b.Size() {
+		var zero T
+		return zero, common.NewIndexError(ErrOutOfBounds, n, b.Size())
+	}
+	data := b.data[:b.size]
+	quickSelect(data, int(n), less)
+	return data[n], nil
+}
+
+// PartialSort rearranges the buffer so that its first k elements are the k
+// smallest ones sorted according to the given function, the remaining elements
+// are left in unspecified order (O(n + k log k) on average)
+func (b *Buffer[T]) PartialSort(k uint64, less func(T, T) bool) error {
+	if k > b.Size() {
+		return ErrOutOfBounds
+	}
+	if k == 0 {
+		return nil
+	}
+	data := b.data[:b.size]
+	if k < b.size {
+		quickSelect(data, int(k-1), less)
+	}
+	head := data[:k]
+	sort.Slice(head, func(i, j int) bool { return less(head[i], head[j]) })
 	return nil
 }
+
+// quickSelect moves the n-th smallest element of data to index n, partitioning
+// the rest of data around it
+func quickSelect[T any](data []T, n int, less func(T, T) bool) {
+	lo, hi := 0, len(data)-1
+	for lo < hi {
+		// Median of three to avoid the worst case on sorted input
+		mid := lo + (hi-lo)/2
+		if less(data[mid], data[lo]) {
+			data[mid], data[lo] = data[lo], data[mid]
+		}
+		if less(data[hi], data[lo]) {
+			data[hi], data[lo] = data[lo], data[hi]
+		}
+		if less(data[hi], data[mid]) {
+			data[hi], data[mid] = data[mid], data[hi]
+		}
+		pivot := data[mid]
+
+		// Three-way partition: [lo, lt) < pivot, [lt, gt] == pivot, (gt, hi] > pivot
+		lt, i, gt := lo, lo, hi
+		for i <= gt {
+			switch {
+			case less(data[i], pivot):
+				data[lt], data[i] = data[i], data[lt]
+				lt++
+				i++
+			case less(pivot, data[i]):
+				data[i], data[gt] = data[gt], data[i]
+				gt--
+			default:
+				i++
+			}
+		}
+
+		switch {
+		case n < lt:
+			hi = lt - 1
+		case n > gt:
+			lo = gt + 1
+		default:
+			return
+		}
+	}
+}
+
+// Min returns the smallest element of the buffer according to less (the first
+// one if there are several), or ErrEmpty if the buffer is empty
+func Min[T any](b *Buffer[T], less func(T, T) bool) (T, error) {
+	if b.IsEmpty() {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+
+	result := b.data[0]
+	for i := uint64(1); i < b.size; i++ {
+		if less(b.data[i], result) {
+			result = b.data[i]
+		}
+	}
+	return result, nil
+}
+
+// Max returns the largest element of the buffer according to less (the first
+// one if there are several), or ErrEmpty if the buffer is empty
+func Max[T any](b *Buffer[T], less func(T, T) bool) (T, error) {
+	return Min(b, func(x, y T) bool { return less(y, x) })
+}
+
+// MinOrdered returns the smallest element of a buffer of an ordered type
+func MinOrdered[T cmp.Ordered](b *Buffer[T]) (T, error) {
+	return Min(b, cmp.Less[T])
+}
+
+// MaxOrdered returns the largest element of a buffer of an ordered type
+func MaxOrdered[T cmp.Ordered](b *Buffer[T]) (T, error) {
+	return Max(b, cmp.Less[T])
+}
+
+// Sum returns the sum of all the elements of a numeric buffer (0 if it's empty)
+// (the numeric subpackage has faster bulk versions of Sum, Blit and Map for
+// numbers)
+func Sum[T common.Number](b *Buffer[T]) T {
+	return ReduceInto(b, T(0), func(acc, v T) T { return acc + v })
+}
+
+// Average returns the arithmetic mean of the elements of a numeric buffer
+// (computed in float64), or ErrEmpty if the buffer is empty
+func Average[T common.Number](b *Buffer[T]) (float64, error) {
+	if b.IsEmpty() {
+		return 0, ErrEmpty
+	}
+	sum := ReduceInto(b, 0.0, func(acc float64, v T) float64 { return acc + float64(v) })
+	return sum / float64(b.size), nil
+}
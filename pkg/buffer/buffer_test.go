@@ -16,12 +16,25 @@
 package buffer_test
 
 import (
+	"cmp"
+	"context"
+	"encoding"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
 	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	buffer "github.com/pzaino/gods/pkg/buffer"
+	common "github.com/pzaino/gods/pkg/common"
 )
 
 const (
@@ -218,6 +231,12 @@ func TestSetCapacity(t *testing.T) {
 	if b.Capacity() != 5 {
 		t.Errorf("Expected capacity 5, got %v", b.Capacity())
 	}
+	// Lowering the capacity below the size removes the last elements
+	_ = b.PushN(1, 2, 3, 4)
+	b.SetCapacity(2)
+	if !slices.Equal(b.ToSlice(), []int{1, 2}) {
+		t.Errorf("Expected [1 2], got %v", b.ToSlice())
+	}
 }
 
 // TestEquals tests the Equals method
@@ -319,6 +338,14 @@ func TestMerge(t *testing.T) {
 	if !b2.IsEmpty() {
 		t.Error("Merged buffer should be empty")
 	}
+
+	// Only the elements that fit are moved to a bounded buffer
+	b3 := createBufferWithElements(t, []int{4, 5, 6}, 0)
+	b1.SetCapacity(4)
+	b1.Merge(b3)
+	if !slices.Equal(b1.ToSlice(), []int{1, 2, 3, 4}) || !slices.Equal(b3.ToSlice(), []int{5, 6}) {
+		t.Errorf("Expected [1 2 3 4] and [5 6], got %v and %v", b1.ToSlice(), b3.ToSlice())
+	}
 }
 
 // TestPopN tests the PopN method
@@ -794,6 +821,54 @@ func TestLastIndexOf(t *testing.T) {
 	}
 }
 
+// TestReverseScans tests FindLast, FindLastIndex and LastIndexOf on the edge cases of the reverse scan
+func TestReverseScans(t *testing.T) {
+	tests := []struct {
+		name     string
+		elements []int
+		value    int
+		index    uint64
+		err      error
+	}{
+		{"empty", nil, 1, 0, buffer.ErrEmpty},
+		{"single match", []int{7}, 7, 0, nil},
+		{"single no match", []int{7}, 1, 0, buffer.ErrNotFound},
+		{"match at index 0", []int{1, 2, 3}, 1, 0, nil},
+		{"all matching", []int{5, 5, 5, 5}, 5, 3, nil},
+		{"no match", []int{1, 2, 3}, 4, 0, buffer.ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := createBufferWithElements(t, tt.elements, 10)
+			match := func(x int) bool { return x == tt.value }
+
+			index, err := b.LastIndexOf(tt.value)
+			if !errors.Is(err, tt.err) || (err == nil && index != tt.index) {
+				t.Errorf("LastIndexOf: expected %d (%v), got %d (%v)", tt.index, tt.err, index, err)
+			}
+			index, err = b.FindLastIndex(match)
+			if !errors.Is(err, tt.err) || (err == nil && index != tt.index) {
+				t.Errorf("FindLastIndex: expected %d (%v), got %d (%v)", tt.index, tt.err, index, err)
+			}
+			value, err := b.FindLast(match)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("FindLast: expected %v, got %v", tt.err, err)
+			}
+			if err == nil && (value == nil || *value != tt.value) {
+				t.Errorf("FindLast: expected %d, got %v", tt.value, value)
+			}
+		})
+	}
+
+	// After a Clear the scans must report an empty buffer, not wrap around
+	b := createBufferWithElements(t, []int{1, 2, 3}, 10)
+	b.Clear()
+	if _, err := b.FindLastIndex(func(int) bool { return true }); !errors.Is(err, buffer.ErrEmpty) {
+		t.Errorf(errExpectedErr, buffer.ErrEmpty, err)
+	}
+}
+
 // TestBlit tests the Blitter method
 func TestBlit(t *testing.T) {
 	b1 := createBufferWithElements(t, []int{1, 2, 3}, 3)
@@ -916,6 +991,47 @@ func TestBlitFrom(t *testing.T) {
 	}
 }
 
+// TestBlitParallelism tests BlitRange in parallel on a range that doesn't start
+// at 0 and goes past the end of the other buffer
+func TestBlitParallelism(t *testing.T) {
+	b := buffer.New[int]()
+	if workers, threshold := b.BlitParallelism(); workers != runtime.GOMAXPROCS(0) || threshold != 1024 {
+		t.Errorf("expected the default parallelism, got %d workers and threshold %d", workers, threshold)
+	}
+
+	other := buffer.New[int]()
+	for i := 0; i < 100; i++ {
+		_ = b.Append(i)
+		if i < 70 {
+			_ = other.Append(1000)
+		}
+	}
+	for _, workers := range []int{1, 3, 8, 200} {
+		c := b.Copy()
+		c.SetBlitParallelism(workers, 1)
+		if w, threshold := c.BlitParallelism(); w != workers || threshold != 1 {
+			t.Errorf("expected %d workers and threshold 1, got %d and %d", workers, w, threshold)
+		}
+		if err := c.BlitRange(10, 90, other, func(a, b int) int { return a + b }); err != nil {
+			t.Fatalf(errUnexpectedErr, err)
+		}
+		for i, v := range c.Values() {
+			expected := i
+			if i >= 10 && i < 70 {
+				expected += 1000
+			}
+			if v != expected {
+				t.Errorf("%d workers: expected %d at %d, got %d", workers, expected, i, v)
+			}
+		}
+	}
+
+	b.SetBlitParallelism(0, 0)
+	if workers, threshold := b.BlitParallelism(); workers != runtime.GOMAXPROCS(0) || threshold != 1024 {
+		t.Errorf("expected the default parallelism, got %d workers and threshold %d", workers, threshold)
+	}
+}
+
 // TestRotateLeft tests the RotateLeft method
 func TestRotateLeft(t *testing.T) {
 	b := createBufferWithElements(t, []int{1, 2, 3, 4, 5}, 5)
@@ -1113,6 +1229,63 @@ func TestConfinedForFrom(t *testing.T) {
 	}
 }
 
+// TestParallelForRange tests the ParallelForEach and ParallelForRange methods
+func TestParallelForRange(t *testing.T) {
+	b := buffer.New[int]()
+	if err := b.ParallelForEach(context.Background(), 2, func(*int) error { return nil }); !errors.Is(err, buffer.ErrEmpty) {
+		t.Errorf(errExpectedErr, buffer.ErrEmpty, err)
+	}
+	for i := 0; i < 1000; i++ {
+		_ = b.Append(i)
+	}
+
+	for _, workers := range []int{0, 1, 3, 2000} {
+		c := b.Copy()
+		if err := c.ParallelForRange(context.Background(), 100, 900, workers, func(v *int) error {
+			*v = -*v
+			return nil
+		}); err != nil {
+			t.Fatalf(errUnexpectedErr, err)
+		}
+		for i, v := range c.Values() {
+			expected := i
+			if i >= 100 && i < 900 {
+				expected = -i
+			}
+			if v != expected {
+				t.Fatalf("%d workers: expected %d at %d, got %d", workers, expected, i, v)
+			}
+		}
+	}
+
+	// The first error stops all the goroutines
+	errStop := errors.New("stop")
+	err := b.ParallelForEach(context.Background(), 4, func(v *int) error {
+		if *v == 10 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf(errExpectedErr, errStop, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls atomic.Int32
+	err = b.ParallelForEach(ctx, 4, func(*int) error {
+		calls.Add(1)
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls.Load() != 0 {
+		t.Errorf("expected %v and no calls, got %v and %d calls", context.Canceled, err, calls.Load())
+	}
+
+	if err := b.ParallelForRange(context.Background(), 10, 1001, 2, func(*int) error { return nil }); !errors.Is(err, buffer.ErrInvalid) {
+		t.Errorf(errExpectedErr, buffer.ErrInvalid, err)
+	}
+}
+
 // TestDestroy tests the Destroy method
 func TestDestroy(t *testing.T) {
 	b := createBufferWithElements(t, []int{1, 2, 3}, 3)
@@ -1167,3 +1340,1278 @@ func TestNewWithSizeAndCapacity(t *testing.T) {
 		t.Errorf("Expected capacity 10, got %v", b.Capacity())
 	}
 }
+
+type sortItem struct {
+	key   int
+	order int
+}
+
+func TestSort(t *testing.T) {
+	b := buffer.New[int]()
+	b.Sort(func(a, b int) bool { return a < b })
+	if !b.IsSorted(func(a, b int) bool { return a < b }) {
+		t.Errorf("expected an empty buffer to be sorted")
+	}
+
+	_ = b.PushN(5, 2, 4, 1, 3)
+	less := func(a, b int) bool { return a < b }
+	if b.IsSorted(less) {
+		t.Errorf("expected buffer not to be sorted")
+	}
+	b.Sort(less)
+	expected := []int{1, 2, 3, 4, 5}
+	if !reflect.DeepEqual(b.ToSlice(), expected) {
+		t.Errorf(errExpectedValue, expected, b.ToSlice())
+	}
+	if !b.IsSorted(less) {
+		t.Errorf("expected buffer to be sorted")
+	}
+}
+
+func TestSortStable(t *testing.T) {
+	b := buffer.New[sortItem]()
+	_ = b.PushN(sortItem{2, 0}, sortItem{1, 1}, sortItem{2, 2}, sortItem{1, 3})
+	less := func(a, b sortItem) bool { return a.key < b.key }
+	b.SortStable(less)
+
+	expected := []sortItem{{1, 1}, {1, 3}, {2, 0}, {2, 2}}
+	if !reflect.DeepEqual(b.ToSlice(), expected) {
+		t.Errorf(errExpectedValue, expected, b.ToSlice())
+	}
+	if !b.IsSorted(less) {
+		t.Errorf("expected buffer to be sorted")
+	}
+}
+
+// TestInsertSorted tests the SearchSorted and InsertSorted methods
+func TestInsertSorted(t *testing.T) {
+	type item struct{ key, seq int }
+	less := func(a, b item) bool { return a.key < b.key }
+	b := buffer.New[item]()
+	if i := b.SearchSorted(item{key: 1}, less); i != 0 {
+		t.Errorf("expected 0 in an empty buffer, got %d", i)
+	}
+
+	rnd := rand.New(rand.NewSource(7))
+	for seq := 0; seq < 200; seq++ {
+		value := item{rnd.Intn(20), seq}
+		expected := b.SearchSorted(value, less)
+		index, err := b.InsertSorted(value, less)
+		if err != nil || index != expected {
+			t.Fatalf("expected %v at %d, got %d (%v)", value, expected, index, err)
+		}
+		if v, _ := b.Get(index); v != value {
+			t.Fatalf("expected %v at %d, got %v", value, index, v)
+		}
+	}
+
+	// Equal keys keep the insertion order
+	values := b.Values()
+	if !b.IsSorted(less) || !slices.IsSortedFunc(values, func(a, b item) int {
+		return cmp.Or(cmp.Compare(a.key, b.key), cmp.Compare(a.seq, b.seq))
+	}) {
+		t.Errorf("expected a stable sorted buffer, got %v", values)
+	}
+	if i := b.SearchSorted(item{key: 100}, less); i != b.Size() {
+		t.Errorf("expected %d, got %d", b.Size(), i)
+	}
+
+	full := buffer.NewWithCapacity[int](2)
+	_, _ = full.InsertSorted(2, func(a, b int) bool { return a < b })
+	_, _ = full.InsertSorted(1, func(a, b int) bool { return a < b })
+	if _, err := full.InsertSorted(3, func(a, b int) bool { return a < b }); !errors.Is(err, buffer.ErrOverflow) {
+		t.Errorf(errExpectedErr, buffer.ErrOverflow, err)
+	}
+	if !slices.Equal(full.Values(), []int{1, 2}) {
+		t.Errorf("expected [1 2], got %v", full.Values())
+	}
+}
+
+func TestZipUnzip(t *testing.T) {
+	a := buffer.New[int]()
+	_ = a.PushN(1, 2, 3)
+	b := buffer.New[string]()
+	_ = b.PushN("a", "b")
+
+	zipped := buffer.Zip(a, b)
+	expected := []common.Pair[int, string]{{First: 1, Second: "a"}, {First: 2, Second: "b"}}
+	if !reflect.DeepEqual(zipped.ToSlice(), expected) {
+		t.Errorf(errExpectedValue, expected, zipped.ToSlice())
+	}
+
+	first, second := buffer.Unzip(zipped)
+	if !reflect.DeepEqual(first.ToSlice(), []int{1, 2}) {
+		t.Errorf(errExpectedValue, []int{1, 2}, first.ToSlice())
+	}
+	if !reflect.DeepEqual(second.ToSlice(), []string{"a", "b"}) {
+		t.Errorf(errExpectedValue, []string{"a", "b"}, second.ToSlice())
+	}
+
+	empty := buffer.Zip(a, buffer.New[string]())
+	if !empty.IsEmpty() {
+		t.Errorf(errExpectedLength, 0, empty.Size())
+	}
+	first, second = buffer.Unzip(empty)
+	if !first.IsEmpty() || !second.IsEmpty() {
+		t.Errorf("expected empty buffers")
+	}
+}
+
+func TestInterleave(t *testing.T) {
+	a := buffer.New[int]()
+	_ = a.PushN(1, 3, 5, 7)
+	b := buffer.New[int]()
+	_ = b.PushN(2, 4)
+
+	result := a.Interleave(b)
+	expected := []int{1, 2, 3, 4, 5, 7}
+	if !reflect.DeepEqual(result.ToSlice(), expected) {
+		t.Errorf(errExpectedValue, expected, result.ToSlice())
+	}
+	if result.Size() != 6 {
+		t.Errorf(errExpectedLength, 6, result.Size())
+	}
+
+	result = buffer.New[int]().Interleave(b)
+	if !reflect.DeepEqual(result.ToSlice(), []int{2, 4}) {
+		t.Errorf(errExpectedValue, []int{2, 4}, result.ToSlice())
+	}
+}
+
+func TestSelectNth(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	b := buffer.New[int]()
+	if _, err := b.SelectNth(0, less); err == nil {
+		t.Errorf("expected an error selecting from an empty buffer")
+	}
+
+	values := []int{9, 3, 7, 3, 1, 8, 2, 7, 5, 0, 3}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+
+	for n := range values {
+		b := buffer.New[int]()
+		_ = b.PushN(values...)
+		v, err := b.SelectNth(uint64(n), less)
+		if err != nil {
+			t.Fatalf(errUnexpectedErr, err)
+		}
+		if v != sorted[n] {
+			t.Errorf(errExpectedValue, sorted[n], v)
+		}
+		data := b.ToSlice()
+		for i := 0; i < n; i++ {
+			if data[i] > v {
+				t.Errorf("element %d (%d) should not be greater than %d", i, data[i], v)
+			}
+		}
+		for i := n + 1; i < len(data); i++ {
+			if data[i] < v {
+				t.Errorf("element %d (%d) should not be smaller than %d", i, data[i], v)
+			}
+		}
+		checkPermutation(t, data, sorted)
+	}
+
+	if _, err := b.SelectNth(uint64(len(values)), less); err == nil {
+		t.Errorf("expected an error selecting out of bounds")
+	}
+}
+
+func TestPartialSort(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	values := make([]int, 1000)
+	for i := range values {
+		values[i] = (i * 7919) % 1000
+	}
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+
+	for _, k := range []uint64{0, 1, 10, 999, 1000} {
+		b := buffer.New[int]()
+		_ = b.PushN(values...)
+		if err := b.PartialSort(k, less); err != nil {
+			t.Fatalf(errUnexpectedErr, err)
+		}
+		data := b.ToSlice()
+		if !reflect.DeepEqual(data[:k], sorted[:k]) {
+			t.Errorf(errExpectedValue, sorted[:k], data[:k])
+		}
+		checkPermutation(t, data, sorted)
+	}
+
+	b := buffer.New[int]()
+	_ = b.PushN(1, 2)
+	if err := b.PartialSort(3, less); err == nil {
+		t.Errorf("expected an error sorting more elements than the buffer size")
+	}
+}
+
+func checkPermutation(t *testing.T, data, sorted []int) {
+	t.Helper()
+	got := append([]int(nil), data...)
+	sort.Ints(got)
+	if !reflect.DeepEqual(got, sorted) {
+		t.Errorf("expected the buffer to be a permutation of the original values")
+	}
+}
+
+func benchmarkValues(n int) []int {
+	r := rand.New(rand.NewSource(42))
+	values := make([]int, n)
+	for i := range values {
+		values[i] = r.Int()
+	}
+	return values
+}
+
+func BenchmarkPartialSort(b *testing.B) {
+	values := benchmarkValues(1_000_000)
+	less := func(a, b int) bool { return a < b }
+	buf := buffer.New[int]()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		buf.Clear()
+		_ = buf.PushN(values...)
+		b.StartTimer()
+		_ = buf.PartialSort(100, less)
+	}
+}
+
+func BenchmarkSelectNth(b *testing.B) {
+	values := benchmarkValues(1_000_000)
+	less := func(a, b int) bool { return a < b }
+	buf := buffer.New[int]()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		buf.Clear()
+		_ = buf.PushN(values...)
+		b.StartTimer()
+		_, _ = buf.SelectNth(100, less)
+	}
+}
+
+// BenchmarkSortForTopK is the full sort baseline for BenchmarkPartialSort
+func BenchmarkSortForTopK(b *testing.B) {
+	values := benchmarkValues(1_000_000)
+	less := func(a, b int) bool { return a < b }
+	buf := buffer.New[int]()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		buf.Clear()
+		_ = buf.PushN(values...)
+		b.StartTimer()
+		buf.Sort(less)
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	b := buffer.NewWithCapacity[int](1)
+	_ = b.Append(1)
+
+	err := b.Append(2)
+	if !errors.Is(err, buffer.ErrOverflow) {
+		t.Errorf(errExpectedValue, buffer.ErrOverflow, err)
+	}
+	// The deprecated messages are still the same
+	if err.Error() != buffer.ErrBufferOverflow {
+		t.Errorf(errExpectedValue, buffer.ErrBufferOverflow, err.Error())
+	}
+
+	err = b.Swap(0, 5)
+	if !errors.Is(err, buffer.ErrOutOfBounds) {
+		t.Errorf(errExpectedValue, buffer.ErrOutOfBounds, err)
+	}
+	var indexErr *common.IndexError
+	if !errors.As(err, &indexErr) {
+		t.Fatalf("expected an IndexError, got %T", err)
+	}
+	if indexErr.Index != 5 || indexErr.Size != 1 {
+		t.Errorf("expected index 5 and size 1, got %d and %d", indexErr.Index, indexErr.Size)
+	}
+
+	if _, err := b.Find(42); !errors.Is(err, buffer.ErrNotFound) {
+		t.Errorf(errExpectedValue, buffer.ErrNotFound, err)
+	}
+}
+
+func TestToSliceReturnsCopy(t *testing.T) {
+	b := buffer.NewWithCapacity[int](3)
+	_ = b.PushN(1, 2, 3)
+
+	values := b.ToSlice()
+	values[0] = 42
+	values = append(values, 4)
+	if v, _ := b.Get(0); v != 1 {
+		t.Errorf(errExpectedValue, 1, v)
+	}
+	if b.Size() != 3 {
+		t.Errorf(errExpectedLength, 3, b.Size())
+	}
+
+	values = b.Values()
+	values[1] = 42
+	if v, _ := b.Get(1); v != 2 {
+		t.Errorf(errExpectedValue, 2, v)
+	}
+	if len(values) != 3 {
+		t.Errorf(errExpectedLength, 3, len(values))
+	}
+}
+
+func TestUnsafeSlice(t *testing.T) {
+	b := buffer.New[int]()
+	if b.UnsafeSlice() != nil {
+		t.Errorf("expected nil slice for an empty buffer")
+	}
+
+	_ = b.PushN(1, 2, 3)
+	raw := b.UnsafeSlice()
+	if len(raw) != 3 {
+		t.Fatalf(errExpectedLength, 3, len(raw))
+	}
+	raw[0] = 42
+	if v, _ := b.Get(0); v != 42 {
+		t.Errorf(errExpectedValue, 42, v)
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	b := buffer.NewWithComparator(slices.Equal[[]int])
+	for _, elem := range [][]int{{1, 2}, {3}, {4, 5, 6}} {
+		if err := b.Append(elem); err != nil {
+			t.Errorf(errUnexpectedErr, err)
+		}
+	}
+
+	if !b.Contains([]int{3}) {
+		t.Error("expected buffer to contain [3]")
+	}
+	if b.Contains([]int{2, 1}) {
+		t.Error("expected buffer not to contain [2 1]")
+	}
+	index, err := b.Find([]int{4, 5, 6})
+	if err != nil {
+		t.Errorf(errUnexpectedErr, err)
+	}
+	if index != 2 {
+		t.Errorf(errExpectedValue, 2, index)
+	}
+	if _, err := b.LastIndexOf([]int{7}); !errors.Is(err, buffer.ErrNotFound) {
+		t.Errorf(errExpectedErr, buffer.ErrNotFound, err)
+	}
+
+	// Copies keep the comparator
+	c := b.Copy()
+	if !b.Equals(c) || !c.Contains([]int{1, 2}) {
+		t.Error("expected the copy to be equal to the original buffer")
+	}
+}
+
+func TestNewWithHasher(t *testing.T) {
+	hasher := common.NewHasher(func(v []int) uint64 { return uint64(len(v)) }, slices.Equal[[]int])
+	l := buffer.NewWithHasher(hasher)
+	for _, v := range [][]int{{1}, {2, 3}, {1}, {4}, {2, 3}} {
+		_ = l.Append(v)
+	}
+
+	if !l.Contains([]int{4}) || l.Contains([]int{3, 2}) {
+		t.Error("expected the hasher equality to be used")
+	}
+	if !l.ContainsAll([]int{4}, []int{1}, []int{2, 3}) || l.ContainsAll([]int{1}, []int{5}) {
+		t.Error("expected ContainsAll to use the hasher")
+	}
+	if !l.ContainsAny([]int{5}, []int{2, 3}) || l.ContainsAny([]int{5}, []int{3, 2}) {
+		t.Error("expected ContainsAny to use the hasher")
+	}
+
+	c := l.Copy()
+	c.Deduplicate()
+	if c.Size() != 3 || l.Size() != 5 {
+		t.Errorf("expected 3 values left in the copy, got %d (original %d)", c.Size(), l.Size())
+	}
+	if !buffer.Features().Has(common.FeatureHasher) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureHasher, buffer.Features())
+	}
+}
+
+func TestReduceInto(t *testing.T) {
+	b := createBufferWithElements(t, []int{1, 2, 3}, 10)
+	got := buffer.ReduceInto(b, []int{0}, func(acc []int, v int) []int {
+		return append(acc, v*10)
+	})
+	if !slices.Equal(got, []int{0, 10, 20, 30}) {
+		t.Errorf(errExpectedValue, []int{0, 10, 20, 30}, got)
+	}
+
+	count := buffer.ReduceInto(buffer.New[int](), 42, func(acc int, _ int) int { return acc + 1 })
+	if count != 42 {
+		t.Errorf(errExpectedValue, 42, count)
+	}
+}
+
+func TestMapTo(t *testing.T) {
+	type user struct {
+		id   int
+		tags []string
+	}
+	src := buffer.NewWithComparator(func(a, b user) bool { return a.id == b.id })
+	for _, u := range []user{{1, []string{"a"}}, {2, nil}, {3, []string{"b", "c"}}} {
+		_ = src.Append(u)
+	}
+
+	ids := buffer.MapTo(src, func(u user) int { return u.id })
+	if !slices.Equal(ids.ToSlice(), []int{1, 2, 3}) || !ids.Contains(2) {
+		t.Errorf("expected the ids [1 2 3], got %v", ids.ToSlice())
+	}
+
+	parity := buffer.MapTo(src, func(u user) int { return u.id % 2 })
+	parity.Deduplicate()
+	if !slices.Equal(parity.ToSlice(), []int{1, 0}) {
+		t.Errorf("expected the deduplicated parities [1 0], got %v", parity.ToSlice())
+	}
+
+	tags := buffer.MapToWithComparator(src, func(u user) []string { return u.tags }, slices.Equal[[]string])
+	if tags.Size() != 3 || !tags.ContainsAll([]string{"b", "c"}) || tags.ContainsAll([]string{"c", "b"}) {
+		t.Errorf("expected the tags to be compared with the given function")
+	}
+
+	if empty := buffer.MapTo(buffer.New[int](), strconv.Itoa); !empty.IsEmpty() {
+		t.Errorf("expected an empty result, got %d values", empty.Size())
+	}
+}
+
+func TestShuffleAndSample(t *testing.T) {
+	elements := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	b := createBufferWithElements(t, elements, 0)
+
+	b.Shuffle(rand.New(rand.NewSource(1)))
+	shuffled := b.ToSlice()
+	sorted := slices.Clone(shuffled)
+	slices.Sort(sorted)
+	if !slices.Equal(sorted, elements) {
+		t.Errorf("expected a permutation of %v, got %v", elements, shuffled)
+	}
+
+	// The same seed produces the same order
+	other := createBufferWithElements(t, elements, 0)
+	other.Shuffle(rand.New(rand.NewSource(1)))
+	if !b.Equals(other) {
+		t.Errorf("expected the same order for the same seed, got %v and %v", shuffled, other.ToSlice())
+	}
+
+	sample := b.Sample(4, nil)
+	if sample.Size() != 4 {
+		t.Fatalf(errExpectedLength, 4, sample.Size())
+	}
+	seen := make(map[int]bool)
+	for _, v := range sample.ToSlice() {
+		if seen[v] || !b.Contains(v) {
+			t.Errorf("unexpected sampled value %v in %v", v, sample.ToSlice())
+		}
+		seen[v] = true
+	}
+	if b.Size() != uint64(len(elements)) {
+		t.Errorf("expected Sample not to modify the buffer, got size %d", b.Size())
+	}
+
+	if all := b.Sample(100, nil); all.Size() != b.Size() {
+		t.Errorf(errExpectedLength, b.Size(), all.Size())
+	}
+	if empty := buffer.New[int]().Sample(3, nil); !empty.IsEmpty() {
+		t.Errorf(errExpectedLength, 0, empty.Size())
+	}
+}
+
+func TestUniqueAndDeduplicate(t *testing.T) {
+	b := createBufferWithElements(t, []int{1, 1, 2, 2, 2, 3, 1, 1}, 0)
+	b.Unique()
+	if !slices.Equal(b.ToSlice(), []int{1, 2, 3, 1}) {
+		t.Errorf(errExpectedValue, []int{1, 2, 3, 1}, b.ToSlice())
+	}
+
+	b = createBufferWithElements(t, []int{3, 1, 3, 2, 1, 4, 2}, 0)
+	b.Deduplicate()
+	if !slices.Equal(b.ToSlice(), []int{3, 1, 2, 4}) {
+		t.Errorf(errExpectedValue, []int{3, 1, 2, 4}, b.ToSlice())
+	}
+	if b.Size() != 4 {
+		t.Errorf(errExpectedLength, 4, b.Size())
+	}
+
+	// Buffers with a custom comparator fall back to comparing the elements
+	s := buffer.NewWithComparator(slices.Equal[[]int])
+	for _, elem := range [][]int{{1}, {2}, {1}, {2}, {2}} {
+		_ = s.Append(elem)
+	}
+	s.Deduplicate()
+	if s.Size() != 2 {
+		t.Errorf(errExpectedLength, 2, s.Size())
+	}
+
+	empty := buffer.New[int]()
+	empty.Unique()
+	empty.Deduplicate()
+	if !empty.IsEmpty() {
+		t.Error("expected empty buffer to stay empty")
+	}
+}
+
+func TestRangeOperations(t *testing.T) {
+	b := createBufferWithElements(t, []int{0, 1, 2, 3, 4, 5}, 0)
+
+	if err := b.RemoveRange(1, 3); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !slices.Equal(b.ToSlice(), []int{0, 3, 4, 5}) {
+		t.Errorf(errExpectedValue, []int{0, 3, 4, 5}, b.ToSlice())
+	}
+
+	if err := b.InsertSliceAt(1, []int{10, 11, 12}); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !slices.Equal(b.ToSlice(), []int{0, 10, 11, 12, 3, 4, 5}) {
+		t.Errorf(errExpectedValue, []int{0, 10, 11, 12, 3, 4, 5}, b.ToSlice())
+	}
+
+	if err := b.InsertSliceAt(b.Size(), []int{6}); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if err := b.ReplaceRange(1, 4, []int{1, 2}); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !slices.Equal(b.ToSlice(), []int{0, 1, 2, 3, 4, 5, 6}) {
+		t.Errorf(errExpectedValue, []int{0, 1, 2, 3, 4, 5, 6}, b.ToSlice())
+	}
+	if b.Size() != 7 {
+		t.Errorf(errExpectedLength, 7, b.Size())
+	}
+
+	// Empty ranges and invalid bounds
+	if err := b.RemoveRange(2, 2); err != nil {
+		t.Errorf(errUnexpectedErr, err)
+	}
+	if err := b.RemoveRange(3, 2); !errors.Is(err, buffer.ErrOutOfBounds) {
+		t.Errorf(errExpectedErr, buffer.ErrOutOfBounds, err)
+	}
+	if err := b.ReplaceRange(0, 8, nil); !errors.Is(err, buffer.ErrOutOfBounds) {
+		t.Errorf(errExpectedErr, buffer.ErrOutOfBounds, err)
+	}
+	if err := b.InsertSliceAt(8, []int{1}); !errors.Is(err, buffer.ErrOutOfBounds) {
+		t.Errorf(errExpectedErr, buffer.ErrOutOfBounds, err)
+	}
+	if err := buffer.New[int]().RemoveRange(0, 0); !errors.Is(err, buffer.ErrEmpty) {
+		t.Errorf(errExpectedErr, buffer.ErrEmpty, err)
+	}
+	if err := buffer.New[int]().InsertSliceAt(0, []int{1, 2}); err != nil {
+		t.Errorf(errUnexpectedErr, err)
+	}
+}
+
+func TestRangeOperationsCapacity(t *testing.T) {
+	b := createBufferWithElements(t, []int{1, 2, 3}, 4)
+	if err := b.InsertSliceAt(1, []int{7, 8}); !errors.Is(err, buffer.ErrOverflow) {
+		t.Errorf(errExpectedErr, buffer.ErrOverflow, err)
+	}
+	if !slices.Equal(b.ToSlice(), []int{1, 2, 3}) {
+		t.Errorf("expected the buffer to be untouched, got %v", b.ToSlice())
+	}
+	if err := b.ReplaceRange(0, 2, []int{7, 8, 9}); err != nil {
+		t.Errorf(errUnexpectedErr, err)
+	}
+	if !b.IsFull() {
+		t.Errorf("expected the buffer to be full, got size %d", b.Size())
+	}
+}
+
+func BenchmarkInsertSliceAt(b *testing.B) {
+	values := benchmarkValues(100_000)
+	items := benchmarkValues(1_000)
+	buf := buffer.New[int]()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		buf.Clear()
+		_ = buf.PushN(values...)
+		b.StartTimer()
+		_ = buf.InsertSliceAt(50_000, items)
+	}
+}
+
+// BenchmarkInsertAtLoop is the element by element baseline for BenchmarkInsertSliceAt
+func BenchmarkInsertAtLoop(b *testing.B) {
+	values := benchmarkValues(100_000)
+	items := benchmarkValues(1_000)
+	buf := buffer.New[int]()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		buf.Clear()
+		_ = buf.PushN(values...)
+		b.StartTimer()
+		for j, item := range items {
+			_ = buf.InsertAt(uint64(50_000+j), item)
+		}
+	}
+}
+
+func TestString(t *testing.T) {
+	var b fmt.Stringer = createBufferWithElements(t, []int{1, 2, 3}, 0)
+	if s := b.String(); s != "[1 2 3]" {
+		t.Errorf(errExpectedValue, "[1 2 3]", s)
+	}
+	if s := fmt.Sprint(buffer.New[int]()); s != "[]" {
+		t.Errorf(errExpectedValue, "[]", s)
+	}
+	hex := createBufferWithElements(t, []int{10, 255}, 0).StringFunc(func(v int) string {
+		return fmt.Sprintf("%#x", v)
+	})
+	if hex != "[0xa 0xff]" {
+		t.Errorf(errExpectedValue, "[0xa 0xff]", hex)
+	}
+}
+
+func TestAdoptDetach(t *testing.T) {
+	items := []int{1, 2, 3}
+	b := buffer.Adopt(items)
+	if b.Size() != 3 {
+		t.Fatalf(errExpectedLength, 3, b.Size())
+	}
+	items[0] = 10 // not copied
+	if v, _ := b.Get(0); v != 10 {
+		t.Errorf(errExpectedValue, 10, v)
+	}
+
+	detached := b.Detach()
+	if !b.IsEmpty() || len(detached) != 3 || detached[0] != 10 {
+		t.Errorf("expected an empty buffer and 3 detached elements, got %d and %v", b.Size(), detached)
+	}
+	if b.Detach() != nil {
+		t.Error("expected Detach on an empty buffer to return nil")
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	c := createBufferWithElements(t, []int{3, 1, 4, 1, 5}, 0)
+
+	if v, err := buffer.Min(c, func(a, b int) bool { return a < b }); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := buffer.Max(c, func(a, b int) bool { return a < b }); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v, err := buffer.MinOrdered(c); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := buffer.MaxOrdered(c); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v := buffer.Sum(c); v != 14 {
+		t.Errorf("expected sum 14, got %v", v)
+	}
+	if v, err := buffer.Average(c); err != nil || v != 2.8 {
+		t.Errorf("expected average 2.8, got %v (%v)", v, err)
+	}
+
+	e := buffer.New[int]()
+	if _, err := buffer.Min(e, func(a, b int) bool { return a < b }); err != buffer.ErrEmpty {
+		t.Errorf("expected %v, got %v", buffer.ErrEmpty, err)
+	}
+	if _, err := buffer.Average(e); err != buffer.ErrEmpty {
+		t.Errorf("expected %v, got %v", buffer.ErrEmpty, err)
+	}
+	if v := buffer.Sum(e); v != 0 {
+		t.Errorf("expected sum 0, got %v", v)
+	}
+}
+
+// mirror applies the change notifications of a container to a slice
+type mirror struct {
+	t     *testing.T
+	items []int
+}
+
+func (m *mirror) insert(index uint64, v int) {
+	m.items = slices.Insert(m.items, int(index), v)
+}
+
+func (m *mirror) remove(index uint64, v int) {
+	if index >= uint64(len(m.items)) || m.items[index] != v {
+		m.t.Fatalf("unexpected removal of %d at %d from %v", v, index, m.items)
+	}
+	m.items = slices.Delete(m.items, int(index), int(index)+1)
+}
+
+func (m *mirror) clear() {
+	m.items = nil
+}
+
+func TestObservers(t *testing.T) {
+	b := buffer.New[int]()
+	m := &mirror{t: t}
+	b.OnInsert(m.insert)
+	b.OnRemove(m.remove)
+	b.OnClear(m.clear)
+
+	check := func(op string) {
+		t.Helper()
+		if !slices.Equal(m.items, b.UnsafeSlice()) {
+			t.Fatalf("after %s: expected %v, got %v", op, b.UnsafeSlice(), m.items)
+		}
+	}
+
+	_ = b.Append(1)
+	_ = b.PushN(2, 3, 3, 4)
+	check("Append/PushN")
+	_ = b.InsertAt(1, 9)
+	_ = b.InsertSliceAt(2, []int{7, 7})
+	check("InsertAt/InsertSliceAt")
+	_ = b.ReplaceRange(1, 4, []int{5})
+	_ = b.RemoveRange(0, 1)
+	check("ReplaceRange/RemoveRange")
+	_ = b.Put(0, 8)
+	_ = b.Remove(1)
+	check("Put/Remove")
+	_, _ = b.PopN(1)
+	check("PopN")
+	_ = b.PushN(1, 1, 2, 1)
+	b.Unique()
+	check("Unique")
+	b.Deduplicate()
+	check("Deduplicate")
+	b.Filter(func(v int) bool { return v%2 == 0 })
+	check("Filter")
+	_ = b.PushN(5, 6)
+	b.ShiftLeft(1)
+	b.ShiftRight(2)
+	check("ShiftLeft/ShiftRight")
+	other := buffer.New[int]()
+	_ = other.PushN(10, 11)
+	b.Merge(other)
+	check("Merge")
+	b.Clear()
+	check("Clear")
+
+	// Hooks can be removed
+	b.OnInsert(nil)
+	_ = b.Append(1)
+	if len(m.items) != 0 {
+		t.Errorf("expected no notification, got %v", m.items)
+	}
+}
+
+func TestCheckInvariants(t *testing.T) {
+	b := buffer.NewWithCapacity[int](4)
+	if err := b.CheckInvariants(); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	var nilBuffer *buffer.Buffer[int]
+	if err := nilBuffer.CheckInvariants(); !errors.Is(err, buffer.ErrInvalid) {
+		t.Errorf("expected %v, got %v", buffer.ErrInvalid, err)
+	}
+}
+
+// FuzzInvariants applies a sequence of operations (one per input byte) to a
+// bounded buffer and checks its invariants after each of them
+func FuzzInvariants(f *testing.F) {
+	f.Add([]byte{0, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13})
+	f.Add([]byte{0, 16, 32, 48, 64, 80, 96, 112, 1, 9, 13, 4, 11, 12})
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		b := buffer.NewWithCapacity[int](8)
+		for i, op := range ops {
+			v := int(op >> 4)
+			index := uint64(op>>4) % (b.Size() + 1)
+			switch op % 14 {
+			case 0:
+				_ = b.Append(v)
+			case 1:
+				_ = b.InsertAt(index, v)
+			case 2:
+				_ = b.Remove(index)
+			case 3:
+				_ = b.InsertSliceAt(index, []int{v, v})
+			case 4:
+				_ = b.RemoveRange(0, index)
+			case 5:
+				_ = b.ReplaceRange(0, index, []int{v})
+			case 6:
+				_, _ = b.PopN(uint64(v % 3))
+			case 7:
+				_ = b.PushN(v, v+1)
+			case 8:
+				b.ShiftLeft(uint64(v % 3))
+				b.ShiftRight(uint64(v % 2))
+			case 9:
+				b.Filter(func(x int) bool { return x%2 == 0 })
+			case 10:
+				b.Unique()
+				b.Deduplicate()
+			case 11:
+				b.SetCapacity(uint64(v%8) + 1)
+			case 12:
+				other := buffer.New[int]()
+				_ = other.Append(v)
+				b.Merge(other)
+			case 13:
+				b.Reset()
+			}
+			if err := b.CheckInvariants(); err != nil {
+				t.Fatalf("after operation %d (%d): %v", i, op%14, err)
+			}
+		}
+	})
+}
+
+func TestChunkWindows(t *testing.T) {
+	buf := buffer.New[int]()
+	_ = buf.PushN(1, 2, 3, 4, 5)
+
+	chunks := buf.Chunk(2)
+	expected := [][]int{{1, 2}, {3, 4}, {5}}
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+	for i, c := range chunks {
+		if !slices.Equal(c.ToSlice(), expected[i]) {
+			t.Errorf("expected chunk %d to be %v, got %v", i, expected[i], c.ToSlice())
+		}
+	}
+
+	windows := buf.Windows(3)
+	expected = [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}
+	if len(windows) != len(expected) {
+		t.Fatalf("expected %d windows, got %d", len(expected), len(windows))
+	}
+	for i, w := range windows {
+		if !slices.Equal(w.ToSlice(), expected[i]) {
+			t.Errorf("expected window %d to be %v, got %v", i, expected[i], w.ToSlice())
+		}
+	}
+
+	// The chunks are copies
+	chunks[0].Clear()
+	if buf.Size() != 5 {
+		t.Errorf("expected size 5, got %d", buf.Size())
+	}
+	if buf.Chunk(0) != nil || buf.Windows(0) != nil || buf.Windows(6) != nil {
+		t.Error("expected nil for invalid sizes")
+	}
+}
+
+func TestJoinFlatten(t *testing.T) {
+	buf := buffer.New[int]()
+	_ = buf.PushN(1, 2, 3, 4, 5)
+	chunks := buf.Chunk(2)
+
+	joined := buffer.Join(append(chunks, nil)...)
+	if !slices.Equal(joined.ToSlice(), []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected [1 2 3 4 5], got %v", joined.ToSlice())
+	}
+
+	nested := buffer.New[*buffer.Buffer[int]]()
+	_ = nested.PushN(chunks...)
+	flat := buffer.Flatten(nested)
+	if !slices.Equal(flat.ToSlice(), []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected [1 2 3 4 5], got %v", flat.ToSlice())
+	}
+	if !buffer.Join[int]().IsEmpty() {
+		t.Error("expected an empty result joining nothing")
+	}
+
+	// The result compares its values like the first part
+	a, b := buffer.NewWithComparator(slices.Equal[[]int]), buffer.NewWithComparator(slices.Equal[[]int])
+	_ = a.PushN([]int{1}, []int{2})
+	_ = b.PushN([]int{3})
+	if all := buffer.Join(nil, a, b); all.Size() != 3 || !all.Contains([]int{3}) {
+		t.Errorf("expected 3 values compared with slices.Equal, got %v", all.ToSlice())
+	}
+}
+
+func TestFindFirst(t *testing.T) {
+	b := buffer.New[int]()
+	if b.FindFirst(func(int) bool { return true }).IsSome() {
+		t.Error("expected None on an empty buffer")
+	}
+	_ = b.PushN(1, 4, 6, 9)
+	if v := b.FindFirst(func(v int) bool { return v%2 == 0 }).OrElse(-1); v != 4 {
+		t.Errorf("expected 4, got %d", v)
+	}
+	if b.FindFirst(func(v int) bool { return v > 10 }).IsSome() {
+		t.Error("expected None when no element matches")
+	}
+}
+
+func TestPopUpToN(t *testing.T) {
+	b := buffer.New[int]()
+	if values := b.PopUpToN(2); values != nil {
+		t.Errorf("expected nil on an empty buffer, got %v", values)
+	}
+	_ = b.PushN(1, 2, 3)
+	if values := b.PopUpToN(2); !slices.Equal(values, []int{2, 3}) {
+		t.Errorf("expected [2 3], got %v", values)
+	}
+	if values := b.PopUpToN(5); !slices.Equal(values, []int{1}) || !b.IsEmpty() {
+		t.Errorf("expected [1] and an empty buffer, got %v (size %d)", values, b.Size())
+	}
+}
+
+func TestPopNReturnsCopy(t *testing.T) {
+	b := buffer.New[int]()
+	_ = b.PushN(1, 2, 3, 4)
+	values, _ := b.PopN(2)
+	_ = b.PushN(5, 6) // reuses the slots freed by PopN
+	if !slices.Equal(values, []int{3, 4}) {
+		t.Errorf("expected the popped elements to be unchanged, got %v", values)
+	}
+}
+
+func TestIndexed(t *testing.T) {
+	b := buffer.New[int]()
+	_ = b.PushN(10, 20, 30)
+	if err := b.ForEachIndexed(func(i uint64, v *int) error {
+		*v += int(i)
+		return nil
+	}); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !slices.Equal(b.ToSlice(), []int{10, 21, 32}) {
+		t.Errorf("expected [10 21 32], got %v", b.ToSlice())
+	}
+
+	mapped, err := b.MapIndexed(func(i uint64, v int) int { return v * int(i) })
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !slices.Equal(mapped.ToSlice(), []int{0, 21, 64}) {
+		t.Errorf("expected [0 21 64], got %v", mapped.ToSlice())
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := buffer.New[*int]()
+	_ = c.Append(&a)
+	_ = c.Append(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestRemoveIf(t *testing.T) {
+	c := buffer.New[int]()
+	_ = c.PushN(1, 2, 1, 3, 1, 2)
+
+	if n := c.RemoveFirstN(1, 2); n != 2 || !slices.Equal(c.ToSlice(), []int{2, 3, 1, 2}) {
+		t.Errorf("expected to remove 2 elements leaving 2, 3, 1, 2}, removed %d leaving %v", n, c.ToSlice())
+	}
+	if n := c.RemoveAll(2); n != 2 || !slices.Equal(c.ToSlice(), []int{3, 1}) {
+		t.Errorf("expected to remove 2 elements leaving 3, 1}, removed %d leaving %v", n, c.ToSlice())
+	}
+	if n := c.RemoveFirstN(1, 5); n != 1 {
+		t.Errorf("expected to remove 1 element, removed %d", n)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v > 10 }); n != 0 {
+		t.Errorf("expected to remove nothing, removed %d", n)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v == 3 }); n != 1 || c.Size() != 0 {
+		t.Errorf("expected to remove the last element, removed %d leaving %v", n, c.ToSlice())
+	}
+}
+
+func TestContainsAllAny(t *testing.T) {
+	c := createBufferWithElements(t, []int{1, 2, 1, 3}, 10)
+
+	if !c.ContainsAll(3, 1, 1) || !c.ContainsAll() || c.ContainsAll(1, 4) {
+		t.Error("expected ContainsAll to be true only when every element is present")
+	}
+	if !c.ContainsAny(4, 2) || c.ContainsAny(4, 5) || c.ContainsAny() {
+		t.Error("expected ContainsAny to be true only when an element is present")
+	}
+	if c.CountOf(1) != 2 || c.CountOf(3) != 1 || c.CountOf(4) != 0 {
+		t.Errorf("expected counts 2, 1 and 0, got %d, %d and %d", c.CountOf(1), c.CountOf(3), c.CountOf(4))
+	}
+}
+
+// upperCodec stores strings in upper case, to check that the codec is used
+type upperCodec struct{}
+
+func (upperCodec) Encode(v string) ([]byte, error)    { return []byte(strings.ToUpper(v)), nil }
+func (upperCodec) Decode(data []byte) (string, error) { return string(data), nil }
+
+var (
+	_ encoding.BinaryMarshaler   = (*buffer.Buffer[int])(nil)
+	_ encoding.BinaryUnmarshaler = (*buffer.Buffer[int])(nil)
+)
+
+func TestMarshalBinary(t *testing.T) {
+	b := buffer.New[int]()
+	for i := 1; i <= 5; i++ {
+		_ = b.Append(i * 10)
+	}
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	restored := buffer.New[int]()
+	inserted := 0
+	restored.OnInsert(func(uint64, int) { inserted++ })
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !restored.Equals(b) || inserted != 5 {
+		t.Errorf("expected %v and 5 insertions, got %v and %d", b.ToSlice(), restored.ToSlice(), inserted)
+	}
+
+	// Too many elements for the capacity: the buffer is left unchanged
+	small := buffer.NewWithCapacity[int](2)
+	_ = small.Append(1)
+	if err := small.UnmarshalBinary(data); !errors.Is(err, buffer.ErrOverflow) {
+		t.Errorf("expected %v, got %v", buffer.ErrOverflow, err)
+	}
+	if !reflect.DeepEqual(small.ToSlice(), []int{1}) {
+		t.Errorf("expected [1], got %v", small.ToSlice())
+	}
+
+	data[0] = 'x'
+	if err := restored.UnmarshalBinary(data); !errors.Is(err, common.ErrInvalidFormat) {
+		t.Errorf("expected %v, got %v", common.ErrInvalidFormat, err)
+	}
+}
+
+func TestMarshalBinaryCodec(t *testing.T) {
+	b := buffer.New[string]()
+	b.SetCodec(upperCodec{})
+	_ = b.Append("a")
+	_ = b.Append("bc")
+	data, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The codec is kept by the copies
+	restored := b.Copy()
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(restored.ToSlice(), []string{"A", "BC"}) {
+		t.Errorf("expected [A BC], got %v", restored.ToSlice())
+	}
+}
+
+func TestDrainAll(t *testing.T) {
+	b := buffer.New[int]()
+	if b.DrainAll() != nil {
+		t.Error("expected nil from an empty buffer")
+	}
+	for i := 0; i < 3; i++ {
+		_ = b.Append(i)
+	}
+
+	items := b.DrainAll()
+	if !reflect.DeepEqual(items, []int{0, 1, 2}) || !b.IsEmpty() {
+		t.Errorf("expected [0 1 2] and an empty buffer, got %v (size %d)", items, b.Size())
+	}
+	_ = b.Append(10)
+	if items[0] != 0 {
+		t.Errorf("expected the drained items not to share the buffer storage, got %v", items)
+	}
+}
+
+func TestTruncateResize(t *testing.T) {
+	b := buffer.NewWithCapacity[int](5)
+	_ = b.PushN(1, 2, 3)
+
+	var removed []int
+	b.OnRemove(func(_ uint64, v int) { removed = append(removed, v) })
+
+	b.Truncate(5) // no-op
+	b.Truncate(1)
+	if !reflect.DeepEqual(b.ToSlice(), []int{1}) || !reflect.DeepEqual(removed, []int{2, 3}) {
+		t.Errorf("expected [1] and [2 3] removed, got %v and %v", b.ToSlice(), removed)
+	}
+
+	if err := b.Resize(4, 9); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !reflect.DeepEqual(b.ToSlice(), []int{1, 9, 9, 9}) {
+		t.Errorf("expected [1 9 9 9], got %v", b.ToSlice())
+	}
+	if err := b.Resize(6, 0); !errors.Is(err, buffer.ErrOverflow) || b.Size() != 4 {
+		t.Errorf("expected %v and an untouched buffer, got %v (size %d)", buffer.ErrOverflow, err, b.Size())
+	}
+	if err := b.Resize(2, 0); err != nil || !reflect.DeepEqual(b.ToSlice(), []int{1, 9}) {
+		t.Errorf("expected [1 9], got %v (%v)", b.ToSlice(), err)
+	}
+	if err := b.Resize(0, 0); err != nil || !b.IsEmpty() {
+		t.Errorf("expected an empty buffer, got %v (%v)", b.ToSlice(), err)
+	}
+	if err := b.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	f := buffer.Features()
+	if !f.Has(common.FeatureBinaryCodec) || !f.Has(common.FeatureObservers) || f.Has(common.FeatureConcurrent) {
+		t.Errorf("unexpected features %v", f)
+	}
+}
+
+func TestWipe(t *testing.T) {
+	items := make([]string, 3, 5)
+	copy(items, []string{"key", "token", "secret"})
+	b := buffer.Adopt(items)
+	b.Truncate(2)
+
+	b.Wipe()
+	if !b.IsEmpty() {
+		t.Errorf("Expected an empty buffer, got %v", b.ToSlice())
+	}
+	for i, v := range items[:cap(items)] {
+		if v != "" {
+			t.Errorf("Expected slot %d to be wiped, but it holds %q", i, v)
+		}
+	}
+
+	b = buffer.Adopt(items[:1])
+	b.Destroy()
+	if b.Capacity() != 0 || b.Size() != 0 {
+		t.Errorf("Expected a destroyed buffer, got size %d and capacity %d", b.Size(), b.Capacity())
+	}
+}
+
+// TestCtxVariants tests the ForEachCtx, MapCtx and FindCtx methods
+func TestCtxVariants(t *testing.T) {
+	b := buffer.New[int]()
+	for i := 0; i < 3000; i++ {
+		_ = b.Append(i)
+	}
+
+	// The context is checked every common.CtxCheckInterval elements
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := b.ForEachCtx(ctx, func(*int) error {
+		calls++
+		if calls == 100 {
+			cancel()
+		}
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != common.CtxCheckInterval {
+		t.Errorf("expected %v after %d calls, got %v after %d", context.Canceled, common.CtxCheckInterval, err, calls)
+	}
+	if _, err := b.MapCtx(ctx, func(v int) int { return v }); !errors.Is(err, context.Canceled) {
+		t.Errorf(errExpectedErr, context.Canceled, err)
+	}
+	if _, err := b.FindCtx(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf(errExpectedErr, context.Canceled, err)
+	}
+
+	mapped, err := b.MapCtx(context.Background(), func(v int) int { return v * 2 })
+	if err != nil || mapped.Size() != 3000 || mapped.Capacity() != b.Capacity() {
+		t.Fatalf("expected 3000 elements, got %v (%v)", mapped, err)
+	}
+	if i, err := mapped.FindCtx(context.Background(), 5000); err != nil || i != 2500 {
+		t.Errorf("expected 5000 at 2500, got %d (%v)", i, err)
+	}
+	if _, err := b.FindCtx(context.Background(), -1); !errors.Is(err, buffer.ErrNotFound) {
+		t.Errorf(errExpectedErr, buffer.ErrNotFound, err)
+	}
+	if err := buffer.New[int]().ForEachCtx(context.Background(), func(*int) error { return nil }); !errors.Is(err, buffer.ErrEmpty) {
+		t.Errorf(errExpectedErr, buffer.ErrEmpty, err)
+	}
+}
+
+func TestJSONStream(t *testing.T) {
+	if !buffer.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, buffer.Features())
+	}
+	if !buffer.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, buffer.Features())
+	}
+	l := buffer.New[int]()
+	for _, v := range []int{1, 2, 3} {
+		l.Append(v)
+	}
+
+	var sb strings.Builder
+	if err := l.EncodeJSONStream(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sb.String() != "[1,2,3]" {
+		t.Errorf("expected [1,2,3], got %s", sb.String())
+	}
+
+	got := buffer.New[int]()
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader(sb.String()))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader("[4] null"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got.ToSlice(), []int{1, 2, 3, 4}) {
+		t.Errorf("expected [1 2 3 4], got %v", got.ToSlice())
+	}
+
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader(`{"a": 5}`))); !errors.Is(err, common.ErrNotJSONArray) {
+		t.Errorf("expected %v, got %v", common.ErrNotJSONArray, err)
+	}
+
+	bounded := buffer.NewWithCapacity[int](2)
+	if err := bounded.DecodeJSONStream(json.NewDecoder(strings.NewReader("[1, 2, 3]"))); !errors.Is(err, buffer.ErrOverflow) {
+		t.Errorf("expected %v, got %v", buffer.ErrOverflow, err)
+	}
+	if !slices.Equal(bounded.ToSlice(), []int{1, 2}) {
+		t.Errorf("expected [1 2], got %v", bounded.ToSlice())
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *buffer.Buffer[int] {
+		l := buffer.New[int]()
+		for _, v := range values {
+			_ = l.Append(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	// The result is normalized to -1, 0 or +1
+	if got := build(1).Compare(build(10), func(a, b int) int { return a - b }); got != -1 {
+		t.Errorf("expected -1, got %d", got)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package numeric_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	numeric "github.com/pzaino/gods/pkg/buffer/numeric"
+)
+
+const benchSize = 1 << 16
+
+// newBuffer creates a buffer with the given elements
+func newBuffer[T int | float64](items ...T) *buffer.Buffer[T] {
+	return buffer.Adopt(slices.Clone(items))
+}
+
+func TestInPlace(t *testing.T) {
+	a := newBuffer(1, 2, 3, 4, 5)
+	b := newBuffer(10, 20, 30, 40, 50)
+
+	if err := numeric.AddInPlace(a, b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(a.ToSlice(), []int{11, 22, 33, 44, 55}) {
+		t.Errorf("expected [11 22 33 44 55], got %v", a.ToSlice())
+	}
+	if err := numeric.SubInPlace(a, b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := numeric.MulInPlace(a, b); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(a.ToSlice(), []int{10, 40, 90, 160, 250}) {
+		t.Errorf("expected [10 40 90 160 250], got %v", a.ToSlice())
+	}
+
+	numeric.ScaleInPlace(b, 2)
+	numeric.OffsetInPlace(b, -1)
+	if !slices.Equal(b.ToSlice(), []int{19, 39, 59, 79, 99}) {
+		t.Errorf("expected [19 39 59 79 99], got %v", b.ToSlice())
+	}
+
+	short := newBuffer(1, 2)
+	for _, err := range []error{numeric.AddInPlace(a, short), numeric.SubInPlace(a, short), numeric.MulInPlace(short, a)} {
+		if !errors.Is(err, numeric.ErrSizeMismatch) {
+			t.Errorf("expected %v, got %v", numeric.ErrSizeMismatch, err)
+		}
+	}
+}
+
+func TestSumDotProduct(t *testing.T) {
+	for n := 0; n < 10; n++ {
+		items := make([]float64, n)
+		expectedSum, expectedDot := 0.0, 0.0
+		for i := range items {
+			items[i] = float64(i) + 0.5
+			expectedSum += items[i]
+			expectedDot += items[i] * items[i]
+		}
+		b := newBuffer(items...)
+		if s := numeric.Sum(b); s != expectedSum {
+			t.Errorf("%d elements: expected sum %v, got %v", n, expectedSum, s)
+		}
+		if d, err := numeric.DotProduct(b, b); err != nil || d != expectedDot {
+			t.Errorf("%d elements: expected dot product %v, got %v (%v)", n, expectedDot, d, err)
+		}
+	}
+
+	if _, err := numeric.DotProduct(newBuffer(1), newBuffer(1, 2)); !errors.Is(err, numeric.ErrSizeMismatch) {
+		t.Errorf("expected %v, got %v", numeric.ErrSizeMismatch, err)
+	}
+	if s := numeric.Sum(buffer.New[int]()); s != 0 {
+		t.Errorf("expected 0, got %d", s)
+	}
+}
+
+// benchBuffers returns two float buffers of benchSize elements
+func benchBuffers() (*buffer.Buffer[float64], *buffer.Buffer[float64]) {
+	a, b := make([]float64, benchSize), make([]float64, benchSize)
+	for i := range a {
+		a[i], b[i] = float64(i), 1
+	}
+	return buffer.Adopt(a), buffer.Adopt(b)
+}
+
+func BenchmarkAddInPlace(b *testing.B) {
+	x, y := benchBuffers()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = numeric.AddInPlace(x, y)
+	}
+}
+
+// BenchmarkBlitAdd is the same workload as BenchmarkAddInPlace with Buffer.Blit
+func BenchmarkBlitAdd(b *testing.B) {
+	x, y := benchBuffers()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = x.Blit(y, func(a, b float64) float64 { return a + b })
+	}
+}
+
+func BenchmarkSum(b *testing.B) {
+	x, _ := benchBuffers()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = numeric.Sum(x)
+	}
+}
+
+// BenchmarkReduceSum is the same workload as BenchmarkSum with Buffer.Reduce
+func BenchmarkReduceSum(b *testing.B) {
+	x, _ := benchBuffers()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = x.Reduce(func(a, b float64) float64 { return a + b })
+	}
+}
+
+func BenchmarkDotProduct(b *testing.B) {
+	x, y := benchBuffers()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = numeric.DotProduct(x, y)
+	}
+}
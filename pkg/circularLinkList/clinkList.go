@@ -16,30 +16,115 @@
 package circularLinkList
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+
+	common "github.com/pzaino/gods/pkg/common"
 )
 
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
 const (
 	ErrIndexOutOfBound = "index out of bounds"
 	ErrListIsEmpty     = "list is empty"
 )
 
-// Node represents a node in the circular linked list
-type Node[T comparable] struct {
-	Value T
-	Next  *Node[T]
+// Sentinel errors returned by the CircularLinkList methods (use errors.Is to check for them)
+var (
+	ErrOutOfBounds = errors.New(ErrIndexOutOfBound)
+	ErrEmpty       = errors.New(ErrListIsEmpty)
+	ErrNotFound    = errors.New("value not found")
+	ErrCorrupted   = errors.New("list is corrupted")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureHasher, common.FeatureInvariants, common.FeatureDeepCopy, common.FeatureJSON)
+}
+
+// Node represents a node in the circular linked list. Its link is private so
+// only the list can change the structure, use the accessors to walk the list
+type Node[T any] struct {
+	value T
+	next  *Node[T]
+}
+
+// Value returns the value held by the node
+func (n *Node[T]) Value() T {
+	return n.value
+}
+
+// SetValue replaces the value held by the node (the observers of the list
+// are not notified)
+func (n *Node[T]) SetValue(value T) {
+	n.value = value
+}
+
+// Next returns the node after n, for the last node of its list it's the
+// first one (nil only for a node removed from its list)
+func (n *Node[T]) Next() *Node[T] {
+	return n.next
 }
 
 // CircularLinkList represents a circular linked list
-type CircularLinkList[T comparable] struct {
-	Head *Node[T]
-	Tail *Node[T]
-	size uint64
+type CircularLinkList[T any] struct {
+	head   *Node[T]
+	tail   *Node[T]
+	size   uint64
+	equals common.EqualFunc[T]
+	key    common.KeyFunc[T] // nil when values can only be compared with equals
+	hasher common.Hasher[T]  // nil unless the list was created with NewWithHasher
 }
 
 // New creates a new CircularLinkList
 func New[T comparable]() *CircularLinkList[T] {
-	return &CircularLinkList[T]{}
+	return &CircularLinkList[T]{equals: common.Equal[T], key: common.Key[T]}
+}
+
+// NewWithComparator creates a new CircularLinkList that compares its values with the given
+// function, so it can hold types that are not comparable (slices, maps, ...)
+func NewWithComparator[T any](equals func(a, b T) bool) *CircularLinkList[T] {
+	return &CircularLinkList[T]{equals: equals}
+}
+
+// NewWithHasher creates a new CircularLinkList that compares its values with the given
+// hasher, which also speeds up ContainsAll, ContainsAny and Deduplicate for
+// types that are not comparable (the hashes are compared first)
+func NewWithHasher[T any](hasher common.Hasher[T]) *CircularLinkList[T] {
+	return &CircularLinkList[T]{equals: hasher.Equals, hasher: hasher}
+}
+
+// newEmpty creates a new empty list that compares its values like l
+func (l *CircularLinkList[T]) newEmpty() *CircularLinkList[T] {
+	return &CircularLinkList[T]{equals: l.equals, key: l.key, hasher: l.hasher}
+}
+
+// seen returns a new Seen set that compares the values like l
+func (l *CircularLinkList[T]) seen() *common.Seen[T] {
+	if l.hasher != nil {
+		return common.NewHashSeen(l.hasher)
+	}
+	return common.NewSeen(l.key, l.equals)
+}
+
+// lookup returns a new Lookup for items that compares the values like l
+func (l *CircularLinkList[T]) lookup(items []T) *common.Lookup[T] {
+	if l.hasher != nil {
+		return common.NewHashLookup(l.hasher, items)
+	}
+	return common.NewLookup(l.key, l.equals, items)
+}
+
+// equal compares two values with the list comparator
+func (l *CircularLinkList[T]) equal(x, y T) bool {
+	return common.Equals(l.equals, x, y)
 }
 
 // NewFromSlice creates a new CircularLinkList from a slice
@@ -51,73 +136,110 @@ func NewFromSlice[T comparable](items []T) *CircularLinkList[T] {
 	return l
 }
 
-// Append adds a new node to the end of the list
-func (l *CircularLinkList[T]) Append(value T) {
-	newNode := &Node[T]{Value: value}
+// insertAfter links a new node holding value after prev, or before the head
+// if prev is nil, and returns it. All the insertions go through it (and
+// deleteAfter) so the size is always up to date
+func (l *CircularLinkList[T]) insertAfter(prev *Node[T], value T) *Node[T] {
+	newNode := &Node[T]{value: value}
+
+	switch {
+	case l.head == nil:
+		newNode.next = newNode
+		l.head = newNode
+		l.tail = newNode
+	case prev == nil:
+		newNode.next = l.head
+		l.head = newNode
+		l.tail.next = newNode
+	default:
+		newNode.next = prev.next
+		prev.next = newNode
+		if prev == l.tail {
+			l.tail = newNode
+		}
+	}
+	l.size++
+	return newNode
+}
 
-	if l.Head == nil {
-		l.Head = newNode
-		l.Tail = newNode
-		newNode.Next = newNode
-		l.size++
+// deleteAfter unlinks the node after prev (the head if prev is the tail)
+func (l *CircularLinkList[T]) deleteAfter(prev *Node[T]) {
+	if l.head == l.tail {
+		l.Clear()
 		return
 	}
 
-	l.Tail.Next = newNode
-	newNode.Next = l.Head
-	l.Tail = newNode
-	l.size++
+	node := prev.next
+	prev.next = node.next
+	if node == l.head {
+		l.head = node.next
+	}
+	if node == l.tail {
+		l.tail = prev
+	}
+	l.size--
 }
 
-// Prepend adds a new node to the beginning of the list
-func (l *CircularLinkList[T]) Prepend(value T) {
-	newNode := &Node[T]{Value: value}
-
-	if l.Head == nil {
-		l.Head = newNode
-		l.Tail = newNode
-		newNode.Next = newNode
-		l.size++
-		return
+// nodeBefore returns the node before the one at the given index (the tail
+// for index 0 and index == size), the index must not be bigger than the size
+func (l *CircularLinkList[T]) nodeBefore(index uint64) *Node[T] {
+	prev := l.tail
+	for i := uint64(0); i < index; i++ {
+		prev = prev.next
 	}
+	return prev
+}
 
-	newNode.Next = l.Head
-	l.Head = newNode
-	l.Tail.Next = newNode
-	l.size++
+// wrapIndex maps the index of a node of a non-empty list to [0, size),
+// indexes bigger than or equal to the size wrap around the list
+func (l *CircularLinkList[T]) wrapIndex(index uint64) uint64 {
+	return index % l.size
 }
 
-// DeleteWithValue deletes the first node with the given value
-func (l *CircularLinkList[T]) DeleteWithValue(value T) {
-	if l.Head == nil {
-		return
+// checkIndex validates the index of a node and maps it to [0, size): indexes
+// bigger than the size wrap around the list, while index == size is out of
+// bounds (it is the end of the list, where InsertAt appends)
+func (l *CircularLinkList[T]) checkIndex(index uint64) (uint64, error) {
+	if l.head == nil || index == l.size {
+		return 0, common.NewIndexError(ErrOutOfBounds, index, l.size)
 	}
+	return l.wrapIndex(index), nil
+}
 
-	// Special case: head node needs to be deleted
-	if l.Head.Value == value {
-		if l.Head == l.Tail {
-			l.Head = nil
-			l.Tail = nil
-			l.size = 0
-			return
-		}
-		l.Head = l.Head.Next
-		l.Tail.Next = l.Head
-		l.size--
-		return
+// wrapPosition maps a position of a non-empty list (an insertion point or the
+// end of a half-open range) to [0, size], bigger positions wrap around the list
+func (l *CircularLinkList[T]) wrapPosition(index uint64) uint64 {
+	if index > l.size {
+		return index % l.size
 	}
+	return index
+}
 
-	current := l.Head
-	for current.Next != l.Head {
-		if current.Next.Value == value {
-			if current.Next == l.Tail {
-				l.Tail = current
-			}
-			current.Next = current.Next.Next
-			l.size--
+// nodeAt returns the node at the given index of a non-empty list, indexes
+// bigger than or equal to the size wrap around the list
+func (l *CircularLinkList[T]) nodeAt(index uint64) *Node[T] {
+	return l.nodeBefore(l.wrapIndex(index)).next
+}
+
+// Append adds a new node to the end of the list
+func (l *CircularLinkList[T]) Append(value T) {
+	l.insertAfter(l.tail, value)
+}
+
+// Prepend adds a new node to the beginning of the list
+func (l *CircularLinkList[T]) Prepend(value T) {
+	l.insertAfter(nil, value)
+}
+
+// DeleteWithValue deletes the first node with the given value
+func (l *CircularLinkList[T]) DeleteWithValue(value T) {
+	prev := l.tail
+	for i := uint64(0); i < l.size; i++ {
+		if l.equal(prev.next.value, value) {
+			l.deleteAfter(prev)
 			return
 		}
-		current = current.Next
+		prev = prev.next
 	}
 }
 
@@ -125,15 +247,15 @@ func (l *CircularLinkList[T]) DeleteWithValue(value T) {
 func (l *CircularLinkList[T]) ToSlice() []T {
 	var result []T
 
-	if l.Head == nil {
+	if l.head == nil {
 		return result
 	}
 
-	current := l.Head
+	current := l.head
 	for {
-		result = append(result, current.Value)
-		current = current.Next
-		if current == l.Head {
+		result = append(result, current.value)
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
@@ -141,53 +263,175 @@ func (l *CircularLinkList[T]) ToSlice() []T {
 	return result
 }
 
+// ToSliceRange converts the nodes in the range [start, end) of the list to a slice
+// (empty if start > end or end > size)
+func (l *CircularLinkList[T]) ToSliceRange(start, end uint64) []T {
+	var result []T
+	if start >= end || end > l.size {
+		return result
+	}
+
+	result = make([]T, 0, end-start)
+	current := l.nodeAt(start)
+	for i := start; i < end; i++ {
+		result = append(result, current.value)
+		current = current.next
+	}
+
+	return result
+}
+
+// String returns a string representation of the list (elements are formatted with %v)
+func (l *CircularLinkList[T]) String() string {
+	return l.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the list with every element formatted by f
+func (l *CircularLinkList[T]) StringFunc(f func(T) string) string {
+	return common.FormatSlice(l.ToSlice(), f)
+}
+
+// EncodeJSONStream writes the values of the list to w as a JSON array, one
+// value at a time (the output of json.Marshal on ToSlice, without the copy)
+func (l *CircularLinkList[T]) EncodeJSONStream(w io.Writer) error {
+	enc := common.NewJSONArrayEncoder[T](w)
+	if l.head != nil {
+		current := l.head
+		for {
+			if err := enc.Encode(current.value); err != nil {
+				return err
+			}
+			current = current.next
+			if current == l.head {
+				break
+			}
+		}
+	}
+	return enc.Close()
+}
+
+// DecodeJSONStream appends the values of the JSON array read from dec, one
+// value at a time, without holding the whole array in memory (see
+// common.DecodeJSONArray). The values decoded before an error stay in the list
+func (l *CircularLinkList[T]) DecodeJSONStream(dec *json.Decoder) error {
+	return common.DecodeJSONArray(dec, func(v T) error {
+		l.Append(v)
+		return nil
+	})
+}
+
 // IsEmpty checks if the list is empty
 func (l *CircularLinkList[T]) IsEmpty() bool {
-	return l.Head == nil
+	return l.head == nil
 }
 
 // Find returns the first node with the given value
 func (l *CircularLinkList[T]) Find(value T) (*Node[T], error) {
-	if l.Head == nil {
-		return nil, errors.New("value not found")
+	if l.head == nil {
+		return nil, ErrNotFound
 	}
 
-	current := l.Head
+	current := l.head
 	for {
-		if current.Value == value {
+		if l.equal(current.value, value) {
 			return current, nil
 		}
-		current = current.Next
-		if current == l.Head {
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
 
-	return nil, errors.New("value not found")
+	return nil, ErrNotFound
+}
+
+// FindCtx returns the first node with the given value like Find, it checks ctx
+// every common.CtxCheckInterval nodes and returns its error when it's done
+func (l *CircularLinkList[T]) FindCtx(ctx context.Context, value T) (*Node[T], error) {
+	if l.head == nil {
+		return nil, ErrNotFound
+	}
+
+	current := l.head
+	for i := uint64(0); ; i++ {
+		if err := common.CtxErr(ctx, i); err != nil {
+			return nil, err
+		}
+		if l.equal(current.value, value) {
+			return current, nil
+		}
+		current = current.next
+		if current == l.head {
+			return nil, ErrNotFound
+		}
+	}
+}
+
+// ContainsAll returns true if the list contains all the given values (true
+// when none is given), they are all checked in a single pass over the list
+func (l *CircularLinkList[T]) ContainsAll(values ...T) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	lookup := l.lookup(values)
+	for i, n := uint64(0), l.head; i < l.size; i, n = i+1, n.next {
+		if lookup.Match(n.value) && lookup.Remaining() == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsAny returns true if the list contains at least one of the given
+// values, they are all checked in a single pass over the list
+func (l *CircularLinkList[T]) ContainsAny(values ...T) bool {
+	if len(values) == 0 {
+		return false
+	}
+
+	lookup := l.lookup(values)
+	for i, n := uint64(0), l.head; i < l.size; i, n = i+1, n.next {
+		if lookup.Match(n.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountOf returns the number of values in the list equal to the given value
+func (l *CircularLinkList[T]) CountOf(value T) uint64 {
+	var count uint64
+	for i, n := uint64(0), l.head; i < l.size; i, n = i+1, n.next {
+		if l.equal(n.value, value) {
+			count++
+		}
+	}
+	return count
 }
 
 // Reverse reverses the list
 func (l *CircularLinkList[T]) Reverse() {
-	if l.Head == nil {
+	if l.head == nil {
 		return
 	}
 
 	var prev, next *Node[T]
-	current := l.Head
-	l.Tail = l.Head
+	current := l.head
+	l.tail = l.head
 
 	for {
-		next = current.Next
-		current.Next = prev
+		next = current.next
+		current.next = prev
 		prev = current
 		current = next
-		if current == l.Head {
+		if current == l.head {
 			break
 		}
 	}
 
-	l.Head.Next = prev
-	l.Head = prev
+	l.head.next = prev
+	l.head = prev
 }
 
 // Size returns the number of nodes in the list
@@ -195,20 +439,54 @@ func (l *CircularLinkList[T]) Size() uint64 {
 	return l.size
 }
 
+// CheckInvariants verifies the internal consistency of the list (the number of
+// nodes matches the size, the tail is the last node and it links back to the
+// head), it returns an error wrapping ErrCorrupted describing the first
+// violation found
+func (l *CircularLinkList[T]) CheckInvariants() error {
+	if l.head == nil || l.tail == nil {
+		if l.head != l.tail || l.size != 0 {
+			return fmt.Errorf("%w: empty list with size %d or only one of head and tail set", ErrCorrupted, l.size)
+		}
+		return nil
+	}
+
+	current := l.head
+	for i := uint64(1); i < l.size; i++ {
+		if current == l.tail {
+			return fmt.Errorf("%w: the tail is node %d but its size is %d", ErrCorrupted, i-1, l.size)
+		}
+		if current.next == nil {
+			return fmt.Errorf("%w: node %d has no Next node", ErrCorrupted, i-1)
+		}
+		current = current.next
+	}
+	if current != l.tail {
+		return fmt.Errorf("%w: the tail is not node %d", ErrCorrupted, l.size-1)
+	}
+	if l.tail.next != l.head {
+		return fmt.Errorf("%w: the tail doesn't link back to the head", ErrCorrupted)
+	}
+	return nil
+}
+
 // CheckSize recalculate the size of the list
+//
+// Deprecated: the size is kept up to date by all the methods of the list, use
+// CheckInvariants to verify it
 func (l *CircularLinkList[T]) CheckSize() {
 	size := uint64(0)
 
-	if l.Head == nil {
+	if l.head == nil {
 		l.size = 0
 		return
 	}
 
-	current := l.Head
+	current := l.head
 	for {
 		size++
-		current = current.Next
-		if current == l.Head {
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
@@ -218,132 +496,91 @@ func (l *CircularLinkList[T]) CheckSize() {
 
 // GetFirst returns the first node in the list
 func (l *CircularLinkList[T]) GetFirst() *Node[T] {
-	return l.Head
+	return l.head
 }
 
 // GetLast returns the last node in the list
 func (l *CircularLinkList[T]) GetLast() *Node[T] {
-	return l.Tail
+	return l.tail
 }
 
-// GetAt returns the node at the given index
+// GetAt returns the node at the given index, indexes bigger than the size wrap
+// around the list (index == size is out of bounds, see checkIndex)
 func (l *CircularLinkList[T]) GetAt(index uint64) (*Node[T], error) {
-	if l.Head == nil {
-		return nil, errors.New(ErrIndexOutOfBound)
-	}
-
-	if index > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		index = index % l.size
-	}
-
-	current := l.Head
-	for i := uint64(0); i < index; i++ {
-		current = current.Next
-		if current == l.Head {
-			return nil, errors.New(ErrIndexOutOfBound)
-		}
+	index, err := l.checkIndex(index)
+	if err != nil {
+		return nil, err
 	}
 
-	return current, nil
+	return l.nodeAt(index), nil
 }
 
-// InsertAt inserts a new node at the given index
+// InsertAt inserts a new node at the given index (index == size appends it),
+// bigger indexes wrap around the list
 func (l *CircularLinkList[T]) InsertAt(index uint64, value T) error {
-	if index > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		index = index % l.size
+	if l.head == nil {
+		l.Append(value)
+		return nil
 	}
-
+	index = l.wrapPosition(index)
 	if index == 0 {
 		l.Prepend(value)
 		return nil
 	}
 
-	current := l.Head
-	for i := uint64(0); i < index-1; i++ {
-		current = current.Next
-		if current == l.Head {
-			return errors.New(ErrIndexOutOfBound)
-		}
-	}
-
-	newNode := &Node[T]{Value: value}
-	newNode.Next = current.Next
-	current.Next = newNode
-
-	if current == l.Tail {
-		l.Tail = newNode
-	}
-
+	l.insertAfter(l.nodeBefore(index), value)
 	return nil
 }
 
-// DeleteAt deletes the node at the given index
+// DeleteAt deletes the node at the given index, indexes bigger than the size
+// wrap around the list (index == size is out of bounds, see checkIndex)
 func (l *CircularLinkList[T]) DeleteAt(index uint64) error {
-	if index > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		index = index % l.size
-	}
-
-	if index == 0 {
-		if l.Head == nil {
-			return errors.New(ErrIndexOutOfBound)
-		}
-		if l.Head == l.Tail {
-			l.Head = nil
-			l.Tail = nil
-			return nil
-		}
-		l.Head = l.Head.Next
-		l.Tail.Next = l.Head
-		return nil
-	}
-
-	current := l.Head
-	for i := uint64(0); i < index-1; i++ {
-		current = current.Next
-		if current == l.Head {
-			return errors.New(ErrIndexOutOfBound)
-		}
-	}
-
-	if current.Next == l.Head {
-		return errors.New(ErrIndexOutOfBound)
+	index, err := l.checkIndex(index)
+	if err != nil {
+		return err
 	}
 
-	if current.Next == l.Tail {
-		l.Tail = current
-	}
-
-	current.Next = current.Next.Next
-
+	l.deleteAfter(l.nodeBefore(index))
 	return nil
 }
 
 // Clear removes all nodes from the list
 func (l *CircularLinkList[T]) Clear() {
-	l.Head = nil
-	l.Tail = nil
+	l.head = nil
+	l.tail = nil
 	l.size = 0
 }
 
-// Copy returns a copy of the list
+// Wipe overwrites the value of every node with the zero value and unlinks the
+// nodes before emptying the list, so sensitive data (tokens, keys, ...)
+// doesn't stay reachable from nodes still referenced elsewhere (a *Node
+// returned by Find, GetAt, ... is wiped too)
+func (l *CircularLinkList[T]) Wipe() {
+	for i := uint64(0); i < l.size; i++ {
+		node := l.head
+		l.head = node.next
+		var zero T
+		node.value = zero
+		node.next = nil
+	}
+	l.Clear()
+}
+
+// Copy returns a copy of the list. The copy is shallow: the values are
+// copied by assignment, so pointers, slices and maps inside them are shared
+// with the original (use CloneWith for a deep copy)
 func (l *CircularLinkList[T]) Copy() *CircularLinkList[T] {
-	newList := New[T]()
+	newList := l.newEmpty()
 
-	if l.Head == nil {
+	if l.head == nil {
 		return newList
 	}
 
-	current := l.Head
+	current := l.head
 	for {
-		newList.Append(current.Value)
-		current = current.Next
-		if current == l.Head {
+		newList.Append(current.value)
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
@@ -351,17 +588,83 @@ func (l *CircularLinkList[T]) Copy() *CircularLinkList[T] {
 	return newList
 }
 
-// Merge appends all the nodes from another list to the current list
+// CopyRange returns a new list with the nodes in the range [start, end) of the original one
+// (empty if start > end or end > size), the copy is shallow like Copy
+func (l *CircularLinkList[T]) CopyRange(start, end uint64) *CircularLinkList[T] {
+	newList := l.newEmpty()
+	if start >= end || end > l.size {
+		return newList
+	}
+
+	current := l.nodeAt(start)
+	for i := start; i < end; i++ {
+		newList.Append(current.value)
+		current = current.next
+	}
+
+	return newList
+}
+
+// CloneWith returns a copy of the list with a copy of every value made by
+// copier, to deep copy values that hold pointers, slices or maps
+func (l *CircularLinkList[T]) CloneWith(copier func(T) T) *CircularLinkList[T] {
+	newList := l.Copy()
+	newList.ForEach(func(v *T) {
+		*v = copier(*v)
+	})
+	return newList
+}
+
+// fromSlice returns a new list, comparing its values like l, with the given values
+func (l *CircularLinkList[T]) fromSlice(items []T) *CircularLinkList[T] {
+	newList := l.newEmpty()
+	for _, item := range items {
+		newList.Append(item)
+	}
+	return newList
+}
+
+// Chunk splits the values of the list in consecutive new lists of size values
+// (the last one may be shorter). It returns nil if size is 0 or the list is empty
+func (l *CircularLinkList[T]) Chunk(size uint64) []*CircularLinkList[T] {
+	if size == 0 || l.size == 0 {
+		return nil
+	}
+	items := l.ToSlice()
+	chunks := make([]*CircularLinkList[T], 0, (l.size+size-1)/size)
+	for start := uint64(0); start < l.size; start += size {
+		chunks = append(chunks, l.fromSlice(items[start:min(start+size, l.size)]))
+	}
+	return chunks
+}
+
+// Windows returns all the sliding windows of size consecutive values of the
+// list as new lists, in order ([0, size), [1, size+1), ...). It returns nil
+// if size is 0 or greater than the size of the list
+func (l *CircularLinkList[T]) Windows(size uint64) []*CircularLinkList[T] {
+	if size == 0 || size > l.size {
+		return nil
+	}
+	items := l.ToSlice()
+	windows := make([]*CircularLinkList[T], 0, l.size-size+1)
+	for start := uint64(0); start+size <= l.size; start++ {
+		windows = append(windows, l.fromSlice(items[start:start+size]))
+	}
+	return windows
+}
+
+// Merge appends all the nodes from another list to the current list, merging
+// a list with itself leaves it unchanged
 func (l *CircularLinkList[T]) Merge(list *CircularLinkList[T]) {
-	if list.Head == nil {
+	if list == l || list.head == nil {
 		return
 	}
 
-	current := list.Head
+	current := list.head
 	for {
-		l.Append(current.Value)
-		current = current.Next
-		if current == list.Head {
+		l.Append(current.value)
+		current = current.next
+		if current == list.head {
 			break
 		}
 	}
@@ -370,17 +673,17 @@ func (l *CircularLinkList[T]) Merge(list *CircularLinkList[T]) {
 
 // Map generates a new list by applying the function to all the nodes in the list
 func (l *CircularLinkList[T]) Map(f func(T) T) *CircularLinkList[T] {
-	newList := New[T]()
+	newList := l.newEmpty()
 
-	if l.Head == nil {
+	if l.head == nil {
 		return newList
 	}
 
-	current := l.Head
+	current := l.head
 	for {
-		newList.Append(f(current.Value))
-		current = current.Next
-		if current == l.Head {
+		newList.Append(f(current.value))
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
@@ -388,32 +691,45 @@ func (l *CircularLinkList[T]) Map(f func(T) T) *CircularLinkList[T] {
 	return newList
 }
 
-// MapFrom generates a new list by applying the function to all the nodes in the list starting from the specified index
-func (l *CircularLinkList[T]) MapFrom(start uint64, f func(T) T) (*CircularLinkList[T], error) {
-	if l.Head == nil {
-		return nil, errors.New(ErrIndexOutOfBound)
-	}
-
-	if start > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		start = start % l.size
+// MapCtx generates a new list like Map, it checks ctx every
+// common.CtxCheckInterval nodes and returns its error (and no list) when it's
+// done
+func (l *CircularLinkList[T]) MapCtx(ctx context.Context, f func(T) T) (*CircularLinkList[T], error) {
+	newList := l.newEmpty()
+	err := l.ForEachCtx(ctx, func(v *T) {
+		newList.Append(f(*v))
+	})
+	if err != nil {
+		return nil, err
 	}
+	return newList, nil
+}
 
-	newList := New[T]()
+// MapIndexed generates a new list by applying the function to all the values in
+// the list and their index
+func (l *CircularLinkList[T]) MapIndexed(f func(uint64, T) T) *CircularLinkList[T] {
+	items := make([]T, 0, l.size)
+	l.ForEachIndexed(func(i uint64, v *T) {
+		items = append(items, f(i, *v))
+	})
+	return l.fromSlice(items)
+}
 
-	current := l.Head
-	for i := uint64(0); i < start; i++ {
-		current = current.Next
-		if current == l.Head {
-			return nil, errors.New(ErrIndexOutOfBound)
-		}
+// MapFrom generates a new list by applying the function to all the nodes in the list starting from the specified index
+// (indexes bigger than the size wrap around the list, index == size is out of bounds)
+func (l *CircularLinkList[T]) MapFrom(start uint64, f func(T) T) (*CircularLinkList[T], error) {
+	start, err := l.checkIndex(start)
+	if err != nil {
+		return nil, err
 	}
 
+	newList := l.newEmpty()
+	current := l.nodeAt(start)
+
 	for {
-		newList.Append(f(current.Value))
-		current = current.Next
-		if current == l.Head {
+		newList.Append(f(current.value))
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
@@ -423,40 +739,23 @@ func (l *CircularLinkList[T]) MapFrom(start uint64, f func(T) T) (*CircularLinkL
 
 // MapRange generates a new list by applying the function to all the nodes in the list in the range [start, end)
 func (l *CircularLinkList[T]) MapRange(start, end uint64, f func(T) T) (*CircularLinkList[T], error) {
-	if l.Head == nil {
-		return nil, errors.New(ErrIndexOutOfBound)
+	if l.head == nil {
+		return nil, ErrOutOfBounds
 	}
 
-	if start > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		start = start % l.size
-	}
-
-	if end > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		end = end % l.size
-	}
+	start, end = l.wrapPosition(start), l.wrapPosition(end)
 
 	if start > end {
-		return nil, errors.New(ErrIndexOutOfBound)
+		return nil, ErrOutOfBounds
 	}
 
-	newList := New[T]()
-
-	current := l.Head
-	for i := uint64(0); i < start; i++ {
-		current = current.Next
-		if current == l.Head {
-			return nil, errors.New(ErrIndexOutOfBound)
-		}
-	}
+	newList := l.newEmpty()
+	current := l.nodeAt(start)
 
 	for i := start; i < end; i++ {
-		newList.Append(f(current.Value))
-		current = current.Next
-		if current == l.Head {
+		newList.Append(f(current.value))
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
@@ -465,55 +764,92 @@ func (l *CircularLinkList[T]) MapRange(start, end uint64, f func(T) T) (*Circula
 }
 
 // ForEach applies the function to each node in the list
+// (f must not add or remove nodes, use ForEachSafe to remove them while iterating)
 func (l *CircularLinkList[T]) ForEach(f func(*T)) {
-	if l.Head == nil {
+	if l.head == nil {
 		return
 	}
 
-	current := l.Head
+	current := l.head
 	for {
-		f(&current.Value)
-		current = current.Next
-		if current == l.Head {
+		f(&current.value)
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
 }
 
-// ForRange applies the function to each node in the list in the range [start, end]
-func (l *CircularLinkList[T]) ForRange(start, end uint64, f func(*T)) error {
-	if l.Head == nil {
-		return errors.New(ErrIndexOutOfBound)
+// ForEachSafe calls fn with every value of the list, from the head to the
+// tail, and removes the nodes for which it returns true (e.g. to expire entries
+// while scanning). The traversal keeps its position when the current node is
+// removed, unlike removing it from a ForEach callback. fn must not modify the
+// list itself
+func (l *CircularLinkList[T]) ForEachSafe(fn func(v T) (remove bool)) {
+	l.Filter(func(v T) bool { return !fn(v) })
+}
+
+// ForEachIndexed applies the function to all the values in the list and their index
+func (l *CircularLinkList[T]) ForEachIndexed(f func(uint64, *T)) {
+	i := uint64(0)
+	l.ForEach(func(v *T) {
+		f(i, v)
+		i++
+	})
+}
+
+// ForEachErr applies the function to each node in the list, it stops at the
+// first error returned by the function and returns it
+func (l *CircularLinkList[T]) ForEachErr(f func(*T) error) error {
+	if l.head == nil {
+		return nil
 	}
 
-	if start > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		start = start % l.size
+	current := l.head
+	for {
+		if err := f(&current.value); err != nil {
+			return err
+		}
+		current = current.next
+		if current == l.head {
+			return nil
+		}
 	}
+}
+
+// ForEachCtx applies the function to all the nodes in the list like ForEach,
+// it checks ctx every common.CtxCheckInterval nodes and stops, returning its
+// error, when it's done
+func (l *CircularLinkList[T]) ForEachCtx(ctx context.Context, f func(*T)) error {
+	var i uint64
+	return l.ForEachErr(func(v *T) error {
+		if err := common.CtxErr(ctx, i); err != nil {
+			return err
+		}
+		i++
+		f(v)
+		return nil
+	})
+}
 
-	if end > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		end = end % l.size
+// ForRange applies the function to each node in the list in the range [start, end]
+func (l *CircularLinkList[T]) ForRange(start, end uint64, f func(*T)) error {
+	if l.head == nil {
+		return ErrOutOfBounds
 	}
 
+	start, end = l.wrapPosition(start), l.wrapPosition(end)
+
 	if start > end {
-		return errors.New(ErrIndexOutOfBound)
+		return ErrOutOfBounds
 	}
 
-	current := l.Head
-	for i := uint64(0); i < start; i++ {
-		current = current.Next
-		if current == l.Head {
-			return errors.New(ErrIndexOutOfBound)
-		}
-	}
+	current := l.nodeAt(start)
 
 	for i := start; i <= end; i++ {
-		f(&current.Value)
-		current = current.Next
-		if current == l.Head {
+		f(&current.value)
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
@@ -522,29 +858,19 @@ func (l *CircularLinkList[T]) ForRange(start, end uint64, f func(*T)) error {
 }
 
 // ForFrom applies the function to each node in the list starting from the index
+// (indexes bigger than the size wrap around the list, index == size is out of bounds)
 func (l *CircularLinkList[T]) ForFrom(start uint64, f func(*T)) error {
-	if l.Head == nil {
-		return errors.New(ErrIndexOutOfBound)
+	start, err := l.checkIndex(start)
+	if err != nil {
+		return err
 	}
 
-	if start > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		start = start % l.size
-	}
-
-	current := l.Head
-	for i := uint64(0); i < start; i++ {
-		current = current.Next
-		if current == l.Head {
-			return errors.New(ErrIndexOutOfBound)
-		}
-	}
+	current := l.nodeAt(start)
 
 	for {
-		f(&current.Value)
-		current = current.Next
-		if current == l.Head {
+		f(&current.value)
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
@@ -554,61 +880,55 @@ func (l *CircularLinkList[T]) ForFrom(start uint64, f func(*T)) error {
 
 // Filter removes nodes from the list that don't match the predicate
 func (l *CircularLinkList[T]) Filter(f func(T) bool) {
-	if l.Head == nil {
-		return
-	}
-
-	// Handle the head separately
-	for l.Head != nil && !f(l.Head.Value) {
-		// Remove the head node
-		if l.Head == l.Tail {
-			// List has only one element
-			l.Head = nil
-			l.Tail = nil
-			l.size = 0
-			return
+	prev := l.tail
+	for n := l.size; n > 0; n-- {
+		if !f(prev.next.value) {
+			l.deleteAfter(prev)
 		} else {
-			l.Head = l.Head.Next
-			l.Tail.Next = l.Head
-			l.size--
+			prev = prev.next
 		}
 	}
+}
 
-	if l.Head == nil {
-		return
-	}
+// RemoveIf removes the nodes that match the predicate (the opposite of
+// Filter) and returns how many were removed
+func (l *CircularLinkList[T]) RemoveIf(predicate func(T) bool) uint64 {
+	size := l.size
+	l.Filter(func(v T) bool { return !predicate(v) })
+	return size - l.size
+}
 
-	current := l.Head
+// RemoveAll removes all the nodes equal to value and returns how many were removed
+func (l *CircularLinkList[T]) RemoveAll(value T) uint64 {
+	return l.RemoveIf(func(v T) bool { return l.equal(v, value) })
+}
 
-	for current.Next != l.Head {
-		if !f(current.Next.Value) {
-			// Remove the node
-			if current.Next == l.Tail {
-				l.Tail = current
-				l.Tail.Next = l.Head
-			} else {
-				current.Next = current.Next.Next
-			}
-			l.size--
-		} else {
-			current = current.Next
+// RemoveFirstN removes the first n nodes equal to value (from the start of
+// the list) and returns how many were removed
+func (l *CircularLinkList[T]) RemoveFirstN(value T, n uint64) uint64 {
+	removed := uint64(0)
+	return l.RemoveIf(func(v T) bool {
+		if removed < n && l.equal(v, value) {
+			removed++
+			return true
 		}
-	}
+		return false
+	})
 }
 
 // Reduce reduces the list to a single value
 func (l *CircularLinkList[T]) Reduce(f func(T, T) T) (T, error) {
-	if l.Head == nil {
+	if l.head == nil {
 		var rVal T
-		return rVal, errors.New(ErrListIsEmpty)
+		return rVal, ErrEmpty
 	}
 
-	result := l.Head.Value
-	current := l.Head.Next
+	result := l.head.value
+	current := l.head.next
 	for {
-		result = f(result, current.Value)
-		current = current.Next
-		if current == l.Head {
+		result = f(result, current.value)
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
@@ -616,33 +936,77 @@ func (l *CircularLinkList[T]) Reduce(f func(T, T) T) (T, error) {
 	return result, nil
 }
 
-// ReduceFrom reduces the list to a single value starting from the index
-func (l *CircularLinkList[T]) ReduceFrom(start uint64, f func(T, T) T) (T, error) {
-	if l.Head == nil || l.size == 0 {
-		var rVal T
-		return rVal, errors.New(ErrListIsEmpty)
+// ReduceInto reduces the list to a value of a different type, starting from
+// initial and applying fn to each value once, from the head to the tail
+func ReduceInto[T, A any](l *CircularLinkList[T], initial A, fn func(A, T) A) A {
+	result := initial
+	if l.head == nil {
+		return result
+	}
+
+	current := l.head
+	for {
+		result = fn(result, current.value)
+		current = current.next
+		if current == l.head {
+			break
+		}
 	}
+	return result
+}
+
+// MapTo returns a new list with the result of fn applied to every value of src,
+// in the same order. Unlike Map the result can hold a different type, e.g. to
+// extract the IDs of a list of structs. Its values are compared with == like
+// the ones of New, use MapToWithComparator when U is not comparable
+func MapTo[T any, U comparable](src *CircularLinkList[T], fn func(T) U) *CircularLinkList[U] {
+	return mapInto(src, fn, New[U]())
+}
+
+// MapToWithComparator is like MapTo, the new list compares its values with
+// the given function
+func MapToWithComparator[T, U any](src *CircularLinkList[T], fn func(T) U, equals func(a, b U) bool) *CircularLinkList[U] {
+	return mapInto(src, fn, NewWithComparator(equals))
+}
 
-	if start > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		start = start % l.size
+// mapInto fills result with fn applied to every element of src
+func mapInto[T, U any](src *CircularLinkList[T], fn func(T) U, result *CircularLinkList[U]) *CircularLinkList[U] {
+	if src.head == nil {
+		return result
 	}
 
-	current := l.Head
-	for i := uint64(0); i < start; i++ {
-		current = current.Next
-		if current == l.Head {
+	current := src.head
+	for {
+		result.Append(fn(current.value))
+		current = current.next
+		if current == src.head {
 			break
 		}
 	}
+	return result
+}
+
+// ReduceFrom reduces the list to a single value starting from the index
+// (indexes bigger than the size wrap around the list, index == size is out of bounds)
+func (l *CircularLinkList[T]) ReduceFrom(start uint64, f func(T, T) T) (T, error) {
+	if l.head == nil || l.size == 0 {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+	start, err := l.checkIndex(start)
+	if err != nil {
+		var rVal T
+		return rVal, err
+	}
 
-	result := current.Value
-	current = current.Next
+	current := l.nodeAt(start)
+
+	result := current.value
+	current = current.next
 	for {
-		result = f(result, current.Value)
-		current = current.Next
-		if current == l.Head {
+		result = f(result, current.value)
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
@@ -652,45 +1016,352 @@ func (l *CircularLinkList[T]) ReduceFrom(start uint64, f func(T, T) T) (T, error
 
 // ReduceRange reduces the list to a single value in the range [start, end)
 func (l *CircularLinkList[T]) ReduceRange(start, end uint64, f func(T, T) T) (T, error) {
-	if l.Head == nil {
+	if l.head == nil {
 		var rVal T
-		return rVal, errors.New(ErrListIsEmpty)
-	}
-
-	if start > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		start = start % l.size
+		return rVal, ErrEmpty
 	}
 
-	if end > l.size {
-		// This is a circular list, so when the index is bigger than the size
-		// we need to calculate the real index
-		end = end % l.size
-	}
+	start, end = l.wrapPosition(start), l.wrapPosition(end)
 
 	if start > end {
 		var rVal T
-		return rVal, errors.New(ErrIndexOutOfBound)
+		return rVal, ErrOutOfBounds
 	}
 
-	current := l.Head
-	for i := uint64(0); i < start; i++ {
-		current = current.Next
-		if current == l.Head {
+	current := l.nodeAt(start)
+
+	result := current.value
+	current = current.next
+	for i := start; i < end; i++ {
+		result = f(result, current.value)
+		current = current.next
+		if current == l.head {
 			break
 		}
 	}
 
-	result := current.Value
-	current = current.Next
-	for i := start; i < end; i++ {
-		result = f(result, current.Value)
-		current = current.Next
-		if current == l.Head {
+	return result, nil
+}
+
+// Sort sorts the list according to the given function (using a stable merge sort)
+// for example, to sort a list of integers in ascending order, use:
+// list.Sort(func(a, b int) bool { return a < b })
+func (l *CircularLinkList[T]) Sort(less func(T, T) bool) {
+	if l.head == nil || l.head == l.tail {
+		return
+	}
+
+	// break the circle, sort the chain and then close it again
+	l.tail.next = nil
+	l.head = mergeSort(l.head, less)
+
+	current := l.head
+	for current.next != nil {
+		current = current.next
+	}
+	l.tail = current
+	l.tail.next = l.head
+}
+
+// IsSorted returns true if the list is sorted according to the given function
+// (starting from the head)
+func (l *CircularLinkList[T]) IsSorted(less func(T, T) bool) bool {
+	if l.head == nil {
+		return true
+	}
+	for current := l.head; current != l.tail; current = current.next {
+		if less(current.next.value, current.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare compares the list with other lexicographically (like bytes.Compare),
+// element by element from the head with compare, which returns a negative
+// number, zero or a positive number like cmp.Compare. It returns -1, 0 or +1,
+// a list that is a prefix of the other is the smaller one
+func (l *CircularLinkList[T]) Compare(other *CircularLinkList[T], compare func(a, b T) int) int {
+	a, o := l.head, other.head
+	for i := uint64(0); i < min(l.size, other.size); i++ {
+		if c := compare(a.value, o.value); c != 0 {
+			return cmp.Compare(c, 0)
+		}
+		a, o = a.next, o.next
+	}
+	return cmp.Compare(l.size, other.size)
+}
+
+// Unique removes consecutive duplicate values (from the head to the tail),
+// keeping the first node of each run
+func (l *CircularLinkList[T]) Unique() {
+	l.removeNextIf(func(prev, next T) bool { return l.equal(prev, next) })
+}
+
+// Deduplicate removes all duplicate values, keeping the first occurrence
+// (from the head to the tail) of each one
+func (l *CircularLinkList[T]) Deduplicate() {
+	if l.head == nil {
+		return
+	}
+
+	seen := l.seen()
+	seen.Add(l.head.value)
+	l.removeNextIf(func(_, next T) bool { return !seen.Add(next) })
+}
+
+// removeNextIf walks the list from the head to the tail removing every node
+// for which f(value of the previous kept node, value of the node) is true,
+// the head is never removed
+func (l *CircularLinkList[T]) removeNextIf(f func(prev, next T) bool) {
+	if l.head == nil {
+		return
+	}
+
+	current := l.head
+	for current != l.tail {
+		if f(current.value, current.next.value) {
+			l.deleteAfter(current)
+		} else {
+			current = current.next
+		}
+	}
+}
+
+// Rotate advances the head of the list by n positions (the node at index n
+// becomes the new head), n can be bigger than the size of the list
+func (l *CircularLinkList[T]) Rotate(n uint64) {
+	if l.head == nil {
+		return
+	}
+	n = n % l.size
+	if n == 0 {
+		return
+	}
+
+	// the new tail is the node right before the new head
+	tail := l.head
+	for i := uint64(1); i < n; i++ {
+		tail = tail.next
+	}
+	l.tail = tail
+	l.head = tail.next
+}
+
+// RotateLeft rotates all the values to the left by n positions, like Rotate
+// (the names match the Buffer ones)
+func (l *CircularLinkList[T]) RotateLeft(n uint64) {
+	l.Rotate(n)
+}
+
+// RotateRight rotates all the values to the right by n positions (the last n
+// nodes become the first ones), n can be bigger than the size of the list
+func (l *CircularLinkList[T]) RotateRight(n uint64) {
+	if l.head == nil {
+		return
+	}
+	l.Rotate(l.size - n%l.size)
+}
+
+// RotateTo rotates the list so that the first node with the given value
+// becomes the head
+func (l *CircularLinkList[T]) RotateTo(value T) error {
+	if l.head == nil {
+		return ErrNotFound
+	}
+
+	prev, current := l.tail, l.head
+	for {
+		if l.equal(current.value, value) {
+			l.head = current
+			l.tail = prev
+			return nil
+		}
+		prev, current = current, current.next
+		if current == l.head {
 			break
 		}
 	}
 
+	return ErrNotFound
+}
+
+// Cursor walks a circular list indefinitely, wrapping around from the tail to
+// the head. It's meant for round-robin style iteration, for example:
+//
+//	c := list.Cursor()
+//	for v, ok := c.Next(); ok; v, ok = c.Next() { ... }
+//
+// The cursor keeps track of the last node it returned, so if that node is
+// removed from the list the cursor should be Reset
+type Cursor[T any] struct {
+	list *CircularLinkList[T]
+	node *Node[T]
+}
+
+// Cursor returns a new cursor positioned before the head of the list
+func (l *CircularLinkList[T]) Cursor() *Cursor[T] {
+	return &Cursor[T]{list: l}
+}
+
+// Next moves the cursor to the next node and returns its value (the first
+// call returns the head), the boolean is false only if the list is empty
+func (c *Cursor[T]) Next() (T, bool) {
+	if c.list.head == nil {
+		c.node = nil
+		var zero T
+		return zero, false
+	}
+	if c.node == nil {
+		c.node = c.list.head
+	} else {
+		c.node = c.node.next
+	}
+	return c.node.value, true
+}
+
+// Current returns the value returned by the last call to Next, the boolean
+// is false if Next has not been called yet (or the list is empty)
+func (c *Cursor[T]) Current() (T, bool) {
+	if c.node == nil {
+		var zero T
+		return zero, false
+	}
+	return c.node.value, true
+}
+
+// Node returns the node the cursor is on (nil if Next has not been called yet)
+func (c *Cursor[T]) Node() *Node[T] {
+	return c.node
+}
+
+// Reset moves the cursor back before the head of the list
+func (c *Cursor[T]) Reset() {
+	c.node = nil
+}
+
+// mergeSort sorts the nil-terminated chain of nodes starting at head and
+// returns the new head
+func mergeSort[T any](head *Node[T], less func(T, T) bool) *Node[T] {
+	if head == nil || head.next == nil {
+		return head
+	}
+
+	// find the middle of the chain (slow/fast pointers) and split it
+	slow, fast := head, head.next
+	for fast != nil && fast.next != nil {
+		slow = slow.next
+		fast = fast.next.next
+	}
+	right := slow.next
+	slow.next = nil
+
+	return merge(mergeSort(head, less), mergeSort(right, less), less)
+}
+
+// merge merges two sorted chains of nodes, on equal values the left one goes first
+func merge[T any](left, right *Node[T], less func(T, T) bool) *Node[T] {
+	var dummy Node[T]
+	tail := &dummy
+	for left != nil && right != nil {
+		if less(right.value, left.value) {
+			tail.next = right
+			right = right.next
+		} else {
+			tail.next = left
+			left = left.next
+		}
+		tail = tail.next
+	}
+	if left != nil {
+		tail.next = left
+	} else {
+		tail.next = right
+	}
+	return dummy.next
+}
+
+// Join returns a new list with the values of all the given lists in order
+// (nil lists are skipped), it's the inverse of Chunk. The new list compares
+// its values like the first list (with == if all of them are nil)
+func Join[T any](parts ...*CircularLinkList[T]) *CircularLinkList[T] {
+	var first *CircularLinkList[T]
+	var items []T
+	for _, part := range parts {
+		if part != nil {
+			if first == nil {
+				first = part
+			}
+			items = append(items, part.ToSlice()...)
+		}
+	}
+	if first == nil {
+		return NewWithComparator[T](nil)
+	}
+	return first.fromSlice(items)
+}
+
+// Flatten returns a new list with the values of all the lists in l in order,
+// compared like the ones of the first list (see Join)
+func Flatten[T any](l *CircularLinkList[*CircularLinkList[T]]) *CircularLinkList[T] {
+	return Join(l.ToSlice()...)
+}
+
+// Min returns the smallest element of the list according to less (the first
+// one if there are several), or ErrEmpty if the list is empty
+func Min[T any](l *CircularLinkList[T], less func(T, T) bool) (T, error) {
+	if l.head == nil {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+
+	result := l.head.value
+	for current := l.head.next; current != l.head; current = current.next {
+		if less(current.value, result) {
+			result = current.value
+		}
+	}
 	return result, nil
 }
+
+// Max returns the largest element of the list according to less (the first
+// one if there are several), or ErrEmpty if the list is empty
+func Max[T any](l *CircularLinkList[T], less func(T, T) bool) (T, error) {
+	return Min(l, func(x, y T) bool { return less(y, x) })
+}
+
+// MinOrdered returns the smallest element of a list of an ordered type
+func MinOrdered[T cmp.Ordered](l *CircularLinkList[T]) (T, error) {
+	return Min(l, cmp.Less[T])
+}
+
+// MaxOrdered returns the largest element of a list of an ordered type
+func MaxOrdered[T cmp.Ordered](l *CircularLinkList[T]) (T, error) {
+	return Max(l, cmp.Less[T])
+}
+
+// Sum returns the sum of all the elements of a numeric list (0 if it's empty)
+func Sum[T common.Number](l *CircularLinkList[T]) T {
+	return ReduceInto(l, T(0), func(acc, v T) T { return acc + v })
+}
+
+// Average returns the arithmetic mean of the elements of a numeric list
+// (computed in float64), or ErrEmpty if the list is empty
+func Average[T common.Number](l *CircularLinkList[T]) (float64, error) {
+	if l.head == nil {
+		return 0, ErrEmpty
+	}
+
+	var sum float64
+	var count uint64
+	current := l.head
+	for {
+		sum += float64(current.value)
+		count++
+		current = current.next
+		if current == l.head {
+			break
+		}
+	}
+	return sum / float64(count), nil
+}
@@ -16,10 +16,19 @@
 package circularLinkList_test
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math/rand"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
+	ctxtest "github.com/pzaino/gods/internal/ctxtest"
 	"github.com/pzaino/gods/pkg/circularLinkList" // Adjust the import path as necessary
+	common "github.com/pzaino/gods/pkg/common"
 )
 
 const (
@@ -99,7 +108,7 @@ func TestFind(t *testing.T) {
 		t.Fatalf("unexpected  error: %v", err)
 	}
 
-	if node == nil || node.Value != 3 {
+	if node == nil || node.Value() != 3 {
 		t.Fatalf("expected to find node with value 3")
 	}
 }
@@ -137,7 +146,7 @@ func TestGetFirst(t *testing.T) {
 	list := circularLinkList.NewFromSlice([]int{1, 2, 3, 4})
 	node := list.GetFirst()
 
-	if node == nil || node.Value != 1 {
+	if node == nil || node.Value() != 1 {
 		t.Fatalf("expected to get first node with value 1")
 	}
 }
@@ -146,7 +155,7 @@ func TestGetLast(t *testing.T) {
 	list := circularLinkList.NewFromSlice([]int{1, 2, 3, 4})
 	node := list.GetLast()
 
-	if node == nil || node.Value != 4 {
+	if node == nil || node.Value() != 4 {
 		t.Fatalf("expected to get last node with value 4")
 	}
 }
@@ -159,7 +168,7 @@ func TestGetAt(t *testing.T) {
 		t.Fatalf("unexpected error:  %v", err)
 	}
 
-	if node == nil || node.Value != 3 {
+	if node == nil || node.Value() != 3 {
 		t.Fatalf("expected to get node with value 3 at index 2")
 	}
 }
@@ -266,6 +275,40 @@ func TestCopy(t *testing.T) {
 	}
 }
 
+func TestToSliceRangeAndCopyRange(t *testing.T) {
+	list := circularLinkList.New[int]()
+	for _, v := range []int{1, 2, 3, 4, 5} {
+		list.Append(v)
+	}
+
+	tests := []struct {
+		start, end uint64
+		expected   []int
+	}{
+		{0, 5, []int{1, 2, 3, 4, 5}},
+		{1, 3, []int{2, 3}},
+		{4, 5, []int{5}},
+		{2, 2, []int{}},
+		{3, 2, []int{}},
+		{0, 6, []int{}},
+	}
+	for _, test := range tests {
+		if result := list.ToSliceRange(test.start, test.end); !slices.Equal(result, test.expected) {
+			t.Errorf("expected ToSliceRange(%d, %d) to return %v, got %v", test.start, test.end, test.expected, result)
+		}
+		c := list.CopyRange(test.start, test.end)
+		if !slices.Equal(c.ToSlice(), test.expected) || c.Size() != uint64(len(test.expected)) {
+			t.Errorf("expected CopyRange(%d, %d) to return %v, got %v", test.start, test.end, test.expected, c.ToSlice())
+		}
+	}
+
+	c := list.CopyRange(1, 3)
+	c.Append(6)
+	if list.Size() != 5 || !slices.Equal(c.ToSlice(), []int{2, 3, 6}) {
+		t.Errorf("expected the copy to be independent, got %v and %v", list.ToSlice(), c.ToSlice())
+	}
+}
+
 func TestMerge(t *testing.T) {
 	list1 := circularLinkList.New[int]()
 	list1.Append(1)
@@ -295,6 +338,11 @@ func TestMerge(t *testing.T) {
 	if list2.Size() != 0 {
 		t.Fatalf("expected list2 to be empty after merge")
 	}
+
+	list1.Merge(list1)
+	if !slices.Equal(list1.ToSlice(), expected) {
+		t.Fatalf("expected merging the list with itself to leave it unchanged, got %v", list1.ToSlice())
+	}
 }
 
 func TestMap(t *testing.T) {
@@ -686,3 +734,895 @@ func TestCheckSize(t *testing.T) {
 		t.Fatalf(errExpectedLength, expectedSize, actualSize)
 	}
 }
+
+func TestGetAtWrapsAround(t *testing.T) {
+	list := circularLinkList.NewFromSlice([]int{1, 2, 3, 4})
+
+	for i := uint64(0); i < 12; i++ {
+		node, err := list.GetAt(i)
+		if i == 4 {
+			// index == size is out of bounds, only bigger indexes wrap
+			if !errors.Is(err, circularLinkList.ErrOutOfBounds) {
+				t.Fatalf(errExpectedError, circularLinkList.ErrOutOfBounds, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf(errExpectedNoErr, err)
+		}
+		expected := int(i%4) + 1
+		if node.Value() != expected {
+			t.Fatalf(errExpectedValue, expected, node.Value())
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+
+	list := circularLinkList.New[int]()
+	list.Sort(less)
+	if !list.IsSorted(less) {
+		t.Fatalf("expected an empty list to be sorted")
+	}
+
+	list = circularLinkList.NewFromSlice([]int{4, 2, 5, 1, 3})
+	if list.IsSorted(less) {
+		t.Fatalf("expected list not to be sorted")
+	}
+	list.Sort(less)
+
+	expected := []int{1, 2, 3, 4, 5}
+	slice := list.ToSlice()
+	if len(slice) != len(expected) {
+		t.Fatalf(errExpectedLength, len(expected), len(slice))
+	}
+	for i, v := range expected {
+		if slice[i] != v {
+			t.Fatalf(errExpectedValue, v, slice[i])
+		}
+	}
+	if !list.IsSorted(less) {
+		t.Fatalf("expected list to be sorted")
+	}
+	if list.GetLast().Value() != 5 || list.GetLast().Next() != list.GetFirst() {
+		t.Fatalf("expected the tail to be 5 and to point back to the head")
+	}
+}
+
+func checkRing(t *testing.T, list *circularLinkList.CircularLinkList[int], expected []int) {
+	t.Helper()
+	slice := list.ToSlice()
+	if len(slice) != len(expected) {
+		t.Fatalf(errExpectedLength, len(expected), len(slice))
+	}
+	for i, v := range expected {
+		if slice[i] != v {
+			t.Fatalf(errExpectedValue, v, slice[i])
+		}
+	}
+	if list.GetLast().Value() != expected[len(expected)-1] || list.GetLast().Next() != list.GetFirst() {
+		t.Fatalf("expected the tail to be %d and to point back to the head", expected[len(expected)-1])
+	}
+}
+
+func TestRotate(t *testing.T) {
+	list := circularLinkList.New[int]()
+	list.Rotate(3) // no-op on an empty list
+	if !list.IsEmpty() {
+		t.Fatalf("expected list to be empty")
+	}
+
+	list = circularLinkList.NewFromSlice([]int{1, 2, 3, 4, 5})
+	list.Rotate(0)
+	checkRing(t, list, []int{1, 2, 3, 4, 5})
+	list.Rotate(1)
+	checkRing(t, list, []int{2, 3, 4, 5, 1})
+	list.Rotate(3)
+	checkRing(t, list, []int{5, 1, 2, 3, 4})
+	list.Rotate(12) // wraps around
+	checkRing(t, list, []int{2, 3, 4, 5, 1})
+	if list.Size() != 5 {
+		t.Fatalf(errExpectedLength, 5, list.Size())
+	}
+}
+
+func TestRotateLeftAndRight(t *testing.T) {
+	values := []int{1, 2, 3, 4, 5}
+	for _, n := range []uint64{0, 1, 2, 4, 5, 7} {
+		k := int(n % uint64(len(values)))
+		left := append(slices.Clone(values[k:]), values[:k]...)
+		right := append(slices.Clone(values[len(values)-k:]), values[:len(values)-k]...)
+
+		l := circularLinkList.NewFromSlice(values)
+		l.RotateLeft(n)
+		if !slices.Equal(l.ToSlice(), left) || l.CheckInvariants() != nil {
+			t.Errorf("expected RotateLeft(%d) to give %v, got %v (%v)", n, left, l.ToSlice(), l.CheckInvariants())
+		}
+		l.Append(6)
+		if last := l.ToSlice()[len(values)]; last != 6 {
+			t.Errorf("expected 6 to be appended after RotateLeft(%d), got %d", n, last)
+		}
+
+		r := circularLinkList.NewFromSlice(values)
+		r.RotateRight(n)
+		if !slices.Equal(r.ToSlice(), right) || r.CheckInvariants() != nil {
+			t.Errorf("expected RotateRight(%d) to give %v, got %v (%v)", n, right, r.ToSlice(), r.CheckInvariants())
+		}
+	}
+
+	empty := circularLinkList.New[int]()
+	empty.RotateLeft(3)
+	empty.RotateRight(3)
+	if !empty.IsEmpty() {
+		t.Error("expected the empty list to stay empty")
+	}
+}
+
+func TestRotateTo(t *testing.T) {
+	list := circularLinkList.New[int]()
+	if err := list.RotateTo(1); err != circularLinkList.ErrNotFound {
+		t.Fatalf(errExpectedError, errValueNotFound, err)
+	}
+
+	list = circularLinkList.NewFromSlice([]int{1, 2, 3, 4})
+	if err := list.RotateTo(3); err != nil {
+		t.Fatalf(errExpectedNoErr, err)
+	}
+	checkRing(t, list, []int{3, 4, 1, 2})
+	if err := list.RotateTo(3); err != nil {
+		t.Fatalf(errExpectedNoErr, err)
+	}
+	checkRing(t, list, []int{3, 4, 1, 2})
+	if err := list.RotateTo(9); err != circularLinkList.ErrNotFound {
+		t.Fatalf(errExpectedError, errValueNotFound, err)
+	}
+	checkRing(t, list, []int{3, 4, 1, 2})
+
+	// Operations relying on the tail still work after rotating
+	list.Append(5)
+	checkRing(t, list, []int{3, 4, 1, 2, 5})
+}
+
+func TestCursor(t *testing.T) {
+	list := circularLinkList.New[int]()
+	c := list.Cursor()
+	if _, ok := c.Next(); ok {
+		t.Fatalf("expected no value from an empty list")
+	}
+
+	list.Append(1)
+	list.Append(2)
+	list.Append(3)
+	if _, ok := c.Current(); ok {
+		t.Fatalf("expected no current value before calling Next")
+	}
+	for i := 0; i < 7; i++ {
+		v, ok := c.Next()
+		if !ok {
+			t.Fatalf("expected a value at step %d", i)
+		}
+		if v != i%3+1 {
+			t.Fatalf(errExpectedValue, i%3+1, v)
+		}
+	}
+	if v, ok := c.Current(); !ok || v != 1 {
+		t.Fatalf(errExpectedValue, 1, v)
+	}
+	if c.Node() == nil || c.Node().Value() != 1 {
+		t.Fatalf("expected the cursor to be on node 1")
+	}
+
+	c.Reset()
+	if v, _ := c.Next(); v != 1 {
+		t.Fatalf(errExpectedValue, 1, v)
+	}
+
+	list.Clear()
+	if _, ok := c.Next(); ok {
+		t.Fatalf("expected no value after clearing the list")
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	l := circularLinkList.NewWithComparator(slices.Equal[[]int])
+	l.Append([]int{1})
+	l.Append([]int{2, 3})
+	l.Append([]int{4})
+
+	node, err := l.Find([]int{2, 3})
+	if err != nil || !slices.Equal(node.Value(), []int{2, 3}) {
+		t.Errorf("expected to find [2 3], got %v (%v)", node, err)
+	}
+	if err := l.RotateTo([]int{4}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !slices.Equal(l.GetFirst().Value(), []int{4}) {
+		t.Errorf("expected head [4], got %v", l.GetFirst().Value())
+	}
+
+	l.DeleteWithValue([]int{2, 3})
+	if l.Size() != 2 {
+		t.Errorf("expected size 2, got %d", l.Size())
+	}
+	if _, err := l.Copy().Find([]int{1}); err != nil {
+		t.Errorf("expected the copy to keep the comparator, got %v", err)
+	}
+}
+
+func TestNewWithHasher(t *testing.T) {
+	hasher := common.NewHasher(func(v []int) uint64 { return uint64(len(v)) }, slices.Equal[[]int])
+	l := circularLinkList.NewWithHasher(hasher)
+	for _, v := range [][]int{{1}, {2, 3}, {1}, {4}, {2, 3}} {
+		l.Append(v)
+	}
+
+	if _, err := l.Find([]int{3, 2}); err == nil || !l.ContainsAll([]int{4}) {
+		t.Error("expected the hasher equality to be used")
+	}
+	if !l.ContainsAll([]int{4}, []int{1}, []int{2, 3}) || l.ContainsAll([]int{1}, []int{5}) {
+		t.Error("expected ContainsAll to use the hasher")
+	}
+	if !l.ContainsAny([]int{5}, []int{2, 3}) || l.ContainsAny([]int{5}, []int{3, 2}) {
+		t.Error("expected ContainsAny to use the hasher")
+	}
+
+	c := l.Copy()
+	c.Deduplicate()
+	if c.Size() != 3 || l.Size() != 5 {
+		t.Errorf("expected 3 values left in the copy, got %d (original %d)", c.Size(), l.Size())
+	}
+	if !circularLinkList.Features().Has(common.FeatureHasher) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureHasher, circularLinkList.Features())
+	}
+}
+
+func TestReduceInto(t *testing.T) {
+	l := circularLinkList.New[int]()
+	l.Append(1)
+	l.Append(2)
+	l.Append(3)
+	got := circularLinkList.ReduceInto(l, []int{0}, func(acc []int, v int) []int {
+		return append(acc, v*10)
+	})
+	if !slices.Equal(got, []int{0, 10, 20, 30}) {
+		t.Errorf("expected [0 10 20 30], got %v", got)
+	}
+
+	if got := circularLinkList.ReduceInto(circularLinkList.New[int](), 42, func(acc int, _ int) int { return acc + 1 }); got != 42 {
+		t.Errorf("expected 42, got %d", got)
+	}
+}
+
+func TestMapTo(t *testing.T) {
+	type user struct {
+		id   int
+		tags []string
+	}
+	src := circularLinkList.NewWithComparator(func(a, b user) bool { return a.id == b.id })
+	for _, u := range []user{{1, []string{"a"}}, {2, nil}, {3, []string{"b", "c"}}} {
+		src.Append(u)
+	}
+
+	ids := circularLinkList.MapTo(src, func(u user) int { return u.id })
+	if !slices.Equal(ids.ToSlice(), []int{1, 2, 3}) || !ids.ContainsAll(2) {
+		t.Errorf("expected the ids [1 2 3], got %v", ids.ToSlice())
+	}
+
+	parity := circularLinkList.MapTo(src, func(u user) int { return u.id % 2 })
+	parity.Deduplicate()
+	if !slices.Equal(parity.ToSlice(), []int{1, 0}) {
+		t.Errorf("expected the deduplicated parities [1 0], got %v", parity.ToSlice())
+	}
+
+	tags := circularLinkList.MapToWithComparator(src, func(u user) []string { return u.tags }, slices.Equal[[]string])
+	if tags.Size() != 3 || !tags.ContainsAll([]string{"b", "c"}) || tags.ContainsAll([]string{"c", "b"}) {
+		t.Errorf("expected the tags to be compared with the given function")
+	}
+
+	if empty := circularLinkList.MapTo(circularLinkList.New[int](), strconv.Itoa); !empty.IsEmpty() {
+		t.Errorf("expected an empty result, got %d values", empty.Size())
+	}
+}
+
+func TestUniqueAndDeduplicate(t *testing.T) {
+	build := func(values ...int) *circularLinkList.CircularLinkList[int] {
+		l := circularLinkList.New[int]()
+		for _, v := range values {
+			l.Append(v)
+		}
+		return l
+	}
+	values := func(l *circularLinkList.CircularLinkList[int]) []int {
+		return circularLinkList.ReduceInto(l, []int(nil), func(acc []int, v int) []int { return append(acc, v) })
+	}
+
+	l := build(1, 1, 2, 2, 2, 3, 1, 1)
+	l.Unique()
+	if got := values(l); !slices.Equal(got, []int{1, 2, 3, 1}) || l.Size() != 4 {
+		t.Errorf("expected [1 2 3 1], got %v (size %d)", got, l.Size())
+	}
+
+	l = build(3, 1, 3, 2, 1, 4, 2, 2)
+	l.Deduplicate()
+	if got := values(l); !slices.Equal(got, []int{3, 1, 2, 4}) || l.Size() != 4 {
+		t.Errorf("expected [3 1 2 4], got %v (size %d)", got, l.Size())
+	}
+	l.Append(5)
+	if got := values(l); !slices.Equal(got, []int{3, 1, 2, 4, 5}) {
+		t.Errorf("expected [3 1 2 4 5] after Append, got %v", got)
+	}
+
+	custom := circularLinkList.NewWithComparator(slices.Equal[[]int])
+	for _, v := range [][]int{{1}, {2}, {1}, {2}, {2}} {
+		custom.Append(v)
+	}
+	custom.Deduplicate()
+	if custom.Size() != 2 {
+		t.Errorf("expected size 2, got %d", custom.Size())
+	}
+
+	empty := circularLinkList.New[int]()
+	empty.Unique()
+	empty.Deduplicate()
+	if empty.Size() != 0 {
+		t.Errorf("expected empty list, got size %d", empty.Size())
+	}
+}
+
+func TestString(t *testing.T) {
+	l := circularLinkList.New[string]()
+	l.Append("a")
+	l.Append("b")
+
+	var s fmt.Stringer = l
+	if got := s.String(); got != "[a b]" {
+		t.Errorf("expected [a b], got %s", got)
+	}
+	if got := l.StringFunc(func(v string) string { return v + v }); got != "[aa bb]" {
+		t.Errorf("expected [aa bb], got %s", got)
+	}
+	if got := circularLinkList.New[int]().String(); got != "[]" {
+		t.Errorf("expected [], got %s", got)
+	}
+}
+
+func TestForEachErr(t *testing.T) {
+	l := circularLinkList.New[int]()
+	for i := 1; i <= 5; i++ {
+		l.Append(i)
+	}
+
+	errStop := fmt.Errorf("stop")
+	var visited []int
+	err := l.ForEachErr(func(v *int) error {
+		visited = append(visited, *v)
+		if *v == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || !slices.Equal(visited, []int{1, 2, 3}) {
+		t.Errorf("expected to stop at 3 with %v, got %v (%v)", errStop, visited, err)
+	}
+
+	if err := l.ForEachErr(func(v *int) error { *v *= 2; return nil }); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !slices.Equal(l.ToSlice(), []int{2, 4, 6, 8, 10}) {
+		t.Errorf("expected [2 4 6 8 10], got %v", l.ToSlice())
+	}
+	if err := circularLinkList.New[int]().ForEachErr(func(*int) error { return errStop }); err != nil {
+		t.Errorf("expected no error on an empty list, got %v", err)
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	c := circularLinkList.NewFromSlice([]int{3, 1, 4, 1, 5})
+
+	if v, err := circularLinkList.Min(c, func(a, b int) bool { return a < b }); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := circularLinkList.Max(c, func(a, b int) bool { return a < b }); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v, err := circularLinkList.MinOrdered(c); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := circularLinkList.MaxOrdered(c); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v := circularLinkList.Sum(c); v != 14 {
+		t.Errorf("expected sum 14, got %v", v)
+	}
+	if v, err := circularLinkList.Average(c); err != nil || v != 2.8 {
+		t.Errorf("expected average 2.8, got %v (%v)", v, err)
+	}
+
+	e := circularLinkList.New[int]()
+	if _, err := circularLinkList.Min(e, func(a, b int) bool { return a < b }); err != circularLinkList.ErrEmpty {
+		t.Errorf("expected %v, got %v", circularLinkList.ErrEmpty, err)
+	}
+	if _, err := circularLinkList.Average(e); err != circularLinkList.ErrEmpty {
+		t.Errorf("expected %v, got %v", circularLinkList.ErrEmpty, err)
+	}
+	if v := circularLinkList.Sum(e); v != 0 {
+		t.Errorf("expected sum 0, got %v", v)
+	}
+}
+
+func TestCheckInvariants(t *testing.T) {
+	list := circularLinkList.NewFromSlice([]int{1, 2, 3})
+	if err := list.CheckInvariants(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := circularLinkList.New[int]().CheckInvariants(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	head, tail := list.GetFirst(), list.GetLast()
+	circularLinkList.SetEnds(list, head.Next(), tail) // the circle no longer goes through the head
+	if err := list.CheckInvariants(); !errors.Is(err, circularLinkList.ErrCorrupted) {
+		t.Errorf("expected %v, got %v", circularLinkList.ErrCorrupted, err)
+	}
+	circularLinkList.SetEnds(list, head, head.Next()) // the tail is not the last node
+	if err := list.CheckInvariants(); !errors.Is(err, circularLinkList.ErrCorrupted) {
+		t.Errorf("expected %v, got %v", circularLinkList.ErrCorrupted, err)
+	}
+}
+
+// FuzzInvariants applies a sequence of operations (one per input byte) and
+// checks the invariants of the lists after each of them
+func FuzzInvariants(f *testing.F) {
+	f.Add([]byte{0, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11})
+	f.Add([]byte{0, 16, 32, 48, 3, 0, 17, 7, 2, 6, 1, 8, 58, 59, 11, 11})
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		list, other := circularLinkList.New[int](), circularLinkList.New[int]()
+		for i, op := range ops {
+			v := int(op >> 4)
+			switch op % 12 {
+			case 0:
+				list.Append(v)
+			case 1:
+				list.Prepend(v)
+			case 2:
+				list.DeleteWithValue(v)
+			case 3:
+				list.Filter(func(x int) bool { return x%2 == 0 })
+			case 4:
+				list.Unique()
+			case 5:
+				list.Deduplicate()
+			case 6:
+				other.Append(v)
+				list.Merge(other)
+			case 7:
+				list.Reverse()
+				list.Sort(func(a, b int) bool { return a < b })
+			case 8:
+				list.Rotate(uint64(v))
+				_ = list.RotateTo(v)
+			case 9:
+				other = list.Copy()
+				list.Clear()
+				list.Merge(other)
+			case 10:
+				_ = list.InsertAt(uint64(v), v)
+			case 11:
+				_ = list.DeleteAt(uint64(v))
+			}
+			for _, l := range []*circularLinkList.CircularLinkList[int]{list, other} {
+				if err := l.CheckInvariants(); err != nil {
+					t.Fatalf("after operation %d (%d): %v", i, op%12, err)
+				}
+			}
+		}
+	})
+}
+
+// TestSizeProperties applies random insertions and deletions to a list and to
+// a slice used as a model, the list must always hold the same values and its
+// size must match the number of values
+func TestSizeProperties(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < 100; run++ {
+		list := circularLinkList.New[int]()
+		var model []int
+		for step := 0; step < 200; step++ {
+			v := rng.Intn(10)
+			switch size := len(model); rng.Intn(6) {
+			case 0:
+				list.Append(v)
+				model = append(model, v)
+			case 1:
+				list.Prepend(v)
+				model = slices.Insert(model, 0, v)
+			case 2:
+				index := rng.Intn(size + 1)
+				if err := list.InsertAt(uint64(index), v); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				model = slices.Insert(model, index, v)
+			case 3:
+				if size == 0 {
+					if err := list.DeleteAt(0); !errors.Is(err, circularLinkList.ErrOutOfBounds) {
+						t.Fatalf("expected %v, got %v", circularLinkList.ErrOutOfBounds, err)
+					}
+					continue
+				}
+				index := rng.Intn(size)
+				if err := list.DeleteAt(uint64(index)); err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				model = slices.Delete(model, index, index+1)
+			case 4:
+				list.DeleteWithValue(v)
+				if i := slices.Index(model, v); i >= 0 {
+					model = slices.Delete(model, i, i+1)
+				}
+			case 5:
+				list.Filter(func(x int) bool { return x != v })
+				model = slices.DeleteFunc(model, func(x int) bool { return x == v })
+			}
+
+			if list.Size() != uint64(len(model)) || !slices.Equal(list.ToSlice(), model) {
+				t.Fatalf("run %d step %d: expected %v (size %d), got %v (size %d)",
+					run, step, model, len(model), list.ToSlice(), list.Size())
+			}
+			if err := list.CheckInvariants(); err != nil {
+				t.Fatalf("run %d step %d: %v", run, step, err)
+			}
+		}
+	}
+}
+
+func TestChunkWindows(t *testing.T) {
+	list := circularLinkList.NewFromSlice([]int{1, 2, 3, 4, 5})
+
+	chunks := list.Chunk(2)
+	expected := [][]int{{1, 2}, {3, 4}, {5}}
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+	for i, c := range chunks {
+		if !slices.Equal(c.ToSlice(), expected[i]) {
+			t.Errorf("expected chunk %d to be %v, got %v", i, expected[i], c.ToSlice())
+		}
+		if err := c.CheckInvariants(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	windows := list.Windows(3)
+	expected = [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}
+	if len(windows) != len(expected) {
+		t.Fatalf("expected %d windows, got %d", len(expected), len(windows))
+	}
+	for i, w := range windows {
+		if !slices.Equal(w.ToSlice(), expected[i]) {
+			t.Errorf("expected window %d to be %v, got %v", i, expected[i], w.ToSlice())
+		}
+	}
+
+	// The chunks are copies
+	chunks[0].Clear()
+	if list.Size() != 5 {
+		t.Errorf("expected size 5, got %d", list.Size())
+	}
+	if list.Chunk(0) != nil || list.Windows(0) != nil || list.Windows(6) != nil {
+		t.Error("expected nil for invalid sizes")
+	}
+}
+
+func TestJoinFlatten(t *testing.T) {
+	list := circularLinkList.NewFromSlice([]int{1, 2, 3, 4, 5})
+	chunks := list.Chunk(2)
+
+	joined := circularLinkList.Join(append(chunks, nil)...)
+	if !slices.Equal(joined.ToSlice(), []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected [1 2 3 4 5], got %v", joined.ToSlice())
+	}
+
+	nested := circularLinkList.NewFromSlice(chunks)
+	flat := circularLinkList.Flatten(nested)
+	if !slices.Equal(flat.ToSlice(), []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected [1 2 3 4 5], got %v", flat.ToSlice())
+	}
+	if !circularLinkList.Join[int]().IsEmpty() {
+		t.Error("expected an empty result joining nothing")
+	}
+
+	// The result compares its values like the first part
+	a, b := circularLinkList.NewWithComparator(slices.Equal[[]int]), circularLinkList.NewWithComparator(slices.Equal[[]int])
+	a.Append([]int{1})
+	a.Append([]int{2})
+	b.Append([]int{3})
+	if all := circularLinkList.Join(nil, a, b); all.Size() != 3 || !all.ContainsAll([]int{3}) {
+		t.Errorf("expected 3 values compared with slices.Equal, got %v", all.ToSlice())
+	}
+}
+
+func TestIndexed(t *testing.T) {
+	list := circularLinkList.NewFromSlice([]int{10, 20, 30})
+	list.ForEachIndexed(func(i uint64, v *int) {
+		*v += int(i)
+	})
+	if !slices.Equal(list.ToSlice(), []int{10, 21, 32}) {
+		t.Errorf("expected [10 21 32], got %v", list.ToSlice())
+	}
+
+	mapped := list.MapIndexed(func(i uint64, v int) int { return v * int(i) })
+	if !slices.Equal(mapped.ToSlice(), []int{0, 21, 64}) {
+		t.Errorf("expected [0 21 64], got %v", mapped.ToSlice())
+	}
+	if err := mapped.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := circularLinkList.New[*int]()
+	c.Append(&a)
+	c.Append(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestRemoveIf(t *testing.T) {
+	c := circularLinkList.NewFromSlice([]int{1, 2, 1, 3, 1, 2})
+
+	if n := c.RemoveFirstN(1, 2); n != 2 || !slices.Equal(c.ToSlice(), []int{2, 3, 1, 2}) {
+		t.Errorf("expected to remove 2 elements leaving 2, 3, 1, 2}, removed %d leaving %v", n, c.ToSlice())
+	}
+	if n := c.RemoveAll(2); n != 2 || !slices.Equal(c.ToSlice(), []int{3, 1}) {
+		t.Errorf("expected to remove 2 elements leaving 3, 1}, removed %d leaving %v", n, c.ToSlice())
+	}
+	if n := c.RemoveFirstN(1, 5); n != 1 {
+		t.Errorf("expected to remove 1 element, removed %d", n)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v > 10 }); n != 0 {
+		t.Errorf("expected to remove nothing, removed %d", n)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v == 3 }); n != 1 || c.Size() != 0 {
+		t.Errorf("expected to remove the last element, removed %d leaving %v", n, c.ToSlice())
+	}
+}
+
+func TestForEachSafe(t *testing.T) {
+	c := circularLinkList.NewFromSlice([]int{1, 2, 3, 4, 5, 6})
+
+	var visited []int
+	c.ForEachSafe(func(v int) bool {
+		visited = append(visited, v)
+		return v%2 == 1 || v == 6
+	})
+	if !slices.Equal(visited, []int{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("expected to visit [1 2 3 4 5 6], got %v", visited)
+	}
+	if !slices.Equal(c.ToSlice(), []int{2, 4}) {
+		t.Errorf("expected [2 4], got %v", c.ToSlice())
+	}
+	if err := c.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+
+	c.ForEachSafe(func(int) bool { return true })
+	if !c.IsEmpty() {
+		t.Errorf("expected an empty list, got %v", c.ToSlice())
+	}
+}
+
+func TestContainsAllAny(t *testing.T) {
+	c := circularLinkList.NewFromSlice([]int{1, 2, 1, 3})
+
+	if !c.ContainsAll(3, 1, 1) || !c.ContainsAll() || c.ContainsAll(1, 4) {
+		t.Error("expected ContainsAll to be true only when every element is present")
+	}
+	if !c.ContainsAny(4, 2) || c.ContainsAny(4, 5) || c.ContainsAny() {
+		t.Error("expected ContainsAny to be true only when an element is present")
+	}
+	if c.CountOf(1) != 2 || c.CountOf(3) != 1 || c.CountOf(4) != 0 {
+		t.Errorf("expected counts 2, 1 and 0, got %d, %d and %d", c.CountOf(1), c.CountOf(3), c.CountOf(4))
+	}
+}
+
+func TestBoundaryIndexes(t *testing.T) {
+	empty := circularLinkList.New[int]()
+	if _, err := empty.GetAt(0); !errors.Is(err, circularLinkList.ErrOutOfBounds) {
+		t.Errorf(errExpectedError, circularLinkList.ErrOutOfBounds, err)
+	}
+	if err := empty.DeleteAt(0); !errors.Is(err, circularLinkList.ErrOutOfBounds) {
+		t.Errorf(errExpectedError, circularLinkList.ErrOutOfBounds, err)
+	}
+
+	// Indexes of non-empty lists bigger than the size wrap around, index ==
+	// size is the end of the list for InsertAt and out of bounds for the
+	// methods that take the index of a node
+	for size := 1; size <= 3; size++ {
+		items := make([]int, size)
+		for i := range items {
+			items[i] = i + 1
+		}
+		for index := 0; index <= 2*size+1; index++ {
+			list := circularLinkList.NewFromSlice(items)
+			if index == size {
+				checkOutOfBounds(t, list, uint64(index))
+				if err := list.DeleteAt(uint64(index)); !errors.Is(err, circularLinkList.ErrOutOfBounds) {
+					t.Errorf("size %d, DeleteAt(%d): expected %v, got %v", size, index, circularLinkList.ErrOutOfBounds, err)
+				}
+				if err := list.CheckInvariants(); err != nil || !slices.Equal(list.ToSlice(), items) {
+					t.Errorf("size %d, DeleteAt(%d): expected %v, got %v (%v)", size, index, items, list.ToSlice(), err)
+				}
+			} else {
+				node, err := list.GetAt(uint64(index))
+				if err != nil || node.Value() != items[index%size] {
+					t.Errorf("size %d, GetAt(%d): expected %d, got %v (%v)", size, index, items[index%size], node, err)
+				}
+				if err := list.DeleteAt(uint64(index)); err != nil {
+					t.Fatalf(errExpectedNoErr, err)
+				}
+				expected := slices.Delete(slices.Clone(items), index%size, index%size+1)
+				if err := list.CheckInvariants(); err != nil || !slices.Equal(list.ToSlice(), expected) {
+					t.Errorf("size %d, DeleteAt(%d): expected %v, got %v (%v)", size, index, expected, list.ToSlice(), err)
+				}
+			}
+
+			list = circularLinkList.NewFromSlice(items)
+			position := index
+			if position > size {
+				position %= size
+			}
+			if err := list.InsertAt(uint64(index), 0); err != nil {
+				t.Fatalf(errExpectedNoErr, err)
+			}
+			expected := slices.Insert(slices.Clone(items), position, 0)
+			if err := list.CheckInvariants(); err != nil || !slices.Equal(list.ToSlice(), expected) {
+				t.Errorf("size %d, InsertAt(%d): expected %v, got %v (%v)", size, index, expected, list.ToSlice(), err)
+			}
+		}
+	}
+}
+
+// checkOutOfBounds checks that the methods that take the index of a node reject
+// index
+func checkOutOfBounds(t *testing.T, list *circularLinkList.CircularLinkList[int], index uint64) {
+	t.Helper()
+	if _, err := list.GetAt(index); !errors.Is(err, circularLinkList.ErrOutOfBounds) {
+		t.Errorf("GetAt(%d): expected %v, got %v", index, circularLinkList.ErrOutOfBounds, err)
+	}
+	if _, err := list.MapFrom(index, func(v int) int { return v }); !errors.Is(err, circularLinkList.ErrOutOfBounds) {
+		t.Errorf("MapFrom(%d): expected %v, got %v", index, circularLinkList.ErrOutOfBounds, err)
+	}
+	if err := list.ForFrom(index, func(*int) {}); !errors.Is(err, circularLinkList.ErrOutOfBounds) {
+		t.Errorf("ForFrom(%d): expected %v, got %v", index, circularLinkList.ErrOutOfBounds, err)
+	}
+	if _, err := list.ReduceFrom(index, func(a, b int) int { return a + b }); !errors.Is(err, circularLinkList.ErrOutOfBounds) {
+		t.Errorf("ReduceFrom(%d): expected %v, got %v", index, circularLinkList.ErrOutOfBounds, err)
+	}
+}
+
+func TestDeleteAtSingleElement(t *testing.T) {
+	list := circularLinkList.NewFromSlice([]int{1})
+	if err := list.DeleteAt(0); err != nil {
+		t.Fatalf(errExpectedNoErr, err)
+	}
+	if list.GetFirst() != nil || list.GetLast() != nil || !list.IsEmpty() {
+		t.Errorf("expected the head and the tail to be nil, got %v and %v", list.GetFirst(), list.GetLast())
+	}
+
+	// The list must still be usable
+	list.Append(2)
+	checkRing(t, list, []int{2})
+}
+
+func TestWipe(t *testing.T) {
+	list := circularLinkList.New[string]()
+	for _, v := range []string{"key", "token", "secret"} {
+		list.Append(v)
+	}
+	nodes := make([]*circularLinkList.Node[string], 0, 3)
+	for i := uint64(0); i < 3; i++ {
+		node, _ := list.GetAt(i)
+		nodes = append(nodes, node)
+	}
+
+	list.Wipe()
+	if !list.IsEmpty() {
+		t.Errorf("Expected an empty list, got %v", list.ToSlice())
+	}
+	for i, node := range nodes {
+		if node.Value() != "" || node.Next() != nil {
+			t.Errorf("Expected node %d to be wiped and unlinked, got %q", i, node.Value())
+		}
+	}
+}
+
+func TestCtxVariants(t *testing.T) {
+	ctxtest.Run[*circularLinkList.CircularLinkList[int], *circularLinkList.Node[int]](t, circularLinkList.New[int], circularLinkList.ErrNotFound)
+}
+
+func TestJSONStream(t *testing.T) {
+	if !circularLinkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, circularLinkList.Features())
+	}
+	l := circularLinkList.New[int]()
+	for _, v := range []int{1, 2, 3} {
+		l.Append(v)
+	}
+
+	var sb strings.Builder
+	if err := l.EncodeJSONStream(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sb.String() != "[1,2,3]" {
+		t.Errorf("expected [1,2,3], got %s", sb.String())
+	}
+
+	got := circularLinkList.New[int]()
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader(sb.String()))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader("[4] null"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got.ToSlice(), []int{1, 2, 3, 4}) {
+		t.Errorf("expected [1 2 3 4], got %v", got.ToSlice())
+	}
+
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader(`{"a": 5}`))); !errors.Is(err, common.ErrNotJSONArray) {
+		t.Errorf("expected %v, got %v", common.ErrNotJSONArray, err)
+	}
+
+	var empty strings.Builder
+	if err := circularLinkList.New[int]().EncodeJSONStream(&empty); err != nil || empty.String() != "[]" {
+		t.Errorf("expected [], got %s (%v)", empty.String(), err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *circularLinkList.CircularLinkList[int] {
+		l := circularLinkList.New[int]()
+		for _, v := range values {
+			l.Append(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	// The result is normalized to -1, 0 or +1
+	if got := build(1).Compare(build(10), func(a, b int) int { return a - b }); got != -1 {
+		t.Errorf("expected -1, got %d", got)
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+// Binary format used by the MarshalBinary methods of the containers:
+//
+//	magic   4 bytes  "gods"
+//	version 1 byte   BinaryVersion
+//	count   uvarint  number of elements
+//	...              container specific fields
+//	element uvarint length followed by the bytes produced by the Codec (count times)
+//
+// A reader rejects the versions it doesn't know, so the format can evolve by
+// bumping the version
+const BinaryVersion = 1
+
+// binaryMagic identifies the data produced by the MarshalBinary methods
+const binaryMagic = "gods"
+
+// Sentinel errors returned when decoding binary data (use errors.Is to check for them)
+var (
+	ErrInvalidFormat      = errors.New("invalid binary format")
+	ErrUnsupportedVersion = errors.New("unsupported binary format version")
+)
+
+// Codec encodes and decodes single elements for the MarshalBinary and
+// UnmarshalBinary methods of the containers
+type Codec[T any] interface {
+	Encode(v T) ([]byte, error)
+	Decode(data []byte) (T, error)
+}
+
+// GobCodec encodes every element with encoding/gob, it works with most types
+// (the exported fields of structs) but it's slow and verbose. It's the
+// default codec of the containers
+type GobCodec[T any] struct{}
+
+// Encode encodes v with encoding/gob
+func (GobCodec[T]) Encode(v T) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode decodes a value encoded by Encode
+func (GobCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&v)
+	return v, err
+}
+
+// BinaryCodec encodes every element with encoding/binary in little-endian
+// order, it's compact and fast but only works with fixed-size types (numbers,
+// bools and arrays or structs of them)
+type BinaryCodec[T any] struct{}
+
+// Encode encodes v with encoding/binary
+func (BinaryCodec[T]) Encode(v T) ([]byte, error) {
+	return binary.Append(nil, binary.LittleEndian, v)
+}
+
+// Decode decodes a value encoded by Encode
+func (BinaryCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	n, err := binary.Decode(data, binary.LittleEndian, &v)
+	if err == nil && n != len(data) {
+		err = fmt.Errorf("%w: %d trailing bytes after an element", ErrInvalidFormat, len(data)-n)
+	}
+	return v, err
+}
+
+// AppendBinaryHeader appends the header of the binary format for count elements to dst
+func AppendBinaryHeader(dst []byte, count uint64) []byte {
+	dst = append(dst, binaryMagic...)
+	dst = append(dst, BinaryVersion)
+	return binary.AppendUvarint(dst, count)
+}
+
+// ReadBinaryHeader checks the header of the binary format at the start of
+// data, it returns the number of elements and the rest of the data
+func ReadBinaryHeader(data []byte) (uint64, []byte, error) {
+	if len(data) < len(binaryMagic)+1 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return 0, nil, fmt.Errorf("%w: missing header", ErrInvalidFormat)
+	}
+	if version := data[len(binaryMagic)]; version != BinaryVersion {
+		return 0, nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, version)
+	}
+	return ReadUvarint(data[len(binaryMagic)+1:])
+}
+
+// ReadUvarint reads an unsigned varint at the start of data, it returns the
+// value and the rest of the data
+func ReadUvarint(data []byte) (uint64, []byte, error) {
+	v, n := binary.Uvarint(data)
+	if n <= 0 {
+		return 0, nil, fmt.Errorf("%w: truncated or overflowing varint", ErrInvalidFormat)
+	}
+	return v, data[n:], nil
+}
+
+// AppendElements appends the length-prefixed encoding of items to dst
+func AppendElements[T any](dst []byte, codec Codec[T], items []T) ([]byte, error) {
+	for _, v := range items {
+		enc, err := codec.Encode(v)
+		if err != nil {
+			return nil, err
+		}
+		dst = binary.AppendUvarint(dst, uint64(len(enc)))
+		dst = append(dst, enc...)
+	}
+	return dst, nil
+}
+
+// ReadElements decodes count length-prefixed elements at the start of data,
+// it returns the elements and the rest of the data
+func ReadElements[T any](data []byte, codec Codec[T], count uint64) ([]T, []byte, error) {
+	// Every element takes at least a byte, don't trust count for the allocation
+	items := make([]T, 0, min(count, uint64(len(data))))
+	for i := uint64(0); i < count; i++ {
+		n, rest, err := ReadUvarint(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if n > uint64(len(rest)) {
+			return nil, nil, fmt.Errorf("%w: element %d is truncated", ErrInvalidFormat, i)
+		}
+		v, err := codec.Decode(rest[:n])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: element %d: %w", ErrInvalidFormat, i, err)
+		}
+		items = append(items, v)
+		data = rest[n:]
+	}
+	return items, data, nil
+}
+
+// MarshalElements encodes items in the binary format (header and elements)
+func MarshalElements[T any](codec Codec[T], items []T) ([]byte, error) {
+	return AppendElements(AppendBinaryHeader(nil, uint64(len(items))), codec, items)
+}
+
+// UnmarshalElements decodes the data produced by MarshalElements, trailing
+// data is an error
+func UnmarshalElements[T any](codec Codec[T], data []byte) ([]T, error) {
+	count, data, err := ReadBinaryHeader(data)
+	if err != nil {
+		return nil, err
+	}
+	items, data, err := ReadElements(data, codec, count)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidFormat, len(data))
+	}
+	return items, nil
+}
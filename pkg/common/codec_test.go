@@ -0,0 +1,78 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+type point struct {
+	X, Y int32
+}
+
+func TestMarshalElements(t *testing.T) {
+	tests := []struct {
+		name  string
+		codec common.Codec[point]
+	}{
+		{"gob", common.GobCodec[point]{}},
+		{"binary", common.BinaryCodec[point]{}},
+	}
+	items := []point{{1, 2}, {-3, 4}, {0, 0}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := common.MarshalElements(tt.codec, items)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got, err := common.UnmarshalElements(tt.codec, data)
+			if err != nil || !reflect.DeepEqual(got, items) {
+				t.Errorf("expected %v, got %v (%v)", items, got, err)
+			}
+
+			// Truncated and trailing data
+			if _, err := common.UnmarshalElements(tt.codec, data[:len(data)-1]); !errors.Is(err, common.ErrInvalidFormat) {
+				t.Errorf("expected %v, got %v", common.ErrInvalidFormat, err)
+			}
+			if _, err := common.UnmarshalElements(tt.codec, append(data, 0)); !errors.Is(err, common.ErrInvalidFormat) {
+				t.Errorf("expected %v, got %v", common.ErrInvalidFormat, err)
+			}
+		})
+	}
+}
+
+func TestBinaryHeader(t *testing.T) {
+	data := common.AppendBinaryHeader(nil, 300)
+	count, rest, err := common.ReadBinaryHeader(data)
+	if err != nil || count != 300 || len(rest) != 0 {
+		t.Errorf("expected 300 and no data left, got %d and %d bytes (%v)", count, len(rest), err)
+	}
+
+	if _, _, err := common.ReadBinaryHeader([]byte("nope\x01\x00")); !errors.Is(err, common.ErrInvalidFormat) {
+		t.Errorf("expected %v, got %v", common.ErrInvalidFormat, err)
+	}
+	future := append([]byte(nil), data...)
+	future[4] = common.BinaryVersion + 1
+	if _, _, err := common.ReadBinaryHeader(future); !errors.Is(err, common.ErrUnsupportedVersion) {
+		t.Errorf("expected %v, got %v", common.ErrUnsupportedVersion, err)
+	}
+	if _, _, err := common.ReadBinaryHeader(data[:5]); !errors.Is(err, common.ErrInvalidFormat) {
+		t.Errorf("expected %v, got %v", common.ErrInvalidFormat, err)
+	}
+}
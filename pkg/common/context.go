@@ -0,0 +1,32 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import "context"
+
+// CtxCheckInterval is the number of elements the *Ctx methods of the
+// containers (ForEachCtx, MapCtx, FindCtx, ...) process between two checks of
+// their context, checking it for every element would slow down short loops
+const CtxCheckInterval = 1024
+
+// CtxErr returns the error of ctx when i (the number of elements processed so
+// far) is a multiple of CtxCheckInterval and nil otherwise, so a loop calling
+// it for every element checks ctx before the first one and then periodically
+func CtxErr(ctx context.Context, i uint64) error {
+	if i%CtxCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
+}
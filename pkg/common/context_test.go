@@ -0,0 +1,40 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+func TestCtxErr(t *testing.T) {
+	if err := common.CtxErr(context.Background(), 0); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	for _, i := range []uint64{0, common.CtxCheckInterval, 3 * common.CtxCheckInterval} {
+		if err := common.CtxErr(ctx, i); !errors.Is(err, context.Canceled) {
+			t.Errorf("expected %v at %d, got %v", context.Canceled, i, err)
+		}
+	}
+	if err := common.CtxErr(ctx, common.CtxCheckInterval+1); err != nil {
+		t.Errorf("expected the context not to be checked, got %v", err)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+// EqualFunc reports whether two values are equal, it's used by the containers
+// to compare elements of types that are not comparable (slices, maps, structs
+// containing them, ...)
+type EqualFunc[T any] func(a, b T) bool
+
+// Equal is the default EqualFunc for comparable types (it uses ==)
+func Equal[T comparable](a, b T) bool {
+	return a == b
+}
+
+// Equals compares a and b with eq, or with == (on the dynamic values) when eq
+// is nil. In the latter case it panics if T is not comparable, so containers
+// of non-comparable types must always be created with an EqualFunc
+func Equals[T any](eq EqualFunc[T], a, b T) bool {
+	if eq != nil {
+		return eq(a, b)
+	}
+	return any(a) == any(b)
+}
+
+// KeyFunc maps a value to a comparable key (usable in a map), two values with
+// the same key must be equal. Containers use it to speed up set-like operations
+type KeyFunc[T any] func(v T) any
+
+// Key is the default KeyFunc for comparable types (the key is the value itself)
+func Key[T comparable](v T) any {
+	return v
+}
+
+// Seen keeps track of the values already visited by a set-like operation
+// (Deduplicate, ...). It uses a map when a KeyFunc is available, buckets of
+// values with the same hash when it's created with a Hasher and falls back to
+// a linear scan with the EqualFunc otherwise
+type Seen[T any] struct {
+	key     KeyFunc[T]
+	eq      EqualFunc[T]
+	keys    map[any]struct{}
+	values  []T
+	hasher  Hasher[T]
+	buckets map[uint64][]T
+}
+
+// NewSeen creates a new empty Seen set
+func NewSeen[T any](key KeyFunc[T], eq EqualFunc[T]) *Seen[T] {
+	s := &Seen[T]{key: key, eq: eq}
+	if key != nil {
+		s.keys = make(map[any]struct{})
+	}
+	return s
+}
+
+// NewHashSeen creates a new empty Seen set that compares the values with hasher
+func NewHashSeen[T any](hasher Hasher[T]) *Seen[T] {
+	return &Seen[T]{hasher: hasher, buckets: make(map[uint64][]T)}
+}
+
+// Add adds v to the set and reports whether it was not already there
+func (s *Seen[T]) Add(v T) bool {
+	if s.hasher != nil {
+		h := s.hasher.Hash(v)
+		for _, seen := range s.buckets[h] {
+			if s.hasher.Equals(seen, v) {
+				return false
+			}
+		}
+		s.buckets[h] = append(s.buckets[h], v)
+		return true
+	}
+
+	if s.key != nil {
+		k := s.key(v)
+		if _, ok := s.keys[k]; ok {
+			return false
+		}
+		s.keys[k] = struct{}{}
+		return true
+	}
+
+	for _, seen := range s.values {
+		if Equals(s.eq, seen, v) {
+			return false
+		}
+	}
+	s.values = append(s.values, v)
+	return true
+}
+
+// Lookup checks the values of a container against a set of wanted items in a
+// single pass (ContainsAll, ContainsAny, ...). Like Seen, it uses a map when a
+// KeyFunc is available, buckets of items with the same hash when it's created
+// with a Hasher and falls back to a linear scan with the EqualFunc otherwise
+type Lookup[T any] struct {
+	key       KeyFunc[T]
+	eq        EqualFunc[T]
+	found     map[any]bool // key -> already matched (when key != nil)
+	items     []T          // wanted items not matched yet (when key == nil)
+	remaining int
+	hasher    Hasher[T]
+	buckets   map[uint64][]wanted[T] // hash -> wanted items (when hasher != nil)
+}
+
+// wanted is an item of a Lookup created with a Hasher
+type wanted[T any] struct {
+	item  T
+	found bool
+}
+
+// NewLookup creates a new Lookup for the given wanted items
+func NewLookup[T any](key KeyFunc[T], eq EqualFunc[T], items []T) *Lookup[T] {
+	l := &Lookup[T]{key: key, eq: eq}
+	if key == nil {
+		seen := NewSeen(nil, eq)
+		for _, item := range items {
+			if seen.Add(item) {
+				l.items = append(l.items, item)
+			}
+		}
+		l.remaining = len(l.items)
+		return l
+	}
+
+	l.found = make(map[any]bool, len(items))
+	for _, item := range items {
+		l.found[key(item)] = false
+	}
+	l.remaining = len(l.found)
+	return l
+}
+
+// NewHashLookup creates a new Lookup for the given wanted items that compares
+// the values with hasher
+func NewHashLookup[T any](hasher Hasher[T], items []T) *Lookup[T] {
+	l := &Lookup[T]{hasher: hasher, buckets: make(map[uint64][]wanted[T])}
+	for _, item := range items {
+		h := hasher.Hash(item)
+		if l.indexOf(h, item) < 0 {
+			l.buckets[h] = append(l.buckets[h], wanted[T]{item: item})
+			l.remaining++
+		}
+	}
+	return l
+}
+
+// indexOf returns the index of v in the bucket of hash h, or -1
+func (l *Lookup[T]) indexOf(h uint64, v T) int {
+	for i, w := range l.buckets[h] {
+		if l.hasher.Equals(w.item, v) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Match reports whether v is one of the wanted items, marking it as found
+func (l *Lookup[T]) Match(v T) bool {
+	if l.hasher != nil {
+		h := l.hasher.Hash(v)
+		i := l.indexOf(h, v)
+		if i < 0 {
+			return false
+		}
+		if w := &l.buckets[h][i]; !w.found {
+			w.found = true
+			l.remaining--
+		}
+		return true
+	}
+
+	if l.key != nil {
+		k := l.key(v)
+		found, ok := l.found[k]
+		if ok && !found {
+			l.found[k] = true
+			l.remaining--
+		}
+		return ok
+	}
+
+	for i, item := range l.items[:l.remaining] {
+		if Equals(l.eq, item, v) {
+			// Move the found item after the ones still wanted
+			l.remaining--
+			l.items[i], l.items[l.remaining] = l.items[l.remaining], l.items[i]
+			return true
+		}
+	}
+	for _, item := range l.items[l.remaining:] {
+		if Equals(l.eq, item, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// Remaining returns the number of distinct wanted items not found yet
+func (l *Lookup[T]) Remaining() int {
+	return l.remaining
+}
@@ -0,0 +1,95 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common_test
+
+import (
+	"slices"
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+func TestEquals(t *testing.T) {
+	if !common.Equals(common.Equal[int], 1, 1) || common.Equals(common.Equal[int], 1, 2) {
+		t.Error("expected Equal to compare with ==")
+	}
+	if !common.Equals[string](nil, "a", "a") {
+		t.Error("expected a nil EqualFunc to fall back to ==")
+	}
+	if !common.Equals(slices.Equal[[]int], []int{1, 2}, []int{1, 2}) {
+		t.Error("expected the custom EqualFunc to be used")
+	}
+}
+
+func TestSeen(t *testing.T) {
+	seen := common.NewSeen(common.Key[int], common.Equal[int])
+	if !seen.Add(1) || !seen.Add(2) || seen.Add(1) {
+		t.Error("expected only the first Add of a value to return true")
+	}
+
+	byLen := common.NewSeen(nil, func(a, b []int) bool { return len(a) == len(b) })
+	if !byLen.Add([]int{1}) || byLen.Add([]int{2}) || !byLen.Add([]int{1, 2}) {
+		t.Error("expected the EqualFunc to be used without a KeyFunc")
+	}
+}
+
+func TestLookup(t *testing.T) {
+	lookup := common.NewLookup(common.Key[int], common.Equal[int], []int{1, 2, 2, 3})
+	if lookup.Remaining() != 3 {
+		t.Errorf("expected 3 distinct wanted items, got %d", lookup.Remaining())
+	}
+	if !lookup.Match(2) || !lookup.Match(2) || lookup.Match(4) || lookup.Remaining() != 2 {
+		t.Errorf("expected 2 to match and 4 not to, got %d remaining", lookup.Remaining())
+	}
+
+	byLen := common.NewLookup(nil, func(a, b []int) bool { return len(a) == len(b) }, [][]int{{1}, {2}, {1, 2}})
+	if byLen.Remaining() != 2 {
+		t.Errorf("expected 2 distinct wanted items, got %d", byLen.Remaining())
+	}
+	if !byLen.Match([]int{9}) || !byLen.Match([]int{8}) || byLen.Remaining() != 1 {
+		t.Errorf("expected the EqualFunc to be used without a KeyFunc, got %d remaining", byLen.Remaining())
+	}
+	if byLen.Match(nil) || !byLen.Match([]int{3, 4}) || byLen.Remaining() != 0 {
+		t.Errorf("expected all the wanted items to be found, got %d remaining", byLen.Remaining())
+	}
+}
+
+func TestHashSeenAndLookup(t *testing.T) {
+	// All the slices of the same length collide, so the equality is needed too
+	calls := 0
+	hasher := common.NewHasher(func(v []int) uint64 {
+		calls++
+		return uint64(len(v))
+	}, slices.Equal[[]int])
+
+	seen := common.NewHashSeen(hasher)
+	if !seen.Add([]int{1}) || !seen.Add([]int{2}) || seen.Add([]int{1}) || !seen.Add([]int{1, 2}) {
+		t.Error("expected only the first Add of a value to return true")
+	}
+	if calls != 4 {
+		t.Errorf("expected a hash per Add, got %d", calls)
+	}
+
+	lookup := common.NewHashLookup(hasher, [][]int{{1}, {2}, {1}, {1, 2}})
+	if lookup.Remaining() != 3 {
+		t.Errorf("expected 3 distinct wanted items, got %d", lookup.Remaining())
+	}
+	if !lookup.Match([]int{2}) || !lookup.Match([]int{2}) || lookup.Match([]int{3}) || lookup.Remaining() != 2 {
+		t.Errorf("expected [2] to match and [3] not to, got %d remaining", lookup.Remaining())
+	}
+	if !lookup.Match([]int{1}) || !lookup.Match([]int{1, 2}) || lookup.Remaining() != 0 {
+		t.Errorf("expected all the wanted items to be found, got %d remaining", lookup.Remaining())
+	}
+}
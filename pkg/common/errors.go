@@ -0,0 +1,46 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import "errors"
+
+// ErrBusy is returned by the Try methods of the concurrent containers when
+// their lock is taken, instead of waiting for it (use errors.Is to check for it)
+var ErrBusy = errors.New("container is busy")
+
+// IndexError is returned when an index is outside the bounds of a data
+// structure. It carries the index and the size of the data structure and
+// matches the sentinel error it wraps when using errors.Is
+type IndexError struct {
+	Err   error
+	Index uint64
+	Size  uint64
+}
+
+// NewIndexError creates a new IndexError wrapping err
+func NewIndexError(err error, index, size uint64) *IndexError {
+	return &IndexError{Err: err, Index: index, Size: size}
+}
+
+// Error returns the message of the wrapped error (so it stays the same
+// as the sentinel one)
+func (e *IndexError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped sentinel error
+func (e *IndexError) Unwrap() error {
+	return e.Err
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"slices"
+	"strings"
+)
+
+// Feature is an optional capability of a container package, every container
+// package reports the ones it supports with its Features function so callers
+// (and bug reports) can tell exactly what the version in use provides
+type Feature string
+
+// The features reported by the container packages
+const (
+	FeatureJSON        Feature = "json"             // EncodeJSONStream/DecodeJSONStream
+	FeatureBinaryCodec Feature = "binary-codec"     // MarshalBinary/UnmarshalBinary with a pluggable Codec
+	FeatureComparator  Feature = "comparator"       // NewWithComparator for non-comparable types
+	FeatureHasher      Feature = "hasher"           // NewWithHasher with a custom Hasher
+	FeatureObservers   Feature = "observers"        // OnInsert/OnRemove/OnClear hooks
+	FeatureConcurrent  Feature = "concurrent-safe"  // safe for concurrent use without external locking
+	FeatureInvariants  Feature = "check-invariants" // CheckInvariants to validate the internal structure
+	FeatureDeepCopy    Feature = "deep-copy"        // CloneWith to copy the elements with a copier
+)
+
+// Features is a sorted set of features
+type Features []Feature
+
+// NewFeatures returns the set of the given features (sorted, without duplicates)
+func NewFeatures(features ...Feature) Features {
+	set := slices.Clone(features)
+	slices.Sort(set)
+	return slices.Compact(set)
+}
+
+// Has returns true if the set contains the feature
+func (f Features) Has(feature Feature) bool {
+	_, found := slices.BinarySearch(f, feature)
+	return found
+}
+
+// String returns the features as a comma separated list (e.g. for bug reports)
+func (f Features) String() string {
+	names := make([]string, len(f))
+	for i, feature := range f {
+		names[i] = string(feature)
+	}
+	return strings.Join(names, ",")
+}
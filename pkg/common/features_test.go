@@ -0,0 +1,37 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common_test
+
+import (
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+func TestFeatures(t *testing.T) {
+	f := common.NewFeatures(common.FeatureObservers, common.FeatureComparator, common.FeatureObservers)
+	if len(f) != 2 {
+		t.Errorf("expected duplicates to be removed, got %v", f)
+	}
+	if !f.Has(common.FeatureComparator) || !f.Has(common.FeatureObservers) || f.Has(common.FeatureJSON) {
+		t.Errorf("unexpected Has results for %v", f)
+	}
+	if s := f.String(); s != "comparator,observers" {
+		t.Errorf("expected comparator,observers, got %s", s)
+	}
+	if s := common.NewFeatures().String(); s != "" {
+		t.Errorf("expected an empty string, got %q", s)
+	}
+}
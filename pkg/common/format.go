@@ -0,0 +1,40 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FormatSlice returns the representation used by the String and StringFunc
+// methods of the containers: "[v1 v2 ...]", where every element is formatted
+// with f, or with %v when f is nil
+func FormatSlice[T any](items []T, f func(T) string) string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, item := range items {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		if f != nil {
+			sb.WriteString(f(item))
+		} else {
+			fmt.Fprintf(&sb, "%v", item)
+		}
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
@@ -0,0 +1,34 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common_test
+
+import (
+	"strings"
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+func TestFormatSlice(t *testing.T) {
+	if s := common.FormatSlice[int](nil, nil); s != "[]" {
+		t.Errorf("expected [], got %s", s)
+	}
+	if s := common.FormatSlice([]string{"a", "b"}, nil); s != "[a b]" {
+		t.Errorf("expected [a b], got %s", s)
+	}
+	if s := common.FormatSlice([]string{"a", "b"}, strings.ToUpper); s != "[A B]" {
+		t.Errorf("expected [A B], got %s", s)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+// FreeList keeps up to a fixed number of released nodes so a container can
+// reuse them instead of allocating new ones, which reduces the GC pressure of
+// workloads that insert and delete many elements. It's not concurrent-safe
+// (it belongs to a single container) and a nil *FreeList is valid: Get always
+// allocates and Put discards the node
+type FreeList[N any] struct {
+	free []*N
+	max  int
+}
+
+// NewFreeList creates a new FreeList that keeps at most max released nodes
+func NewFreeList[N any](max int) *FreeList[N] {
+	return &FreeList[N]{max: max}
+}
+
+// Get returns a released node (set to its zero value) or a new one
+func (fl *FreeList[N]) Get() *N {
+	if fl == nil || len(fl.free) == 0 {
+		return new(N)
+	}
+	last := len(fl.free) - 1
+	n := fl.free[last]
+	fl.free[last] = nil
+	fl.free = fl.free[:last]
+	return n
+}
+
+// Put releases a node for reuse, the node is set to its zero value so it
+// doesn't keep its value (and the nodes it links to) alive. The caller must
+// not use n afterwards
+func (fl *FreeList[N]) Put(n *N) {
+	if fl == nil || len(fl.free) >= fl.max {
+		return
+	}
+	var zero N
+	*n = zero
+	fl.free = append(fl.free, n)
+}
+
+// Len returns the number of released nodes ready to be reused
+func (fl *FreeList[N]) Len() int {
+	if fl == nil {
+		return 0
+	}
+	return len(fl.free)
+}
@@ -0,0 +1,56 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common_test
+
+import (
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+type node struct {
+	value int
+	next  *node
+}
+
+func TestFreeList(t *testing.T) {
+	fl := common.NewFreeList[node](2)
+	a, b, c := fl.Get(), fl.Get(), fl.Get()
+	a.value, a.next = 1, b
+
+	fl.Put(a)
+	fl.Put(b)
+	fl.Put(c) // the list is full, c is discarded
+	if fl.Len() != 2 {
+		t.Fatalf("expected 2 released nodes, got %d", fl.Len())
+	}
+
+	n := fl.Get()
+	if n != b {
+		t.Error("expected the last released node to be reused first")
+	}
+	if n = fl.Get(); n != a || n.value != 0 || n.next != nil {
+		t.Errorf("expected a zeroed reused node, got %+v", *n)
+	}
+	if fl.Len() != 0 {
+		t.Errorf("expected no released nodes, got %d", fl.Len())
+	}
+
+	var none *common.FreeList[node]
+	none.Put(a)
+	if n := none.Get(); n == nil || n == a || none.Len() != 0 {
+		t.Error("expected a nil FreeList to always allocate")
+	}
+}
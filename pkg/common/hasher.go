@@ -0,0 +1,47 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+// Hasher hashes and compares values of type T. It's the equality of the
+// containers created with NewWithHasher, whose set-like operations
+// (ContainsAll, ContainsAny, Deduplicate, ...) use the hashes instead of
+// comparing every pair of values, and of hash-based structures for types that
+// can't rely on == (or need a different equality). Equal values must have the
+// same hash
+type Hasher[T any] interface {
+	Hash(v T) uint64
+	Equals(a, b T) bool
+}
+
+// NewHasher returns a Hasher made of the given hash and equality functions
+func NewHasher[T any](hash func(v T) uint64, equals func(a, b T) bool) Hasher[T] {
+	return funcHasher[T]{hash: hash, equals: equals}
+}
+
+// funcHasher is the Hasher returned by NewHasher
+type funcHasher[T any] struct {
+	hash   func(v T) uint64
+	equals func(a, b T) bool
+}
+
+// Hash returns the hash of v
+func (h funcHasher[T]) Hash(v T) uint64 {
+	return h.hash(v)
+}
+
+// Equals reports whether a and b are equal
+func (h funcHasher[T]) Equals(a, b T) bool {
+	return h.equals(a, b)
+}
@@ -0,0 +1,100 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrNotJSONArray is returned when a JSON stream doesn't hold an array where
+// the elements of a container are expected (use errors.Is to check for it)
+var ErrNotJSONArray = errors.New("JSON value is not an array")
+
+// DecodeJSONArray reads a JSON array from dec one element at a time and calls
+// add with each of them, so the array is never held in memory as a whole (a
+// JSON null is an empty array). It stops at the first error of dec or add,
+// after the elements already added. dec can hold more values after the array
+func DecodeJSONArray[T any](dec *json.Decoder, add func(v T) error) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		return nil
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("%w: found %v", ErrNotJSONArray, tok)
+	}
+
+	for dec.More() {
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			return err
+		}
+		if err := add(v); err != nil {
+			return err
+		}
+	}
+
+	// The closing bracket
+	_, err = dec.Token()
+	return err
+}
+
+// JSONArrayEncoder writes a JSON array to a writer one element at a time, the
+// output is the same produced by json.Marshal for a slice of the elements
+type JSONArrayEncoder[T any] struct {
+	w     io.Writer
+	count uint64
+}
+
+// NewJSONArrayEncoder creates a new JSONArrayEncoder that writes to w (use a
+// bufio.Writer for unbuffered writers, every element is a separate Write)
+func NewJSONArrayEncoder[T any](w io.Writer) *JSONArrayEncoder[T] {
+	return &JSONArrayEncoder[T]{w: w}
+}
+
+// Encode writes v as the next element of the array
+func (e *JSONArrayEncoder[T]) Encode(v T) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	sep := ","
+	if e.count == 0 {
+		sep = "["
+	}
+	if _, err := io.WriteString(e.w, sep); err != nil {
+		return err
+	}
+	if _, err := e.w.Write(data); err != nil {
+		return err
+	}
+	e.count++
+	return nil
+}
+
+// Close ends the array, it must be called once after the last element
+func (e *JSONArrayEncoder[T]) Close() error {
+	end := "]"
+	if e.count == 0 {
+		end = "[]"
+	}
+	_, err := io.WriteString(e.w, end)
+	return err
+}
@@ -0,0 +1,105 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common_test
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+func TestJSONArrayEncoder(t *testing.T) {
+	for _, values := range [][]point{nil, {{1, 2}}, {{1, 2}, {3, 4}, {5, 6}}} {
+		var sb strings.Builder
+		enc := common.NewJSONArrayEncoder[point](&sb)
+		for _, v := range values {
+			if err := enc.Encode(v); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := enc.Close(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want, _ := json.Marshal(append([]point{}, values...))
+		if sb.String() != string(want) {
+			t.Errorf("expected %s, got %s", want, sb.String())
+		}
+	}
+}
+
+func TestDecodeJSONArray(t *testing.T) {
+	decode := func(s string) ([]int, error) {
+		var got []int
+		err := common.DecodeJSONArray(json.NewDecoder(strings.NewReader(s)), func(v int) error {
+			got = append(got, v)
+			return nil
+		})
+		return got, err
+	}
+
+	tests := []struct {
+		in   string
+		want []int
+	}{
+		{"[1, 2, 3]", []int{1, 2, 3}},
+		{"[]", nil},
+		{"null", nil},
+	}
+	for _, tt := range tests {
+		got, err := decode(tt.in)
+		if err != nil || !slices.Equal(got, tt.want) {
+			t.Errorf("%s: expected %v, got %v (%v)", tt.in, tt.want, got, err)
+		}
+	}
+
+	if _, err := decode(`{"a": 1}`); !errors.Is(err, common.ErrNotJSONArray) {
+		t.Errorf("expected %v, got %v", common.ErrNotJSONArray, err)
+	}
+	if got, err := decode(`[1, "two", 3]`); err == nil || !slices.Equal(got, []int{1}) {
+		t.Errorf("expected an error after [1], got %v (%v)", got, err)
+	}
+
+	errStop := errors.New("stop")
+	n := 0
+	err := common.DecodeJSONArray(json.NewDecoder(strings.NewReader("[1, 2, 3]")), func(int) error {
+		n++
+		if n == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) || n != 2 {
+		t.Errorf("expected %v after 2 elements, got %v after %d", errStop, err, n)
+	}
+
+	// The decoder can be reused for the values after the array
+	dec := json.NewDecoder(strings.NewReader("[1] [2, 3]"))
+	var all []int
+	add := func(v int) error {
+		all = append(all, v)
+		return nil
+	}
+	if err := common.DecodeJSONArray(dec, add); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := common.DecodeJSONArray(dec, add); err != nil || !slices.Equal(all, []int{1, 2, 3}) {
+		t.Errorf("expected [1 2 3], got %v (%v)", all, err)
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+// Observers holds the optional change-notification hooks of a container, the
+// zero value has no hooks. The containers call them synchronously after a
+// successful mutation, once per element and in an order that replays the
+// change: the index passed to Insert is the position of the element right
+// after it was inserted, the one passed to Remove is its position right
+// before it was removed. In-place changes (sorting, swapping, updating an
+// element through a pointer, ...) are not reported
+type Observers[T any] struct {
+	Insert func(index uint64, v T)
+	Remove func(index uint64, v T)
+	Clear  func()
+}
+
+// Removal is an element removed from a container and the index it had right
+// before it was removed, it's used to report scattered removals (Filter, ...)
+// once the mutation is complete
+type Removal[T any] struct {
+	Index uint64
+	Value T
+}
+
+// Inserted calls the Insert hook (if any) for items, inserted in order from index
+func (o *Observers[T]) Inserted(index uint64, items ...T) {
+	if o.Insert == nil {
+		return
+	}
+	for i, v := range items {
+		o.Insert(index+uint64(i), v)
+	}
+}
+
+// Removed calls the Remove hook (if any) for items, removed in order from index
+func (o *Observers[T]) Removed(index uint64, items ...T) {
+	if o.Remove == nil {
+		return
+	}
+	for _, v := range items {
+		o.Remove(index, v)
+	}
+}
+
+// RemovedAll calls the Remove hook (if any) for every removal, in order
+func (o *Observers[T]) RemovedAll(removals []Removal[T]) {
+	if o.Remove == nil {
+		return
+	}
+	for _, r := range removals {
+		o.Remove(r.Index, r.Value)
+	}
+}
+
+// Cleared calls the Clear hook (if any)
+func (o *Observers[T]) Cleared() {
+	if o.Clear != nil {
+		o.Clear()
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import "fmt"
+
+// Optional holds a value or nothing, it's returned by the functions that may
+// not have a result to give when that isn't an error (the zero Optional is empty)
+type Optional[T any] struct {
+	value T
+	ok    bool
+}
+
+// Some returns an Optional holding value
+func Some[T any](value T) Optional[T] {
+	return Optional[T]{value: value, ok: true}
+}
+
+// None returns an empty Optional
+func None[T any]() Optional[T] {
+	return Optional[T]{}
+}
+
+// IsSome returns true if the Optional holds a value
+func (o Optional[T]) IsSome() bool {
+	return o.ok
+}
+
+// IsNone returns true if the Optional is empty
+func (o Optional[T]) IsNone() bool {
+	return !o.ok
+}
+
+// Get returns the value and true, or the zero value and false if the Optional is empty
+func (o Optional[T]) Get() (T, bool) {
+	return o.value, o.ok
+}
+
+// OrElse returns the value, or other if the Optional is empty
+func (o Optional[T]) OrElse(other T) T {
+	if o.ok {
+		return o.value
+	}
+	return other
+}
+
+// String returns Some(value) or None
+func (o Optional[T]) String() string {
+	if o.ok {
+		return fmt.Sprintf("Some(%v)", o.value)
+	}
+	return "None"
+}
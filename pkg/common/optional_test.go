@@ -0,0 +1,48 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package common provides small generic helper types.
+package common_test
+
+import (
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+func TestOptional(t *testing.T) {
+	some := common.Some(42)
+	if !some.IsSome() || some.IsNone() {
+		t.Error("expected Some to hold a value")
+	}
+	if v, ok := some.Get(); !ok || v != 42 {
+		t.Errorf("expected 42, got %d (%v)", v, ok)
+	}
+	if some.OrElse(0) != 42 || some.String() != "Some(42)" {
+		t.Errorf("expected 42, got %d (%s)", some.OrElse(0), some)
+	}
+
+	var zero common.Optional[int]
+	for _, none := range []common.Optional[int]{common.None[int](), zero} {
+		if none.IsSome() || !none.IsNone() {
+			t.Error("expected None to be empty")
+		}
+		if _, ok := none.Get(); ok {
+			t.Error("expected Get to fail on None")
+		}
+		if none.OrElse(7) != 7 || none.String() != "None" {
+			t.Errorf("expected 7, got %d (%s)", none.OrElse(7), none)
+		}
+	}
+}
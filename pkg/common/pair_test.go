@@ -0,0 +1,54 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package common provides small generic helper types.
+package common_test
+
+import (
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+func TestPair(t *testing.T) {
+	p := common.NewPair(1, "one")
+	if p.First != 1 || p.Second != "one" {
+		t.Errorf("expected (1, one), got (%v, %v)", p.First, p.Second)
+	}
+
+	a, b := p.Values()
+	if a != 1 || b != "one" {
+		t.Errorf("expected (1, one), got (%v, %v)", a, b)
+	}
+
+	s := p.Swap()
+	if s.First != "one" || s.Second != 1 {
+		t.Errorf("expected (one, 1), got (%v, %v)", s.First, s.Second)
+	}
+
+	if p != common.NewPair(1, "one") {
+		t.Errorf("expected pairs to be equal")
+	}
+}
+
+func TestTriple(t *testing.T) {
+	tr := common.NewTriple(1, "one", 1.5)
+	a, b, c := tr.Values()
+	if a != 1 || b != "one" || c != 1.5 {
+		t.Errorf("expected (1, one, 1.5), got (%v, %v, %v)", a, b, c)
+	}
+	if tr != common.NewTriple(1, "one", 1.5) {
+		t.Errorf("expected triples to be equal")
+	}
+}
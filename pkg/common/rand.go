@@ -0,0 +1,45 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import "math/rand"
+
+// intn returns a random number in [0, n) from rng, or from the global
+// math/rand source when rng is nil
+func intn(rng *rand.Rand, n int) int {
+	if rng == nil {
+		return rand.Intn(n)
+	}
+	return rng.Intn(n)
+}
+
+// Shuffle shuffles items in place (Fisher-Yates) using rng, or the global
+// math/rand source when rng is nil
+func Shuffle[T any](rng *rand.Rand, items []T) {
+	Sample(rng, items, len(items))
+}
+
+// Sample moves n randomly chosen items (without replacement) to the
+// beginning of items, in random order (partial Fisher-Yates). n is
+// clamped to len(items)
+func Sample[T any](rng *rand.Rand, items []T, n int) {
+	if n > len(items) {
+		n = len(items)
+	}
+	for i := 0; i < n && i < len(items)-1; i++ {
+		j := i + intn(rng, len(items)-i)
+		items[i], items[j] = items[j], items[i]
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common_test
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+func TestSample(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	common.Sample(rand.New(rand.NewSource(3)), items, 2)
+	sorted := slices.Clone(items)
+	slices.Sort(sorted)
+	if !slices.Equal(sorted, []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected a permutation of [1 2 3 4 5], got %v", items)
+	}
+
+	// n larger than the slice is clamped
+	common.Sample(nil, items, 10)
+	common.Shuffle[int](nil, nil)
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats is a snapshot of the statistics collected by a concurrent container
+// while they are enabled
+type Stats struct {
+	Reads    uint64            // operations that took the read lock
+	Writes   uint64            // operations that took the write lock
+	Ops      map[string]uint64 // operations by method name (Append, Pop, ...), nil if there was none
+	Waits    uint64            // operations that found the lock taken and had to wait
+	Busy     uint64            // Try operations that found the lock taken and gave up
+	WaitTime time.Duration     // total time spent waiting for the lock
+	PeakSize uint64            // largest size seen after a write
+}
+
+// LockStats collects the opt-in Stats of a concurrent container, it's meant to
+// be embedded in the container and used to take its lock. The zero value is
+// disabled and then costs a single atomic load per lock
+type LockStats struct {
+	enabled  atomic.Bool
+	reads    atomic.Uint64
+	writes   atomic.Uint64
+	waits    atomic.Uint64
+	busy     atomic.Uint64
+	waitTime atomic.Int64
+	peakSize atomic.Uint64
+	ops      sync.Map // method name -> *atomic.Uint64
+}
+
+// Enable turns the collection on or off, turning it on resets the statistics
+func (s *LockStats) Enable(on bool) {
+	if on && !s.enabled.Load() {
+		s.reads.Store(0)
+		s.writes.Store(0)
+		s.waits.Store(0)
+		s.busy.Store(0)
+		s.waitTime.Store(0)
+		s.peakSize.Store(0)
+		s.ops.Clear()
+	}
+	s.enabled.Store(on)
+}
+
+// Enabled returns true if the statistics are being collected
+func (s *LockStats) Enabled() bool {
+	return s.enabled.Load()
+}
+
+// Lock takes the write lock of mu for the method op (Append, Pop, ...),
+// counting the operation and the time spent waiting
+func (s *LockStats) Lock(mu *sync.RWMutex, op string) {
+	if !s.enabled.Load() {
+		mu.Lock()
+		return
+	}
+	s.writes.Add(1)
+	s.countOp(op)
+	if !mu.TryLock() {
+		start := time.Now()
+		mu.Lock()
+		s.waited(start)
+	}
+}
+
+// RLock takes the read lock of mu for the method op, counting the operation and
+// the time spent waiting
+func (s *LockStats) RLock(mu *sync.RWMutex, op string) {
+	if !s.enabled.Load() {
+		mu.RLock()
+		return
+	}
+	s.reads.Add(1)
+	s.countOp(op)
+	if !mu.TryRLock() {
+		start := time.Now()
+		mu.RLock()
+		s.waited(start)
+	}
+}
+
+// TryLock takes the write lock of mu for the method op if it's free and returns
+// true, counting the operation, or returns false without waiting (counting it
+// as busy)
+func (s *LockStats) TryLock(mu *sync.RWMutex, op string) bool {
+	if !mu.TryLock() {
+		s.gaveUp()
+		return false
+	}
+	if s.enabled.Load() {
+		s.writes.Add(1)
+		s.countOp(op)
+	}
+	return true
+}
+
+// TryRLock takes the read lock of mu for the method op if it's available and
+// returns true, counting the operation, or returns false without waiting
+// (counting it as busy)
+func (s *LockStats) TryRLock(mu *sync.RWMutex, op string) bool {
+	if !mu.TryRLock() {
+		s.gaveUp()
+		return false
+	}
+	if s.enabled.Load() {
+		s.reads.Add(1)
+		s.countOp(op)
+	}
+	return true
+}
+
+// countOp counts an operation of the method op, an empty op is counted only
+// as a read or a write
+func (s *LockStats) countOp(op string) {
+	if op == "" {
+		return
+	}
+	counter, ok := s.ops.Load(op)
+	if !ok {
+		counter, _ = s.ops.LoadOrStore(op, new(atomic.Uint64))
+	}
+	counter.(*atomic.Uint64).Add(1)
+}
+
+// gaveUp records a Try operation that found the lock taken
+func (s *LockStats) gaveUp() {
+	if s.enabled.Load() {
+		s.busy.Add(1)
+	}
+}
+
+// waited records a wait for the lock that started at start
+func (s *LockStats) waited(start time.Time) {
+	s.waits.Add(1)
+	s.waitTime.Add(int64(time.Since(start)))
+}
+
+// Observe records the size of the container, updating the peak size
+func (s *LockStats) Observe(size uint64) {
+	if !s.enabled.Load() {
+		return
+	}
+	for {
+		peak := s.peakSize.Load()
+		if size <= peak || s.peakSize.CompareAndSwap(peak, size) {
+			return
+		}
+	}
+}
+
+// Snapshot returns the statistics collected so far
+func (s *LockStats) Snapshot() Stats {
+	st := Stats{
+		Reads:    s.reads.Load(),
+		Writes:   s.writes.Load(),
+		Waits:    s.waits.Load(),
+		Busy:     s.busy.Load(),
+		WaitTime: time.Duration(s.waitTime.Load()),
+		PeakSize: s.peakSize.Load(),
+	}
+	s.ops.Range(func(name, counter any) bool {
+		if st.Ops == nil {
+			st.Ops = make(map[string]uint64)
+		}
+		st.Ops[name.(string)] = counter.(*atomic.Uint64).Load()
+		return true
+	})
+	return st
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package common provides small generic helper types.
+package common_test
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+func TestLockStats(t *testing.T) {
+	var s common.LockStats
+	var mu sync.RWMutex
+
+	// Disabled: nothing is collected
+	s.Lock(&mu, "")
+	mu.Unlock()
+	s.Observe(10)
+	if s.Enabled() || !reflect.DeepEqual(s.Snapshot(), common.Stats{}) {
+		t.Errorf("expected no statistics while disabled, got %+v", s.Snapshot())
+	}
+
+	s.Enable(true)
+	s.RLock(&mu, "")
+	mu.RUnlock()
+	s.Observe(3)
+	s.Observe(7)
+	s.Observe(5)
+
+	// A writer that has to wait for a reader
+	s.RLock(&mu, "")
+	done := make(chan struct{})
+	go func() {
+		s.Lock(&mu, "")
+		mu.Unlock()
+		close(done)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	mu.RUnlock()
+	<-done
+
+	st := s.Snapshot()
+	if st.Reads != 2 || st.Writes != 1 || st.Waits != 1 || st.WaitTime <= 0 || st.PeakSize != 7 {
+		t.Errorf("unexpected statistics %+v", st)
+	}
+
+	s.Enable(false)
+	s.Enable(true)
+	if !reflect.DeepEqual(s.Snapshot(), common.Stats{}) {
+		t.Errorf("expected enabling to reset the statistics, got %+v", s.Snapshot())
+	}
+}
+
+func TestLockStatsTry(t *testing.T) {
+	var s common.LockStats
+	var mu sync.RWMutex
+	s.Enable(true)
+
+	if !s.TryRLock(&mu, "") {
+		t.Fatal("expected the read lock to be available")
+	}
+	if s.TryLock(&mu, "") {
+		t.Error("expected TryLock to fail while the read lock is held")
+	}
+	mu.RUnlock()
+
+	if !s.TryLock(&mu, "") {
+		t.Fatal("expected the write lock to be free")
+	}
+	if s.TryRLock(&mu, "") {
+		t.Error("expected TryRLock to fail while the write lock is held")
+	}
+	mu.Unlock()
+
+	// Only the operations that took the lock are counted, the others are busy
+	if st := s.Snapshot(); st.Reads != 1 || st.Writes != 1 || st.Waits != 0 || st.Busy != 2 {
+		t.Errorf("expected 1 read, 1 write and 2 busy, got %+v", st)
+	}
+}
+
+// box is a minimal concurrent container to check the operations by name
+type box struct {
+	mu    sync.RWMutex
+	stats common.LockStats
+	v     int
+}
+
+func (b *box) lock(op string) {
+	b.stats.Lock(&b.mu, op)
+}
+
+func (b *box) Get() int {
+	b.stats.RLock(&b.mu, "Get")
+	defer b.mu.RUnlock()
+	return b.v
+}
+
+func (b *box) Set(v int) {
+	b.lock("Set")
+	defer b.mu.Unlock()
+	b.v = v
+}
+
+func (b *box) Add(v int) {
+	b.lock("Add")
+	defer b.mu.Unlock()
+	b.v += v
+}
+
+func TestLockStatsOps(t *testing.T) {
+	b := &box{}
+	b.Set(1)
+	b.stats.Enable(true)
+	for i := 0; i < 3; i++ {
+		b.Set(i)
+		_ = b.Get()
+	}
+	b.Add(1)
+	b.lock("")
+	b.mu.Unlock()
+
+	// The operations are counted by the name passed to the lock, the locks
+	// taken without a name are only counted as reads or writes
+	want := map[string]uint64{"Set": 3, "Get": 3, "Add": 1}
+	if st := b.stats.Snapshot(); !reflect.DeepEqual(st.Ops, want) || st.Reads != 3 || st.Writes != 5 {
+		t.Errorf("expected the operations %v, got %+v", want, st)
+	}
+
+	b.stats.Enable(false)
+	b.stats.Enable(true)
+	if st := b.stats.Snapshot(); st.Ops != nil {
+		t.Errorf("expected enabling to reset the operations, got %v", st.Ops)
+	}
+}
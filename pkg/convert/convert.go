@@ -0,0 +1,315 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package convert moves elements between the gods containers.
+//
+// All the conversions have the same semantics: the elements are moved, so the
+// source container is left empty, and their insertion order is preserved (for
+// a Stack that is from the bottom to the top, for a Queue from the front to the
+// back). When both containers are backed by a slice (Buffer, Stack and Queue)
+// the backing storage is handed over and no element is copied. To keep the
+// source, convert a Copy of it.
+//
+// The new container compares its elements with ==, every conversion has a
+// ...WithComparator variant that takes the function to compare them with, for
+// element types that are not comparable.
+package convert
+
+import (
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	dlinkList "github.com/pzaino/gods/pkg/dlinkList"
+	linkList "github.com/pzaino/gods/pkg/linkList"
+	queue "github.com/pzaino/gods/pkg/queue"
+	stack "github.com/pzaino/gods/pkg/stack"
+)
+
+// takeList empties a linked list and returns its values in order
+func takeList[T any](l *linkList.LinkList[T]) []T {
+	items := l.ToSlice()
+	l.Clear()
+	return items
+}
+
+// takeDLinkList empties a doubly linked list and returns its values in order
+func takeDLinkList[T any](l *dlinkList.DLinkList[T]) []T {
+	items := l.ToSlice()
+	l.Clear()
+	return items
+}
+
+// newList creates a linked list with the given values
+func newList[T comparable](items []T) *linkList.LinkList[T] {
+	return fillList(linkList.New[T](), items)
+}
+
+// fillList adds the given values to the empty linked list l, it prepends from
+// the end so it doesn't walk the list for every value
+func fillList[T any](l *linkList.LinkList[T], items []T) *linkList.LinkList[T] {
+	for i := len(items) - 1; i >= 0; i-- {
+		l.Prepend(items[i])
+	}
+	return l
+}
+
+// newDLinkList creates a doubly linked list with the given values
+func newDLinkList[T comparable](items []T) *dlinkList.DLinkList[T] {
+	return fillDLinkList(dlinkList.New[T](), items)
+}
+
+// fillDLinkList adds the given values to the empty doubly linked list l
+func fillDLinkList[T any](l *dlinkList.DLinkList[T], items []T) *dlinkList.DLinkList[T] {
+	for _, v := range items {
+		l.Append(v)
+	}
+	return l
+}
+
+// BufferToStack moves the elements of a buffer to a new stack (the last
+// element of the buffer is the top of the stack)
+func BufferToStack[T comparable](b *buffer.Buffer[T]) *stack.Stack[T] {
+	return stack.Adopt(b.Detach())
+}
+
+// BufferToStackWithComparator is like BufferToStack, the new stack compares its
+// items with the given function so T doesn't have to be comparable
+func BufferToStackWithComparator[T any](b *buffer.Buffer[T], equals func(a, b T) bool) *stack.Stack[T] {
+	return stack.AdoptWithComparator(b.Detach(), equals)
+}
+
+// BufferToQueue moves the elements of a buffer to a new queue (the first
+// element of the buffer is the front of the queue)
+func BufferToQueue[T comparable](b *buffer.Buffer[T]) *queue.Queue[T] {
+	return queue.Adopt(b.Detach())
+}
+
+// BufferToQueueWithComparator is like BufferToQueue, the new queue compares its
+// elements with the given function so T doesn't have to be comparable
+func BufferToQueueWithComparator[T any](b *buffer.Buffer[T], equals func(a, b T) bool) *queue.Queue[T] {
+	return queue.AdoptWithComparator(b.Detach(), equals)
+}
+
+// BufferToList moves the elements of a buffer to a new linked list
+func BufferToList[T comparable](b *buffer.Buffer[T]) *linkList.LinkList[T] {
+	return newList(b.Detach())
+}
+
+// BufferToListWithComparator is like BufferToList, the new list compares its
+// values with the given function so T doesn't have to be comparable
+func BufferToListWithComparator[T any](b *buffer.Buffer[T], equals func(a, b T) bool) *linkList.LinkList[T] {
+	return fillList(linkList.NewWithComparator(equals), b.Detach())
+}
+
+// BufferToDLinkList moves the elements of a buffer to a new doubly linked list
+func BufferToDLinkList[T comparable](b *buffer.Buffer[T]) *dlinkList.DLinkList[T] {
+	return newDLinkList(b.Detach())
+}
+
+// BufferToDLinkListWithComparator is like BufferToDLinkList, the new list
+// compares its values with the given function so T doesn't have to be
+// comparable
+func BufferToDLinkListWithComparator[T any](b *buffer.Buffer[T], equals func(a, b T) bool) *dlinkList.DLinkList[T] {
+	return fillDLinkList(dlinkList.NewWithComparator(equals), b.Detach())
+}
+
+// StackToBuffer moves the elements of a stack to a new buffer (the bottom of
+// the stack is the first element of the buffer)
+func StackToBuffer[T comparable](s *stack.Stack[T]) *buffer.Buffer[T] {
+	return buffer.Adopt(s.Detach())
+}
+
+// StackToBufferWithComparator is like StackToBuffer, the new buffer compares
+// its elements with the given function so T doesn't have to be comparable
+func StackToBufferWithComparator[T any](s *stack.Stack[T], equals func(a, b T) bool) *buffer.Buffer[T] {
+	return buffer.AdoptWithComparator(s.Detach(), equals)
+}
+
+// StackToQueue moves the elements of a stack to a new queue (the bottom of
+// the stack is the front of the queue)
+func StackToQueue[T comparable](s *stack.Stack[T]) *queue.Queue[T] {
+	return queue.Adopt(s.Detach())
+}
+
+// StackToQueueWithComparator is like StackToQueue, the new queue compares its
+// elements with the given function so T doesn't have to be comparable
+func StackToQueueWithComparator[T any](s *stack.Stack[T], equals func(a, b T) bool) *queue.Queue[T] {
+	return queue.AdoptWithComparator(s.Detach(), equals)
+}
+
+// StackToList moves the elements of a stack to a new linked list (the bottom
+// of the stack is the head of the list)
+func StackToList[T comparable](s *stack.Stack[T]) *linkList.LinkList[T] {
+	return newList(s.Detach())
+}
+
+// StackToListWithComparator is like StackToList, the new list compares its
+// values with the given function so T doesn't have to be comparable
+func StackToListWithComparator[T any](s *stack.Stack[T], equals func(a, b T) bool) *linkList.LinkList[T] {
+	return fillList(linkList.NewWithComparator(equals), s.Detach())
+}
+
+// StackToDLinkList moves the elements of a stack to a new doubly linked list
+// (the bottom of the stack is the head of the list)
+func StackToDLinkList[T comparable](s *stack.Stack[T]) *dlinkList.DLinkList[T] {
+	return newDLinkList(s.Detach())
+}
+
+// StackToDLinkListWithComparator is like StackToDLinkList, the new list
+// compares its values with the given function so T doesn't have to be
+// comparable
+func StackToDLinkListWithComparator[T any](s *stack.Stack[T], equals func(a, b T) bool) *dlinkList.DLinkList[T] {
+	return fillDLinkList(dlinkList.NewWithComparator(equals), s.Detach())
+}
+
+// QueueToBuffer moves the elements of a queue to a new buffer (the front of
+// the queue is the first element of the buffer)
+func QueueToBuffer[T comparable](q *queue.Queue[T]) *buffer.Buffer[T] {
+	return buffer.Adopt(q.Detach())
+}
+
+// QueueToBufferWithComparator is like QueueToBuffer, the new buffer compares
+// its elements with the given function so T doesn't have to be comparable
+func QueueToBufferWithComparator[T any](q *queue.Queue[T], equals func(a, b T) bool) *buffer.Buffer[T] {
+	return buffer.AdoptWithComparator(q.Detach(), equals)
+}
+
+// QueueToStack moves the elements of a queue to a new stack (the back of the
+// queue is the top of the stack)
+func QueueToStack[T comparable](q *queue.Queue[T]) *stack.Stack[T] {
+	return stack.Adopt(q.Detach())
+}
+
+// QueueToStackWithComparator is like QueueToStack, the new stack compares its
+// items with the given function so T doesn't have to be comparable
+func QueueToStackWithComparator[T any](q *queue.Queue[T], equals func(a, b T) bool) *stack.Stack[T] {
+	return stack.AdoptWithComparator(q.Detach(), equals)
+}
+
+// QueueToList moves the elements of a queue to a new linked list (the front
+// of the queue is the head of the list)
+func QueueToList[T comparable](q *queue.Queue[T]) *linkList.LinkList[T] {
+	return newList(q.Detach())
+}
+
+// QueueToListWithComparator is like QueueToList, the new list compares its
+// values with the given function so T doesn't have to be comparable
+func QueueToListWithComparator[T any](q *queue.Queue[T], equals func(a, b T) bool) *linkList.LinkList[T] {
+	return fillList(linkList.NewWithComparator(equals), q.Detach())
+}
+
+// QueueToDLinkList moves the elements of a queue to a new doubly linked list
+// (the front of the queue is the head of the list)
+func QueueToDLinkList[T comparable](q *queue.Queue[T]) *dlinkList.DLinkList[T] {
+	return newDLinkList(q.Detach())
+}
+
+// QueueToDLinkListWithComparator is like QueueToDLinkList, the new list
+// compares its values with the given function so T doesn't have to be
+// comparable
+func QueueToDLinkListWithComparator[T any](q *queue.Queue[T], equals func(a, b T) bool) *dlinkList.DLinkList[T] {
+	return fillDLinkList(dlinkList.NewWithComparator(equals), q.Detach())
+}
+
+// ListToBuffer moves the elements of a linked list to a new buffer
+func ListToBuffer[T comparable](l *linkList.LinkList[T]) *buffer.Buffer[T] {
+	return buffer.Adopt(takeList(l))
+}
+
+// ListToBufferWithComparator is like ListToBuffer, the new buffer compares its
+// elements with the given function so T doesn't have to be comparable
+func ListToBufferWithComparator[T any](l *linkList.LinkList[T], equals func(a, b T) bool) *buffer.Buffer[T] {
+	return buffer.AdoptWithComparator(takeList(l), equals)
+}
+
+// ListToStack moves the elements of a linked list to a new stack (the tail of
+// the list is the top of the stack)
+func ListToStack[T comparable](l *linkList.LinkList[T]) *stack.Stack[T] {
+	return stack.Adopt(takeList(l))
+}
+
+// ListToStackWithComparator is like ListToStack, the new stack compares its
+// items with the given function so T doesn't have to be comparable
+func ListToStackWithComparator[T any](l *linkList.LinkList[T], equals func(a, b T) bool) *stack.Stack[T] {
+	return stack.AdoptWithComparator(takeList(l), equals)
+}
+
+// ListToQueue moves the elements of a linked list to a new queue (the head of
+// the list is the front of the queue)
+func ListToQueue[T comparable](l *linkList.LinkList[T]) *queue.Queue[T] {
+	return queue.Adopt(takeList(l))
+}
+
+// ListToQueueWithComparator is like ListToQueue, the new queue compares its
+// elements with the given function so T doesn't have to be comparable
+func ListToQueueWithComparator[T any](l *linkList.LinkList[T], equals func(a, b T) bool) *queue.Queue[T] {
+	return queue.AdoptWithComparator(takeList(l), equals)
+}
+
+// ListToDLinkList moves the elements of a linked list to a new doubly linked list
+func ListToDLinkList[T comparable](l *linkList.LinkList[T]) *dlinkList.DLinkList[T] {
+	return newDLinkList(takeList(l))
+}
+
+// ListToDLinkListWithComparator is like ListToDLinkList, the new list compares
+// its values with the given function so T doesn't have to be comparable
+func ListToDLinkListWithComparator[T any](l *linkList.LinkList[T], equals func(a, b T) bool) *dlinkList.DLinkList[T] {
+	return fillDLinkList(dlinkList.NewWithComparator(equals), takeList(l))
+}
+
+// DLinkListToBuffer moves the elements of a doubly linked list to a new buffer
+func DLinkListToBuffer[T comparable](l *dlinkList.DLinkList[T]) *buffer.Buffer[T] {
+	return buffer.Adopt(takeDLinkList(l))
+}
+
+// DLinkListToBufferWithComparator is like DLinkListToBuffer, the new buffer
+// compares its elements with the given function so T doesn't have to be
+// comparable
+func DLinkListToBufferWithComparator[T any](l *dlinkList.DLinkList[T], equals func(a, b T) bool) *buffer.Buffer[T] {
+	return buffer.AdoptWithComparator(takeDLinkList(l), equals)
+}
+
+// DLinkListToStack moves the elements of a doubly linked list to a new stack
+// (the tail of the list is the top of the stack)
+func DLinkListToStack[T comparable](l *dlinkList.DLinkList[T]) *stack.Stack[T] {
+	return stack.Adopt(takeDLinkList(l))
+}
+
+// DLinkListToStackWithComparator is like DLinkListToStack, the new stack
+// compares its items with the given function so T doesn't have to be comparable
+func DLinkListToStackWithComparator[T any](l *dlinkList.DLinkList[T], equals func(a, b T) bool) *stack.Stack[T] {
+	return stack.AdoptWithComparator(takeDLinkList(l), equals)
+}
+
+// DLinkListToQueue moves the elements of a doubly linked list to a new queue
+// (the head of the list is the front of the queue)
+func DLinkListToQueue[T comparable](l *dlinkList.DLinkList[T]) *queue.Queue[T] {
+	return queue.Adopt(takeDLinkList(l))
+}
+
+// DLinkListToQueueWithComparator is like DLinkListToQueue, the new queue
+// compares its elements with the given function so T doesn't have to be
+// comparable
+func DLinkListToQueueWithComparator[T any](l *dlinkList.DLinkList[T], equals func(a, b T) bool) *queue.Queue[T] {
+	return queue.AdoptWithComparator(takeDLinkList(l), equals)
+}
+
+// DLinkListToList moves the elements of a doubly linked list to a new linked list
+func DLinkListToList[T comparable](l *dlinkList.DLinkList[T]) *linkList.LinkList[T] {
+	return newList(takeDLinkList(l))
+}
+
+// DLinkListToListWithComparator is like DLinkListToList, the new list compares
+// its values with the given function so T doesn't have to be comparable
+func DLinkListToListWithComparator[T any](l *dlinkList.DLinkList[T], equals func(a, b T) bool) *linkList.LinkList[T] {
+	return fillList(linkList.NewWithComparator(equals), takeDLinkList(l))
+}
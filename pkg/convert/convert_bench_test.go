@@ -0,0 +1,52 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert_test
+
+import (
+	"slices"
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	convert "github.com/pzaino/gods/pkg/convert"
+)
+
+// BenchmarkBufferToStack hands the storage of a buffer of n elements over
+// to a stack
+func BenchmarkBufferToStack(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			buf := buffer.Adopt(slices.Clone(values))
+			b.StartTimer()
+			_ = convert.BufferToStack(buf)
+		}
+	})
+}
+
+// BenchmarkBufferToList moves n elements from a buffer to a linked list
+func BenchmarkBufferToList(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			buf := buffer.Adopt(slices.Clone(values))
+			b.StartTimer()
+			_ = convert.BufferToList(buf)
+		}
+		bench.PerElement(b, n)
+	})
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package convert_test
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	convert "github.com/pzaino/gods/pkg/convert"
+	dlinkList "github.com/pzaino/gods/pkg/dlinkList"
+	linkList "github.com/pzaino/gods/pkg/linkList"
+	queue "github.com/pzaino/gods/pkg/queue"
+	stack "github.com/pzaino/gods/pkg/stack"
+)
+
+const errExpected = "expected %v, got %v"
+
+func newBuffer(items ...int) *buffer.Buffer[int] {
+	b := buffer.New[int]()
+	_ = b.PushN(items...)
+	return b
+}
+
+func TestBufferStealsStorage(t *testing.T) {
+	b := newBuffer(1, 2, 3)
+	backing := b.UnsafeSlice()
+
+	s := convert.BufferToStack(b)
+	if !b.IsEmpty() {
+		t.Errorf("expected the buffer to be empty, got size %d", b.Size())
+	}
+	if top, err := s.Pop(); err != nil || *top != 3 {
+		t.Errorf(errExpected, 3, top)
+	}
+
+	// The stack shares the storage of the buffer
+	backing[0] = 10
+	q := convert.StackToQueue(s)
+	if v, _ := q.Dequeue(); v != 10 {
+		t.Errorf(errExpected, 10, v)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	expected := []int{1, 2, 3, 4}
+
+	b := newBuffer(expected...)
+	l := convert.BufferToList(b)
+	d := convert.ListToDLinkList(l)
+	q := convert.DLinkListToQueue(d)
+	s := convert.QueueToStack(q)
+	l2 := convert.StackToList(s)
+	d2 := convert.StackToDLinkList(convert.ListToStack(l2))
+	result := convert.QueueToBuffer(convert.DLinkListToQueue(d2))
+
+	if !reflect.DeepEqual(result.ToSlice(), expected) {
+		t.Errorf(errExpected, expected, result.ToSlice())
+	}
+	if !l.IsEmpty() || !d.IsEmpty() || !q.IsEmpty() || !s.IsEmpty() || !l2.IsEmpty() || !d2.IsEmpty() {
+		t.Error("expected all the intermediate containers to be empty")
+	}
+}
+
+func TestAllConversions(t *testing.T) {
+	expected := []int{1, 2, 3}
+
+	lists := []*linkList.LinkList[int]{
+		convert.BufferToList(newBuffer(expected...)),
+		convert.StackToList(stack.NewFromSlice(expected)),
+		convert.QueueToList(queue.Adopt([]int{1, 2, 3})),
+		convert.DLinkListToList(convert.BufferToDLinkList(newBuffer(expected...))),
+	}
+	for i, l := range lists {
+		if !reflect.DeepEqual(l.ToSlice(), expected) {
+			t.Errorf("list %d: "+errExpected, i, expected, l.ToSlice())
+		}
+	}
+
+	dlists := []*dlinkList.DLinkList[int]{
+		convert.QueueToDLinkList(queue.Adopt([]int{1, 2, 3})),
+		convert.ListToDLinkList(linkList.NewFromSlice(expected)),
+	}
+	for i, l := range dlists {
+		if !reflect.DeepEqual(l.ToSlice(), expected) {
+			t.Errorf("dlist %d: "+errExpected, i, expected, l.ToSlice())
+		}
+	}
+
+	buffers := []*buffer.Buffer[int]{
+		convert.StackToBuffer(stack.NewFromSlice(expected)),
+		convert.ListToBuffer(linkList.NewFromSlice(expected)),
+		convert.DLinkListToBuffer(convert.ListToDLinkList(linkList.NewFromSlice(expected))),
+		convert.QueueToBuffer(convert.BufferToQueue(newBuffer(expected...))),
+		convert.QueueToBuffer(convert.ListToQueue(linkList.NewFromSlice(expected))),
+		convert.StackToBuffer(convert.DLinkListToStack(convert.ListToDLinkList(linkList.NewFromSlice(expected)))),
+	}
+	for i, b := range buffers {
+		if !reflect.DeepEqual(b.ToSlice(), expected) {
+			t.Errorf("buffer %d: "+errExpected, i, expected, b.ToSlice())
+		}
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	if s := convert.BufferToStack(buffer.New[int]()); !s.IsEmpty() {
+		t.Errorf("expected an empty stack, got size %d", s.Size())
+	}
+	if l := convert.QueueToList(queue.New[int]()); !l.IsEmpty() {
+		t.Errorf("expected an empty list, got size %d", l.Size())
+	}
+	if b := convert.ListToBuffer(linkList.New[int]()); !b.IsEmpty() {
+		t.Errorf("expected an empty buffer, got size %d", b.Size())
+	}
+}
+
+func TestWithComparator(t *testing.T) {
+	eq := slices.Equal[[]int]
+	check := func(name string, found bool, size uint64) {
+		t.Helper()
+		if !found || size != 2 {
+			t.Errorf("%s: expected 2 elements compared with the given function, got %d (found %v)", name, size, found)
+		}
+	}
+
+	b := buffer.NewWithComparator(eq)
+	_ = b.PushN([]int{1}, []int{2, 3})
+	var (
+		s *stack.Stack[[]int]
+		q *queue.Queue[[]int]
+		l *linkList.LinkList[[]int]
+		d *dlinkList.DLinkList[[]int]
+	)
+	s = convert.BufferToStackWithComparator(b, eq)
+	check("BufferToStack", s.Contains([]int{2, 3}), s.Size())
+	b = convert.StackToBufferWithComparator(s, eq)
+	check("StackToBuffer", b.Contains([]int{2, 3}), b.Size())
+	q = convert.BufferToQueueWithComparator(b, eq)
+	check("BufferToQueue", q.Contains([]int{2, 3}), q.Size())
+	b = convert.QueueToBufferWithComparator(q, eq)
+	check("QueueToBuffer", b.Contains([]int{2, 3}), b.Size())
+	l = convert.BufferToListWithComparator(b, eq)
+	check("BufferToList", l.Contains([]int{2, 3}), l.Size())
+	b = convert.ListToBufferWithComparator(l, eq)
+	check("ListToBuffer", b.Contains([]int{2, 3}), b.Size())
+	d = convert.BufferToDLinkListWithComparator(b, eq)
+	check("BufferToDLinkList", d.Contains([]int{2, 3}), d.Size())
+	s = convert.DLinkListToStackWithComparator(d, eq)
+	check("DLinkListToStack", s.Contains([]int{2, 3}), s.Size())
+	q = convert.StackToQueueWithComparator(s, eq)
+	check("StackToQueue", q.Contains([]int{2, 3}), q.Size())
+	s = convert.QueueToStackWithComparator(q, eq)
+	check("QueueToStack", s.Contains([]int{2, 3}), s.Size())
+	l = convert.StackToListWithComparator(s, eq)
+	check("StackToList", l.Contains([]int{2, 3}), l.Size())
+	s = convert.ListToStackWithComparator(l, eq)
+	check("ListToStack", s.Contains([]int{2, 3}), s.Size())
+	d = convert.StackToDLinkListWithComparator(s, eq)
+	check("StackToDLinkList", d.Contains([]int{2, 3}), d.Size())
+	q = convert.DLinkListToQueueWithComparator(d, eq)
+	check("DLinkListToQueue", q.Contains([]int{2, 3}), q.Size())
+	l = convert.QueueToListWithComparator(q, eq)
+	check("QueueToList", l.Contains([]int{2, 3}), l.Size())
+	q = convert.ListToQueueWithComparator(l, eq)
+	check("ListToQueue", q.Contains([]int{2, 3}), q.Size())
+	d = convert.QueueToDLinkListWithComparator(q, eq)
+	check("QueueToDLinkList", d.Contains([]int{2, 3}), d.Size())
+	l = convert.DLinkListToListWithComparator(d, eq)
+	check("DLinkListToList", l.Contains([]int{2, 3}), l.Size())
+	d = convert.ListToDLinkListWithComparator(l, eq)
+	check("ListToDLinkList", d.Contains([]int{2, 3}), d.Size())
+	b = convert.DLinkListToBufferWithComparator(d, eq)
+	check("DLinkListToBuffer", b.Contains([]int{2, 3}), b.Size())
+	if !reflect.DeepEqual(b.ToSlice(), [][]int{{1}, {2, 3}}) {
+		t.Errorf(errExpected, [][]int{{1}, {2, 3}}, b.ToSlice())
+	}
+}
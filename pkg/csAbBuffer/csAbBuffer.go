@@ -0,0 +1,492 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csAbBuffer provides a thread-safe wrapper around the ABBuffer type.
+//
+// The ABBuffer already takes its own lock in every method, so every call
+// through the wrapper takes two locks: the wrapper's one, which collects the
+// statistics (see EnableStats) and makes the batch operations (AppendN, ...)
+// atomic, and then the one of the ABBuffer. Use an ABBuffer directly when
+// neither is needed.
+package csAbBuffer
+
+import (
+	"sync"
+
+	abBuffer "github.com/pzaino/gods/pkg/abBuffer"
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the CSABBuffer methods, they are the same
+// returned by the abBuffer package (use errors.Is to check for them).
+var (
+	ErrOverflow    = abBuffer.ErrOverflow
+	ErrInvalid     = abBuffer.ErrInvalid
+	ErrEmpty       = abBuffer.ErrEmpty
+	ErrNotFound    = abBuffer.ErrNotFound
+	ErrOutOfBounds = abBuffer.ErrOutOfBounds
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureConcurrent, common.FeatureDeepCopy)
+}
+
+// CSABBuffer is a thread-safe wrapper around the ABBuffer type.
+// Swaps are atomic with respect to in-flight appends.
+type CSABBuffer[T any] struct {
+	mu    sync.RWMutex
+	stats common.LockStats // opt-in statistics, see EnableStats
+	b     *abBuffer.ABBuffer[T]
+}
+
+// New creates a new CSABBuffer whose active side can hold capacity elements,
+// the inactive side can be configured with the abBuffer options.
+func New[T comparable](capacity uint64, opts ...abBuffer.Option) *CSABBuffer[T] {
+	return &CSABBuffer[T]{b: abBuffer.New[T](capacity, opts...)}
+}
+
+// NewWithComparator creates a new CSABBuffer like New that compares its
+// elements with the given function, so it can hold types that are not
+// comparable (slices, maps, ...).
+func NewWithComparator[T any](capacity uint64, equals func(a, b T) bool, opts ...abBuffer.Option) *CSABBuffer[T] {
+	return &CSABBuffer[T]{b: abBuffer.NewWithComparator(capacity, equals, opts...)}
+}
+
+// lock takes the write lock for the method op, collecting the statistics when
+// enabled.
+func (cs *CSABBuffer[T]) lock(op string) {
+	cs.stats.Lock(&cs.mu, op)
+}
+
+// rlock takes the read lock for the method op, collecting the statistics when
+// enabled.
+func (cs *CSABBuffer[T]) rlock(op string) {
+	cs.stats.RLock(&cs.mu, op)
+}
+
+// unlock records the size of the active buffer and releases the write lock.
+func (cs *CSABBuffer[T]) unlock() {
+	if cs.stats.Enabled() {
+		cs.stats.Observe(cs.b.Size())
+	}
+	cs.mu.Unlock()
+}
+
+// EnableStats turns on or off the collection of the statistics returned by
+// Stats, enabling it resets them. When disabled the overhead is an atomic load
+// per operation.
+func (cs *CSABBuffer[T]) EnableStats(on bool) {
+	cs.stats.Enable(on)
+}
+
+// Stats returns the statistics collected since they were enabled: the
+// operations by type (read or write) and by method, the peak size and the
+// waits for the lock.
+func (cs *CSABBuffer[T]) Stats() common.Stats {
+	return cs.stats.Snapshot()
+}
+
+// Append adds a new element to the active buffer.
+func (cs *CSABBuffer[T]) Append(value T) error {
+	cs.lock("Append")
+	defer cs.unlock()
+	return cs.b.Append(value)
+}
+
+// AppendN adds multiple elements to the active buffer, stopping at the first error.
+func (cs *CSABBuffer[T]) AppendN(values ...T) error {
+	cs.lock("AppendN")
+	defer cs.unlock()
+	for _, v := range values {
+		if err := cs.b.Append(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Clear clears the active buffer.
+func (cs *CSABBuffer[T]) Clear() {
+	cs.lock("Clear")
+	defer cs.unlock()
+	cs.b.Clear()
+}
+
+// ClearAll clears both the active and inactive buffers.
+func (cs *CSABBuffer[T]) ClearAll() {
+	cs.lock("ClearAll")
+	defer cs.unlock()
+	cs.b.ClearAll()
+}
+
+// Wipe overwrites all the elements of both buffers with the zero value and
+// releases their storage (see abBuffer.ABBuffer.Wipe).
+func (cs *CSABBuffer[T]) Wipe() {
+	cs.lock("Wipe")
+	defer cs.unlock()
+	cs.b.Wipe()
+}
+
+// Destroy wipes both buffers and releases the underlying A/B buffer.
+func (cs *CSABBuffer[T]) Destroy() {
+	cs.lock("Destroy")
+	defer cs.unlock()
+	cs.b.Destroy()
+}
+
+// Swap swaps the active buffer with the inactive one, it returns ErrOverflow,
+// without swapping, if the active buffer doesn't fit in the inactive capacity.
+func (cs *CSABBuffer[T]) Swap() error {
+	cs.lock("Swap")
+	defer cs.unlock()
+	return cs.b.Swap()
+}
+
+// TrySwap swaps the active buffer with the inactive one like Swap.
+func (cs *CSABBuffer[T]) TrySwap() error {
+	cs.lock("TrySwap")
+	defer cs.unlock()
+	return cs.b.TrySwap()
+}
+
+// SwapAndGet swaps the buffers and returns the content of the newly inactive
+// buffer in a single locked operation. The inactive buffer is drained, so it's
+// empty when it becomes active again. It returns nil, without swapping, if the
+// active buffer doesn't fit in the inactive capacity.
+func (cs *CSABBuffer[T]) SwapAndGet() []T {
+	cs.lock("SwapAndGet")
+	defer cs.unlock()
+	if cs.b.TrySwap() != nil {
+		return nil
+	}
+	return cs.b.FetchInactive()
+}
+
+// DrainInactive passes the content of the inactive buffer to fn and then empties it,
+// keeping its storage for reuse. fn must not retain the slice nor call methods of cs.
+func (cs *CSABBuffer[T]) DrainInactive(fn func([]T)) error {
+	cs.lock("DrainInactive")
+	defer cs.unlock()
+	return cs.b.DrainInactive(fn)
+}
+
+// SwapAndDrain swaps the buffers and drains the newly inactive one in a single locked operation.
+func (cs *CSABBuffer[T]) SwapAndDrain(fn func([]T)) error {
+	cs.lock("SwapAndDrain")
+	defer cs.unlock()
+	return cs.b.SwapAndDrain(fn)
+}
+
+// SetActiveA sets the active buffer to A.
+func (cs *CSABBuffer[T]) SetActiveA() {
+	cs.lock("SetActiveA")
+	defer cs.unlock()
+	cs.b.SetActiveA()
+}
+
+// SetActiveB sets the active buffer to B.
+func (cs *CSABBuffer[T]) SetActiveB() {
+	cs.lock("SetActiveB")
+	defer cs.unlock()
+	cs.b.SetActiveB()
+}
+
+// GetActive returns a copy of the active buffer.
+func (cs *CSABBuffer[T]) GetActive() []T {
+	cs.rlock("GetActive")
+	defer cs.mu.RUnlock()
+	return cs.b.GetActive()
+}
+
+// GetInactive returns a copy of the inactive buffer.
+func (cs *CSABBuffer[T]) GetInactive() []T {
+	cs.rlock("GetInactive")
+	defer cs.mu.RUnlock()
+	return cs.b.GetInactive()
+}
+
+// FetchInactive returns the inactive buffer and clears it.
+func (cs *CSABBuffer[T]) FetchInactive() []T {
+	cs.lock("FetchInactive")
+	defer cs.unlock()
+	return cs.b.FetchInactive()
+}
+
+// Size returns the number of elements in the active buffer.
+func (cs *CSABBuffer[T]) Size() uint64 {
+	cs.rlock("Size")
+	defer cs.mu.RUnlock()
+	return cs.b.Size()
+}
+
+// Capacity returns the capacity of the active side of the buffer.
+func (cs *CSABBuffer[T]) Capacity() uint64 {
+	cs.rlock("Capacity")
+	defer cs.mu.RUnlock()
+	return cs.b.Capacity()
+}
+
+// InactiveCapacity returns the capacity of the inactive side of the buffer (0 means unlimited).
+func (cs *CSABBuffer[T]) InactiveCapacity() uint64 {
+	cs.rlock("InactiveCapacity")
+	defer cs.mu.RUnlock()
+	return cs.b.InactiveCapacity()
+}
+
+// HighWaterMark returns the largest number of elements the active buffer has held.
+func (cs *CSABBuffer[T]) HighWaterMark() uint64 {
+	cs.rlock("HighWaterMark")
+	defer cs.mu.RUnlock()
+	return cs.b.HighWaterMark()
+}
+
+// ResetHighWaterMark sets the high-water mark to the current size of the active buffer.
+func (cs *CSABBuffer[T]) ResetHighWaterMark() {
+	cs.lock("ResetHighWaterMark")
+	defer cs.unlock()
+	cs.b.ResetHighWaterMark()
+}
+
+// IsEmpty checks if the active buffer is empty.
+func (cs *CSABBuffer[T]) IsEmpty() bool {
+	cs.rlock("IsEmpty")
+	defer cs.mu.RUnlock()
+	return cs.b.IsEmpty()
+}
+
+// ToSlice returns a copy of the active buffer as a slice.
+func (cs *CSABBuffer[T]) ToSlice() []T {
+	return cs.GetActive()
+}
+
+// ToSliceInactive returns a copy of the inactive buffer as a slice.
+func (cs *CSABBuffer[T]) ToSliceInactive() []T {
+	return cs.GetInactive()
+}
+
+// String returns a string representation of the active buffer (elements are formatted with %v).
+func (cs *CSABBuffer[T]) String() string {
+	return cs.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the active buffer with every element formatted by f.
+func (cs *CSABBuffer[T]) StringFunc(f func(T) string) string {
+	cs.rlock("StringFunc")
+	defer cs.mu.RUnlock()
+	return cs.b.StringFunc(f)
+}
+
+// Find returns the first index of the given value in the active buffer.
+func (cs *CSABBuffer[T]) Find(value T) (uint64, error) {
+	cs.rlock("Find")
+	defer cs.mu.RUnlock()
+	return cs.b.Find(value)
+}
+
+// Remove removes the element at the given index in the active buffer.
+func (cs *CSABBuffer[T]) Remove(index uint64) error {
+	cs.lock("Remove")
+	defer cs.unlock()
+	return cs.b.Remove(index)
+}
+
+// InsertAt inserts a new element at the given index in the active buffer.
+func (cs *CSABBuffer[T]) InsertAt(index uint64, value T) error {
+	cs.lock("InsertAt")
+	defer cs.unlock()
+	return cs.b.InsertAt(index, value)
+}
+
+// ForEach applies the function to all elements in the active buffer.
+func (cs *CSABBuffer[T]) ForEach(f func(*T) error) error {
+	cs.lock("ForEach")
+	defer cs.unlock()
+	return cs.b.ForEach(f)
+}
+
+// ForFrom applies the function to all elements in the active buffer starting from the given index.
+func (cs *CSABBuffer[T]) ForFrom(index uint64, f func(*T) error) error {
+	cs.lock("ForFrom")
+	defer cs.unlock()
+	return cs.b.ForFrom(index, f)
+}
+
+// ForRange applies the function to all elements in the active buffer in the range [start, end).
+func (cs *CSABBuffer[T]) ForRange(start, end uint64, f func(*T) error) error {
+	cs.lock("ForRange")
+	defer cs.unlock()
+	return cs.b.ForRange(start, end, f)
+}
+
+// Map generates a new buffer by applying the function to all elements in the active buffer.
+func (cs *CSABBuffer[T]) Map(f func(T) T) (*CSABBuffer[T], error) {
+	cs.rlock("Map")
+	defer cs.mu.RUnlock()
+	nb, err := cs.b.Map(f)
+	if err != nil {
+		return nil, err
+	}
+	return &CSABBuffer[T]{b: nb}, nil
+}
+
+// MapFrom generates a new buffer by applying the function to all elements in the active buffer starting from the given index.
+func (cs *CSABBuffer[T]) MapFrom(index uint64, f func(T) T) (*CSABBuffer[T], error) {
+	cs.rlock("MapFrom")
+	defer cs.mu.RUnlock()
+	nb, err := cs.b.MapFrom(index, f)
+	if err != nil {
+		return nil, err
+	}
+	return &CSABBuffer[T]{b: nb}, nil
+}
+
+// MapRange generates a new buffer by applying the function to all elements in the active buffer in the range [start, end).
+func (cs *CSABBuffer[T]) MapRange(start, end uint64, f func(T) T) (*CSABBuffer[T], error) {
+	cs.rlock("MapRange")
+	defer cs.mu.RUnlock()
+	nb, err := cs.b.MapRange(start, end, f)
+	if err != nil {
+		return nil, err
+	}
+	return &CSABBuffer[T]{b: nb}, nil
+}
+
+// Filter removes the elements of the active buffer that don't match the predicate.
+func (cs *CSABBuffer[T]) Filter(f func(T) bool) {
+	cs.lock("Filter")
+	defer cs.unlock()
+	cs.b.Filter(f)
+}
+
+// Reduce reduces the active buffer to a single value using the given function.
+func (cs *CSABBuffer[T]) Reduce(f func(T, T) T) (T, error) {
+	cs.rlock("Reduce")
+	defer cs.mu.RUnlock()
+	return cs.b.Reduce(f)
+}
+
+// ReduceFrom reduces the active buffer to a single value starting from the given index.
+func (cs *CSABBuffer[T]) ReduceFrom(index uint64, f func(T, T) T) (T, error) {
+	cs.rlock("ReduceFrom")
+	defer cs.mu.RUnlock()
+	return cs.b.ReduceFrom(index, f)
+}
+
+// ReduceRange reduces the active buffer to a single value in the range [start, end).
+func (cs *CSABBuffer[T]) ReduceRange(start, end uint64, f func(T, T) T) (T, error) {
+	cs.rlock("ReduceRange")
+	defer cs.mu.RUnlock()
+	return cs.b.ReduceRange(start, end, f)
+}
+
+// Contains checks if the active buffer contains the given value.
+func (cs *CSABBuffer[T]) Contains(value T) bool {
+	cs.rlock("Contains")
+	defer cs.mu.RUnlock()
+	return cs.b.Contains(value)
+}
+
+// Any checks if any element in the active buffer matches the predicate.
+func (cs *CSABBuffer[T]) Any(f func(T) bool) bool {
+	cs.rlock("Any")
+	defer cs.mu.RUnlock()
+	return cs.b.Any(f)
+}
+
+// All checks if all elements in the active buffer match the predicate.
+func (cs *CSABBuffer[T]) All(f func(T) bool) bool {
+	cs.rlock("All")
+	defer cs.mu.RUnlock()
+	return cs.b.All(f)
+}
+
+// LastIndexOf returns the index of the last element with the given value in the active buffer.
+func (cs *CSABBuffer[T]) LastIndexOf(value T) (uint64, error) {
+	cs.rlock("LastIndexOf")
+	defer cs.mu.RUnlock()
+	return cs.b.LastIndexOf(value)
+}
+
+// Copy returns a copy of both the banks of the A/B buffer (the elements are
+// copied by assignment, use CloneWith to deep copy them).
+func (cs *CSABBuffer[T]) Copy() *CSABBuffer[T] {
+	cs.rlock("Copy")
+	defer cs.mu.RUnlock()
+	return &CSABBuffer[T]{b: cs.b.Copy()}
+}
+
+// CloneWith returns a copy with a copy of every element made by copier, to deep
+// copy elements that hold pointers, slices or maps.
+func (cs *CSABBuffer[T]) CloneWith(copier func(T) T) *CSABBuffer[T] {
+	cs.rlock("CloneWith")
+	defer cs.mu.RUnlock()
+	return &CSABBuffer[T]{b: cs.b.CloneWith(copier)}
+}
+
+// CopyActive returns a new A/B buffer containing a copy of the active buffer.
+func (cs *CSABBuffer[T]) CopyActive() *CSABBuffer[T] {
+	cs.rlock("CopyActive")
+	defer cs.mu.RUnlock()
+	return &CSABBuffer[T]{b: cs.b.CopyActive()}
+}
+
+// CopyInactive returns a new A/B buffer containing a copy of the inactive buffer.
+func (cs *CSABBuffer[T]) CopyInactive() *CSABBuffer[T] {
+	cs.rlock("CopyInactive")
+	defer cs.mu.RUnlock()
+	return &CSABBuffer[T]{b: cs.b.CopyInactive()}
+}
+
+// Merge merges the active buffer with the active buffer of another A/B buffer
+// (the elements are taken out of other first, so the two A/B buffers are never
+// locked at the same time). Merging a buffer with itself does nothing.
+func (cs *CSABBuffer[T]) Merge(other *CSABBuffer[T]) {
+	if other == cs {
+		return
+	}
+	other.lock("Merge")
+	moved := other.b.CopyActive()
+	other.b.Clear()
+	other.unlock()
+
+	cs.lock("Merge")
+	defer cs.unlock()
+	cs.b.Merge(moved)
+}
+
+// Blit overwrites the values of the active buffer with the values of the other buffer using the function
+// (the active buffer of other is copied first, so the two A/B buffers are never locked at the same time).
+func (cs *CSABBuffer[T]) Blit(other *CSABBuffer[T], f func(T, T) T) error {
+	if other == cs {
+		cs.lock("Blit")
+		defer cs.unlock()
+		return cs.b.Blit(cs.b, f)
+	}
+	other.rlock("Blit")
+	src := other.b.CopyActive()
+	other.mu.RUnlock()
+
+	cs.lock("Blit")
+	defer cs.unlock()
+	return cs.b.Blit(src, f)
+}
+
+// SetBlitParallelism sets how Blit splits the work on both the buffers (see buffer.SetBlitParallelism).
+func (cs *CSABBuffer[T]) SetBlitParallelism(workers int, threshold uint64) {
+	cs.lock("SetBlitParallelism")
+	defer cs.unlock()
+	cs.b.SetBlitParallelism(workers, threshold)
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csAbBuffer_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	csAbBuffer "github.com/pzaino/gods/pkg/csAbBuffer"
+)
+
+// newBenchBuffer returns an A/B buffer whose active buffer holds values
+func newBenchBuffer(values []int) *csAbBuffer.CSABBuffer[int] {
+	buf := csAbBuffer.New[int](0)
+	for _, v := range values {
+		_ = buf.Append(v)
+	}
+	return buf
+}
+
+// BenchmarkAppend appends n elements, swapping the buffers every 1000
+func BenchmarkAppend(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		for i := 0; i < b.N; i++ {
+			buf := csAbBuffer.New[int](0)
+			for v := 0; v < n; v++ {
+				_ = buf.Append(v)
+				if v%1000 == 999 {
+					buf.Swap()
+				}
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkInsert inserts an element in the middle of n elements (and removes
+// the last one, so the size stays n)
+func BenchmarkInsert(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		buf := newBenchBuffer(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = buf.InsertAt(uint64(n/2), i)
+			_ = buf.Remove(uint64(n))
+		}
+	})
+}
+
+// BenchmarkDelete removes the element in the middle of n elements (and
+// appends one, so the size stays n)
+func BenchmarkDelete(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		buf := newBenchBuffer(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = buf.Remove(uint64(n / 2))
+			_ = buf.Append(i)
+		}
+	})
+}
+
+// BenchmarkFind looks for the value in the middle of n elements
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		target := values[n/2]
+		buf := newBenchBuffer(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = buf.Find(target)
+		}
+	})
+}
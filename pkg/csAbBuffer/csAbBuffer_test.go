@@ -0,0 +1,329 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csAbBuffer provides a thread-safe wrapper around the ABBuffer type.
+package csAbBuffer_test
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	abBuffer "github.com/pzaino/gods/pkg/abBuffer"
+	csAbBuffer "github.com/pzaino/gods/pkg/csAbBuffer"
+)
+
+const (
+	errUnexpectedErr = "unexpected error: %v"
+	errExpectedXGotY = "expected %v, got %v"
+)
+
+func runConcurrent(_ *testing.T, n int, fn func(j int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(j int) {
+			defer wg.Done()
+			fn(j)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestCSABBufferAppendAndSwap(t *testing.T) {
+	cs := csAbBuffer.New[int](0)
+	runConcurrent(t, 1000, func(j int) {
+		if err := cs.Append(j); err != nil {
+			t.Errorf(errUnexpectedErr, err)
+		}
+	})
+	if cs.Size() != 1000 {
+		t.Fatalf(errExpectedXGotY, 1000, cs.Size())
+	}
+
+	cs.Swap()
+	if !cs.IsEmpty() {
+		t.Errorf(errExpectedXGotY, 0, cs.Size())
+	}
+	if len(cs.GetInactive()) != 1000 {
+		t.Errorf(errExpectedXGotY, 1000, len(cs.GetInactive()))
+	}
+}
+
+func TestCSABBufferSwapAndGet(t *testing.T) {
+	cs := csAbBuffer.New[int](0)
+	_ = cs.AppendN(1, 2, 3)
+
+	data := cs.SwapAndGet()
+	if len(data) != 3 || data[0] != 1 || data[2] != 3 {
+		t.Errorf(errExpectedXGotY, []int{1, 2, 3}, data)
+	}
+	if len(cs.GetInactive()) != 0 {
+		t.Errorf(errExpectedXGotY, 0, len(cs.GetInactive()))
+	}
+
+	_ = cs.Append(4)
+	cs.SwapAndGet()
+	// The drained bank is active again and must be empty
+	if !cs.IsEmpty() {
+		t.Errorf(errExpectedXGotY, 0, cs.Size())
+	}
+}
+
+// TestCSABBufferProducersConsumer checks that no element is lost or duplicated
+// when multiple producers append while a consumer swaps and drains.
+func TestCSABBufferProducersConsumer(t *testing.T) {
+	cs := csAbBuffer.New[int](0)
+	var drained atomic.Int64
+	done := make(chan struct{})
+
+	var consumer sync.WaitGroup
+	consumer.Add(1)
+	go func() {
+		defer consumer.Done()
+		for {
+			select {
+			case <-done:
+				drained.Add(int64(len(cs.SwapAndGet())))
+				return
+			default:
+				drained.Add(int64(len(cs.SwapAndGet())))
+			}
+		}
+	}()
+
+	runConcurrent(t, 10, func(_ int) {
+		for i := 0; i < 1000; i++ {
+			_ = cs.Append(i)
+		}
+	})
+	close(done)
+	consumer.Wait()
+
+	if drained.Load() != 10000 {
+		t.Errorf(errExpectedXGotY, 10000, drained.Load())
+	}
+}
+
+func TestCSABBufferCapacity(t *testing.T) {
+	cs := csAbBuffer.New[int](2)
+	if cs.Capacity() != 2 {
+		t.Errorf(errExpectedXGotY, 2, cs.Capacity())
+	}
+	if err := cs.AppendN(1, 2, 3); err == nil {
+		t.Errorf("expected buffer overflow error")
+	}
+}
+
+func TestCSABBufferReadOperations(t *testing.T) {
+	cs := csAbBuffer.New[int](0)
+	_ = cs.AppendN(1, 2, 3, 2)
+
+	runConcurrent(t, 100, func(_ int) {
+		if !cs.Contains(2) {
+			t.Errorf(errExpectedXGotY, true, false)
+		}
+		if i, _ := cs.Find(2); i != 1 {
+			t.Errorf(errExpectedXGotY, 1, i)
+		}
+		if i, _ := cs.LastIndexOf(2); i != 3 {
+			t.Errorf(errExpectedXGotY, 3, i)
+		}
+		if !cs.Any(func(v int) bool { return v == 3 }) {
+			t.Errorf(errExpectedXGotY, true, false)
+		}
+		if !cs.All(func(v int) bool { return v > 0 }) {
+			t.Errorf(errExpectedXGotY, true, false)
+		}
+		if sum, _ := cs.Reduce(func(a, b int) int { return a + b }); sum != 8 {
+			t.Errorf(errExpectedXGotY, 8, sum)
+		}
+	})
+}
+
+func TestCSABBufferWriteOperations(t *testing.T) {
+	cs := csAbBuffer.New[int](0)
+	_ = cs.AppendN(1, 2, 3, 4)
+
+	runConcurrent(t, 10, func(_ int) {
+		_ = cs.ForEach(func(v *int) error {
+			*v++
+			return nil
+		})
+	})
+	if s := cs.ToSlice(); s[0] != 11 || s[3] != 14 {
+		t.Errorf(errExpectedXGotY, []int{11, 12, 13, 14}, s)
+	}
+
+	cs.Filter(func(v int) bool { return v%2 == 0 })
+	if s := cs.ToSlice(); len(s) != 2 || s[0] != 12 {
+		t.Errorf(errExpectedXGotY, []int{12, 14}, s)
+	}
+
+	other := csAbBuffer.New[int](0)
+	_ = other.AppendN(1, 1)
+	cs.Merge(other)
+	if cs.Size() != 4 || !other.IsEmpty() {
+		t.Errorf(errExpectedXGotY, 4, cs.Size())
+	}
+
+	cp := cs.Copy()
+	cs.ClearAll()
+	if cp.Size() != 4 || cs.Size() != 0 {
+		t.Errorf(errExpectedXGotY, 4, cp.Size())
+	}
+}
+
+func TestMergeAndBlitSelf(t *testing.T) {
+	cs := csAbBuffer.New[int](0)
+	_ = cs.AppendN(1, 2, 3)
+	cs.Merge(cs)
+	if s := cs.ToSlice(); !slices.Equal(s, []int{1, 2, 3}) {
+		t.Errorf(errExpectedXGotY, []int{1, 2, 3}, s)
+	}
+	if err := cs.Blit(cs, func(a, b int) int { return a + b }); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if s := cs.ToSlice(); !slices.Equal(s, []int{2, 4, 6}) {
+		t.Errorf(errExpectedXGotY, []int{2, 4, 6}, s)
+	}
+}
+
+func TestCrossMerge(t *testing.T) {
+	a := csAbBuffer.New[int](0)
+	b := csAbBuffer.New[int](0)
+	runConcurrent(t, 100, func(j int) {
+		if j%2 == 0 {
+			_ = a.Append(j)
+			a.Merge(b)
+			_ = a.Blit(b, func(x, _ int) int { return x })
+		} else {
+			_ = b.Append(j)
+			b.Merge(a)
+			_ = b.Blit(a, func(x, _ int) int { return x })
+		}
+	})
+	if size := a.Size() + b.Size(); size != 100 {
+		t.Errorf(errExpectedXGotY, 100, size)
+	}
+}
+
+func TestCSABBufferSwapAndDrain(t *testing.T) {
+	cs := csAbBuffer.New[int](0)
+	var total atomic.Int64
+	runConcurrent(t, 100, func(j int) {
+		_ = cs.Append(j)
+		if j%10 == 0 {
+			_ = cs.SwapAndDrain(func(items []int) { total.Add(int64(len(items))) })
+		}
+	})
+	_ = cs.SwapAndDrain(func(items []int) { total.Add(int64(len(items))) })
+	_ = cs.SwapAndDrain(func(items []int) { total.Add(int64(len(items))) })
+	if total.Load() != 100 {
+		t.Errorf(errExpectedXGotY, 100, total.Load())
+	}
+	if err := cs.DrainInactive(func([]int) {}); err != csAbBuffer.ErrEmpty {
+		t.Errorf(errExpectedXGotY, csAbBuffer.ErrEmpty, err)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := csAbBuffer.New[*int](0)
+	_ = c.Append(&a)
+	_ = c.Append(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestInactiveCapacity(t *testing.T) {
+	cs := csAbBuffer.New[int](0, abBuffer.WithInactiveCapacity(2))
+	if cs.InactiveCapacity() != 2 {
+		t.Fatalf(errExpectedXGotY, 2, cs.InactiveCapacity())
+	}
+	if err := cs.AppendN(1, 2, 3); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if err := cs.TrySwap(); !errors.Is(err, csAbBuffer.ErrOverflow) {
+		t.Errorf(errExpectedXGotY, csAbBuffer.ErrOverflow, err)
+	}
+	if got := cs.SwapAndGet(); got != nil {
+		t.Errorf("expected no swap, got %v", got)
+	}
+	if cs.HighWaterMark() != 3 {
+		t.Errorf(errExpectedXGotY, 3, cs.HighWaterMark())
+	}
+	cs.Clear()
+	cs.ResetHighWaterMark()
+	if cs.HighWaterMark() != 0 {
+		t.Errorf(errExpectedXGotY, 0, cs.HighWaterMark())
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	cs := csAbBuffer.NewWithComparator(0, slices.Equal[[]string])
+	_ = cs.Append([]string{"a", "b"})
+	if !cs.Contains([]string{"a", "b"}) || cs.Contains([]string{"b", "a"}) {
+		t.Error("expected buffer to contain [a b] only")
+	}
+	cs.Swap()
+	if cs.Contains([]string{"a", "b"}) {
+		t.Error("expected the active bank to be empty after the swap")
+	}
+}
+
+func TestStats(t *testing.T) {
+	cs := csAbBuffer.New[int](10)
+	_ = cs.Append(1)
+	if st := cs.Stats(); st.Reads != 0 || st.Writes != 0 || st.PeakSize != 0 {
+		t.Errorf("expected no statistics while disabled, got %+v", st)
+	}
+
+	cs.EnableStats(true)
+	_ = cs.Append(2)
+	_ = cs.Append(3)
+	cs.Clear()
+	_ = cs.Size()
+	st := cs.Stats()
+	if st.Writes != 3 || st.Reads != 1 || st.PeakSize != 3 {
+		t.Errorf("expected 3 writes, 1 read and peak size 3, got %+v", st)
+	}
+	if want := map[string]uint64{"Append": 2, "Clear": 1, "Size": 1}; !reflect.DeepEqual(st.Ops, want) {
+		t.Errorf("expected the operations %v, got %v", want, st.Ops)
+	}
+
+	cs.EnableStats(false)
+	_ = cs.Append(1)
+	if !reflect.DeepEqual(cs.Stats(), st) {
+		t.Errorf("expected the statistics to stop changing once disabled, got %+v", cs.Stats())
+	}
+}
@@ -13,18 +13,55 @@
 // limitations under the License.
 
 // Package csBuffer provides a thread-safe wrapper around the Buffer type.
+//
+// Callbacks: the methods that only read the elements (Map, Reduce, Any,
+// FindIndex, StringFunc, the *Snapshot iterations, ...) call their function on
+// a snapshot of the buffer without holding the lock, so the function can
+// safely call other methods of the same buffer. ForEach, ForFrom, ForRange and
+// their Parallel variants pass pointers to the elements in place, like the
+// methods that reorder or remove elements with a function (Filter, Sort, Blit,
+// ...), and call the function with the lock held: it must not call methods of
+// the same buffer or it deadlocks.
 package csBuffer
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"sync"
+	"unsafe"
 
 	buffer "github.com/pzaino/gods/pkg/buffer"
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the ConcurrentBuffer methods, they are the same
+// returned by the buffer package (use errors.Is to check for them).
+var (
+	ErrOverflow    = buffer.ErrOverflow
+	ErrInvalid     = buffer.ErrInvalid
+	ErrEmpty       = buffer.ErrEmpty
+	ErrNotFound    = buffer.ErrNotFound
+	ErrOutOfBounds = buffer.ErrOutOfBounds
+	// ErrBusy is returned by the Try methods when the lock is taken.
+	ErrBusy = common.ErrBusy
 )
 
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureHasher, common.FeatureObservers, common.FeatureBinaryCodec, common.FeatureConcurrent, common.FeatureDeepCopy, common.FeatureJSON)
+}
+
 // ConcurrentBuffer is a thread-safe wrapper around the Buffer type.
-type ConcurrentBuffer[T comparable] struct {
-	b  *buffer.Buffer[T]
-	mu sync.RWMutex
+type ConcurrentBuffer[T any] struct {
+	b     *buffer.Buffer[T]
+	mu    sync.RWMutex
+	stats common.LockStats // opt-in statistics, see EnableStats
+	pool  sync.Pool        // recycled snapshot slices used by Scan
+	// changed is created by Drain while it waits for new elements and closed
+	// by the methods that add elements (nil when nobody is waiting)
+	changed chan struct{}
 }
 
 // New creates a new ConcurrentBuffer.
@@ -32,6 +69,16 @@ func New[T comparable]() *ConcurrentBuffer[T] {
 	return &ConcurrentBuffer[T]{b: buffer.New[T]()}
 }
 
+// NewWithComparator creates a new ConcurrentBuffer that compares its elements with the given function.
+func NewWithComparator[T any](equals func(a, b T) bool) *ConcurrentBuffer[T] {
+	return &ConcurrentBuffer[T]{b: buffer.NewWithComparator(equals)}
+}
+
+// NewWithHasher creates a new ConcurrentBuffer that compares its elements with the given hasher (see buffer.NewWithHasher).
+func NewWithHasher[T any](hasher common.Hasher[T]) *ConcurrentBuffer[T] {
+	return &ConcurrentBuffer[T]{b: buffer.NewWithHasher(hasher)}
+}
+
 // NewWithCapacity creates a new ConcurrentBuffer with the given capacity.
 func NewWithCapacity[T comparable](capacity uint64) *ConcurrentBuffer[T] {
 	return &ConcurrentBuffer[T]{b: buffer.NewWithCapacity[T](capacity)}
@@ -47,198 +94,518 @@ func NewWithSizeAndCapacity[T comparable](size, capacity uint64) *ConcurrentBuff
 	return &ConcurrentBuffer[T]{b: buffer.NewWithSizeAndCapacity[T](size, capacity)}
 }
 
+// lock takes the write lock for the method op, collecting the statistics when
+// enabled.
+func (cb *ConcurrentBuffer[T]) lock(op string) {
+	cb.stats.Lock(&cb.mu, op)
+}
+
+// rlock takes the read lock for the method op, collecting the statistics when
+// enabled.
+func (cb *ConcurrentBuffer[T]) rlock(op string) {
+	cb.stats.RLock(&cb.mu, op)
+}
+
+// unlock records the size of the buffer and releases the write lock.
+func (cb *ConcurrentBuffer[T]) unlock() {
+	cb.stats.Observe(cb.b.Size())
+	cb.mu.Unlock()
+}
+
+// tryLock takes the write lock for the method op if it's free, collecting the
+// statistics when enabled, and returns false without waiting otherwise.
+func (cb *ConcurrentBuffer[T]) tryLock(op string) bool {
+	return cb.stats.TryLock(&cb.mu, op)
+}
+
+// tryRLock takes the read lock for the method op if it's available, collecting
+// the statistics when enabled, and returns false without waiting otherwise.
+func (cb *ConcurrentBuffer[T]) tryRLock(op string) bool {
+	return cb.stats.TryRLock(&cb.mu, op)
+}
+
+// EnableStats turns on or off the collection of the statistics returned by
+// Stats, enabling it resets them. When disabled the overhead is an atomic load
+// per operation.
+func (cb *ConcurrentBuffer[T]) EnableStats(on bool) {
+	cb.stats.Enable(on)
+}
+
+// Stats returns the statistics collected since they were enabled: the
+// operations by type (read or write), the peak size and the waits for the lock.
+func (cb *ConcurrentBuffer[T]) Stats() common.Stats {
+	return cb.stats.Snapshot()
+}
+
 // Append adds an element to the end of the buffer.
 func (cb *ConcurrentBuffer[T]) Append(elem T) error {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("Append")
+	defer cb.unlock()
+	defer cb.notify()
+	return cb.b.Append(elem)
+}
+
+// TryAppend adds an element to the end of the buffer like Append if the lock
+// is free, otherwise it returns ErrBusy without waiting (for the paths that
+// prefer to skip the work rather than wait on a contended buffer).
+func (cb *ConcurrentBuffer[T]) TryAppend(elem T) error {
+	if !cb.tryLock("TryAppend") {
+		return ErrBusy
+	}
+	defer cb.unlock()
+	defer cb.notify()
 	return cb.b.Append(elem)
 }
 
 // InsertAt adds an element at the given index.
 func (cb *ConcurrentBuffer[T]) InsertAt(index uint64, elem T) error {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("InsertAt")
+	defer cb.unlock()
+	defer cb.notify()
 	return cb.b.InsertAt(index, elem)
 }
 
 // Put replaces the element at the given index.
 func (cb *ConcurrentBuffer[T]) Put(index uint64, elem T) error {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("Put")
+	defer cb.unlock()
 	return cb.b.Put(index, elem)
 }
 
 // Get returns the element at the given index.
 func (cb *ConcurrentBuffer[T]) Get(index uint64) (T, error) {
-	cb.mu.RLock()
+	cb.rlock("Get")
+	defer cb.mu.RUnlock()
+	return cb.b.Get(index)
+}
+
+// TryGet returns the element at the given index like Get if the buffer isn't
+// being written, otherwise it returns ErrBusy without waiting.
+func (cb *ConcurrentBuffer[T]) TryGet(index uint64) (T, error) {
+	if !cb.tryRLock("TryGet") {
+		var zero T
+		return zero, ErrBusy
+	}
 	defer cb.mu.RUnlock()
 	return cb.b.Get(index)
 }
 
 // Remove removes the element at the given index.
 func (cb *ConcurrentBuffer[T]) Remove(index uint64) error {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("Remove")
+	defer cb.unlock()
 	return cb.b.Remove(index)
 }
 
+// InsertSliceAt inserts all the given items at the given index (all or nothing).
+func (cb *ConcurrentBuffer[T]) InsertSliceAt(index uint64, items []T) error {
+	cb.lock("InsertSliceAt")
+	defer cb.unlock()
+	defer cb.notify()
+	return cb.b.InsertSliceAt(index, items)
+}
+
+// RemoveRange removes the elements in the range [start, end).
+func (cb *ConcurrentBuffer[T]) RemoveRange(start, end uint64) error {
+	cb.lock("RemoveRange")
+	defer cb.unlock()
+	return cb.b.RemoveRange(start, end)
+}
+
+// ReplaceRange replaces the elements in the range [start, end) with the given items (all or nothing).
+func (cb *ConcurrentBuffer[T]) ReplaceRange(start, end uint64, items []T) error {
+	cb.lock("ReplaceRange")
+	defer cb.unlock()
+	defer cb.notify()
+	return cb.b.ReplaceRange(start, end, items)
+}
+
 // Clear removes all elements from the buffer.
 func (cb *ConcurrentBuffer[T]) Clear() {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("Clear")
+	defer cb.unlock()
 	cb.b.Clear()
 }
 
-// Destroy removes all elements from the buffer and sets the capacity to 0.
+// Wipe overwrites every element of the buffer with the zero value and
+// releases its storage (see buffer.Buffer.Wipe).
+func (cb *ConcurrentBuffer[T]) Wipe() {
+	cb.lock("Wipe")
+	defer cb.unlock()
+	cb.b.Wipe()
+}
+
+// Destroy wipes the buffer (see Wipe) and sets the capacity to 0.
 func (cb *ConcurrentBuffer[T]) Destroy() {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("Destroy")
+	defer cb.unlock()
 	cb.b.Destroy()
 }
 
-// Values returns all elements in the buffer.
-func (cb *ConcurrentBuffer[T]) Values() []T {
-	cb.mu.RLock()
+// SetCodec sets the codec used by MarshalBinary and UnmarshalBinary to encode
+// the elements (nil restores the default common.GobCodec).
+func (cb *ConcurrentBuffer[T]) SetCodec(codec common.Codec[T]) {
+	cb.lock("SetCodec")
+	defer cb.unlock()
+	cb.b.SetCodec(codec)
+}
+
+// MarshalBinary encodes the elements of the buffer under the read lock
+// (implements encoding.BinaryMarshaler).
+func (cb *ConcurrentBuffer[T]) MarshalBinary() ([]byte, error) {
+	cb.rlock("MarshalBinary")
 	defer cb.mu.RUnlock()
-	return cb.b.Values()
+	return cb.b.MarshalBinary()
+}
+
+// UnmarshalBinary replaces the elements of the buffer with the ones encoded by
+// MarshalBinary (implements encoding.BinaryUnmarshaler), waking up the
+// goroutines waiting in Drain.
+func (cb *ConcurrentBuffer[T]) UnmarshalBinary(data []byte) error {
+	cb.lock("UnmarshalBinary")
+	defer cb.unlock()
+	defer cb.notify()
+	return cb.b.UnmarshalBinary(data)
+}
+
+// EncodeJSONStream writes the elements of the buffer to w as a JSON array,
+// holding the read lock while writing.
+func (cb *ConcurrentBuffer[T]) EncodeJSONStream(w io.Writer) error {
+	cb.rlock("EncodeJSONStream")
+	defer cb.mu.RUnlock()
+	return cb.b.EncodeJSONStream(w)
+}
+
+// DecodeJSONStream appends the elements of the JSON array read from dec,
+// holding the write lock while reading, and wakes up the goroutines waiting in
+// Drain.
+func (cb *ConcurrentBuffer[T]) DecodeJSONStream(dec *json.Decoder) error {
+	cb.lock("DecodeJSONStream")
+	defer cb.unlock()
+	defer cb.notify()
+	return cb.b.DecodeJSONStream(dec)
+}
+
+// Snapshot returns a copy of all elements in the buffer taken under a single
+// read lock acquisition, so it is a consistent view of the buffer at one point in
+// time. Iterate over a snapshot instead of calling Get(i) in a loop, which can
+// observe concurrent writes between two calls and doesn't block writers for the whole scan.
+func (cb *ConcurrentBuffer[T]) Snapshot() []T {
+	cb.rlock("Snapshot")
+	defer cb.mu.RUnlock()
+	return cb.b.ToSlice()
+}
+
+// Values returns a snapshot of all elements in the buffer (same as Snapshot).
+func (cb *ConcurrentBuffer[T]) Values() []T {
+	return cb.Snapshot()
+}
+
+// ToSlice returns a snapshot of all elements in the buffer (same as Snapshot).
+func (cb *ConcurrentBuffer[T]) ToSlice() []T {
+	return cb.Snapshot()
+}
+
+// DrainAll atomically removes and returns all elements in a new slice, the
+// buffer keeps its storage (see Buffer.DrainAll). Unlike Drain, it doesn't
+// wait for new elements.
+func (cb *ConcurrentBuffer[T]) DrainAll() []T {
+	cb.lock("DrainAll")
+	defer cb.unlock()
+	return cb.b.DrainAll()
+}
+
+// String returns a string representation of the buffer (elements are formatted with %v).
+func (cb *ConcurrentBuffer[T]) String() string {
+	return cb.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the buffer with every element formatted by f.
+func (cb *ConcurrentBuffer[T]) StringFunc(f func(T) string) string {
+	return cb.view("StringFunc").StringFunc(f)
 }
 
 // Size returns the number of elements in the buffer.
 func (cb *ConcurrentBuffer[T]) Size() uint64 {
-	cb.mu.RLock()
+	cb.rlock("Size")
 	defer cb.mu.RUnlock()
 	return cb.b.Size()
 }
 
 // Capacity returns the capacity of the buffer.
 func (cb *ConcurrentBuffer[T]) Capacity() uint64 {
-	cb.mu.RLock()
+	cb.rlock("Capacity")
 	defer cb.mu.RUnlock()
 	return cb.b.Capacity()
 }
 
 // SetCapacity sets the capacity of the buffer.
 func (cb *ConcurrentBuffer[T]) SetCapacity(capacity uint64) {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("SetCapacity")
+	defer cb.unlock()
 	cb.b.SetCapacity(capacity)
 }
 
 // Contains returns true if the buffer contains the given element.
 func (cb *ConcurrentBuffer[T]) Contains(value T) bool {
-	cb.mu.RLock()
+	cb.rlock("Contains")
 	defer cb.mu.RUnlock()
 	return cb.b.Contains(value)
 }
 
 // IsEmpty returns true if the buffer is empty.
 func (cb *ConcurrentBuffer[T]) IsEmpty() bool {
-	cb.mu.RLock()
+	cb.rlock("IsEmpty")
 	defer cb.mu.RUnlock()
 	return cb.b.IsEmpty()
 }
 
 // IsFull returns true if the buffer is full.
 func (cb *ConcurrentBuffer[T]) IsFull() bool {
-	cb.mu.RLock()
+	cb.rlock("IsFull")
 	defer cb.mu.RUnlock()
 	return cb.b.IsFull()
 }
 
 // Find returns the index of the first element with the given value.
 func (cb *ConcurrentBuffer[T]) Find(value T) (uint64, error) {
-	cb.mu.RLock()
+	cb.rlock("Find")
 	defer cb.mu.RUnlock()
 	return cb.b.Find(value)
 }
 
+// FindCtx returns the index of the first element with the given value, it stops
+// with the error of ctx when it's done (see buffer.FindCtx).
+func (cb *ConcurrentBuffer[T]) FindCtx(ctx context.Context, value T) (uint64, error) {
+	cb.rlock("FindCtx")
+	defer cb.mu.RUnlock()
+	return cb.b.FindCtx(ctx, value)
+}
+
 // Reverse reverses the buffer.
 func (cb *ConcurrentBuffer[T]) Reverse() {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("Reverse")
+	defer cb.unlock()
 	cb.b.Reverse()
 }
 
-// Equals returns true if the buffer is equal to another buffer.
+// Equals returns true if the buffer is equal to another buffer. It works on a
+// copy of other, so the locks of the two buffers are never held together.
 func (cb *ConcurrentBuffer[T]) Equals(other *ConcurrentBuffer[T]) bool {
-	cb.mu.RLock()
+	if other == cb {
+		return true
+	}
+	theirs := other.view("Equals")
+	cb.rlock("Equals")
 	defer cb.mu.RUnlock()
-	other.mu.RLock()
-	defer other.mu.RUnlock()
-	return cb.b.Equals(other.b)
+	return cb.b.Equals(theirs)
 }
 
-// Copy returns a new buffer with copied elements.
+// Copy returns a new buffer with copied elements (a shallow copy, see
+// buffer.Buffer.Copy).
 func (cb *ConcurrentBuffer[T]) Copy() *ConcurrentBuffer[T] {
-	cb.mu.RLock()
+	cb.rlock("Copy")
 	defer cb.mu.RUnlock()
 	newBuffer := cb.b.Copy()
 	return &ConcurrentBuffer[T]{b: newBuffer}
 }
 
-// Merge appends all elements from another buffer.
+// CloneWith returns a copy with a copy of every element made by copier, to deep
+// copy elements that hold pointers, slices or maps.
+func (cb *ConcurrentBuffer[T]) CloneWith(copier func(T) T) *ConcurrentBuffer[T] {
+	return &ConcurrentBuffer[T]{b: cb.view("CloneWith").CloneWith(copier)}
+}
+
+// Merge moves all elements from another buffer to the end of this one (see
+// buffer.Buffer.Merge), merging a buffer with itself does nothing. Both the
+// buffers change, so their write locks are taken in the same order (by address)
+// whatever the direction of the merge: a.Merge(b) and b.Merge(a) can run at the
+// same time without deadlocking, and either of them moves all the elements.
 func (cb *ConcurrentBuffer[T]) Merge(other *ConcurrentBuffer[T]) {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
-	other.mu.RLock()
-	defer other.mu.RUnlock()
+	if other == cb {
+		return
+	}
+	first, second := cb, other
+	if uintptr(unsafe.Pointer(other)) < uintptr(unsafe.Pointer(cb)) {
+		first, second = other, cb
+	}
+	first.lock("Merge")
+	defer first.unlock()
+	second.lock("Merge")
+	defer second.unlock()
+	defer cb.notify()
 	cb.b.Merge(other.b)
 }
 
-// PopN removes and returns the last n elements.
+// PopN removes and returns the last n elements, in the order they had in the
+// buffer. It's atomic: either all the n elements are removed or, if the buffer
+// has fewer, none and ErrEmpty is returned. PopN and PushN never interleave, so
+// a PopN of the same size as the last PushN returns exactly its batch.
 func (cb *ConcurrentBuffer[T]) PopN(n uint64) ([]T, error) {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("PopN")
+	defer cb.unlock()
 	return cb.b.PopN(n)
 }
 
-// PushN adds multiple elements to the end of the buffer.
+// PopUpToN atomically removes and returns the last n elements like PopN, or all
+// the elements if the buffer has fewer than n (nil if it's empty).
+func (cb *ConcurrentBuffer[T]) PopUpToN(n uint64) []T {
+	cb.lock("PopUpToN")
+	defer cb.unlock()
+	return cb.b.PopUpToN(n)
+}
+
+// TryPop removes and returns the last element of the buffer (ErrEmpty if
+// there is none) if the lock is free, otherwise it returns ErrBusy without
+// waiting.
+func (cb *ConcurrentBuffer[T]) TryPop() (T, error) {
+	if !cb.tryLock("TryPop") {
+		var zero T
+		return zero, ErrBusy
+	}
+	defer cb.unlock()
+	values, err := cb.b.PopN(1)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return values[0], nil
+}
+
+// PushN atomically adds multiple elements to the end of the buffer, in order:
+// either all of them are added or, if they don't fit, none and ErrOverflow is
+// returned.
 func (cb *ConcurrentBuffer[T]) PushN(items ...T) error {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("PushN")
+	defer cb.unlock()
+	defer cb.notify()
 	return cb.b.PushN(items...)
 }
 
+// Truncate atomically keeps the first n elements of the buffer and removes the
+// others, it does nothing if the buffer holds n elements or fewer.
+func (cb *ConcurrentBuffer[T]) Truncate(n uint64) {
+	cb.lock("Truncate")
+	defer cb.unlock()
+	cb.b.Truncate(n)
+}
+
+// Resize atomically sets the number of elements of the buffer to n, filling a
+// bigger buffer with copies of fill or truncating a smaller one. It fails with
+// ErrOverflow, leaving the buffer untouched, if n exceeds the capacity.
+func (cb *ConcurrentBuffer[T]) Resize(n uint64, fill T) error {
+	cb.lock("Resize")
+	defer cb.unlock()
+	defer cb.notify()
+	return cb.b.Resize(n, fill)
+}
+
+// notify wakes up the Drain goroutines waiting for new elements, it must be
+// called with the write lock held
+func (cb *ConcurrentBuffer[T]) notify() {
+	if cb.changed != nil {
+		close(cb.changed)
+		cb.changed = nil
+	}
+}
+
+// Drain returns a channel that receives the elements of the buffer from the
+// first one (FIFO order), removing each element as it's sent. When the buffer
+// is empty it waits for new elements to be added, until ctx is done, then the
+// channel is closed. An element taken from the buffer when ctx is done is put
+// back at the front of the buffer instead of being sent, if the buffer was
+// filled in the meantime it's sent anyway before the channel is closed, so
+// keep receiving until the channel is closed not to lose it.
+func (cb *ConcurrentBuffer[T]) Drain(ctx context.Context) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for {
+			cb.lock("Drain")
+			if cb.b.IsEmpty() {
+				if cb.changed == nil {
+					cb.changed = make(chan struct{})
+				}
+				changed := cb.changed
+				cb.unlock()
+				select {
+				case <-changed:
+					continue
+				case <-ctx.Done():
+					return
+				}
+			}
+			elem, _ := cb.b.Get(0)
+			_ = cb.b.Remove(0)
+			cb.unlock()
+
+			select {
+			case out <- elem:
+			case <-ctx.Done():
+				cb.lock("Drain")
+				err := cb.b.InsertAt(0, elem)
+				cb.notify()
+				cb.unlock()
+				if err != nil {
+					out <- elem
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
 // ShiftLeft shifts all elements to the left by n positions.
 func (cb *ConcurrentBuffer[T]) ShiftLeft(n uint64) {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("ShiftLeft")
+	defer cb.unlock()
 	cb.b.ShiftLeft(n)
 }
 
 // ShiftRight shifts all elements to the right by n positions.
 func (cb *ConcurrentBuffer[T]) ShiftRight(n uint64) {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("ShiftRight")
+	defer cb.unlock()
+	defer cb.notify()
 	cb.b.ShiftRight(n)
 }
 
 // RotateLeft rotates all elements to the left by n positions.
 func (cb *ConcurrentBuffer[T]) RotateLeft(n uint64) {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("RotateLeft")
+	defer cb.unlock()
 	cb.b.RotateLeft(n)
 }
 
 // RotateRight rotates all elements to the right by n positions.
 func (cb *ConcurrentBuffer[T]) RotateRight(n uint64) {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("RotateRight")
+	defer cb.unlock()
 	cb.b.RotateRight(n)
 }
 
 // Filter removes elements that don't match the predicate.
+// The predicate is called with the lock held, it must not call methods of cb.
 func (cb *ConcurrentBuffer[T]) Filter(predicate func(T) bool) {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("Filter")
+	defer cb.unlock()
 	cb.b.Filter(predicate)
 }
 
 // Map creates a new buffer with the results of applying the function to each element.
 func (cb *ConcurrentBuffer[T]) Map(fn func(T) T) (*ConcurrentBuffer[T], error) {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	mappedBuffer, err := cb.b.Map(fn)
+	mappedBuffer, err := cb.view("Map").Map(fn)
+	if err != nil {
+		return nil, err
+	}
+	return &ConcurrentBuffer[T]{b: mappedBuffer}, nil
+}
+
+// MapCtx creates a new buffer with the results of applying the function to each
+// element, it stops with the error of ctx when it's done (see buffer.MapCtx).
+func (cb *ConcurrentBuffer[T]) MapCtx(ctx context.Context, fn func(T) T) (*ConcurrentBuffer[T], error) {
+	mappedBuffer, err := cb.view("MapCtx").MapCtx(ctx, fn)
 	if err != nil {
 		return nil, err
 	}
@@ -247,101 +614,353 @@ func (cb *ConcurrentBuffer[T]) Map(fn func(T) T) (*ConcurrentBuffer[T], error) {
 
 // Reduce reduces the buffer to a single value.
 func (cb *ConcurrentBuffer[T]) Reduce(fn func(T, T) T) (T, error) {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	return cb.b.Reduce(fn)
+	return cb.view("Reduce").Reduce(fn)
 }
 
 // Swap swaps the elements at the given indices.
 func (cb *ConcurrentBuffer[T]) Swap(i, j uint64) error {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("Swap")
+	defer cb.unlock()
 	return cb.b.Swap(i, j)
 }
 
-// ForEach applies the function to each element in the buffer.
+// ForEach applies the function to each element in the buffer in place,
+// holding the write lock: fn must not call methods of cb (it would deadlock).
+// Use ForEachSnapshot to run a function that only reads the elements without
+// holding the lock.
 func (cb *ConcurrentBuffer[T]) ForEach(fn func(*T) error) error {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("ForEach")
+	defer cb.unlock()
 	return cb.b.ForEach(fn)
 }
 
-// ForFrom applies the function to each element in the buffer starting from the given index.
+// ForEachCtx applies the function in place to each element in the buffer like
+// ForEach, it stops with the error of ctx when it's done (see
+// buffer.ForEachCtx).
+func (cb *ConcurrentBuffer[T]) ForEachCtx(ctx context.Context, fn func(*T) error) error {
+	cb.lock("ForEachCtx")
+	defer cb.unlock()
+	return cb.b.ForEachCtx(ctx, fn)
+}
+
+// ForFrom applies the function in place to each element in the buffer
+// starting from the given index, holding the write lock (see ForEach).
 func (cb *ConcurrentBuffer[T]) ForFrom(start uint64, fn func(*T) error) error {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("ForFrom")
+	defer cb.unlock()
 	return cb.b.ForFrom(start, fn)
 }
 
-// ForRange applies the function to each element in the buffer within the given range.
+// ForRange applies the function in place to each element in the buffer
+// within the given range, holding the write lock (see ForEach).
 func (cb *ConcurrentBuffer[T]) ForRange(start, end uint64, fn func(*T) error) error {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
+	cb.lock("ForRange")
+	defer cb.unlock()
 	return cb.b.ForRange(start, end, fn)
 }
 
+// ForEachSnapshot calls fn with each element of a snapshot of the buffer,
+// stopping at the first error. The lock is held only while the snapshot is
+// taken, so fn can call methods of cb.
+func (cb *ConcurrentBuffer[T]) ForEachSnapshot(fn func(T) error) error {
+	return cb.view("ForEachSnapshot").ForEach(byValue(fn))
+}
+
+// ForFromSnapshot calls fn with each element of a snapshot of the buffer
+// starting from the given index (see ForEachSnapshot).
+func (cb *ConcurrentBuffer[T]) ForFromSnapshot(start uint64, fn func(T) error) error {
+	return cb.view("ForFromSnapshot").ForFrom(start, byValue(fn))
+}
+
+// ForRangeSnapshot calls fn with each element of a snapshot of the buffer
+// within the given range (see ForEachSnapshot).
+func (cb *ConcurrentBuffer[T]) ForRangeSnapshot(start, end uint64, fn func(T) error) error {
+	return cb.view("ForRangeSnapshot").ForRange(start, end, byValue(fn))
+}
+
+// ParallelForEach applies the function in place to each element in the
+// buffer using up to workers goroutines, stopping at the first error or when
+// ctx is done, holding the write lock (see buffer.ParallelForRange and
+// ForEach).
+func (cb *ConcurrentBuffer[T]) ParallelForEach(ctx context.Context, workers int, fn func(*T) error) error {
+	cb.lock("ParallelForEach")
+	defer cb.unlock()
+	return cb.b.ParallelForEach(ctx, workers, fn)
+}
+
+// ParallelForRange applies the function in place to each element in the
+// buffer within the given range using up to workers goroutines, holding the
+// write lock (see ParallelForEach).
+func (cb *ConcurrentBuffer[T]) ParallelForRange(ctx context.Context, start, end uint64, workers int, fn func(*T) error) error {
+	cb.lock("ParallelForRange")
+	defer cb.unlock()
+	return cb.b.ParallelForRange(ctx, start, end, workers, fn)
+}
+
+// ParallelForEachSnapshot calls fn with each element of a snapshot of the
+// buffer using up to workers goroutines, without holding the lock (see
+// ParallelForEach and ForEachSnapshot).
+func (cb *ConcurrentBuffer[T]) ParallelForEachSnapshot(ctx context.Context, workers int, fn func(T) error) error {
+	return cb.view("ParallelForEachSnapshot").ParallelForEach(ctx, workers, byValue(fn))
+}
+
+// ParallelForRangeSnapshot calls fn with each element of a snapshot of the
+// buffer within the given range using up to workers goroutines (see
+// ParallelForEachSnapshot).
+func (cb *ConcurrentBuffer[T]) ParallelForRangeSnapshot(ctx context.Context, start, end uint64, workers int, fn func(T) error) error {
+	return cb.view("ParallelForRangeSnapshot").ParallelForRange(ctx, start, end, workers, byValue(fn))
+}
+
+// byValue adapts a function taking the elements by value to the iteration
+// methods of buffer.Buffer.
+func byValue[T any](fn func(T) error) func(*T) error {
+	return func(v *T) error {
+		return fn(*v)
+	}
+}
+
+// Scan calls fn for each element of a snapshot of the buffer, stopping as soon
+// as fn returns false. The read lock is held only while the snapshot is taken,
+// so long scans (or slow callbacks) don't block writers; the snapshot slices are
+// recycled through a sync.Pool to avoid an allocation per scan.
+func (cb *ConcurrentBuffer[T]) Scan(fn func(T) bool) {
+	snap := cb.snapshot("Scan")
+	defer cb.release(snap)
+	for _, v := range *snap {
+		if !fn(v) {
+			return
+		}
+	}
+}
+
+// snapshot copies the buffer content into a (possibly recycled) slice, op is
+// the method counted in the statistics.
+func (cb *ConcurrentBuffer[T]) snapshot(op string) *[]T {
+	snap, _ := cb.pool.Get().(*[]T)
+	if snap == nil {
+		snap = new([]T)
+	}
+	cb.rlock(op)
+	*snap = append((*snap)[:0], cb.b.UnsafeSlice()...)
+	cb.mu.RUnlock()
+	return snap
+}
+
+// release clears a snapshot (so it doesn't keep elements alive) and returns it to the pool.
+func (cb *ConcurrentBuffer[T]) release(snap *[]T) {
+	clear(*snap)
+	*snap = (*snap)[:0]
+	cb.pool.Put(snap)
+}
+
+// view returns a copy of the underlying buffer taken under the read lock, the
+// callbacks of the read-only methods run on it without holding the lock (op is
+// the method counted in the statistics).
+func (cb *ConcurrentBuffer[T]) view(op string) *buffer.Buffer[T] {
+	cb.rlock(op)
+	defer cb.mu.RUnlock()
+	return cb.b.Copy()
+}
+
 // Any checks if any element in the buffer matches the predicate.
 func (cb *ConcurrentBuffer[T]) Any(predicate func(T) bool) bool {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	return cb.b.Any(predicate)
+	return cb.view("Any").Any(predicate)
 }
 
 // All checks if all elements in the buffer match the predicate.
 func (cb *ConcurrentBuffer[T]) All(predicate func(T) bool) bool {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	return cb.b.All(predicate)
+	return cb.view("All").All(predicate)
 }
 
 // FindIndex returns the index of the first element that matches the predicate.
 func (cb *ConcurrentBuffer[T]) FindIndex(predicate func(T) bool) (uint64, error) {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	return cb.b.FindIndex(predicate)
+	return cb.view("FindIndex").FindIndex(predicate)
 }
 
-// FindLast returns the last element that matches the predicate.
+// FindLast returns the last element that matches the predicate (a pointer to a
+// copy of the element, changing it doesn't change the buffer).
 func (cb *ConcurrentBuffer[T]) FindLast(predicate func(T) bool) (*T, error) {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	return cb.b.FindLast(predicate)
+	return cb.view("FindLast").FindLast(predicate)
 }
 
 // FindLastIndex returns the index of the last element that matches the predicate.
 func (cb *ConcurrentBuffer[T]) FindLastIndex(predicate func(T) bool) (uint64, error) {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	return cb.b.FindLastIndex(predicate)
+	return cb.view("FindLastIndex").FindLastIndex(predicate)
 }
 
 // FindAll returns all elements that match the predicate.
 func (cb *ConcurrentBuffer[T]) FindAll(predicate func(T) bool) *ConcurrentBuffer[T] {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	newBuffer := cb.b.FindAll(predicate)
+	newBuffer := cb.view("FindAll").FindAll(predicate)
 	return &ConcurrentBuffer[T]{b: newBuffer}
 }
 
 // FindIndices returns the indices of all elements that match the predicate.
 func (cb *ConcurrentBuffer[T]) FindIndices(predicate func(T) bool) []uint64 {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	return cb.b.FindIndices(predicate)
+	return cb.view("FindIndices").FindIndices(predicate)
 }
 
 // LastIndexOf returns the index of the last element with the given value.
 func (cb *ConcurrentBuffer[T]) LastIndexOf(value T) (uint64, error) {
-	cb.mu.RLock()
+	cb.rlock("LastIndexOf")
 	defer cb.mu.RUnlock()
 	return cb.b.LastIndexOf(value)
 }
 
 // Blit combines/overwrites the values in the buffer with the values of another buffer using a function.
+// It works on a copy of other, so the locks of the two buffers are never held together.
+// f is called with the lock of cb held, it must not call methods of cb.
 func (cb *ConcurrentBuffer[T]) Blit(other *ConcurrentBuffer[T], f func(T, T) T) error {
-	cb.mu.Lock()
-	defer cb.mu.Unlock()
-	other.mu.RLock()
-	defer other.mu.RUnlock()
-	return cb.b.Blit(other.b, f)
+	if other == cb {
+		cb.lock("Blit")
+		defer cb.unlock()
+		return cb.b.Blit(cb.b, f)
+	}
+	theirs := other.view("Blit")
+	cb.lock("Blit")
+	defer cb.unlock()
+	return cb.b.Blit(theirs, f)
+}
+
+// SetBlitParallelism sets the number of goroutines and the minimum number of
+// elements Blit uses to work in parallel (see buffer.SetBlitParallelism).
+func (cb *ConcurrentBuffer[T]) SetBlitParallelism(workers int, threshold uint64) {
+	cb.lock("SetBlitParallelism")
+	defer cb.unlock()
+	cb.b.SetBlitParallelism(workers, threshold)
+}
+
+// Sort sorts the buffer according to the given function.
+// less is called with the lock held, it must not call methods of cb.
+func (cb *ConcurrentBuffer[T]) Sort(less func(T, T) bool) {
+	cb.lock("Sort")
+	defer cb.unlock()
+	cb.b.Sort(less)
+}
+
+// SortStable sorts the buffer according to the given function keeping the original order of equal elements.
+// less is called with the lock held, it must not call methods of cb.
+func (cb *ConcurrentBuffer[T]) SortStable(less func(T, T) bool) {
+	cb.lock("SortStable")
+	defer cb.unlock()
+	cb.b.SortStable(less)
+}
+
+// IsSorted returns true if the buffer is sorted according to the given function.
+func (cb *ConcurrentBuffer[T]) IsSorted(less func(T, T) bool) bool {
+	return cb.view("IsSorted").IsSorted(less)
+}
+
+// Compare compares the buffer with other lexicographically (see
+// buffer.Buffer.Compare). It works on a copy of other, so the locks of the two
+// buffers are never held together.
+func (cb *ConcurrentBuffer[T]) Compare(other *ConcurrentBuffer[T], compare func(a, b T) int) int {
+	if other == cb {
+		return 0
+	}
+	theirs := other.view("Compare")
+	cb.rlock("Compare")
+	defer cb.mu.RUnlock()
+	return cb.b.Compare(theirs, compare)
+}
+
+// SearchSorted returns the index where value would be inserted in the sorted
+// buffer (see buffer.SearchSorted).
+// less is called with the lock held, it must not call methods of cb.
+func (cb *ConcurrentBuffer[T]) SearchSorted(value T, less func(T, T) bool) uint64 {
+	cb.rlock("SearchSorted")
+	defer cb.mu.RUnlock()
+	return cb.b.SearchSorted(value, less)
+}
+
+// InsertSorted inserts value in the sorted buffer keeping it sorted and returns
+// its index (see buffer.InsertSorted).
+// less is called with the lock held, it must not call methods of cb.
+func (cb *ConcurrentBuffer[T]) InsertSorted(value T, less func(T, T) bool) (uint64, error) {
+	cb.lock("InsertSorted")
+	defer cb.unlock()
+	defer cb.notify()
+	return cb.b.InsertSorted(value, less)
+}
+
+// Interleave returns a new buffer alternating the elements of the buffer with the elements of another buffer.
+// It works on a copy of other, so the locks of the two buffers are never held together
+// (and other can be the buffer itself).
+func (cb *ConcurrentBuffer[T]) Interleave(other *ConcurrentBuffer[T]) *ConcurrentBuffer[T] {
+	theirs := other.view("Interleave")
+	cb.rlock("Interleave")
+	defer cb.mu.RUnlock()
+	return &ConcurrentBuffer[T]{b: cb.b.Interleave(theirs)}
+}
+
+// Zip returns a new buffer of pairs combining the elements of a and b at the same index.
+// It works on a copy of b, so the locks of the two buffers are never held together
+// (and b can be a itself).
+func Zip[T, U comparable](a *ConcurrentBuffer[T], b *ConcurrentBuffer[U]) *ConcurrentBuffer[common.Pair[T, U]] {
+	theirs := b.view("Zip")
+	a.rlock("Zip")
+	defer a.mu.RUnlock()
+	return &ConcurrentBuffer[common.Pair[T, U]]{b: buffer.Zip(a.b, theirs)}
+}
+
+// Unzip splits a buffer of pairs into two buffers.
+func Unzip[T, U comparable](cb *ConcurrentBuffer[common.Pair[T, U]]) (*ConcurrentBuffer[T], *ConcurrentBuffer[U]) {
+	cb.rlock("Unzip")
+	defer cb.mu.RUnlock()
+	first, second := buffer.Unzip(cb.b)
+	return &ConcurrentBuffer[T]{b: first}, &ConcurrentBuffer[U]{b: second}
+}
+
+// SelectNth moves the n-th smallest element (according to the given function) to index n and returns it.
+// less is called with the lock held, it must not call methods of cb.
+func (cb *ConcurrentBuffer[T]) SelectNth(n uint64, less func(T, T) bool) (T, error) {
+	cb.lock("SelectNth")
+	defer cb.unlock()
+	return cb.b.SelectNth(n, less)
+}
+
+// PartialSort sorts the k smallest elements at the beginning of the buffer.
+// less is called with the lock held, it must not call methods of cb.
+func (cb *ConcurrentBuffer[T]) PartialSort(k uint64, less func(T, T) bool) error {
+	cb.lock("PartialSort")
+	defer cb.unlock()
+	return cb.b.PartialSort(k, less)
+}
+
+// OnInsert registers a function called after an element is added to the buffer.
+// The hooks are called with the lock held, so they must not call methods of cb.
+func (cb *ConcurrentBuffer[T]) OnInsert(f func(index uint64, v T)) {
+	cb.lock("OnInsert")
+	defer cb.unlock()
+	cb.b.OnInsert(f)
+}
+
+// OnRemove registers a function called after an element is removed from the buffer.
+func (cb *ConcurrentBuffer[T]) OnRemove(f func(index uint64, v T)) {
+	cb.lock("OnRemove")
+	defer cb.unlock()
+	cb.b.OnRemove(f)
+}
+
+// OnClear registers a function called after all the elements are removed at once from the buffer.
+func (cb *ConcurrentBuffer[T]) OnClear(f func()) {
+	cb.lock("OnClear")
+	defer cb.unlock()
+	cb.b.OnClear(f)
+}
+
+// WithLock runs fn while holding the write lock, so compound operations (check-then-append,
+// find-then-remove, ...) on the underlying buffer are atomic.
+// fn must not call any ConcurrentBuffer method (it would deadlock) nor keep a reference to the buffer.
+func (cb *ConcurrentBuffer[T]) WithLock(fn func(b *buffer.Buffer[T])) {
+	cb.lock("WithLock")
+	defer cb.unlock()
+	defer cb.notify()
+	fn(cb.b)
+}
+
+// WithRLock runs fn while holding the read lock, fn must not modify the buffer.
+func (cb *ConcurrentBuffer[T]) WithRLock(fn func(b *buffer.Buffer[T])) {
+	cb.rlock("WithRLock")
+	defer cb.mu.RUnlock()
+	fn(cb.b)
 }
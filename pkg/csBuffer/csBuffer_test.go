@@ -2,9 +2,21 @@
 package csBuffer_test
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	rawBuffer "github.com/pzaino/gods/pkg/buffer"
+	common "github.com/pzaino/gods/pkg/common"
 	buffer "github.com/pzaino/gods/pkg/csBuffer"
 )
 
@@ -1350,3 +1362,1053 @@ func TestConcurrentWidthSizeAndCapacity(t *testing.T) {
 
 	wg.Wait()
 }
+
+// TestConcurrentSort tests sorting the buffer while other goroutines read it.
+func TestConcurrentSort(t *testing.T) {
+	cb := buffer.New[int]()
+	for i := 100; i > 0; i-- {
+		_ = cb.Append(i)
+	}
+	less := func(a, b int) bool { return a < b }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				cb.Sort(less)
+			} else {
+				cb.SortStable(less)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			cb.IsSorted(less)
+		}()
+	}
+	wg.Wait()
+
+	if !cb.IsSorted(less) {
+		t.Errorf("expected buffer to be sorted")
+	}
+}
+
+// TestConcurrentZip tests zipping, unzipping and interleaving buffers concurrently.
+func TestConcurrentZip(t *testing.T) {
+	a := buffer.New[int]()
+	b := buffer.New[string]()
+	for i := 0; i < 100; i++ {
+		_ = a.Append(i)
+		_ = b.Append("x")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			zipped := buffer.Zip(a, b)
+			first, _ := buffer.Unzip(zipped)
+			if first.Size() != 100 {
+				t.Errorf(errExpectedSize, 100, first.Size())
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if a.Interleave(a.Copy()).Size() != 200 {
+				t.Errorf(errExpectedSize, 200, a.Size()*2)
+			}
+		}()
+	}
+	wg.Wait()
+
+	zipped := buffer.Zip(a, b)
+	v, err := zipped.Get(42)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if v != common.NewPair(42, "x") {
+		t.Errorf("expected pair (42, x), got %v", v)
+	}
+}
+
+// TestZipSelf tests zipping and interleaving a buffer with itself while it's
+// being written, which must not deadlock on a writer waiting between two read locks.
+func TestZipSelf(t *testing.T) {
+	a := buffer.New[int]()
+	_ = a.Append(1)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			_ = a.Append(i)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			if zipped := buffer.Zip(a, a); zipped.IsEmpty() {
+				t.Errorf("expected the zipped buffer to have elements")
+			}
+			_ = a.Interleave(a)
+		}
+	}()
+	wg.Wait()
+
+	zipped := buffer.Zip(a, a)
+	if v, _ := zipped.Get(0); v != common.NewPair(1, 1) {
+		t.Errorf("expected pair (1, 1), got %v", v)
+	}
+	if interleaved := a.Interleave(a); interleaved.Size() != 2*a.Size() {
+		t.Errorf(errExpectedSize, 2*a.Size(), interleaved.Size())
+	}
+}
+
+// TestSelfAndCrossOps tests Equals, Merge and Blit of a buffer with itself and
+// of two buffers with each other in both directions while they're being
+// written, which must not deadlock.
+func TestSelfAndCrossOps(t *testing.T) {
+	a, b := buffer.New[int](), buffer.New[int]()
+	var wg sync.WaitGroup
+	for _, pair := range [][2]*buffer.ConcurrentBuffer[int]{{a, b}, {b, a}} {
+		wg.Add(2)
+		go func(x, y *buffer.ConcurrentBuffer[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_ = x.Equals(y)
+				_ = x.Blit(y, func(v, _ int) int { return v })
+				x.Merge(y)
+				if !x.Equals(x) {
+					t.Errorf("expected the buffer to be equal to itself")
+				}
+				_ = x.Blit(x, func(v, w int) int { return v + w })
+				x.Merge(x)
+			}
+		}(pair[0], pair[1])
+		go func(x *buffer.ConcurrentBuffer[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_ = x.Append(i)
+			}
+		}(pair[0])
+	}
+	wg.Wait()
+
+	if size := a.Size() + b.Size(); size != 1000 {
+		t.Errorf(errExpectedSize, 1000, size)
+	}
+}
+
+// TestConcurrentPartialSort tests partial sorting and selection concurrently.
+func TestConcurrentPartialSort(t *testing.T) {
+	cb := buffer.New[int]()
+	for i := 100; i > 0; i-- {
+		_ = cb.Append(i)
+	}
+	less := func(a, b int) bool { return a < b }
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := cb.PartialSort(10, less); err != nil {
+				t.Errorf(errUnexpectedErr, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			v, err := cb.SelectNth(0, less)
+			if err != nil {
+				t.Errorf(errUnexpectedErr, err)
+			}
+			if v != 1 {
+				t.Errorf(errExpectedVal, 1, v)
+			}
+		}()
+	}
+	wg.Wait()
+
+	// SelectNth may have reordered the head, sort it again before checking
+	if err := cb.PartialSort(10, less); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	for i := uint64(0); i < 10; i++ {
+		v, _ := cb.Get(i)
+		if v != int(i)+1 {
+			t.Errorf(errExpectedVal, i+1, v)
+		}
+	}
+}
+
+// TestValuesReturnsCopy tests that the returned values don't alias the buffer.
+func TestValuesReturnsCopy(t *testing.T) {
+	cb := buffer.New[int]()
+	for i := 0; i < 100; i++ {
+		_ = cb.Append(i)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			values := cb.Values()
+			for j := range values {
+				values[j] = -1
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			_ = cb.Append(100)
+		}()
+	}
+	wg.Wait()
+
+	for i := uint64(0); i < 100; i++ {
+		v, _ := cb.Get(i)
+		if v != int(i) {
+			t.Fatalf(errExpectedVal, i, v)
+		}
+	}
+}
+
+// TestScan tests scanning a snapshot of the buffer.
+func TestScan(t *testing.T) {
+	cb := buffer.New[int]()
+	for i := 0; i < 10; i++ {
+		_ = cb.Append(i)
+	}
+
+	var seen []int
+	cb.Scan(func(v int) bool {
+		seen = append(seen, v)
+		return true
+	})
+	if len(seen) != 10 {
+		t.Fatalf(errExpectedSize, 10, len(seen))
+	}
+	for i, v := range seen {
+		if v != i {
+			t.Errorf(errExpectedVal, i, v)
+		}
+	}
+
+	// Stop early
+	count := 0
+	cb.Scan(func(v int) bool {
+		count++
+		return v < 4
+	})
+	if count != 5 {
+		t.Errorf("expected 5 calls, got %d", count)
+	}
+
+	// The callback can write to the buffer, since the lock is not held
+	cb.Scan(func(v int) bool {
+		_ = cb.Append(v)
+		return true
+	})
+	if cb.Size() != 20 {
+		t.Errorf(errExpectedSize, 20, cb.Size())
+	}
+
+	// Empty buffer
+	empty := buffer.New[int]()
+	empty.Scan(func(int) bool {
+		t.Error("unexpected call on an empty buffer")
+		return true
+	})
+}
+
+// TestConcurrentScan tests scanning while other goroutines modify the buffer.
+func TestConcurrentScan(t *testing.T) {
+	cb := buffer.New[int]()
+	for i := 0; i < 100; i++ {
+		_ = cb.Append(i)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			n := 0
+			cb.Scan(func(v int) bool {
+				if n < 100 && v != n {
+					t.Errorf(errExpectedVal, n, v)
+				}
+				n++
+				return true
+			})
+			if n < 100 {
+				t.Errorf("expected at least 100 elements, got %d", n)
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			_ = cb.Append(100 + i)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// mutexBuffer is a buffer guarded by a plain mutex, used as a baseline in the
+// benchmarks below.
+type mutexBuffer struct {
+	mu sync.Mutex
+	b  *rawBuffer.Buffer[int]
+}
+
+func (mb *mutexBuffer) Get(index uint64) (int, error) {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	return mb.b.Get(index)
+}
+
+func (mb *mutexBuffer) Put(index uint64, elem int) error {
+	mb.mu.Lock()
+	defer mb.mu.Unlock()
+	return mb.b.Put(index, elem)
+}
+
+const benchSize = 1024
+
+type benchBuffer interface {
+	Get(index uint64) (int, error)
+	Put(index uint64, elem int) error
+}
+
+func benchBuffers() map[string]benchBuffer {
+	cb := buffer.New[int]()
+	mb := &mutexBuffer{b: rawBuffer.New[int]()}
+	for i := 0; i < benchSize; i++ {
+		_ = cb.Append(i)
+		_ = mb.b.Append(i)
+	}
+	return map[string]benchBuffer{"RWMutex": cb, "Mutex": mb}
+}
+
+// BenchmarkConcurrentGet measures parallel reads, which don't block each
+// other with the RWMutex.
+func BenchmarkConcurrentGet(b *testing.B) {
+	for name, bb := range benchBuffers() {
+		b.Run(name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				i := uint64(0)
+				for pb.Next() {
+					_, _ = bb.Get(i % benchSize)
+					i++
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkMixedReadWrite measures parallel access with one write every ten reads.
+func BenchmarkMixedReadWrite(b *testing.B) {
+	for name, bb := range benchBuffers() {
+		b.Run(name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				i := uint64(0)
+				for pb.Next() {
+					if i%10 == 0 {
+						_ = bb.Put(i%benchSize, int(i))
+					} else {
+						_, _ = bb.Get(i % benchSize)
+					}
+					i++
+				}
+			})
+		})
+	}
+}
+
+// BenchmarkScan measures a full scan through a pooled snapshot.
+func BenchmarkScan(b *testing.B) {
+	cb := buffer.New[int]()
+	for i := 0; i < benchSize; i++ {
+		_ = cb.Append(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sum := 0
+		cb.Scan(func(v int) bool {
+			sum += v
+			return true
+		})
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	cb := buffer.NewWithComparator(slices.Equal[[]int])
+	_ = cb.Append([]int{1, 2})
+	_ = cb.Append([]int{3})
+
+	if !cb.Contains([]int{3}) {
+		t.Error("expected buffer to contain [3]")
+	}
+	if index, err := cb.Find([]int{3}); err != nil || index != 1 {
+		t.Errorf("expected index 1, got %v (%v)", index, err)
+	}
+	if !cb.Equals(cb.Copy()) {
+		t.Error("expected the copy to be equal to the original buffer")
+	}
+}
+
+func TestNewWithHasher(t *testing.T) {
+	hasher := common.NewHasher(func(v []int) uint64 { return uint64(len(v)) }, slices.Equal[[]int])
+	cb := buffer.NewWithHasher(hasher)
+	_ = cb.Append([]int{1, 2})
+	_ = cb.Append([]int{3})
+
+	if !cb.Contains([]int{3}) || cb.Contains([]int{2, 1}) {
+		t.Error("expected the hasher equality to be used")
+	}
+	if !buffer.Features().Has(common.FeatureHasher) {
+		t.Errorf("expected the %s feature", common.FeatureHasher)
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	cb := buffer.New[int]()
+
+	// Concurrent check-then-append must not add duplicates
+	var wg sync.WaitGroup
+	for i := 0; i < 1000; i++ {
+		wg.Add(1)
+		go func(j int) {
+			defer wg.Done()
+			cb.WithLock(func(b *rawBuffer.Buffer[int]) {
+				if !b.Contains(j % 10) {
+					_ = b.Append(j % 10)
+				}
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	var size uint64
+	cb.WithRLock(func(b *rawBuffer.Buffer[int]) {
+		size = b.Size()
+	})
+	if size != 10 {
+		t.Errorf("expected size 10, got %d", size)
+	}
+}
+
+func TestRangeOperations(t *testing.T) {
+	cb := buffer.New[int]()
+	_ = cb.PushN(0, 1, 2, 3)
+
+	if err := cb.RemoveRange(1, 3); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cb.InsertSliceAt(1, []int{5, 6}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cb.ReplaceRange(0, 1, []int{9, 9}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := cb.Values(); !slices.Equal(got, []int{9, 9, 5, 6, 3}) {
+		t.Errorf("expected [9 9 5 6 3], got %v", got)
+	}
+}
+
+// TestSnapshot tests that snapshots are consistent under concurrent writes.
+func TestSnapshot(t *testing.T) {
+	cb := buffer.New[int]()
+	done := make(chan struct{})
+
+	// The writer only ever adds or removes pairs of equal values
+	go func() {
+		defer close(done)
+		for i := 0; i < 2000; i++ {
+			if err := cb.PushN(i, i); err != nil {
+				t.Errorf(errUnexpectedErr, err)
+				return
+			}
+			if i%3 == 0 {
+				_, _ = cb.PopN(2)
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			if snap := cb.ToSlice(); uint64(len(snap)) != cb.Size() {
+				t.Errorf(errExpectedSize, cb.Size(), len(snap))
+			}
+			return
+		default:
+		}
+		snap := cb.Snapshot()
+		if len(snap)%2 != 0 {
+			t.Fatalf("torn snapshot of %d elements", len(snap))
+		}
+		for i := 0; i < len(snap); i += 2 {
+			if snap[i] != snap[i+1] {
+				t.Fatalf("torn snapshot at %d: %d != %d", i, snap[i], snap[i+1])
+			}
+		}
+	}
+}
+
+// TestObservers tests the change notifications under concurrent appends.
+func TestObservers(t *testing.T) {
+	cb := buffer.New[int]()
+	inserted, cleared := 0, false
+	cb.OnInsert(func(uint64, int) { inserted++ }) // called with the lock held
+	cb.OnClear(func() { cleared = true })
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_ = cb.Append(i)
+		}(i)
+	}
+	wg.Wait()
+	cb.Clear()
+
+	if inserted != 100 || !cleared {
+		t.Errorf("expected 100 insertions and a clear, got %d and %v", inserted, cleared)
+	}
+}
+
+func TestDrain(t *testing.T) {
+	const n = 1000
+	cb := buffer.New[int]()
+	_ = cb.Append(0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := cb.Drain(ctx)
+
+	go func() {
+		for i := 1; i < n; i++ {
+			if i%2 == 0 {
+				_ = cb.Append(i)
+			} else {
+				_ = cb.PushN(i)
+			}
+		}
+	}()
+
+	// The elements are received in FIFO order as they are added
+	for i := 0; i < n; i++ {
+		if v := <-ch; v != i {
+			t.Fatalf("expected %d, got %d", i, v)
+		}
+	}
+	if !cb.IsEmpty() {
+		t.Errorf("expected an empty buffer, got size %d", cb.Size())
+	}
+
+	// Drain waits for new elements in select loops until the context is cancelled
+	select {
+	case v := <-ch:
+		t.Fatalf("unexpected element %d", v)
+	default:
+	}
+	cancel()
+	if _, ok := <-ch; ok {
+		t.Error("expected the channel to be closed")
+	}
+	_ = cb.Append(42)
+	if v, _ := cb.Get(0); cb.Size() != 1 || v != 42 {
+		t.Errorf("expected the buffer to keep 42, got %v", cb.Values())
+	}
+}
+
+func TestDrainCancelledWhenFull(t *testing.T) {
+	cb := buffer.NewWithCapacity[int](1)
+	_ = cb.Append(1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := cb.Drain(ctx)
+	// Wait for Drain to take 1, then fill the buffer before cancelling
+	for !cb.IsEmpty() {
+		runtime.Gosched()
+	}
+	if err := cb.Append(2); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	cancel()
+
+	// 1 can't be put back, so it's sent before the channel is closed (and 2
+	// may be sent too if the receive wins the race with the cancellation)
+	got := []int{}
+	for v := range ch {
+		got = append(got, v)
+	}
+	if all := append(got, cb.Values()...); len(got) == 0 || !slices.Equal(all, []int{1, 2}) {
+		t.Errorf("expected to receive 1 and keep the rest of [1 2], got %v and %v", got, cb.Values())
+	}
+}
+
+// TestPopNPushNAtomic checks that concurrent PopN calls return whole batches
+// pushed by PushN, in the order they were pushed
+func TestPopNPushNAtomic(t *testing.T) {
+	const batch, producers, batches = 8, 4, 200
+	cb := buffer.New[int]()
+
+	var wg sync.WaitGroup
+	popped := make(chan []int, producers*batches)
+	for p := 0; p < producers; p++ {
+		wg.Add(2)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < batches; i++ {
+				items := make([]int, batch)
+				for j := range items {
+					items[j] = (p*batches+i)*batch + j
+				}
+				if err := cb.PushN(items...); err != nil {
+					t.Errorf(errUnexpectedErr, err)
+				}
+			}
+		}(p)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < batches; {
+				values, err := cb.PopN(batch)
+				if err != nil {
+					runtime.Gosched()
+					continue
+				}
+				popped <- values
+				i++
+			}
+		}()
+	}
+	wg.Wait()
+	close(popped)
+
+	for values := range popped {
+		for j, v := range values {
+			if v != values[0]-values[0]%batch+j {
+				t.Fatalf("popped a mixed batch: %v", values)
+			}
+		}
+	}
+	if values := cb.PopUpToN(batch); values != nil {
+		t.Errorf("expected an empty buffer, got %v", values)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := buffer.New[*int]()
+	_ = c.Append(&a)
+	_ = c.Append(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestStats(t *testing.T) {
+	cb := buffer.New[int]()
+	_ = cb.Append(1)
+	if st := cb.Stats(); st.Reads != 0 || st.Writes != 0 || st.PeakSize != 0 {
+		t.Errorf("expected no statistics while disabled, got %+v", st)
+	}
+
+	cb.EnableStats(true)
+	_ = cb.Append(2)
+	_ = cb.Append(3)
+	_, _ = cb.PopN(2)
+	_ = cb.Size()
+	st := cb.Stats()
+	if st.Writes != 3 || st.Reads != 1 || st.PeakSize != 3 {
+		t.Errorf("expected 3 writes, 1 read and peak size 3, got %+v", st)
+	}
+	if want := map[string]uint64{"Append": 2, "PopN": 1, "Size": 1}; !reflect.DeepEqual(st.Ops, want) {
+		t.Errorf("expected the operations %v, got %v", want, st.Ops)
+	}
+
+	cb.EnableStats(false)
+	_ = cb.Append(4)
+	if !reflect.DeepEqual(cb.Stats(), st) {
+		t.Errorf("expected the statistics to stop changing once disabled, got %+v", cb.Stats())
+	}
+}
+
+// TestCallbackReentrancy tests that the read-only callbacks can call back into the buffer.
+func TestCallbackReentrancy(t *testing.T) {
+	cb := buffer.New[int]()
+	for i := 0; i < 5; i++ {
+		_ = cb.Append(i)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = cb.ForEachSnapshot(func(int) error {
+			return cb.Append(int(cb.Size()))
+		})
+		_ = cb.ForRangeSnapshot(0, 2, func(int) error { return cb.Append(-1) })
+		cb.Any(func(v int) bool { return cb.Contains(v + 1) })
+		_, _ = cb.Map(func(v int) int { return v + int(cb.Size()) })
+		_ = cb.FindIndices(func(v int) bool { return cb.Contains(v) })
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a callback calling back into the buffer deadlocked")
+	}
+
+	if cb.Size() != 12 {
+		t.Errorf("expected 12 elements, got %d", cb.Size())
+	}
+
+	if err := cb.ForEach(func(v *int) error {
+		*v *= 2
+		return nil
+	}); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if v, _ := cb.Get(1); v != 2 {
+		t.Errorf("expected ForEach to modify the buffer in place, got %d", v)
+	}
+}
+
+func TestMarshalBinary(t *testing.T) {
+	cb := buffer.New[int]()
+	for i := 0; i < 3; i++ {
+		_ = cb.Append(i)
+	}
+	data, err := cb.MarshalBinary()
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+
+	restored := buffer.New[int]()
+	restored.SetCodec(common.GobCodec[int]{})
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !slices.Equal(restored.ToSlice(), []int{0, 1, 2}) {
+		t.Errorf("expected [0 1 2], got %v", restored.ToSlice())
+	}
+	if err := restored.UnmarshalBinary(data[:3]); !errors.Is(err, common.ErrInvalidFormat) {
+		t.Errorf("expected %v, got %v", common.ErrInvalidFormat, err)
+	}
+}
+
+func TestDrainAll(t *testing.T) {
+	cb := buffer.New[int]()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = cb.Append(i*100 + j)
+			}
+		}(i)
+	}
+
+	// Every element is drained exactly once
+	seen := make(map[int]bool)
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	for finished := false; !finished; {
+		select {
+		case <-done:
+			finished = true
+		default:
+		}
+		for _, v := range cb.DrainAll() {
+			if seen[v] {
+				t.Fatalf("element %d drained twice", v)
+			}
+			seen[v] = true
+		}
+	}
+	if len(seen) != 400 || !cb.IsEmpty() {
+		t.Errorf("expected 400 drained elements and an empty buffer, got %d (size %d)", len(seen), cb.Size())
+	}
+}
+
+func TestTruncateResize(t *testing.T) {
+	cb := buffer.NewWithCapacity[int](100)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := cb.Resize(uint64(i*10), i); err != nil {
+				t.Errorf(errUnexpectedErr, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := cb.Resize(20, -1); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	cb.Truncate(3)
+	if cb.Size() != 3 {
+		t.Errorf("expected size 3, got %d", cb.Size())
+	}
+	if err := cb.Resize(101, 0); !errors.Is(err, buffer.ErrOverflow) {
+		t.Errorf("expected %v, got %v", buffer.ErrOverflow, err)
+	}
+}
+
+func TestFeatures(t *testing.T) {
+	if f := buffer.Features(); !f.Has(common.FeatureConcurrent) || !f.Has(common.FeatureBinaryCodec) {
+		t.Errorf("unexpected features %v", f)
+	}
+}
+
+func TestParallelForEach(t *testing.T) {
+	cb := buffer.New[int]()
+	for i := 0; i < 1000; i++ {
+		_ = cb.Append(i)
+	}
+
+	var sum atomic.Int64
+	if err := cb.ParallelForEachSnapshot(context.Background(), 4, func(v int) error {
+		sum.Add(int64(v))
+		return cb.Put(uint64(v), v) // fn can call methods of cb
+	}); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if sum.Load() != 999*1000/2 {
+		t.Errorf("expected %d, got %d", 999*1000/2, sum.Load())
+	}
+	sum.Store(0)
+	if err := cb.ParallelForRangeSnapshot(context.Background(), 10, 20, 3, func(v int) error {
+		sum.Add(int64(v))
+		return nil
+	}); err != nil || sum.Load() != 145 {
+		t.Errorf("expected 145, got %d (%v)", sum.Load(), err)
+	}
+
+	if err := cb.ParallelForRange(context.Background(), 10, 20, 3, func(v *int) error {
+		*v = -*v
+		return nil
+	}); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if err := cb.ParallelForEach(context.Background(), 0, func(v *int) error {
+		*v *= 2
+		return nil
+	}); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	for _, i := range []uint64{9, 10, 19, 20} {
+		expected := int(i) * 2
+		if i >= 10 && i < 20 {
+			expected = -expected
+		}
+		if v, _ := cb.Get(i); v != expected {
+			t.Errorf("expected %d at %d, got %d", expected, i, v)
+		}
+	}
+	if err := cb.ParallelForRange(context.Background(), 5, 1001, 2, func(*int) error { return nil }); !errors.Is(err, buffer.ErrInvalid) {
+		t.Errorf("expected %v, got %v", buffer.ErrInvalid, err)
+	}
+}
+
+func TestCtxVariants(t *testing.T) {
+	cb := buffer.New[int]()
+	for i := 0; i < 3000; i++ {
+		_ = cb.Append(i)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := 0
+	err := cb.ForEachCtx(ctx, func(v *int) error {
+		calls++
+		if calls == 100 {
+			cancel()
+		}
+		*v *= 2 // in place
+		return nil
+	})
+	if !errors.Is(err, context.Canceled) || calls != common.CtxCheckInterval {
+		t.Errorf("expected %v after %d calls, got %v after %d", context.Canceled, common.CtxCheckInterval, err, calls)
+	}
+	if _, err := cb.MapCtx(ctx, func(v int) int { return v }); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+	if _, err := cb.FindCtx(ctx, 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected %v, got %v", context.Canceled, err)
+	}
+
+	if v, _ := cb.Get(common.CtxCheckInterval - 1); v != 2*(common.CtxCheckInterval-1) {
+		t.Errorf("expected ForEachCtx to modify the buffer in place, got %d", v)
+	}
+
+	mapped, err := cb.MapCtx(context.Background(), func(v int) int { return v * 2 })
+	if err != nil || mapped.Size() != 3000 {
+		t.Fatalf("expected 3000 elements, got %v", err)
+	}
+	if i, err := mapped.FindCtx(context.Background(), 5000); err != nil || i != 2500 {
+		t.Errorf("expected 5000 at 2500, got %d (%v)", i, err)
+	}
+}
+
+func TestInsertSorted(t *testing.T) {
+	cb := buffer.New[int]()
+	less := func(a, b int) bool { return a < b }
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				if _, err := cb.InsertSorted((i*7+g)%100, less); err != nil {
+					t.Errorf(errUnexpectedErr, err)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if cb.Size() != 200 || !cb.IsSorted(less) {
+		t.Errorf("expected 200 sorted elements, got %d (sorted: %v)", cb.Size(), cb.IsSorted(less))
+	}
+	if i := cb.SearchSorted(1000, less); i != 200 {
+		t.Errorf("expected 200, got %d", i)
+	}
+}
+
+func TestJSONStream(t *testing.T) {
+	if !buffer.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, buffer.Features())
+	}
+	if !buffer.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, buffer.Features())
+	}
+	l := buffer.New[int]()
+	if err := l.DecodeJSONStream(json.NewDecoder(strings.NewReader("[1, 2, 3]"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var sb strings.Builder
+	if err := l.EncodeJSONStream(&sb); err != nil || sb.String() != "[1,2,3]" {
+		t.Errorf("expected [1,2,3], got %s (%v)", sb.String(), err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *buffer.ConcurrentBuffer[int] {
+		l := buffer.New[int]()
+		for _, v := range values {
+			_ = l.Append(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	l := build(1, 2)
+	if got := l.Compare(l, cmp.Compare[int]); got != 0 {
+		t.Errorf("expected a buffer to be equal to itself, got %d", got)
+	}
+}
+
+func TestTryVariants(t *testing.T) {
+	cb := buffer.New[int]()
+	cb.EnableStats(true)
+	if err := cb.TryAppend(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, err := cb.TryGet(0); err != nil || v != 1 {
+		t.Errorf("expected 1, got %d (%v)", v, err)
+	}
+
+	cb.WithLock(func(*rawBuffer.Buffer[int]) {
+		if err := cb.TryAppend(2); !errors.Is(err, buffer.ErrBusy) {
+			t.Errorf("expected %v, got %v", buffer.ErrBusy, err)
+		}
+		if _, err := cb.TryGet(0); !errors.Is(err, common.ErrBusy) {
+			t.Errorf("expected %v, got %v", common.ErrBusy, err)
+		}
+		if _, err := cb.TryPop(); !errors.Is(err, buffer.ErrBusy) {
+			t.Errorf("expected %v, got %v", buffer.ErrBusy, err)
+		}
+	})
+	cb.WithRLock(func(*rawBuffer.Buffer[int]) {
+		if err := cb.TryAppend(2); !errors.Is(err, buffer.ErrBusy) {
+			t.Errorf("expected %v, got %v", buffer.ErrBusy, err)
+		}
+		if v, err := cb.TryGet(0); err != nil || v != 1 {
+			t.Errorf("expected 1 under a read lock, got %d (%v)", v, err)
+		}
+	})
+
+	if cb.Size() != 1 {
+		t.Errorf("expected 1 element, got %v", cb.ToSlice())
+	}
+	if v, err := cb.TryPop(); err != nil || v != 1 || !cb.IsEmpty() {
+		t.Errorf("expected to pop 1, got %d (%v)", v, err)
+	}
+	if _, err := cb.TryPop(); !errors.Is(err, buffer.ErrEmpty) {
+		t.Errorf("expected %v, got %v", buffer.ErrEmpty, err)
+	}
+	if st := cb.Stats(); st.Waits != 0 || st.Busy != 4 {
+		t.Errorf("expected the Try methods to never wait and give up 4 times, got %+v", st)
+	}
+}
+
+func TestCompareBothWays(t *testing.T) {
+	a, b := buffer.New[int](), buffer.New[int]()
+	var wg sync.WaitGroup
+	for _, pair := range [][2]*buffer.ConcurrentBuffer[int]{{a, b}, {b, a}} {
+		wg.Add(2)
+		go func(x, y *buffer.ConcurrentBuffer[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_ = x.Compare(y, cmp.Compare[int])
+			}
+		}(pair[0], pair[1])
+		go func(x *buffer.ConcurrentBuffer[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_ = x.Append(i)
+			}
+		}(pair[0])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,397 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csMap provides a concurrency-safe hash map split in shards, every
+// shard is a Go map with its own lock so goroutines working on keys of
+// different shards don't contend (unlike a single map behind a RWMutex).
+package csMap
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/maphash"
+	"math"
+	"reflect"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the CSMap constructors (use errors.Is to check for them)
+var (
+	ErrInvalidShardCount = errors.New("invalid shard count")
+	ErrNilHasher         = errors.New("hash function cannot be nil")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureConcurrent)
+}
+
+// maxShards is the maximum number of shards of a map
+const maxShards = 1 << 16
+
+// shard is a part of the map with its own lock, padded so the locks of
+// adjacent shards don't share a cache line
+type shard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+	_  [64]byte
+}
+
+// CSMap is a concurrency-safe map from keys of type K to values of type V.
+// The zero value is not usable, create maps with New, NewWithShards or
+// NewWithHasher
+type CSMap[K comparable, V any] struct {
+	shards []shard[K, V]
+	mask   uint64
+	hash   func(K) uint64
+	stats  common.LockStats // opt-in statistics, see EnableStats
+	size   atomic.Int64     // number of keys, only kept while the statistics are enabled
+}
+
+// New creates a new empty CSMap with a number of shards based on the number
+// of CPUs (4 per CPU, rounded up to a power of two)
+func New[K comparable, V any]() *CSMap[K, V] {
+	m, _ := NewWithShards[K, V](uint64(4 * runtime.GOMAXPROCS(0)))
+	return m
+}
+
+// NewWithShards creates a new empty CSMap with the given number of shards,
+// rounded up to the next power of two (at most 65536)
+func NewWithShards[K comparable, V any](shards uint64) (*CSMap[K, V], error) {
+	return NewWithHasher[K, V](shards, defaultHasher[K]())
+}
+
+// NewWithHasher creates a new empty CSMap like NewWithShards that picks the
+// shard of a key with the given hash function, equal keys must have the same
+// hash. The default one handles strings, numbers and booleans directly and
+// walks the keys of the other types with reflection (pointers and channels are
+// hashed by address, like == compares them), so a custom hash is much faster
+// for struct keys
+func NewWithHasher[K comparable, V any](shards uint64, hash func(K) uint64) (*CSMap[K, V], error) {
+	if shards == 0 || shards > maxShards {
+		return nil, ErrInvalidShardCount
+	}
+	if hash == nil {
+		return nil, ErrNilHasher
+	}
+
+	n := uint64(1)
+	for n < shards {
+		n <<= 1
+	}
+	m := &CSMap[K, V]{shards: make([]shard[K, V], n), mask: n - 1, hash: hash}
+	for i := range m.shards {
+		m.shards[i].m = make(map[K]V)
+	}
+	return m, nil
+}
+
+// defaultHasher returns a seeded hash function for keys of type K
+func defaultHasher[K comparable]() func(K) uint64 {
+	seed := maphash.MakeSeed()
+	salt := maphash.String(seed, "")
+	return func(key K) uint64 {
+		switch k := any(key).(type) {
+		case string:
+			return maphash.String(seed, k)
+		case int:
+			return mix(uint64(k) ^ salt)
+		case int8:
+			return mix(uint64(k) ^ salt)
+		case int16:
+			return mix(uint64(k) ^ salt)
+		case int32:
+			return mix(uint64(k) ^ salt)
+		case int64:
+			return mix(uint64(k) ^ salt)
+		case uint:
+			return mix(uint64(k) ^ salt)
+		case uint8:
+			return mix(uint64(k) ^ salt)
+		case uint16:
+			return mix(uint64(k) ^ salt)
+		case uint32:
+			return mix(uint64(k) ^ salt)
+		case uint64:
+			return mix(k ^ salt)
+		case uintptr:
+			return mix(uint64(k) ^ salt)
+		case float32:
+			return mix(floatBits(float64(k)) ^ salt)
+		case float64:
+			return mix(floatBits(k) ^ salt)
+		case bool:
+			if k {
+				return mix(1 ^ salt)
+			}
+			return mix(salt)
+		default:
+			var h maphash.Hash
+			h.SetSeed(seed)
+			hashValue(&h, reflect.ValueOf(key))
+			return h.Sum64()
+		}
+	}
+}
+
+// hashValue writes to h the parts of v that == compares, so equal values have
+// the same hash: the address of pointers and channels, the fields of structs
+// (but the blank ones), the elements of arrays and the dynamic value of
+// interfaces
+func hashValue(h *maphash.Hash, v reflect.Value) {
+	var buf [8]byte
+	writeUint := func(x uint64) {
+		binary.LittleEndian.PutUint64(buf[:], x)
+		_, _ = h.Write(buf[:])
+	}
+
+	switch v.Kind() {
+	case reflect.Invalid:
+		writeUint(0)
+	case reflect.String:
+		writeUint(uint64(v.Len()))
+		_, _ = h.WriteString(v.String())
+	case reflect.Bool:
+		if v.Bool() {
+			writeUint(1)
+		} else {
+			writeUint(0)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		writeUint(uint64(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		writeUint(v.Uint())
+	case reflect.Float32, reflect.Float64:
+		writeUint(floatBits(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		c := v.Complex()
+		writeUint(floatBits(real(c)))
+		writeUint(floatBits(imag(c)))
+	case reflect.Pointer, reflect.Chan, reflect.UnsafePointer:
+		writeUint(uint64(v.Pointer()))
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			hashValue(h, v.Index(i))
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if t.Field(i).Name != "_" {
+				hashValue(h, v.Field(i))
+			}
+		}
+	case reflect.Interface:
+		if v.IsNil() {
+			writeUint(0)
+			return
+		}
+		_, _ = h.WriteString(v.Elem().Type().String())
+		hashValue(h, v.Elem())
+	default:
+		// Not comparable (a func, map or slice in an interface key): == panics
+		// on it anyway
+		_, _ = h.WriteString(v.Type().String())
+	}
+}
+
+// floatBits returns the bits of f with -0 mapped to 0, as the two are equal keys
+func floatBits(f float64) uint64 {
+	if f == 0 {
+		return 0
+	}
+	return math.Float64bits(f)
+}
+
+// mix is the splitmix64 finalizer, it spreads the bits of consecutive integers
+// over all the shards
+func mix(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// shardOf returns the shard holding key
+func (m *CSMap[K, V]) shardOf(key K) *shard[K, V] {
+	return &m.shards[m.hash(key)&m.mask]
+}
+
+// EnableStats turns on or off the collection of the statistics returned by
+// Stats, enabling it resets them. When disabled the overhead is an atomic load
+// per operation
+func (m *CSMap[K, V]) EnableStats(on bool) {
+	if on && !m.stats.Enabled() {
+		m.size.Store(int64(m.Len()))
+	}
+	m.stats.Enable(on)
+}
+
+// Stats returns the statistics collected since they were enabled: the
+// operations by type (read or write) and by method, the peak number of keys
+// and the waits for the lock. Range, Len and Clear take the lock of every
+// shard, so they count as one operation per shard
+func (m *CSMap[K, V]) Stats() common.Stats {
+	return m.stats.Snapshot()
+}
+
+// resized records that n keys were added (or removed, when n is negative) for
+// the peak size of the statistics, while they are enabled
+func (m *CSMap[K, V]) resized(n int64) {
+	if m.stats.Enabled() {
+		if size := m.size.Add(n); size > 0 {
+			m.stats.Observe(uint64(size))
+		}
+	}
+}
+
+// ShardCount returns the number of shards of the map
+func (m *CSMap[K, V]) ShardCount() uint64 {
+	return m.mask + 1
+}
+
+// Get returns the value associated to key and true, or the zero value and
+// false if the key is not in the map
+func (m *CSMap[K, V]) Get(key K) (V, bool) {
+	s := m.shardOf(key)
+	m.stats.RLock(&s.mu, "Get")
+	defer s.mu.RUnlock()
+	v, ok := s.m[key]
+	return v, ok
+}
+
+// Contains returns true if the key is in the map
+func (m *CSMap[K, V]) Contains(key K) bool {
+	_, ok := m.Get(key)
+	return ok
+}
+
+// Put associates value to key, replacing the previous value if any
+func (m *CSMap[K, V]) Put(key K, value V) {
+	s := m.shardOf(key)
+	m.stats.Lock(&s.mu, "Put")
+	defer s.mu.Unlock()
+	if _, ok := s.m[key]; !ok {
+		m.resized(1)
+	}
+	s.m[key] = value
+}
+
+// Delete removes key from the map, it returns true if the key was there
+func (m *CSMap[K, V]) Delete(key K) bool {
+	s := m.shardOf(key)
+	m.stats.Lock(&s.mu, "Delete")
+	defer s.mu.Unlock()
+	_, ok := s.m[key]
+	if ok {
+		delete(s.m, key)
+		m.resized(-1)
+	}
+	return ok
+}
+
+// GetOrCompute returns the value associated to key, or calls compute and
+// stores its result if the key is not in the map. loaded is true if the value
+// was already there. compute is called at most once per missing key, even when
+// many goroutines ask for the same key at the same time, with the shard of the
+// key locked: it must not use the map
+func (m *CSMap[K, V]) GetOrCompute(key K, compute func() V) (value V, loaded bool) {
+	s := m.shardOf(key)
+	m.stats.RLock(&s.mu, "GetOrCompute")
+	value, loaded = s.m[key]
+	s.mu.RUnlock()
+	if loaded {
+		return value, true
+	}
+
+	m.stats.Lock(&s.mu, "GetOrCompute")
+	defer s.mu.Unlock()
+	if value, loaded = s.m[key]; loaded {
+		return value, true
+	}
+	value = compute()
+	s.m[key] = value
+	m.resized(1)
+	return value, false
+}
+
+// Range calls f for every key and value in the map until f returns false.
+// The shards are visited one at a time and f is called on a copy of the
+// entries of each shard without holding its lock, so f can use the map. It's
+// not a snapshot of the whole map: changes to shards not visited yet are seen
+func (m *CSMap[K, V]) Range(f func(key K, value V) bool) {
+	var keys []K
+	var values []V
+	for i := range m.shards {
+		s := &m.shards[i]
+		keys, values = keys[:0], values[:0]
+		m.stats.RLock(&s.mu, "Range")
+		for k, v := range s.m {
+			keys = append(keys, k)
+			values = append(values, v)
+		}
+		s.mu.RUnlock()
+
+		for j := range keys {
+			if !f(keys[j], values[j]) {
+				return
+			}
+		}
+	}
+}
+
+// Len returns the number of keys in the map, with concurrent writers it's
+// only a snapshot as the shards are counted one at a time
+func (m *CSMap[K, V]) Len() uint64 {
+	n := uint64(0)
+	for i := range m.shards {
+		s := &m.shards[i]
+		m.stats.RLock(&s.mu, "Len")
+		n += uint64(len(s.m))
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// IsEmpty checks if the map is empty (a snapshot, like Len)
+func (m *CSMap[K, V]) IsEmpty() bool {
+	return m.Len() == 0
+}
+
+// Clear removes all the keys from the map
+func (m *CSMap[K, V]) Clear() {
+	for i := range m.shards {
+		s := &m.shards[i]
+		m.stats.Lock(&s.mu, "Clear")
+		m.resized(-int64(len(s.m)))
+		clear(s.m)
+		s.mu.Unlock()
+	}
+}
+
+// ToMap returns a copy of the map as a Go map (built like Range)
+func (m *CSMap[K, V]) ToMap() map[K]V {
+	result := make(map[K]V)
+	m.Range(func(k K, v V) bool {
+		result[k] = v
+		return true
+	})
+	return result
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csMap_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	csMap "github.com/pzaino/gods/pkg/csMap"
+)
+
+// newBenchMap returns a map from keys to themselves
+func newBenchMap(keys []int) *csMap.CSMap[int, int] {
+	m := csMap.New[int, int]()
+	for _, k := range keys {
+		m.Put(k, k)
+	}
+	return m
+}
+
+// BenchmarkPut puts n keys
+func BenchmarkPut(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		keys := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = newBenchMap(keys)
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkDelete deletes a key from a map of n keys (and puts it back, so
+// the size stays n)
+func BenchmarkDelete(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		keys := bench.Ints(n)
+		m := newBenchMap(keys)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			k := keys[i%n]
+			m.Delete(k)
+			m.Put(k, k)
+		}
+	})
+}
+
+// BenchmarkFind looks for a key in a map of n keys
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		keys := bench.Ints(n)
+		m := newBenchMap(keys)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = m.Get(keys[i%n])
+		}
+	})
+}
@@ -0,0 +1,301 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csMap_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	csMap "github.com/pzaino/gods/pkg/csMap"
+)
+
+const errUnexpectedErr = "unexpected error: %v"
+
+func TestNew(t *testing.T) {
+	if _, err := csMap.NewWithShards[string, int](0); !errors.Is(err, csMap.ErrInvalidShardCount) {
+		t.Errorf("expected %v, got %v", csMap.ErrInvalidShardCount, err)
+	}
+	if _, err := csMap.NewWithHasher[string, int](4, nil); !errors.Is(err, csMap.ErrNilHasher) {
+		t.Errorf("expected %v, got %v", csMap.ErrNilHasher, err)
+	}
+
+	m, err := csMap.NewWithShards[string, int](5)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if m.ShardCount() != 8 {
+		t.Errorf("expected 8 shards, got %d", m.ShardCount())
+	}
+	if !m.IsEmpty() || csMap.New[int, int]().ShardCount() == 0 {
+		t.Error("expected an empty map with at least one shard")
+	}
+}
+
+func TestGetPutDelete(t *testing.T) {
+	m := csMap.New[string, int]()
+	if _, ok := m.Get("a"); ok {
+		t.Error("expected a to be missing")
+	}
+
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Put("a", 3)
+	if v, ok := m.Get("a"); !ok || v != 3 {
+		t.Errorf("expected 3, got %d (%v)", v, ok)
+	}
+	if m.Len() != 2 || !m.Contains("b") {
+		t.Errorf("expected 2 keys, got %d", m.Len())
+	}
+
+	if !m.Delete("a") || m.Delete("a") {
+		t.Error("expected Delete to report whether the key was there")
+	}
+	m.Clear()
+	if !m.IsEmpty() {
+		t.Errorf("expected an empty map, got %v", m.ToMap())
+	}
+}
+
+func TestKeyTypes(t *testing.T) {
+	floats := csMap.New[float64, string]()
+	floats.Put(0, "zero")
+	if v, ok := floats.Get(math.Copysign(0, -1)); !ok || v != "zero" {
+		t.Errorf("expected -0 to find the value of 0, got %q (%v)", v, ok)
+	}
+
+	type point struct{ x, y int }
+	points := csMap.New[point, int]()
+	for i := 0; i < 100; i++ {
+		points.Put(point{i, -i}, i)
+	}
+	if v, ok := points.Get(point{42, -42}); !ok || v != 42 || points.Len() != 100 {
+		t.Errorf("expected 42 and 100 keys, got %d (%v) and %d keys", v, ok, points.Len())
+	}
+
+	custom, _ := csMap.NewWithHasher[point, int](4, func(p point) uint64 { return uint64(p.x) })
+	custom.Put(point{1, 2}, 3)
+	if v, _ := custom.Get(point{1, 2}); v != 3 {
+		t.Errorf("expected 3, got %d", v)
+	}
+}
+
+func TestCompositeKeys(t *testing.T) {
+	// Pointers are equal keys when they have the same address, whatever they
+	// point to
+	type node struct{ n int }
+	pointers := csMap.New[*node, int]()
+	nodes := make([]*node, 100)
+	for i := range nodes {
+		nodes[i] = &node{i}
+		pointers.Put(nodes[i], i)
+	}
+	for i, n := range nodes {
+		n.n = -i - 1
+	}
+	for i, n := range nodes {
+		if v, ok := pointers.Get(n); !ok || v != i {
+			t.Fatalf("expected %d for a mutated pointer key, got %d (%v)", i, v, ok)
+		}
+		pointers.Put(n, i)
+	}
+	if pointers.Len() != 100 {
+		t.Errorf("expected 100 keys after putting them again, got %d", pointers.Len())
+	}
+
+	// -0 and 0 are equal fields
+	type value struct {
+		f float64
+		s string
+		_ int
+	}
+	structs := csMap.New[value, int]()
+	structs.Put(value{f: 0, s: "a"}, 1)
+	structs.Put(value{f: math.Copysign(0, -1), s: "a"}, 2)
+	if v, ok := structs.Get(value{s: "a"}); !ok || v != 2 || structs.Len() != 1 {
+		t.Errorf("expected a single key with value 2, got %d (%v) and %d keys", v, ok, structs.Len())
+	}
+
+	type nested struct {
+		p    *node
+		arr  [2]any
+		flag bool
+	}
+	n := &node{1}
+	others := csMap.New[nested, int]()
+	others.Put(nested{p: n, arr: [2]any{1, "x"}}, 1)
+	n.n = 2
+	if v, ok := others.Get(nested{p: n, arr: [2]any{1, "x"}}); !ok || v != 1 {
+		t.Errorf("expected 1, got %d (%v)", v, ok)
+	}
+	if others.Contains(nested{p: n, arr: [2]any{1, "y"}}) {
+		t.Error("expected a different key not to be found")
+	}
+}
+
+func TestGetOrCompute(t *testing.T) {
+	m := csMap.New[int, int]()
+	var calls atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, _ := m.GetOrCompute(7, func() int {
+				calls.Add(1)
+				return 49
+			})
+			if v != 49 {
+				t.Errorf("expected 49, got %d", v)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Errorf("expected compute to be called once, got %d", calls.Load())
+	}
+	if _, loaded := m.GetOrCompute(7, func() int { return 0 }); !loaded {
+		t.Error("expected the value to be loaded")
+	}
+}
+
+func TestRange(t *testing.T) {
+	m := csMap.New[int, int]()
+	for i := 0; i < 100; i++ {
+		m.Put(i, i*i)
+	}
+
+	seen := 0
+	m.Range(func(k, v int) bool {
+		if v != k*k {
+			t.Errorf("expected %d, got %d", k*k, v)
+		}
+		m.Delete(k) // f can use the map
+		seen++
+		return true
+	})
+	if seen != 100 || !m.IsEmpty() {
+		t.Errorf("expected 100 keys visited and deleted, got %d (%d left)", seen, m.Len())
+	}
+
+	m.Put(1, 1)
+	m.Put(2, 2)
+	seen = 0
+	m.Range(func(int, int) bool {
+		seen++
+		return false
+	})
+	if seen != 1 {
+		t.Errorf("expected Range to stop after the first key, got %d", seen)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	m := csMap.New[string, int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				key := strconv.Itoa(g*1000 + i)
+				m.Put(key, i)
+				if v, ok := m.Get(key); !ok || v != i {
+					t.Errorf("expected %d, got %d (%v)", i, v, ok)
+				}
+				if i%2 == 0 {
+					m.Delete(key)
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	if m.Len() != 4000 {
+		t.Errorf("expected 4000 keys, got %d", m.Len())
+	}
+}
+
+// rwMap is a map behind a single RWMutex, the baseline of the benchmarks
+type rwMap struct {
+	mu sync.RWMutex
+	m  map[int]int
+}
+
+// benchmarkMixed runs a parallel 90% reads / 10% writes workload
+func benchmarkMixed(b *testing.B, get func(int) (int, bool), put func(int, int)) {
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := i & 4095
+			if i%10 == 0 {
+				put(key, i)
+			} else {
+				_, _ = get(key)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkCSMap(b *testing.B) {
+	m := csMap.New[int, int]()
+	benchmarkMixed(b, m.Get, m.Put)
+}
+
+func BenchmarkRWMutexMap(b *testing.B) {
+	m := &rwMap{m: make(map[int]int)}
+	benchmarkMixed(b, func(k int) (int, bool) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+		v, ok := m.m[k]
+		return v, ok
+	}, func(k, v int) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		m.m[k] = v
+	})
+}
+
+func TestStats(t *testing.T) {
+	cs := csMap.New[int, string]()
+	cs.Put(1, "a")
+	if st := cs.Stats(); st.Reads != 0 || st.Writes != 0 || st.PeakSize != 0 {
+		t.Errorf("expected no statistics while disabled, got %+v", st)
+	}
+
+	cs.EnableStats(true)
+	cs.Put(2, "b")
+	cs.Put(3, "c")
+	cs.Put(3, "d")
+	cs.Delete(1)
+	_, _ = cs.Get(2)
+	st := cs.Stats()
+	if st.Writes != 4 || st.Reads != 1 || st.PeakSize != 3 {
+		t.Errorf("expected 4 writes, 1 read and peak size 3, got %+v", st)
+	}
+	if want := map[string]uint64{"Put": 3, "Delete": 1, "Get": 1}; !reflect.DeepEqual(st.Ops, want) {
+		t.Errorf("expected the operations %v, got %v", want, st.Ops)
+	}
+
+	cs.EnableStats(false)
+	cs.Put(1, "a")
+	if !reflect.DeepEqual(cs.Stats(), st) {
+		t.Errorf("expected the statistics to stop changing once disabled, got %+v", cs.Stats())
+	}
+}
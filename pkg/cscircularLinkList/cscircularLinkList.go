@@ -0,0 +1,502 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cscircularLinkList provides a concurrency-safe circular linked list using circularLinkList package.
+package cscircularLinkList
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	circularLinkList "github.com/pzaino/gods/pkg/circularLinkList"
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the CSCircularLinkList methods, they are the same
+// returned by the circularLinkList package (use errors.Is to check for them).
+var (
+	ErrOutOfBounds = circularLinkList.ErrOutOfBounds
+	ErrEmpty       = circularLinkList.ErrEmpty
+	ErrNotFound    = circularLinkList.ErrNotFound
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureHasher, common.FeatureConcurrent, common.FeatureDeepCopy, common.FeatureJSON)
+}
+
+// CSCircularLinkList is a concurrency-safe circular linked list.
+type CSCircularLinkList[T any] struct {
+	mu    sync.RWMutex
+	stats common.LockStats // opt-in statistics, see EnableStats
+	l     *circularLinkList.CircularLinkList[T]
+}
+
+// New creates a new concurrency-safe circular linked list.
+func New[T comparable]() *CSCircularLinkList[T] {
+	return &CSCircularLinkList[T]{l: circularLinkList.New[T]()}
+}
+
+// NewWithComparator creates a new concurrency-safe circular linked list that compares its elements with the given function.
+func NewWithComparator[T any](equals func(a, b T) bool) *CSCircularLinkList[T] {
+	return &CSCircularLinkList[T]{l: circularLinkList.NewWithComparator(equals)}
+}
+
+// NewWithHasher creates a new concurrency-safe circular linked list that compares its elements with the given hasher (see circularLinkList.NewWithHasher).
+func NewWithHasher[T any](hasher common.Hasher[T]) *CSCircularLinkList[T] {
+	return &CSCircularLinkList[T]{l: circularLinkList.NewWithHasher(hasher)}
+}
+
+// NewFromSlice creates a new concurrency-safe circular linked list from a slice.
+func NewFromSlice[T comparable](items []T) *CSCircularLinkList[T] {
+	cs := New[T]()
+	cs.l = circularLinkList.NewFromSlice(items)
+	return cs
+}
+
+// lock takes the write lock for the method op, collecting the statistics when
+// enabled.
+func (cs *CSCircularLinkList[T]) lock(op string) {
+	cs.stats.Lock(&cs.mu, op)
+}
+
+// rlock takes the read lock for the method op, collecting the statistics when
+// enabled.
+func (cs *CSCircularLinkList[T]) rlock(op string) {
+	cs.stats.RLock(&cs.mu, op)
+}
+
+// unlock records the size of the list and releases the write lock.
+func (cs *CSCircularLinkList[T]) unlock() {
+	cs.stats.Observe(cs.l.Size())
+	cs.mu.Unlock()
+}
+
+// EnableStats turns on or off the collection of the statistics returned by
+// Stats, enabling it resets them. When disabled the overhead is an atomic load
+// per operation.
+func (cs *CSCircularLinkList[T]) EnableStats(on bool) {
+	cs.stats.Enable(on)
+}
+
+// Stats returns the statistics collected since they were enabled: the
+// operations by type (read or write) and by method, the peak size and the
+// waits for the lock.
+func (cs *CSCircularLinkList[T]) Stats() common.Stats {
+	return cs.stats.Snapshot()
+}
+
+// Append adds a new node to the end of the list.
+func (cs *CSCircularLinkList[T]) Append(value T) {
+	cs.lock("Append")
+	defer cs.unlock()
+	cs.l.Append(value)
+}
+
+// Prepend adds a new node to the beginning of the list.
+func (cs *CSCircularLinkList[T]) Prepend(value T) {
+	cs.lock("Prepend")
+	defer cs.unlock()
+	cs.l.Prepend(value)
+}
+
+// DeleteWithValue deletes the first node with the given value.
+func (cs *CSCircularLinkList[T]) DeleteWithValue(value T) {
+	cs.lock("DeleteWithValue")
+	defer cs.unlock()
+	cs.l.DeleteWithValue(value)
+}
+
+// ToSlice returns the list as a slice.
+func (cs *CSCircularLinkList[T]) ToSlice() []T {
+	cs.rlock("ToSlice")
+	defer cs.mu.RUnlock()
+	return cs.l.ToSlice()
+}
+
+// ToSliceRange converts the elements in the range [start, end) of the list to a slice.
+func (cs *CSCircularLinkList[T]) ToSliceRange(start, end uint64) []T {
+	cs.rlock("ToSliceRange")
+	defer cs.mu.RUnlock()
+	return cs.l.ToSliceRange(start, end)
+}
+
+// String returns a string representation of the list (elements are formatted with %v).
+func (cs *CSCircularLinkList[T]) String() string {
+	return cs.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the list with every element formatted by f.
+func (cs *CSCircularLinkList[T]) StringFunc(f func(T) string) string {
+	cs.rlock("StringFunc")
+	defer cs.mu.RUnlock()
+	return cs.l.StringFunc(f)
+}
+
+// EncodeJSONStream writes the elements of the list to w as a JSON array, holding
+// the read lock while writing.
+func (cs *CSCircularLinkList[T]) EncodeJSONStream(w io.Writer) error {
+	cs.rlock("EncodeJSONStream")
+	defer cs.mu.RUnlock()
+	return cs.l.EncodeJSONStream(w)
+}
+
+// DecodeJSONStream appends the elements of the JSON array read from dec, holding
+// the write lock while reading.
+func (cs *CSCircularLinkList[T]) DecodeJSONStream(dec *json.Decoder) error {
+	cs.lock("DecodeJSONStream")
+	defer cs.unlock()
+	return cs.l.DecodeJSONStream(dec)
+}
+
+// IsEmpty checks if the list is empty.
+func (cs *CSCircularLinkList[T]) IsEmpty() bool {
+	cs.rlock("IsEmpty")
+	defer cs.mu.RUnlock()
+	return cs.l.IsEmpty()
+}
+
+// Find returns the first node with the given value.
+func (cs *CSCircularLinkList[T]) Find(value T) (*circularLinkList.Node[T], error) {
+	cs.rlock("Find")
+	defer cs.mu.RUnlock()
+	return cs.l.Find(value)
+}
+
+// FindCtx returns the first node with the given value, it stops with the error
+// of ctx when it's done (see circularLinkList.FindCtx).
+func (cs *CSCircularLinkList[T]) FindCtx(ctx context.Context, value T) (*circularLinkList.Node[T], error) {
+	cs.rlock("FindCtx")
+	defer cs.mu.RUnlock()
+	return cs.l.FindCtx(ctx, value)
+}
+
+// Reverse reverses the list.
+func (cs *CSCircularLinkList[T]) Reverse() {
+	cs.lock("Reverse")
+	defer cs.unlock()
+	cs.l.Reverse()
+}
+
+// Size returns the number of nodes in the list.
+func (cs *CSCircularLinkList[T]) Size() uint64 {
+	cs.rlock("Size")
+	defer cs.mu.RUnlock()
+	return cs.l.Size()
+}
+
+// CheckSize recalculates the size of the list.
+//
+// Deprecated: the size is kept up to date by all the methods of the list.
+func (cs *CSCircularLinkList[T]) CheckSize() {
+	cs.lock("CheckSize")
+	defer cs.unlock()
+	cs.l.CheckSize()
+}
+
+// GetFirst returns the first node in the list.
+func (cs *CSCircularLinkList[T]) GetFirst() *circularLinkList.Node[T] {
+	cs.rlock("GetFirst")
+	defer cs.mu.RUnlock()
+	return cs.l.GetFirst()
+}
+
+// GetLast returns the last node in the list.
+func (cs *CSCircularLinkList[T]) GetLast() *circularLinkList.Node[T] {
+	cs.rlock("GetLast")
+	defer cs.mu.RUnlock()
+	return cs.l.GetLast()
+}
+
+// GetAt returns the node at the given index.
+// Indexes bigger than the list size wrap around (modulo the list size), while
+// index == size is out of bounds.
+func (cs *CSCircularLinkList[T]) GetAt(index uint64) (*circularLinkList.Node[T], error) {
+	cs.rlock("GetAt")
+	defer cs.mu.RUnlock()
+	return cs.l.GetAt(index)
+}
+
+// InsertAt inserts a new node at the given index.
+func (cs *CSCircularLinkList[T]) InsertAt(index uint64, value T) error {
+	cs.lock("InsertAt")
+	defer cs.unlock()
+	return cs.l.InsertAt(index, value)
+}
+
+// DeleteAt deletes the node at the given index (indexes wrap around like in GetAt).
+func (cs *CSCircularLinkList[T]) DeleteAt(index uint64) error {
+	cs.lock("DeleteAt")
+	defer cs.unlock()
+	return cs.l.DeleteAt(index)
+}
+
+// Clear removes all nodes from the list.
+func (cs *CSCircularLinkList[T]) Clear() {
+	cs.lock("Clear")
+	defer cs.unlock()
+	cs.l.Clear()
+}
+
+// Wipe overwrites the value of every node with the zero value and unlinks the
+// nodes before emptying the list (see circularLinkList.CircularLinkList.Wipe).
+func (cs *CSCircularLinkList[T]) Wipe() {
+	cs.lock("Wipe")
+	defer cs.unlock()
+	cs.l.Wipe()
+}
+
+// Copy returns a copy of the list (a shallow copy, see
+// circularLinkList.CircularLinkList.Copy).
+func (cs *CSCircularLinkList[T]) Copy() *CSCircularLinkList[T] {
+	cs.rlock("Copy")
+	defer cs.mu.RUnlock()
+	return &CSCircularLinkList[T]{l: cs.l.Copy()}
+}
+
+// CloneWith returns a copy with a copy of every element made by copier, to deep
+// copy elements that hold pointers, slices or maps.
+func (cs *CSCircularLinkList[T]) CloneWith(copier func(T) T) *CSCircularLinkList[T] {
+	cs.rlock("CloneWith")
+	defer cs.mu.RUnlock()
+	return &CSCircularLinkList[T]{l: cs.l.CloneWith(copier)}
+}
+
+// CopyRange returns a copy of the elements in the range [start, end) (see circularLinkList.CircularLinkList.CopyRange).
+func (cs *CSCircularLinkList[T]) CopyRange(start, end uint64) *CSCircularLinkList[T] {
+	cs.rlock("CopyRange")
+	defer cs.mu.RUnlock()
+	return &CSCircularLinkList[T]{l: cs.l.CopyRange(start, end)}
+}
+
+// Merge appends all the nodes from another list to the current list, merging
+// a list with itself leaves it unchanged.
+func (cs *CSCircularLinkList[T]) Merge(list *CSCircularLinkList[T]) {
+	if list == cs {
+		return
+	}
+	cs.lock("Merge")
+	defer cs.unlock()
+	list.lock("Merge")
+	defer list.unlock()
+	cs.l.Merge(list.l)
+}
+
+// Map generates a new list by applying the function to all the nodes in the list.
+func (cs *CSCircularLinkList[T]) Map(f func(T) T) *CSCircularLinkList[T] {
+	cs.rlock("Map")
+	defer cs.mu.RUnlock()
+	return &CSCircularLinkList[T]{l: cs.l.Map(f)}
+}
+
+// MapCtx generates a new list by applying the function to all the nodes in the
+// list, it stops with the error of ctx when it's done (see circularLinkList.MapCtx).
+func (cs *CSCircularLinkList[T]) MapCtx(ctx context.Context, f func(T) T) (*CSCircularLinkList[T], error) {
+	cs.rlock("MapCtx")
+	defer cs.mu.RUnlock()
+
+	newList, err := cs.l.MapCtx(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	return &CSCircularLinkList[T]{l: newList}, nil
+}
+
+// MapFrom generates a new list by applying the function to all the nodes in the list starting from the specified index.
+func (cs *CSCircularLinkList[T]) MapFrom(start uint64, f func(T) T) (*CSCircularLinkList[T], error) {
+	cs.rlock("MapFrom")
+	defer cs.mu.RUnlock()
+
+	newList, err := cs.l.MapFrom(start, f)
+	if err != nil {
+		return nil, err
+	}
+	return &CSCircularLinkList[T]{l: newList}, nil
+}
+
+// MapRange generates a new list by applying the function to all the nodes in the list in the range [start, end).
+func (cs *CSCircularLinkList[T]) MapRange(start, end uint64, f func(T) T) (*CSCircularLinkList[T], error) {
+	cs.rlock("MapRange")
+	defer cs.mu.RUnlock()
+
+	newList, err := cs.l.MapRange(start, end, f)
+	if err != nil {
+		return nil, err
+	}
+	return &CSCircularLinkList[T]{l: newList}, nil
+}
+
+// ForEach applies the function to each node in the list.
+func (cs *CSCircularLinkList[T]) ForEach(f func(*T)) {
+	cs.lock("ForEach")
+	defer cs.unlock()
+	cs.l.ForEach(f)
+}
+
+// ForEachCtx applies the function to all the nodes in the list, it stops with
+// the error of ctx when it's done (see circularLinkList.ForEachCtx).
+func (cs *CSCircularLinkList[T]) ForEachCtx(ctx context.Context, f func(*T)) error {
+	cs.lock("ForEachCtx")
+	defer cs.unlock()
+	return cs.l.ForEachCtx(ctx, f)
+}
+
+// ForEachErr applies the function to each node in the list, stopping at the first error returned by the function.
+func (cs *CSCircularLinkList[T]) ForEachErr(f func(*T) error) error {
+	cs.lock("ForEachErr")
+	defer cs.unlock()
+	return cs.l.ForEachErr(f)
+}
+
+// ForRange applies the function to each node in the list in the range [start, end].
+func (cs *CSCircularLinkList[T]) ForRange(start, end uint64, f func(*T)) error {
+	cs.lock("ForRange")
+	defer cs.unlock()
+	return cs.l.ForRange(start, end, f)
+}
+
+// ForFrom applies the function to each node in the list starting from the index.
+func (cs *CSCircularLinkList[T]) ForFrom(start uint64, f func(*T)) error {
+	cs.lock("ForFrom")
+	defer cs.unlock()
+	return cs.l.ForFrom(start, f)
+}
+
+// Filter removes nodes from the list that don't match the predicate.
+func (cs *CSCircularLinkList[T]) Filter(f func(T) bool) {
+	cs.lock("Filter")
+	defer cs.unlock()
+	cs.l.Filter(f)
+}
+
+// Reduce reduces the list to a single value.
+func (cs *CSCircularLinkList[T]) Reduce(f func(T, T) T) (T, error) {
+	cs.rlock("Reduce")
+	defer cs.mu.RUnlock()
+	return cs.l.Reduce(f)
+}
+
+// ReduceFrom reduces the list to a single value starting from the index.
+func (cs *CSCircularLinkList[T]) ReduceFrom(start uint64, f func(T, T) T) (T, error) {
+	cs.rlock("ReduceFrom")
+	defer cs.mu.RUnlock()
+	return cs.l.ReduceFrom(start, f)
+}
+
+// ReduceRange reduces the list to a single value in the range [start, end).
+func (cs *CSCircularLinkList[T]) ReduceRange(start, end uint64, f func(T, T) T) (T, error) {
+	cs.rlock("ReduceRange")
+	defer cs.mu.RUnlock()
+	return cs.l.ReduceRange(start, end, f)
+}
+
+// Sort sorts the list according to the given function (using a stable merge sort).
+func (cs *CSCircularLinkList[T]) Sort(less func(T, T) bool) {
+	cs.lock("Sort")
+	defer cs.unlock()
+	cs.l.Sort(less)
+}
+
+// IsSorted returns true if the list is sorted according to the given function.
+func (cs *CSCircularLinkList[T]) IsSorted(less func(T, T) bool) bool {
+	cs.rlock("IsSorted")
+	defer cs.mu.RUnlock()
+	return cs.l.IsSorted(less)
+}
+
+// Compare compares the list with other lexicographically (see
+// circularLinkList.CircularLinkList.Compare). It works on a copy of other, so
+// the locks of the two lists are never held together.
+func (cs *CSCircularLinkList[T]) Compare(other *CSCircularLinkList[T], compare func(a, b T) int) int {
+	if other == cs {
+		return 0
+	}
+	other.rlock("Compare")
+	theirs := other.l.Copy()
+	other.mu.RUnlock()
+	cs.rlock("Compare")
+	defer cs.mu.RUnlock()
+	return cs.l.Compare(theirs, compare)
+}
+
+// Rotate advances the head of the list by n positions.
+func (cs *CSCircularLinkList[T]) Rotate(n uint64) {
+	cs.lock("Rotate")
+	defer cs.unlock()
+	cs.l.Rotate(n)
+}
+
+// RotateLeft rotates all the elements to the left by n positions.
+func (cs *CSCircularLinkList[T]) RotateLeft(n uint64) {
+	cs.lock("RotateLeft")
+	defer cs.unlock()
+	cs.l.RotateLeft(n)
+}
+
+// RotateRight rotates all the elements to the right by n positions.
+func (cs *CSCircularLinkList[T]) RotateRight(n uint64) {
+	cs.lock("RotateRight")
+	defer cs.unlock()
+	cs.l.RotateRight(n)
+}
+
+// RotateTo rotates the list so that the first node with the given value becomes the head.
+func (cs *CSCircularLinkList[T]) RotateTo(value T) error {
+	cs.lock("RotateTo")
+	defer cs.unlock()
+	return cs.l.RotateTo(value)
+}
+
+// Cursor is a concurrency-safe cursor that walks the list indefinitely.
+// It can be shared between goroutines (for example to distribute work round-robin).
+type Cursor[T any] struct {
+	mu   sync.Mutex
+	list *CSCircularLinkList[T]
+	c    *circularLinkList.Cursor[T]
+}
+
+// Cursor returns a new cursor positioned before the head of the list.
+func (cs *CSCircularLinkList[T]) Cursor() *Cursor[T] {
+	cs.rlock("Cursor")
+	defer cs.mu.RUnlock()
+	return &Cursor[T]{list: cs, c: cs.l.Cursor()}
+}
+
+// Next moves the cursor to the next node and returns its value.
+func (c *Cursor[T]) Next() (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.list.rlock("Next")
+	defer c.list.mu.RUnlock()
+	return c.c.Next()
+}
+
+// Current returns the value returned by the last call to Next.
+func (c *Cursor[T]) Current() (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.list.rlock("Current")
+	defer c.list.mu.RUnlock()
+	return c.c.Current()
+}
+
+// Reset moves the cursor back before the head of the list.
+func (c *Cursor[T]) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.Reset()
+}
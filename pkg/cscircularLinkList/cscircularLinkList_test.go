@@ -0,0 +1,583 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cscircularLinkList provides a concurrent-safe circular linked list.
+package cscircularLinkList_test
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	ctxtest "github.com/pzaino/gods/internal/ctxtest"
+	circularLinkList "github.com/pzaino/gods/pkg/circularLinkList"
+	common "github.com/pzaino/gods/pkg/common"
+	cscircularLinkList "github.com/pzaino/gods/pkg/cscircularLinkList"
+)
+
+const (
+	errExpectedNoError = "expected no error, got %v"
+	errExpectedSizeX   = "expected size %d, got %d"
+	errExpectedValue   = "expected %d, got %d"
+)
+
+func runConcurrent(_ *testing.T, n int, fn func(j int)) {
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(j int) {
+			defer wg.Done()
+			fn(j)
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestCSCircularLinkListFromSlice(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3, 4, 5})
+	if cs.Size() != 5 {
+		t.Fatalf(errExpectedSizeX, 5, cs.Size())
+	}
+	if cs.GetLast().Next() != cs.GetFirst() {
+		t.Fatalf("expected the tail to point back to the head")
+	}
+}
+
+func TestCSCircularLinkListAppend(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	runConcurrent(t, 1000, func(j int) {
+		cs.Append(j)
+	})
+	if cs.Size() != 1000 {
+		t.Fatalf(errExpectedSizeX, 1000, cs.Size())
+	}
+}
+
+func TestCSCircularLinkListPrepend(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	runConcurrent(t, 1000, func(j int) {
+		cs.Prepend(j)
+	})
+	if cs.Size() != 1000 {
+		t.Fatalf(errExpectedSizeX, 1000, cs.Size())
+	}
+}
+
+func TestCSCircularLinkListDeleteWithValue(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	for i := 0; i < 1000; i++ {
+		cs.Append(i)
+	}
+	runConcurrent(t, 100, func(_ int) {
+		cs.DeleteWithValue(500)
+	})
+	if _, err := cs.Find(500); err == nil {
+		t.Fatalf("expected value 500 to be deleted")
+	}
+	if cs.Size() != 999 {
+		t.Fatalf(errExpectedSizeX, 999, cs.Size())
+	}
+}
+
+func TestCSCircularLinkListToSlice(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	for i := 0; i < 1000; i++ {
+		cs.Append(i)
+	}
+	runConcurrent(t, 1000, func(_ int) {
+		if len(cs.ToSlice()) != 1000 {
+			t.Errorf(errExpectedSizeX, 1000, len(cs.ToSlice()))
+		}
+	})
+}
+
+func TestCSCircularLinkListIsEmpty(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	if !cs.IsEmpty() {
+		t.Fatalf("expected list to be empty")
+	}
+	cs.Append(1)
+	runConcurrent(t, 1000, func(_ int) {
+		if cs.IsEmpty() {
+			t.Errorf("expected list not to be empty")
+		}
+	})
+}
+
+func TestCSCircularLinkListFind(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	cs.Append(1)
+	runConcurrent(t, 1000, func(_ int) {
+		_, err := cs.Find(1)
+		if err != nil {
+			t.Errorf(errExpectedNoError, err)
+		}
+	})
+}
+
+func TestCSCircularLinkListReverse(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	for i := 0; i < 1000; i++ {
+		cs.Append(i)
+	}
+	runConcurrent(t, 1000, func(_ int) {
+		cs.Reverse()
+	})
+	// An even number of reversals must leave the list untouched
+	if cs.GetFirst().Value() != 0 || cs.GetLast().Value() != 999 {
+		t.Fatalf("expected list to be in the original order")
+	}
+}
+
+func TestCSCircularLinkListCheckSize(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3})
+	runConcurrent(t, 100, func(_ int) {
+		cs.CheckSize()
+	})
+	if cs.Size() != 3 {
+		t.Fatalf(errExpectedSizeX, 3, cs.Size())
+	}
+}
+
+func TestCSCircularLinkListGetAt(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{0, 1, 2, 3, 4})
+	runConcurrent(t, 1000, func(j int) {
+		node, err := cs.GetAt(uint64(j))
+		if j == 5 {
+			// index == size is out of bounds, only bigger indexes wrap
+			if !errors.Is(err, cscircularLinkList.ErrOutOfBounds) {
+				t.Errorf("expected %v, got %v", cscircularLinkList.ErrOutOfBounds, err)
+			}
+			return
+		}
+		if err != nil {
+			t.Errorf(errExpectedNoError, err)
+			return
+		}
+		// Indexes bigger than the size wrap around the list
+		if j > 5 && node.Value() != j%5 {
+			t.Errorf(errExpectedValue, j%5, node.Value())
+		}
+	})
+}
+
+func TestCSCircularLinkListInsertAt(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3})
+	runConcurrent(t, 100, func(j int) {
+		err := cs.InsertAt(1, j)
+		if err != nil {
+			t.Errorf(errExpectedNoError, err)
+		}
+	})
+	if len(cs.ToSlice()) != 103 {
+		t.Fatalf(errExpectedSizeX, 103, len(cs.ToSlice()))
+	}
+}
+
+func TestCSCircularLinkListDeleteAt(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	for i := 0; i < 200; i++ {
+		cs.Append(i)
+	}
+	runConcurrent(t, 100, func(_ int) {
+		err := cs.DeleteAt(1)
+		if err != nil {
+			t.Errorf(errExpectedNoError, err)
+		}
+	})
+	if len(cs.ToSlice()) != 100 {
+		t.Fatalf(errExpectedSizeX, 100, len(cs.ToSlice()))
+	}
+}
+
+func TestCSCircularLinkListClear(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3})
+	runConcurrent(t, 100, func(_ int) {
+		cs.Clear()
+	})
+	if !cs.IsEmpty() {
+		t.Fatalf("expected list to be empty")
+	}
+}
+
+func TestCSCircularLinkListCopy(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3})
+	runConcurrent(t, 100, func(_ int) {
+		c := cs.Copy()
+		if c.Size() != 3 {
+			t.Errorf(errExpectedSizeX, 3, c.Size())
+		}
+	})
+}
+
+func TestCSCircularLinkListMerge(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	runConcurrent(t, 100, func(j int) {
+		other := cscircularLinkList.NewFromSlice[int]([]int{j, j})
+		cs.Merge(other)
+		if !other.IsEmpty() {
+			t.Errorf("expected merged list to be empty")
+		}
+	})
+	if cs.Size() != 200 {
+		t.Fatalf(errExpectedSizeX, 200, cs.Size())
+	}
+
+	// Merging the list with itself must not deadlock
+	cs.Merge(cs)
+	if cs.Size() != 200 {
+		t.Fatalf(errExpectedSizeX, 200, cs.Size())
+	}
+}
+
+func TestCSCircularLinkListMap(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3})
+	runConcurrent(t, 100, func(_ int) {
+		m := cs.Map(func(v int) int { return v * 2 })
+		if m.GetLast().Value() != 6 {
+			t.Errorf(errExpectedValue, 6, m.GetLast().Value())
+		}
+	})
+}
+
+func TestCSCircularLinkListMapFrom(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3})
+	m, err := cs.MapFrom(1, func(v int) int { return v * 2 })
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if m.Size() != 2 {
+		t.Fatalf(errExpectedSizeX, 2, m.Size())
+	}
+
+	_, err = cscircularLinkList.New[int]().MapFrom(0, func(v int) int { return v })
+	if err == nil {
+		t.Fatalf("expected error on empty list")
+	}
+}
+
+func TestCSCircularLinkListMapRange(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3, 4})
+	m, err := cs.MapRange(1, 3, func(v int) int { return v * 2 })
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if m.Size() != 2 {
+		t.Fatalf(errExpectedSizeX, 2, m.Size())
+	}
+
+	_, err = cs.MapRange(3, 1, func(v int) int { return v })
+	if err == nil {
+		t.Fatalf("expected error when start is greater than end")
+	}
+}
+
+func TestCSCircularLinkListForEach(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{0, 0, 0})
+	runConcurrent(t, 100, func(_ int) {
+		cs.ForEach(func(v *int) { *v++ })
+	})
+	for _, v := range cs.ToSlice() {
+		if v != 100 {
+			t.Fatalf(errExpectedValue, 100, v)
+		}
+	}
+}
+
+func TestCSCircularLinkListForRange(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{0, 0, 0, 0})
+	runConcurrent(t, 100, func(_ int) {
+		err := cs.ForRange(1, 2, func(v *int) { *v++ })
+		if err != nil {
+			t.Errorf(errExpectedNoError, err)
+		}
+	})
+	expected := []int{0, 100, 100, 0}
+	for i, v := range cs.ToSlice() {
+		if v != expected[i] {
+			t.Fatalf(errExpectedValue, expected[i], v)
+		}
+	}
+}
+
+func TestCSCircularLinkListForFrom(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{0, 0, 0})
+	runConcurrent(t, 100, func(_ int) {
+		err := cs.ForFrom(1, func(v *int) { *v++ })
+		if err != nil {
+			t.Errorf(errExpectedNoError, err)
+		}
+	})
+	expected := []int{0, 100, 100}
+	for i, v := range cs.ToSlice() {
+		if v != expected[i] {
+			t.Fatalf(errExpectedValue, expected[i], v)
+		}
+	}
+}
+
+func TestCSCircularLinkListFilter(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	for i := 0; i < 100; i++ {
+		cs.Append(i)
+	}
+	runConcurrent(t, 10, func(_ int) {
+		cs.Filter(func(v int) bool { return v%2 == 0 })
+	})
+	if cs.Size() != 50 {
+		t.Fatalf(errExpectedSizeX, 50, cs.Size())
+	}
+}
+
+func TestCSCircularLinkListReduce(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3, 4})
+	runConcurrent(t, 100, func(_ int) {
+		sum, err := cs.Reduce(func(a, b int) int { return a + b })
+		if err != nil {
+			t.Errorf(errExpectedNoError, err)
+		}
+		if sum != 10 {
+			t.Errorf(errExpectedValue, 10, sum)
+		}
+	})
+}
+
+func TestCSCircularLinkListReduceFrom(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3, 4})
+	sum, err := cs.ReduceFrom(1, func(a, b int) int { return a + b })
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if sum != 9 {
+		t.Fatalf(errExpectedValue, 9, sum)
+	}
+}
+
+func TestCSCircularLinkListReduceRange(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice[int]([]int{1, 2, 3, 4})
+	sum, err := cs.ReduceRange(0, 2, func(a, b int) int { return a + b })
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if sum != 6 {
+		t.Fatalf(errExpectedValue, 6, sum)
+	}
+}
+
+func TestCSCircularLinkListSort(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice([]int{5, 4, 3, 2, 1})
+	less := func(a, b int) bool { return a < b }
+	runConcurrent(t, 100, func(_ int) {
+		cs.Sort(less)
+		cs.IsSorted(less)
+	})
+	if !cs.IsSorted(less) {
+		t.Fatalf("expected list to be sorted")
+	}
+	if cs.GetLast().Value() != 5 {
+		t.Fatalf(errExpectedValue, 5, cs.GetLast().Value())
+	}
+}
+
+func TestCSCircularLinkListRotate(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice([]int{1, 2, 3, 4})
+	runConcurrent(t, 100, func(_ int) {
+		cs.Rotate(1)
+	})
+	// 100 rotations of a 4 elements list bring it back to the start
+	if cs.GetFirst().Value() != 1 || cs.GetLast().Value() != 4 {
+		t.Fatalf(errExpectedValue, 1, cs.GetFirst().Value())
+	}
+
+	if err := cs.RotateTo(3); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if cs.GetFirst().Value() != 3 || cs.GetLast().Value() != 2 {
+		t.Fatalf(errExpectedValue, 3, cs.GetFirst().Value())
+	}
+	if err := cs.RotateTo(9); err != cscircularLinkList.ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestCSCircularLinkListCursor(t *testing.T) {
+	cs := cscircularLinkList.NewFromSlice([]int{1, 2, 3, 4})
+	c := cs.Cursor()
+
+	var mu sync.Mutex
+	counts := make(map[int]int)
+	runConcurrent(t, 100, func(_ int) {
+		v, ok := c.Next()
+		if !ok {
+			t.Errorf("expected a value")
+			return
+		}
+		mu.Lock()
+		counts[v]++
+		mu.Unlock()
+	})
+	// The cursor is shared, so the work is spread evenly (round-robin)
+	for v := 1; v <= 4; v++ {
+		if counts[v] != 25 {
+			t.Fatalf(errExpectedValue, 25, counts[v])
+		}
+	}
+	if v, ok := c.Current(); !ok || v != 4 {
+		t.Fatalf(errExpectedValue, 4, v)
+	}
+
+	c.Reset()
+	if v, _ := c.Next(); v != 1 {
+		t.Fatalf(errExpectedValue, 1, v)
+	}
+}
+
+func TestString(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	cs.Append(1)
+	cs.Append(2)
+	if s := cs.String(); s != "[1 2]" {
+		t.Errorf("expected [1 2], got %s", s)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := cscircularLinkList.New[*int]()
+	c.Append(&a)
+	c.Append(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestCtxVariants(t *testing.T) {
+	ctxtest.Run[*cscircularLinkList.CSCircularLinkList[int], *circularLinkList.Node[int]](t, cscircularLinkList.New[int], cscircularLinkList.ErrNotFound)
+}
+
+func TestJSONStream(t *testing.T) {
+	if !cscircularLinkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, cscircularLinkList.Features())
+	}
+	if !cscircularLinkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, cscircularLinkList.Features())
+	}
+	l := cscircularLinkList.New[int]()
+	if err := l.DecodeJSONStream(json.NewDecoder(strings.NewReader("[1, 2, 3]"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var sb strings.Builder
+	if err := l.EncodeJSONStream(&sb); err != nil || sb.String() != "[1,2,3]" {
+		t.Errorf("expected [1,2,3], got %s (%v)", sb.String(), err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *cscircularLinkList.CSCircularLinkList[int] {
+		l := cscircularLinkList.New[int]()
+		for _, v := range values {
+			l.Append(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	l := build(1, 2)
+	if got := l.Compare(l, cmp.Compare[int]); got != 0 {
+		t.Errorf("expected a list to be equal to itself, got %d", got)
+	}
+}
+
+func TestCompareBothWays(t *testing.T) {
+	a, b := cscircularLinkList.New[int](), cscircularLinkList.New[int]()
+	var wg sync.WaitGroup
+	for _, pair := range [][2]*cscircularLinkList.CSCircularLinkList[int]{{a, b}, {b, a}} {
+		wg.Add(2)
+		go func(x, y *cscircularLinkList.CSCircularLinkList[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_ = x.Compare(y, cmp.Compare[int])
+			}
+		}(pair[0], pair[1])
+		go func(x *cscircularLinkList.CSCircularLinkList[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				x.Append(i)
+			}
+		}(pair[0])
+	}
+	wg.Wait()
+}
+
+func TestStats(t *testing.T) {
+	cs := cscircularLinkList.New[int]()
+	cs.Append(1)
+	if st := cs.Stats(); st.Reads != 0 || st.Writes != 0 || st.PeakSize != 0 {
+		t.Errorf("expected no statistics while disabled, got %+v", st)
+	}
+
+	cs.EnableStats(true)
+	cs.Append(2)
+	cs.Append(3)
+	cs.DeleteWithValue(1)
+	_ = cs.Size()
+	st := cs.Stats()
+	if st.Writes != 3 || st.Reads != 1 || st.PeakSize != 3 {
+		t.Errorf("expected 3 writes, 1 read and peak size 3, got %+v", st)
+	}
+	if want := map[string]uint64{"Append": 2, "DeleteWithValue": 1, "Size": 1}; !reflect.DeepEqual(st.Ops, want) {
+		t.Errorf("expected the operations %v, got %v", want, st.Ops)
+	}
+
+	cs.EnableStats(false)
+	cs.Append(1)
+	if !reflect.DeepEqual(cs.Stats(), st) {
+		t.Errorf("expected the statistics to stop changing once disabled, got %+v", cs.Stats())
+	}
+}
@@ -16,15 +16,36 @@
 package csdlinkList
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"sync"
 
+	common "github.com/pzaino/gods/pkg/common"
 	dlinkList "github.com/pzaino/gods/pkg/dlinkList"
 )
 
+// Sentinel errors returned by the CSDLinkList methods, they are the same
+// returned by the dlinkList package (use errors.Is to check for them).
+var (
+	ErrOutOfBounds  = dlinkList.ErrOutOfBounds
+	ErrInsertFailed = dlinkList.ErrInsertFailed
+	ErrNotFound     = dlinkList.ErrNotFound
+	ErrSameList     = dlinkList.ErrSameList
+	ErrNotInList    = dlinkList.ErrNotInList
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureHasher, common.FeatureObservers, common.FeatureConcurrent, common.FeatureDeepCopy, common.FeatureJSON)
+}
+
 // CSDLinkList is a concurrency-safe doubly linked list.
-type CSDLinkList[T comparable] struct {
-	mu sync.RWMutex
-	l  *dlinkList.DLinkList[T]
+type CSDLinkList[T any] struct {
+	mu    sync.RWMutex
+	stats common.LockStats // opt-in statistics, see EnableStats
+	l     *dlinkList.DLinkList[T]
 }
 
 // New creates a new concurrency-safe doubly linked list.
@@ -32,55 +53,120 @@ func New[T comparable]() *CSDLinkList[T] {
 	return &CSDLinkList[T]{l: dlinkList.New[T]()}
 }
 
+// NewWithComparator creates a new concurrency-safe doubly linked list that compares its elements with the given function.
+func NewWithComparator[T any](equals func(a, b T) bool) *CSDLinkList[T] {
+	return &CSDLinkList[T]{l: dlinkList.NewWithComparator(equals)}
+}
+
+// NewWithHasher creates a new concurrency-safe doubly linked list that compares its elements with the given hasher (see dlinkList.NewWithHasher).
+func NewWithHasher[T any](hasher common.Hasher[T]) *CSDLinkList[T] {
+	return &CSDLinkList[T]{l: dlinkList.NewWithHasher(hasher)}
+}
+
+// lock takes the write lock for the method op, collecting the statistics when
+// enabled.
+func (cs *CSDLinkList[T]) lock(op string) {
+	cs.stats.Lock(&cs.mu, op)
+}
+
+// rlock takes the read lock for the method op, collecting the statistics when
+// enabled.
+func (cs *CSDLinkList[T]) rlock(op string) {
+	cs.stats.RLock(&cs.mu, op)
+}
+
+// unlock records the size of the list and releases the write lock.
+func (cs *CSDLinkList[T]) unlock() {
+	cs.stats.Observe(cs.l.Size())
+	cs.mu.Unlock()
+}
+
+// EnableStats turns on or off the collection of the statistics returned by
+// Stats, enabling it resets them. When disabled the overhead is an atomic load
+// per operation.
+func (cs *CSDLinkList[T]) EnableStats(on bool) {
+	cs.stats.Enable(on)
+}
+
+// Stats returns the statistics collected since they were enabled: the
+// operations by type (read or write), the peak size and the waits for the lock.
+func (cs *CSDLinkList[T]) Stats() common.Stats {
+	return cs.stats.Snapshot()
+}
+
 // Append adds a new node to the end of the doubly linked list.
 func (cs *CSDLinkList[T]) Append(value T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Append")
+	defer cs.unlock()
 	cs.l.Append(value)
 }
 
 // Prepend adds a new node to the beginning of the doubly linked list.
 func (cs *CSDLinkList[T]) Prepend(value T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Prepend")
+	defer cs.unlock()
 	cs.l.Prepend(value)
 }
 
+// AppendN adds new nodes with the given values to the end of the doubly linked list,
+// taking the lock once for the whole batch.
+func (cs *CSDLinkList[T]) AppendN(values ...T) {
+	cs.lock("AppendN")
+	defer cs.unlock()
+	cs.l.AppendN(values...)
+}
+
+// PrependN adds new nodes with the given values to the beginning of the doubly linked list
+// in the given order, taking the lock once for the whole batch.
+func (cs *CSDLinkList[T]) PrependN(values ...T) {
+	cs.lock("PrependN")
+	defer cs.unlock()
+	cs.l.PrependN(values...)
+}
+
 // Insert inserts a new node with the given value at the first available index.
 func (cs *CSDLinkList[T]) Insert(value T) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Insert")
+	defer cs.unlock()
 	return cs.l.Insert(value)
 }
 
 // InsertAfter inserts a new node with the given value after the node with the given value.
 func (cs *CSDLinkList[T]) InsertAfter(value, newValue T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("InsertAfter")
+	defer cs.unlock()
 	cs.l.InsertAfter(value, newValue)
 }
 
 // InsertBefore inserts a new node with the given value before the node with the given value.
 func (cs *CSDLinkList[T]) InsertBefore(value, newValue T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("InsertBefore")
+	defer cs.unlock()
 	cs.l.InsertBefore(value, newValue)
 }
 
 // InsertAt inserts a new node with the given value at the given index.
 func (cs *CSDLinkList[T]) InsertAt(index uint64, value T) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("InsertAt")
+	defer cs.unlock()
 	return cs.l.InsertAt(index, value)
 }
 
 // DeleteWithValue deletes the first occurrence of a node with the given value.
 func (cs *CSDLinkList[T]) DeleteWithValue(value T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("DeleteWithValue")
+	defer cs.unlock()
 	cs.l.DeleteWithValue(value)
 }
 
+// DeleteN deletes the first node with each of the given values, taking the
+// lock once for the whole batch, and returns how many nodes were deleted.
+func (cs *CSDLinkList[T]) DeleteN(values ...T) uint64 {
+	cs.lock("DeleteN")
+	defer cs.unlock()
+	return cs.l.DeleteN(values...)
+}
+
 // Remove is an alias for DeleteWithValue.
 func (cs *CSDLinkList[T]) Remove(value T) {
 	cs.DeleteWithValue(value)
@@ -93,307 +179,539 @@ func (cs *CSDLinkList[T]) RemoveAt(index uint64) error {
 
 // Delete deletes the first node with the given value.
 func (cs *CSDLinkList[T]) Delete(value T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Delete")
+	defer cs.unlock()
 	cs.l.Delete(value)
 }
 
 // DeleteLast deletes the last node in the doubly linked list.
 func (cs *CSDLinkList[T]) DeleteLast() {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("DeleteLast")
+	defer cs.unlock()
 	cs.l.DeleteLast()
 }
 
 // DeleteFirst deletes the first node in the doubly linked list.
 func (cs *CSDLinkList[T]) DeleteFirst() {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("DeleteFirst")
+	defer cs.unlock()
 	cs.l.DeleteFirst()
 }
 
 // DeleteAt deletes the node at the given index.
 func (cs *CSDLinkList[T]) DeleteAt(index uint64) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("DeleteAt")
+	defer cs.unlock()
 	return cs.l.DeleteAt(index)
 }
 
 // ToSlice converts the doubly linked list to a slice.
 func (cs *CSDLinkList[T]) ToSlice() []T {
-	cs.mu.RLock()
+	cs.rlock("ToSlice")
 	defer cs.mu.RUnlock()
 	return cs.l.ToSlice()
 }
 
+// String returns a string representation of the doubly linked list (elements are formatted with %v).
+func (cs *CSDLinkList[T]) String() string {
+	return cs.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the doubly linked list with every element formatted by f.
+func (cs *CSDLinkList[T]) StringFunc(f func(T) string) string {
+	cs.rlock("StringFunc")
+	defer cs.mu.RUnlock()
+	return cs.l.StringFunc(f)
+}
+
+// EncodeJSONStream writes the elements of the list to w as a JSON array, holding
+// the read lock while writing.
+func (cs *CSDLinkList[T]) EncodeJSONStream(w io.Writer) error {
+	cs.rlock("EncodeJSONStream")
+	defer cs.mu.RUnlock()
+	return cs.l.EncodeJSONStream(w)
+}
+
+// DecodeJSONStream appends the elements of the JSON array read from dec, holding
+// the write lock while reading.
+func (cs *CSDLinkList[T]) DecodeJSONStream(dec *json.Decoder) error {
+	cs.lock("DecodeJSONStream")
+	defer cs.unlock()
+	return cs.l.DecodeJSONStream(dec)
+}
+
 // ToSliceReverse converts the doubly linked list to a slice in reverse order.
 func (cs *CSDLinkList[T]) ToSliceReverse() []T {
-	cs.mu.RLock()
+	cs.rlock("ToSliceReverse")
 	defer cs.mu.RUnlock()
 	return cs.l.ToSliceReverse()
 }
 
 // ToSliceFromIndex converts the doubly linked list to a slice starting from the given index.
 func (cs *CSDLinkList[T]) ToSliceFromIndex(index uint64) []T {
-	cs.mu.RLock()
+	cs.rlock("ToSliceFromIndex")
 	defer cs.mu.RUnlock()
 	return cs.l.ToSliceFromIndex(index)
 }
 
 // ToSliceReverseFromIndex converts the doubly linked list to a slice in reverse order starting from the given index.
 func (cs *CSDLinkList[T]) ToSliceReverseFromIndex(index uint64) []T {
-	cs.mu.RLock()
+	cs.rlock("ToSliceReverseFromIndex")
 	defer cs.mu.RUnlock()
 	return cs.l.ToSliceReverseFromIndex(index)
 }
 
+// ToSliceRange converts the elements in the range [start, end) of the doubly linked list to a slice.
+func (cs *CSDLinkList[T]) ToSliceRange(start, end uint64) []T {
+	cs.rlock("ToSliceRange")
+	defer cs.mu.RUnlock()
+	return cs.l.ToSliceRange(start, end)
+}
+
 // Reverse reverses the doubly linked list.
 func (cs *CSDLinkList[T]) Reverse() {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Reverse")
+	defer cs.unlock()
 	cs.l.Reverse()
 }
 
+// RotateLeft rotates all the elements to the left by n positions.
+func (cs *CSDLinkList[T]) RotateLeft(n uint64) {
+	cs.lock("RotateLeft")
+	defer cs.unlock()
+	cs.l.RotateLeft(n)
+}
+
+// RotateRight rotates all the elements to the right by n positions.
+func (cs *CSDLinkList[T]) RotateRight(n uint64) {
+	cs.lock("RotateRight")
+	defer cs.unlock()
+	cs.l.RotateRight(n)
+}
+
 // Find returns the first node with the given value.
 func (cs *CSDLinkList[T]) Find(value T) (*dlinkList.Node[T], error) {
-	cs.mu.RLock()
+	cs.rlock("Find")
 	defer cs.mu.RUnlock()
 	return cs.l.Find(value)
 }
 
+// FindCtx returns the first node with the given value, it stops with the error
+// of ctx when it's done (see dlinkList.FindCtx).
+func (cs *CSDLinkList[T]) FindCtx(ctx context.Context, value T) (*dlinkList.Node[T], error) {
+	cs.rlock("FindCtx")
+	defer cs.mu.RUnlock()
+	return cs.l.FindCtx(ctx, value)
+}
+
 // IsEmpty returns true if the doubly linked list is empty.
 func (cs *CSDLinkList[T]) IsEmpty() bool {
-	cs.mu.RLock()
+	cs.rlock("IsEmpty")
 	defer cs.mu.RUnlock()
 	return cs.l.IsEmpty()
 }
 
 // GetAt returns the node at the given index.
 func (cs *CSDLinkList[T]) GetAt(index uint64) (*dlinkList.Node[T], error) {
-	cs.mu.RLock()
+	cs.rlock("GetAt")
 	defer cs.mu.RUnlock()
 	return cs.l.GetAt(index)
 }
 
 // GetLast returns the last node in the doubly linked list.
 func (cs *CSDLinkList[T]) GetLast() *dlinkList.Node[T] {
-	cs.mu.RLock()
+	cs.rlock("GetLast")
 	defer cs.mu.RUnlock()
 	return cs.l.GetLast()
 }
 
 // GetFirst returns the first node in the doubly linked list.
 func (cs *CSDLinkList[T]) GetFirst() *dlinkList.Node[T] {
-	cs.mu.RLock()
+	cs.rlock("GetFirst")
 	defer cs.mu.RUnlock()
 	return cs.l.GetFirst()
 }
 
 // Size returns the number of nodes in the doubly linked list.
 func (cs *CSDLinkList[T]) Size() uint64 {
-	cs.mu.RLock()
+	cs.rlock("Size")
 	defer cs.mu.RUnlock()
 	return cs.l.Size()
 }
 
 // Clear removes all nodes from the doubly linked list.
 func (cs *CSDLinkList[T]) Clear() {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Clear")
+	defer cs.unlock()
 	cs.l.Clear()
 }
 
+// Wipe overwrites the value of every node with the zero value and unlinks the
+// nodes before emptying the list (see dlinkList.DLinkList.Wipe).
+func (cs *CSDLinkList[T]) Wipe() {
+	cs.lock("Wipe")
+	defer cs.unlock()
+	cs.l.Wipe()
+}
+
 // Contains returns true if the doubly linked list contains the given value.
 func (cs *CSDLinkList[T]) Contains(value T) bool {
-	cs.mu.RLock()
+	cs.rlock("Contains")
 	defer cs.mu.RUnlock()
 	return cs.l.Contains(value)
 }
 
 // ForEach traverses the doubly linked list and applies the given function to each node.
 func (cs *CSDLinkList[T]) ForEach(f func(*T)) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForEach")
+	defer cs.unlock()
 	cs.l.ForEach(f)
 }
 
+// ForEachCtx applies the function to all the nodes in the list, it stops with
+// the error of ctx when it's done (see dlinkList.ForEachCtx).
+func (cs *CSDLinkList[T]) ForEachCtx(ctx context.Context, f func(*T)) error {
+	cs.lock("ForEachCtx")
+	defer cs.unlock()
+	return cs.l.ForEachCtx(ctx, f)
+}
+
+// ForEachErr traverses the doubly linked list and applies the given function to each node, stopping at the first error returned by the function.
+func (cs *CSDLinkList[T]) ForEachErr(f func(*T) error) error {
+	cs.lock("ForEachErr")
+	defer cs.unlock()
+	return cs.l.ForEachErr(f)
+}
+
 // ForFrom traverses the doubly linked list starting from the given index and applies the given function to each node.
 func (cs *CSDLinkList[T]) ForFrom(index uint64, f func(*T)) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForFrom")
+	defer cs.unlock()
 	cs.l.ForFrom(index, f)
 }
 
 // ForReverseFrom traverses the doubly linked list in reverse order starting from the given index and applies the given function to each node.
 func (cs *CSDLinkList[T]) ForReverseFrom(index uint64, f func(*T)) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForReverseFrom")
+	defer cs.unlock()
 	cs.l.ForReverseFrom(index, f)
 }
 
 // ForEachReverse traverses the doubly linked list in reverse order and applies the given function to each node.
 func (cs *CSDLinkList[T]) ForEachReverse(f func(*T)) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForEachReverse")
+	defer cs.unlock()
 	cs.l.ForEachReverse(f)
 }
 
 // ForRange traverses the doubly linked list in the given range and applies the given function to each node.
 func (cs *CSDLinkList[T]) ForRange(start, end uint64, f func(*T)) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForRange")
+	defer cs.unlock()
 	cs.l.ForRange(start, end, f)
 }
 
 // ForReverseRange traverses the doubly linked list in reverse order in the given range and applies the given function to each node.
 func (cs *CSDLinkList[T]) ForReverseRange(start, end uint64, f func(*T)) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForReverseRange")
+	defer cs.unlock()
 	cs.l.ForReverseRange(start, end, f)
 }
 
 // Any returns true if the given function returns true for any node in the doubly linked list.
 func (cs *CSDLinkList[T]) Any(f func(T) bool) bool {
-	cs.mu.RLock()
+	cs.rlock("Any")
 	defer cs.mu.RUnlock()
 	return cs.l.Any(f)
 }
 
 // All returns true if the given function returns true for all nodes in the doubly linked list.
 func (cs *CSDLinkList[T]) All(f func(T) bool) bool {
-	cs.mu.RLock()
+	cs.rlock("All")
 	defer cs.mu.RUnlock()
 	return cs.l.All(f)
 }
 
 // IndexOf returns the index of the first occurrence of the given value in the doubly linked list.
 func (cs *CSDLinkList[T]) IndexOf(value T) int {
-	cs.mu.RLock()
+	cs.rlock("IndexOf")
 	defer cs.mu.RUnlock()
 	return cs.l.IndexOf(value)
 }
 
 // LastIndexOf returns the index of the last occurrence of the given value in the doubly linked list.
 func (cs *CSDLinkList[T]) LastIndexOf(value T) (uint64, error) {
-	cs.mu.RLock()
+	cs.rlock("LastIndexOf")
 	defer cs.mu.RUnlock()
 	return cs.l.LastIndexOf(value)
 }
 
 // Filter returns a new doubly linked list containing only the nodes that satisfy the given function.
 func (cs *CSDLinkList[T]) Filter(f func(T) bool) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Filter")
+	defer cs.unlock()
 	cs.l.Filter(f)
 }
 
 // Map returns a new doubly linked list containing the result of applying the given function to each node.
 func (cs *CSDLinkList[T]) Map(f func(T) T) *CSDLinkList[T] {
-	cs.mu.RLock()
+	cs.rlock("Map")
 	defer cs.mu.RUnlock()
 	return &CSDLinkList[T]{l: cs.l.Map(f)}
 }
 
+// MapCtx generates a new list by applying the function to all the nodes in the
+// list, it stops with the error of ctx when it's done (see dlinkList.MapCtx).
+func (cs *CSDLinkList[T]) MapCtx(ctx context.Context, f func(T) T) (*CSDLinkList[T], error) {
+	cs.rlock("MapCtx")
+	defer cs.mu.RUnlock()
+
+	newList, err := cs.l.MapCtx(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	return &CSDLinkList[T]{l: newList}, nil
+}
+
 // MapFrom returns a new doubly linked list containing the result of applying the given function to each node starting from the given index.
 func (cs *CSDLinkList[T]) MapFrom(index uint64, f func(T) T) *CSDLinkList[T] {
-	cs.mu.RLock()
+	cs.rlock("MapFrom")
 	defer cs.mu.RUnlock()
 	return &CSDLinkList[T]{l: cs.l.MapFrom(index, f)}
 }
 
 // MapRange returns a new doubly linked list containing the result of applying the given function to each node in the given range.
 func (cs *CSDLinkList[T]) MapRange(start, end uint64, f func(T) T) *CSDLinkList[T] {
-	cs.mu.RLock()
+	cs.rlock("MapRange")
 	defer cs.mu.RUnlock()
 	return &CSDLinkList[T]{l: cs.l.MapRange(start, end, f)}
 }
 
 // Reduce reduces the doubly linked list to a single value using the given function.
 func (cs *CSDLinkList[T]) Reduce(f func(T, T) T) T {
-	cs.mu.RLock()
+	cs.rlock("Reduce")
 	defer cs.mu.RUnlock()
 	return cs.l.Reduce(f)
 }
 
-// Copy returns a new doubly linked list with the same nodes as the original doubly linked list.
+// Copy returns a new doubly linked list with the same nodes as the original doubly linked list
+// (a shallow copy, see dlinkList.DLinkList.Copy).
 func (cs *CSDLinkList[T]) Copy() *CSDLinkList[T] {
-	cs.mu.RLock()
+	cs.rlock("Copy")
 	defer cs.mu.RUnlock()
 	return &CSDLinkList[T]{l: cs.l.Copy()}
 }
 
+// CloneWith returns a copy with a copy of every element made by copier, to deep
+// copy elements that hold pointers, slices or maps.
+func (cs *CSDLinkList[T]) CloneWith(copier func(T) T) *CSDLinkList[T] {
+	cs.rlock("CloneWith")
+	defer cs.mu.RUnlock()
+	return &CSDLinkList[T]{l: cs.l.CloneWith(copier)}
+}
+
+// CopyRange returns a copy of the elements in the range [start, end) (see dlinkList.DLinkList.CopyRange).
+func (cs *CSDLinkList[T]) CopyRange(start, end uint64) *CSDLinkList[T] {
+	cs.rlock("CopyRange")
+	defer cs.mu.RUnlock()
+	return &CSDLinkList[T]{l: cs.l.CopyRange(start, end)}
+}
+
 // Merge appends the nodes of the given doubly linked list to the original doubly linked list.
 func (cs *CSDLinkList[T]) Merge(list *CSDLinkList[T]) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	list.mu.Lock()
-	defer list.mu.Unlock()
+	cs.lock("Merge")
+	defer cs.unlock()
+	list.lock("Merge")
+	defer list.unlock()
 	cs.l.Merge(list.l)
 }
 
 // ReverseCopy returns a new doubly linked list with the nodes of the original doubly linked list in reverse order.
 func (cs *CSDLinkList[T]) ReverseCopy() *CSDLinkList[T] {
-	cs.mu.RLock()
+	cs.rlock("ReverseCopy")
 	defer cs.mu.RUnlock()
 	return &CSDLinkList[T]{l: cs.l.ReverseCopy()}
 }
 
 // ReverseMerge appends the nodes of the given doubly linked list to the original doubly linked list in reverse order.
 func (cs *CSDLinkList[T]) ReverseMerge(list *CSDLinkList[T]) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	list.mu.Lock()
-	defer list.mu.Unlock()
+	cs.lock("ReverseMerge")
+	defer cs.unlock()
+	list.lock("ReverseMerge")
+	defer list.unlock()
 	cs.l.ReverseMerge(list.l)
 }
 
 // Equal returns true if the given doubly linked list is equal to the original doubly linked list.
 func (cs *CSDLinkList[T]) Equal(list *CSDLinkList[T]) bool {
-	cs.mu.RLock()
+	cs.rlock("Equal")
 	defer cs.mu.RUnlock()
-	list.mu.RLock()
+	list.rlock("Equal")
 	defer list.mu.RUnlock()
 	return cs.l.Equal(list.l)
 }
 
+// Compare compares the list with other lexicographically (see
+// dlinkList.DLinkList.Compare). It works on a copy of other, so the locks of
+// the two lists are never held together.
+func (cs *CSDLinkList[T]) Compare(other *CSDLinkList[T], compare func(a, b T) int) int {
+	if other == cs {
+		return 0
+	}
+	other.rlock("Compare")
+	theirs := other.l.Copy()
+	other.mu.RUnlock()
+	cs.rlock("Compare")
+	defer cs.mu.RUnlock()
+	return cs.l.Compare(theirs, compare)
+}
+
 // Swap swaps the nodes at the given indices.
 func (cs *CSDLinkList[T]) Swap(i, j uint64) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Swap")
+	defer cs.unlock()
 	return cs.l.Swap(i, j)
 }
 
+// SwapNodes swaps the positions of two nodes of the list by re-linking them
+// (see dlinkList.DLinkList.SwapNodes).
+func (cs *CSDLinkList[T]) SwapNodes(a, b *dlinkList.Node[T]) error {
+	cs.lock("SwapNodes")
+	defer cs.unlock()
+	return cs.l.SwapNodes(a, b)
+}
+
+// MoveToFront moves a node of the list to the front by re-linking it (see
+// dlinkList.DLinkList.MoveToFront).
+func (cs *CSDLinkList[T]) MoveToFront(node *dlinkList.Node[T]) error {
+	cs.lock("MoveToFront")
+	defer cs.unlock()
+	return cs.l.MoveToFront(node)
+}
+
+// MoveToBack moves a node of the list to the back by re-linking it (see
+// dlinkList.DLinkList.MoveToBack).
+func (cs *CSDLinkList[T]) MoveToBack(node *dlinkList.Node[T]) error {
+	cs.lock("MoveToBack")
+	defer cs.unlock()
+	return cs.l.MoveToBack(node)
+}
+
 // Sort sorts the doubly linked list according to the given function.
 func (cs *CSDLinkList[T]) Sort(f func(T, T) bool) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Sort")
+	defer cs.unlock()
 	cs.l.Sort(f)
 }
 
+// IsSorted returns true if the doubly linked list is sorted according to the given function.
+func (cs *CSDLinkList[T]) IsSorted(f func(T, T) bool) bool {
+	cs.rlock("IsSorted")
+	defer cs.mu.RUnlock()
+	return cs.l.IsSorted(f)
+}
+
+// SearchSorted returns the index where value would be inserted in the sorted
+// doubly linked list (see dlinkList.SearchSorted).
+func (cs *CSDLinkList[T]) SearchSorted(value T, less func(T, T) bool) uint64 {
+	cs.rlock("SearchSorted")
+	defer cs.mu.RUnlock()
+	return cs.l.SearchSorted(value, less)
+}
+
+// InsertSorted inserts value in the sorted doubly linked list keeping it sorted
+// and returns its index (see dlinkList.InsertSorted).
+func (cs *CSDLinkList[T]) InsertSorted(value T, less func(T, T) bool) uint64 {
+	cs.lock("InsertSorted")
+	defer cs.unlock()
+	return cs.l.InsertSorted(value, less)
+}
+
 // FindAll returns a new doubly linked list containing all nodes that satisfy the given function.
 func (cs *CSDLinkList[T]) FindAll(f func(T) bool) *CSDLinkList[T] {
-	cs.mu.RLock()
+	cs.rlock("FindAll")
 	defer cs.mu.RUnlock()
 	return &CSDLinkList[T]{l: cs.l.FindAll(f)}
 }
 
 // FindLast returns the last node that satisfies the given function.
 func (cs *CSDLinkList[T]) FindLast(f func(T) bool) (*dlinkList.Node[T], error) {
-	cs.mu.RLock()
+	cs.rlock("FindLast")
 	defer cs.mu.RUnlock()
 	return cs.l.FindLast(f)
 }
 
 // FindLastIndex returns the index of the last node that satisfies the given function.
 func (cs *CSDLinkList[T]) FindLastIndex(f func(T) bool) int {
-	cs.mu.RLock()
+	cs.rlock("FindLastIndex")
 	defer cs.mu.RUnlock()
 	return cs.l.FindLastIndex(f)
 }
 
 // FindIndex returns the index of the first node that satisfies the given function.
 func (cs *CSDLinkList[T]) FindIndex(f func(T) bool) int {
-	cs.mu.RLock()
+	cs.rlock("FindIndex")
 	defer cs.mu.RUnlock()
 	return cs.l.FindIndex(f)
 }
+
+// SplitAt splits the list in two lists with the nodes in [0, index) and [index, size), the list is left empty.
+func (cs *CSDLinkList[T]) SplitAt(index uint64) (*CSDLinkList[T], *CSDLinkList[T], error) {
+	cs.lock("SplitAt")
+	defer cs.unlock()
+	left, right, err := cs.l.SplitAt(index)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &CSDLinkList[T]{l: left}, &CSDLinkList[T]{l: right}, nil
+}
+
+// Splice moves all the nodes of the other list into this one starting at the given index, the other list is left empty.
+func (cs *CSDLinkList[T]) Splice(index uint64, list *CSDLinkList[T]) error {
+	if list == cs {
+		return ErrSameList
+	}
+	cs.lock("Splice")
+	defer cs.unlock()
+	list.lock("Splice")
+	defer list.unlock()
+	return cs.l.Splice(index, list.l)
+}
+
+// OnInsert registers a function called after an element is added to the list.
+// The hooks are called with the lock held, so they must not call methods of cs.
+func (cs *CSDLinkList[T]) OnInsert(f func(index uint64, v T)) {
+	cs.lock("OnInsert")
+	defer cs.unlock()
+	cs.l.OnInsert(f)
+}
+
+// OnRemove registers a function called after an element is removed from the list.
+func (cs *CSDLinkList[T]) OnRemove(f func(index uint64, v T)) {
+	cs.lock("OnRemove")
+	defer cs.unlock()
+	cs.l.OnRemove(f)
+}
+
+// OnClear registers a function called after all the elements are removed at once from the list.
+func (cs *CSDLinkList[T]) OnClear(f func()) {
+	cs.lock("OnClear")
+	defer cs.unlock()
+	cs.l.OnClear(f)
+}
+
+// WithLock runs fn while holding the write lock, so compound operations (check-then-insert,
+// find-then-delete, ...) on the underlying list are atomic.
+// fn must not call any CSDLinkList method (it would deadlock) nor keep a reference to the list.
+func (cs *CSDLinkList[T]) WithLock(fn func(l *dlinkList.DLinkList[T])) {
+	cs.lock("WithLock")
+	defer cs.unlock()
+	fn(cs.l)
+}
+
+// WithRLock runs fn while holding the read lock, fn must not modify the list.
+func (cs *CSDLinkList[T]) WithRLock(fn func(l *dlinkList.DLinkList[T])) {
+	cs.rlock("WithRLock")
+	defer cs.mu.RUnlock()
+	fn(cs.l)
+}
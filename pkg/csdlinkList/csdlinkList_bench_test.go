@@ -0,0 +1,95 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csdlinkList_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	csdlinkList "github.com/pzaino/gods/pkg/csdlinkList"
+)
+
+// newBenchList returns a list holding values
+func newBenchList(values []int) *csdlinkList.CSDLinkList[int] {
+	list := csdlinkList.New[int]()
+	for _, v := range values {
+		list.Append(v)
+	}
+	return list
+}
+
+func BenchmarkAppend(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		for i := 0; i < b.N; i++ {
+			list := csdlinkList.New[int]()
+			for v := 0; v < n; v++ {
+				list.Append(v)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkInsert inserts an element in the middle of n elements (and deletes
+// the first one, so the size stays n)
+func BenchmarkInsert(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		list := newBenchList(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = list.InsertAt(uint64(n/2), i)
+			_ = list.DeleteAt(0)
+		}
+	})
+}
+
+// BenchmarkDelete deletes the element in the middle of n elements (and
+// appends one, so the size stays n)
+func BenchmarkDelete(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		list := newBenchList(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = list.DeleteAt(uint64(n / 2))
+			list.Append(i)
+		}
+	})
+}
+
+// BenchmarkFind looks for the value in the middle of n elements
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		target := values[n/2]
+		list := newBenchList(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = list.Find(target)
+		}
+	})
+}
+
+// BenchmarkSort sorts n shuffled elements
+func BenchmarkSort(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			list := newBenchList(values)
+			b.StartTimer()
+			list.Sort(func(x, y int) bool { return x < y })
+		}
+	})
+}
@@ -16,14 +16,24 @@
 package csdlinkList_test
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 
+	ctxtest "github.com/pzaino/gods/internal/ctxtest"
+	common "github.com/pzaino/gods/pkg/common"
 	csdlinkList "github.com/pzaino/gods/pkg/csdlinkList"
+	dlinkList "github.com/pzaino/gods/pkg/dlinkList"
 )
 
 const (
 	errExpectedNoError = "expected no error, got %v"
+	errExpectedSizeX   = "expected size %d, got %d"
 )
 
 func runConcurrent(_ *testing.T, n int, fn func(j int)) {
@@ -58,6 +68,38 @@ func TestCSDLinkListPrepend(t *testing.T) {
 	}
 }
 
+func TestCSDLinkListAppendNPrependNDeleteN(t *testing.T) {
+	cs := csdlinkList.New[int]()
+	runConcurrent(t, 10, func(j int) {
+		values := make([]int, 100)
+		for i := range values {
+			values[i] = j
+		}
+		if j%2 == 0 {
+			cs.AppendN(values...)
+		} else {
+			cs.PrependN(values...)
+		}
+	})
+	if cs.Size() != 1000 {
+		t.Fatalf(errExpectedSizeX, 1000, cs.Size())
+	}
+
+	// The batches are not interleaved
+	values := cs.ToSlice()
+	for i := 0; i < len(values); i += 100 {
+		for _, v := range values[i : i+100] {
+			if v != values[i] {
+				t.Fatalf("expected the batch at %d to hold only %d, got %v", i, values[i], values[i:i+100])
+			}
+		}
+	}
+
+	if n := cs.DeleteN(0, 0, 1); n != 3 || cs.Size() != 997 {
+		t.Errorf("expected 3 nodes deleted, got %d (size %d)", n, cs.Size())
+	}
+}
+
 func TestCSDLinkListInsert(t *testing.T) {
 	cs := csdlinkList.New[int]()
 	runConcurrent(t, 1000, func(_ int) {
@@ -656,3 +698,292 @@ func TestCSDLinkListRemove(t *testing.T) {
 		t.Fatalf("expected value 500 to be removed")
 	}
 }
+
+func TestCSDLinkListIsSorted(t *testing.T) {
+	cs := csdlinkList.New[int]()
+	for i := 100; i > 0; i-- {
+		cs.Append(i)
+	}
+	less := func(a, b int) bool { return a < b }
+	if cs.IsSorted(less) {
+		t.Fatalf("expected list not to be sorted")
+	}
+	cs.Sort(less)
+	runConcurrent(t, 100, func(_ int) {
+		if !cs.IsSorted(less) {
+			t.Errorf("expected list to be sorted")
+		}
+	})
+}
+
+func TestCSDLinkListSplitAtAndSplice(t *testing.T) {
+	cs := csdlinkList.New[int]()
+	for i := 1; i <= 4; i++ {
+		cs.Append(i)
+	}
+	left, right, err := cs.SplitAt(2)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if left.Size() != 2 || right.Size() != 2 || cs.Size() != 0 {
+		t.Fatalf("expected sizes 2, 2 and 0, got %d, %d and %d", left.Size(), right.Size(), cs.Size())
+	}
+
+	runConcurrent(t, 10, func(j int) {
+		other := csdlinkList.New[int]()
+		other.Append(j)
+		if err := left.Splice(left.Size()/2, other); err != nil {
+			t.Errorf(errExpectedNoError, err)
+		}
+	})
+	if left.Size() != 12 {
+		t.Fatalf("expected size 12, got %d", left.Size())
+	}
+	if err := left.Splice(0, left); err != csdlinkList.ErrSameList {
+		t.Fatalf("expected ErrSameList, got %v", err)
+	}
+	if err := left.Splice(0, right); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if left.Size() != 14 || right.Size() != 0 {
+		t.Fatalf("expected sizes 14 and 0, got %d and %d", left.Size(), right.Size())
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	cs := csdlinkList.New[int]()
+
+	// Concurrent check-then-insert must not add duplicates
+	runConcurrent(t, 1000, func(j int) {
+		cs.WithLock(func(l *dlinkList.DLinkList[int]) {
+			if !l.Contains(j % 10) {
+				l.Append(j % 10)
+			}
+		})
+	})
+	if cs.Size() != 10 {
+		t.Errorf("expected size 10, got %d", cs.Size())
+	}
+
+	var sum int
+	cs.WithRLock(func(l *dlinkList.DLinkList[int]) {
+		l.ForEach(func(v *int) { sum += *v })
+	})
+	if sum != 45 {
+		t.Errorf("expected sum 45, got %d", sum)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := csdlinkList.New[*int]()
+	c.Append(&a)
+	c.Append(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestStats(t *testing.T) {
+	cs := csdlinkList.New[int]()
+	cs.Append(1)
+	if st := cs.Stats(); st.Reads != 0 || st.Writes != 0 || st.PeakSize != 0 {
+		t.Errorf("expected no statistics while disabled, got %+v", st)
+	}
+
+	cs.EnableStats(true)
+	cs.Append(2)
+	cs.Append(3)
+	cs.DeleteWithValue(1)
+	_ = cs.Size()
+	st := cs.Stats()
+	if st.Writes != 3 || st.Reads != 1 || st.PeakSize != 3 {
+		t.Errorf("expected 3 writes, 1 read and peak size 3, got %+v", st)
+	}
+	if want := map[string]uint64{"Append": 2, "DeleteWithValue": 1, "Size": 1}; !reflect.DeepEqual(st.Ops, want) {
+		t.Errorf("expected the operations %v, got %v", want, st.Ops)
+	}
+
+	cs.EnableStats(false)
+	cs.Append(4)
+	if !reflect.DeepEqual(cs.Stats(), st) {
+		t.Errorf("expected the statistics to stop changing once disabled, got %+v", cs.Stats())
+	}
+}
+
+func TestMoveNodes(t *testing.T) {
+	cs := csdlinkList.New[int]()
+	nodes := make([]*dlinkList.Node[int], 10)
+	for i := range nodes {
+		cs.Append(i)
+		nodes[i], _ = cs.GetAt(uint64(i))
+	}
+
+	runConcurrent(t, 1000, func(j int) {
+		var err error
+		switch j % 3 {
+		case 0:
+			err = cs.MoveToFront(nodes[j%10])
+		case 1:
+			err = cs.MoveToBack(nodes[j%10])
+		default:
+			err = cs.SwapNodes(nodes[j%10], nodes[(j+3)%10])
+		}
+		if err != nil {
+			t.Errorf(errExpectedNoError, err)
+		}
+	})
+
+	cs.WithRLock(func(l *dlinkList.DLinkList[int]) {
+		if err := l.CheckInvariants(); err != nil {
+			t.Errorf(errExpectedNoError, err)
+		}
+	})
+	values := cs.ToSlice()
+	slices.Sort(values)
+	if !slices.Equal(values, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Errorf("expected the same values, got %v", values)
+	}
+	if err := cs.MoveToFront(nil); !errors.Is(err, csdlinkList.ErrNotInList) {
+		t.Errorf("expected %v, got %v", csdlinkList.ErrNotInList, err)
+	}
+}
+
+func TestCtxVariants(t *testing.T) {
+	ctxtest.Run[*csdlinkList.CSDLinkList[int], *dlinkList.Node[int]](t, csdlinkList.New[int], csdlinkList.ErrNotFound)
+}
+
+func TestInsertSorted(t *testing.T) {
+	cs := csdlinkList.New[int]()
+	less := func(a, b int) bool { return a < b }
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				cs.InsertSorted((i*7+g)%100, less)
+			}
+		}(g)
+	}
+	wg.Wait()
+	if cs.Size() != 200 || !cs.IsSorted(less) {
+		t.Errorf("expected 200 sorted nodes, got %d (sorted: %v)", cs.Size(), cs.IsSorted(less))
+	}
+	if i := cs.SearchSorted(-1, less); i != 0 {
+		t.Errorf("expected 0, got %d", i)
+	}
+}
+
+func TestCSDLinkListNewWithHasher(t *testing.T) {
+	hasher := common.NewHasher(func(v []int) uint64 { return uint64(len(v)) }, slices.Equal[[]int])
+	cs := csdlinkList.NewWithHasher(hasher)
+	cs.Append([]int{1, 2})
+	cs.Append([]int{3})
+
+	if !cs.Contains([]int{3}) || cs.Contains([]int{2, 1}) {
+		t.Error("expected the hasher equality to be used")
+	}
+	if !csdlinkList.Features().Has(common.FeatureHasher) {
+		t.Errorf("expected the %s feature", common.FeatureHasher)
+	}
+}
+
+func TestCSDLinkListToSliceRangeAndCopyRange(t *testing.T) {
+	cs := csdlinkList.New[int]()
+	for _, v := range []int{1, 2, 3, 4} {
+		cs.Append(v)
+	}
+
+	if result := cs.ToSliceRange(1, 3); !slices.Equal(result, []int{2, 3}) {
+		t.Errorf("expected [2 3], got %v", result)
+	}
+	if c := cs.CopyRange(2, 4); !slices.Equal(c.ToSlice(), []int{3, 4}) {
+		t.Errorf("expected [3 4], got %v", c.ToSlice())
+	}
+}
+
+func TestJSONStream(t *testing.T) {
+	if !csdlinkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, csdlinkList.Features())
+	}
+	if !csdlinkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, csdlinkList.Features())
+	}
+	l := csdlinkList.New[int]()
+	if err := l.DecodeJSONStream(json.NewDecoder(strings.NewReader("[1, 2, 3]"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var sb strings.Builder
+	if err := l.EncodeJSONStream(&sb); err != nil || sb.String() != "[1,2,3]" {
+		t.Errorf("expected [1,2,3], got %s (%v)", sb.String(), err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *csdlinkList.CSDLinkList[int] {
+		l := csdlinkList.New[int]()
+		for _, v := range values {
+			l.Append(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	l := build(1, 2)
+	if got := l.Compare(l, cmp.Compare[int]); got != 0 {
+		t.Errorf("expected a list to be equal to itself, got %d", got)
+	}
+}
+
+func TestCompareBothWays(t *testing.T) {
+	a, b := csdlinkList.New[int](), csdlinkList.New[int]()
+	var wg sync.WaitGroup
+	for _, pair := range [][2]*csdlinkList.CSDLinkList[int]{{a, b}, {b, a}} {
+		wg.Add(2)
+		go func(x, y *csdlinkList.CSDLinkList[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_ = x.Compare(y, cmp.Compare[int])
+			}
+		}(pair[0], pair[1])
+		go func(x *csdlinkList.CSDLinkList[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				x.Append(i)
+			}
+		}(pair[0])
+	}
+	wg.Wait()
+}
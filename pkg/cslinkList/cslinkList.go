@@ -16,15 +16,36 @@
 package cslinkList
 
 import (
+	"context"
+	"encoding/json"
+	"io"
 	"sync"
 
+	common "github.com/pzaino/gods/pkg/common"
 	linkList "github.com/pzaino/gods/pkg/linkList"
 )
 
+// Sentinel errors returned by the CSLinkList methods, they are the same
+// returned by the linkList package (use errors.Is to check for them).
+var (
+	ErrOutOfBounds  = linkList.ErrOutOfBounds
+	ErrNotFound     = linkList.ErrNotFound
+	ErrInvalidRange = linkList.ErrInvalidRange
+	ErrSameList     = linkList.ErrSameList
+	ErrCorrupted    = linkList.ErrCorrupted
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureHasher, common.FeatureConcurrent, common.FeatureDeepCopy, common.FeatureJSON)
+}
+
 // CSLinkList is a concurrency-safe linked list.
-type CSLinkList[T comparable] struct {
-	mu sync.RWMutex
-	l  *linkList.LinkList[T]
+type CSLinkList[T any] struct {
+	mu    sync.RWMutex
+	stats common.LockStats // opt-in statistics, see EnableStats
+	l     *linkList.LinkList[T]
 }
 
 // New creates a new concurrency-safe linked list.
@@ -32,6 +53,16 @@ func New[T comparable]() *CSLinkList[T] {
 	return &CSLinkList[T]{l: linkList.New[T]()}
 }
 
+// NewWithComparator creates a new concurrency-safe linked list that compares its elements with the given function.
+func NewWithComparator[T any](equals func(a, b T) bool) *CSLinkList[T] {
+	return &CSLinkList[T]{l: linkList.NewWithComparator(equals)}
+}
+
+// NewWithHasher creates a new concurrency-safe linked list that compares its elements with the given hasher (see linkList.NewWithHasher).
+func NewWithHasher[T any](hasher common.Hasher[T]) *CSLinkList[T] {
+	return &CSLinkList[T]{l: linkList.NewWithHasher(hasher)}
+}
+
 // NewFromSlice creates a new concurrency-safe linked list from a slice.
 func NewFromSlice[T comparable](items []T) *CSLinkList[T] {
 	cs := New[T]()
@@ -39,142 +70,331 @@ func NewFromSlice[T comparable](items []T) *CSLinkList[T] {
 	return cs
 }
 
+// lock takes the write lock for the method op, collecting the statistics when
+// enabled.
+func (cs *CSLinkList[T]) lock(op string) {
+	cs.stats.Lock(&cs.mu, op)
+}
+
+// rlock takes the read lock for the method op, collecting the statistics when
+// enabled.
+func (cs *CSLinkList[T]) rlock(op string) {
+	cs.stats.RLock(&cs.mu, op)
+}
+
+// unlock records the size of the list and releases the write lock.
+func (cs *CSLinkList[T]) unlock() {
+	cs.stats.Observe(cs.l.Size())
+	cs.mu.Unlock()
+}
+
+// EnableStats turns on or off the collection of the statistics returned by
+// Stats, enabling it resets them. When disabled the overhead is an atomic load
+// per operation.
+func (cs *CSLinkList[T]) EnableStats(on bool) {
+	cs.stats.Enable(on)
+}
+
+// Stats returns the statistics collected since they were enabled: the
+// operations by type (read or write), the peak size and the waits for the lock.
+func (cs *CSLinkList[T]) Stats() common.Stats {
+	return cs.stats.Snapshot()
+}
+
 // Append adds a new node to the end of the list.
 func (cs *CSLinkList[T]) Append(value T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Append")
+	defer cs.unlock()
 	cs.l.Append(value)
 }
 
 // Prepend adds a new node to the beginning of the list.
 func (cs *CSLinkList[T]) Prepend(value T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Prepend")
+	defer cs.unlock()
 	cs.l.Prepend(value)
 }
 
+// AppendN adds new nodes with the given values to the end of the list,
+// taking the lock once for the whole batch.
+func (cs *CSLinkList[T]) AppendN(values ...T) {
+	cs.lock("AppendN")
+	defer cs.unlock()
+	cs.l.AppendN(values...)
+}
+
+// PrependN adds new nodes with the given values to the beginning of the list
+// in the given order, taking the lock once for the whole batch.
+func (cs *CSLinkList[T]) PrependN(values ...T) {
+	cs.lock("PrependN")
+	defer cs.unlock()
+	cs.l.PrependN(values...)
+}
+
 // DeleteWithValue deletes the first node with the given value.
 func (cs *CSLinkList[T]) DeleteWithValue(value T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("DeleteWithValue")
+	defer cs.unlock()
 	cs.l.DeleteWithValue(value)
 }
 
+// DeleteN deletes the first node with each of the given values, taking the
+// lock once for the whole batch, and returns how many nodes were deleted.
+func (cs *CSLinkList[T]) DeleteN(values ...T) uint64 {
+	cs.lock("DeleteN")
+	defer cs.unlock()
+	return cs.l.DeleteN(values...)
+}
+
 // ToSlice returns the list as a slice.
 func (cs *CSLinkList[T]) ToSlice() []T {
-	cs.mu.RLock()
+	cs.rlock("ToSlice")
 	defer cs.mu.RUnlock()
 	return cs.l.ToSlice()
 }
 
+// ToSliceRange converts the elements in the range [start, end) of the list to a slice.
+func (cs *CSLinkList[T]) ToSliceRange(start, end uint64) []T {
+	cs.rlock("ToSliceRange")
+	defer cs.mu.RUnlock()
+	return cs.l.ToSliceRange(start, end)
+}
+
+// String returns a string representation of the list (elements are formatted with %v).
+func (cs *CSLinkList[T]) String() string {
+	return cs.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the list with every element formatted by f.
+func (cs *CSLinkList[T]) StringFunc(f func(T) string) string {
+	cs.rlock("StringFunc")
+	defer cs.mu.RUnlock()
+	return cs.l.StringFunc(f)
+}
+
+// EncodeJSONStream writes the elements of the list to w as a JSON array, holding
+// the read lock while writing.
+func (cs *CSLinkList[T]) EncodeJSONStream(w io.Writer) error {
+	cs.rlock("EncodeJSONStream")
+	defer cs.mu.RUnlock()
+	return cs.l.EncodeJSONStream(w)
+}
+
+// DecodeJSONStream appends the elements of the JSON array read from dec, holding
+// the write lock while reading.
+func (cs *CSLinkList[T]) DecodeJSONStream(dec *json.Decoder) error {
+	cs.lock("DecodeJSONStream")
+	defer cs.unlock()
+	return cs.l.DecodeJSONStream(dec)
+}
+
 // IsEmpty checks if the list is empty.
 func (cs *CSLinkList[T]) IsEmpty() bool {
-	cs.mu.RLock()
+	cs.rlock("IsEmpty")
 	defer cs.mu.RUnlock()
 	return cs.l.IsEmpty()
 }
 
 // Find returns the first node with the given value.
 func (cs *CSLinkList[T]) Find(value T) (*linkList.Node[T], error) {
-	cs.mu.RLock()
+	cs.rlock("Find")
 	defer cs.mu.RUnlock()
 	return cs.l.Find(value)
 }
 
+// FindCtx returns the first node with the given value, it stops with the error
+// of ctx when it's done (see linkList.FindCtx).
+func (cs *CSLinkList[T]) FindCtx(ctx context.Context, value T) (*linkList.Node[T], error) {
+	cs.rlock("FindCtx")
+	defer cs.mu.RUnlock()
+	return cs.l.FindCtx(ctx, value)
+}
+
 // Reverse reverses the list.
 func (cs *CSLinkList[T]) Reverse() {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Reverse")
+	defer cs.unlock()
 	cs.l.Reverse()
 }
 
+// RotateLeft rotates all the elements to the left by n positions.
+func (cs *CSLinkList[T]) RotateLeft(n uint64) {
+	cs.lock("RotateLeft")
+	defer cs.unlock()
+	cs.l.RotateLeft(n)
+}
+
+// RotateRight rotates all the elements to the right by n positions.
+func (cs *CSLinkList[T]) RotateRight(n uint64) {
+	cs.lock("RotateRight")
+	defer cs.unlock()
+	cs.l.RotateRight(n)
+}
+
 // Size returns the number of nodes in the list.
 func (cs *CSLinkList[T]) Size() uint64 {
-	cs.mu.RLock()
+	cs.rlock("Size")
 	defer cs.mu.RUnlock()
 	return cs.l.Size()
 }
 
+// CheckSize recalculates the size of the list.
+func (cs *CSLinkList[T]) CheckSize() {
+	cs.lock("CheckSize")
+	defer cs.unlock()
+	cs.l.CheckSize()
+}
+
+// CheckInvariants verifies the internal consistency of the list, it returns
+// an error wrapping ErrCorrupted describing the first violation found.
+func (cs *CSLinkList[T]) CheckInvariants() error {
+	cs.rlock("CheckInvariants")
+	defer cs.mu.RUnlock()
+	return cs.l.CheckInvariants()
+}
+
 // GetFirst returns the first node in the list.
 func (cs *CSLinkList[T]) GetFirst() *linkList.Node[T] {
-	cs.mu.RLock()
+	cs.rlock("GetFirst")
 	defer cs.mu.RUnlock()
 	return cs.l.GetFirst()
 }
 
 // GetLast returns the last node in the list.
 func (cs *CSLinkList[T]) GetLast() *linkList.Node[T] {
-	cs.mu.RLock()
+	cs.rlock("GetLast")
 	defer cs.mu.RUnlock()
 	return cs.l.GetLast()
 }
 
 // GetAt returns the node at the given index.
 func (cs *CSLinkList[T]) GetAt(index uint64) (*linkList.Node[T], error) {
-	cs.mu.RLock()
+	cs.rlock("GetAt")
 	defer cs.mu.RUnlock()
 	return cs.l.GetAt(index)
 }
 
 // InsertAt inserts a new node at the given index.
 func (cs *CSLinkList[T]) InsertAt(index uint64, value T) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("InsertAt")
+	defer cs.unlock()
 	return cs.l.InsertAt(index, value)
 }
 
 // DeleteAt deletes the node at the given index.
 func (cs *CSLinkList[T]) DeleteAt(index uint64) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("DeleteAt")
+	defer cs.unlock()
 	return cs.l.DeleteAt(index)
 }
 
 // Remove is just an alias for DeleteWithValue.
 func (cs *CSLinkList[T]) Remove(value T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Remove")
+	defer cs.unlock()
 	cs.l.Remove(value)
 }
 
 // Clear removes all nodes from the list.
 func (cs *CSLinkList[T]) Clear() {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Clear")
+	defer cs.unlock()
 	cs.l.Clear()
 }
 
-// Copy returns a copy of the list.
+// Wipe overwrites the value of every node with the zero value and unlinks the
+// nodes before emptying the list (see linkList.LinkList.Wipe).
+func (cs *CSLinkList[T]) Wipe() {
+	cs.lock("Wipe")
+	defer cs.unlock()
+	cs.l.Wipe()
+}
+
+// Copy returns a copy of the list (a shallow copy, see linkList.LinkList.Copy).
 func (cs *CSLinkList[T]) Copy() *CSLinkList[T] {
-	cs.mu.RLock()
+	cs.rlock("Copy")
 	defer cs.mu.RUnlock()
 	return &CSLinkList[T]{l: cs.l.Copy()}
 }
 
+// CloneWith returns a copy with a copy of every element made by copier, to deep
+// copy elements that hold pointers, slices or maps.
+func (cs *CSLinkList[T]) CloneWith(copier func(T) T) *CSLinkList[T] {
+	cs.rlock("CloneWith")
+	defer cs.mu.RUnlock()
+	return &CSLinkList[T]{l: cs.l.CloneWith(copier)}
+}
+
+// CopyRange returns a copy of the elements in the range [start, end) (see linkList.LinkList.CopyRange).
+func (cs *CSLinkList[T]) CopyRange(start, end uint64) *CSLinkList[T] {
+	cs.rlock("CopyRange")
+	defer cs.mu.RUnlock()
+	return &CSLinkList[T]{l: cs.l.CopyRange(start, end)}
+}
+
+// Chunk splits the elements of the list in consecutive new lists of size elements
+// (see linkList.LinkList.Chunk).
+func (cs *CSLinkList[T]) Chunk(size uint64) []*CSLinkList[T] {
+	cs.rlock("Chunk")
+	defer cs.mu.RUnlock()
+	return wrap(cs.l.Chunk(size))
+}
+
+// Windows returns all the sliding windows of size consecutive elements of the list
+// as new lists (see linkList.LinkList.Windows).
+func (cs *CSLinkList[T]) Windows(size uint64) []*CSLinkList[T] {
+	cs.rlock("Windows")
+	defer cs.mu.RUnlock()
+	return wrap(cs.l.Windows(size))
+}
+
+// wrap returns the given lists as concurrency-safe lists.
+func wrap[T any](lists []*linkList.LinkList[T]) []*CSLinkList[T] {
+	if lists == nil {
+		return nil
+	}
+	result := make([]*CSLinkList[T], len(lists))
+	for i, l := range lists {
+		result[i] = &CSLinkList[T]{l: l}
+	}
+	return result
+}
+
 // Merge appends all the nodes from another list to the current list.
 func (cs *CSLinkList[T]) Merge(list *CSLinkList[T]) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	list.mu.Lock()
-	defer list.mu.Unlock()
+	cs.lock("Merge")
+	defer cs.unlock()
+	list.lock("Merge")
+	defer list.unlock()
 	cs.l.Merge(list.l)
 	list.l.Clear()
 }
 
 // Map generates a new list by applying the function to all the nodes in the list.
 func (cs *CSLinkList[T]) Map(f func(T) T) *CSLinkList[T] {
-	cs.mu.RLock()
+	cs.rlock("Map")
 	defer cs.mu.RUnlock()
 
 	newList := cs.l.Map(f)
-	newCSList := New[T]()
-	newCSList.l = newList
-	return newCSList
+	return &CSLinkList[T]{l: newList}
+}
+
+// MapCtx generates a new list by applying the function to all the nodes in the
+// list, it stops with the error of ctx when it's done (see linkList.MapCtx).
+func (cs *CSLinkList[T]) MapCtx(ctx context.Context, f func(T) T) (*CSLinkList[T], error) {
+	cs.rlock("MapCtx")
+	defer cs.mu.RUnlock()
+
+	newList, err := cs.l.MapCtx(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	return &CSLinkList[T]{l: newList}, nil
 }
 
 // MapFrom generates a new list by applying the function to all the nodes in the list starting from the specified index.
 func (cs *CSLinkList[T]) MapFrom(start uint64, f func(T) T) (*CSLinkList[T], error) {
-	cs.mu.RLock()
+	cs.rlock("MapFrom")
 	defer cs.mu.RUnlock()
 
 	newList, err := cs.l.MapFrom(start, f)
@@ -182,14 +402,12 @@ func (cs *CSLinkList[T]) MapFrom(start uint64, f func(T) T) (*CSLinkList[T], err
 		return nil, err
 	}
 
-	newCSList := New[T]()
-	newCSList.l = newList
-	return newCSList, nil
+	return &CSLinkList[T]{l: newList}, nil
 }
 
 // MapRange generates a new list by applying the function to all the nodes in the list in the range [start, end).
 func (cs *CSLinkList[T]) MapRange(start, end uint64, f func(T) T) (*CSLinkList[T], error) {
-	cs.mu.RLock()
+	cs.rlock("MapRange")
 	defer cs.mu.RUnlock()
 
 	newList, err := cs.l.MapRange(start, end, f)
@@ -197,112 +415,271 @@ func (cs *CSLinkList[T]) MapRange(start, end uint64, f func(T) T) (*CSLinkList[T
 		return nil, err
 	}
 
-	newCSList := New[T]()
-	newCSList.l = newList
-	return newCSList, nil
+	return &CSLinkList[T]{l: newList}, nil
+}
+
+// MapIndexed generates a new list by applying the function to all the elements in the list and their index.
+func (cs *CSLinkList[T]) MapIndexed(f func(uint64, T) T) *CSLinkList[T] {
+	cs.rlock("MapIndexed")
+	defer cs.mu.RUnlock()
+	return &CSLinkList[T]{l: cs.l.MapIndexed(f)}
 }
 
 // Filter removes nodes from the list that don't match the predicate.
 func (cs *CSLinkList[T]) Filter(f func(T) bool) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Filter")
+	defer cs.unlock()
 	cs.l.Filter(f)
 }
 
+// RemoveIf removes the nodes that match the predicate and returns how many were removed.
+func (cs *CSLinkList[T]) RemoveIf(predicate func(T) bool) uint64 {
+	cs.lock("RemoveIf")
+	defer cs.unlock()
+	return cs.l.RemoveIf(predicate)
+}
+
+// RemoveAll removes all the nodes equal to value and returns how many were removed.
+func (cs *CSLinkList[T]) RemoveAll(value T) uint64 {
+	cs.lock("RemoveAll")
+	defer cs.unlock()
+	return cs.l.RemoveAll(value)
+}
+
+// RemoveFirstN removes the first n nodes equal to value and returns how many were removed.
+func (cs *CSLinkList[T]) RemoveFirstN(value T, n uint64) uint64 {
+	cs.lock("RemoveFirstN")
+	defer cs.unlock()
+	return cs.l.RemoveFirstN(value, n)
+}
+
+// Unique removes consecutive duplicate elements, keeping the first node of each run.
+func (cs *CSLinkList[T]) Unique() {
+	cs.lock("Unique")
+	defer cs.unlock()
+	cs.l.Unique()
+}
+
+// Deduplicate removes all duplicate elements, keeping the first occurrence of each one.
+func (cs *CSLinkList[T]) Deduplicate() {
+	cs.lock("Deduplicate")
+	defer cs.unlock()
+	cs.l.Deduplicate()
+}
+
 // Reduce reduces the list to a single value.
 func (cs *CSLinkList[T]) Reduce(f func(T, T) T, initial T) T {
-	cs.mu.RLock()
+	cs.rlock("Reduce")
 	defer cs.mu.RUnlock()
 	return cs.l.Reduce(f, initial)
 }
 
 // ForEach applies the function to all the nodes in the list.
 func (cs *CSLinkList[T]) ForEach(f func(*T)) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForEach")
+	defer cs.unlock()
 	cs.l.ForEach(f)
 }
 
+// ForEachCtx applies the function to all the nodes in the list, it stops with
+// the error of ctx when it's done (see linkList.ForEachCtx).
+func (cs *CSLinkList[T]) ForEachCtx(ctx context.Context, f func(*T)) error {
+	cs.lock("ForEachCtx")
+	defer cs.unlock()
+	return cs.l.ForEachCtx(ctx, f)
+}
+
+// ForEachErr applies the function to all the nodes in the list, stopping at the first error returned by the function.
+func (cs *CSLinkList[T]) ForEachErr(f func(*T) error) error {
+	cs.lock("ForEachErr")
+	defer cs.unlock()
+	return cs.l.ForEachErr(f)
+}
+
+// ForEachIndexed applies the function to all the elements in the list and their index.
+func (cs *CSLinkList[T]) ForEachIndexed(f func(uint64, *T)) {
+	cs.lock("ForEachIndexed")
+	defer cs.unlock()
+	cs.l.ForEachIndexed(f)
+}
+
+// ForEachSafe calls fn for every element and removes the nodes for which it
+// returns true (see linkList.LinkList.ForEachSafe), fn must not use the list.
+func (cs *CSLinkList[T]) ForEachSafe(fn func(v T) (remove bool)) {
+	cs.lock("ForEachSafe")
+	defer cs.unlock()
+	cs.l.ForEachSafe(fn)
+}
+
 // ForRange applies the function to all the nodes in the list in the range [start, end).
 func (cs *CSLinkList[T]) ForRange(start, end uint64, f func(*T)) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForRange")
+	defer cs.unlock()
 	return cs.l.ForRange(start, end, f)
 }
 
 // ForFrom applies the function to all the nodes in the list starting from the index.
 func (cs *CSLinkList[T]) ForFrom(start uint64, f func(*T)) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForFrom")
+	defer cs.unlock()
 	return cs.l.ForFrom(start, f)
 }
 
 // Any checks if any node in the list matches the predicate.
 func (cs *CSLinkList[T]) Any(f func(T) bool) bool {
-	cs.mu.RLock()
+	cs.rlock("Any")
 	defer cs.mu.RUnlock()
 	return cs.l.Any(f)
 }
 
 // All checks if all nodes in the list match the predicate.
 func (cs *CSLinkList[T]) All(f func(T) bool) bool {
-	cs.mu.RLock()
+	cs.rlock("All")
 	defer cs.mu.RUnlock()
 	return cs.l.All(f)
 }
 
 // Contains checks if the list contains the given value.
 func (cs *CSLinkList[T]) Contains(value T) bool {
-	cs.mu.RLock()
+	cs.rlock("Contains")
 	defer cs.mu.RUnlock()
 	return cs.l.Contains(value)
 }
 
+// ContainsAll returns true if the list contains all the given elements (true when none is given).
+func (cs *CSLinkList[T]) ContainsAll(values ...T) bool {
+	cs.rlock("ContainsAll")
+	defer cs.mu.RUnlock()
+	return cs.l.ContainsAll(values...)
+}
+
+// ContainsAny returns true if the list contains at least one of the given elements.
+func (cs *CSLinkList[T]) ContainsAny(values ...T) bool {
+	cs.rlock("ContainsAny")
+	defer cs.mu.RUnlock()
+	return cs.l.ContainsAny(values...)
+}
+
+// CountOf returns the number of elements in the list equal to the given value.
+func (cs *CSLinkList[T]) CountOf(value T) uint64 {
+	cs.rlock("CountOf")
+	defer cs.mu.RUnlock()
+	return cs.l.CountOf(value)
+}
+
 // IndexOf returns the index of the first node with the given value.
 func (cs *CSLinkList[T]) IndexOf(value T) (uint64, error) {
-	cs.mu.RLock()
+	cs.rlock("IndexOf")
 	defer cs.mu.RUnlock()
 	return cs.l.IndexOf(value)
 }
 
 // LastIndexOf returns the index of the last node with the given value.
 func (cs *CSLinkList[T]) LastIndexOf(value T) (uint64, error) {
-	cs.mu.RLock()
+	cs.rlock("LastIndexOf")
 	defer cs.mu.RUnlock()
 	return cs.l.LastIndexOf(value)
 }
 
 // FindIndex returns the index of the first node that matches the predicate.
 func (cs *CSLinkList[T]) FindIndex(f func(T) bool) (uint64, error) {
-	cs.mu.RLock()
+	cs.rlock("FindIndex")
 	defer cs.mu.RUnlock()
 	return cs.l.FindIndex(f)
 }
 
 // FindLastIndex returns the index of the last node that matches the predicate.
 func (cs *CSLinkList[T]) FindLastIndex(f func(T) bool) (uint64, error) {
-	cs.mu.RLock()
+	cs.rlock("FindLastIndex")
 	defer cs.mu.RUnlock()
 	return cs.l.FindLastIndex(f)
 }
 
 // FindAll returns all nodes that match the predicate.
 func (cs *CSLinkList[T]) FindAll(f func(T) bool) *CSLinkList[T] {
-	cs.mu.RLock()
+	cs.rlock("FindAll")
 	defer cs.mu.RUnlock()
 	return &CSLinkList[T]{l: cs.l.FindAll(f)}
 }
 
 // FindLast returns the last node that matches the predicate.
 func (cs *CSLinkList[T]) FindLast(f func(T) bool) (*linkList.Node[T], error) {
-	cs.mu.RLock()
+	cs.rlock("FindLast")
 	defer cs.mu.RUnlock()
 	return cs.l.FindLast(f)
 }
 
 // FindAllIndexes returns the indexes of all nodes that match the predicate.
 func (cs *CSLinkList[T]) FindAllIndexes(f func(T) bool) []uint64 {
-	cs.mu.RLock()
+	cs.rlock("FindAllIndexes")
 	defer cs.mu.RUnlock()
 	return cs.l.FindAllIndexes(f)
 }
+
+// Sort sorts the list according to the given function (using a stable merge sort).
+func (cs *CSLinkList[T]) Sort(less func(T, T) bool) {
+	cs.lock("Sort")
+	defer cs.unlock()
+	cs.l.Sort(less)
+}
+
+// IsSorted returns true if the list is sorted according to the given function.
+func (cs *CSLinkList[T]) IsSorted(less func(T, T) bool) bool {
+	cs.rlock("IsSorted")
+	defer cs.mu.RUnlock()
+	return cs.l.IsSorted(less)
+}
+
+// Compare compares the list with other lexicographically (see
+// linkList.LinkList.Compare). It works on a copy of other, so the locks of the
+// two lists are never held together.
+func (cs *CSLinkList[T]) Compare(other *CSLinkList[T], compare func(a, b T) int) int {
+	if other == cs {
+		return 0
+	}
+	other.rlock("Compare")
+	theirs := other.l.Copy()
+	other.mu.RUnlock()
+	cs.rlock("Compare")
+	defer cs.mu.RUnlock()
+	return cs.l.Compare(theirs, compare)
+}
+
+// SplitAt splits the list in two lists with the nodes in [0, index) and [index, size), the list is left empty.
+func (cs *CSLinkList[T]) SplitAt(index uint64) (*CSLinkList[T], *CSLinkList[T], error) {
+	cs.lock("SplitAt")
+	defer cs.unlock()
+	left, right, err := cs.l.SplitAt(index)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &CSLinkList[T]{l: left}, &CSLinkList[T]{l: right}, nil
+}
+
+// Splice moves all the nodes of the other list into this one starting at the given index, the other list is left empty.
+func (cs *CSLinkList[T]) Splice(index uint64, list *CSLinkList[T]) error {
+	if list == cs {
+		return ErrSameList
+	}
+	cs.lock("Splice")
+	defer cs.unlock()
+	list.lock("Splice")
+	defer list.unlock()
+	return cs.l.Splice(index, list.l)
+}
+
+// WithLock runs fn while holding the write lock, so compound operations (check-then-insert,
+// find-then-delete, ...) on the underlying list are atomic.
+// fn must not call any CSLinkList method (it would deadlock) nor keep a reference to the list.
+func (cs *CSLinkList[T]) WithLock(fn func(l *linkList.LinkList[T])) {
+	cs.lock("WithLock")
+	defer cs.unlock()
+	fn(cs.l)
+}
+
+// WithRLock runs fn while holding the read lock, fn must not modify the list.
+func (cs *CSLinkList[T]) WithRLock(fn func(l *linkList.LinkList[T])) {
+	cs.rlock("WithRLock")
+	defer cs.mu.RUnlock()
+	fn(cs.l)
+}
@@ -16,10 +16,18 @@
 package cslinkList_test
 
 import (
+	"cmp"
+	"encoding/json"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
 	"testing"
 
+	ctxtest "github.com/pzaino/gods/internal/ctxtest"
+	common "github.com/pzaino/gods/pkg/common"
 	cslinkList "github.com/pzaino/gods/pkg/cslinkList"
+	linkList "github.com/pzaino/gods/pkg/linkList"
 )
 
 const (
@@ -66,6 +74,38 @@ func TestCSLinkListPrepend(t *testing.T) {
 	}
 }
 
+func TestCSLinkListAppendNPrependNDeleteN(t *testing.T) {
+	cs := cslinkList.New[int]()
+	runConcurrent(t, 10, func(j int) {
+		values := make([]int, 100)
+		for i := range values {
+			values[i] = j
+		}
+		if j%2 == 0 {
+			cs.AppendN(values...)
+		} else {
+			cs.PrependN(values...)
+		}
+	})
+	if cs.Size() != 1000 {
+		t.Fatalf(errExpectedSizeX, 1000, cs.Size())
+	}
+
+	// The batches are not interleaved
+	values := cs.ToSlice()
+	for i := 0; i < len(values); i += 100 {
+		for _, v := range values[i : i+100] {
+			if v != values[i] {
+				t.Fatalf("expected the batch at %d to hold only %d, got %v", i, values[i], values[i:i+100])
+			}
+		}
+	}
+
+	if n := cs.DeleteN(0, 0, 1); n != 3 || cs.Size() != 997 {
+		t.Errorf("expected 3 nodes deleted, got %d (size %d)", n, cs.Size())
+	}
+}
+
 func TestCSLinkListDeleteWithValue(t *testing.T) {
 	cs := cslinkList.New[int]()
 	for i := 0; i < 1000; i++ {
@@ -478,3 +518,265 @@ func TestCSLinkListMapRange(t *testing.T) {
 		}
 	})
 }
+
+func TestCSLinkListSort(t *testing.T) {
+	cs := cslinkList.NewFromSlice([]int{5, 4, 3, 2, 1})
+	less := func(a, b int) bool { return a < b }
+	runConcurrent(t, 100, func(_ int) {
+		cs.Sort(less)
+		cs.IsSorted(less)
+	})
+	if !cs.IsSorted(less) {
+		t.Fatalf("expected list to be sorted")
+	}
+}
+
+func TestCSLinkListSplitAtAndSplice(t *testing.T) {
+	cs := cslinkList.NewFromSlice([]int{1, 2, 3, 4})
+	left, right, err := cs.SplitAt(2)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if left.Size() != 2 || right.Size() != 2 || cs.Size() != 0 {
+		t.Fatalf(errExpectedSizeX, 2, left.Size())
+	}
+
+	runConcurrent(t, 10, func(j int) {
+		other := cslinkList.NewFromSlice([]int{j})
+		if err := left.Splice(0, other); err != nil {
+			t.Errorf(errExpectedNoError, err)
+		}
+	})
+	if left.Size() != 12 {
+		t.Fatalf(errExpectedSizeX, 12, left.Size())
+	}
+	if err := left.Splice(0, left); err != cslinkList.ErrSameList {
+		t.Fatalf("expected ErrSameList, got %v", err)
+	}
+	if _, _, err := left.SplitAt(13); err == nil {
+		t.Fatalf("expected an error, got nil")
+	}
+}
+
+func TestWithLock(t *testing.T) {
+	cs := cslinkList.New[int]()
+
+	// Concurrent check-then-insert must not add duplicates
+	runConcurrent(t, 1000, func(j int) {
+		cs.WithLock(func(l *linkList.LinkList[int]) {
+			if !l.Contains(j % 10) {
+				l.Append(j % 10)
+			}
+		})
+	})
+	if cs.Size() != 10 {
+		t.Errorf("expected size 10, got %d", cs.Size())
+	}
+
+	var size uint64
+	cs.WithRLock(func(l *linkList.LinkList[int]) {
+		size = l.Size()
+	})
+	if size != 10 {
+		t.Errorf("expected size 10, got %d", size)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := cslinkList.New[*int]()
+	c.Append(&a)
+	c.Append(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestStats(t *testing.T) {
+	cs := cslinkList.New[int]()
+	cs.Append(1)
+	if st := cs.Stats(); st.Reads != 0 || st.Writes != 0 || st.PeakSize != 0 {
+		t.Errorf("expected no statistics while disabled, got %+v", st)
+	}
+
+	cs.EnableStats(true)
+	cs.Append(2)
+	cs.Append(3)
+	cs.DeleteWithValue(1)
+	_ = cs.Size()
+	st := cs.Stats()
+	if st.Writes != 3 || st.Reads != 1 || st.PeakSize != 3 {
+		t.Errorf("expected 3 writes, 1 read and peak size 3, got %+v", st)
+	}
+	if want := map[string]uint64{"Append": 2, "DeleteWithValue": 1, "Size": 1}; !reflect.DeepEqual(st.Ops, want) {
+		t.Errorf("expected the operations %v, got %v", want, st.Ops)
+	}
+
+	cs.EnableStats(false)
+	cs.Append(4)
+	if !reflect.DeepEqual(cs.Stats(), st) {
+		t.Errorf("expected the statistics to stop changing once disabled, got %+v", cs.Stats())
+	}
+}
+
+func TestCtxVariants(t *testing.T) {
+	ctxtest.Run[*cslinkList.CSLinkList[int], *linkList.Node[int]](t, cslinkList.New[int], cslinkList.ErrNotFound)
+}
+
+func TestParity(t *testing.T) {
+	// Methods that only make sense on the concurrent list
+	extra := map[string]bool{"EnableStats": true, "Stats": true, "WithLock": true, "WithRLock": true}
+
+	plain := reflect.TypeOf(linkList.New[int]())
+	cs := reflect.TypeOf(cslinkList.New[int]())
+	for i := 0; i < plain.NumMethod(); i++ {
+		want := plain.Method(i)
+		got, ok := cs.MethodByName(want.Name)
+		if !ok {
+			t.Errorf("missing method %s", want.Name)
+			continue
+		}
+		if got.Type.NumIn() != want.Type.NumIn() || got.Type.NumOut() != want.Type.NumOut() {
+			t.Errorf("expected %s to be like %v, got %v", want.Name, want.Type, got.Type)
+		}
+	}
+	for i := 0; i < cs.NumMethod(); i++ {
+		if name := cs.Method(i).Name; !extra[name] {
+			if _, ok := plain.MethodByName(name); !ok {
+				t.Errorf("unexpected method %s", name)
+			}
+		}
+	}
+}
+
+func TestParityMethods(t *testing.T) {
+	cs := cslinkList.NewFromSlice([]int{1, 1, 2, 3, 3, 1})
+	if err := cs.CheckInvariants(); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if !cs.ContainsAll(1, 3) || cs.ContainsAny(4, 5) || cs.CountOf(1) != 3 {
+		t.Error("expected ContainsAll, ContainsAny and CountOf to match the values")
+	}
+	if chunks := cs.Chunk(4); len(chunks) != 2 || !slices.Equal(chunks[1].ToSlice(), []int{3, 1}) {
+		t.Errorf("expected 2 chunks, got %d", len(chunks))
+	}
+	if windows := cs.Windows(5); len(windows) != 2 || !slices.Equal(windows[1].ToSlice(), []int{1, 2, 3, 3, 1}) {
+		t.Errorf("expected 2 windows, got %d", len(windows))
+	}
+	if m := cs.MapIndexed(func(i uint64, v int) int { return int(i) * v }); !slices.Equal(m.ToSlice(), []int{0, 1, 4, 9, 12, 5}) {
+		t.Errorf("unexpected MapIndexed result %v", m.ToSlice())
+	}
+
+	u := cs.Copy()
+	u.Unique()
+	if !slices.Equal(u.ToSlice(), []int{1, 2, 3, 1}) {
+		t.Errorf("expected [1 2 3 1], got %v", u.ToSlice())
+	}
+	u.Deduplicate()
+	if !slices.Equal(u.ToSlice(), []int{1, 2, 3}) {
+		t.Errorf("expected [1 2 3], got %v", u.ToSlice())
+	}
+
+	if n := cs.RemoveFirstN(1, 2); n != 2 || cs.Size() != 4 {
+		t.Errorf("expected 2 values removed, got %d (size %d)", n, cs.Size())
+	}
+	if n := cs.RemoveAll(3); n != 2 {
+		t.Errorf("expected 2 values removed, got %d", n)
+	}
+	if n := cs.RemoveIf(func(v int) bool { return v == 2 }); n != 1 || !slices.Equal(cs.ToSlice(), []int{1}) {
+		t.Errorf("expected [1] left, got %v", cs.ToSlice())
+	}
+
+	cs.Append(5)
+	cs.ForEachIndexed(func(i uint64, v *int) { *v += int(i) })
+	cs.ForEachSafe(func(v int) bool { return v == 1 })
+	cs.CheckSize()
+	if !slices.Equal(cs.ToSlice(), []int{6}) {
+		t.Errorf("expected [6], got %v", cs.ToSlice())
+	}
+}
+
+func TestJSONStream(t *testing.T) {
+	if !cslinkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, cslinkList.Features())
+	}
+	if !cslinkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, cslinkList.Features())
+	}
+	l := cslinkList.New[int]()
+	if err := l.DecodeJSONStream(json.NewDecoder(strings.NewReader("[1, 2, 3]"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var sb strings.Builder
+	if err := l.EncodeJSONStream(&sb); err != nil || sb.String() != "[1,2,3]" {
+		t.Errorf("expected [1,2,3], got %s (%v)", sb.String(), err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *cslinkList.CSLinkList[int] {
+		l := cslinkList.New[int]()
+		for _, v := range values {
+			l.Append(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	l := build(1, 2)
+	if got := l.Compare(l, cmp.Compare[int]); got != 0 {
+		t.Errorf("expected a list to be equal to itself, got %d", got)
+	}
+}
+
+func TestCompareBothWays(t *testing.T) {
+	a, b := cslinkList.New[int](), cslinkList.New[int]()
+	var wg sync.WaitGroup
+	for _, pair := range [][2]*cslinkList.CSLinkList[int]{{a, b}, {b, a}} {
+		wg.Add(2)
+		go func(x, y *cslinkList.CSLinkList[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_ = x.Compare(y, cmp.Compare[int])
+			}
+		}(pair[0], pair[1])
+		go func(x *cslinkList.CSLinkList[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				x.Append(i)
+			}
+		}(pair[0])
+	}
+	wg.Wait()
+}
@@ -16,16 +16,37 @@
 package csstack
 
 import (
-	"errors"
 	"sync"
 
+	common "github.com/pzaino/gods/pkg/common"
 	stack "github.com/pzaino/gods/pkg/stack"
 )
 
+// Sentinel errors returned by the CSStack methods, they are the same
+// returned by the stack package (use errors.Is to check for them).
+var (
+	ErrNotFound        = stack.ErrNotFound
+	ErrEmpty           = stack.ErrEmpty
+	ErrStartOutOfRange = stack.ErrStartOutOfRange
+	ErrEndOutOfRange   = stack.ErrEndOutOfRange
+	ErrInvalidRange    = stack.ErrInvalidRange
+	ErrNotEnoughItems  = stack.ErrNotEnoughItems
+	ErrFull            = stack.ErrFull
+	// ErrBusy is returned by the Try methods when the lock is taken.
+	ErrBusy = common.ErrBusy
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureObservers, common.FeatureConcurrent, common.FeatureDeepCopy)
+}
+
 // CSStack is a concurrency-safe stack.
-type CSStack[T comparable] struct {
-	mu sync.RWMutex
-	s  *stack.Stack[T]
+type CSStack[T any] struct {
+	mu    sync.RWMutex
+	stats common.LockStats // opt-in statistics, see EnableStats
+	s     *stack.Stack[T]
 }
 
 // New creates a new concurrency-safe stack.
@@ -33,6 +54,17 @@ func New[T comparable]() *CSStack[T] {
 	return &CSStack[T]{s: stack.New[T]()}
 }
 
+// NewWithComparator creates a new concurrency-safe stack that compares its elements with the given function.
+func NewWithComparator[T any](equals func(a, b T) bool) *CSStack[T] {
+	return &CSStack[T]{s: stack.NewWithComparator(equals)}
+}
+
+// NewWithCapacity creates a new bounded concurrency-safe stack that holds at most
+// capacity items (0 means unbounded).
+func NewWithCapacity[T comparable](capacity uint64) *CSStack[T] {
+	return &CSStack[T]{s: stack.NewWithCapacity[T](capacity)}
+}
+
 // NewFromSlice creates a new concurrency-safe stack from a slice.
 func NewFromSlice[T comparable](items []T) *CSStack[T] {
 	cs := New[T]()
@@ -40,153 +72,355 @@ func NewFromSlice[T comparable](items []T) *CSStack[T] {
 	return cs
 }
 
-// Push adds an item to the stack.
-func (cs *CSStack[T]) Push(item T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	cs.s.Push(item)
+// lock takes the write lock for the method op, collecting the statistics when
+// enabled.
+func (cs *CSStack[T]) lock(op string) {
+	cs.stats.Lock(&cs.mu, op)
+}
+
+// rlock takes the read lock for the method op, collecting the statistics when
+// enabled.
+func (cs *CSStack[T]) rlock(op string) {
+	cs.stats.RLock(&cs.mu, op)
+}
+
+// unlock records the size of the stack and releases the write lock.
+func (cs *CSStack[T]) unlock() {
+	cs.stats.Observe(cs.s.Size())
+	cs.mu.Unlock()
+}
+
+// tryLock takes the write lock for the method op if it's free, collecting the
+// statistics when enabled, and returns false without waiting otherwise.
+func (cs *CSStack[T]) tryLock(op string) bool {
+	return cs.stats.TryLock(&cs.mu, op)
+}
+
+// tryRLock takes the read lock for the method op if it's available, collecting
+// the statistics when enabled, and returns false without waiting otherwise.
+func (cs *CSStack[T]) tryRLock(op string) bool {
+	return cs.stats.TryRLock(&cs.mu, op)
+}
+
+// EnableStats turns on or off the collection of the statistics returned by
+// Stats, enabling it resets them. When disabled the overhead is an atomic load
+// per operation.
+func (cs *CSStack[T]) EnableStats(on bool) {
+	cs.stats.Enable(on)
+}
+
+// Stats returns the statistics collected since they were enabled: the
+// operations by type (read or write) and by method, the peak size and the
+// waits for the lock.
+func (cs *CSStack[T]) Stats() common.Stats {
+	return cs.stats.Snapshot()
+}
+
+// Capacity returns the maximum number of items of the stack (0 means unbounded).
+func (cs *CSStack[T]) Capacity() uint64 {
+	cs.rlock("Capacity")
+	defer cs.mu.RUnlock()
+	return cs.s.Capacity()
+}
+
+// SetCapacity sets the maximum number of items of the stack (0 means unbounded),
+// dropping the oldest items if the stack holds more.
+func (cs *CSStack[T]) SetCapacity(capacity uint64) {
+	cs.lock("SetCapacity")
+	defer cs.unlock()
+	cs.s.SetCapacity(capacity)
+}
+
+// Reserve makes sure the stack can take n more items without reallocating its
+// storage (bounded stacks reserve at most the room left to their capacity).
+func (cs *CSStack[T]) Reserve(n uint64) {
+	cs.lock("Reserve")
+	defer cs.unlock()
+	cs.s.Reserve(n)
+}
+
+// ShrinkToFit reallocates the storage of the stack to the exact number of
+// items it holds, releasing the memory left by the popped items.
+func (cs *CSStack[T]) ShrinkToFit() {
+	cs.lock("ShrinkToFit")
+	defer cs.unlock()
+	cs.s.ShrinkToFit()
+}
+
+// IsFull returns true if the stack is bounded and has reached its capacity.
+func (cs *CSStack[T]) IsFull() bool {
+	cs.rlock("IsFull")
+	defer cs.mu.RUnlock()
+	return cs.s.IsFull()
+}
+
+// Push adds an item to the stack, it fails with ErrFull if the stack is full.
+func (cs *CSStack[T]) Push(item T) error {
+	cs.lock("Push")
+	defer cs.unlock()
+	return cs.s.Push(item)
+}
+
+// TryPush adds an item to the stack like Push if the lock is free, otherwise
+// it returns ErrBusy without waiting.
+func (cs *CSStack[T]) TryPush(item T) error {
+	if !cs.tryLock("TryPush") {
+		return ErrBusy
+	}
+	defer cs.unlock()
+	return cs.s.Push(item)
+}
+
+// PushIfAbsent atomically pushes the item if the stack doesn't contain it yet,
+// it returns false if the item was already there or the stack is full.
+func (cs *CSStack[T]) PushIfAbsent(item T) bool {
+	cs.lock("PushIfAbsent")
+	defer cs.unlock()
+	return cs.s.PushIfAbsent(item)
 }
 
 // IsEmpty checks if the stack is empty.
 func (cs *CSStack[T]) IsEmpty() bool {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("IsEmpty")
+	defer cs.unlock()
 	return cs.s.IsEmpty()
 }
 
 // Pop removes and returns the top item from the stack.
 func (cs *CSStack[T]) Pop() (*T, error) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Pop")
+	defer cs.unlock()
 	return cs.s.Pop()
 }
 
-// ToSlice returns the stack as a slice.
+// TryPop removes and returns the top item like Pop if the lock is free,
+// otherwise it returns ErrBusy without waiting.
+func (cs *CSStack[T]) TryPop() (*T, error) {
+	if !cs.tryLock("TryPop") {
+		return nil, ErrBusy
+	}
+	defer cs.unlock()
+	return cs.s.Pop()
+}
+
+// ToSlice returns a copy of the items of the stack from the top to the bottom.
 func (cs *CSStack[T]) ToSlice() []T {
-	cs.mu.RLock()
+	cs.rlock("ToSlice")
 	defer cs.mu.RUnlock()
 	return cs.s.ToSlice()
 }
 
 // ToStack returns the stack as a stack (non-concurrent-safe).
 func (cs *CSStack[T]) ToStack() *stack.Stack[T] {
-	cs.mu.RLock()
+	cs.rlock("ToStack")
 	defer cs.mu.RUnlock()
 	return cs.s
 }
 
 // Reverse reverses the stack.
 func (cs *CSStack[T]) Reverse() {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Reverse")
+	defer cs.unlock()
 	cs.s.Reverse()
 }
 
 // Swap swaps the top two items on the stack.
 func (cs *CSStack[T]) Swap() error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Swap")
+	defer cs.unlock()
 	return cs.s.Swap()
 }
 
 // Top returns the top item from the stack without removing it.
 func (cs *CSStack[T]) Top() (*T, error) {
-	cs.mu.RLock()
+	cs.rlock("Top")
+	defer cs.mu.RUnlock()
+	return cs.s.Top()
+}
+
+// TryTop returns the top item like Top if the stack isn't being written,
+// otherwise it returns ErrBusy without waiting.
+func (cs *CSStack[T]) TryTop() (*T, error) {
+	if !cs.tryRLock("TryTop") {
+		return nil, ErrBusy
+	}
 	defer cs.mu.RUnlock()
 	return cs.s.Top()
 }
 
 // Peek is a wrapper around Top (for those more used to using Peek).
 func (cs *CSStack[T]) Peek() (*T, error) {
-	cs.mu.RLock()
+	cs.rlock("Peek")
 	defer cs.mu.RUnlock()
 	return cs.s.Peek()
 }
 
+// PeekN returns the top n items from the stack without removing them.
+func (cs *CSStack[T]) PeekN(n uint64) ([]T, error) {
+	cs.rlock("PeekN")
+	defer cs.mu.RUnlock()
+	return cs.s.PeekN(n)
+}
+
+// Get returns the item at the given index (0 is the top of the stack) without removing it.
+func (cs *CSStack[T]) Get(index uint64) (*T, error) {
+	cs.rlock("Get")
+	defer cs.mu.RUnlock()
+	return cs.s.Get(index)
+}
+
 // Size returns the number of items in the stack.
 func (cs *CSStack[T]) Size() uint64 {
-	cs.mu.RLock()
+	cs.rlock("Size")
 	defer cs.mu.RUnlock()
 	return cs.s.Size()
 }
 
 // Clear removes all items from the stack.
 func (cs *CSStack[T]) Clear() {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Clear")
+	defer cs.unlock()
 	cs.s.Clear()
 }
 
+// Wipe overwrites every item of the stack with the zero value and releases
+// its storage (see stack.Stack.Wipe).
+func (cs *CSStack[T]) Wipe() {
+	cs.lock("Wipe")
+	defer cs.unlock()
+	cs.s.Wipe()
+}
+
 // Contains checks if the stack contains an item.
 func (cs *CSStack[T]) Contains(item T) bool {
-	cs.mu.RLock()
+	cs.rlock("Contains")
 	defer cs.mu.RUnlock()
 	return cs.s.Contains(item)
 }
 
-// Copy returns a new CSStack with the same items.
+// Copy returns a new CSStack with the same items (a shallow copy, see
+// stack.Stack.Copy).
 func (cs *CSStack[T]) Copy() *CSStack[T] {
-	cs.mu.RLock()
+	cs.rlock("Copy")
 	defer cs.mu.RUnlock()
 	return &CSStack[T]{s: cs.s.Copy()}
 }
 
-// Equal checks if two stacks are equal.
+// CloneWith returns a copy with a copy of every element made by copier, to deep
+// copy elements that hold pointers, slices or maps.
+func (cs *CSStack[T]) CloneWith(copier func(T) T) *CSStack[T] {
+	cs.rlock("CloneWith")
+	defer cs.mu.RUnlock()
+	return &CSStack[T]{s: cs.s.CloneWith(copier)}
+}
+
+// Equal checks if two stacks are equal. It works on a copy of other, so the
+// locks of the two stacks are never held together.
 func (cs *CSStack[T]) Equal(other *CSStack[T]) bool {
-	cs.mu.RLock()
+	if other == cs {
+		return true
+	}
+	other.rlock("Equal")
+	theirs := other.s.Copy()
+	other.mu.RUnlock()
+	cs.rlock("Equal")
 	defer cs.mu.RUnlock()
-	other.mu.Lock()
-	defer other.mu.Unlock()
-	return cs.s.Equal(other.s)
+	return cs.s.Equal(theirs)
 }
 
-// String returns a string representation of the stack.
+// Compare compares the stack with other lexicographically (see
+// stack.Stack.Compare). It works on a copy of other, so the locks of the two
+// stacks are never held together.
+func (cs *CSStack[T]) Compare(other *CSStack[T], compare func(a, b T) int) int {
+	if other == cs {
+		return 0
+	}
+	other.rlock("Compare")
+	theirs := other.s.Copy()
+	other.mu.RUnlock()
+	cs.rlock("Compare")
+	defer cs.mu.RUnlock()
+	return cs.s.Compare(theirs, compare)
+}
+
+// String returns a string representation of the stack (from the bottom to the top, items are formatted with %v).
 func (cs *CSStack[T]) String() string {
-	cs.mu.RLock()
+	return cs.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the stack (from the bottom to the top) with every item formatted by f.
+func (cs *CSStack[T]) StringFunc(f func(T) string) string {
+	cs.rlock("StringFunc")
 	defer cs.mu.RUnlock()
-	return cs.s.String()
+	return cs.s.StringFunc(f)
 }
 
 func (cs *CSStack[T]) PopN(n uint64) ([]T, error) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("PopN")
+	defer cs.unlock()
 	if cs.s.Size() < n {
-		return nil, errors.New("Stack has less than n items")
+		return nil, ErrNotEnoughItems
 	}
 	return cs.s.PopN(n)
 }
 
-// PushN adds multiple items to the stack.
-func (cs *CSStack[T]) PushN(items ...T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	cs.s.PushN(items...)
+// PushN atomically adds multiple items to the stack, either all of them or
+// none (and ErrFull is returned) if they don't fit.
+func (cs *CSStack[T]) PushN(items ...T) error {
+	cs.lock("PushN")
+	defer cs.unlock()
+	return cs.s.PushN(items...)
 }
 
 // PopAll removes and returns all items from the stack.
 func (cs *CSStack[T]) PopAll() []T {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("PopAll")
+	defer cs.unlock()
 	return cs.s.PopAll()
 }
 
-// PushAll adds multiple items to the stack.
-func (cs *CSStack[T]) PushAll(items []T) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
-	cs.s.PushAll(items)
+// PopWhile atomically pops the items from the top of the stack as long as they
+// match the predicate and returns them in pop order (see Stack.PopWhile).
+func (cs *CSStack[T]) PopWhile(pred func(T) bool) []T {
+	cs.lock("PopWhile")
+	defer cs.unlock()
+	return cs.s.PopWhile(pred)
+}
+
+// PopUntil atomically pops the items from the top of the stack until the top
+// one matches the predicate and returns them in pop order (see Stack.PopUntil).
+func (cs *CSStack[T]) PopUntil(pred func(T) bool) []T {
+	cs.lock("PopUntil")
+	defer cs.unlock()
+	return cs.s.PopUntil(pred)
+}
+
+// DrainAll atomically removes and returns all items from the top to the
+// bottom, the stack keeps its storage (see Stack.DrainAll).
+func (cs *CSStack[T]) DrainAll() []T {
+	cs.lock("DrainAll")
+	defer cs.unlock()
+	return cs.s.DrainAll()
+}
+
+// PushAll atomically adds multiple items to the stack, either all of them or
+// none (and ErrFull is returned) if they don't fit.
+func (cs *CSStack[T]) PushAll(items []T) error {
+	cs.lock("PushAll")
+	defer cs.unlock()
+	return cs.s.PushAll(items)
 }
 
 // Filter removes items from the stack that don't match the predicate.
 func (cs *CSStack[T]) Filter(predicate func(T) bool) {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("Filter")
+	defer cs.unlock()
 	cs.s.Filter(predicate)
 }
 
-// Map creates a new stack with the results of applying the function to each item.
+// Map creates a new stack with the results of applying the function to each item (from the bottom to the top).
 func (cs *CSStack[T]) Map(fn func(T) T) (*CSStack[T], error) {
-	cs.mu.RLock()
+	cs.rlock("Map")
 	defer cs.mu.RUnlock()
 	csStack := &CSStack[T]{}
 	var err error
@@ -194,86 +428,132 @@ func (cs *CSStack[T]) Map(fn func(T) T) (*CSStack[T], error) {
 	return csStack, err
 }
 
-// Reduce reduces the stack to a single value.
+// MapReverse creates a new stack with the results of applying the function to each item (from the top to the bottom).
+func (cs *CSStack[T]) MapReverse(fn func(T) T) (*CSStack[T], error) {
+	cs.rlock("MapReverse")
+	defer cs.mu.RUnlock()
+	csStack := &CSStack[T]{}
+	var err error
+	csStack.s, err = cs.s.MapReverse(fn)
+	return csStack, err
+}
+
+// Reduce reduces the stack to a single value (from the bottom to the top).
 func (cs *CSStack[T]) Reduce(fn func(T, T) T) (T, error) {
-	cs.mu.RLock()
+	cs.rlock("Reduce")
 	defer cs.mu.RUnlock()
 	return cs.s.Reduce(fn)
 }
 
-// ForEach applies the function to each item in the stack.
+// ReduceRight reduces the stack to a single value (from the top to the bottom).
+func (cs *CSStack[T]) ReduceRight(fn func(T, T) T) (T, error) {
+	cs.rlock("ReduceRight")
+	defer cs.mu.RUnlock()
+	return cs.s.ReduceRight(fn)
+}
+
+// ForEach applies the function to each item in the stack (from the top to the bottom).
 func (cs *CSStack[T]) ForEach(fn func(*T) error) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForEach")
+	defer cs.unlock()
 	return cs.s.ForEach(fn)
 }
 
+// ForEachReverse applies the function to each item in the stack (from the bottom to the top).
+func (cs *CSStack[T]) ForEachReverse(fn func(*T) error) error {
+	cs.lock("ForEachReverse")
+	defer cs.unlock()
+	return cs.s.ForEachReverse(fn)
+}
+
 // ForRange applies the function to each item in the stack in the range [start, end).
 func (cs *CSStack[T]) ForRange(start, end uint64, fn func(*T) error) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForRange")
+	defer cs.unlock()
 	return cs.s.ForRange(start, end, fn)
 }
 
 // ForFrom applies the function to each item in the stack starting from the index.
 func (cs *CSStack[T]) ForFrom(start uint64, fn func(*T) error) error {
-	cs.mu.Lock()
-	defer cs.mu.Unlock()
+	cs.lock("ForFrom")
+	defer cs.unlock()
 	return cs.s.ForFrom(start, fn)
 }
 
 // Any checks if any item in the stack matches the predicate.
 func (cs *CSStack[T]) Any(predicate func(T) bool) bool {
-	cs.mu.RLock()
+	cs.rlock("Any")
 	defer cs.mu.RUnlock()
 	return cs.s.Any(predicate)
 }
 
 // All checks if all items in the stack match the predicate.
 func (cs *CSStack[T]) All(predicate func(T) bool) bool {
-	cs.mu.RLock()
+	cs.rlock("All")
 	defer cs.mu.RUnlock()
 	return cs.s.All(predicate)
 }
 
 // Find returns the first item that matches the predicate.
 func (cs *CSStack[T]) Find(predicate func(T) bool) (*T, error) {
-	cs.mu.RLock()
+	cs.rlock("Find")
 	defer cs.mu.RUnlock()
 	return cs.s.Find(predicate)
 }
 
 // FindIndex returns the index of the first item that matches the predicate.
 func (cs *CSStack[T]) FindIndex(predicate func(T) bool) (uint64, error) {
-	cs.mu.RLock()
+	cs.rlock("FindIndex")
 	defer cs.mu.RUnlock()
 	return cs.s.FindIndex(predicate)
 }
 
 // FindLast returns the last item that matches the predicate.
 func (cs *CSStack[T]) FindLast(predicate func(T) bool) (*T, error) {
-	cs.mu.RLock()
+	cs.rlock("FindLast")
 	defer cs.mu.RUnlock()
 	return cs.s.FindLast(predicate)
 }
 
 // FindLastIndex returns the index of the last item that matches the predicate.
 func (cs *CSStack[T]) FindLastIndex(predicate func(T) bool) (uint64, error) {
-	cs.mu.RLock()
+	cs.rlock("FindLastIndex")
 	defer cs.mu.RUnlock()
 	return cs.s.FindLastIndex(predicate)
 }
 
 // FindAll returns all items that match the predicate.
 func (cs *CSStack[T]) FindAll(predicate func(T) bool) []T {
-	cs.mu.RLock()
+	cs.rlock("FindAll")
 	defer cs.mu.RUnlock()
 	return cs.s.FindAll(predicate)
 }
 
 // FindIndices returns the indices of all items that match the predicate.
 func (cs *CSStack[T]) FindIndices(predicate func(T) bool) []uint64 {
-	cs.mu.RLock()
+	cs.rlock("FindIndices")
 	defer cs.mu.RUnlock()
 	return cs.s.FindIndices(predicate)
 }
+
+// OnInsert registers a function called after an element is added to the stack.
+// The hooks are called with the lock held, so they must not call methods of cs.
+func (cs *CSStack[T]) OnInsert(f func(index uint64, v T)) {
+	cs.lock("OnInsert")
+	defer cs.unlock()
+	cs.s.OnInsert(f)
+}
+
+// OnRemove registers a function called after an element is removed from the stack.
+func (cs *CSStack[T]) OnRemove(f func(index uint64, v T)) {
+	cs.lock("OnRemove")
+	defer cs.unlock()
+	cs.s.OnRemove(f)
+}
+
+// OnClear registers a function called after all the elements are removed at once from the stack.
+func (cs *CSStack[T]) OnClear(f func()) {
+	cs.lock("OnClear")
+	defer cs.unlock()
+	cs.s.OnClear(f)
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package csstack_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	csstack "github.com/pzaino/gods/pkg/csstack"
+)
+
+// BenchmarkPush pushes n elements
+func BenchmarkPush(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		for i := 0; i < b.N; i++ {
+			s := csstack.New[int]()
+			for v := 0; v < n; v++ {
+				_ = s.Push(v)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkPop pops an element from a stack of n elements (and pushes it
+// back, so the size stays n)
+func BenchmarkPop(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		s := csstack.NewFromSlice(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			v, _ := s.Pop()
+			_ = s.Push(*v)
+		}
+	})
+}
+
+// BenchmarkFind looks for the value in the middle of n elements
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		target := values[n/2]
+		s := csstack.NewFromSlice(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = s.Find(func(v int) bool { return v == target })
+		}
+	})
+}
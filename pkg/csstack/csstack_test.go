@@ -16,7 +16,12 @@
 package csstack_test
 
 import (
+	"cmp"
+	"errors"
+	"reflect"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"testing"
 
 	csstack "github.com/pzaino/gods/pkg/csstack"
@@ -230,7 +235,7 @@ func TestCSStackPopN(t *testing.T) {
 
 	runConcurrent(t, 100, func(j int) { // Reduce the number of goroutines to avoid exhausting the stack too quickly
 		_, err := cs.PopN(10)
-		if err != nil && err.Error() != "Stack has less than n items" {
+		if err != nil && !errors.Is(err, csstack.ErrNotEnoughItems) {
 			t.Fatalf(errExpectedNoError, err)
 		}
 	})
@@ -262,6 +267,29 @@ func TestCSStackPopAll(t *testing.T) {
 	}
 }
 
+func TestCSStackPopWhileAndPopUntil(t *testing.T) {
+	cs := csstack.NewFromSlice([]int{1, 2, 3, 4})
+	if items := cs.PopWhile(func(v int) bool { return v%2 == 0 }); !slices.Equal(items, []int{4}) {
+		t.Errorf("expected [4] popped, got %v", items)
+	}
+	if items := cs.PopUntil(func(v int) bool { return v == 1 }); !slices.Equal(items, []int{3, 2}) || cs.Size() != 1 {
+		t.Errorf("expected [3 2] popped, got %v (size %d)", items, cs.Size())
+	}
+}
+
+func TestCSStackPushIfAbsent(t *testing.T) {
+	cs := csstack.New[int]()
+	var pushed atomic.Int32
+	runConcurrent(t, 100, func(j int) {
+		if cs.PushIfAbsent(j % 10) {
+			pushed.Add(1)
+		}
+	})
+	if pushed.Load() != 10 || cs.Size() != 10 {
+		t.Errorf("expected 10 distinct items pushed, got %d (size %d)", pushed.Load(), cs.Size())
+	}
+}
+
 func TestCSStackPushAll(t *testing.T) {
 	cs := csstack.New[int]()
 	items := []int{1, 2, 3, 4, 5}
@@ -487,3 +515,270 @@ func TestNewFromSlice(t *testing.T) {
 		}
 	}
 }
+
+func TestCSStackPeekN(t *testing.T) {
+	cs := csstack.NewFromSlice([]int{1, 2, 3})
+	runConcurrent(t, 1000, func(j int) {
+		items, err := cs.PeekN(2)
+		if err != nil {
+			t.Errorf(errExpectedNoError, err)
+			return
+		}
+		if items[0] != 3 || items[1] != 2 {
+			t.Errorf("expected [3 2], got %v", items)
+		}
+	})
+	if cs.Size() != 3 {
+		t.Fatalf(errExpectedSizeX, 3, cs.Size())
+	}
+}
+
+func TestCSStackGet(t *testing.T) {
+	cs := csstack.NewFromSlice([]int{1, 2, 3})
+	runConcurrent(t, 1000, func(j int) {
+		item, err := cs.Get(2)
+		if err != nil {
+			t.Errorf(errExpectedNoError, err)
+			return
+		}
+		if *item != 1 {
+			t.Errorf("expected 1, got %d", *item)
+		}
+	})
+}
+
+func TestReverseVariants(t *testing.T) {
+	cs := csstack.New[int]()
+	for i := 1; i <= 3; i++ {
+		cs.Push(i)
+	}
+
+	var visited []int
+	_ = cs.ForEachReverse(func(v *int) error {
+		visited = append(visited, *v)
+		return nil
+	})
+	if len(visited) != 3 || visited[0] != 1 || visited[2] != 3 {
+		t.Errorf("expected [1 2 3], got %v", visited)
+	}
+
+	r, err := cs.ReduceRight(func(a, b int) int { return a*10 + b })
+	if err != nil || r != 321 {
+		t.Errorf("expected 321, got %d (%v)", r, err)
+	}
+
+	mapped, err := cs.MapReverse(func(v int) int { return -v })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if top, _ := mapped.Pop(); *top != -3 {
+		t.Errorf("expected top -3, got %d", *top)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := csstack.New[*int]()
+	c.Push(&a)
+	c.Push(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	cs := csstack.NewWithCapacity[int](100)
+	var wg sync.WaitGroup
+	var full atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if err := cs.Push(j); errors.Is(err, csstack.ErrFull) {
+					full.Add(1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if cs.Size() != 100 || !cs.IsFull() || full.Load() != 100 {
+		t.Errorf("expected 100 items and 100 ErrFull, got %d and %d", cs.Size(), full.Load())
+	}
+	cs.SetCapacity(10)
+	if cs.Size() != 10 || cs.Capacity() != 10 {
+		t.Errorf("expected 10 items, got %d", cs.Size())
+	}
+}
+
+func TestCSStackDrainAll(t *testing.T) {
+	cs := csstack.New[int]()
+	if cs.DrainAll() != nil {
+		t.Error("expected nil from an empty stack")
+	}
+	for i := 1; i <= 3; i++ {
+		_ = cs.Push(i)
+	}
+	items := cs.DrainAll()
+	if !slices.Equal(items, []int{3, 2, 1}) || !cs.IsEmpty() {
+		t.Errorf("expected [3 2 1] and an empty stack, got %v", items)
+	}
+}
+
+func TestCSStackReserveShrinkToFit(t *testing.T) {
+	s := csstack.New[int]()
+	s.Reserve(64)
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 16; i++ {
+				_ = s.Push(i)
+			}
+		}()
+	}
+	wg.Wait()
+	_, _ = s.PopN(60)
+	s.ShrinkToFit()
+	if s.Size() != 4 {
+		t.Errorf("expected 4 items, got %d", s.Size())
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *csstack.CSStack[int] {
+		l := csstack.New[int]()
+		for _, v := range values {
+			_ = l.Push(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	l := build(1, 2)
+	if got := l.Compare(l, cmp.Compare[int]); got != 0 {
+		t.Errorf("expected a stack to be equal to itself, got %d", got)
+	}
+}
+
+func TestTryVariants(t *testing.T) {
+	cs := csstack.New[int]()
+	cs.EnableStats(true)
+	if err := cs.TryPush(1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := cs.TryPush(2); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, err := cs.TryTop(); err != nil || *v != 2 {
+		t.Errorf("expected 2, got %v (%v)", v, err)
+	}
+
+	// ForEach holds the lock while it runs
+	_ = cs.ForEach(func(*int) error {
+		if err := cs.TryPush(3); !errors.Is(err, csstack.ErrBusy) {
+			t.Errorf("expected %v, got %v", csstack.ErrBusy, err)
+		}
+		if _, err := cs.TryPop(); !errors.Is(err, csstack.ErrBusy) {
+			t.Errorf("expected %v, got %v", csstack.ErrBusy, err)
+		}
+		if _, err := cs.TryTop(); !errors.Is(err, csstack.ErrBusy) {
+			t.Errorf("expected %v, got %v", csstack.ErrBusy, err)
+		}
+		return nil
+	})
+
+	if v, err := cs.TryPop(); err != nil || *v != 2 {
+		t.Errorf("expected 2, got %v (%v)", v, err)
+	}
+	if cs.Size() != 1 {
+		t.Errorf("expected 1 item, got %v", cs.ToSlice())
+	}
+	if want := map[string]uint64{"TryPush": 2, "TryTop": 1, "TryPop": 1, "ForEach": 1, "Size": 1}; !reflect.DeepEqual(cs.Stats().Ops, want) {
+		t.Errorf("expected the operations %v, got %v", want, cs.Stats().Ops)
+	}
+	if st := cs.Stats(); st.Busy != 6 || st.Waits != 0 {
+		t.Errorf("expected 6 busy (3 per item) and no waits, got %+v", st)
+	}
+}
+
+func TestCompareBothWays(t *testing.T) {
+	a, b := csstack.New[int](), csstack.New[int]()
+	var wg sync.WaitGroup
+	for _, pair := range [][2]*csstack.CSStack[int]{{a, b}, {b, a}} {
+		wg.Add(2)
+		go func(x, y *csstack.CSStack[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_ = x.Compare(y, cmp.Compare[int])
+				_ = x.Equal(y)
+				_ = x.Equal(x)
+			}
+		}(pair[0], pair[1])
+		go func(x *csstack.CSStack[int]) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				_ = x.Push(i)
+			}
+		}(pair[0])
+	}
+	wg.Wait()
+}
+
+func TestStats(t *testing.T) {
+	cs := csstack.New[int]()
+	_ = cs.Push(1)
+	if st := cs.Stats(); st.Reads != 0 || st.Writes != 0 || st.PeakSize != 0 {
+		t.Errorf("expected no statistics while disabled, got %+v", st)
+	}
+
+	cs.EnableStats(true)
+	_ = cs.Push(2)
+	_ = cs.Push(3)
+	_, _ = cs.Pop()
+	_ = cs.Size()
+	st := cs.Stats()
+	if st.Writes != 3 || st.Reads != 1 || st.PeakSize != 3 {
+		t.Errorf("expected 3 writes, 1 read and peak size 3, got %+v", st)
+	}
+	if want := map[string]uint64{"Push": 2, "Pop": 1, "Size": 1}; !reflect.DeepEqual(st.Ops, want) {
+		t.Errorf("expected the operations %v, got %v", want, st.Ops)
+	}
+
+	cs.EnableStats(false)
+	_ = cs.Push(1)
+	if !reflect.DeepEqual(cs.Stats(), st) {
+		t.Errorf("expected the statistics to stop changing once disabled, got %+v", cs.Stats())
+	}
+}
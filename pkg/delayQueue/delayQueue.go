@@ -0,0 +1,271 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package delayQueue provides a concurrency-safe delay queue: every element
+// is enqueued with a deadline and it can be dequeued only once its deadline
+// has expired. Elements with the same deadline are dequeued in FIFO order.
+package delayQueue
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the DelayQueue methods (use errors.Is to check for them)
+var (
+	ErrEmpty      = errors.New("queue is empty")
+	ErrNotExpired = errors.New("no expired element")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureConcurrent)
+}
+
+// item is an element of the queue with its deadline
+type item[T any] struct {
+	value    T
+	deadline time.Time
+	seq      uint64 // insertion order, used to keep FIFO order on equal deadlines
+}
+
+// DelayQueue is a concurrency-safe queue of delayed elements, ordered by
+// deadline. The zero value is an empty queue ready to use
+type DelayQueue[T any] struct {
+	mu      sync.Mutex
+	data    []item[T]
+	seq     uint64
+	changed chan struct{} // closed (and dropped) every time the head of the queue changes, see waitChanged
+}
+
+// New creates a new DelayQueue
+func New[T any]() *DelayQueue[T] {
+	return &DelayQueue[T]{}
+}
+
+// Helper functions for heap operations
+
+// before reports whether the element at index i must be dequeued before the one at index j
+func (dq *DelayQueue[T]) before(i, j int) bool {
+	if dq.data[i].deadline.Equal(dq.data[j].deadline) {
+		return dq.data[i].seq < dq.data[j].seq
+	}
+	return dq.data[i].deadline.Before(dq.data[j].deadline)
+}
+
+// upHeap moves the element at the given index up the heap to restore the heap property
+func (dq *DelayQueue[T]) upHeap(index int) {
+	for index > 0 {
+		parent := (index - 1) / 2
+		if !dq.before(index, parent) {
+			break
+		}
+		dq.data[index], dq.data[parent] = dq.data[parent], dq.data[index]
+		index = parent
+	}
+}
+
+// downHeap moves the element at the given index down the heap to restore the heap property
+func (dq *DelayQueue[T]) downHeap(index int) {
+	size := len(dq.data)
+	for {
+		left := 2*index + 1
+		if left >= size {
+			break
+		}
+		child := left
+		if right := left + 1; right < size && dq.before(right, left) {
+			child = right
+		}
+		if !dq.before(child, index) {
+			break
+		}
+		dq.data[index], dq.data[child] = dq.data[child], dq.data[index]
+		index = child
+	}
+}
+
+// pop removes and returns the head of the heap, the queue must not be empty
+func (dq *DelayQueue[T]) pop() T {
+	head := dq.data[0]
+	last := len(dq.data) - 1
+	dq.data[0] = dq.data[last]
+	dq.data[last] = item[T]{} // let the GC collect the value
+	dq.data = dq.data[:last]
+	if last > 0 {
+		dq.downHeap(0)
+	}
+	return head.value
+}
+
+// notify wakes up all the goroutines blocked in Dequeue, it must be called with the lock held
+func (dq *DelayQueue[T]) notify() {
+	if dq.changed != nil {
+		close(dq.changed)
+		dq.changed = nil
+	}
+}
+
+// waitChanged returns the channel notify closes, making it if nobody is
+// waiting yet. It must be called with the lock held
+func (dq *DelayQueue[T]) waitChanged() <-chan struct{} {
+	if dq.changed == nil {
+		dq.changed = make(chan struct{})
+	}
+	return dq.changed
+}
+
+// Enqueue adds an element that can be dequeued after the given delay
+func (dq *DelayQueue[T]) Enqueue(value T, delay time.Duration) {
+	dq.EnqueueAt(value, time.Now().Add(delay))
+}
+
+// EnqueueAt adds an element that can be dequeued once the given time is reached
+func (dq *DelayQueue[T]) EnqueueAt(value T, deadline time.Time) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	dq.data = append(dq.data, item[T]{value: value, deadline: deadline, seq: dq.seq})
+	dq.seq++
+	index := len(dq.data) - 1
+	dq.upHeap(index)
+
+	// Only a new head can change when the next element expires
+	if dq.data[0].seq == dq.seq-1 {
+		dq.notify()
+	}
+}
+
+// TryDequeue removes and returns the first expired element without blocking,
+// the boolean is false if there are no expired elements
+func (dq *DelayQueue[T]) TryDequeue() (T, bool) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	if len(dq.data) == 0 || dq.data[0].deadline.After(time.Now()) {
+		var rVal T
+		return rVal, false
+	}
+	return dq.pop(), true
+}
+
+// Dequeue blocks until an element expires and returns it, or until the
+// context is done, in which case it returns the context error
+func (dq *DelayQueue[T]) Dequeue(ctx context.Context) (T, error) {
+	var timer *time.Timer
+	defer func() {
+		if timer != nil {
+			timer.Stop()
+		}
+	}()
+
+	for {
+		dq.mu.Lock()
+		var wait <-chan time.Time
+		if len(dq.data) > 0 {
+			delay := time.Until(dq.data[0].deadline)
+			if delay <= 0 {
+				value := dq.pop()
+				dq.mu.Unlock()
+				return value, nil
+			}
+			if timer == nil {
+				timer = time.NewTimer(delay)
+			} else {
+				timer.Reset(delay)
+			}
+			wait = timer.C
+		}
+		changed := dq.waitChanged()
+		dq.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			var rVal T
+			return rVal, ctx.Err()
+		case <-wait:
+		case <-changed:
+			if timer != nil && !timer.Stop() {
+				// Drain the channel so the next Reset starts clean
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+		}
+	}
+}
+
+// DequeueExpired removes and returns all the expired elements (in deadline order)
+func (dq *DelayQueue[T]) DequeueExpired() []T {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	var items []T
+	now := time.Now()
+	for len(dq.data) > 0 && !dq.data[0].deadline.After(now) {
+		items = append(items, dq.pop())
+	}
+	return items
+}
+
+// Peek returns the element that will expire first and its deadline, without removing it
+func (dq *DelayQueue[T]) Peek() (T, time.Time, error) {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+
+	if len(dq.data) == 0 {
+		var rVal T
+		return rVal, time.Time{}, ErrEmpty
+	}
+	return dq.data[0].value, dq.data[0].deadline, nil
+}
+
+// PeekExpired returns the first expired element without removing it
+func (dq *DelayQueue[T]) PeekExpired() (T, error) {
+	value, deadline, err := dq.Peek()
+	if err != nil {
+		return value, err
+	}
+	if deadline.After(time.Now()) {
+		var rVal T
+		return rVal, ErrNotExpired
+	}
+	return value, nil
+}
+
+// Size returns the number of elements in the queue (expired or not)
+func (dq *DelayQueue[T]) Size() uint64 {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+	return uint64(len(dq.data))
+}
+
+// IsEmpty checks if the queue is empty
+func (dq *DelayQueue[T]) IsEmpty() bool {
+	return dq.Size() == 0
+}
+
+// Clear removes all the elements from the queue
+func (dq *DelayQueue[T]) Clear() {
+	dq.mu.Lock()
+	defer dq.mu.Unlock()
+	dq.data = nil
+	dq.notify()
+}
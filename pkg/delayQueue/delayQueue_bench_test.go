@@ -0,0 +1,60 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package delayQueue_test
+
+import (
+	"testing"
+	"time"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	delayQueue "github.com/pzaino/gods/pkg/delayQueue"
+)
+
+// newBenchQueue returns a delay queue holding values, all already expired
+func newBenchQueue(values []int) *delayQueue.DelayQueue[int] {
+	dq := delayQueue.New[int]()
+	for _, v := range values {
+		dq.Enqueue(v, -time.Duration(v))
+	}
+	return dq
+}
+
+// BenchmarkEnqueue enqueues n elements with different delays
+func BenchmarkEnqueue(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			dq := delayQueue.New[int]()
+			for _, v := range values {
+				dq.Enqueue(v, time.Duration(v))
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkDequeue dequeues an expired element from n (and enqueues it back,
+// so the size stays n)
+func BenchmarkDequeue(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		dq := newBenchQueue(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			v, _ := dq.TryDequeue()
+			dq.Enqueue(v, -time.Duration(v))
+		}
+	})
+}
@@ -0,0 +1,204 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package delayQueue_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	delayQueue "github.com/pzaino/gods/pkg/delayQueue"
+)
+
+func TestNew(t *testing.T) {
+	dq := delayQueue.New[int]()
+	if !dq.IsEmpty() {
+		t.Fatal("expected new delay queue to be empty")
+	}
+	if _, _, err := dq.Peek(); !errors.Is(err, delayQueue.ErrEmpty) {
+		t.Errorf("expected %v, got %v", delayQueue.ErrEmpty, err)
+	}
+}
+
+func TestTryDequeue(t *testing.T) {
+	dq := delayQueue.New[string]()
+	dq.Enqueue("later", time.Hour)
+	dq.Enqueue("now", 0)
+
+	if v, ok := dq.TryDequeue(); !ok || v != "now" {
+		t.Errorf("expected now, got %v (%v)", v, ok)
+	}
+	if _, ok := dq.TryDequeue(); ok {
+		t.Error("expected no expired element")
+	}
+	if _, err := dq.PeekExpired(); !errors.Is(err, delayQueue.ErrNotExpired) {
+		t.Errorf("expected %v, got %v", delayQueue.ErrNotExpired, err)
+	}
+	if v, _, err := dq.Peek(); err != nil || v != "later" {
+		t.Errorf("expected later, got %v (%v)", v, err)
+	}
+	if dq.Size() != 1 {
+		t.Errorf("expected size 1, got %d", dq.Size())
+	}
+}
+
+func TestDeadlineOrder(t *testing.T) {
+	dq := delayQueue.New[int]()
+	base := time.Now().Add(-time.Minute)
+	dq.EnqueueAt(3, base.Add(3*time.Second))
+	dq.EnqueueAt(1, base.Add(1*time.Second))
+	dq.EnqueueAt(2, base.Add(2*time.Second))
+	dq.EnqueueAt(4, base.Add(2*time.Second)) // same deadline as 2, FIFO
+	dq.Enqueue(5, time.Hour)
+
+	got := dq.DequeueExpired()
+	expected := []int{1, 2, 4, 3}
+	if len(got) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, got)
+			break
+		}
+	}
+	if dq.Size() != 1 {
+		t.Errorf("expected size 1, got %d", dq.Size())
+	}
+}
+
+func TestDequeueBlocks(t *testing.T) {
+	dq := delayQueue.New[int]()
+	start := time.Now()
+	dq.Enqueue(1, 30*time.Millisecond)
+
+	v, err := dq.Dequeue(context.Background())
+	if err != nil || v != 1 {
+		t.Fatalf("expected 1, got %v (%v)", v, err)
+	}
+	if elapsed := time.Since(start); elapsed < 30*time.Millisecond {
+		t.Errorf("Dequeue returned after %v, before the delay expired", elapsed)
+	}
+}
+
+func TestDequeueWakesOnEarlierElement(t *testing.T) {
+	dq := delayQueue.New[int]()
+	dq.Enqueue(1, time.Hour)
+
+	result := make(chan int, 1)
+	go func() {
+		v, _ := dq.Dequeue(context.Background())
+		result <- v
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	dq.Enqueue(2, 10*time.Millisecond)
+
+	select {
+	case v := <-result:
+		if v != 2 {
+			t.Errorf("expected 2, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dequeue did not wake up for the new element")
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	var dq delayQueue.DelayQueue[int]
+	dq.Enqueue(1, 0)
+	if v, ok := dq.TryDequeue(); !ok || v != 1 {
+		t.Errorf("expected 1, got %d (%v)", v, ok)
+	}
+
+	result := make(chan int, 1)
+	go func() {
+		v, _ := dq.Dequeue(context.Background())
+		result <- v
+	}()
+	time.Sleep(10 * time.Millisecond)
+	dq.Enqueue(2, 10*time.Millisecond)
+
+	select {
+	case v := <-result:
+		if v != 2 {
+			t.Errorf("expected 2, got %d", v)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Dequeue did not wake up for the new element")
+	}
+	dq.Clear()
+}
+
+func TestDequeueContext(t *testing.T) {
+	dq := delayQueue.New[int]()
+	dq.Enqueue(1, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := dq.Dequeue(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected %v, got %v", context.DeadlineExceeded, err)
+	}
+	if dq.Size() != 1 {
+		t.Errorf("expected size 1, got %d", dq.Size())
+	}
+}
+
+func TestConcurrentDequeue(t *testing.T) {
+	dq := delayQueue.New[int]()
+	const n = 100
+
+	var wg sync.WaitGroup
+	results := make(chan int, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := dq.Dequeue(context.Background())
+			if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			results <- v
+		}()
+	}
+	for i := 0; i < n; i++ {
+		dq.Enqueue(i, time.Duration(i%5)*time.Millisecond)
+	}
+	wg.Wait()
+	close(results)
+
+	seen := make(map[int]bool)
+	for v := range results {
+		if seen[v] {
+			t.Errorf("element %d dequeued twice", v)
+		}
+		seen[v] = true
+	}
+	if len(seen) != n || !dq.IsEmpty() {
+		t.Errorf("expected %d distinct elements and an empty queue, got %d (size %d)", n, len(seen), dq.Size())
+	}
+}
+
+func TestClear(t *testing.T) {
+	dq := delayQueue.New[int]()
+	dq.Enqueue(1, 0)
+	dq.Enqueue(2, time.Hour)
+	dq.Clear()
+	if !dq.IsEmpty() {
+		t.Errorf("expected empty queue, got size %d", dq.Size())
+	}
+}
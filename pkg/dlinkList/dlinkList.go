@@ -15,65 +15,234 @@
 // Package dlinkList provides a non-concurrent-safe doubly linked list.
 package dlinkList
 
-import "errors"
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
 
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
 const (
 	ErrIndexOutOfBound = "index out of bounds"
 	ErrFailedToInsert  = "failed to insert"
 	ErrValueNotFound   = "value not found"
 )
 
-// Node is a representation of a node in a doubly linked list
-type Node[T comparable] struct {
-	Value T
-	Next  *Node[T]
-	Prev  *Node[T]
+// Sentinel errors returned by the DLinkList methods (use errors.Is to check for them)
+var (
+	ErrOutOfBounds  = errors.New(ErrIndexOutOfBound)
+	ErrEmpty        = errors.New("list is empty")
+	ErrInsertFailed = errors.New(ErrFailedToInsert)
+	ErrNotFound     = errors.New(ErrValueNotFound)
+	ErrSameList     = errors.New("cannot splice a list into itself")
+	ErrCorrupted    = errors.New("list is corrupted")
+	ErrNotInList    = errors.New("node is not in the list")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureHasher, common.FeatureObservers, common.FeatureInvariants, common.FeatureDeepCopy, common.FeatureJSON)
+}
+
+// Node is a representation of a node in a doubly linked list. Its links are
+// private so only the list can change the structure, use the accessors to
+// walk the list (or MoveToFront, MoveToBack and SwapNodes to move a node)
+type Node[T any] struct {
+	value T
+	next  *Node[T]
+	prev  *Node[T]
+}
+
+// Value returns the value held by the node
+func (n *Node[T]) Value() T {
+	return n.value
+}
+
+// SetValue replaces the value held by the node (the observers of the list
+// are not notified)
+func (n *Node[T]) SetValue(value T) {
+	n.value = value
+}
+
+// Next returns the node after n, nil if n is the last node of its list
+func (n *Node[T]) Next() *Node[T] {
+	return n.next
+}
+
+// Prev returns the node before n, nil if n is the first node of its list
+func (n *Node[T]) Prev() *Node[T] {
+	return n.prev
 }
 
 // DLinkList is a representation of a doubly linked list
-type DLinkList[T comparable] struct {
-	Head *Node[T]
-	Tail *Node[T]
-	size uint64
+type DLinkList[T any] struct {
+	head   *Node[T]
+	tail   *Node[T]
+	size   uint64
+	equals common.EqualFunc[T]
+	key    common.KeyFunc[T] // nil when values can only be compared with equals
+	hasher common.Hasher[T]  // nil unless the list was created with NewWithHasher
+	obs    common.Observers[T]
+	pool   *common.FreeList[Node[T]] // nil unless the list was created with NewWithPool
 }
 
+// poolSize is the maximum number of released nodes kept by a list created with NewWithPool
+const poolSize = 4096
+
 // New creates a new doubly linked list
 func New[T comparable]() *DLinkList[T] {
-	return &DLinkList[T]{}
+	return &DLinkList[T]{equals: common.Equal[T], key: common.Key[T]}
+}
+
+// NewWithComparator creates a new doubly linked list that compares its values with the given
+// function, so it can hold types that are not comparable (slices, maps, ...)
+func NewWithComparator[T any](equals func(a, b T) bool) *DLinkList[T] {
+	return &DLinkList[T]{equals: equals}
+}
+
+// NewWithHasher creates a new doubly linked list that compares its values with the given
+// hasher, which also speeds up ContainsAll, ContainsAny and Deduplicate for
+// types that are not comparable (the hashes are compared first)
+func NewWithHasher[T any](hasher common.Hasher[T]) *DLinkList[T] {
+	return &DLinkList[T]{equals: hasher.Equals, hasher: hasher}
+}
+
+// NewWithPool creates a new doubly linked list that reuses the nodes of the
+// deleted values (up to 4096 of them) for the values inserted later, instead
+// of allocating a new node for each one. It reduces the allocations and the GC
+// work of workloads that keep inserting and deleting values, at the cost of
+// keeping the released nodes in memory for as long as the list is alive. A
+// deleted node is reset and reused, so a *Node returned by Find, GetAt, ...
+// must not be used after its value is deleted
+func NewWithPool[T comparable]() *DLinkList[T] {
+	l := New[T]()
+	l.pool = common.NewFreeList[Node[T]](poolSize)
+	return l
+}
+
+// newEmpty creates a new empty list that compares its values like l
+func (l *DLinkList[T]) newEmpty() *DLinkList[T] {
+	return &DLinkList[T]{equals: l.equals, key: l.key, hasher: l.hasher}
+}
+
+// seen returns a new Seen set that compares the values like l
+func (l *DLinkList[T]) seen() *common.Seen[T] {
+	if l.hasher != nil {
+		return common.NewHashSeen(l.hasher)
+	}
+	return common.NewSeen(l.key, l.equals)
+}
+
+// lookup returns a new Lookup for items that compares the values like l
+func (l *DLinkList[T]) lookup(items []T) *common.Lookup[T] {
+	if l.hasher != nil {
+		return common.NewHashLookup(l.hasher, items)
+	}
+	return common.NewLookup(l.key, l.equals, items)
+}
+
+// equal compares two values with the list comparator
+func (l *DLinkList[T]) equal(x, y T) bool {
+	return common.Equals(l.equals, x, y)
+}
+
+// OnInsert registers a function called after a value is added to the list (nil
+// removes it). See common.Observers for the meaning of the index, for the methods
+// that don't take an index (InsertAfter, InsertBefore, ...) it's computed walking
+// the list only while a hook is registered
+func (l *DLinkList[T]) OnInsert(f func(index uint64, v T)) {
+	l.obs.Insert = f
+}
+
+// OnRemove registers a function called after a value is removed from the list (nil removes it)
+func (l *DLinkList[T]) OnRemove(f func(index uint64, v T)) {
+	l.obs.Remove = f
+}
+
+// OnClear registers a function called after all the values are removed at once
+// by Clear (and by the methods that move all the nodes to another list) (nil removes it)
+func (l *DLinkList[T]) OnClear(f func()) {
+	l.obs.Clear = f
+}
+
+// indexOf returns the index of a node of the list
+func (l *DLinkList[T]) indexOf(node *Node[T]) uint64 {
+	var index uint64
+	for current := l.head; current != node; current = current.next {
+		index++
+	}
+	return index
+}
+
+// newNode returns a node holding value, taken from the pool when possible
+func (l *DLinkList[T]) newNode(value T) *Node[T] {
+	node := l.pool.Get()
+	node.value = value
+	return node
+}
+
+// release returns a node removed from the list to the pool and returns its value
+func (l *DLinkList[T]) release(node *Node[T]) T {
+	value := node.value
+	l.pool.Put(node)
+	return value
 }
 
 // Append adds a new node to the end of the doubly linked list
 func (l *DLinkList[T]) Append(value T) {
-	newNode := &Node[T]{Value: value}
+	newNode := l.newNode(value)
 
-	if l.Head == nil {
-		l.Head = newNode
-		l.Tail = newNode
-		l.size++
-		return
+	if l.head == nil {
+		l.head = newNode
+		l.tail = newNode
+	} else {
+		newNode.prev = l.tail
+		l.tail.next = newNode
+		l.tail = newNode
 	}
-
-	newNode.Prev = l.Tail
-	l.Tail.Next = newNode
-	l.Tail = newNode
 	l.size++
+	l.obs.Inserted(l.size-1, value)
 }
 
 // Prepend adds a new node to the beginning of the doubly linked list
 func (l *DLinkList[T]) Prepend(value T) {
-	newNode := &Node[T]{Value: value}
+	newNode := l.newNode(value)
 
-	if l.Head == nil {
-		l.Head = newNode
-		l.Tail = newNode
-		l.size++
-		return
+	if l.head == nil {
+		l.head = newNode
+		l.tail = newNode
+	} else {
+		newNode.next = l.head
+		l.head.prev = newNode
+		l.head = newNode
 	}
-
-	newNode.Next = l.Head
-	l.Head.Prev = newNode
-	l.Head = newNode
 	l.size++
+	l.obs.Inserted(0, value)
+}
+
+// AppendN adds new nodes with the given values to the end of the doubly linked list, in order
+func (l *DLinkList[T]) AppendN(values ...T) {
+	for _, value := range values {
+		l.Append(value)
+	}
+}
+
+// PrependN adds new nodes with the given values to the beginning of the doubly linked list,
+// they end up at the head in the given order
+func (l *DLinkList[T]) PrependN(values ...T) {
+	for i := len(values) - 1; i >= 0; i-- {
+		l.Prepend(values[i])
+	}
 }
 
 // Insert inserts a new node with the given value at first available index
@@ -82,7 +251,7 @@ func (l *DLinkList[T]) Insert(value T) error {
 	ln := l.size
 	l.Append(value)
 	if ln == l.size {
-		return errors.New(ErrFailedToInsert)
+		return ErrInsertFailed
 	}
 	return nil
 }
@@ -94,12 +263,18 @@ func (l *DLinkList[T]) InsertAfter(value, newValue T) {
 		return
 	}
 
-	newNode := &Node[T]{Value: newValue}
-	newNode.Next = node.Next
-	newNode.Prev = node
-	node.Next = newNode
-	if newNode.Next != nil {
-		newNode.Next.Prev = newNode
+	newNode := l.newNode(newValue)
+	newNode.next = node.next
+	newNode.prev = node
+	node.next = newNode
+	if newNode.next != nil {
+		newNode.next.prev = newNode
+	} else {
+		l.tail = newNode
+	}
+	l.size++
+	if l.obs.Insert != nil {
+		l.obs.Insert(l.indexOf(newNode), newValue)
 	}
 }
 
@@ -110,80 +285,68 @@ func (l *DLinkList[T]) InsertBefore(value, newValue T) {
 		return
 	}
 
-	newNode := &Node[T]{Value: newValue}
-	newNode.Next = node
-	newNode.Prev = node.Prev
-	node.Prev = newNode
-	if newNode.Prev != nil {
-		newNode.Prev.Next = newNode
+	l.insertBefore(node, newValue)
+	if l.obs.Insert != nil {
+		l.obs.Insert(l.indexOf(node.prev), newValue)
 	}
 }
 
+// insertBefore links a new node with the given value before node
+func (l *DLinkList[T]) insertBefore(node *Node[T], value T) {
+	newNode := l.newNode(value)
+	newNode.next = node
+	newNode.prev = node.prev
+	node.prev = newNode
+	if newNode.prev != nil {
+		newNode.prev.next = newNode
+	} else {
+		l.head = newNode
+	}
+	l.size++
+}
+
 // InsertAt inserts a new node with the given value at the given index
 func (l *DLinkList[T]) InsertAt(index uint64, value T) error {
-	if index > l.size {
-		return errors.New(ErrIndexOutOfBound)
+	if err := l.checkPosition(index); err != nil {
+		return err
 	}
 
 	if index == 0 {
 		l.Prepend(value)
 		return nil
 	}
-
-	current := l.Head
-	for i := uint64(0); i < index-1; i++ {
-		if current == nil {
-			return errors.New(ErrIndexOutOfBound)
-		}
-		current = current.Next
-	}
-
-	if current == nil {
-		return errors.New(ErrIndexOutOfBound)
+	if index == l.size {
+		l.Append(value)
+		return nil
 	}
 
-	newNode := &Node[T]{Value: value}
-	newNode.Next = current.Next
-	newNode.Prev = current
-	current.Next = newNode
-	if newNode.Next != nil {
-		newNode.Next.Prev = newNode
-	}
-	l.size++
-
+	l.insertBefore(l.nodeAt(index), value)
+	l.obs.Inserted(index, value)
 	return nil
 }
 
 // DeleteWithValue deletes the first occurrence of a node with the given value
 func (l *DLinkList[T]) DeleteWithValue(value T) {
-	if l.Head == nil {
+	node, err := l.Find(value)
+	if err != nil {
 		return
 	}
-
-	if l.Head.Value == value {
-		l.Head = l.Head.Next
-		if l.Head != nil {
-			l.Head.Prev = nil
-		}
-		l.size--
-		return
+	var index uint64
+	if l.obs.Remove != nil {
+		index = l.indexOf(node)
 	}
+	l.removeNode(node)
+	l.obs.Removed(index, l.release(node))
+}
 
-	current := l.Head
-	for current.Next != nil {
-		if current == nil {
-			return
-		}
-		if current.Next.Value == value {
-			current.Next = current.Next.Next
-			if current.Next != nil {
-				current.Next.Prev = current
-			}
-			l.size--
-			return
-		}
-		current = current.Next
+// DeleteN deletes the first node with each of the given values (a value given
+// twice deletes two nodes) and returns how many nodes were deleted
+func (l *DLinkList[T]) DeleteN(values ...T) uint64 {
+	size := l.size
+	for _, value := range values {
+		l.DeleteWithValue(value)
 	}
+	return size - l.size
 }
 
 func (l *DLinkList[T]) Remove(value T) {
@@ -196,111 +359,54 @@ func (l *DLinkList[T]) RemoveAt(index uint64) error {
 
 // Delete deletes the first node with the given value
 func (l *DLinkList[T]) Delete(value T) {
-	node, err := l.Find(value)
-	if err != nil {
-		return
-	}
-
-	if node.Prev == nil {
-		l.Head = node.Next
-		if l.Head != nil {
-			l.Head.Prev = nil
-		}
-		l.size--
-		return
-	}
-
-	if node.Next == nil {
-		l.Tail = node.Prev
-		l.Tail.Next = nil
-		l.size--
-		return
-	}
-
-	node.Prev.Next = node.Next
-	node.Next.Prev = node.Prev
-	l.size--
+	l.DeleteWithValue(value)
 }
 
 // DeleteLast deletes the last node in the doubly linked list
 func (l *DLinkList[T]) DeleteLast() {
-	if l.Tail == nil {
+	if l.tail == nil {
 		return
 	}
 
-	if l.Tail.Prev == nil {
-		l.Head = nil
-		l.Tail = nil
-		l.size--
-		return
+	last := l.tail
+	if l.tail.prev == nil {
+		l.head = nil
+		l.tail = nil
+	} else {
+		l.tail = l.tail.prev
+		l.tail.next = nil
 	}
-
-	l.Tail = l.Tail.Prev
-	l.Tail.Next = nil
 	l.size--
+	l.obs.Removed(l.size, l.release(last))
 }
 
 // DeleteFirst deletes the first node in the doubly linked list
 func (l *DLinkList[T]) DeleteFirst() {
-	if l.Head == nil {
+	if l.head == nil {
 		return
 	}
 
-	if l.Head.Next == nil {
-		l.Head = nil
-		l.Tail = nil
-		l.size--
-		return
+	first := l.head
+	if l.head.next == nil {
+		l.head = nil
+		l.tail = nil
+	} else {
+		l.head = l.head.next
+		l.head.prev = nil
 	}
-
-	l.Head = l.Head.Next
-	l.Head.Prev = nil
 	l.size--
+	l.obs.Removed(0, l.release(first))
 }
 
 // DeleteAt deletes the node at the given index
 func (l *DLinkList[T]) DeleteAt(index uint64) error {
-	if index > l.size {
-		return errors.New(ErrIndexOutOfBound)
-	}
-
-	// delete the first node
-	if index == 0 {
-		if l.Head == nil {
-			return errors.New(ErrIndexOutOfBound)
-		}
-		l.Head = l.Head.Next
-		l.Head.Prev = nil
-		l.size--
-		return nil
-	}
-
-	// find the node at the given index
-	current := l.Head
-	for i := uint64(0); i < index; i++ {
-		if current == nil {
-			return errors.New(ErrIndexOutOfBound)
-		}
-		current = current.Next
-	}
-
-	// Check if the node is valid
-	if current == nil {
-		return errors.New(ErrIndexOutOfBound)
-	}
-
-	// this is the last node
-	if current.Next == nil {
-		current.Prev.Next = nil
-		l.size--
-		return nil
+	if err := l.checkIndex(index); err != nil {
+		return err
 	}
 
-	// regular node in the middle
-	current.Prev.Next = current.Next
-	current.Next.Prev = current.Prev
-	l.size--
-
+	node := l.nodeAt(index)
+	l.removeNode(node)
+	l.obs.Removed(index, l.release(node))
 	return nil
 }
 
@@ -308,23 +414,55 @@ func (l *DLinkList[T]) DeleteAt(index uint64) error {
 func (l *DLinkList[T]) ToSlice() []T {
 	var result []T
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		result = append(result, current.Value)
-		current = current.Next
+		result = append(result, current.value)
+		current = current.next
 	}
 
 	return result
 }
 
+// String returns a string representation of the doubly linked list (elements are formatted with %v)
+func (l *DLinkList[T]) String() string {
+	return l.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the doubly linked list with every element formatted by f
+func (l *DLinkList[T]) StringFunc(f func(T) string) string {
+	return common.FormatSlice(l.ToSlice(), f)
+}
+
+// EncodeJSONStream writes the values of the list to w as a JSON array, one
+// value at a time (the output of json.Marshal on ToSlice, without the copy)
+func (l *DLinkList[T]) EncodeJSONStream(w io.Writer) error {
+	enc := common.NewJSONArrayEncoder[T](w)
+	for current := l.head; current != nil; current = current.next {
+		if err := enc.Encode(current.value); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// DecodeJSONStream appends the values of the JSON array read from dec, one
+// value at a time, without holding the whole array in memory (see
+// common.DecodeJSONArray). The values decoded before an error stay in the list
+func (l *DLinkList[T]) DecodeJSONStream(dec *json.Decoder) error {
+	return common.DecodeJSONArray(dec, func(v T) error {
+		l.Append(v)
+		return nil
+	})
+}
+
 // ToSliceReverse converts the doubly linked list to a slice in reverse order
 func (l *DLinkList[T]) ToSliceReverse() []T {
 	var result []T
 
-	current := l.Tail
+	current := l.tail
 	for current != nil {
-		result = append(result, current.Value)
-		current = current.Prev
+		result = append(result, current.value)
+		current = current.prev
 	}
 
 	return result
@@ -340,8 +478,8 @@ func (l *DLinkList[T]) ToSliceFromIndex(index uint64) []T {
 	}
 
 	for current != nil {
-		result = append(result, current.Value)
-		current = current.Next
+		result = append(result, current.value)
+		current = current.next
 	}
 
 	return result
@@ -351,18 +489,32 @@ func (l *DLinkList[T]) ToSliceFromIndex(index uint64) []T {
 func (l *DLinkList[T]) ToSliceReverseFromIndex(index uint64) []T {
 	var result []T
 
-	if index > l.size {
+	if l.checkIndex(index) != nil {
 		return result
 	}
 
-	current, err := l.GetAt((l.Size() - 1) - index)
-	if err != nil {
+	current := l.nodeAt(l.size - 1 - index)
+	for current != nil {
+		result = append(result, current.value)
+		current = current.prev
+	}
+
+	return result
+}
+
+// ToSliceRange converts the nodes in the range [start, end) of the doubly linked list to a slice
+// (empty if start > end or end > size)
+func (l *DLinkList[T]) ToSliceRange(start, end uint64) []T {
+	var result []T
+	if start >= end || end > l.size {
 		return result
 	}
 
-	for current != nil {
-		result = append(result, current.Value)
-		current = current.Prev
+	result = make([]T, 0, end-start)
+	current := l.nodeAt(start)
+	for i := start; i < end; i++ {
+		result = append(result, current.value)
+		current = current.next
 	}
 
 	return result
@@ -370,74 +522,136 @@ func (l *DLinkList[T]) ToSliceReverseFromIndex(index uint64) []T {
 
 // Reverse reverses the doubly linked list
 func (l *DLinkList[T]) Reverse() {
-	current := l.Head
+	current := l.head
 	var prev *Node[T]
 
 	for current != nil {
-		next := current.Next
-		current.Next = prev
-		current.Prev = next
+		next := current.next
+		current.next = prev
+		current.prev = next
 		prev = current
 		current = next
 	}
 
-	l.Head, l.Tail = l.Tail, l.Head
+	l.head, l.tail = l.tail, l.head
+}
+
+// RotateLeft rotates all the values to the left by n positions (the node at
+// index n becomes the head), n can be bigger than the size of the list. The
+// nodes are relinked in O(min(n, size - n)) without allocations
+func (l *DLinkList[T]) RotateLeft(n uint64) {
+	if l.size == 0 {
+		return
+	}
+	n = n % l.size
+	if n == 0 {
+		return
+	}
+
+	head := l.nodeAt(n)
+	tail := head.prev
+	l.tail.next = l.head
+	l.head.prev = l.tail
+	head.prev = nil
+	tail.next = nil
+	l.head, l.tail = head, tail
+}
+
+// RotateRight rotates all the values to the right by n positions (the last n
+// nodes are moved to the head), n can be bigger than the size of the list
+func (l *DLinkList[T]) RotateRight(n uint64) {
+	if l.size == 0 {
+		return
+	}
+	l.RotateLeft(l.size - n%l.size)
 }
 
 // Find returns the first node with the given value
 func (l *DLinkList[T]) Find(value T) (*Node[T], error) {
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if current.Value == value {
+		if l.equal(current.value, value) {
 			return current, nil
 		}
-		current = current.Next
+		current = current.next
 	}
 
-	return nil, errors.New(ErrValueNotFound)
+	return nil, ErrNotFound
+}
+
+// FindCtx returns the first node with the given value like Find, it checks ctx
+// every common.CtxCheckInterval nodes and returns its error when it's done
+func (l *DLinkList[T]) FindCtx(ctx context.Context, value T) (*Node[T], error) {
+	var i uint64
+	for current := l.head; current != nil; current = current.next {
+		if err := common.CtxErr(ctx, i); err != nil {
+			return nil, err
+		}
+		if l.equal(current.value, value) {
+			return current, nil
+		}
+		i++
+	}
+	return nil, ErrNotFound
 }
 
 // IsEmpty returns true if the doubly linked list is empty
 func (l *DLinkList[T]) IsEmpty() bool {
-	return l.Head == nil
+	return l.head == nil
 }
 
 // GetAt returns the node at the given index
 func (l *DLinkList[T]) GetAt(index uint64) (*Node[T], error) {
-	if index > l.size {
-		return nil, errors.New(ErrIndexOutOfBound)
+	if err := l.checkIndex(index); err != nil {
+		return nil, err
 	}
+	return l.nodeAt(index), nil
+}
 
-	current := l.Head
-	if current == nil {
-		return nil, errors.New(ErrIndexOutOfBound)
+// checkIndex returns an IndexError unless index is the position of an
+// element (index < size), every method addressing an element uses it
+func (l *DLinkList[T]) checkIndex(index uint64) error {
+	if index >= l.size {
+		return common.NewIndexError(ErrOutOfBounds, index, l.size)
 	}
-	if index == 0 {
-		return current, nil
+	return nil
+}
+
+// checkPosition returns an IndexError unless index is a valid insertion
+// point (index <= size, size means after the last element)
+func (l *DLinkList[T]) checkPosition(index uint64) error {
+	if index > l.size {
+		return common.NewIndexError(ErrOutOfBounds, index, l.size)
 	}
+	return nil
+}
 
-	for i := uint64(0); i < index; i++ {
-		if current == nil {
-			return nil, errors.New(ErrIndexOutOfBound)
+// nodeAt returns the node at the given index walking from the closest end
+// of the list (index must be smaller than the size of the list)
+func (l *DLinkList[T]) nodeAt(index uint64) *Node[T] {
+	if index < l.size/2 {
+		current := l.head
+		for i := uint64(0); i < index; i++ {
+			current = current.next
 		}
-		current = current.Next
+		return current
 	}
 
-	if current == nil {
-		return nil, errors.New(ErrIndexOutOfBound)
+	current := l.tail
+	for i := l.size - 1; i > index; i-- {
+		current = current.prev
 	}
-
-	return current, nil
+	return current
 }
 
 // GetLast returns the last node in the doubly linked list
 func (l *DLinkList[T]) GetLast() *Node[T] {
-	return l.Tail
+	return l.tail
 }
 
 // GetFirst returns the first node in the doubly linked list
 func (l *DLinkList[T]) GetFirst() *Node[T] {
-	return l.Head
+	return l.head
 }
 
 // Size returns the number of nodes in the doubly linked list
@@ -445,13 +659,46 @@ func (l *DLinkList[T]) Size() uint64 {
 	return l.size
 }
 
+// CheckInvariants verifies the internal consistency of the list (the number of
+// nodes matches the size, Next and Prev links are symmetric and the tail is the
+// last node reachable from the head), it returns an error wrapping ErrCorrupted
+// describing the first violation found
+func (l *DLinkList[T]) CheckInvariants() error {
+	if (l.head == nil) != (l.tail == nil) {
+		return fmt.Errorf("%w: only one of head and tail is nil", ErrCorrupted)
+	}
+	if l.head != nil && l.head.prev != nil {
+		return fmt.Errorf("%w: the head has a Prev node", ErrCorrupted)
+	}
+
+	var count uint64
+	var last *Node[T]
+	for current := l.head; current != nil; current = current.next {
+		count++
+		if count > l.size {
+			return fmt.Errorf("%w: more nodes than its size %d (or a cycle)", ErrCorrupted, l.size)
+		}
+		if current.prev != last {
+			return fmt.Errorf("%w: the Prev link of node %d doesn't point to node %d", ErrCorrupted, count-1, count-2)
+		}
+		last = current
+	}
+	if count != l.size {
+		return fmt.Errorf("%w: %d nodes but its size is %d", ErrCorrupted, count, l.size)
+	}
+	if last != l.tail {
+		return fmt.Errorf("%w: the tail is not the last node", ErrCorrupted)
+	}
+	return nil
+}
+
 // CheckSize recalculates the size of the doubly linked list
 func (l *DLinkList[T]) CheckSize() {
 	size := uint64(0)
-	current := l.Head
+	current := l.head
 	for current != nil {
 		size++
-		current = current.Next
+		current = current.next
 	}
 
 	l.size = size
@@ -459,55 +706,164 @@ func (l *DLinkList[T]) CheckSize() {
 
 // Clear removes all nodes from the doubly linked list
 func (l *DLinkList[T]) Clear() {
-	l.Head = nil
-	l.Tail = nil
+	// Release the nodes until the pool is full, the rest is left to the GC
+	for l.pool != nil && l.head != nil && l.pool.Len() < poolSize {
+		next := l.head.next
+		l.pool.Put(l.head)
+		l.head = next
+	}
+	l.reset()
+}
+
+// Wipe overwrites the value of every node with the zero value and unlinks the
+// nodes before emptying the list, so sensitive data (tokens, keys, ...)
+// doesn't stay reachable from nodes still referenced elsewhere (a *Node
+// returned by Find, GetAt, ... is wiped too)
+func (l *DLinkList[T]) Wipe() {
+	for l.head != nil {
+		node := l.head
+		l.head = node.next
+		var zero T
+		node.value = zero
+		node.next, node.prev = nil, nil
+		l.pool.Put(node)
+	}
+	l.reset()
+}
+
+// reset empties the list without releasing its nodes to the pool, it's used
+// when the nodes have been moved to another list
+func (l *DLinkList[T]) reset() {
+	l.head = nil
+	l.tail = nil
 	l.size = 0
+	l.obs.Cleared()
 }
 
 // Contains returns true if the doubly linked list contains the given value
 func (l *DLinkList[T]) Contains(value T) bool {
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if current.Value == value {
+		if l.equal(current.value, value) {
+			return true
+		}
+		current = current.next
+	}
+
+	return false
+}
+
+// ContainsAll returns true if the doubly linked list contains all the given values (true
+// when none is given), they are all checked in a single pass over the doubly linked list
+func (l *DLinkList[T]) ContainsAll(values ...T) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	lookup := l.lookup(values)
+	for n := l.head; n != nil; n = n.next {
+		if lookup.Match(n.value) && lookup.Remaining() == 0 {
 			return true
 		}
-		current = current.Next
+	}
+	return false
+}
+
+// ContainsAny returns true if the doubly linked list contains at least one of the given
+// values, they are all checked in a single pass over the doubly linked list
+func (l *DLinkList[T]) ContainsAny(values ...T) bool {
+	if len(values) == 0 {
+		return false
 	}
 
+	lookup := l.lookup(values)
+	for n := l.head; n != nil; n = n.next {
+		if lookup.Match(n.value) {
+			return true
+		}
+	}
 	return false
 }
 
+// CountOf returns the number of values in the doubly linked list equal to the given value
+func (l *DLinkList[T]) CountOf(value T) uint64 {
+	var count uint64
+	for n := l.head; n != nil; n = n.next {
+		if l.equal(n.value, value) {
+			count++
+		}
+	}
+	return count
+}
+
 // ForEach traverses the doubly linked list and applies the given function to each node
+// (f must not add or remove nodes, use ForEachSafe to remove them while iterating)
 func (l *DLinkList[T]) ForEach(f func(*T)) {
 	if l.IsEmpty() {
 		return
 	}
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		f(&current.Value)
-		current = current.Next
+		f(&current.value)
+		current = current.next
 	}
 }
 
-// ForFrom traverses the doubly linked list starting from the given index and applies the given function to each node
-func (l *DLinkList[T]) ForFrom(index uint64, f func(*T)) {
-	if index > l.size {
-		return
-	}
+// ForEachSafe calls fn with every value of the list, from the head to the
+// tail, and removes the nodes for which it returns true (e.g. to expire entries
+// while scanning). The traversal keeps its position when the current node is
+// removed, unlike removing it from a ForEach callback. fn must not modify the
+// list itself
+func (l *DLinkList[T]) ForEachSafe(fn func(v T) (remove bool)) {
+	l.Filter(func(v T) bool { return !fn(v) })
+}
 
-	if l.IsEmpty() {
-		return
+// ForEachIndexed applies the function to all the values in the list and their index
+func (l *DLinkList[T]) ForEachIndexed(f func(uint64, *T)) {
+	i := uint64(0)
+	l.ForEach(func(v *T) {
+		f(i, v)
+		i++
+	})
+}
+
+// ForEachErr traverses the doubly linked list and applies the given function to each node,
+// it stops at the first error returned by the function and returns it
+func (l *DLinkList[T]) ForEachErr(f func(*T) error) error {
+	for current := l.head; current != nil; current = current.next {
+		if err := f(&current.value); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	current, err := l.GetAt(index)
-	if err != nil {
+// ForEachCtx applies the function to all the nodes in the list like ForEach,
+// it checks ctx every common.CtxCheckInterval nodes and stops, returning its
+// error, when it's done
+func (l *DLinkList[T]) ForEachCtx(ctx context.Context, f func(*T)) error {
+	var i uint64
+	return l.ForEachErr(func(v *T) error {
+		if err := common.CtxErr(ctx, i); err != nil {
+			return err
+		}
+		i++
+		f(v)
+		return nil
+	})
+}
+
+// ForFrom traverses the doubly linked list starting from the given index and applies the given function to each node
+func (l *DLinkList[T]) ForFrom(index uint64, f func(*T)) {
+	if l.checkIndex(index) != nil {
 		return
 	}
 
+	current := l.nodeAt(index)
 	for current != nil {
-		f(&current.Value)
-		current = current.Next
+		f(&current.value)
+		current = current.next
 		if current == nil {
 			break
 		}
@@ -520,31 +876,23 @@ func (l *DLinkList[T]) ForEachReverse(f func(*T)) {
 		return
 	}
 
-	current := l.Tail
+	current := l.tail
 	for current != nil {
-		f(&current.Value)
-		current = current.Prev
+		f(&current.value)
+		current = current.prev
 	}
 }
 
 // ForReverseFrom traverses the doubly linked list in reverse order starting from the given index and applies the given function to each node
 func (l *DLinkList[T]) ForReverseFrom(index uint64, f func(*T)) {
-	if index > l.size {
-		return
-	}
-
-	if l.IsEmpty() {
-		return
-	}
-
-	current, err := l.GetAt((l.Size() - 1) - index)
-	if err != nil {
+	if l.checkIndex(index) != nil {
 		return
 	}
 
+	current := l.nodeAt(l.size - 1 - index)
 	for current != nil {
-		f(&current.Value)
-		current = current.Prev
+		f(&current.value)
+		current = current.prev
 		if current == nil {
 			break
 		}
@@ -567,8 +915,8 @@ func (l *DLinkList[T]) ForRange(start, end uint64, f func(*T)) {
 	}
 
 	for i := start; i <= end; i++ {
-		f(&current.Value)
-		current = current.Next
+		f(&current.value)
+		current = current.next
 		if current == nil {
 			break
 		}
@@ -598,7 +946,7 @@ func (l *DLinkList[T]) ForReverseRange(start, end uint64, f func(*T)) {
 	}
 
 	if start == 0 && end == 0 {
-		f(&l.Head.Value)
+		f(&l.head.value)
 		return
 	}
 
@@ -608,8 +956,8 @@ func (l *DLinkList[T]) ForReverseRange(start, end uint64, f func(*T)) {
 	}
 
 	for i := start; i <= end; i++ {
-		f(&current.Value)
-		current = current.Prev
+		f(&current.value)
+		current = current.prev
 		if current == nil {
 			break
 		}
@@ -618,12 +966,12 @@ func (l *DLinkList[T]) ForReverseRange(start, end uint64, f func(*T)) {
 
 // Any returns true if the given function returns true for any node in the doubly linked list
 func (l *DLinkList[T]) Any(f func(T) bool) bool {
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if f(current.Value) {
+		if f(current.value) {
 			return true
 		}
-		current = current.Next
+		current = current.next
 	}
 
 	return false
@@ -631,12 +979,12 @@ func (l *DLinkList[T]) Any(f func(T) bool) bool {
 
 // All returns true if the given function returns true for all nodes in the doubly linked list
 func (l *DLinkList[T]) All(f func(T) bool) bool {
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if !f(current.Value) {
+		if !f(current.value) {
 			return false
 		}
-		current = current.Next
+		current = current.next
 	}
 
 	return true
@@ -644,14 +992,14 @@ func (l *DLinkList[T]) All(f func(T) bool) bool {
 
 // IndexOf returns the index of the first occurrence of the given value in the doubly linked list
 func (l *DLinkList[T]) IndexOf(value T) int {
-	current := l.Head
+	current := l.head
 	index := 0
 	for current != nil {
-		if current.Value == value {
+		if l.equal(current.value, value) {
 			return index
 		}
 		index++
-		current = current.Next
+		current = current.next
 	}
 
 	return -1
@@ -659,97 +1007,226 @@ func (l *DLinkList[T]) IndexOf(value T) int {
 
 // LastIndexOf returns the index of the last occurrence of the given value in the doubly linked list
 func (l *DLinkList[T]) LastIndexOf(value T) (uint64, error) {
-	current := l.Tail
+	current := l.tail
 	index := l.Size() - 1
 	for current != nil {
-		if current.Value == value {
+		if l.equal(current.value, value) {
 			return index, nil
 		}
 		index--
-		current = current.Prev
+		current = current.prev
 	}
 
-	return 0, errors.New(ErrValueNotFound)
+	return 0, ErrNotFound
 }
 
 // removeNode removes a node from the doubly linked list
 // note: this is a private method and should not be used outside of this package
 func (l *DLinkList[T]) removeNode(node *Node[T]) {
-	if node.Prev == nil {
-		l.Head = node.Next
-		if l.Head != nil {
-			l.Head.Prev = nil
+	l.unlink(node)
+	l.size--
+}
+
+// unlink detaches a node from its neighbours without changing the size, the
+// node keeps its own links until it's linked again
+func (l *DLinkList[T]) unlink(node *Node[T]) {
+	if node.prev == nil {
+		l.head = node.next
+		if l.head != nil {
+			l.head.prev = nil
 		}
 	} else {
-		node.Prev.Next = node.Next
+		node.prev.next = node.next
 	}
 
-	if node.Next == nil {
-		l.Tail = node.Prev
-		if l.Tail != nil {
-			l.Tail.Next = nil
+	if node.next == nil {
+		l.tail = node.prev
+		if l.tail != nil {
+			l.tail.next = nil
 		}
 	} else {
-		node.Next.Prev = node.Prev
+		node.next.prev = node.prev
 	}
+}
 
-	l.size--
+// linkAfter links a detached node after prev, or at the front of the list
+// when prev is nil
+func (l *DLinkList[T]) linkAfter(prev, node *Node[T]) {
+	node.prev = prev
+	if prev == nil {
+		node.next = l.head
+		l.head = node
+	} else {
+		node.next = prev.next
+		prev.next = node
+	}
+	if node.next == nil {
+		l.tail = node
+	} else {
+		node.next.prev = node
+	}
 }
 
-// Filter returns a new doubly linked list containing only the nodes that satisfy the given function
+// checkNode returns ErrNotInList if node is nil or clearly not a node of the
+// list (a detached node, or the head or tail of another list). Any other node
+// is trusted to belong to the list, as checking it would cost O(n)
+func (l *DLinkList[T]) checkNode(node *Node[T]) error {
+	if node == nil || (node.prev == nil && node != l.head) || (node.next == nil && node != l.tail) {
+		return ErrNotInList
+	}
+	return nil
+}
+
+// Filter removes the nodes that don't satisfy the given function
 func (l *DLinkList[T]) Filter(f func(T) bool) {
-	if l.size == 0 || l.Head == nil {
+	if l.size == 0 || l.head == nil {
 		return
 	}
 
-	current := l.Head
+	var removed []common.Removal[T]
+	var index uint64
+	current := l.head
 	for current != nil {
-		next := current.Next // Store the next node
-		if !f(current.Value) {
+		next := current.next // Store the next node
+		if !f(current.value) {
 			l.removeNode(current)
+			if l.obs.Remove != nil {
+				removed = append(removed, common.Removal[T]{Index: index, Value: current.value})
+			}
+			l.release(current)
+		} else {
+			index++
 		}
 		current = next // Move to the next node
 	}
 
-	// If the list is now empty after filtering, reset the Tail pointer
+	// If the list is now empty after filtering, reset the tail pointer
 	if l.size == 0 {
-		l.Tail = nil
+		l.tail = nil
+	}
+	l.obs.RemovedAll(removed)
+}
+
+// RemoveIf removes the nodes that match the predicate (the opposite of
+// Filter) and returns how many were removed
+func (l *DLinkList[T]) RemoveIf(predicate func(T) bool) uint64 {
+	size := l.size
+	l.Filter(func(v T) bool { return !predicate(v) })
+	return size - l.size
+}
+
+// RemoveAll removes all the nodes equal to value and returns how many were removed
+func (l *DLinkList[T]) RemoveAll(value T) uint64 {
+	return l.RemoveIf(func(v T) bool { return l.equal(v, value) })
+}
+
+// RemoveFirstN removes the first n nodes equal to value (from the start of
+// the list) and returns how many were removed
+func (l *DLinkList[T]) RemoveFirstN(value T, n uint64) uint64 {
+	removed := uint64(0)
+	return l.RemoveIf(func(v T) bool {
+		if removed < n && l.equal(v, value) {
+			removed++
+			return true
+		}
+		return false
+	})
+}
+
+// Unique removes consecutive duplicate values, keeping the first node of each run
+func (l *DLinkList[T]) Unique() {
+	if l.head == nil {
+		return
+	}
+
+	var removed []common.Removal[T]
+	index := uint64(1)
+	for current := l.head.next; current != nil; {
+		next := current.next
+		if l.equal(current.prev.value, current.value) {
+			l.removeNode(current)
+			if l.obs.Remove != nil {
+				removed = append(removed, common.Removal[T]{Index: index, Value: current.value})
+			}
+			l.release(current)
+		} else {
+			index++
+		}
+		current = next
+	}
+	l.obs.RemovedAll(removed)
+}
+
+// Deduplicate removes all duplicate values, keeping the first occurrence of each one
+func (l *DLinkList[T]) Deduplicate() {
+	seen := l.seen()
+	var removed []common.Removal[T]
+	var index uint64
+	for current := l.head; current != nil; {
+		next := current.next
+		if !seen.Add(current.value) {
+			l.removeNode(current)
+			if l.obs.Remove != nil {
+				removed = append(removed, common.Removal[T]{Index: index, Value: current.value})
+			}
+			l.release(current)
+		} else {
+			index++
+		}
+		current = next
 	}
+	l.obs.RemovedAll(removed)
 }
 
 // Map returns a new doubly linked list containing the result of applying the given function to each node
 func (l *DLinkList[T]) Map(f func(T) T) *DLinkList[T] {
-	result := New[T]()
+	result := l.newEmpty()
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		result.Append(f(current.Value))
-		current = current.Next
+		result.Append(f(current.value))
+		current = current.next
 	}
 
 	return result
 }
 
-// MapFrom returns a new doubly linked list containing the result of applying the given function to each node starting from the given index
-func (l *DLinkList[T]) MapFrom(index uint64, f func(T) T) *DLinkList[T] {
-	result := New[T]()
-
-	if index > l.size {
-		return result
+// MapCtx generates a new list like Map, it checks ctx every
+// common.CtxCheckInterval nodes and returns its error (and no list) when it's
+// done
+func (l *DLinkList[T]) MapCtx(ctx context.Context, f func(T) T) (*DLinkList[T], error) {
+	newList := l.newEmpty()
+	err := l.ForEachCtx(ctx, func(v *T) {
+		newList.Append(f(*v))
+	})
+	if err != nil {
+		return nil, err
 	}
+	return newList, nil
+}
 
-	if l.IsEmpty() {
-		return result
-	}
+// MapIndexed generates a new list by applying the function to all the values in
+// the list and their index
+func (l *DLinkList[T]) MapIndexed(f func(uint64, T) T) *DLinkList[T] {
+	items := make([]T, 0, l.size)
+	l.ForEachIndexed(func(i uint64, v *T) {
+		items = append(items, f(i, *v))
+	})
+	return l.fromSlice(items)
+}
 
-	current, err := l.GetAt(index)
-	if err != nil {
+// MapFrom returns a new doubly linked list containing the result of applying the given function to each node starting from the given index
+func (l *DLinkList[T]) MapFrom(index uint64, f func(T) T) *DLinkList[T] {
+	result := l.newEmpty()
+
+	if l.checkIndex(index) != nil {
 		return result
 	}
 
+	current := l.nodeAt(index)
 	for current != nil {
-		result.Append(f(current.Value))
-		current = current.Next
+		result.Append(f(current.value))
+		current = current.next
 		if current == nil {
 			break
 		}
@@ -760,7 +1237,7 @@ func (l *DLinkList[T]) MapFrom(index uint64, f func(T) T) *DLinkList[T] {
 
 // MapRange returns a new doubly linked list containing the result of applying the given function to each node in the range [start, end)
 func (l *DLinkList[T]) MapRange(start, end uint64, f func(T) T) *DLinkList[T] {
-	result := New[T]()
+	result := l.newEmpty()
 
 	if start > end || start > l.size || end > l.size {
 		return result
@@ -776,8 +1253,8 @@ func (l *DLinkList[T]) MapRange(start, end uint64, f func(T) T) *DLinkList[T] {
 	}
 
 	for i := start; i <= end; i++ {
-		result.Append(f(current.Value))
-		current = current.Next
+		result.Append(f(current.value))
+		current = current.next
 		if current == nil {
 			break
 		}
@@ -793,52 +1270,232 @@ func (l *DLinkList[T]) Reduce(f func(T, T) T) T {
 		return rVal
 	}
 
-	result := l.Head.Value
-	current := l.Head.Next
+	result := l.head.value
+	current := l.head.next
 	for current != nil {
-		result = f(result, current.Value)
-		current = current.Next
+		result = f(result, current.value)
+		current = current.next
+	}
+
+	return result
+}
+
+// ReduceInto reduces the doubly linked list to a value of a different type, starting from
+// initial and applying fn to each value from the head to the tail
+func ReduceInto[T, A any](l *DLinkList[T], initial A, fn func(A, T) A) A {
+	result := initial
+	for current := l.head; current != nil; current = current.next {
+		result = fn(result, current.value)
 	}
+	return result
+}
+
+// ReduceRight reduces the doubly linked list to a value like ReduceInto,
+// applying fn to each value from the tail to the head
+func ReduceRight[T, A any](l *DLinkList[T], initial A, fn func(A, T) A) A {
+	result := initial
+	for current := l.tail; current != nil; current = current.prev {
+		result = fn(result, current.value)
+	}
+	return result
+}
+
+// MapTo returns a new list with the result of fn applied to every value of src,
+// in the same order. Unlike Map the result can hold a different type, e.g. to
+// extract the IDs of a list of structs. Its values are compared with == like
+// the ones of New, use MapToWithComparator when U is not comparable
+func MapTo[T any, U comparable](src *DLinkList[T], fn func(T) U) *DLinkList[U] {
+	return mapInto(src, fn, New[U]())
+}
+
+// MapToWithComparator is like MapTo, the new list compares its values with
+// the given function
+func MapToWithComparator[T, U any](src *DLinkList[T], fn func(T) U, equals func(a, b U) bool) *DLinkList[U] {
+	return mapInto(src, fn, NewWithComparator(equals))
+}
 
+// mapInto fills result with fn applied to every element of src
+func mapInto[T, U any](src *DLinkList[T], fn func(T) U, result *DLinkList[U]) *DLinkList[U] {
+	for current := src.head; current != nil; current = current.next {
+		result.Append(fn(current.value))
+	}
 	return result
 }
 
-// Copy returns a new doubly linked list with the same nodes as the original doubly linked list
+// Copy returns a new doubly linked list with the same nodes as the original doubly linked list. The copy is shallow: the values are
+// copied by assignment, so pointers, slices and maps inside them are shared
+// with the original (use CloneWith for a deep copy)
 func (l *DLinkList[T]) Copy() *DLinkList[T] {
-	newList := New[T]()
+	newList := l.newEmpty()
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		newList.Append(current.Value)
-		current = current.Next
+		newList.Append(current.value)
+		current = current.next
+	}
+
+	return newList
+}
+
+// CopyRange returns a new doubly linked list with the nodes in the range [start, end) of the original one
+// (empty if start > end or end > size), the copy is shallow like Copy
+func (l *DLinkList[T]) CopyRange(start, end uint64) *DLinkList[T] {
+	newList := l.newEmpty()
+	if start >= end || end > l.size {
+		return newList
+	}
+
+	current := l.nodeAt(start)
+	for i := start; i < end; i++ {
+		newList.Append(current.value)
+		current = current.next
 	}
 
 	return newList
 }
 
+// CloneWith returns a copy of the list with a copy of every value made by
+// copier, to deep copy values that hold pointers, slices or maps
+func (l *DLinkList[T]) CloneWith(copier func(T) T) *DLinkList[T] {
+	newList := l.Copy()
+	newList.ForEach(func(v *T) {
+		*v = copier(*v)
+	})
+	return newList
+}
+
+// fromSlice returns a new list, comparing its values like l, with the given values
+func (l *DLinkList[T]) fromSlice(items []T) *DLinkList[T] {
+	newList := l.newEmpty()
+	for _, item := range items {
+		newList.Append(item)
+	}
+	return newList
+}
+
+// Chunk splits the values of the list in consecutive new lists of size values
+// (the last one may be shorter). It returns nil if size is 0 or the list is empty
+func (l *DLinkList[T]) Chunk(size uint64) []*DLinkList[T] {
+	if size == 0 || l.size == 0 {
+		return nil
+	}
+	items := l.ToSlice()
+	chunks := make([]*DLinkList[T], 0, (l.size+size-1)/size)
+	for start := uint64(0); start < l.size; start += size {
+		chunks = append(chunks, l.fromSlice(items[start:min(start+size, l.size)]))
+	}
+	return chunks
+}
+
+// Windows returns all the sliding windows of size consecutive values of the
+// list as new lists, in order ([0, size), [1, size+1), ...). It returns nil
+// if size is 0 or greater than the size of the list
+func (l *DLinkList[T]) Windows(size uint64) []*DLinkList[T] {
+	if size == 0 || size > l.size {
+		return nil
+	}
+	items := l.ToSlice()
+	windows := make([]*DLinkList[T], 0, l.size-size+1)
+	for start := uint64(0); start+size <= l.size; start++ {
+		windows = append(windows, l.fromSlice(items[start:start+size]))
+	}
+	return windows
+}
+
 // Merge appends the nodes of the given doubly linked list to the original doubly linked list
 func (l *DLinkList[T]) Merge(list *DLinkList[T]) {
 	if list.IsEmpty() {
 		return
 	}
 
-	current := list.Head
+	current := list.head
 	for current != nil {
-		l.Append(current.Value)
-		current = current.Next
+		l.Append(current.value)
+		current = current.next
 	}
 	// clear the list
 	list.Clear()
 }
 
+// SplitAt splits the list in two: left gets the nodes in [0, index) and right
+// the nodes in [index, size). The nodes are moved (not copied), so the list is
+// left empty
+func (l *DLinkList[T]) SplitAt(index uint64) (*DLinkList[T], *DLinkList[T], error) {
+	if err := l.checkPosition(index); err != nil {
+		return nil, nil, err
+	}
+
+	left := &DLinkList[T]{size: index, equals: l.equals, key: l.key, hasher: l.hasher}
+	right := &DLinkList[T]{size: l.size - index, equals: l.equals, key: l.key, hasher: l.hasher}
+	switch index {
+	case 0:
+		right.head, right.tail = l.head, l.tail
+	case l.size:
+		left.head, left.tail = l.head, l.tail
+	default:
+		node := l.nodeAt(index)
+		left.head, left.tail = l.head, node.prev
+		right.head, right.tail = node, l.tail
+		node.prev.next = nil
+		node.prev = nil
+	}
+
+	l.reset()
+	return left, right, nil
+}
+
+// Splice moves all the nodes of the other list into this one, so that the
+// first of them ends up at the given index (use Size() to append them). The
+// nodes are re-linked rather than copied and the other list is left empty
+func (l *DLinkList[T]) Splice(index uint64, list *DLinkList[T]) error {
+	if list == l {
+		return ErrSameList
+	}
+	if err := l.checkPosition(index); err != nil {
+		return err
+	}
+	if list.head == nil {
+		return nil
+	}
+
+	var inserted []T
+	if l.obs.Insert != nil {
+		inserted = list.ToSlice()
+	}
+	if index == l.size {
+		list.head.prev = l.tail
+		if l.tail == nil {
+			l.head = list.head
+		} else {
+			l.tail.next = list.head
+		}
+		l.tail = list.tail
+	} else {
+		node := l.nodeAt(index)
+		list.head.prev = node.prev
+		list.tail.next = node
+		if node.prev == nil {
+			l.head = list.head
+		} else {
+			node.prev.next = list.head
+		}
+		node.prev = list.tail
+	}
+
+	l.size += list.size
+	list.reset()
+	l.obs.Inserted(index, inserted...)
+	return nil
+}
+
 // ReverseCopy returns a new doubly linked list with the nodes of the original doubly linked list in reverse order
 func (l *DLinkList[T]) ReverseCopy() *DLinkList[T] {
-	newList := New[T]()
+	newList := l.newEmpty()
 
-	current := l.Tail
+	current := l.tail
 	for current != nil {
-		newList.Append(current.Value)
-		current = current.Prev
+		newList.Append(current.value)
+		current = current.prev
 	}
 
 	return newList
@@ -850,10 +1507,10 @@ func (l *DLinkList[T]) ReverseMerge(list *DLinkList[T]) {
 		return
 	}
 
-	current := list.Tail
+	current := list.tail
 	for current != nil {
-		l.Append(current.Value)
-		current = current.Prev
+		l.Append(current.value)
+		current = current.prev
 	}
 	// clear the list
 	list.Clear()
@@ -861,34 +1518,107 @@ func (l *DLinkList[T]) ReverseMerge(list *DLinkList[T]) {
 
 // Equal returns true if the given doubly linked list is equal to the original doubly linked list
 func (l *DLinkList[T]) Equal(list *DLinkList[T]) bool {
-	current1 := l.Head
-	current2 := list.Head
+	current1 := l.head
+	current2 := list.head
 
 	for current1 != nil && current2 != nil {
-		if current1.Value != current2.Value {
+		if !l.equal(current1.value, current2.value) {
 			return false
 		}
-		current1 = current1.Next
-		current2 = current2.Next
+		current1 = current1.next
+		current2 = current2.next
 	}
 
 	return current1 == nil && current2 == nil
 }
 
+// Compare compares the list with other lexicographically (like bytes.Compare),
+// element by element from the head with compare, which returns a negative
+// number, zero or a positive number like cmp.Compare. It returns -1, 0 or +1,
+// a list that is a prefix of the other is the smaller one
+func (l *DLinkList[T]) Compare(other *DLinkList[T], compare func(a, b T) int) int {
+	a, o := l.head, other.head
+	for i := uint64(0); i < min(l.size, other.size); i++ {
+		if c := compare(a.value, o.value); c != 0 {
+			return cmp.Compare(c, 0)
+		}
+		a, o = a.next, o.next
+	}
+	return cmp.Compare(l.size, other.size)
+}
+
 // Swap swaps the nodes at the given indices
 func (l *DLinkList[T]) Swap(i, j uint64) error {
-	node1, err := l.GetAt(i)
-	if err != nil {
+	if err := l.checkIndex(i); err != nil {
+		return err
+	}
+	if err := l.checkIndex(j); err != nil {
 		return err
 	}
 
-	node2, err := l.GetAt(j)
-	if err != nil {
+	node1, node2 := l.nodeAt(i), l.nodeAt(j)
+	node1.value, node2.value = node2.value, node1.value
+
+	return nil
+}
+
+// SwapNodes swaps the positions of two nodes of the list in O(1) by re-linking
+// them, so (unlike Swap) the values stay in their nodes and references to the
+// nodes (e.g. from Find or GetAt) follow them. The nodes must belong to the
+// list (see MoveToFront), the observers are not notified
+func (l *DLinkList[T]) SwapNodes(a, b *Node[T]) error {
+	if err := l.checkNode(a); err != nil {
 		return err
 	}
+	if err := l.checkNode(b); err != nil {
+		return err
+	}
+	if a == b {
+		return nil
+	}
+
+	if b.next == a {
+		a, b = b, a
+	}
+	if a.next == b {
+		l.unlink(a)
+		l.linkAfter(b, a)
+		return nil
+	}
+	aPrev, bPrev := a.prev, b.prev
+	l.unlink(a)
+	l.unlink(b)
+	l.linkAfter(aPrev, b)
+	l.linkAfter(bPrev, a)
+	return nil
+}
 
-	node1.Value, node2.Value = node2.Value, node1.Value
+// MoveToFront moves a node of the list to the front in O(1) by re-linking it
+// (e.g. to keep the most recently used entry first in an LRU cache). The node
+// must belong to the list: ErrNotInList is returned for nil and detached
+// nodes, but a node of another list is only detected when it's its head or
+// tail, and moving it corrupts both lists. The observers are not notified
+func (l *DLinkList[T]) MoveToFront(node *Node[T]) error {
+	if err := l.checkNode(node); err != nil {
+		return err
+	}
+	if node != l.head {
+		l.unlink(node)
+		l.linkAfter(nil, node)
+	}
+	return nil
+}
 
+// MoveToBack moves a node of the list to the back in O(1) by re-linking it,
+// the node must belong to the list (see MoveToFront)
+func (l *DLinkList[T]) MoveToBack(node *Node[T]) error {
+	if err := l.checkNode(node); err != nil {
+		return err
+	}
+	if node != l.tail {
+		l.unlink(node)
+		l.linkAfter(l.tail, node)
+	}
 	return nil
 }
 
@@ -905,26 +1635,122 @@ func (l *DLinkList[T]) Sort(f func(T, T) bool) {
 	}
 
 	nodes := make([]*Node[T], 0, l.Size())
-	current := l.Head
+	current := l.head
 	for current != nil {
 		nodes = append(nodes, current)
-		current = current.Next
+		current = current.next
 	}
 
 	quickSort(nodes, f, 0, len(nodes)-1)
 
-	l.Head = nodes[0]
-	l.Tail = nodes[len(nodes)-1]
+	l.head = nodes[0]
+	l.tail = nodes[len(nodes)-1]
+	l.head.prev = nil
 
 	var i int
 	for i = 0; i < len(nodes)-1; i++ {
-		nodes[i].Next = nodes[i+1]
-		nodes[i+1].Prev = nodes[i]
+		nodes[i].next = nodes[i+1]
+		nodes[i+1].prev = nodes[i]
+	}
+	nodes[i].next = nil
+}
+
+// IsSorted returns true if the doubly linked list is sorted according to the given function
+func (l *DLinkList[T]) IsSorted(f func(T, T) bool) bool {
+	if l.head == nil {
+		return true
 	}
-	nodes[i].Next = nil
+	for current := l.head; current.next != nil; current = current.next {
+		if f(current.next.value, current.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// SearchSorted returns the index where value would be inserted in a list
+// sorted according to less: the index of the first node greater than value, so
+// after the nodes equal to it (the list size if there are none). The list is
+// scanned from the head, the result is undefined if it isn't sorted
+func (l *DLinkList[T]) SearchSorted(value T, less func(T, T) bool) uint64 {
+	index, _ := l.searchSorted(value, less)
+	return index
 }
 
-func quickSort[T comparable](nodes []*Node[T], f func(T, T) bool, low, high int) {
+// searchSorted returns the index and the first node greater than value (nil if
+// there are none)
+func (l *DLinkList[T]) searchSorted(value T, less func(T, T) bool) (uint64, *Node[T]) {
+	index := uint64(0)
+	current := l.head
+	for current != nil && !less(value, current.value) {
+		current = current.next
+		index++
+	}
+	return index, current
+}
+
+// InsertSorted inserts value in a list sorted according to less keeping it
+// sorted (after the nodes equal to it, see SearchSorted) and returns its index
+func (l *DLinkList[T]) InsertSorted(value T, less func(T, T) bool) uint64 {
+	index, next := l.searchSorted(value, less)
+	switch {
+	case next == nil:
+		l.Append(value)
+	case next == l.head:
+		l.Prepend(value)
+	default:
+		l.insertBefore(next, value)
+		l.obs.Inserted(index, value)
+	}
+	return index
+}
+
+// Shuffle randomly reorders the nodes of the doubly linked list using rng (if
+// rng is nil the global math/rand source is used). Nodes are re-linked, not
+// copied, so pointers to them stay valid
+func (l *DLinkList[T]) Shuffle(rng *rand.Rand) {
+	if l.Size() < 2 {
+		return
+	}
+
+	nodes := make([]*Node[T], 0, l.Size())
+	for current := l.head; current != nil; current = current.next {
+		nodes = append(nodes, current)
+	}
+	common.Shuffle(rng, nodes)
+
+	l.head = nodes[0]
+	l.tail = nodes[len(nodes)-1]
+	l.head.prev = nil
+	l.tail.next = nil
+	for i := 0; i < len(nodes)-1; i++ {
+		nodes[i].next = nodes[i+1]
+		nodes[i+1].prev = nodes[i]
+	}
+}
+
+// Sample returns a new doubly linked list with n values chosen at random
+// (without replacement) using rng (if rng is nil the global math/rand source
+// is used). If n is greater than the size of the list, all the values are
+// returned in random order
+func (l *DLinkList[T]) Sample(n uint64, rng *rand.Rand) *DLinkList[T] {
+	values := make([]T, 0, l.Size())
+	for current := l.head; current != nil; current = current.next {
+		values = append(values, current.value)
+	}
+	if n > uint64(len(values)) {
+		n = uint64(len(values))
+	}
+	common.Sample(rng, values, int(n))
+
+	result := l.newEmpty()
+	for _, v := range values[:n] {
+		result.Append(v)
+	}
+	return result
+}
+
+func quickSort[T any](nodes []*Node[T], f func(T, T) bool, low, high int) {
 	if low < high {
 		p := partition(nodes, f, low, high)
 		quickSort(nodes, f, low, p-1)
@@ -932,12 +1758,12 @@ func quickSort[T comparable](nodes []*Node[T], f func(T, T) bool, low, high int)
 	}
 }
 
-func partition[T comparable](nodes []*Node[T], f func(T, T) bool, low, high int) int {
+func partition[T any](nodes []*Node[T], f func(T, T) bool, low, high int) int {
 	pivot := nodes[high]
 	i := low
 
 	for j := low; j < high; j++ {
-		if f(nodes[j].Value, pivot.Value) {
+		if f(nodes[j].value, pivot.value) {
 			nodes[i], nodes[j] = nodes[j], nodes[i]
 			i++
 		}
@@ -950,14 +1776,14 @@ func partition[T comparable](nodes []*Node[T], f func(T, T) bool, low, high int)
 
 // FindAll returns a new doubly linked list containing all nodes that satisfy the given function
 func (l *DLinkList[T]) FindAll(f func(T) bool) *DLinkList[T] {
-	newList := New[T]()
+	newList := l.newEmpty()
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if f(current.Value) {
-			newList.Append(current.Value)
+		if f(current.value) {
+			newList.Append(current.value)
 		}
-		current = current.Next
+		current = current.next
 	}
 
 	return newList
@@ -967,16 +1793,16 @@ func (l *DLinkList[T]) FindAll(f func(T) bool) *DLinkList[T] {
 func (l *DLinkList[T]) FindLast(f func(T) bool) (*Node[T], error) {
 	var result *Node[T]
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if f(current.Value) {
+		if f(current.value) {
 			result = current
 		}
-		current = current.Next
+		current = current.next
 	}
 
 	if result == nil {
-		return nil, errors.New(ErrValueNotFound)
+		return nil, ErrNotFound
 	}
 
 	return result, nil
@@ -984,14 +1810,14 @@ func (l *DLinkList[T]) FindLast(f func(T) bool) (*Node[T], error) {
 
 // FindLastIndex returns the index of the last node that satisfies the given function
 func (l *DLinkList[T]) FindLastIndex(f func(T) bool) int {
-	current := l.Head
+	current := l.head
 	index := -1
 	i := 0
 	for current != nil {
-		if f(current.Value) {
+		if f(current.value) {
 			index = i
 		}
-		current = current.Next
+		current = current.next
 		i++
 	}
 
@@ -1000,15 +1826,95 @@ func (l *DLinkList[T]) FindLastIndex(f func(T) bool) int {
 
 // FindIndex returns the index of the first node that satisfies the given function
 func (l *DLinkList[T]) FindIndex(f func(T) bool) int {
-	current := l.Head
+	current := l.head
 	index := 0
 	for current != nil {
-		if f(current.Value) {
+		if f(current.value) {
 			return index
 		}
-		current = current.Next
+		current = current.next
 		index++
 	}
 
 	return -1
 }
+
+// Join returns a new list with the values of all the given lists in order
+// (nil lists are skipped), it's the inverse of Chunk. The new list compares
+// its values like the first list (with == if all of them are nil)
+func Join[T any](parts ...*DLinkList[T]) *DLinkList[T] {
+	var first *DLinkList[T]
+	var items []T
+	for _, part := range parts {
+		if part != nil {
+			if first == nil {
+				first = part
+			}
+			items = append(items, part.ToSlice()...)
+		}
+	}
+	if first == nil {
+		return NewWithComparator[T](nil)
+	}
+	return first.fromSlice(items)
+}
+
+// Flatten returns a new list with the values of all the lists in l in order,
+// compared like the ones of the first list (see Join)
+func Flatten[T any](l *DLinkList[*DLinkList[T]]) *DLinkList[T] {
+	return Join(l.ToSlice()...)
+}
+
+// Min returns the smallest element of the list according to less (the first
+// one if there are several), or ErrEmpty if the list is empty
+func Min[T any](l *DLinkList[T], less func(T, T) bool) (T, error) {
+	if l.head == nil {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+
+	result := l.head.value
+	for current := l.head.next; current != nil; current = current.next {
+		if less(current.value, result) {
+			result = current.value
+		}
+	}
+	return result, nil
+}
+
+// Max returns the largest element of the list according to less (the first
+// one if there are several), or ErrEmpty if the list is empty
+func Max[T any](l *DLinkList[T], less func(T, T) bool) (T, error) {
+	return Min(l, func(x, y T) bool { return less(y, x) })
+}
+
+// MinOrdered returns the smallest element of a list of an ordered type
+func MinOrdered[T cmp.Ordered](l *DLinkList[T]) (T, error) {
+	return Min(l, cmp.Less[T])
+}
+
+// MaxOrdered returns the largest element of a list of an ordered type
+func MaxOrdered[T cmp.Ordered](l *DLinkList[T]) (T, error) {
+	return Max(l, cmp.Less[T])
+}
+
+// Sum returns the sum of all the elements of a numeric list (0 if it's empty)
+func Sum[T common.Number](l *DLinkList[T]) T {
+	return ReduceInto(l, T(0), func(acc, v T) T { return acc + v })
+}
+
+// Average returns the arithmetic mean of the elements of a numeric list
+// (computed in float64), or ErrEmpty if the list is empty
+func Average[T common.Number](l *DLinkList[T]) (float64, error) {
+	if l.head == nil {
+		return 0, ErrEmpty
+	}
+
+	var sum float64
+	var count uint64
+	for current := l.head; current != nil; current = current.next {
+		sum += float64(current.value)
+		count++
+	}
+	return sum / float64(count), nil
+}
@@ -16,9 +16,19 @@
 package dlinkList_test
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
+	ctxtest "github.com/pzaino/gods/internal/ctxtest"
+	common "github.com/pzaino/gods/pkg/common"
 	dlinkList "github.com/pzaino/gods/pkg/dlinkList"
 )
 
@@ -65,6 +75,25 @@ func TestPrepend(t *testing.T) {
 	}
 }
 
+func TestAppendNPrependNDeleteN(t *testing.T) {
+	l := dlinkList.New[int]()
+	l.AppendN()
+	l.AppendN(3, 4)
+	l.PrependN(1, 2)
+	l.AppendN(5)
+	if !slices.Equal(l.ToSlice(), []int{1, 2, 3, 4, 5}) || l.Size() != 5 {
+		t.Errorf("expected [1 2 3 4 5], got %v", l.ToSlice())
+	}
+
+	l.AppendN(1)
+	if n := l.DeleteN(1, 1, 1, 9, 4); n != 3 || !slices.Equal(l.ToSlice(), []int{2, 3, 5}) {
+		t.Errorf("expected 3 nodes deleted and [2 3 5] left, got %d and %v", n, l.ToSlice())
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestRemove(t *testing.T) {
 	list := dlinkList.New[int]()
 	list.Append(1)
@@ -143,20 +172,20 @@ func TestIsEmptyAfterRemove(t *testing.T) {
 func TestGetFirst(t *testing.T) {
 	list := dlinkList.New[int]()
 	list.Append(1)
-	if list.GetFirst().Value != 1 {
-		t.Errorf("Expected first element to be 1, but got %v", list.GetFirst().Value)
+	if list.GetFirst().Value() != 1 {
+		t.Errorf("Expected first element to be 1, but got %v", list.GetFirst().Value())
 	}
 }
 
 func TestGetLast(t *testing.T) {
 	list := dlinkList.New[int]()
 	list.Append(1)
-	if list.GetLast().Value != 1 {
-		t.Errorf("Expected last element to be 1, but got %v", list.GetLast().Value)
+	if list.GetLast().Value() != 1 {
+		t.Errorf("Expected last element to be 1, but got %v", list.GetLast().Value())
 	}
 	list.Append(2)
-	if list.GetLast().Value != 2 {
-		t.Errorf("Expected last element to be 2, but got %v", list.GetLast().Value)
+	if list.GetLast().Value() != 2 {
+		t.Errorf("Expected last element to be 2, but got %v", list.GetLast().Value())
 	}
 }
 
@@ -169,8 +198,8 @@ func TestGetAt(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if node.Value != 2 {
-		t.Errorf(errWrongValue, 2, node.Value)
+	if node.Value() != 2 {
+		t.Errorf(errWrongValue, 2, node.Value())
 	}
 }
 
@@ -205,8 +234,8 @@ func TestInsertAt(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 2 {
-		t.Errorf(errExpectedValToBe, 1, 2, item.Value)
+	if item.Value() != 2 {
+		t.Errorf(errExpectedValToBe, 1, 2, item.Value())
 	}
 }
 
@@ -241,8 +270,8 @@ func TestInsertAtHead(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 2 {
-		t.Errorf(errExpectedValToBe, 0, 2, item.Value)
+	if item.Value() != 2 {
+		t.Errorf(errExpectedValToBe, 0, 2, item.Value())
 	}
 }
 
@@ -261,8 +290,8 @@ func TestInsertAtTail(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 2 {
-		t.Errorf(errExpectedValToBe, 1, 2, item.Value)
+	if item.Value() != 2 {
+		t.Errorf(errExpectedValToBe, 1, 2, item.Value())
 	}
 }
 
@@ -281,8 +310,8 @@ func TestInsertAtMiddle(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 2 {
-		t.Errorf(errExpectedValToBe, 1, 2, item.Value)
+	if item.Value() != 2 {
+		t.Errorf(errExpectedValToBe, 1, 2, item.Value())
 	}
 }
 
@@ -301,8 +330,8 @@ func TestRemoveAt(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 1, 3, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 1, 3, item.Value())
 	}
 }
 
@@ -338,8 +367,8 @@ func TestRemoveAtHead(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 2 {
-		t.Errorf(errExpectedValToBe, 0, 2, item.Value)
+	if item.Value() != 2 {
+		t.Errorf(errExpectedValToBe, 0, 2, item.Value())
 	}
 }
 
@@ -358,8 +387,8 @@ func TestRemoveAtTail(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 0, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 0, 1, item.Value())
 	}
 }
 
@@ -379,8 +408,8 @@ func TestRemoveAtMiddle(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 3 {
-		t.Errorf(errExpectedValToBe, 1, 3, item.Value)
+	if item.Value() != 3 {
+		t.Errorf(errExpectedValToBe, 1, 3, item.Value())
 	}
 }
 
@@ -394,22 +423,54 @@ func TestReverse(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 3 {
-		t.Errorf(errExpectedValToBe, 0, 3, item.Value)
+	if item.Value() != 3 {
+		t.Errorf(errExpectedValToBe, 0, 3, item.Value())
 	}
 	item, err = list.GetAt(1)
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 2 {
-		t.Errorf(errExpectedValToBe, 1, 2, item.Value)
+	if item.Value() != 2 {
+		t.Errorf(errExpectedValToBe, 1, 2, item.Value())
 	}
 	item, err = list.GetAt(2)
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 2, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 2, 1, item.Value())
+	}
+}
+
+func TestRotateLeftAndRight(t *testing.T) {
+	values := []int{1, 2, 3, 4, 5}
+	for _, n := range []uint64{0, 1, 2, 4, 5, 7} {
+		k := int(n % uint64(len(values)))
+		left := append(slices.Clone(values[k:]), values[:k]...)
+		right := append(slices.Clone(values[len(values)-k:]), values[:len(values)-k]...)
+
+		l := newFromSlice(values)
+		l.RotateLeft(n)
+		if !slices.Equal(l.ToSlice(), left) || l.CheckInvariants() != nil {
+			t.Errorf("expected RotateLeft(%d) to give %v, got %v (%v)", n, left, l.ToSlice(), l.CheckInvariants())
+		}
+		l.Append(6)
+		if last := l.ToSlice()[len(values)]; last != 6 {
+			t.Errorf("expected 6 to be appended after RotateLeft(%d), got %d", n, last)
+		}
+
+		r := newFromSlice(values)
+		r.RotateRight(n)
+		if !slices.Equal(r.ToSlice(), right) || r.CheckInvariants() != nil {
+			t.Errorf("expected RotateRight(%d) to give %v, got %v (%v)", n, right, r.ToSlice(), r.CheckInvariants())
+		}
+	}
+
+	empty := dlinkList.New[int]()
+	empty.RotateLeft(3)
+	empty.RotateRight(3)
+	if !empty.IsEmpty() {
+		t.Error("expected the empty list to stay empty")
 	}
 }
 
@@ -429,8 +490,8 @@ func TestReverseSingle(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 0, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 0, 1, item.Value())
 	}
 }
 
@@ -443,15 +504,15 @@ func TestReverseDouble(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 2 {
-		t.Errorf(errExpectedValToBe, 0, 2, item.Value)
+	if item.Value() != 2 {
+		t.Errorf(errExpectedValToBe, 0, 2, item.Value())
 	}
 	item, err = list.GetAt(1)
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 1, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 1, 1, item.Value())
 	}
 }
 
@@ -470,22 +531,22 @@ func TestReverseTriple(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value.index != 3 {
-		t.Errorf(errExpectedValToBe, 0, 3, item.Value)
+	if item.Value().index != 3 {
+		t.Errorf(errExpectedValToBe, 0, 3, item.Value())
 	}
 	item, err = list.GetAt(1)
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value.index != 2 {
-		t.Errorf(errExpectedValToBe, 1, 2, item.Value)
+	if item.Value().index != 2 {
+		t.Errorf(errExpectedValToBe, 1, 2, item.Value())
 	}
 	item, err = list.GetAt(2)
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value.index != 1 {
-		t.Errorf(errExpectedValToBe, 2, 1, item.Value)
+	if item.Value().index != 1 {
+		t.Errorf(errExpectedValToBe, 2, 1, item.Value())
 	}
 }
 
@@ -507,8 +568,8 @@ func TestCopy(t *testing.T) {
 		if err != nil {
 			t.Errorf(errNoError, err)
 		}
-		if item.Value != newItem.Value {
-			t.Errorf(errExpectedValToBe, i, item.Value, newItem.Value)
+		if item.Value() != newItem.Value() {
+			t.Errorf(errExpectedValToBe, i, item.Value(), newItem.Value())
 		}
 	}
 }
@@ -531,8 +592,8 @@ func TestMerge(t *testing.T) {
 		if err != nil {
 			t.Errorf(errNoError, err)
 		}
-		if item.Value != int(i)+1 {
-			t.Errorf(errExpectedValToBe, i, i+1, item.Value)
+		if item.Value() != int(i)+1 {
+			t.Errorf(errExpectedValToBe, i, i+1, item.Value())
 		}
 	}
 }
@@ -558,8 +619,8 @@ func TestMergeEmptyList(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 0, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 0, 1, item.Value())
 	}
 }
 
@@ -575,8 +636,8 @@ func TestMergeEmptyList2(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 0, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 0, 1, item.Value())
 	}
 }
 
@@ -592,8 +653,8 @@ func TestMergeEmptyList3(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 0, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 0, 1, item.Value())
 	}
 }
 
@@ -609,8 +670,8 @@ func TestMergeEmptyList5(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 0, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 0, 1, item.Value())
 	}
 }
 
@@ -626,8 +687,8 @@ func TestMergeEmptyList6(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 0, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 0, 1, item.Value())
 	}
 }
 
@@ -693,16 +754,16 @@ func TestInsert(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 4 {
-		t.Errorf(errWrongValue, 4, item.Value)
+	if item.Value() != 4 {
+		t.Errorf(errWrongValue, 4, item.Value())
 	}
 
 	item, err = list.GetAt(2)
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 2 {
-		t.Errorf(errWrongValue, 2, item.Value)
+	if item.Value() != 2 {
+		t.Errorf(errWrongValue, 2, item.Value())
 	}
 
 	// Test case 2: Insert at the end of the list
@@ -720,8 +781,8 @@ func TestInsert(t *testing.T) {
 		t.Errorf(errNoError, err)
 	}
 
-	if item.Value != 5 {
-		t.Errorf(errWrongValue, 5, item.Value)
+	if item.Value() != 5 {
+		t.Errorf(errWrongValue, 5, item.Value())
 	}
 
 	item, err = list.GetAt(3)
@@ -729,8 +790,8 @@ func TestInsert(t *testing.T) {
 		t.Errorf(errNoError, err)
 	}
 
-	if item.Value != 3 {
-		t.Errorf(errWrongValue, 3, item.Value)
+	if item.Value() != 3 {
+		t.Errorf(errWrongValue, 3, item.Value())
 	}
 
 	// Test case 3: Insert outside of the list
@@ -760,16 +821,16 @@ func TestInsertAtStart(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 4 {
-		t.Errorf(errWrongValue, 4, item.Value)
+	if item.Value() != 4 {
+		t.Errorf(errWrongValue, 4, item.Value())
 	}
 
 	item, err = list.GetAt(1)
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errWrongValue, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errWrongValue, 1, item.Value())
 	}
 }
 
@@ -792,16 +853,16 @@ func TestInsertAtEnd(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 4 {
-		t.Errorf(errWrongValue, 4, item.Value)
+	if item.Value() != 4 {
+		t.Errorf(errWrongValue, 4, item.Value())
 	}
 
 	item, err = list.GetAt(2)
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 3 {
-		t.Errorf(errWrongValue, 3, item.Value)
+	if item.Value() != 3 {
+		t.Errorf(errWrongValue, 3, item.Value())
 	}
 }
 
@@ -862,8 +923,8 @@ func TestFind(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if node.Value != 2 {
-		t.Errorf("Expected value to be 2, but got %v", node.Value)
+	if node.Value() != 2 {
+		t.Errorf("Expected value to be 2, but got %v", node.Value())
 	}
 
 	// Test case 2: Value does not exist in the list
@@ -1016,8 +1077,8 @@ func TestDeleteLast(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 2 {
-		t.Errorf(errExpectedValToBe, 1, 2, item.Value)
+	if item.Value() != 2 {
+		t.Errorf(errExpectedValToBe, 1, 2, item.Value())
 	}
 
 	list.DeleteLast()
@@ -1029,8 +1090,8 @@ func TestDeleteLast(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 0, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 0, 1, item.Value())
 	}
 
 	list.DeleteLast()
@@ -1063,8 +1124,8 @@ func TestDeleteFirst(t *testing.T) {
 		t.Errorf(errWrongSize, 2, list.Size())
 	}
 
-	if list.GetFirst().Value != 2 {
-		t.Errorf("Expected first element to be 2, but got %v", list.GetFirst().Value)
+	if list.GetFirst().Value() != 2 {
+		t.Errorf("Expected first element to be 2, but got %v", list.GetFirst().Value())
 	}
 }
 
@@ -1162,6 +1223,40 @@ func TestToSliceFromIndex(t *testing.T) {
 	}
 }
 
+func TestToSliceRangeAndCopyRange(t *testing.T) {
+	list := dlinkList.New[int]()
+	for _, v := range []int{1, 2, 3, 4, 5} {
+		list.Append(v)
+	}
+
+	tests := []struct {
+		start, end uint64
+		expected   []int
+	}{
+		{0, 5, []int{1, 2, 3, 4, 5}},
+		{1, 3, []int{2, 3}},
+		{4, 5, []int{5}},
+		{2, 2, []int{}},
+		{3, 2, []int{}},
+		{0, 6, []int{}},
+	}
+	for _, test := range tests {
+		if result := list.ToSliceRange(test.start, test.end); !slices.Equal(result, test.expected) {
+			t.Errorf("expected ToSliceRange(%d, %d) to return %v, got %v", test.start, test.end, test.expected, result)
+		}
+		c := list.CopyRange(test.start, test.end)
+		if !slices.Equal(c.ToSlice(), test.expected) || c.Size() != uint64(len(test.expected)) {
+			t.Errorf("expected CopyRange(%d, %d) to return %v, got %v", test.start, test.end, test.expected, c.ToSlice())
+		}
+	}
+
+	c := list.CopyRange(1, 3)
+	c.Append(6)
+	if list.Size() != 5 || !slices.Equal(c.ToSlice(), []int{2, 3, 6}) {
+		t.Errorf("expected the copy to be independent, got %v and %v", list.ToSlice(), c.ToSlice())
+	}
+}
+
 func TestToSliceReverseFromIndex(t *testing.T) {
 	list := dlinkList.New[int]()
 	list.Append(1)
@@ -1513,24 +1608,24 @@ func TestReverseCopy(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 3 {
-		t.Errorf(errExpectedValToBe, 0, 3, item.Value)
+	if item.Value() != 3 {
+		t.Errorf(errExpectedValToBe, 0, 3, item.Value())
 	}
 
 	item, err = reverseCopy.GetAt(1)
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 2 {
-		t.Errorf(errExpectedValToBe, 1, 2, item.Value)
+	if item.Value() != 2 {
+		t.Errorf(errExpectedValToBe, 1, 2, item.Value())
 	}
 
 	item, err = reverseCopy.GetAt(2)
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if item.Value != 1 {
-		t.Errorf(errExpectedValToBe, 2, 1, item.Value)
+	if item.Value() != 1 {
+		t.Errorf(errExpectedValToBe, 2, 1, item.Value())
 	}
 }
 
@@ -1820,8 +1915,8 @@ func TestFindLast(t *testing.T) {
 	if err != nil {
 		t.Errorf(errNoError, err)
 	}
-	if node.Value != 2 {
-		t.Errorf("Expected value to be 2, but got %v", node.Value)
+	if node.Value() != 2 {
+		t.Errorf("Expected value to be 2, but got %v", node.Value())
 	}
 
 	// Test case 2: Value does not exist in the list
@@ -2246,3 +2341,1276 @@ func TestForEachReverseEmpty(t *testing.T) {
 		t.Errorf(errExpectedEmpty, result)
 	}
 }
+
+func TestIsSorted(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+	list := dlinkList.New[int]()
+	if !list.IsSorted(less) {
+		t.Errorf("Expected an empty list to be sorted")
+	}
+
+	list.Append(2)
+	list.Append(1)
+	if list.IsSorted(less) {
+		t.Errorf("Expected list not to be sorted")
+	}
+	list.Sort(less)
+	if !list.IsSorted(less) {
+		t.Errorf("Expected list to be sorted")
+	}
+}
+
+func TestInsertSorted(t *testing.T) {
+	type item struct{ key, seq int }
+	less := func(a, b item) bool { return a.key < b.key }
+	l := dlinkList.New[item]()
+	var inserted []uint64
+	l.OnInsert(func(index uint64, _ item) {
+		inserted = append(inserted, index)
+	})
+
+	rnd := rand.New(rand.NewSource(7))
+	for seq := 0; seq < 200; seq++ {
+		value := item{rnd.Intn(20), seq}
+		expected := l.SearchSorted(value, less)
+		if index := l.InsertSorted(value, less); index != expected {
+			t.Fatalf("expected %v at %d, got %d", value, expected, index)
+		}
+		if node, _ := l.GetAt(expected); node.Value() != value {
+			t.Fatalf("expected %v at %d, got %v", value, expected, node.Value())
+		}
+		if inserted[len(inserted)-1] != expected {
+			t.Fatalf("expected OnInsert at %d, got %d", expected, inserted[len(inserted)-1])
+		}
+	}
+
+	// Equal keys keep the insertion order
+	values := l.ToSlice()
+	if !l.IsSorted(less) || !slices.IsSortedFunc(values, func(a, b item) int {
+		return cmp.Or(cmp.Compare(a.key, b.key), cmp.Compare(a.seq, b.seq))
+	}) {
+		t.Errorf("expected a stable sorted list, got %v", values)
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+	if i := l.SearchSorted(item{key: 100}, less); i != l.Size() {
+		t.Errorf("expected %d, got %d", l.Size(), i)
+	}
+}
+
+// checkLinks verifies the list can be walked consistently from both ends
+func checkLinks(t *testing.T, list *dlinkList.DLinkList[int], expected []int) {
+	t.Helper()
+	if list.Size() != uint64(len(expected)) {
+		t.Fatalf(errWrongSize, len(expected), list.Size())
+	}
+	if !reflect.DeepEqual(list.ToSlice(), expected) && len(expected) > 0 {
+		t.Fatalf(errExpectedX, expected, list.ToSlice())
+	}
+	reversed := list.ToSliceReverse()
+	for i := range expected {
+		if reversed[len(expected)-1-i] != expected[i] {
+			t.Fatalf(errExpectedX, expected, reversed)
+		}
+	}
+	for i, v := range expected {
+		node, err := list.GetAt(uint64(i))
+		if err != nil {
+			t.Fatalf(errNoError, err)
+		}
+		if node.Value() != v {
+			t.Fatalf(errExpectedValToBe, i, v, node.Value())
+		}
+	}
+}
+
+func TestGetAtFromBothEnds(t *testing.T) {
+	list := dlinkList.New[int]()
+	for i := 0; i < 11; i++ {
+		list.Append(i)
+	}
+	checkLinks(t, list, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10})
+
+	if _, err := list.GetAt(11); err == nil {
+		t.Errorf(errYesError)
+	}
+}
+
+func TestInsertAndDeleteAtKeepLinks(t *testing.T) {
+	list := dlinkList.New[int]()
+	_ = list.InsertAt(0, 2)
+	_ = list.InsertAt(1, 4)
+	_ = list.InsertAt(1, 3)
+	_ = list.InsertAt(0, 1)
+	_ = list.InsertAt(4, 5)
+	checkLinks(t, list, []int{1, 2, 3, 4, 5})
+	if err := list.InsertAt(7, 0); err == nil {
+		t.Errorf(errYesError)
+	}
+
+	_ = list.DeleteAt(3)
+	checkLinks(t, list, []int{1, 2, 3, 5})
+	_ = list.DeleteAt(3)
+	checkLinks(t, list, []int{1, 2, 3})
+	_ = list.DeleteAt(0)
+	checkLinks(t, list, []int{2, 3})
+	if err := list.DeleteAt(2); err == nil {
+		t.Errorf(errYesError)
+	}
+	_ = list.DeleteAt(0)
+	_ = list.DeleteAt(0)
+	checkLinks(t, list, []int{})
+	if list.GetFirst() != nil || list.GetLast() != nil {
+		t.Errorf(errListNotEmpty)
+	}
+}
+
+func TestInsertBeforeAfterKeepLinks(t *testing.T) {
+	list := dlinkList.New[int]()
+	list.Append(2)
+	list.InsertBefore(2, 1)
+	list.InsertAfter(2, 4)
+	list.InsertBefore(4, 3)
+	checkLinks(t, list, []int{1, 2, 3, 4})
+
+	list.DeleteWithValue(4)
+	list.DeleteWithValue(1)
+	checkLinks(t, list, []int{2, 3})
+}
+
+func TestSwapFromBothEnds(t *testing.T) {
+	list := dlinkList.New[int]()
+	for i := 0; i < 6; i++ {
+		list.Append(i)
+	}
+	if err := list.Swap(0, 5); err != nil {
+		t.Fatalf(errNoError, err)
+	}
+	checkLinks(t, list, []int{5, 1, 2, 3, 4, 0})
+	if err := list.Swap(0, 6); err == nil {
+		t.Errorf(errYesError)
+	}
+}
+
+func newBenchmarkList(n int) *dlinkList.DLinkList[int] {
+	list := dlinkList.New[int]()
+	for i := 0; i < n; i++ {
+		list.Append(i)
+	}
+	return list
+}
+
+func BenchmarkGetAtHead(b *testing.B) {
+	list := newBenchmarkList(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = list.GetAt(10)
+	}
+}
+
+func BenchmarkGetAtTail(b *testing.B) {
+	list := newBenchmarkList(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = list.GetAt(100000 - 10)
+	}
+}
+
+func BenchmarkGetAtMiddle(b *testing.B) {
+	list := newBenchmarkList(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = list.GetAt(50000)
+	}
+}
+
+func BenchmarkInsertDeleteAtTail(b *testing.B) {
+	list := newBenchmarkList(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = list.InsertAt(100000-10, i)
+		_ = list.DeleteAt(100000 - 10)
+	}
+}
+
+func BenchmarkForReverseFrom(b *testing.B) {
+	list := newBenchmarkList(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list.ForReverseFrom(10, func(*int) {})
+	}
+}
+
+func newFromSlice(items []int) *dlinkList.DLinkList[int] {
+	list := dlinkList.New[int]()
+	for _, v := range items {
+		list.Append(v)
+	}
+	return list
+}
+
+func TestSplitAt(t *testing.T) {
+	tests := []struct {
+		index       uint64
+		left, right []int
+	}{
+		{0, []int{}, []int{1, 2, 3, 4, 5}},
+		{1, []int{1}, []int{2, 3, 4, 5}},
+		{2, []int{1, 2}, []int{3, 4, 5}},
+		{4, []int{1, 2, 3, 4}, []int{5}},
+		{5, []int{1, 2, 3, 4, 5}, []int{}},
+	}
+	for _, tt := range tests {
+		list := newFromSlice([]int{1, 2, 3, 4, 5})
+		left, right, err := list.SplitAt(tt.index)
+		if err != nil {
+			t.Fatalf(errNoError, err)
+		}
+		checkLinks(t, left, tt.left)
+		checkLinks(t, right, tt.right)
+		if !list.IsEmpty() {
+			t.Fatalf(errListNotEmpty)
+		}
+	}
+
+	list := newFromSlice([]int{1, 2})
+	if _, _, err := list.SplitAt(3); err == nil {
+		t.Fatalf(errYesError)
+	}
+	checkLinks(t, list, []int{1, 2})
+}
+
+func TestSplice(t *testing.T) {
+	tests := []struct {
+		index    uint64
+		expected []int
+	}{
+		{0, []int{8, 9, 1, 2, 3}},
+		{1, []int{1, 8, 9, 2, 3}},
+		{2, []int{1, 2, 8, 9, 3}},
+		{3, []int{1, 2, 3, 8, 9}},
+	}
+	for _, tt := range tests {
+		list := newFromSlice([]int{1, 2, 3})
+		other := newFromSlice([]int{8, 9})
+		if err := list.Splice(tt.index, other); err != nil {
+			t.Fatalf(errNoError, err)
+		}
+		checkLinks(t, list, tt.expected)
+		if !other.IsEmpty() || other.Size() != 0 {
+			t.Fatalf(errListNotEmpty)
+		}
+	}
+
+	list := dlinkList.New[int]()
+	if err := list.Splice(0, newFromSlice([]int{1, 2})); err != nil {
+		t.Fatalf(errNoError, err)
+	}
+	checkLinks(t, list, []int{1, 2})
+	if err := list.Splice(2, dlinkList.New[int]()); err != nil {
+		t.Fatalf(errNoError, err)
+	}
+	checkLinks(t, list, []int{1, 2})
+
+	if err := list.Splice(3, newFromSlice([]int{5})); err == nil {
+		t.Fatalf(errYesError)
+	}
+	if err := list.Splice(0, list); err != dlinkList.ErrSameList {
+		t.Fatalf(errExpectedX, dlinkList.ErrSameList, err)
+	}
+	checkLinks(t, list, []int{1, 2})
+}
+
+func BenchmarkSplitAtAndSplice(b *testing.B) {
+	list := dlinkList.New[int]()
+	for i := 0; i < 100000; i++ {
+		list.Append(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		left, right, _ := list.SplitAt(list.Size() / 2)
+		_ = left.Splice(left.Size(), right)
+		list = left
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	l := dlinkList.NewWithComparator(slices.Equal[[]int])
+	l.Append([]int{1})
+	l.Append([]int{2, 3})
+	l.Append([]int{1})
+
+	if !l.Contains([]int{2, 3}) {
+		t.Error("expected list to contain [2 3]")
+	}
+	if index := l.IndexOf([]int{2, 3}); index != 1 {
+		t.Errorf("expected index 1, got %d", index)
+	}
+	if index, err := l.LastIndexOf([]int{1}); err != nil || index != 2 {
+		t.Errorf("expected index 2, got %v (%v)", index, err)
+	}
+	if _, err := l.Find([]int{3, 2}); err == nil {
+		t.Error("expected an error for a missing value")
+	}
+
+	c := l.Copy()
+	if !l.Equal(c) {
+		t.Error("expected the copy to be equal to the original list")
+	}
+}
+
+func TestNewWithHasher(t *testing.T) {
+	hasher := common.NewHasher(func(v []int) uint64 { return uint64(len(v)) }, slices.Equal[[]int])
+	l := dlinkList.NewWithHasher(hasher)
+	for _, v := range [][]int{{1}, {2, 3}, {1}, {4}, {2, 3}} {
+		l.Append(v)
+	}
+
+	if !l.Contains([]int{4}) || l.Contains([]int{3, 2}) {
+		t.Error("expected the hasher equality to be used")
+	}
+	if !l.ContainsAll([]int{4}, []int{1}, []int{2, 3}) || l.ContainsAll([]int{1}, []int{5}) {
+		t.Error("expected ContainsAll to use the hasher")
+	}
+	if !l.ContainsAny([]int{5}, []int{2, 3}) || l.ContainsAny([]int{5}, []int{3, 2}) {
+		t.Error("expected ContainsAny to use the hasher")
+	}
+
+	c := l.Copy()
+	c.Deduplicate()
+	if c.Size() != 3 || l.Size() != 5 {
+		t.Errorf("expected 3 values left in the copy, got %d (original %d)", c.Size(), l.Size())
+	}
+	if !dlinkList.Features().Has(common.FeatureHasher) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureHasher, dlinkList.Features())
+	}
+}
+
+func TestReduceInto(t *testing.T) {
+	l := dlinkList.New[int]()
+	l.Append(1)
+	l.Append(2)
+	l.Append(3)
+	got := dlinkList.ReduceInto(l, []int{0}, func(acc []int, v int) []int {
+		return append(acc, v*10)
+	})
+	if !slices.Equal(got, []int{0, 10, 20, 30}) {
+		t.Errorf("expected [0 10 20 30], got %v", got)
+	}
+
+	if got := dlinkList.ReduceInto(dlinkList.New[int](), 42, func(acc int, _ int) int { return acc + 1 }); got != 42 {
+		t.Errorf("expected 42, got %d", got)
+	}
+}
+
+func TestMapTo(t *testing.T) {
+	type user struct {
+		id   int
+		tags []string
+	}
+	src := dlinkList.NewWithComparator(func(a, b user) bool { return a.id == b.id })
+	for _, u := range []user{{1, []string{"a"}}, {2, nil}, {3, []string{"b", "c"}}} {
+		src.Append(u)
+	}
+
+	ids := dlinkList.MapTo(src, func(u user) int { return u.id })
+	if !slices.Equal(ids.ToSlice(), []int{1, 2, 3}) || !ids.Contains(2) {
+		t.Errorf("expected the ids [1 2 3], got %v", ids.ToSlice())
+	}
+
+	parity := dlinkList.MapTo(src, func(u user) int { return u.id % 2 })
+	parity.Deduplicate()
+	if !slices.Equal(parity.ToSlice(), []int{1, 0}) {
+		t.Errorf("expected the deduplicated parities [1 0], got %v", parity.ToSlice())
+	}
+
+	tags := dlinkList.MapToWithComparator(src, func(u user) []string { return u.tags }, slices.Equal[[]string])
+	if tags.Size() != 3 || !tags.ContainsAll([]string{"b", "c"}) || tags.ContainsAll([]string{"c", "b"}) {
+		t.Errorf("expected the tags to be compared with the given function")
+	}
+
+	if empty := dlinkList.MapTo(dlinkList.New[int](), strconv.Itoa); !empty.IsEmpty() {
+		t.Errorf("expected an empty result, got %d values", empty.Size())
+	}
+}
+
+func TestReduceRight(t *testing.T) {
+	l := dlinkList.New[int]()
+	l.Append(1)
+	l.Append(2)
+	l.Append(3)
+	got := dlinkList.ReduceRight(l, []int{0}, func(acc []int, v int) []int {
+		return append(acc, v*10)
+	})
+	if !slices.Equal(got, []int{0, 30, 20, 10}) {
+		t.Errorf("expected [0 30 20 10], got %v", got)
+	}
+
+	// A right fold of a non-associative function
+	if got := dlinkList.ReduceRight(l, "", func(acc string, v int) string { return "(" + strconv.Itoa(v) + acc + ")" }); got != "(1(2(3)))" {
+		t.Errorf("expected (1(2(3))), got %s", got)
+	}
+	if got := dlinkList.ReduceRight(dlinkList.New[int](), 42, func(acc int, _ int) int { return acc + 1 }); got != 42 {
+		t.Errorf("expected 42, got %d", got)
+	}
+}
+
+func TestShuffleAndSample(t *testing.T) {
+	l := dlinkList.New[int]()
+	for i := 1; i <= 8; i++ {
+		l.Append(i)
+	}
+	head, tail := l.GetFirst(), l.GetLast()
+
+	l.Shuffle(rand.New(rand.NewSource(7)))
+	values := l.ToSlice()
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	if !slices.Equal(sorted, []int{1, 2, 3, 4, 5, 6, 7, 8}) {
+		t.Errorf("expected a permutation of [1..8], got %v", values)
+	}
+	// Links must be consistent in both directions
+	reversed := l.ToSliceReverse()
+	slices.Reverse(reversed)
+	if !slices.Equal(values, reversed) {
+		t.Errorf("inconsistent links: %v forward, %v backward", values, reversed)
+	}
+	// Nodes are re-linked, not copied
+	if n, _ := l.Find(1); n != head {
+		t.Error("expected the node holding 1 to be the original head node")
+	}
+	if n, _ := l.Find(8); n != tail {
+		t.Error("expected the node holding 8 to be the original tail node")
+	}
+
+	sample := l.Sample(3, nil)
+	if sample.Size() != 3 {
+		t.Fatalf("expected size 3, got %d", sample.Size())
+	}
+	seen := make(map[int]bool)
+	for _, v := range sample.ToSlice() {
+		if seen[v] || !l.Contains(v) {
+			t.Errorf("unexpected sampled value %v", v)
+		}
+		seen[v] = true
+	}
+	if l.Size() != 8 {
+		t.Errorf("expected Sample not to modify the list, got size %d", l.Size())
+	}
+}
+
+func TestUniqueAndDeduplicate(t *testing.T) {
+	build := func(values ...int) *dlinkList.DLinkList[int] {
+		l := dlinkList.New[int]()
+		for _, v := range values {
+			l.Append(v)
+		}
+		return l
+	}
+	values := func(l *dlinkList.DLinkList[int]) []int {
+		return dlinkList.ReduceInto(l, []int(nil), func(acc []int, v int) []int { return append(acc, v) })
+	}
+
+	l := build(1, 1, 2, 2, 2, 3, 1, 1)
+	l.Unique()
+	if got := values(l); !slices.Equal(got, []int{1, 2, 3, 1}) || l.Size() != 4 {
+		t.Errorf("expected [1 2 3 1], got %v (size %d)", got, l.Size())
+	}
+
+	l = build(3, 1, 3, 2, 1, 4, 2, 2)
+	l.Deduplicate()
+	if got := values(l); !slices.Equal(got, []int{3, 1, 2, 4}) || l.Size() != 4 {
+		t.Errorf("expected [3 1 2 4], got %v (size %d)", got, l.Size())
+	}
+	l.Append(5)
+	if got := values(l); !slices.Equal(got, []int{3, 1, 2, 4, 5}) {
+		t.Errorf("expected [3 1 2 4 5] after Append, got %v", got)
+	}
+
+	custom := dlinkList.NewWithComparator(slices.Equal[[]int])
+	for _, v := range [][]int{{1}, {2}, {1}, {2}, {2}} {
+		custom.Append(v)
+	}
+	custom.Deduplicate()
+	if custom.Size() != 2 {
+		t.Errorf("expected size 2, got %d", custom.Size())
+	}
+
+	empty := dlinkList.New[int]()
+	empty.Unique()
+	empty.Deduplicate()
+	if empty.Size() != 0 {
+		t.Errorf("expected empty list, got size %d", empty.Size())
+	}
+}
+
+func TestString(t *testing.T) {
+	l := dlinkList.New[string]()
+	l.Append("a")
+	l.Append("b")
+
+	var s fmt.Stringer = l
+	if got := s.String(); got != "[a b]" {
+		t.Errorf("expected [a b], got %s", got)
+	}
+	if got := l.StringFunc(func(v string) string { return v + v }); got != "[aa bb]" {
+		t.Errorf("expected [aa bb], got %s", got)
+	}
+	if got := dlinkList.New[int]().String(); got != "[]" {
+		t.Errorf("expected [], got %s", got)
+	}
+}
+
+func TestForEachErr(t *testing.T) {
+	l := dlinkList.New[int]()
+	for i := 1; i <= 5; i++ {
+		l.Append(i)
+	}
+
+	errStop := fmt.Errorf("stop")
+	var visited []int
+	err := l.ForEachErr(func(v *int) error {
+		visited = append(visited, *v)
+		if *v == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || !slices.Equal(visited, []int{1, 2, 3}) {
+		t.Errorf("expected to stop at 3 with %v, got %v (%v)", errStop, visited, err)
+	}
+
+	if err := l.ForEachErr(func(v *int) error { *v *= 2; return nil }); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !slices.Equal(l.ToSlice(), []int{2, 4, 6, 8, 10}) {
+		t.Errorf("expected [2 4 6 8 10], got %v", l.ToSlice())
+	}
+	if err := dlinkList.New[int]().ForEachErr(func(*int) error { return errStop }); err != nil {
+		t.Errorf("expected no error on an empty list, got %v", err)
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	c := dlinkList.New[int]()
+	for _, v := range []int{3, 1, 4, 1, 5} {
+		c.Append(v)
+	}
+
+	if v, err := dlinkList.Min(c, func(a, b int) bool { return a < b }); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := dlinkList.Max(c, func(a, b int) bool { return a < b }); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v, err := dlinkList.MinOrdered(c); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := dlinkList.MaxOrdered(c); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v := dlinkList.Sum(c); v != 14 {
+		t.Errorf("expected sum 14, got %v", v)
+	}
+	if v, err := dlinkList.Average(c); err != nil || v != 2.8 {
+		t.Errorf("expected average 2.8, got %v (%v)", v, err)
+	}
+
+	e := dlinkList.New[int]()
+	if _, err := dlinkList.Min(e, func(a, b int) bool { return a < b }); err != dlinkList.ErrEmpty {
+		t.Errorf("expected %v, got %v", dlinkList.ErrEmpty, err)
+	}
+	if _, err := dlinkList.Average(e); err != dlinkList.ErrEmpty {
+		t.Errorf("expected %v, got %v", dlinkList.ErrEmpty, err)
+	}
+	if v := dlinkList.Sum(e); v != 0 {
+		t.Errorf("expected sum 0, got %v", v)
+	}
+}
+
+// mirror applies the change notifications of a container to a slice
+type mirror struct {
+	t     *testing.T
+	items []int
+}
+
+func (m *mirror) insert(index uint64, v int) {
+	m.items = slices.Insert(m.items, int(index), v)
+}
+
+func (m *mirror) remove(index uint64, v int) {
+	if index >= uint64(len(m.items)) || m.items[index] != v {
+		m.t.Fatalf("unexpected removal of %d at %d from %v", v, index, m.items)
+	}
+	m.items = slices.Delete(m.items, int(index), int(index)+1)
+}
+
+func (m *mirror) clear() {
+	m.items = nil
+}
+
+func TestObservers(t *testing.T) {
+	l := dlinkList.New[int]()
+	m := &mirror{t: t}
+	l.OnInsert(m.insert)
+	l.OnRemove(m.remove)
+	l.OnClear(m.clear)
+
+	check := func(op string) {
+		t.Helper()
+		if !slices.Equal(m.items, l.ToSlice()) {
+			t.Fatalf("after %s: expected %v, got %v", op, l.ToSlice(), m.items)
+		}
+	}
+
+	l.Append(2)
+	l.Prepend(1)
+	_ = l.Insert(5)
+	_ = l.InsertAt(2, 3)
+	check("Append/Prepend/Insert/InsertAt")
+	l.InsertAfter(3, 4)
+	l.InsertBefore(1, 0)
+	check("InsertAfter/InsertBefore")
+	l.DeleteWithValue(3)
+	l.Delete(0)
+	_ = l.DeleteAt(1)
+	check("DeleteWithValue/Delete/DeleteAt")
+	l.DeleteFirst()
+	l.DeleteLast()
+	check("DeleteFirst/DeleteLast")
+	for _, v := range []int{1, 1, 2, 1, 3, 3} {
+		l.Append(v)
+	}
+	l.Unique()
+	check("Unique")
+	l.Deduplicate()
+	check("Deduplicate")
+	l.Filter(func(v int) bool { return v != 2 })
+	check("Filter")
+	l.AppendN(4, 5)
+	l.PrependN(-1, 0)
+	l.DeleteN(5, 0)
+	check("AppendN/PrependN/DeleteN")
+	other := dlinkList.New[int]()
+	other.Append(7)
+	other.Append(8)
+	_ = l.Splice(1, other)
+	check("Splice")
+	_, _, _ = l.SplitAt(1)
+	check("SplitAt")
+}
+
+func TestNewWithPool(t *testing.T) {
+	list := dlinkList.NewWithPool[int]()
+	for i := 0; i < 10; i++ {
+		list.Append(i)
+	}
+	list.Filter(func(v int) bool { return v%2 == 0 })
+	list.DeleteFirst()
+	list.DeleteLast()
+	list.DeleteWithValue(4)
+	list.InsertBefore(6, 5)
+	list.InsertAfter(6, 7)
+	if got := list.ToSlice(); !slices.Equal(got, []int{2, 5, 6, 7}) {
+		t.Errorf(errExpectedX, []int{2, 5, 6, 7}, got)
+	}
+	if got := list.ToSliceReverse(); !slices.Equal(got, []int{7, 6, 5, 2}) {
+		t.Errorf(errExpectedX, []int{7, 6, 5, 2}, got)
+	}
+
+	list.Clear()
+	if !list.IsEmpty() {
+		t.Error(errListNotEmpty)
+	}
+
+	// Once the pool holds some nodes, a delete/insert cycle doesn't allocate
+	list.Append(1)
+	list.Append(2)
+	list.DeleteLast()
+	allocs := testing.AllocsPerRun(100, func() {
+		list.Append(2)
+		list.DeleteLast()
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, but got %v", allocs)
+	}
+	if got := list.ToSlice(); !slices.Equal(got, []int{1}) {
+		t.Errorf(errExpectedX, []int{1}, got)
+	}
+}
+
+func TestNewWithPoolMovedNodes(t *testing.T) {
+	list := dlinkList.NewWithPool[int]()
+	for i := 0; i < 4; i++ {
+		list.Append(i)
+	}
+	// The nodes moved to another list must not be released to the pool
+	left, right, err := list.SplitAt(2)
+	if err != nil {
+		t.Fatalf(errNoError, err)
+	}
+	other := dlinkList.NewWithPool[int]()
+	other.Append(9)
+	if err := left.Splice(1, other); err != nil {
+		t.Fatalf(errNoError, err)
+	}
+	list.Append(5)
+	other.Append(6)
+	if got := left.ToSlice(); !slices.Equal(got, []int{0, 9, 1}) {
+		t.Errorf(errExpectedX, []int{0, 9, 1}, got)
+	}
+	if got := right.ToSliceReverse(); !slices.Equal(got, []int{3, 2}) {
+		t.Errorf(errExpectedX, []int{3, 2}, got)
+	}
+}
+
+// benchmarkChurn appends values to a list of 1000 values and deletes its first one
+func benchmarkChurn(b *testing.B, list *dlinkList.DLinkList[int]) {
+	for i := 0; i < 1000; i++ {
+		list.Append(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list.Append(i)
+		list.DeleteFirst()
+	}
+}
+
+func BenchmarkChurn(b *testing.B) {
+	benchmarkChurn(b, dlinkList.New[int]())
+}
+
+func BenchmarkChurnWithPool(b *testing.B) {
+	benchmarkChurn(b, dlinkList.NewWithPool[int]())
+}
+
+func TestCheckInvariants(t *testing.T) {
+	list := dlinkList.New[int]()
+	for i := 1; i <= 3; i++ {
+		list.Append(i)
+	}
+	if err := list.CheckInvariants(); err != nil {
+		t.Fatalf(errNoError, err)
+	}
+
+	head, tail := list.GetFirst(), list.GetLast()
+	dlinkList.SetEnds(list, head.Next(), tail) // the head has a Prev node
+	if err := list.CheckInvariants(); !errors.Is(err, dlinkList.ErrCorrupted) {
+		t.Errorf(errExpectedX, dlinkList.ErrCorrupted, err)
+	}
+	dlinkList.SetEnds(list, head, head) // the tail is no longer the last node
+	if err := list.CheckInvariants(); !errors.Is(err, dlinkList.ErrCorrupted) {
+		t.Errorf(errExpectedX, dlinkList.ErrCorrupted, err)
+	}
+}
+
+// FuzzInvariants applies a sequence of operations (one per input byte) and
+// checks the invariants of the lists after each of them
+func FuzzInvariants(f *testing.F) {
+	f.Add([]byte{0, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15})
+	f.Add([]byte{0, 16, 32, 48, 3, 9, 0, 0, 9, 4, 4, 1, 8, 14, 10})
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		list, other := dlinkList.New[int](), dlinkList.New[int]()
+		for i, op := range ops {
+			v := int(op >> 4)
+			index := uint64(op>>4) % (list.Size() + 1)
+			switch op % 16 {
+			case 0:
+				list.Append(v)
+			case 1:
+				list.Prepend(v)
+			case 2:
+				_ = list.InsertAt(index, v)
+			case 3:
+				_ = list.DeleteAt(index)
+			case 4:
+				list.DeleteWithValue(v)
+			case 5:
+				list.Filter(func(x int) bool { return x%2 == 0 })
+			case 6:
+				list.Unique()
+			case 7:
+				list.Deduplicate()
+			case 8:
+				other.Append(v)
+				list.Merge(other)
+			case 9:
+				left, right, err := list.SplitAt(index)
+				if err == nil {
+					list = left
+					other = right
+				}
+			case 10:
+				_ = list.Splice(index, other)
+			case 11:
+				list.Reverse()
+				list.Sort(func(a, b int) bool { return a < b })
+			case 12:
+				list.DeleteFirst()
+				list.DeleteLast()
+			case 13:
+				list.InsertBefore(v, v+1)
+				list.InsertAfter(v, v+2)
+			case 14:
+				other.Prepend(v)
+				list.ReverseMerge(other)
+			case 15:
+				_ = list.Swap(0, index)
+			}
+			for _, l := range []*dlinkList.DLinkList[int]{list, other} {
+				if err := l.CheckInvariants(); err != nil {
+					t.Fatalf("after operation %d (%d): %v", i, op%16, err)
+				}
+			}
+		}
+	})
+}
+
+func TestChunkWindows(t *testing.T) {
+	list := dlinkList.New[int]()
+	for i := 1; i <= 5; i++ {
+		list.Append(i)
+	}
+
+	chunks := list.Chunk(2)
+	expected := [][]int{{1, 2}, {3, 4}, {5}}
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+	for i, c := range chunks {
+		if !slices.Equal(c.ToSlice(), expected[i]) {
+			t.Errorf("expected chunk %d to be %v, got %v", i, expected[i], c.ToSlice())
+		}
+		if err := c.CheckInvariants(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	windows := list.Windows(3)
+	expected = [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}
+	if len(windows) != len(expected) {
+		t.Fatalf("expected %d windows, got %d", len(expected), len(windows))
+	}
+	for i, w := range windows {
+		if !slices.Equal(w.ToSlice(), expected[i]) {
+			t.Errorf("expected window %d to be %v, got %v", i, expected[i], w.ToSlice())
+		}
+	}
+
+	// The chunks are copies
+	chunks[0].Clear()
+	if list.Size() != 5 {
+		t.Errorf("expected size 5, got %d", list.Size())
+	}
+	if list.Chunk(0) != nil || list.Windows(0) != nil || list.Windows(6) != nil {
+		t.Error("expected nil for invalid sizes")
+	}
+}
+
+func TestJoinFlatten(t *testing.T) {
+	list := dlinkList.New[int]()
+	for i := 1; i <= 5; i++ {
+		list.Append(i)
+	}
+	chunks := list.Chunk(2)
+
+	joined := dlinkList.Join(append(chunks, nil)...)
+	if !slices.Equal(joined.ToSlice(), []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected [1 2 3 4 5], got %v", joined.ToSlice())
+	}
+
+	nested := dlinkList.New[*dlinkList.DLinkList[int]]()
+	for _, c := range chunks {
+		nested.Append(c)
+	}
+	flat := dlinkList.Flatten(nested)
+	if !slices.Equal(flat.ToSlice(), []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected [1 2 3 4 5], got %v", flat.ToSlice())
+	}
+	if !dlinkList.Join[int]().IsEmpty() {
+		t.Error("expected an empty result joining nothing")
+	}
+
+	// The result compares its values like the first part
+	a, b := dlinkList.NewWithComparator(slices.Equal[[]int]), dlinkList.NewWithComparator(slices.Equal[[]int])
+	a.Append([]int{1})
+	a.Append([]int{2})
+	b.Append([]int{3})
+	if all := dlinkList.Join(nil, a, b); all.Size() != 3 || !all.Contains([]int{3}) {
+		t.Errorf("expected 3 values compared with slices.Equal, got %v", all.ToSlice())
+	}
+}
+
+func TestIndexed(t *testing.T) {
+	list := dlinkList.New[int]()
+	for _, v := range []int{10, 20, 30} {
+		list.Append(v)
+	}
+	list.ForEachIndexed(func(i uint64, v *int) {
+		*v += int(i)
+	})
+	if !slices.Equal(list.ToSlice(), []int{10, 21, 32}) {
+		t.Errorf("expected [10 21 32], got %v", list.ToSlice())
+	}
+
+	mapped := list.MapIndexed(func(i uint64, v int) int { return v * int(i) })
+	if !slices.Equal(mapped.ToSlice(), []int{0, 21, 64}) {
+		t.Errorf("expected [0 21 64], got %v", mapped.ToSlice())
+	}
+	if err := mapped.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := dlinkList.New[*int]()
+	c.Append(&a)
+	c.Append(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestRemoveIf(t *testing.T) {
+	c := dlinkList.New[int]()
+	for _, v := range []int{1, 2, 1, 3, 1, 2} {
+		c.Append(v)
+	}
+
+	if n := c.RemoveFirstN(1, 2); n != 2 || !slices.Equal(c.ToSlice(), []int{2, 3, 1, 2}) {
+		t.Errorf("expected to remove 2 elements leaving 2, 3, 1, 2}, removed %d leaving %v", n, c.ToSlice())
+	}
+	if n := c.RemoveAll(2); n != 2 || !slices.Equal(c.ToSlice(), []int{3, 1}) {
+		t.Errorf("expected to remove 2 elements leaving 3, 1}, removed %d leaving %v", n, c.ToSlice())
+	}
+	if n := c.RemoveFirstN(1, 5); n != 1 {
+		t.Errorf("expected to remove 1 element, removed %d", n)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v > 10 }); n != 0 {
+		t.Errorf("expected to remove nothing, removed %d", n)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v == 3 }); n != 1 || c.Size() != 0 {
+		t.Errorf("expected to remove the last element, removed %d leaving %v", n, c.ToSlice())
+	}
+}
+
+func TestForEachSafe(t *testing.T) {
+	c := dlinkList.NewWithPool[int]()
+	for _, v := range []int{1, 2, 3, 4, 5, 6} {
+		c.Append(v)
+	}
+
+	var visited []int
+	c.ForEachSafe(func(v int) bool {
+		visited = append(visited, v)
+		return v%2 == 1 || v == 6
+	})
+	if !slices.Equal(visited, []int{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("expected to visit [1 2 3 4 5 6], got %v", visited)
+	}
+	if !slices.Equal(c.ToSlice(), []int{2, 4}) {
+		t.Errorf("expected [2 4], got %v", c.ToSlice())
+	}
+	if err := c.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+
+	c.ForEachSafe(func(int) bool { return true })
+	if !c.IsEmpty() {
+		t.Errorf("expected an empty list, got %v", c.ToSlice())
+	}
+}
+
+func TestContainsAllAny(t *testing.T) {
+	c := dlinkList.New[int]()
+	for _, v := range []int{1, 2, 1, 3} {
+		c.Append(v)
+	}
+
+	if !c.ContainsAll(3, 1, 1) || !c.ContainsAll() || c.ContainsAll(1, 4) {
+		t.Error("expected ContainsAll to be true only when every element is present")
+	}
+	if !c.ContainsAny(4, 2) || c.ContainsAny(4, 5) || c.ContainsAny() {
+		t.Error("expected ContainsAny to be true only when an element is present")
+	}
+	if c.CountOf(1) != 2 || c.CountOf(3) != 1 || c.CountOf(4) != 0 {
+		t.Errorf("expected counts 2, 1 and 0, got %d, %d and %d", c.CountOf(1), c.CountOf(3), c.CountOf(4))
+	}
+}
+
+func TestBoundaryIndexes(t *testing.T) {
+	for size := 0; size <= 3; size++ {
+		items := make([]int, size)
+		for i := range items {
+			items[i] = i + 1
+		}
+		check := func(op string, index int, list *dlinkList.DLinkList[int], err error, valid bool) {
+			t.Helper()
+			if valid && err != nil {
+				t.Errorf("size %d, %s(%d): "+errNoError, size, op, index, err)
+			}
+			if !valid && !errors.Is(err, dlinkList.ErrOutOfBounds) {
+				t.Errorf("size %d, %s(%d): "+errExpectedX, size, op, index, dlinkList.ErrOutOfBounds, err)
+			}
+			if err := list.CheckInvariants(); err != nil {
+				t.Errorf("size %d, %s(%d): %v", size, op, index, err)
+			}
+		}
+
+		for index := 0; index <= size+1; index++ {
+			list := newFromSlice(items)
+			_, err := list.GetAt(uint64(index))
+			check("GetAt", index, list, err, index < size)
+
+			list = newFromSlice(items)
+			err = list.DeleteAt(uint64(index))
+			check("DeleteAt", index, list, err, index < size)
+			if err == nil && !slices.Equal(list.ToSlice(), slices.Delete(slices.Clone(items), index, index+1)) {
+				t.Errorf("size %d, DeleteAt(%d): got %v", size, index, list.ToSlice())
+			}
+
+			list = newFromSlice(items)
+			err = list.InsertAt(uint64(index), 0)
+			check("InsertAt", index, list, err, index <= size)
+			if err == nil && !slices.Equal(list.ToSlice(), slices.Insert(slices.Clone(items), index, 0)) {
+				t.Errorf("size %d, InsertAt(%d): got %v", size, index, list.ToSlice())
+			}
+
+			list = newFromSlice(items)
+			left, right, err := list.SplitAt(uint64(index))
+			check("SplitAt", index, list, err, index <= size)
+			if err == nil {
+				check("SplitAt", index, left, nil, true)
+				check("SplitAt", index, right, nil, true)
+			}
+
+			list = newFromSlice(items)
+			err = list.Splice(uint64(index), newFromSlice([]int{0}))
+			check("Splice", index, list, err, index <= size)
+		}
+	}
+}
+
+func TestDeleteAtSingleElement(t *testing.T) {
+	list := newFromSlice([]int{1})
+	if err := list.DeleteAt(0); err != nil {
+		t.Fatalf(errNoError, err)
+	}
+	if list.GetFirst() != nil || list.GetLast() != nil || !list.IsEmpty() {
+		t.Errorf("expected the head and the tail to be nil, got %v and %v", list.GetFirst(), list.GetLast())
+	}
+	if err := list.DeleteAt(0); !errors.Is(err, dlinkList.ErrOutOfBounds) {
+		t.Errorf(errExpectedX, dlinkList.ErrOutOfBounds, err)
+	}
+
+	// The list must still be usable
+	list.Append(2)
+	if list.GetFirst() != list.GetLast() || list.GetFirst().Prev() != nil || list.GetFirst().Next() != nil {
+		t.Error("expected a single node with no links")
+	}
+}
+
+func TestWipe(t *testing.T) {
+	list := dlinkList.New[string]()
+	for _, v := range []string{"key", "token", "secret"} {
+		list.Append(v)
+	}
+	middle, _ := list.GetAt(1)
+
+	list.Wipe()
+	if !list.IsEmpty() {
+		t.Errorf("Expected an empty list, got %v", list.ToSlice())
+	}
+	if middle.Value() != "" || middle.Next() != nil || middle.Prev() != nil {
+		t.Errorf("Expected a wiped and unlinked node, got %q", middle.Value())
+	}
+}
+
+// checkRelinked checks that list holds want, in both directions
+func checkRelinked(t *testing.T, list *dlinkList.DLinkList[int], want []int) {
+	t.Helper()
+	if err := list.CheckInvariants(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := list.ToSlice(); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	backward := slices.Clone(want)
+	slices.Reverse(backward)
+	if got := list.ToSliceReverse(); !slices.Equal(got, backward) {
+		t.Errorf("Expected %v backwards, got %v", want, got)
+	}
+}
+
+func TestSwapNodes(t *testing.T) {
+	cases := []struct{ i, j int }{
+		{0, 4}, // head and tail
+		{4, 0},
+		{0, 1}, // adjacent at the head
+		{1, 0},
+		{3, 4}, // adjacent at the tail
+		{1, 3},
+		{2, 2},
+	}
+	for _, c := range cases {
+		list := newFromSlice([]int{1, 2, 3, 4, 5})
+		a, _ := list.GetAt(uint64(c.i))
+		b, _ := list.GetAt(uint64(c.j))
+		if err := list.SwapNodes(a, b); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		want := []int{1, 2, 3, 4, 5}
+		want[c.i], want[c.j] = want[c.j], want[c.i]
+		checkRelinked(t, list, want)
+		if a.Value() != c.i+1 || b.Value() != c.j+1 {
+			t.Errorf("Expected the nodes to keep their values, got %d and %d", a.Value(), b.Value())
+		}
+	}
+
+	list := newFromSlice([]int{1, 2})
+	other := newFromSlice([]int{3})
+	for _, node := range []*dlinkList.Node[int]{nil, new(dlinkList.Node[int]), other.GetFirst()} {
+		if err := list.SwapNodes(list.GetFirst(), node); !errors.Is(err, dlinkList.ErrNotInList) {
+			t.Errorf("Expected %v, got %v", dlinkList.ErrNotInList, err)
+		}
+	}
+	checkRelinked(t, list, []int{1, 2})
+}
+
+func TestMoveToFrontBack(t *testing.T) {
+	list := newFromSlice([]int{1, 2, 3, 4})
+	node, _ := list.GetAt(2)
+	_ = list.MoveToFront(node)
+	checkRelinked(t, list, []int{3, 1, 2, 4})
+	_ = list.MoveToFront(list.GetLast())
+	checkRelinked(t, list, []int{4, 3, 1, 2})
+	_ = list.MoveToFront(list.GetFirst())
+	checkRelinked(t, list, []int{4, 3, 1, 2})
+
+	_ = list.MoveToBack(node)
+	checkRelinked(t, list, []int{4, 1, 2, 3})
+	_ = list.MoveToBack(list.GetFirst())
+	checkRelinked(t, list, []int{1, 2, 3, 4})
+	_ = list.MoveToBack(list.GetLast())
+	checkRelinked(t, list, []int{1, 2, 3, 4})
+
+	single := newFromSlice([]int{1})
+	if err := single.MoveToBack(single.GetFirst()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkRelinked(t, single, []int{1})
+	if err := list.MoveToFront(single.GetFirst()); !errors.Is(err, dlinkList.ErrNotInList) {
+		t.Errorf("Expected %v, got %v", dlinkList.ErrNotInList, err)
+	}
+	if err := list.MoveToBack(nil); !errors.Is(err, dlinkList.ErrNotInList) {
+		t.Errorf("Expected %v, got %v", dlinkList.ErrNotInList, err)
+	}
+}
+
+func TestNodeAccessors(t *testing.T) {
+	list := newFromSlice([]int{1, 2, 3})
+	middle, _ := list.GetAt(1)
+	if middle.Prev() != list.GetFirst() || middle.Next() != list.GetLast() || list.GetFirst().Prev() != nil || list.GetLast().Next() != nil {
+		t.Fatal("Expected the accessors to follow the links of the list")
+	}
+
+	middle.SetValue(5)
+	if middle.Value() != 5 || !slices.Equal(list.ToSlice(), []int{1, 5, 3}) {
+		t.Errorf("Expected [1 5 3], got %v", list.ToSlice())
+	}
+}
+
+func TestCtxVariants(t *testing.T) {
+	ctxtest.Run[*dlinkList.DLinkList[int], *dlinkList.Node[int]](t, dlinkList.New[int], dlinkList.ErrNotFound)
+}
+
+func TestJSONStream(t *testing.T) {
+	if !dlinkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, dlinkList.Features())
+	}
+	if !dlinkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, dlinkList.Features())
+	}
+	l := dlinkList.New[int]()
+	for _, v := range []int{1, 2, 3} {
+		l.Append(v)
+	}
+
+	var sb strings.Builder
+	if err := l.EncodeJSONStream(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sb.String() != "[1,2,3]" {
+		t.Errorf("expected [1,2,3], got %s", sb.String())
+	}
+
+	got := dlinkList.New[int]()
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader(sb.String()))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader("[4] null"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got.ToSlice(), []int{1, 2, 3, 4}) {
+		t.Errorf("expected [1 2 3 4], got %v", got.ToSlice())
+	}
+
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader(`{"a": 5}`))); !errors.Is(err, common.ErrNotJSONArray) {
+		t.Errorf("expected %v, got %v", common.ErrNotJSONArray, err)
+	}
+
+	var empty strings.Builder
+	if err := dlinkList.New[int]().EncodeJSONStream(&empty); err != nil || empty.String() != "[]" {
+		t.Errorf("expected [], got %s (%v)", empty.String(), err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *dlinkList.DLinkList[int] {
+		l := dlinkList.New[int]()
+		for _, v := range values {
+			l.Append(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	// The result is normalized to -1, 0 or +1
+	if got := build(1).Compare(build(10), func(a, b int) int { return a - b }); got != -1 {
+		t.Errorf("expected -1, got %d", got)
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package flipflop provides a concurrency-safe "collect then flush" double
+// buffer built on top of abBuffer: writers append to the active bank while
+// a Flip swaps the banks and hands the full one to a callback.
+package flipflop
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	abBuffer "github.com/pzaino/gods/pkg/abBuffer"
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
+const (
+	ErrAlreadyRunning  = "periodic flip already running"
+	ErrNotRunning      = "periodic flip not running"
+	ErrInvalidInterval = "invalid interval"
+	ErrNilCallback     = "callback cannot be nil"
+)
+
+// Sentinel errors returned by the FlipFlop methods (use errors.Is to check for them)
+var (
+	ErrRunning     = errors.New(ErrAlreadyRunning)
+	ErrStopped     = errors.New(ErrNotRunning)
+	ErrBadInterval = errors.New(ErrInvalidInterval)
+	ErrNoCallback  = errors.New(ErrNilCallback)
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureConcurrent)
+}
+
+// FlipFlop is a concurrency-safe double buffer with a flush callback.
+type FlipFlop[T any] struct {
+	mu   sync.Mutex
+	ab   *abBuffer.ABBuffer[T]
+	stop chan struct{}
+	done chan struct{}
+	fn   func([]T)
+}
+
+// New creates a new FlipFlop, each bank can hold up to capacity
+// elements (0 means unlimited).
+func New[T comparable](capacity uint64) *FlipFlop[T] {
+	return &FlipFlop[T]{ab: abBuffer.New[T](capacity)}
+}
+
+// NewWithComparator creates a new FlipFlop like New for types that are not
+// comparable (slices, maps, ...), the banks compare their elements with the
+// given function.
+func NewWithComparator[T any](capacity uint64, equals func(a, b T) bool) *FlipFlop[T] {
+	return &FlipFlop[T]{ab: abBuffer.NewWithComparator(capacity, equals)}
+}
+
+// Append adds a new element to the active bank.
+func (f *FlipFlop[T]) Append(value T) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ab.Append(value)
+}
+
+// AppendN adds multiple elements to the active bank, stopping at the first error.
+func (f *FlipFlop[T]) AppendN(values ...T) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, v := range values {
+		if err := f.ab.Append(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Pending returns the number of elements waiting in the active bank.
+func (f *FlipFlop[T]) Pending() uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ab.Size()
+}
+
+// Capacity returns the capacity of each bank.
+func (f *FlipFlop[T]) Capacity() uint64 {
+	return f.ab.Capacity()
+}
+
+// Flip atomically swaps the banks and passes the content of the full
+// bank to fn (fn is called without holding the lock, so writers are not
+// blocked while the data is processed). fn is not called if there is
+// nothing to flush.
+func (f *FlipFlop[T]) Flip(fn func([]T)) {
+	f.mu.Lock()
+	f.ab.Swap()
+	data := f.ab.FetchInactive()
+	f.mu.Unlock()
+
+	if fn != nil && len(data) > 0 {
+		fn(data)
+	}
+}
+
+// Start flips the banks every interval passing the data to fn.
+func (f *FlipFlop[T]) Start(interval time.Duration, fn func([]T)) error {
+	if interval <= 0 {
+		return ErrBadInterval
+	}
+	if fn == nil {
+		return ErrNoCallback
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stop != nil {
+		return ErrRunning
+	}
+	f.stop = make(chan struct{})
+	f.done = make(chan struct{})
+	f.fn = fn
+	go f.run(interval, fn, f.stop, f.done)
+	return nil
+}
+
+// Stop stops the periodic flips and flushes the remaining data
+// to the callback passed to Start.
+func (f *FlipFlop[T]) Stop() error {
+	f.mu.Lock()
+	if f.stop == nil {
+		f.mu.Unlock()
+		return ErrStopped
+	}
+	stop, done, fn := f.stop, f.done, f.fn
+	f.stop, f.done, f.fn = nil, nil, nil
+	f.mu.Unlock()
+
+	close(stop)
+	<-done
+	f.Flip(fn)
+	return nil
+}
+
+// IsRunning checks if the periodic flips are running.
+func (f *FlipFlop[T]) IsRunning() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stop != nil
+}
+
+func (f *FlipFlop[T]) run(interval time.Duration, fn func([]T), stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			f.Flip(fn)
+		}
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package flipflop_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	flipflop "github.com/pzaino/gods/pkg/flipflop"
+)
+
+// BenchmarkAppend appends n elements to a bank and flips it
+func BenchmarkAppend(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		f := flipflop.New[int](uint64(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for v := 0; v < n; v++ {
+				_ = f.Append(v)
+			}
+			f.Flip(func([]int) {})
+		}
+		bench.PerElement(b, n)
+	})
+}
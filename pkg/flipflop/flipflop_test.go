@@ -0,0 +1,146 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package flipflop provides a concurrency-safe double buffer.
+package flipflop_test
+
+import (
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	flipflop "github.com/pzaino/gods/pkg/flipflop"
+)
+
+const (
+	errExpectedNoError = "expected no error, got %v"
+	errExpectedError   = "expected an error, got nil"
+	errExpectedXGotY   = "expected %v, got %v"
+)
+
+func TestFlip(t *testing.T) {
+	f := flipflop.New[int](0)
+	if err := f.AppendN(1, 2, 3); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if f.Pending() != 3 {
+		t.Errorf(errExpectedXGotY, 3, f.Pending())
+	}
+
+	var got []int
+	f.Flip(func(data []int) { got = data })
+	if len(got) != 3 || got[0] != 1 || got[2] != 3 {
+		t.Errorf(errExpectedXGotY, []int{1, 2, 3}, got)
+	}
+	if f.Pending() != 0 {
+		t.Errorf(errExpectedXGotY, 0, f.Pending())
+	}
+
+	_ = f.Append(4)
+	f.Flip(func(data []int) { got = data })
+	if len(got) != 1 || got[0] != 4 {
+		t.Errorf(errExpectedXGotY, []int{4}, got)
+	}
+
+	// Nothing to flush
+	called := false
+	f.Flip(func([]int) { called = true })
+	if called {
+		t.Errorf("expected callback not to be called on an empty bank")
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	f := flipflop.New[int](2)
+	if f.Capacity() != 2 {
+		t.Errorf(errExpectedXGotY, 2, f.Capacity())
+	}
+	if err := f.AppendN(1, 2, 3); err == nil {
+		t.Errorf(errExpectedError)
+	}
+	f.Flip(nil)
+	if err := f.Append(3); err != nil {
+		t.Errorf(errExpectedNoError, err)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	f := flipflop.New[int](0)
+	if err := f.Start(0, func([]int) {}); !errors.Is(err, flipflop.ErrBadInterval) {
+		t.Errorf(errExpectedError)
+	}
+	if err := f.Start(time.Millisecond, nil); !errors.Is(err, flipflop.ErrNoCallback) {
+		t.Errorf(errExpectedError)
+	}
+	if err := f.Stop(); !errors.Is(err, flipflop.ErrStopped) {
+		t.Errorf(errExpectedError)
+	}
+
+	var mu sync.Mutex
+	total := 0
+	flush := func(data []int) {
+		mu.Lock()
+		total += len(data)
+		mu.Unlock()
+	}
+	if err := f.Start(time.Millisecond, flush); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if err := f.Start(time.Millisecond, flush); !errors.Is(err, flipflop.ErrRunning) {
+		t.Errorf(errExpectedError)
+	}
+	if !f.IsRunning() {
+		t.Errorf("expected flipflop to be running")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				_ = f.Append(j)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := f.Stop(); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if f.IsRunning() {
+		t.Errorf("expected flipflop not to be running")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if total != 10000 {
+		t.Errorf(errExpectedXGotY, 10000, total)
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	ff := flipflop.NewWithComparator(0, slices.Equal[[]int])
+	if err := ff.AppendN([]int{1}, []int{2, 3}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var got [][]int
+	ff.Flip(func(items [][]int) { got = append(got, items...) })
+	if len(got) != 2 || !slices.Equal(got[1], []int{2, 3}) {
+		t.Errorf("expected [[1] [2 3]], got %v", got)
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gapBuffer provides a non-concurrent-safe gap buffer, a buffer that
+// keeps its free space (the gap) at a cursor so insertions and deletions near
+// the cursor are amortized O(1) (the text editor pattern). Moving the cursor
+// costs O(distance).
+package gapBuffer
+
+import (
+	"errors"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the GapBuffer methods (use errors.Is to check for them)
+var (
+	ErrOutOfBounds = errors.New("index out of bounds")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator)
+}
+
+// minGap is the minimum free space left when the buffer grows
+const minGap = 16
+
+// GapBuffer is a sequence of elements stored in data[:gapStart] and
+// data[gapEnd:], the cursor is at gapStart. The zero value is an empty buffer
+// ready to use, its ToBuffer compares the elements with ==
+type GapBuffer[T any] struct {
+	data     []T
+	gapStart uint64
+	gapEnd   uint64
+	adopt    func([]T) *buffer.Buffer[T] // creates the Buffer returned by ToBuffer, nil for the zero value
+}
+
+// New creates a new empty GapBuffer
+func New[T comparable]() *GapBuffer[T] {
+	return &GapBuffer[T]{adopt: buffer.Adopt[T]}
+}
+
+// NewWithComparator creates a new empty GapBuffer for types that are not
+// comparable (slices, maps, ...), the Buffer returned by ToBuffer compares its
+// elements with the given function
+func NewWithComparator[T any](equals func(a, b T) bool) *GapBuffer[T] {
+	return &GapBuffer[T]{adopt: func(items []T) *buffer.Buffer[T] {
+		return buffer.AdoptWithComparator(items, equals)
+	}}
+}
+
+// NewFromSlice creates a new GapBuffer with a copy of items and the cursor at the end
+func NewFromSlice[T comparable](items []T) *GapBuffer[T] {
+	g := New[T]()
+	g.data = make([]T, len(items)+minGap)
+	copy(g.data, items)
+	g.gapStart = uint64(len(items))
+	g.gapEnd = uint64(len(g.data))
+	return g
+}
+
+// NewFromBuffer creates a new GapBuffer with a copy of the elements of b and the cursor at the end
+func NewFromBuffer[T comparable](b *buffer.Buffer[T]) *GapBuffer[T] {
+	return NewFromSlice(b.UnsafeSlice())
+}
+
+// gapSize returns the free space at the cursor
+func (g *GapBuffer[T]) gapSize() uint64 {
+	return g.gapEnd - g.gapStart
+}
+
+// grow makes room for at least n more elements in the gap
+func (g *GapBuffer[T]) grow(n uint64) {
+	if g.gapSize() >= n {
+		return
+	}
+	size := g.Size()
+	capacity := max(2*uint64(len(g.data)), size+n+minGap)
+	data := make([]T, capacity)
+	copy(data, g.data[:g.gapStart])
+	tail := uint64(len(g.data)) - g.gapEnd
+	copy(data[capacity-tail:], g.data[g.gapEnd:])
+	g.data = data
+	g.gapEnd = capacity - tail
+}
+
+// Size returns the number of elements in the buffer
+func (g *GapBuffer[T]) Size() uint64 {
+	return uint64(len(g.data)) - g.gapSize()
+}
+
+// IsEmpty checks if the buffer is empty
+func (g *GapBuffer[T]) IsEmpty() bool {
+	return g.Size() == 0
+}
+
+// Cursor returns the position of the cursor (the gap), between 0 and Size
+func (g *GapBuffer[T]) Cursor() uint64 {
+	return g.gapStart
+}
+
+// MoveGap moves the cursor before the element at index (index == Size moves
+// it to the end), the elements between the old and the new position are moved
+// across the gap
+func (g *GapBuffer[T]) MoveGap(index uint64) error {
+	if index > g.Size() {
+		return ErrOutOfBounds
+	}
+	switch {
+	case index < g.gapStart:
+		// Move the elements in [index, gapStart) to the end of the gap
+		n := g.gapStart - index
+		copy(g.data[g.gapEnd-n:g.gapEnd], g.data[index:g.gapStart])
+		clear(g.data[index:min(g.gapStart, g.gapEnd-n)]) // let the GC collect the moved elements
+		g.gapStart -= n
+		g.gapEnd -= n
+	case index > g.gapStart:
+		// Move the n elements after the gap to its start
+		n := index - g.gapStart
+		copy(g.data[g.gapStart:index], g.data[g.gapEnd:g.gapEnd+n])
+		clear(g.data[max(index, g.gapEnd) : g.gapEnd+n])
+		g.gapStart += n
+		g.gapEnd += n
+	}
+	return nil
+}
+
+// Insert inserts items at the cursor, the cursor moves after them
+func (g *GapBuffer[T]) Insert(items ...T) {
+	g.grow(uint64(len(items)))
+	copy(g.data[g.gapStart:], items)
+	g.gapStart += uint64(len(items))
+}
+
+// Delete removes n elements after the cursor (like the Delete key of an editor)
+func (g *GapBuffer[T]) Delete(n uint64) error {
+	if n > uint64(len(g.data))-g.gapEnd {
+		return ErrOutOfBounds
+	}
+	clear(g.data[g.gapEnd : g.gapEnd+n])
+	g.gapEnd += n
+	return nil
+}
+
+// Backspace removes n elements before the cursor (like the Backspace key of an editor)
+func (g *GapBuffer[T]) Backspace(n uint64) error {
+	if n > g.gapStart {
+		return ErrOutOfBounds
+	}
+	clear(g.data[g.gapStart-n : g.gapStart])
+	g.gapStart -= n
+	return nil
+}
+
+// index returns the position of the element at index in data
+func (g *GapBuffer[T]) index(index uint64) (uint64, error) {
+	if index >= g.Size() {
+		return 0, ErrOutOfBounds
+	}
+	if index < g.gapStart {
+		return index, nil
+	}
+	return index + g.gapSize(), nil
+}
+
+// Get returns the element at index
+func (g *GapBuffer[T]) Get(index uint64) (T, error) {
+	i, err := g.index(index)
+	if err != nil {
+		var rVal T
+		return rVal, err
+	}
+	return g.data[i], nil
+}
+
+// Set replaces the element at index
+func (g *GapBuffer[T]) Set(index uint64, elem T) error {
+	i, err := g.index(index)
+	if err != nil {
+		return err
+	}
+	g.data[i] = elem
+	return nil
+}
+
+// Clear removes all the elements from the buffer
+func (g *GapBuffer[T]) Clear() {
+	g.data = nil
+	g.gapStart = 0
+	g.gapEnd = 0
+}
+
+// ToSlice returns a copy of the elements of the buffer
+func (g *GapBuffer[T]) ToSlice() []T {
+	items := make([]T, 0, g.Size())
+	items = append(items, g.data[:g.gapStart]...)
+	return append(items, g.data[g.gapEnd:]...)
+}
+
+// ToBuffer returns a new Buffer with a copy of the elements of the buffer
+func (g *GapBuffer[T]) ToBuffer() *buffer.Buffer[T] {
+	if g.adopt == nil {
+		return buffer.AdoptWithComparator[T](g.ToSlice(), nil)
+	}
+	return g.adopt(g.ToSlice())
+}
+
+// String returns a string representation of the buffer (elements are formatted with %v)
+func (g *GapBuffer[T]) String() string {
+	return g.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the buffer with every element formatted by f
+func (g *GapBuffer[T]) StringFunc(f func(T) string) string {
+	return common.FormatSlice(g.ToSlice(), f)
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gapBuffer_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	gapBuffer "github.com/pzaino/gods/pkg/gapBuffer"
+)
+
+// BenchmarkAppend inserts n elements at the cursor
+func BenchmarkAppend(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		for i := 0; i < b.N; i++ {
+			g := gapBuffer.New[int]()
+			for v := 0; v < n; v++ {
+				g.Insert(v)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkInsert moves the cursor back and forth across n elements and
+// inserts an element (deleting it afterwards, so the size stays n)
+func BenchmarkInsert(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		g := gapBuffer.NewFromSlice(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = g.MoveGap(uint64(i%2) * uint64(n/2))
+			g.Insert(i)
+			_ = g.Backspace(1)
+		}
+	})
+}
+
+// BenchmarkGet reads the element in the middle of n elements
+func BenchmarkGet(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		g := gapBuffer.NewFromSlice(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = g.Get(uint64(n / 2))
+		}
+	})
+}
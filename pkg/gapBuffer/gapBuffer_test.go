@@ -0,0 +1,214 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gapBuffer_test
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	gapBuffer "github.com/pzaino/gods/pkg/gapBuffer"
+)
+
+const errUnexpectedErr = "unexpected error: %v"
+
+func TestNew(t *testing.T) {
+	g := gapBuffer.New[int]()
+	if !g.IsEmpty() || g.Cursor() != 0 {
+		t.Errorf("expected an empty buffer, got size %d cursor %d", g.Size(), g.Cursor())
+	}
+	if _, err := g.Get(0); !errors.Is(err, gapBuffer.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", gapBuffer.ErrOutOfBounds, err)
+	}
+
+	g = gapBuffer.NewFromSlice([]int{1, 2, 3})
+	if g.Size() != 3 || g.Cursor() != 3 {
+		t.Errorf("expected size 3 and cursor 3, got %d and %d", g.Size(), g.Cursor())
+	}
+}
+
+func TestEditing(t *testing.T) {
+	g := gapBuffer.NewFromSlice([]rune("hello world"))
+	if err := g.MoveGap(5); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	g.Insert([]rune(",")...)
+	if err := g.Delete(6); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	g.Insert([]rune(" there")...)
+	if s := string(g.ToSlice()); s != "hello, there" {
+		t.Errorf("expected \"hello, there\", got %q", s)
+	}
+	if err := g.Backspace(7); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if s := string(g.ToSlice()); s != "hello" || g.Cursor() != 5 {
+		t.Errorf("expected \"hello\" with the cursor at 5, got %q at %d", s, g.Cursor())
+	}
+	if err := g.MoveGap(0); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	g.Insert('>')
+	if s := string(g.ToSlice()); s != ">hello" {
+		t.Errorf("expected \">hello\", got %q", s)
+	}
+
+	if err := g.MoveGap(7); !errors.Is(err, gapBuffer.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", gapBuffer.ErrOutOfBounds, err)
+	}
+	if err := g.Delete(6); !errors.Is(err, gapBuffer.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", gapBuffer.ErrOutOfBounds, err)
+	}
+	if err := g.Backspace(2); !errors.Is(err, gapBuffer.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", gapBuffer.ErrOutOfBounds, err)
+	}
+}
+
+func TestGetSet(t *testing.T) {
+	g := gapBuffer.NewFromSlice([]int{1, 2, 3, 4})
+	_ = g.MoveGap(2)
+	if v, err := g.Get(2); err != nil || v != 3 {
+		t.Errorf("expected 3, got %d (%v)", v, err)
+	}
+	if err := g.Set(3, 40); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if err := g.Set(4, 0); !errors.Is(err, gapBuffer.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", gapBuffer.ErrOutOfBounds, err)
+	}
+	if !reflect.DeepEqual(g.ToSlice(), []int{1, 2, 3, 40}) {
+		t.Errorf("expected [1 2 3 40], got %v", g.ToSlice())
+	}
+	if g.String() != "[1 2 3 40]" {
+		t.Errorf("expected [1 2 3 40], got %s", g.String())
+	}
+	g.Clear()
+	if !g.IsEmpty() {
+		t.Errorf("expected an empty buffer, got size %d", g.Size())
+	}
+}
+
+func TestBufferConversion(t *testing.T) {
+	b := buffer.New[int]()
+	_ = b.PushN(1, 2, 3)
+	g := gapBuffer.NewFromBuffer(b)
+	_ = g.MoveGap(1)
+	g.Insert(9)
+
+	out := g.ToBuffer()
+	if !reflect.DeepEqual(out.ToSlice(), []int{1, 9, 2, 3}) {
+		t.Errorf("expected [1 9 2 3], got %v", out.ToSlice())
+	}
+	if !reflect.DeepEqual(b.ToSlice(), []int{1, 2, 3}) {
+		t.Errorf("expected the source buffer to be unchanged, got %v", b.ToSlice())
+	}
+}
+
+func TestZeroValue(t *testing.T) {
+	var g gapBuffer.GapBuffer[int]
+	g.Insert(1, 3)
+	_ = g.MoveGap(1)
+	g.Insert(2)
+
+	out := g.ToBuffer()
+	if !reflect.DeepEqual(out.ToSlice(), []int{1, 2, 3}) {
+		t.Errorf("expected [1 2 3], got %v", out.ToSlice())
+	}
+	if !out.Contains(2) {
+		t.Errorf("expected the buffer to compare its elements with ==")
+	}
+}
+
+// TestRandomEdits checks the gap buffer against a plain slice
+func TestRandomEdits(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	g := gapBuffer.New[int]()
+	var model []int
+	cursor := 0
+
+	for i := 0; i < 5000; i++ {
+		switch rnd.Intn(4) {
+		case 0:
+			cursor = rnd.Intn(len(model) + 1)
+			if err := g.MoveGap(uint64(cursor)); err != nil {
+				t.Fatalf(errUnexpectedErr, err)
+			}
+		case 1:
+			n := rnd.Intn(3)
+			if err := g.Delete(uint64(n)); (err != nil) != (cursor+n > len(model)) {
+				t.Fatalf("Delete(%d) at %d of %d: %v", n, cursor, len(model), err)
+			}
+			if cursor+n <= len(model) {
+				model = append(model[:cursor], model[cursor+n:]...)
+			}
+		case 2:
+			n := rnd.Intn(3)
+			if err := g.Backspace(uint64(n)); (err != nil) != (n > cursor) {
+				t.Fatalf("Backspace(%d) at %d: %v", n, cursor, err)
+			}
+			if n <= cursor {
+				model = append(model[:cursor-n], model[cursor:]...)
+				cursor -= n
+			}
+		default:
+			items := []int{i, -i}
+			g.Insert(items...)
+			model = append(model[:cursor], append(items, model[cursor:]...)...)
+			cursor += len(items)
+		}
+		if g.Cursor() != uint64(cursor) || !reflect.DeepEqual(g.ToSlice(), append([]int{}, model...)) {
+			t.Fatalf("step %d: expected %v with the cursor at %d, got %v at %d", i, model, cursor, g.ToSlice(), g.Cursor())
+		}
+	}
+}
+
+// BenchmarkInsertAtCursor types in the middle of a large buffer
+func BenchmarkInsertAtCursor(b *testing.B) {
+	g := gapBuffer.NewFromSlice(make([]int, 1<<16))
+	_ = g.MoveGap(1 << 15)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Insert(i)
+	}
+}
+
+// BenchmarkBufferInsertAt is the same workload with Buffer.InsertAt
+func BenchmarkBufferInsertAt(b *testing.B) {
+	buf := buffer.Adopt(make([]int, 1<<16))
+	pos := uint64(1 << 15)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = buf.InsertAt(pos, i)
+		pos++
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	g := gapBuffer.NewWithComparator(slices.Equal[[]int])
+	g.Insert([]int{1}, []int{2, 3})
+	if err := g.MoveGap(1); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	g.Insert([]int{4})
+
+	b := g.ToBuffer()
+	if b.Size() != 3 || !b.Contains([]int{4}) || b.Contains([]int{3, 2}) {
+		t.Errorf("expected [[1] [4] [2 3]], got %v", b.ToSlice())
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package geogrid provides a concurrency-safe spatial hash grid that maps
+// 2D coordinates to cell buckets for fast entity lookup (games, simulations).
+package geogrid
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
+const (
+	ErrInvalidCellSize = "invalid cell size"
+	ErrInvalidRadius   = "invalid radius"
+	ErrInvalidRect     = "invalid rectangle"
+	ErrInvalidPosition = "invalid position"
+	ErrIDAlreadyExists = "id already exists"
+	ErrIDNotFound      = "id not found"
+)
+
+// Sentinel errors returned by the Grid methods (use errors.Is to check for them)
+var (
+	ErrBadCellSize = errors.New(ErrInvalidCellSize)
+	ErrBadRadius   = errors.New(ErrInvalidRadius)
+	ErrBadRect     = errors.New(ErrInvalidRect)
+	ErrBadPosition = errors.New(ErrInvalidPosition)
+	ErrDuplicateID = errors.New(ErrIDAlreadyExists)
+	ErrNotFound    = errors.New(ErrIDNotFound)
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureConcurrent)
+}
+
+// cell identifies a bucket of the grid
+type cell struct {
+	x, y int64
+}
+
+// entry is the position of an entity and the cell it lives in
+type entry struct {
+	x, y float64
+	c    cell
+}
+
+// Grid is a concurrency-safe spatial hash grid.
+type Grid[K comparable] struct {
+	mu       sync.RWMutex
+	cellSize float64
+	cells    map[cell]map[K]struct{}
+	entities map[K]entry
+}
+
+// New creates a new Grid with the given cell size.
+func New[K comparable](cellSize float64) (*Grid[K], error) {
+	if cellSize <= 0 || math.IsNaN(cellSize) || math.IsInf(cellSize, 0) {
+		return nil, ErrBadCellSize
+	}
+	return &Grid[K]{
+		cellSize: cellSize,
+		cells:    make(map[cell]map[K]struct{}),
+		entities: make(map[K]entry),
+	}, nil
+}
+
+// CellSize returns the size of the grid cells.
+func (g *Grid[K]) CellSize() float64 {
+	return g.cellSize
+}
+
+// Insert adds a new entity to the grid at the given coordinates, which must be
+// finite numbers (not NaN or infinite).
+func (g *Grid[K]) Insert(id K, x, y float64) error {
+	if !finite(x, y) {
+		return ErrBadPosition
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if _, ok := g.entities[id]; ok {
+		return ErrDuplicateID
+	}
+	g.add(id, x, y)
+	return nil
+}
+
+// Move updates the coordinates of an existing entity, which must be finite
+// numbers (not NaN or infinite).
+func (g *Grid[K]) Move(id K, x, y float64) error {
+	if !finite(x, y) {
+		return ErrBadPosition
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	e, ok := g.entities[id]
+	if !ok {
+		return ErrNotFound
+	}
+	c := g.cellOf(x, y)
+	if c == e.c {
+		g.entities[id] = entry{x: x, y: y, c: c}
+		return nil
+	}
+	g.unlink(id, e.c)
+	g.add(id, x, y)
+	return nil
+}
+
+// Remove removes an entity from the grid.
+func (g *Grid[K]) Remove(id K) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	e, ok := g.entities[id]
+	if !ok {
+		return ErrNotFound
+	}
+	g.unlink(id, e.c)
+	delete(g.entities, id)
+	return nil
+}
+
+// Position returns the coordinates of an entity.
+func (g *Grid[K]) Position(id K) (float64, float64, error) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	e, ok := g.entities[id]
+	if !ok {
+		return 0, 0, ErrNotFound
+	}
+	return e.x, e.y, nil
+}
+
+// Contains checks if an entity is stored in the grid.
+func (g *Grid[K]) Contains(id K) bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	_, ok := g.entities[id]
+	return ok
+}
+
+// Size returns the number of entities in the grid.
+func (g *Grid[K]) Size() uint64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return uint64(len(g.entities))
+}
+
+// IsEmpty checks if the grid is empty.
+func (g *Grid[K]) IsEmpty() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.entities) == 0
+}
+
+// Clear removes all entities from the grid.
+func (g *Grid[K]) Clear() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.cells = make(map[cell]map[K]struct{})
+	g.entities = make(map[K]entry)
+}
+
+// QueryRadius returns the ids of all entities within radius of (x, y).
+// The order of the returned ids is unspecified.
+func (g *Grid[K]) QueryRadius(x, y, radius float64) ([]K, error) {
+	if radius < 0 || !finite(radius) {
+		return nil, ErrBadRadius
+	}
+	if !finite(x, y) {
+		return nil, ErrBadPosition
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	r2 := radius * radius
+	return g.query(x-radius, y-radius, x+radius, y+radius, func(e entry) bool {
+		dx, dy := e.x-x, e.y-y
+		return dx*dx+dy*dy <= r2
+	}), nil
+}
+
+// QueryRect returns the ids of all entities inside the rectangle
+// [minX, maxX] x [minY, maxY]. The order of the returned ids is unspecified.
+func (g *Grid[K]) QueryRect(minX, minY, maxX, maxY float64) ([]K, error) {
+	if !finite(minX, minY, maxX, maxY) || minX > maxX || minY > maxY {
+		return nil, ErrBadRect
+	}
+
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	return g.query(minX, minY, maxX, maxY, func(e entry) bool {
+		return e.x >= minX && e.x <= maxX && e.y >= minY && e.y <= maxY
+	}), nil
+}
+
+// query visits every cell overlapping the given bounds and collects
+// the entities accepted by match
+func (g *Grid[K]) query(minX, minY, maxX, maxY float64, match func(entry) bool) []K {
+	result := make([]K, 0)
+	lo := g.cellOf(minX, minY)
+	hi := g.cellOf(maxX, maxY)
+
+	// When the area covers more cells than there are occupied ones,
+	// it's cheaper to scan the occupied cells only
+	if float64(hi.x-lo.x+1)*float64(hi.y-lo.y+1) > float64(len(g.cells)) {
+		for c, bucket := range g.cells {
+			if c.x < lo.x || c.x > hi.x || c.y < lo.y || c.y > hi.y {
+				continue
+			}
+			result = g.collect(result, bucket, match)
+		}
+		return result
+	}
+
+	for cx := lo.x; cx <= hi.x; cx++ {
+		for cy := lo.y; cy <= hi.y; cy++ {
+			if bucket, ok := g.cells[cell{cx, cy}]; ok {
+				result = g.collect(result, bucket, match)
+			}
+		}
+	}
+	return result
+}
+
+func (g *Grid[K]) collect(result []K, bucket map[K]struct{}, match func(entry) bool) []K {
+	for id := range bucket {
+		if match(g.entities[id]) {
+			result = append(result, id)
+		}
+	}
+	return result
+}
+
+// finite returns true if none of the values is NaN or infinite, which would
+// have no cell in the grid
+func finite(values ...float64) bool {
+	for _, v := range values {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *Grid[K]) cellOf(x, y float64) cell {
+	return cell{
+		x: int64(math.Floor(x / g.cellSize)),
+		y: int64(math.Floor(y / g.cellSize)),
+	}
+}
+
+func (g *Grid[K]) add(id K, x, y float64) {
+	c := g.cellOf(x, y)
+	bucket, ok := g.cells[c]
+	if !ok {
+		bucket = make(map[K]struct{})
+		g.cells[c] = bucket
+	}
+	bucket[id] = struct{}{}
+	g.entities[id] = entry{x: x, y: y, c: c}
+}
+
+func (g *Grid[K]) unlink(id K, c cell) {
+	bucket := g.cells[c]
+	delete(bucket, id)
+	if len(bucket) == 0 {
+		delete(g.cells, c)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package geogrid_test
+
+import (
+	"math"
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	geogrid "github.com/pzaino/gods/pkg/geogrid"
+)
+
+// newBenchGrid returns a grid with n points in the unit square, the cells
+// hold about 10 points each
+func newBenchGrid(n int, coords []float64) *geogrid.Grid[int] {
+	g, _ := geogrid.New[int](math.Sqrt(10 / float64(n)))
+	for i := 0; i < n; i++ {
+		_ = g.Insert(i, coords[2*i], coords[2*i+1])
+	}
+	return g
+}
+
+// BenchmarkInsert inserts n points
+func BenchmarkInsert(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		coords := bench.Float64s(2 * n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = newBenchGrid(n, coords)
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkDelete removes a point from a grid of n points (and inserts it
+// back, so the size stays n)
+func BenchmarkDelete(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		coords := bench.Float64s(2 * n)
+		g := newBenchGrid(n, coords)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			id := i % n
+			_ = g.Remove(id)
+			_ = g.Insert(id, coords[2*id], coords[2*id+1])
+		}
+	})
+}
+
+// BenchmarkFind finds the points around a point of a grid of n points (about
+// 30 of them)
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		coords := bench.Float64s(2 * n)
+		g := newBenchGrid(n, coords)
+		radius := math.Sqrt(10 / float64(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			id := i % n
+			_, _ = g.QueryRadius(coords[2*id], coords[2*id+1], radius)
+		}
+	})
+}
@@ -0,0 +1,273 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package geogrid provides a concurrency-safe spatial hash grid.
+package geogrid_test
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"testing"
+
+	geogrid "github.com/pzaino/gods/pkg/geogrid"
+)
+
+const (
+	errExpectedNoError = "expected no error, got %v"
+	errExpectedError   = "expected an error, got nil"
+	errExpectedSize    = "expected size %d, got %d"
+	errExpectedIDs     = "expected ids %v, got %v"
+)
+
+func newGrid(t *testing.T) *geogrid.Grid[int] {
+	t.Helper()
+	g, err := geogrid.New[int](10)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	return g
+}
+
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sort.Ints(a)
+	sort.Ints(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestNew(t *testing.T) {
+	if _, err := geogrid.New[int](0); !errors.Is(err, geogrid.ErrBadCellSize) {
+		t.Errorf(errExpectedError)
+	}
+	if _, err := geogrid.New[int](-1); !errors.Is(err, geogrid.ErrBadCellSize) {
+		t.Errorf(errExpectedError)
+	}
+
+	g := newGrid(t)
+	if !g.IsEmpty() {
+		t.Errorf("expected grid to be empty")
+	}
+	if g.CellSize() != 10 {
+		t.Errorf("expected cell size 10, got %v", g.CellSize())
+	}
+}
+
+func TestInsertAndRemove(t *testing.T) {
+	g := newGrid(t)
+	if err := g.Insert(1, 5, 5); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if err := g.Insert(1, 6, 6); !errors.Is(err, geogrid.ErrDuplicateID) {
+		t.Errorf(errExpectedError)
+	}
+	if err := g.Insert(2, -5, -5); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if g.Size() != 2 {
+		t.Errorf(errExpectedSize, 2, g.Size())
+	}
+	if !g.Contains(2) {
+		t.Errorf("expected grid to contain id 2")
+	}
+
+	if err := g.Remove(2); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if err := g.Remove(2); !errors.Is(err, geogrid.ErrNotFound) {
+		t.Errorf(errExpectedError)
+	}
+	if g.Contains(2) {
+		t.Errorf("expected grid not to contain id 2")
+	}
+
+	g.Clear()
+	if g.Size() != 0 {
+		t.Errorf(errExpectedSize, 0, g.Size())
+	}
+}
+
+func TestMove(t *testing.T) {
+	g := newGrid(t)
+	_ = g.Insert(1, 1, 1)
+
+	// Same cell
+	if err := g.Move(1, 2, 2); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	// Different cell
+	if err := g.Move(1, 55, -33); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	x, y, err := g.Position(1)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if x != 55 || y != -33 {
+		t.Errorf("expected position (55, -33), got (%v, %v)", x, y)
+	}
+
+	ids, _ := g.QueryRadius(0, 0, 5)
+	if len(ids) != 0 {
+		t.Errorf(errExpectedIDs, []int{}, ids)
+	}
+	ids, _ = g.QueryRadius(55, -33, 1)
+	if !sameIDs(ids, []int{1}) {
+		t.Errorf(errExpectedIDs, []int{1}, ids)
+	}
+
+	if err := g.Move(2, 0, 0); !errors.Is(err, geogrid.ErrNotFound) {
+		t.Errorf(errExpectedError)
+	}
+	if _, _, err := g.Position(2); !errors.Is(err, geogrid.ErrNotFound) {
+		t.Errorf(errExpectedError)
+	}
+}
+
+func TestQueryRadius(t *testing.T) {
+	g := newGrid(t)
+	_ = g.Insert(1, 0, 0)
+	_ = g.Insert(2, 3, 4)
+	_ = g.Insert(3, 9, 9)
+	_ = g.Insert(4, -12, 0)
+
+	ids, err := g.QueryRadius(0, 0, 5)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if !sameIDs(ids, []int{1, 2}) {
+		t.Errorf(errExpectedIDs, []int{1, 2}, ids)
+	}
+
+	ids, _ = g.QueryRadius(0, 0, 13)
+	if !sameIDs(ids, []int{1, 2, 3, 4}) {
+		t.Errorf(errExpectedIDs, []int{1, 2, 3, 4}, ids)
+	}
+
+	if _, err := g.QueryRadius(0, 0, -1); !errors.Is(err, geogrid.ErrBadRadius) {
+		t.Errorf(errExpectedError)
+	}
+}
+
+func TestQueryRect(t *testing.T) {
+	g := newGrid(t)
+	_ = g.Insert(1, 0, 0)
+	_ = g.Insert(2, 15, 15)
+	_ = g.Insert(3, 25, 5)
+	_ = g.Insert(4, -1, -1)
+
+	ids, err := g.QueryRect(0, 0, 20, 20)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if !sameIDs(ids, []int{1, 2}) {
+		t.Errorf(errExpectedIDs, []int{1, 2}, ids)
+	}
+
+	// Very large area, only the occupied cells are scanned
+	ids, _ = g.QueryRect(-1e9, -1e9, 1e9, 1e9)
+	if !sameIDs(ids, []int{1, 2, 3, 4}) {
+		t.Errorf(errExpectedIDs, []int{1, 2, 3, 4}, ids)
+	}
+
+	if _, err := g.QueryRect(10, 0, 0, 10); !errors.Is(err, geogrid.ErrBadRect) {
+		t.Errorf(errExpectedError)
+	}
+}
+
+func TestInvalidCoordinates(t *testing.T) {
+	g := newGrid(t)
+	_ = g.Insert(1, 0, 0)
+	nan, inf := math.NaN(), math.Inf(1)
+	for _, p := range [][2]float64{{nan, 0}, {0, nan}, {inf, 0}, {0, -inf}} {
+		if err := g.Insert(2, p[0], p[1]); !errors.Is(err, geogrid.ErrBadPosition) {
+			t.Errorf("expected %v inserting at %v, got %v", geogrid.ErrBadPosition, p, err)
+		}
+		if err := g.Move(1, p[0], p[1]); !errors.Is(err, geogrid.ErrBadPosition) {
+			t.Errorf("expected %v moving to %v, got %v", geogrid.ErrBadPosition, p, err)
+		}
+		if _, err := g.QueryRadius(p[0], p[1], 1); !errors.Is(err, geogrid.ErrBadPosition) {
+			t.Errorf("expected %v querying around %v, got %v", geogrid.ErrBadPosition, p, err)
+		}
+		if _, err := g.QueryRect(p[0], p[1], 10, 10); !errors.Is(err, geogrid.ErrBadRect) {
+			t.Errorf("expected %v querying from %v, got %v", geogrid.ErrBadRect, p, err)
+		}
+	}
+	for _, radius := range []float64{nan, inf} {
+		if _, err := g.QueryRadius(0, 0, radius); !errors.Is(err, geogrid.ErrBadRadius) {
+			t.Errorf("expected %v for radius %v, got %v", geogrid.ErrBadRadius, radius, err)
+		}
+	}
+
+	// The entity and the grid are left unchanged
+	if x, y, _ := g.Position(1); x != 0 || y != 0 || g.Size() != 1 {
+		t.Errorf("expected 1 entity at (0, 0), got %d at (%v, %v)", g.Size(), x, y)
+	}
+}
+
+func TestQueryMatchesBruteForce(t *testing.T) {
+	g := newGrid(t)
+	r := rand.New(rand.NewSource(42))
+	xs := make([]float64, 500)
+	ys := make([]float64, 500)
+	for i := range xs {
+		xs[i] = r.Float64()*200 - 100
+		ys[i] = r.Float64()*200 - 100
+		_ = g.Insert(i, xs[i], ys[i])
+	}
+
+	for q := 0; q < 20; q++ {
+		cx, cy, radius := r.Float64()*200-100, r.Float64()*200-100, r.Float64()*30
+		expected := []int{}
+		for i := range xs {
+			dx, dy := xs[i]-cx, ys[i]-cy
+			if dx*dx+dy*dy <= radius*radius {
+				expected = append(expected, i)
+			}
+		}
+		ids, _ := g.QueryRadius(cx, cy, radius)
+		if !sameIDs(ids, expected) {
+			t.Fatalf(errExpectedIDs, expected, ids)
+		}
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	g := newGrid(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			_ = g.Insert(id, float64(id), float64(id))
+			_ = g.Move(id, float64(-id), float64(id))
+			_, _ = g.QueryRadius(0, 0, 50)
+			_, _ = g.QueryRect(-50, 0, 0, 50)
+		}(i)
+	}
+	wg.Wait()
+
+	if g.Size() != 100 {
+		t.Errorf(errExpectedSize, 100, g.Size())
+	}
+}
@@ -0,0 +1,447 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package guard provides wrappers that validate container invariants
+// (ordering, uniqueness, etc.) after every mutation.
+// Guards are meant to be used while debugging: they walk the whole container
+// on every mutation, so they should be disabled (or removed) in production.
+package guard
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	common "github.com/pzaino/gods/pkg/common"
+	dlinkList "github.com/pzaino/gods/pkg/dlinkList"
+)
+
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
+const (
+	ErrNotSorted = "sort order invariant violated"
+	ErrNotUnique = "uniqueness invariant violated"
+)
+
+// Sentinel errors wrapped by the errors of the invariants, with the index of
+// the violation (use errors.Is to check for them)
+var (
+	ErrUnsorted  = errors.New(ErrNotSorted)
+	ErrDuplicate = errors.New(ErrNotUnique)
+)
+
+// Invariant is a check that must hold for the values of a container.
+// It returns a non-nil error when the invariant is violated.
+type Invariant[T any] func(values []T) error
+
+// ViolationHandler is called every time an invariant is violated.
+type ViolationHandler func(err error)
+
+// PanicOnViolation is the default ViolationHandler, it panics with the violation error.
+func PanicOnViolation(err error) {
+	panic(err)
+}
+
+// LogOnViolation is a ViolationHandler that logs the violation and carries on.
+func LogOnViolation(err error) {
+	log.Printf("guard: %v", err)
+}
+
+// MustRemainSorted returns an invariant that checks the values are sorted
+// according to the given function (for example, func(a, b int) bool { return a < b }).
+func MustRemainSorted[T any](less func(T, T) bool) Invariant[T] {
+	return func(values []T) error {
+		for i := 1; i < len(values); i++ {
+			if less(values[i], values[i-1]) {
+				return fmt.Errorf("%w at index %d", ErrUnsorted, i)
+			}
+		}
+		return nil
+	}
+}
+
+// MustBeUnique returns an invariant that checks the values contain no duplicates.
+func MustBeUnique[T comparable]() Invariant[T] {
+	return func(values []T) error {
+		seen := make(map[T]struct{}, len(values))
+		for i, v := range values {
+			if _, ok := seen[v]; ok {
+				return fmt.Errorf("%w at index %d", ErrDuplicate, i)
+			}
+			seen[v] = struct{}{}
+		}
+		return nil
+	}
+}
+
+// MustBeUniqueFunc returns an invariant that checks the values contain no
+// duplicates according to the given function, for types that are not
+// comparable. It's O(n^2), prefer MustBeUnique for comparable types.
+func MustBeUniqueFunc[T any](equals func(a, b T) bool) Invariant[T] {
+	return func(values []T) error {
+		seen := common.NewSeen(nil, equals)
+		for i, v := range values {
+			if !seen.Add(v) {
+				return fmt.Errorf("%w at index %d", ErrDuplicate, i)
+			}
+		}
+		return nil
+	}
+}
+
+// checker holds the state shared by all the guard wrappers.
+type checker[T any] struct {
+	invariants []Invariant[T]
+	handler    ViolationHandler
+	enabled    bool
+}
+
+func newChecker[T any](invariants []Invariant[T]) checker[T] {
+	return checker[T]{
+		invariants: invariants,
+		handler:    PanicOnViolation,
+		enabled:    true,
+	}
+}
+
+// SetEnabled enables or disables the invariants checks (enabled by default).
+func (c *checker[T]) SetEnabled(enabled bool) {
+	c.enabled = enabled
+}
+
+// SetViolationHandler sets the function called on invariant violations (PanicOnViolation by default).
+func (c *checker[T]) SetViolationHandler(h ViolationHandler) {
+	if h == nil {
+		h = PanicOnViolation
+	}
+	c.handler = h
+}
+
+// validate runs all the invariants against the values and returns the first violation (if any).
+func (c *checker[T]) validate(values []T) error {
+	for _, inv := range c.invariants {
+		if err := inv(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// check validates the invariants (when enabled) and reports the first
+// violation (if any) to the handler.
+func (c *checker[T]) check(values func() []T) {
+	if !c.enabled || len(c.invariants) == 0 {
+		return
+	}
+	if err := c.validate(values()); err != nil {
+		c.handler(err)
+	}
+}
+
+// Buffer is a buffer.Buffer that validates its invariants after every mutation.
+type Buffer[T any] struct {
+	checker[T]
+	b *buffer.Buffer[T]
+}
+
+// NewBuffer wraps the given buffer with the given invariants.
+// The invariants are checked immediately, so the buffer must already satisfy them.
+func NewBuffer[T any](b *buffer.Buffer[T], invariants ...Invariant[T]) *Buffer[T] {
+	gb := &Buffer[T]{checker: newChecker(invariants), b: b}
+	gb.verify()
+	return gb
+}
+
+// Check validates the invariants on demand and returns the first violation (if any).
+func (gb *Buffer[T]) Check() error {
+	return gb.validate(gb.b.UnsafeSlice())
+}
+
+// Unwrap returns the guarded buffer. Mutations done directly on the returned
+// buffer are not validated.
+func (gb *Buffer[T]) Unwrap() *buffer.Buffer[T] {
+	return gb.b
+}
+
+func (gb *Buffer[T]) verify() {
+	gb.check(gb.b.UnsafeSlice)
+}
+
+// Append adds an element to the end of the buffer.
+func (gb *Buffer[T]) Append(elem T) error {
+	if err := gb.b.Append(elem); err != nil {
+		return err
+	}
+	gb.verify()
+	return nil
+}
+
+// InsertAt adds an element at the given index.
+func (gb *Buffer[T]) InsertAt(index uint64, elem T) error {
+	if err := gb.b.InsertAt(index, elem); err != nil {
+		return err
+	}
+	gb.verify()
+	return nil
+}
+
+// Put replaces the element at the given index.
+func (gb *Buffer[T]) Put(index uint64, elem T) error {
+	if err := gb.b.Put(index, elem); err != nil {
+		return err
+	}
+	gb.verify()
+	return nil
+}
+
+// Set is an alias for Put.
+func (gb *Buffer[T]) Set(index uint64, elem T) error {
+	return gb.Put(index, elem)
+}
+
+// Remove removes the element at the given index.
+func (gb *Buffer[T]) Remove(index uint64) error {
+	if err := gb.b.Remove(index); err != nil {
+		return err
+	}
+	gb.verify()
+	return nil
+}
+
+// PushN adds multiple elements to the end of the buffer.
+func (gb *Buffer[T]) PushN(items ...T) error {
+	if err := gb.b.PushN(items...); err != nil {
+		return err
+	}
+	gb.verify()
+	return nil
+}
+
+// PopN removes and returns the last n elements.
+func (gb *Buffer[T]) PopN(n uint64) ([]T, error) {
+	values, err := gb.b.PopN(n)
+	if err != nil {
+		return nil, err
+	}
+	gb.verify()
+	return values, nil
+}
+
+// Swap swaps the elements at the given indices.
+func (gb *Buffer[T]) Swap(i, j uint64) error {
+	if err := gb.b.Swap(i, j); err != nil {
+		return err
+	}
+	gb.verify()
+	return nil
+}
+
+// Reverse reverses the buffer.
+func (gb *Buffer[T]) Reverse() {
+	gb.b.Reverse()
+	gb.verify()
+}
+
+// Filter removes elements that don't match the predicate.
+func (gb *Buffer[T]) Filter(predicate func(T) bool) {
+	gb.b.Filter(predicate)
+	gb.verify()
+}
+
+// Merge appends all elements from another buffer.
+func (gb *Buffer[T]) Merge(other *buffer.Buffer[T]) {
+	gb.b.Merge(other)
+	gb.verify()
+}
+
+// ForEach applies the function to each element in the buffer.
+func (gb *Buffer[T]) ForEach(fn func(*T) error) error {
+	err := gb.b.ForEach(fn)
+	gb.verify()
+	return err
+}
+
+// Clear removes all elements from the buffer.
+func (gb *Buffer[T]) Clear() {
+	gb.b.Clear()
+}
+
+// Get returns the element at the given index.
+func (gb *Buffer[T]) Get(index uint64) (T, error) {
+	return gb.b.Get(index)
+}
+
+// Size returns the number of elements in the buffer.
+func (gb *Buffer[T]) Size() uint64 {
+	return gb.b.Size()
+}
+
+// Values returns all elements in the buffer.
+func (gb *Buffer[T]) Values() []T {
+	return gb.b.Values()
+}
+
+// String returns a string representation of the buffer (elements are formatted with %v).
+func (gb *Buffer[T]) String() string {
+	return gb.b.String()
+}
+
+// StringFunc returns a string representation of the buffer with every element formatted by f.
+func (gb *Buffer[T]) StringFunc(f func(T) string) string {
+	return gb.b.StringFunc(f)
+}
+
+// DLinkList is a dlinkList.DLinkList that validates its invariants after every mutation.
+type DLinkList[T any] struct {
+	checker[T]
+	l *dlinkList.DLinkList[T]
+}
+
+// NewDLinkList wraps the given doubly linked list with the given invariants.
+// The invariants are checked immediately, so the list must already satisfy them.
+func NewDLinkList[T any](l *dlinkList.DLinkList[T], invariants ...Invariant[T]) *DLinkList[T] {
+	gl := &DLinkList[T]{checker: newChecker(invariants), l: l}
+	gl.verify()
+	return gl
+}
+
+// Check validates the invariants on demand and returns the first violation (if any).
+func (gl *DLinkList[T]) Check() error {
+	return gl.validate(gl.l.ToSlice())
+}
+
+// Unwrap returns the guarded list. Mutations done directly on the returned
+// list are not validated.
+func (gl *DLinkList[T]) Unwrap() *dlinkList.DLinkList[T] {
+	return gl.l
+}
+
+func (gl *DLinkList[T]) verify() {
+	gl.check(gl.l.ToSlice)
+}
+
+// Append adds a new node to the end of the list.
+func (gl *DLinkList[T]) Append(value T) {
+	gl.l.Append(value)
+	gl.verify()
+}
+
+// Prepend adds a new node to the beginning of the list.
+func (gl *DLinkList[T]) Prepend(value T) {
+	gl.l.Prepend(value)
+	gl.verify()
+}
+
+// InsertAt inserts a new node with the given value at the given index.
+func (gl *DLinkList[T]) InsertAt(index uint64, value T) error {
+	if err := gl.l.InsertAt(index, value); err != nil {
+		return err
+	}
+	gl.verify()
+	return nil
+}
+
+// InsertAfter inserts a new node with the given value after the node with the given value.
+func (gl *DLinkList[T]) InsertAfter(value, newValue T) {
+	gl.l.InsertAfter(value, newValue)
+	gl.verify()
+}
+
+// InsertBefore inserts a new node with the given value before the node with the given value.
+func (gl *DLinkList[T]) InsertBefore(value, newValue T) {
+	gl.l.InsertBefore(value, newValue)
+	gl.verify()
+}
+
+// DeleteWithValue deletes the first occurrence of a node with the given value.
+func (gl *DLinkList[T]) DeleteWithValue(value T) {
+	gl.l.DeleteWithValue(value)
+	gl.verify()
+}
+
+// DeleteAt deletes the node at the given index.
+func (gl *DLinkList[T]) DeleteAt(index uint64) error {
+	if err := gl.l.DeleteAt(index); err != nil {
+		return err
+	}
+	gl.verify()
+	return nil
+}
+
+// Swap swaps the nodes at the given indices.
+func (gl *DLinkList[T]) Swap(i, j uint64) error {
+	if err := gl.l.Swap(i, j); err != nil {
+		return err
+	}
+	gl.verify()
+	return nil
+}
+
+// Reverse reverses the list.
+func (gl *DLinkList[T]) Reverse() {
+	gl.l.Reverse()
+	gl.verify()
+}
+
+// Sort sorts the list according to the given function.
+func (gl *DLinkList[T]) Sort(f func(T, T) bool) {
+	gl.l.Sort(f)
+	gl.verify()
+}
+
+// Filter removes the nodes that don't satisfy the given function.
+func (gl *DLinkList[T]) Filter(f func(T) bool) {
+	gl.l.Filter(f)
+	gl.verify()
+}
+
+// ForEach applies the given function to each node.
+func (gl *DLinkList[T]) ForEach(f func(*T)) {
+	gl.l.ForEach(f)
+	gl.verify()
+}
+
+// Merge appends the nodes of the given list.
+func (gl *DLinkList[T]) Merge(list *dlinkList.DLinkList[T]) {
+	gl.l.Merge(list)
+	gl.verify()
+}
+
+// Clear removes all nodes from the list.
+func (gl *DLinkList[T]) Clear() {
+	gl.l.Clear()
+}
+
+// Size returns the number of nodes in the list.
+func (gl *DLinkList[T]) Size() uint64 {
+	return gl.l.Size()
+}
+
+// ToSlice converts the list to a slice.
+func (gl *DLinkList[T]) ToSlice() []T {
+	return gl.l.ToSlice()
+}
+
+// String returns a string representation of the list (elements are formatted with %v).
+func (gl *DLinkList[T]) String() string {
+	return gl.l.String()
+}
+
+// StringFunc returns a string representation of the list with every element formatted by f.
+func (gl *DLinkList[T]) StringFunc(f func(T) string) string {
+	return gl.l.StringFunc(f)
+}
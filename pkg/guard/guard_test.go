@@ -0,0 +1,249 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package guard provides wrappers that validate container invariants.
+package guard_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	dlinkList "github.com/pzaino/gods/pkg/dlinkList"
+	guard "github.com/pzaino/gods/pkg/guard"
+)
+
+const (
+	errExpectedNoError   = "expected no error, got %v"
+	errExpectedViolation = "expected violation %v, got %v"
+)
+
+func less(a, b int) bool { return a < b }
+
+// recorder returns a violation handler that records the violations.
+func recorder(errs *[]error) guard.ViolationHandler {
+	return func(err error) {
+		*errs = append(*errs, err)
+	}
+}
+
+func TestMustRemainSorted(t *testing.T) {
+	inv := guard.MustRemainSorted(less)
+	if err := inv([]int{1, 2, 2, 3}); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if err := inv(nil); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	err := inv([]int{1, 3, 2})
+	if !errors.Is(err, guard.ErrUnsorted) {
+		t.Fatalf(errExpectedViolation, guard.ErrUnsorted, err)
+	}
+}
+
+func TestMustBeUnique(t *testing.T) {
+	inv := guard.MustBeUnique[int]()
+	if err := inv([]int{1, 2, 3}); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	err := inv([]int{1, 2, 1})
+	if !errors.Is(err, guard.ErrDuplicate) {
+		t.Fatalf(errExpectedViolation, guard.ErrDuplicate, err)
+	}
+}
+
+func TestBufferPanicsOnViolation(t *testing.T) {
+	gb := guard.NewBuffer(buffer.New[int](), guard.MustRemainSorted(less))
+	if err := gb.PushN(1, 2, 3); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected a panic on invariant violation")
+		}
+	}()
+	_ = gb.Append(0)
+}
+
+func TestBufferViolationHandler(t *testing.T) {
+	var errs []error
+	gb := guard.NewBuffer(buffer.New[int](), guard.MustRemainSorted(less), guard.MustBeUnique[int]())
+	gb.SetViolationHandler(recorder(&errs))
+
+	_ = gb.Append(1)
+	_ = gb.Append(3)
+	_ = gb.InsertAt(1, 2)
+	if len(errs) != 0 {
+		t.Fatalf("expected no violations, got %v", errs)
+	}
+
+	_ = gb.Append(3)
+	if len(errs) != 1 || !errors.Is(errs[0], guard.ErrDuplicate) {
+		t.Fatalf(errExpectedViolation, guard.ErrDuplicate, errs)
+	}
+	_, _ = gb.PopN(1)
+
+	gb.Reverse()
+	if len(errs) != 2 || !errors.Is(errs[1], guard.ErrUnsorted) {
+		t.Fatalf(errExpectedViolation, guard.ErrUnsorted, errs)
+	}
+	if err := gb.Check(); err == nil {
+		t.Fatalf("expected Check to report the violation")
+	}
+}
+
+func TestBufferSetEnabled(t *testing.T) {
+	var errs []error
+	gb := guard.NewBuffer(buffer.New[int](), guard.MustRemainSorted(less))
+	gb.SetViolationHandler(recorder(&errs))
+	gb.SetEnabled(false)
+
+	_ = gb.PushN(3, 2, 1)
+	if len(errs) != 0 {
+		t.Fatalf("expected no violations while disabled, got %v", errs)
+	}
+	if gb.Check() == nil {
+		t.Fatalf("expected Check to validate even while disabled")
+	}
+
+	gb.SetEnabled(true)
+	_ = gb.Put(0, 0)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(errs))
+	}
+}
+
+func TestBufferMutations(t *testing.T) {
+	var errs []error
+	gb := guard.NewBuffer(buffer.New[int](), guard.MustRemainSorted(less))
+	gb.SetViolationHandler(recorder(&errs))
+
+	_ = gb.PushN(1, 2, 3, 4)
+	_ = gb.Remove(0)
+	gb.Filter(func(v int) bool { return v != 3 })
+	_ = gb.ForEach(func(v *int) error { *v *= 2; return nil })
+	_ = gb.Set(0, 1)
+	if len(errs) != 0 {
+		t.Fatalf("expected no violations, got %v", errs)
+	}
+	if gb.Size() != 2 {
+		t.Fatalf("expected size 2, got %d", gb.Size())
+	}
+
+	_ = gb.Swap(0, 1)
+	other := buffer.New[int]()
+	_ = other.Append(0)
+	gb.Merge(other)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 violations, got %d", len(errs))
+	}
+
+	gb.Clear()
+	if v := gb.Values(); len(v) != 0 {
+		t.Fatalf("expected empty buffer, got %v", v)
+	}
+	if gb.Unwrap() == nil {
+		t.Fatalf("expected the wrapped buffer")
+	}
+}
+
+func TestDLinkListViolationHandler(t *testing.T) {
+	var errs []error
+	gl := guard.NewDLinkList(dlinkList.New[int](), guard.MustBeUnique[int]())
+	gl.SetViolationHandler(recorder(&errs))
+
+	gl.Append(2)
+	gl.Prepend(1)
+	_ = gl.InsertAt(2, 3)
+	if len(errs) != 0 {
+		t.Fatalf("expected no violations, got %v", errs)
+	}
+
+	gl.Append(1)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(errs))
+	}
+	gl.DeleteWithValue(1)
+	if err := gl.Check(); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+}
+
+func TestDLinkListSorted(t *testing.T) {
+	var errs []error
+	gl := guard.NewDLinkList(dlinkList.New[int](), guard.MustRemainSorted(less))
+	gl.SetViolationHandler(recorder(&errs))
+
+	gl.Append(3)
+	gl.Append(1)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 violation, got %d", len(errs))
+	}
+
+	gl.Sort(less)
+	_ = gl.DeleteAt(0)
+	gl.Filter(func(v int) bool { return v > 0 })
+	gl.ForEach(func(v *int) { *v++ })
+	if len(errs) != 1 {
+		t.Fatalf("expected no new violations, got %v", errs)
+	}
+	if gl.Size() != 1 || gl.ToSlice()[0] != 4 {
+		t.Fatalf("expected [4], got %v", gl.ToSlice())
+	}
+
+	other := dlinkList.New[int]()
+	other.Append(0)
+	gl.Merge(other)
+	gl.Reverse()
+	_ = gl.Swap(0, 1)
+	if len(errs) != 3 {
+		t.Fatalf("expected 3 violations, got %d", len(errs))
+	}
+
+	gl.Clear()
+	if gl.Unwrap().Size() != 0 {
+		t.Fatalf("expected empty list")
+	}
+}
+
+func TestNewPanicsOnInvalidContainer(t *testing.T) {
+	b := buffer.New[int]()
+	_ = b.PushN(2, 1)
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatalf("expected a panic when wrapping an invalid buffer")
+		}
+	}()
+	guard.NewBuffer(b, guard.MustRemainSorted(less))
+}
+
+func TestMustBeUniqueFunc(t *testing.T) {
+	b := buffer.NewWithComparator(slices.Equal[[]int])
+	_ = b.Append([]int{1})
+	gb := guard.NewBuffer(b, guard.MustBeUniqueFunc(slices.Equal[[]int]))
+
+	var violation error
+	gb.SetViolationHandler(func(err error) { violation = err })
+	_ = gb.Append([]int{1, 2})
+	if violation != nil {
+		t.Fatalf("unexpected violation: %v", violation)
+	}
+	_ = gb.Append([]int{1})
+	if !errors.Is(violation, guard.ErrDuplicate) {
+		t.Errorf("expected a uniqueness violation, got %v", violation)
+	}
+}
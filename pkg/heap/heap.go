@@ -0,0 +1,302 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package heap provides a non-concurrent-safe generic binary heap, exposed
+// directly so algorithms like k-way merges and top-k selections can use it
+// without the priority queue abstraction on top.
+package heap
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the Heap methods (use errors.Is to check for them)
+var (
+	ErrEmpty       = errors.New("heap is empty")
+	ErrOutOfBounds = errors.New("index out of bounds")
+	ErrNotFound    = errors.New("value not found")
+	ErrSameHeap    = errors.New("cannot meld a heap with itself")
+	ErrCorrupted   = errors.New("heap is corrupted")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureInvariants, common.FeatureDeepCopy)
+}
+
+// Heap is a binary heap stored in a slice, the children of the element at
+// index i are at 2i+1 and 2i+2. The root is the element for which less
+// returns true against all the others, so a MinHeap (NewMin) pops the
+// smallest element first and a MaxHeap (NewMax) the largest one.
+// Indexes (Fix, Remove, Find) are positions in the heap slice, they change
+// when the heap is modified
+type Heap[T any] struct {
+	data []T
+	less func(a, b T) bool
+}
+
+// NewMin creates a new empty min-heap ordered with the < operator
+func NewMin[T cmp.Ordered]() *Heap[T] {
+	return NewWithLess(cmp.Less[T])
+}
+
+// NewMax creates a new empty max-heap ordered with the < operator
+func NewMax[T cmp.Ordered]() *Heap[T] {
+	return NewWithLess(func(a, b T) bool { return cmp.Less(b, a) })
+}
+
+// NewWithLess creates a new empty heap whose root is the smallest element
+// according to less (invert less for a max-heap)
+func NewWithLess[T any](less func(a, b T) bool) *Heap[T] {
+	return &Heap[T]{less: less}
+}
+
+// NewFromSlice creates a new heap ordered with less from a copy of the given
+// items, heapifying them in O(n)
+func NewFromSlice[T any](items []T, less func(a, b T) bool) *Heap[T] {
+	h := &Heap[T]{data: make([]T, len(items)), less: less}
+	copy(h.data, items)
+	h.heapify()
+	return h
+}
+
+// heapify restores the heap property of the whole slice in O(n)
+func (h *Heap[T]) heapify() {
+	for i := len(h.data)/2 - 1; i >= 0; i-- {
+		h.down(i)
+	}
+}
+
+// up moves the element at index i up the heap to restore the heap property
+func (h *Heap[T]) up(i int) {
+	for i > 0 {
+		parent := (i - 1) / 2
+		if !h.less(h.data[i], h.data[parent]) {
+			break
+		}
+		h.data[i], h.data[parent] = h.data[parent], h.data[i]
+		i = parent
+	}
+}
+
+// down moves the element at index i down the heap to restore the heap
+// property, it returns true if the element moved
+func (h *Heap[T]) down(i int) bool {
+	start, n := i, len(h.data)
+	for {
+		child := 2*i + 1
+		if child >= n {
+			break
+		}
+		if right := child + 1; right < n && h.less(h.data[right], h.data[child]) {
+			child = right
+		}
+		if !h.less(h.data[child], h.data[i]) {
+			break
+		}
+		h.data[i], h.data[child] = h.data[child], h.data[i]
+		i = child
+	}
+	return i > start
+}
+
+// Size returns the number of elements in the heap
+func (h *Heap[T]) Size() uint64 {
+	return uint64(len(h.data))
+}
+
+// IsEmpty returns true if the heap has no elements
+func (h *Heap[T]) IsEmpty() bool {
+	return len(h.data) == 0
+}
+
+// Push adds the given elements to the heap
+func (h *Heap[T]) Push(items ...T) {
+	for _, item := range items {
+		h.data = append(h.data, item)
+		h.up(len(h.data) - 1)
+	}
+}
+
+// Peek returns the root of the heap without removing it
+func (h *Heap[T]) Peek() (T, error) {
+	if len(h.data) == 0 {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+	return h.data[0], nil
+}
+
+// Pop removes and returns the root of the heap
+func (h *Heap[T]) Pop() (T, error) {
+	if len(h.data) == 0 {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+	return h.remove(0), nil
+}
+
+// PushPop pushes value and then pops the root, it's faster than a Push
+// followed by a Pop (value is returned directly if it would be the root)
+func (h *Heap[T]) PushPop(value T) T {
+	if len(h.data) == 0 || !h.less(h.data[0], value) {
+		return value
+	}
+	root := h.data[0]
+	h.data[0] = value
+	h.down(0)
+	return root
+}
+
+// Fix restores the heap order after the ordering of the element at index i
+// has changed (e.g. a pointer element whose priority was updated), it's
+// cheaper than a Remove and a Push
+func (h *Heap[T]) Fix(i uint64) error {
+	if i >= h.Size() {
+		return ErrOutOfBounds
+	}
+	if !h.down(int(i)) {
+		h.up(int(i))
+	}
+	return nil
+}
+
+// Set replaces the element at index i and restores the heap order
+func (h *Heap[T]) Set(i uint64, value T) error {
+	if i >= h.Size() {
+		return ErrOutOfBounds
+	}
+	h.data[i] = value
+	return h.Fix(i)
+}
+
+// Get returns the element at index i of the heap slice
+func (h *Heap[T]) Get(i uint64) (T, error) {
+	if i >= h.Size() {
+		var rVal T
+		return rVal, ErrOutOfBounds
+	}
+	return h.data[i], nil
+}
+
+// Remove removes and returns the element at index i
+func (h *Heap[T]) Remove(i uint64) (T, error) {
+	if i >= h.Size() {
+		var rVal T
+		return rVal, ErrOutOfBounds
+	}
+	return h.remove(int(i)), nil
+}
+
+// remove removes the element at index i (which must be valid) moving the
+// last element in its place
+func (h *Heap[T]) remove(i int) T {
+	last := len(h.data) - 1
+	value := h.data[i]
+	h.data[i] = h.data[last]
+	var zero T
+	h.data[last] = zero // let the GC collect the element
+	h.data = h.data[:last]
+	if i < last && !h.down(i) {
+		h.up(i)
+	}
+	return value
+}
+
+// Find returns the index of the first element (in heap slice order) that
+// matches the predicate, to be used with Fix, Set and Remove
+func (h *Heap[T]) Find(predicate func(T) bool) (uint64, error) {
+	for i, v := range h.data {
+		if predicate(v) {
+			return uint64(i), nil
+		}
+	}
+	return 0, ErrNotFound
+}
+
+// Meld moves all the elements of other into the heap, leaving other empty.
+// Both heaps must be ordered by the same less function, the result is
+// heapified in O(n+m)
+func (h *Heap[T]) Meld(other *Heap[T]) error {
+	if h == other {
+		return ErrSameHeap
+	}
+	if len(other.data) == 0 {
+		return nil
+	}
+	if len(other.data) < len(h.data)/8 {
+		// Pushing a few elements is cheaper than heapifying everything
+		h.Push(other.data...)
+	} else {
+		h.data = append(h.data, other.data...)
+		h.heapify()
+	}
+	other.Clear()
+	return nil
+}
+
+// Clear removes all the elements from the heap
+func (h *Heap[T]) Clear() {
+	h.data = nil
+}
+
+// Copy returns a copy of the heap. The copy is shallow: the elements are
+// copied by assignment (use CloneWith for a deep copy)
+func (h *Heap[T]) Copy() *Heap[T] {
+	return h.CloneWith(func(v T) T { return v })
+}
+
+// CloneWith returns a copy of the heap with a copy of every element made by
+// copier, to deep copy elements that hold pointers, slices or maps
+func (h *Heap[T]) CloneWith(copier func(T) T) *Heap[T] {
+	c := &Heap[T]{data: make([]T, len(h.data)), less: h.less}
+	for i, v := range h.data {
+		c.data[i] = copier(v)
+	}
+	return c
+}
+
+// ToSlice returns a copy of the elements in heap slice order (only the
+// first one is guaranteed to be the root, use Sorted for an ordered copy)
+func (h *Heap[T]) ToSlice() []T {
+	items := make([]T, len(h.data))
+	copy(items, h.data)
+	return items
+}
+
+// Sorted returns a copy of the elements in pop order, leaving the heap unchanged
+func (h *Heap[T]) Sorted() []T {
+	c := h.Copy()
+	items := make([]T, 0, len(h.data))
+	for !c.IsEmpty() {
+		items = append(items, c.remove(0))
+	}
+	return items
+}
+
+// CheckInvariants verifies that no element is less than its parent, it
+// returns an error wrapping ErrCorrupted describing the first violation found
+func (h *Heap[T]) CheckInvariants() error {
+	for i := 1; i < len(h.data); i++ {
+		if parent := (i - 1) / 2; h.less(h.data[i], h.data[parent]) {
+			return fmt.Errorf("%w: the element at %d is less than its parent at %d", ErrCorrupted, i, parent)
+		}
+	}
+	return nil
+}
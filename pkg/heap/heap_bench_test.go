@@ -0,0 +1,76 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heap_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	heap "github.com/pzaino/gods/pkg/heap"
+)
+
+func less(x, y int) bool { return x < y }
+
+// BenchmarkPush pushes n elements
+func BenchmarkPush(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			h := heap.NewMin[int]()
+			for _, v := range values {
+				h.Push(v)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkPop pops the root of a heap of n elements (and pushes it back, so
+// the size stays n)
+func BenchmarkPop(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		h := heap.NewFromSlice(bench.Ints(n), less)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			v, _ := h.Pop()
+			h.Push(v)
+		}
+	})
+}
+
+// BenchmarkFind looks for the value in the middle of n elements
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		target := values[n/2]
+		h := heap.NewFromSlice(values, less)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = h.Find(func(v int) bool { return v == target })
+		}
+	})
+}
+
+// BenchmarkSort returns the n elements of a heap in order (heapsort)
+func BenchmarkSort(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		h := heap.NewFromSlice(bench.Ints(n), less)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = h.Sorted()
+		}
+	})
+}
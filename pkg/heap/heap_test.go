@@ -0,0 +1,209 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package heap_test
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+
+	heap "github.com/pzaino/gods/pkg/heap"
+)
+
+func checkHeap[T any](t *testing.T, h *heap.Heap[T]) {
+	t.Helper()
+	if err := h.CheckInvariants(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	items := []int{5, 3, 8, 1, 9, 2, 7}
+	minHeap, maxHeap := heap.NewMin[int](), heap.NewMax[int]()
+	minHeap.Push(items...)
+	maxHeap.Push(items...)
+	checkHeap(t, minHeap)
+	checkHeap(t, maxHeap)
+
+	if v, err := minHeap.Peek(); err != nil || v != 1 {
+		t.Errorf("expected 1, got %d (%v)", v, err)
+	}
+	if v, err := maxHeap.Peek(); err != nil || v != 9 {
+		t.Errorf("expected 9, got %d (%v)", v, err)
+	}
+	if got := minHeap.Sorted(); !slices.Equal(got, []int{1, 2, 3, 5, 7, 8, 9}) {
+		t.Errorf("expected ascending order, got %v", got)
+	}
+	if got := maxHeap.Sorted(); !slices.Equal(got, []int{9, 8, 7, 5, 3, 2, 1}) {
+		t.Errorf("expected descending order, got %v", got)
+	}
+	if minHeap.Size() != uint64(len(items)) {
+		t.Errorf("expected Sorted not to change the heap, got size %d", minHeap.Size())
+	}
+
+	for _, expected := range []int{1, 2, 3, 5, 7, 8, 9} {
+		if v, err := minHeap.Pop(); err != nil || v != expected {
+			t.Fatalf("expected %d, got %d (%v)", expected, v, err)
+		}
+	}
+	if _, err := minHeap.Pop(); !errors.Is(err, heap.ErrEmpty) {
+		t.Errorf("expected %v, got %v", heap.ErrEmpty, err)
+	}
+	if _, err := minHeap.Peek(); !errors.Is(err, heap.ErrEmpty) {
+		t.Errorf("expected %v, got %v", heap.ErrEmpty, err)
+	}
+}
+
+func TestNewFromSlice(t *testing.T) {
+	items := rand.Perm(1000)
+	h := heap.NewFromSlice(items, func(a, b int) bool { return a < b })
+	checkHeap(t, h)
+	items[0] = -1
+	if v, _ := h.Peek(); v != 0 {
+		t.Errorf("expected the heap to copy the slice, got root %d", v)
+	}
+	sorted := h.Sorted()
+	if !slices.IsSorted(sorted) || len(sorted) != 1000 {
+		t.Errorf("expected 1000 sorted elements, got %d", len(sorted))
+	}
+}
+
+func TestPushPop(t *testing.T) {
+	h := heap.NewMin[int]()
+	if v := h.PushPop(5); v != 5 || !h.IsEmpty() {
+		t.Errorf("expected 5 and an empty heap, got %d (size %d)", v, h.Size())
+	}
+	h.Push(3, 7)
+	if v := h.PushPop(1); v != 1 {
+		t.Errorf("expected 1, got %d", v)
+	}
+	if v := h.PushPop(5); v != 3 {
+		t.Errorf("expected 3, got %d", v)
+	}
+	checkHeap(t, h)
+	if got := h.Sorted(); !slices.Equal(got, []int{5, 7}) {
+		t.Errorf("expected [5 7], got %v", got)
+	}
+}
+
+type task struct {
+	name     string
+	priority int
+}
+
+func TestFixRemove(t *testing.T) {
+	h := heap.NewWithLess(func(a, b *task) bool { return a.priority < b.priority })
+	tasks := []*task{{"a", 5}, {"b", 3}, {"c", 8}, {"d", 1}, {"e", 6}}
+	h.Push(tasks...)
+
+	// Raise the priority of c to the top
+	i, err := h.Find(func(v *task) bool { return v.name == "c" })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	tasks[2].priority = 0
+	if err := h.Fix(i); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkHeap(t, h)
+	if v, _ := h.Peek(); v.name != "c" {
+		t.Errorf("expected c at the root, got %s", v.name)
+	}
+
+	// Lower the root with Set
+	if err := h.Set(0, &task{"c", 10}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkHeap(t, h)
+
+	i, _ = h.Find(func(v *task) bool { return v.name == "a" })
+	if v, err := h.Remove(i); err != nil || v.name != "a" {
+		t.Errorf("expected to remove a, got %v (%v)", v, err)
+	}
+	checkHeap(t, h)
+
+	var names []string
+	for _, v := range h.Sorted() {
+		names = append(names, v.name)
+	}
+	if !slices.Equal(names, []string{"d", "b", "e", "c"}) {
+		t.Errorf("expected [d b e c], got %v", names)
+	}
+
+	if err := h.Fix(4); !errors.Is(err, heap.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", heap.ErrOutOfBounds, err)
+	}
+	if _, err := h.Remove(4); !errors.Is(err, heap.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", heap.ErrOutOfBounds, err)
+	}
+	if _, err := h.Find(func(v *task) bool { return v.name == "z" }); !errors.Is(err, heap.ErrNotFound) {
+		t.Errorf("expected %v, got %v", heap.ErrNotFound, err)
+	}
+}
+
+func TestRemoveRandom(t *testing.T) {
+	h := heap.NewFromSlice(rand.Perm(200), func(a, b int) bool { return a < b })
+	for !h.IsEmpty() {
+		if _, err := h.Remove(uint64(rand.Intn(int(h.Size())))); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkHeap(t, h)
+	}
+}
+
+func TestMeld(t *testing.T) {
+	for _, sizes := range [][2]int{{100, 3}, {10, 50}, {0, 5}, {5, 0}} {
+		a, b := heap.NewMin[int](), heap.NewMin[int]()
+		for i := 0; i < sizes[0]; i++ {
+			a.Push(i * 2)
+		}
+		for i := 0; i < sizes[1]; i++ {
+			b.Push(i*2 + 1)
+		}
+		if err := a.Meld(b); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		checkHeap(t, a)
+		if a.Size() != uint64(sizes[0]+sizes[1]) || !b.IsEmpty() {
+			t.Errorf("expected %d elements and an empty other heap, got %d and %d", sizes[0]+sizes[1], a.Size(), b.Size())
+		}
+		if !slices.IsSorted(a.Sorted()) {
+			t.Errorf("expected the melded heap to pop in order, got %v", a.Sorted())
+		}
+	}
+
+	a := heap.NewMin[int]()
+	if err := a.Meld(a); !errors.Is(err, heap.ErrSameHeap) {
+		t.Errorf("expected %v, got %v", heap.ErrSameHeap, err)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	h := heap.NewWithLess(func(a, b *task) bool { return a.priority < b.priority })
+	h.Push(&task{"a", 1}, &task{"b", 2})
+	clone := h.CloneWith(func(v *task) *task {
+		c := *v
+		return &c
+	})
+	root, _ := h.Peek()
+	root.priority = 100
+	if v, _ := clone.Peek(); v.priority != 1 {
+		t.Errorf("expected the clone not to share the elements, got priority %d", v.priority)
+	}
+	if v, _ := h.Copy().Peek(); v != root {
+		t.Error("expected Copy to share the elements")
+	}
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history provides a bounded log that keeps the last N items pushed
+// (e.g. the recent events of a connection), overwriting the oldest one when
+// it's full so it never grows. The items are stored in a ring buffer, Push is
+// O(1) and the overwritten items are passed to the OnOverflow subscribers.
+//
+// A History is not concurrency-safe, use a lock if it's shared by goroutines.
+package history
+
+import (
+	"errors"
+
+	common "github.com/pzaino/gods/pkg/common"
+	ringBuffer "github.com/pzaino/gods/pkg/ringBuffer"
+)
+
+// Sentinel errors returned by the History methods (use errors.Is to check for them)
+var (
+	ErrInvalidCapacity = errors.New("invalid capacity")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures()
+}
+
+// History keeps the last Capacity items pushed, from the oldest to the newest
+type History[T any] struct {
+	ring       *ringBuffer.CircularBuffer[T]
+	dropped    uint64
+	onOverflow []func(dropped T)
+}
+
+// New creates a new empty History that keeps the last capacity items
+func New[T any](capacity uint64) (*History[T], error) {
+	if capacity == 0 {
+		return nil, ErrInvalidCapacity
+	}
+	// The items are never compared, the ring buffer doesn't need a comparator
+	return &History[T]{ring: ringBuffer.NewWithComparator[T](capacity, nil)}, nil
+}
+
+// OnOverflow subscribes fn to the items overwritten by Push, the subscribers
+// are called in order after the new item has been added and must not push
+// items to the history
+func (h *History[T]) OnOverflow(fn func(dropped T)) {
+	h.onOverflow = append(h.onOverflow, fn)
+}
+
+// Push adds item as the newest one, overwriting the oldest one if the history
+// is full
+func (h *History[T]) Push(item T) {
+	if !h.ring.IsFull() {
+		h.ring.Append(item)
+		return
+	}
+
+	oldest, _ := h.ring.Remove()
+	h.ring.Append(item)
+	h.dropped++
+	for _, fn := range h.onOverflow {
+		fn(oldest)
+	}
+}
+
+// Last returns a copy of the newest k items (all of them if there are fewer),
+// from the oldest to the newest
+func (h *History[T]) Last(k uint64) []T {
+	n := h.ring.Size()
+	if k > n {
+		k = n
+	}
+
+	items := make([]T, k)
+	for i := uint64(0); i < k; i++ {
+		items[i], _ = h.ring.Get(n - k + i)
+	}
+	return items
+}
+
+// Newest returns the last item pushed, false if the history is empty
+func (h *History[T]) Newest() (T, bool) {
+	if h.ring.IsEmpty() {
+		var zero T
+		return zero, false
+	}
+	item, _ := h.ring.Get(h.ring.Size() - 1)
+	return item, true
+}
+
+// Snapshot returns a copy of all the items, from the oldest to the newest
+func (h *History[T]) Snapshot() []T {
+	return h.ring.ToSlice()
+}
+
+// ForEach calls fn for every item, from the oldest to the newest
+func (h *History[T]) ForEach(fn func(item T)) {
+	h.ring.ForEach(fn)
+}
+
+// Len returns the number of items in the history
+func (h *History[T]) Len() uint64 {
+	return h.ring.Size()
+}
+
+// Capacity returns the maximum number of items kept
+func (h *History[T]) Capacity() uint64 {
+	return h.ring.Capacity()
+}
+
+// IsEmpty checks if the history is empty
+func (h *History[T]) IsEmpty() bool {
+	return h.ring.IsEmpty()
+}
+
+// IsFull checks if the history is full, so every Push overwrites an item
+func (h *History[T]) IsFull() bool {
+	return h.ring.IsFull()
+}
+
+// Dropped returns the number of items overwritten since the history was
+// created or cleared
+func (h *History[T]) Dropped() uint64 {
+	return h.dropped
+}
+
+// Clear removes all the items (without calling the OnOverflow subscribers)
+// and resets the Dropped count
+func (h *History[T]) Clear() {
+	h.ring.Clear()
+	h.dropped = 0
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	history "github.com/pzaino/gods/pkg/history"
+)
+
+// BenchmarkPush fills a history of capacity n
+func BenchmarkPush(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			h, _ := history.New[int](uint64(n))
+			for _, v := range values {
+				h.Push(v)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkOverflow pushes an item in a full history of capacity n
+func BenchmarkOverflow(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		h, _ := history.New[int](uint64(n))
+		for _, v := range bench.Ints(n) {
+			h.Push(v)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			h.Push(i)
+		}
+	})
+}
+
+// BenchmarkLast copies the newest 100 items of a full history of capacity n
+func BenchmarkLast(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		h, _ := history.New[int](uint64(n))
+		for _, v := range bench.Ints(n) {
+			h.Push(v)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = h.Last(100)
+		}
+	})
+}
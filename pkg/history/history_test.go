@@ -0,0 +1,139 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	history "github.com/pzaino/gods/pkg/history"
+)
+
+const errUnexpectedErr = "unexpected error: %v"
+
+// newHistory creates a history of the given capacity, failing the test on error
+func newHistory(t *testing.T, capacity uint64) *history.History[int] {
+	t.Helper()
+	h, err := history.New[int](capacity)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	return h
+}
+
+func TestNew(t *testing.T) {
+	if _, err := history.New[int](0); !errors.Is(err, history.ErrInvalidCapacity) {
+		t.Errorf("expected %v, got %v", history.ErrInvalidCapacity, err)
+	}
+
+	h := newHistory(t, 3)
+	if !h.IsEmpty() || h.IsFull() || h.Len() != 0 || h.Capacity() != 3 {
+		t.Errorf("expected an empty history of capacity 3, got %d/%d", h.Len(), h.Capacity())
+	}
+	if _, ok := h.Newest(); ok {
+		t.Error("expected no newest item")
+	}
+	if items := h.Last(2); len(items) != 0 {
+		t.Errorf("expected no items, got %v", items)
+	}
+}
+
+func TestPushAndOverflow(t *testing.T) {
+	h := newHistory(t, 3)
+	var first, second []int
+	h.OnOverflow(func(dropped int) { first = append(first, dropped) })
+	h.OnOverflow(func(dropped int) {
+		if newest, _ := h.Newest(); newest != dropped+3 {
+			t.Errorf("expected the new item to be in the history, got %d", newest)
+		}
+		second = append(second, dropped)
+	})
+
+	for i := 1; i <= 5; i++ {
+		h.Push(i)
+	}
+	if !slices.Equal(h.Snapshot(), []int{3, 4, 5}) || !h.IsFull() {
+		t.Errorf("expected [3 4 5], got %v", h.Snapshot())
+	}
+	if !slices.Equal(first, []int{1, 2}) || !slices.Equal(second, first) || h.Dropped() != 2 {
+		t.Errorf("expected 1 and 2 to be dropped, got %v and %v (%d)", first, second, h.Dropped())
+	}
+
+	h.Clear()
+	if !h.IsEmpty() || h.Dropped() != 0 {
+		t.Errorf("expected an empty history, got %v (%d dropped)", h.Snapshot(), h.Dropped())
+	}
+	h.Push(6)
+	if !slices.Equal(h.Snapshot(), []int{6}) || len(first) != 2 {
+		t.Errorf("expected [6] and no overflow, got %v", h.Snapshot())
+	}
+}
+
+func TestLast(t *testing.T) {
+	h := newHistory(t, 5)
+	for i := 1; i <= 7; i++ {
+		h.Push(i)
+	}
+
+	tests := []struct {
+		k        uint64
+		expected []int
+	}{
+		{0, []int{}},
+		{1, []int{7}},
+		{3, []int{5, 6, 7}},
+		{5, []int{3, 4, 5, 6, 7}},
+		{10, []int{3, 4, 5, 6, 7}},
+	}
+	for _, test := range tests {
+		if items := h.Last(test.k); !slices.Equal(items, test.expected) {
+			t.Errorf("expected Last(%d) to return %v, got %v", test.k, test.expected, items)
+		}
+	}
+	if newest, ok := h.Newest(); !ok || newest != 7 {
+		t.Errorf("expected 7, got %d (%v)", newest, ok)
+	}
+}
+
+func TestSnapshotIsACopy(t *testing.T) {
+	h := newHistory(t, 2)
+	h.Push(1)
+	h.Push(2)
+
+	snapshot := h.Snapshot()
+	last := h.Last(2)
+	snapshot[0], last[0] = 10, 20
+	h.Push(3)
+
+	var items []int
+	h.ForEach(func(item int) { items = append(items, item) })
+	if !slices.Equal(items, []int{2, 3}) || !slices.Equal(snapshot, []int{10, 2}) {
+		t.Errorf("expected [2 3] and [10 2], got %v and %v", items, snapshot)
+	}
+}
+
+func TestNonComparableItems(t *testing.T) {
+	h, err := history.New[[]string](2)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	h.Push([]string{"a"})
+	h.Push(nil)
+	h.Push([]string{"b", "c"})
+	if last := h.Last(1); len(last) != 1 || !slices.Equal(last[0], []string{"b", "c"}) {
+		t.Errorf("expected [[b c]], got %v", last)
+	}
+}
@@ -0,0 +1,283 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package idring provides a concurrency-safe generator of monotonically
+// increasing, time-ordered unique IDs (ULID-like) that retains the most
+// recent IDs in a bounded ring for duplicate detection.
+package idring
+
+import (
+	"crypto/rand"
+	"errors"
+	"sync"
+	"time"
+
+	common "github.com/pzaino/gods/pkg/common"
+	ringBuffer "github.com/pzaino/gods/pkg/ringBuffer"
+)
+
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
+const (
+	ErrInvalidCapacity = "invalid capacity"
+	ErrInvalidID       = "invalid id"
+)
+
+// Sentinel errors returned by the Ring methods (use errors.Is to check for them)
+var (
+	ErrBadCapacity = errors.New(ErrInvalidCapacity)
+	ErrBadID       = errors.New(ErrInvalidID)
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureConcurrent)
+}
+
+// Crockford's base32 alphabet (as used by ULID)
+const encoding = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// encodedLen is the length of the string representation of an ID
+const encodedLen = 26
+
+// ID is a 128-bit time-ordered identifier: 48 bits of milliseconds since the
+// Unix epoch followed by 80 bits of entropy
+type ID [16]byte
+
+// Time returns the timestamp embedded in the ID
+func (id ID) Time() time.Time {
+	return time.UnixMilli(int64(id.timestamp()))
+}
+
+// String returns the 26 characters Crockford base32 representation of the ID
+func (id ID) String() string {
+	var dst [encodedLen]byte
+	// 128 bits are encoded in 130 bits (26 * 5), the 2 extra bits are the
+	// leading zero bits of the first character
+	var acc uint64
+	bits := 2
+	pos := 0
+	for _, b := range id {
+		acc = acc<<8 | uint64(b)
+		bits += 8
+		for bits >= 5 {
+			bits -= 5
+			dst[pos] = encoding[(acc>>uint(bits))&0x1F]
+			pos++
+		}
+	}
+	return string(dst[:])
+}
+
+// Compare returns -1, 0 or 1 if the ID is smaller, equal or greater than other
+func (id ID) Compare(other ID) int {
+	for i := range id {
+		if id[i] < other[i] {
+			return -1
+		}
+		if id[i] > other[i] {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (id ID) timestamp() uint64 {
+	var ms uint64
+	for i := 0; i < 6; i++ {
+		ms = ms<<8 | uint64(id[i])
+	}
+	return ms
+}
+
+// ParseID parses the string representation of an ID
+func ParseID(s string) (ID, error) {
+	var id ID
+	// The first character can only carry 3 bits
+	if len(s) != encodedLen || decode(s[0]) > 7 {
+		return id, ErrBadID
+	}
+
+	var acc uint64
+	bits := -2
+	pos := 0
+	for i := 0; i < encodedLen; i++ {
+		v := decode(s[i])
+		if v == 0xFF {
+			return ID{}, ErrBadID
+		}
+		acc = acc<<5 | uint64(v)
+		bits += 5
+		if bits >= 8 {
+			bits -= 8
+			id[pos] = byte(acc >> uint(bits))
+			pos++
+		}
+	}
+	return id, nil
+}
+
+func decode(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		c -= 'a' - 'A'
+	}
+	for i := 0; i < len(encoding); i++ {
+		if encoding[i] == c {
+			return byte(i)
+		}
+	}
+	return 0xFF
+}
+
+// IDRing generates time-ordered IDs and remembers the most recent ones.
+type IDRing struct {
+	mu    sync.Mutex
+	ring  *ringBuffer.CircularBuffer[ID]
+	seen  map[ID]struct{}
+	last  ID
+	clock func() time.Time
+}
+
+// New creates a new IDRing retaining up to capacity recent IDs.
+func New(capacity uint64) (*IDRing, error) {
+	if capacity == 0 {
+		return nil, ErrBadCapacity
+	}
+	return &IDRing{
+		ring:  ringBuffer.New[ID](capacity),
+		seen:  make(map[ID]struct{}, capacity),
+		clock: time.Now,
+	}, nil
+}
+
+// SetClock replaces the time source used to generate new IDs.
+func (r *IDRing) SetClock(clock func() time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if clock == nil {
+		clock = time.Now
+	}
+	r.clock = clock
+}
+
+// NewID generates a new ID, strictly greater than any ID previously
+// generated by the ring, and records it.
+func (r *IDRing) NewID() (ID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var id ID
+	ms := uint64(r.clock().UnixMilli())
+	if ms <= r.last.timestamp() {
+		// Same millisecond (or clock moved backwards):
+		// keep the last timestamp and increment the entropy
+		id = r.last
+		if !increment(id[6:]) {
+			// Entropy overflow, move to the next millisecond
+			ms = r.last.timestamp() + 1
+			id = ID{}
+			putTimestamp(&id, ms)
+		}
+	} else {
+		putTimestamp(&id, ms)
+		if _, err := rand.Read(id[6:]); err != nil {
+			return ID{}, err
+		}
+		// Leave room for increments within the same millisecond
+		id[6] &= 0x7F
+	}
+
+	r.last = id
+	r.record(id)
+	return id, nil
+}
+
+// Observe records an externally generated ID and returns true if it was
+// already among the retained IDs (a duplicate).
+func (r *IDRing) Observe(id ID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.seen[id]; ok {
+		return true
+	}
+	r.record(id)
+	return false
+}
+
+// Seen checks if the ID is among the retained IDs.
+func (r *IDRing) Seen(id ID) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.seen[id]
+	return ok
+}
+
+// Snapshot returns the retained IDs from the oldest to the newest.
+func (r *IDRing) Snapshot() []ID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ring.ToSlice()
+}
+
+// Size returns the number of retained IDs.
+func (r *IDRing) Size() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ring.Size()
+}
+
+// Capacity returns the maximum number of retained IDs.
+func (r *IDRing) Capacity() uint64 {
+	return r.ring.Capacity()
+}
+
+// Clear forgets all the retained IDs (IDs generated afterwards are
+// still greater than the ones generated before).
+func (r *IDRing) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ring.Clear()
+	r.seen = make(map[ID]struct{}, r.ring.Capacity())
+}
+
+// record adds the ID to the ring, evicting the oldest one if the ring is full
+func (r *IDRing) record(id ID) {
+	if r.ring.IsFull() {
+		oldest, _ := r.ring.Remove()
+		delete(r.seen, oldest)
+	}
+	r.ring.Append(id)
+	r.seen[id] = struct{}{}
+}
+
+func putTimestamp(id *ID, ms uint64) {
+	for i := 5; i >= 0; i-- {
+		id[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+// increment adds one to the big-endian number in b, returns false on overflow
+func increment(b []byte) bool {
+	for i := len(b) - 1; i >= 0; i-- {
+		b[i]++
+		if b[i] != 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package idring_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	idring "github.com/pzaino/gods/pkg/idring"
+)
+
+// BenchmarkNewID generates IDs with a ring remembering the last n
+func BenchmarkNewID(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		r, _ := idring.New(uint64(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = r.NewID()
+		}
+	})
+}
+
+// BenchmarkFind checks if an ID is among the last n generated
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		r, _ := idring.New(uint64(n))
+		ids := make([]idring.ID, n)
+		for i := range ids {
+			ids[i], _ = r.NewID()
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = r.Seen(ids[i%n])
+		}
+	})
+}
@@ -0,0 +1,192 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package idring provides a time-ordered unique ID ring.
+package idring_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	idring "github.com/pzaino/gods/pkg/idring"
+)
+
+const (
+	errExpectedNoError = "expected no error, got %v"
+	errExpectedSize    = "expected size %d, got %d"
+)
+
+func newRing(t *testing.T, capacity uint64) *idring.IDRing {
+	t.Helper()
+	r, err := idring.New(capacity)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	return r
+}
+
+func TestNew(t *testing.T) {
+	if _, err := idring.New(0); !errors.Is(err, idring.ErrBadCapacity) {
+		t.Errorf("expected an error for zero capacity")
+	}
+	r := newRing(t, 8)
+	if r.Capacity() != 8 {
+		t.Errorf("expected capacity 8, got %d", r.Capacity())
+	}
+	if r.Size() != 0 {
+		t.Errorf(errExpectedSize, 0, r.Size())
+	}
+}
+
+func TestNewIDIsMonotonic(t *testing.T) {
+	r := newRing(t, 16)
+	now := time.UnixMilli(1700000000000)
+	r.SetClock(func() time.Time { return now })
+
+	prev, err := r.NewID()
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	for i := 0; i < 1000; i++ {
+		// Move the clock backwards half of the times
+		if i%2 == 0 {
+			now = now.Add(-time.Millisecond)
+		}
+		id, err := r.NewID()
+		if err != nil {
+			t.Fatalf(errExpectedNoError, err)
+		}
+		if id.Compare(prev) <= 0 {
+			t.Fatalf("expected %s to be greater than %s", id, prev)
+		}
+		if id.String() <= prev.String() {
+			t.Fatalf("expected string %s to sort after %s", id, prev)
+		}
+		prev = id
+	}
+
+	if !prev.Time().Equal(time.UnixMilli(1700000000000)) {
+		t.Errorf("expected timestamp %v, got %v", time.UnixMilli(1700000000000), prev.Time())
+	}
+}
+
+func TestSeenAndEviction(t *testing.T) {
+	r := newRing(t, 3)
+	ids := make([]idring.ID, 5)
+	for i := range ids {
+		id, err := r.NewID()
+		if err != nil {
+			t.Fatalf(errExpectedNoError, err)
+		}
+		ids[i] = id
+	}
+
+	if r.Size() != 3 {
+		t.Errorf(errExpectedSize, 3, r.Size())
+	}
+	for i, id := range ids {
+		if r.Seen(id) != (i >= 2) {
+			t.Errorf("unexpected Seen result for id %d", i)
+		}
+	}
+
+	snapshot := r.Snapshot()
+	for i, id := range snapshot {
+		if id != ids[i+2] {
+			t.Errorf("expected snapshot[%d] to be %s, got %s", i, ids[i+2], id)
+		}
+	}
+
+	r.Clear()
+	if r.Size() != 0 || r.Seen(ids[4]) {
+		t.Errorf("expected ring to be empty after Clear")
+	}
+	id, _ := r.NewID()
+	if id.Compare(ids[4]) <= 0 {
+		t.Errorf("expected ids to keep increasing after Clear")
+	}
+}
+
+func TestObserve(t *testing.T) {
+	r := newRing(t, 2)
+	a, _ := idring.ParseID("01ARZ3NDEKTSV4RRFFQ69G5FAV")
+	b, _ := idring.ParseID("01ARZ3NDEKTSV4RRFFQ69G5FAW")
+	c, _ := idring.ParseID("01ARZ3NDEKTSV4RRFFQ69G5FAX")
+
+	if r.Observe(a) {
+		t.Errorf("expected first observation not to be a duplicate")
+	}
+	if !r.Observe(a) {
+		t.Errorf("expected second observation to be a duplicate")
+	}
+	r.Observe(b)
+	r.Observe(c)
+	if r.Seen(a) {
+		t.Errorf("expected oldest id to be evicted")
+	}
+}
+
+func TestParseID(t *testing.T) {
+	const s = "01ARZ3NDEKTSV4RRFFQ69G5FAV"
+	id, err := idring.ParseID(s)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if id.String() != s {
+		t.Errorf("expected %s, got %s", s, id.String())
+	}
+	if id.Time().UnixMilli() != 1469922850259 {
+		t.Errorf("expected timestamp 1469922850259, got %d", id.Time().UnixMilli())
+	}
+
+	lower, err := idring.ParseID("01arz3ndektsv4rrffq69g5fav")
+	if err != nil || lower != id {
+		t.Errorf("expected lowercase ids to be accepted")
+	}
+
+	for _, bad := range []string{"", "01ARZ3NDEKTSV4RRFFQ69G5FA", "81ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAU"} {
+		if _, err := idring.ParseID(bad); !errors.Is(err, idring.ErrBadID) {
+			t.Errorf("expected an error parsing %q", bad)
+		}
+	}
+}
+
+func TestConcurrentNewID(t *testing.T) {
+	r := newRing(t, 10000)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				if _, err := r.NewID(); err != nil {
+					t.Errorf(errExpectedNoError, err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	snapshot := r.Snapshot()
+	if len(snapshot) != 10000 {
+		t.Fatalf(errExpectedSize, 10000, len(snapshot))
+	}
+	for i := 1; i < len(snapshot); i++ {
+		if snapshot[i].Compare(snapshot[i-1]) <= 0 {
+			t.Fatalf("expected snapshot to be strictly increasing at %d", i)
+		}
+	}
+}
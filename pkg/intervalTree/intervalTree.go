@@ -0,0 +1,401 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package intervalTree provides a non-concurrent-safe interval tree, an AVL
+// tree of closed [low, high] intervals with generic payloads, where every
+// node knows the largest high of its subtree so the stab and overlap queries
+// skip the subtrees that can't match.
+package intervalTree
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the Tree methods (use errors.Is to check for them)
+var (
+	ErrInvalidInterval = errors.New("the low end of the interval is greater than the high end")
+	ErrCorrupted       = errors.New("tree is corrupted")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureInvariants)
+}
+
+// Interval is a closed interval [Low, High]
+type Interval[K any] struct {
+	Low  K
+	High K
+}
+
+// Entry is an interval with its payload, as returned by the queries
+type Entry[K, V any] struct {
+	Interval[K]
+	Value V
+}
+
+// node holds all the payloads of an interval (in insertion order), maxHigh
+// is the largest high end in its subtree
+type node[K, V any] struct {
+	interval Interval[K]
+	values   []V
+	maxHigh  K
+	height   int
+	left     *node[K, V]
+	right    *node[K, V]
+}
+
+// height returns the height of the subtree of n (0 for nil)
+func height[K, V any](n *node[K, V]) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// Tree is an interval tree, the same interval can be inserted more than once
+// with different payloads
+type Tree[K, V any] struct {
+	root *node[K, V]
+	size uint64
+	less func(a, b K) bool
+}
+
+// New creates a new empty Tree ordering the interval ends with the < operator
+func New[K cmp.Ordered, V any]() *Tree[K, V] {
+	return NewWithLess[K, V](cmp.Less[K])
+}
+
+// NewWithLess creates a new empty Tree ordering the interval ends with less
+func NewWithLess[K, V any](less func(a, b K) bool) *Tree[K, V] {
+	return &Tree[K, V]{less: less}
+}
+
+// Size returns the number of entries in the tree
+func (t *Tree[K, V]) Size() uint64 {
+	return t.size
+}
+
+// IsEmpty returns true if the tree has no entries
+func (t *Tree[K, V]) IsEmpty() bool {
+	return t.size == 0
+}
+
+// Clear removes all the entries from the tree
+func (t *Tree[K, V]) Clear() {
+	t.root = nil
+	t.size = 0
+}
+
+// compare orders the intervals by their low end and then by their high end
+func (t *Tree[K, V]) compare(a, b Interval[K]) int {
+	switch {
+	case t.less(a.Low, b.Low):
+		return -1
+	case t.less(b.Low, a.Low):
+		return 1
+	case t.less(a.High, b.High):
+		return -1
+	case t.less(b.High, a.High):
+		return 1
+	}
+	return 0
+}
+
+// update recomputes the height and the largest high end of n from its children
+func (t *Tree[K, V]) update(n *node[K, V]) {
+	n.height = 1 + max(height(n.left), height(n.right))
+	n.maxHigh = n.interval.High
+	for _, c := range []*node[K, V]{n.left, n.right} {
+		if c != nil && t.less(n.maxHigh, c.maxHigh) {
+			n.maxHigh = c.maxHigh
+		}
+	}
+}
+
+// rotateRight rotates the subtree of n to the right and returns its new root
+func (t *Tree[K, V]) rotateRight(n *node[K, V]) *node[K, V] {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	t.update(n)
+	t.update(l)
+	return l
+}
+
+// rotateLeft rotates the subtree of n to the left and returns its new root
+func (t *Tree[K, V]) rotateLeft(n *node[K, V]) *node[K, V] {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	t.update(n)
+	t.update(r)
+	return r
+}
+
+// rebalance updates n and restores the AVL property of its subtree, it
+// returns the new root of the subtree
+func (t *Tree[K, V]) rebalance(n *node[K, V]) *node[K, V] {
+	t.update(n)
+	switch b := height(n.left) - height(n.right); {
+	case b > 1:
+		if height(n.left.left) < height(n.left.right) {
+			n.left = t.rotateLeft(n.left)
+		}
+		return t.rotateRight(n)
+	case b < -1:
+		if height(n.right.right) < height(n.right.left) {
+			n.right = t.rotateRight(n.right)
+		}
+		return t.rotateLeft(n)
+	}
+	return n
+}
+
+// Insert adds the interval [low, high] with its payload to the tree
+func (t *Tree[K, V]) Insert(low, high K, value V) error {
+	if t.less(high, low) {
+		return ErrInvalidInterval
+	}
+	t.root = t.insert(t.root, Interval[K]{Low: low, High: high}, value)
+	t.size++
+	return nil
+}
+
+// insert adds the interval to the subtree of n and returns its new root
+func (t *Tree[K, V]) insert(n *node[K, V], interval Interval[K], value V) *node[K, V] {
+	if n == nil {
+		return &node[K, V]{interval: interval, values: []V{value}, maxHigh: interval.High, height: 1}
+	}
+	switch c := t.compare(interval, n.interval); {
+	case c < 0:
+		n.left = t.insert(n.left, interval, value)
+	case c > 0:
+		n.right = t.insert(n.right, interval, value)
+	default:
+		n.values = append(n.values, value)
+		return n
+	}
+	return t.rebalance(n)
+}
+
+// Delete removes the interval [low, high] with all its payloads, it returns
+// the number of entries removed
+func (t *Tree[K, V]) Delete(low, high K) uint64 {
+	return t.DeleteFunc(low, high, func(V) bool { return true })
+}
+
+// DeleteFunc removes the entries of the interval [low, high] whose payload
+// matches the predicate, it returns the number of entries removed
+func (t *Tree[K, V]) DeleteFunc(low, high K, match func(V) bool) uint64 {
+	var removed uint64
+	t.root = t.delete(t.root, Interval[K]{Low: low, High: high}, match, &removed)
+	t.size -= removed
+	return removed
+}
+
+// delete removes the matching payloads of the interval from the subtree of
+// n (and the node, when none is left) and returns its new root
+func (t *Tree[K, V]) delete(n *node[K, V], interval Interval[K], match func(V) bool, removed *uint64) *node[K, V] {
+	if n == nil {
+		return nil
+	}
+
+	switch c := t.compare(interval, n.interval); {
+	case c < 0:
+		n.left = t.delete(n.left, interval, match, removed)
+	case c > 0:
+		n.right = t.delete(n.right, interval, match, removed)
+	default:
+		kept := n.values[:0]
+		for _, v := range n.values {
+			if match(v) {
+				*removed++
+			} else {
+				kept = append(kept, v)
+			}
+		}
+		clear(n.values[len(kept):]) // let the GC collect the removed payloads
+		n.values = kept
+		if len(kept) > 0 {
+			return n
+		}
+		if n.left == nil {
+			return n.right
+		}
+		if n.right == nil {
+			return n.left
+		}
+		// Replace n with its successor, the leftmost node of the right subtree
+		var succ *node[K, V]
+		n.right, succ = t.removeMin(n.right)
+		succ.left, succ.right = n.left, n.right
+		n = succ
+	}
+	return t.rebalance(n)
+}
+
+// removeMin detaches the leftmost node of the subtree of n, it returns the
+// new root of the subtree and the detached node
+func (t *Tree[K, V]) removeMin(n *node[K, V]) (*node[K, V], *node[K, V]) {
+	if n.left == nil {
+		return n.right, n
+	}
+	var m *node[K, V]
+	n.left, m = t.removeMin(n.left)
+	return t.rebalance(n), m
+}
+
+// StabQuery returns all the entries whose interval contains point, ordered
+// by interval
+func (t *Tree[K, V]) StabQuery(point K) []Entry[K, V] {
+	return t.OverlapQuery(point, point)
+}
+
+// OverlapQuery returns all the entries whose interval overlaps [low, high]
+// (they share at least one point), ordered by interval. It runs in
+// O(log n + m) for m results
+func (t *Tree[K, V]) OverlapQuery(low, high K) []Entry[K, V] {
+	var entries []Entry[K, V]
+	t.overlap(t.root, Interval[K]{Low: low, High: high}, func(n *node[K, V]) {
+		for _, v := range n.values {
+			entries = append(entries, Entry[K, V]{Interval: n.interval, Value: v})
+		}
+	})
+	return entries
+}
+
+// Overlaps returns true if any interval in the tree overlaps [low, high]
+func (t *Tree[K, V]) Overlaps(low, high K) bool {
+	query := Interval[K]{Low: low, High: high}
+	n := t.root
+	for n != nil {
+		if t.overlapping(n.interval, query) {
+			return true
+		}
+		// If the left subtree reaches low it has an overlap when anything does
+		if n.left != nil && !t.less(n.left.maxHigh, low) {
+			n = n.left
+		} else {
+			n = n.right
+		}
+	}
+	return false
+}
+
+// overlapping returns true if the two closed intervals share at least one point
+func (t *Tree[K, V]) overlapping(a, b Interval[K]) bool {
+	return !t.less(a.High, b.Low) && !t.less(b.High, a.Low)
+}
+
+// overlap calls fn, in order, for the nodes of the subtree of n that overlap query
+func (t *Tree[K, V]) overlap(n *node[K, V], query Interval[K], fn func(*node[K, V])) {
+	if n == nil || t.less(n.maxHigh, query.Low) {
+		// Every interval in the subtree ends before the query
+		return
+	}
+	t.overlap(n.left, query, fn)
+	if t.less(query.High, n.interval.Low) {
+		// n and its right subtree start after the query
+		return
+	}
+	if t.overlapping(n.interval, query) {
+		fn(n)
+	}
+	t.overlap(n.right, query, fn)
+}
+
+// Ascend calls fn for every entry ordered by interval until fn returns false
+func (t *Tree[K, V]) Ascend(fn func(Entry[K, V]) bool) {
+	t.ascend(t.root, fn)
+}
+
+// ascend visits the subtree of n in order, it returns false if fn stopped it
+func (t *Tree[K, V]) ascend(n *node[K, V], fn func(Entry[K, V]) bool) bool {
+	if n == nil {
+		return true
+	}
+	if !t.ascend(n.left, fn) {
+		return false
+	}
+	for _, v := range n.values {
+		if !fn(Entry[K, V]{Interval: n.interval, Value: v}) {
+			return false
+		}
+	}
+	return t.ascend(n.right, fn)
+}
+
+// ToSlice returns all the entries ordered by interval
+func (t *Tree[K, V]) ToSlice() []Entry[K, V] {
+	entries := make([]Entry[K, V], 0, t.size)
+	t.Ascend(func(e Entry[K, V]) bool {
+		entries = append(entries, e)
+		return true
+	})
+	return entries
+}
+
+// CheckInvariants verifies the internal consistency of the tree (the order
+// of the intervals, the largest high ends, the heights, the AVL balance and
+// the size), it returns an error wrapping ErrCorrupted describing the first
+// violation found
+func (t *Tree[K, V]) CheckInvariants() error {
+	count, err := t.check(t.root, nil, nil)
+	if err != nil {
+		return err
+	}
+	if count != t.size {
+		return fmt.Errorf("%w: %d entries but its size is %d", ErrCorrupted, count, t.size)
+	}
+	return nil
+}
+
+// check verifies the subtree of n, whose intervals must be in (lo, hi), and
+// returns its number of entries
+func (t *Tree[K, V]) check(n *node[K, V], lo, hi *Interval[K]) (uint64, error) {
+	if n == nil {
+		return 0, nil
+	}
+	if (lo != nil && t.compare(*lo, n.interval) >= 0) || (hi != nil && t.compare(n.interval, *hi) >= 0) {
+		return 0, fmt.Errorf("%w: the interval %v is out of order", ErrCorrupted, n.interval)
+	}
+	if len(n.values) == 0 || t.less(n.interval.High, n.interval.Low) {
+		return 0, fmt.Errorf("%w: the node of %v is empty or invalid", ErrCorrupted, n.interval)
+	}
+
+	left, err := t.check(n.left, lo, &n.interval)
+	if err != nil {
+		return 0, err
+	}
+	right, err := t.check(n.right, &n.interval, hi)
+	if err != nil {
+		return 0, err
+	}
+
+	expected := *n
+	t.update(&expected)
+	if expected.height != n.height || t.less(expected.maxHigh, n.maxHigh) || t.less(n.maxHigh, expected.maxHigh) {
+		return 0, fmt.Errorf("%w: the node of %v has wrong height or largest high end", ErrCorrupted, n.interval)
+	}
+	if b := height(n.left) - height(n.right); b > 1 || b < -1 {
+		return 0, fmt.Errorf("%w: the node of %v is unbalanced (%d)", ErrCorrupted, n.interval, b)
+	}
+	return left + uint64(len(n.values)) + right, nil
+}
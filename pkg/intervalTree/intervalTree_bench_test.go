@@ -0,0 +1,70 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intervalTree_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	intervalTree "github.com/pzaino/gods/pkg/intervalTree"
+)
+
+// newBenchTree returns a tree holding the intervals [v, v+10] of values
+func newBenchTree(values []int) *intervalTree.Tree[int, int] {
+	tree := intervalTree.New[int, int]()
+	for _, v := range values {
+		_ = tree.Insert(v, v+10, v)
+	}
+	return tree
+}
+
+// BenchmarkInsert inserts n shuffled intervals
+func BenchmarkInsert(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = newBenchTree(values)
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkDelete deletes an interval from a tree of n intervals (and
+// inserts it back, so the size stays n)
+func BenchmarkDelete(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		tree := newBenchTree(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			v := values[i%n]
+			tree.Delete(v, v+10)
+			_ = tree.Insert(v, v+10, v)
+		}
+	})
+}
+
+// BenchmarkFind finds the intervals containing a point among n intervals
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		tree := newBenchTree(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = tree.StabQuery(values[i%n])
+		}
+	})
+}
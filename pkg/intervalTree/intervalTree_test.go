@@ -0,0 +1,155 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package intervalTree_test
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+
+	intervalTree "github.com/pzaino/gods/pkg/intervalTree"
+)
+
+func checkTree[K, V any](t *testing.T, tree *intervalTree.Tree[K, V]) {
+	t.Helper()
+	if err := tree.CheckInvariants(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// values returns the payloads of the entries
+func values[K, V any](entries []intervalTree.Entry[K, V]) []V {
+	var items []V
+	for _, e := range entries {
+		items = append(items, e.Value)
+	}
+	return items
+}
+
+func TestInsert(t *testing.T) {
+	tree := intervalTree.New[int, string]()
+	if err := tree.Insert(5, 1, "bad"); !errors.Is(err, intervalTree.ErrInvalidInterval) {
+		t.Errorf("expected %v, got %v", intervalTree.ErrInvalidInterval, err)
+	}
+	for _, e := range []struct {
+		low, high int
+		value     string
+	}{{15, 20, "a"}, {10, 30, "b"}, {17, 19, "c"}, {5, 20, "d"}, {12, 15, "e"}, {30, 40, "f"}, {10, 30, "g"}} {
+		if err := tree.Insert(e.low, e.high, e.value); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	checkTree(t, tree)
+	if tree.Size() != 7 {
+		t.Errorf("expected 7 entries, got %d", tree.Size())
+	}
+	if got := values(tree.ToSlice()); !slices.Equal(got, []string{"d", "b", "g", "e", "a", "c", "f"}) {
+		t.Errorf("expected the entries ordered by interval, got %v", got)
+	}
+}
+
+func TestQueries(t *testing.T) {
+	tree := intervalTree.New[int, string]()
+	_ = tree.Insert(15, 20, "a")
+	_ = tree.Insert(10, 30, "b")
+	_ = tree.Insert(17, 19, "c")
+	_ = tree.Insert(5, 20, "d")
+	_ = tree.Insert(12, 15, "e")
+	_ = tree.Insert(30, 40, "f")
+
+	if got := values(tree.StabQuery(18)); !slices.Equal(got, []string{"d", "b", "a", "c"}) {
+		t.Errorf("expected [d b a c], got %v", got)
+	}
+	if got := values(tree.StabQuery(30)); !slices.Equal(got, []string{"b", "f"}) {
+		t.Errorf("expected the ends to be included, got %v", got)
+	}
+	if got := tree.StabQuery(41); got != nil {
+		t.Errorf("expected no entries, got %v", got)
+	}
+	if got := values(tree.OverlapQuery(0, 11)); !slices.Equal(got, []string{"d", "b"}) {
+		t.Errorf("expected [d b], got %v", got)
+	}
+	if !tree.Overlaps(35, 50) || tree.Overlaps(41, 50) || tree.Overlaps(0, 4) {
+		t.Error("expected Overlaps to agree with OverlapQuery")
+	}
+}
+
+func TestDelete(t *testing.T) {
+	tree := intervalTree.New[int, int]()
+	_ = tree.Insert(1, 5, 1)
+	_ = tree.Insert(1, 5, 2)
+	_ = tree.Insert(3, 8, 3)
+
+	if n := tree.DeleteFunc(1, 5, func(v int) bool { return v == 2 }); n != 1 {
+		t.Errorf("expected to remove 1 entry, removed %d", n)
+	}
+	if got := values(tree.StabQuery(2)); !slices.Equal(got, []int{1}) {
+		t.Errorf("expected [1], got %v", got)
+	}
+	if n := tree.Delete(3, 8); n != 1 || tree.Overlaps(6, 7) {
+		t.Errorf("expected to remove [3, 8], removed %d", n)
+	}
+	if n := tree.Delete(3, 8); n != 0 {
+		t.Errorf("expected nothing to remove, removed %d", n)
+	}
+	checkTree(t, tree)
+	tree.Clear()
+	if !tree.IsEmpty() {
+		t.Error("expected Clear to empty the tree")
+	}
+}
+
+func TestRandomized(t *testing.T) {
+	type entry struct{ low, high, id int }
+	tree := intervalTree.New[int, int]()
+	var reference []entry
+	for id := 0; id < 3000; id++ {
+		if len(reference) > 0 && rand.Intn(3) == 0 {
+			e := reference[rand.Intn(len(reference))]
+			removed := tree.DeleteFunc(e.low, e.high, func(v int) bool { return v == e.id })
+			if removed != 1 {
+				t.Fatalf("expected to remove entry %d, removed %d", e.id, removed)
+			}
+			reference = slices.DeleteFunc(reference, func(r entry) bool { return r.id == e.id })
+			continue
+		}
+		low := rand.Intn(1000)
+		e := entry{low, low + rand.Intn(50), id}
+		_ = tree.Insert(e.low, e.high, e.id)
+		reference = append(reference, e)
+	}
+	checkTree(t, tree)
+
+	for q := 0; q < 200; q++ {
+		low := rand.Intn(1100) - 50
+		high := low + rand.Intn(30)
+		var expected []int
+		for _, e := range reference {
+			if e.low <= high && low <= e.high {
+				expected = append(expected, e.id)
+			}
+		}
+		got := values(tree.OverlapQuery(low, high))
+		slices.Sort(expected)
+		slices.Sort(got)
+		if !slices.Equal(got, expected) {
+			t.Fatalf("OverlapQuery(%d, %d): expected %v, got %v", low, high, expected, got)
+		}
+		if tree.Overlaps(low, high) != (len(expected) > 0) {
+			t.Fatalf("Overlaps(%d, %d): expected %v", low, high, len(expected) > 0)
+		}
+	}
+}
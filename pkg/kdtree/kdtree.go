@@ -0,0 +1,332 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kdtree provides a non-concurrent-safe k-dimensional tree
+// for nearest neighbor and range (radius) searches.
+package kdtree
+
+import (
+	"errors"
+	"sort"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
+const (
+	ErrDimensionMismatch = "point dimensions mismatch"
+	ErrInvalidDimensions = "invalid number of dimensions"
+	ErrTreeIsEmpty       = "tree is empty"
+)
+
+// Sentinel errors returned by the KDTree methods (use errors.Is to check for them)
+var (
+	ErrSizeMismatch  = errors.New(ErrDimensionMismatch)
+	ErrBadDimensions = errors.New(ErrInvalidDimensions)
+	ErrEmpty         = errors.New(ErrTreeIsEmpty)
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures()
+}
+
+// Point is the constraint for the elements stored in a KDTree.
+type Point interface {
+	comparable
+	// Dimensions returns the number of coordinates of the point
+	Dimensions() int
+	// Coordinate returns the i-th coordinate of the point
+	Coordinate(i int) float64
+}
+
+// Point2D is a ready to use 2-dimensional point
+type Point2D [2]float64
+
+// Dimensions returns the number of coordinates of the point
+func (p Point2D) Dimensions() int { return 2 }
+
+// Coordinate returns the i-th coordinate of the point
+func (p Point2D) Coordinate(i int) float64 { return p[i] }
+
+// Point3D is a ready to use 3-dimensional point
+type Point3D [3]float64
+
+// Dimensions returns the number of coordinates of the point
+func (p Point3D) Dimensions() int { return 3 }
+
+// Coordinate returns the i-th coordinate of the point
+func (p Point3D) Coordinate(i int) float64 { return p[i] }
+
+type node[P Point] struct {
+	point P
+	axis  int
+	left  *node[P]
+	right *node[P]
+}
+
+// KDTree is a k-dimensional tree
+type KDTree[P Point] struct {
+	root *node[P]
+	dims int
+	size uint64
+}
+
+// New creates a new empty KDTree for points with the given number of dimensions
+func New[P Point](dims int) (*KDTree[P], error) {
+	if dims <= 0 {
+		return nil, ErrBadDimensions
+	}
+	return &KDTree[P]{dims: dims}, nil
+}
+
+// Build creates a new balanced KDTree from the points in the given buffer
+// (the buffer is not modified)
+func Build[P Point](dims int, points *buffer.Buffer[P]) (*KDTree[P], error) {
+	t, err := New[P](dims)
+	if err != nil {
+		return nil, err
+	}
+	if points.IsEmpty() {
+		return t, nil
+	}
+
+	items := points.ToSlice()
+	for _, p := range items {
+		if p.Dimensions() != dims {
+			return nil, ErrSizeMismatch
+		}
+	}
+
+	t.root = t.build(items, 0)
+	t.size = uint64(len(items))
+	return t, nil
+}
+
+// build recursively builds a balanced subtree using the median on each axis.
+// The points equal to the median on the axis go to the right subtree, like in
+// Insert, so the searches find them going right on ties
+func (t *KDTree[P]) build(items []P, depth int) *node[P] {
+	if len(items) == 0 {
+		return nil
+	}
+
+	axis := depth % t.dims
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Coordinate(axis) < items[j].Coordinate(axis)
+	})
+	median := len(items) / 2
+	for median > 0 && items[median-1].Coordinate(axis) == items[median].Coordinate(axis) {
+		median--
+	}
+
+	return &node[P]{
+		point: items[median],
+		axis:  axis,
+		left:  t.build(items[:median], depth+1),
+		right: t.build(items[median+1:], depth+1),
+	}
+}
+
+// Insert adds a point to the tree
+// note: inserting many points one by one may unbalance the tree, use Build
+// when all the points are known in advance
+func (t *KDTree[P]) Insert(p P) error {
+	if p.Dimensions() != t.dims {
+		return ErrSizeMismatch
+	}
+
+	t.size++
+	if t.root == nil {
+		t.root = &node[P]{point: p}
+		return nil
+	}
+
+	current := t.root
+	for {
+		if p.Coordinate(current.axis) < current.point.Coordinate(current.axis) {
+			if current.left == nil {
+				current.left = &node[P]{point: p, axis: (current.axis + 1) % t.dims}
+				return nil
+			}
+			current = current.left
+		} else {
+			if current.right == nil {
+				current.right = &node[P]{point: p, axis: (current.axis + 1) % t.dims}
+				return nil
+			}
+			current = current.right
+		}
+	}
+}
+
+// Size returns the number of points in the tree
+func (t *KDTree[P]) Size() uint64 {
+	return t.size
+}
+
+// IsEmpty returns true if the tree is empty
+func (t *KDTree[P]) IsEmpty() bool {
+	return t.size == 0
+}
+
+// Dimensions returns the number of dimensions of the tree
+func (t *KDTree[P]) Dimensions() int {
+	return t.dims
+}
+
+// Contains returns true if the tree contains the given point
+func (t *KDTree[P]) Contains(p P) bool {
+	if p.Dimensions() != t.dims {
+		return false
+	}
+
+	current := t.root
+	for current != nil {
+		if current.point == p {
+			return true
+		}
+		if p.Coordinate(current.axis) < current.point.Coordinate(current.axis) {
+			current = current.left
+		} else {
+			current = current.right
+		}
+	}
+	return false
+}
+
+// Clear removes all points from the tree
+func (t *KDTree[P]) Clear() {
+	t.root = nil
+	t.size = 0
+}
+
+// ToSlice returns all the points in the tree (in no particular order)
+func (t *KDTree[P]) ToSlice() []P {
+	result := make([]P, 0, t.size)
+	var walk func(n *node[P])
+	walk = func(n *node[P]) {
+		if n == nil {
+			return
+		}
+		walk(n.left)
+		result = append(result, n.point)
+		walk(n.right)
+	}
+	walk(t.root)
+	return result
+}
+
+// Distance returns the squared euclidean distance between two points
+func Distance[P Point](a, b P) float64 {
+	var d float64
+	for i := 0; i < a.Dimensions(); i++ {
+		diff := a.Coordinate(i) - b.Coordinate(i)
+		d += diff * diff
+	}
+	return d
+}
+
+// neighbor is a candidate result of a nearest neighbor search
+type neighbor[P Point] struct {
+	point P
+	dist  float64
+}
+
+// NearestNeighbor returns the k points closest to the target (closest first)
+// If the tree holds less than k points, all of them are returned
+func (t *KDTree[P]) NearestNeighbor(target P, k int) ([]P, error) {
+	if t.root == nil {
+		return nil, ErrEmpty
+	}
+	if target.Dimensions() != t.dims {
+		return nil, ErrSizeMismatch
+	}
+	if k <= 0 {
+		return []P{}, nil
+	}
+
+	// best is kept sorted by distance (closest first)
+	best := make([]neighbor[P], 0, k)
+	t.nearest(t.root, target, k, &best)
+
+	result := make([]P, len(best))
+	for i, n := range best {
+		result[i] = n.point
+	}
+	return result, nil
+}
+
+func (t *KDTree[P]) nearest(n *node[P], target P, k int, best *[]neighbor[P]) {
+	if n == nil {
+		return
+	}
+
+	d := Distance(n.point, target)
+	if len(*best) < k || d < (*best)[len(*best)-1].dist {
+		// insert the candidate keeping the slice sorted
+		i := sort.Search(len(*best), func(i int) bool { return (*best)[i].dist > d })
+		if len(*best) < k {
+			*best = append(*best, neighbor[P]{})
+		}
+		copy((*best)[i+1:], (*best)[i:])
+		(*best)[i] = neighbor[P]{point: n.point, dist: d}
+	}
+
+	diff := target.Coordinate(n.axis) - n.point.Coordinate(n.axis)
+	near, far := n.left, n.right
+	if diff >= 0 {
+		near, far = n.right, n.left
+	}
+
+	t.nearest(near, target, k, best)
+	// Visit the other side only if it can contain closer points
+	if len(*best) < k || diff*diff < (*best)[len(*best)-1].dist {
+		t.nearest(far, target, k, best)
+	}
+}
+
+// RangeSearch returns all the points within the given radius from the center
+// (the points are returned in no particular order)
+func (t *KDTree[P]) RangeSearch(center P, radius float64) ([]P, error) {
+	if center.Dimensions() != t.dims {
+		return nil, ErrSizeMismatch
+	}
+
+	var result []P
+	r2 := radius * radius
+	var search func(n *node[P])
+	search = func(n *node[P]) {
+		if n == nil {
+			return
+		}
+		if Distance(n.point, center) <= r2 {
+			result = append(result, n.point)
+		}
+		diff := center.Coordinate(n.axis) - n.point.Coordinate(n.axis)
+		if diff-radius < 0 {
+			search(n.left)
+		}
+		if diff+radius >= 0 {
+			search(n.right)
+		}
+	}
+	search(t.root)
+	return result, nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kdtree_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	kdtree "github.com/pzaino/gods/pkg/kdtree"
+)
+
+// benchPoints returns n points in the unit square, the same ones for every run
+func benchPoints(n int) []kdtree.Point2D {
+	coords := bench.Float64s(2 * n)
+	points := make([]kdtree.Point2D, n)
+	for i := range points {
+		points[i] = kdtree.Point2D{coords[2*i], coords[2*i+1]}
+	}
+	return points
+}
+
+// BenchmarkInsert inserts n points one at a time
+func BenchmarkInsert(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		points := benchPoints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			tree, _ := kdtree.New[kdtree.Point2D](2)
+			for _, p := range points {
+				_ = tree.Insert(p)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkBuild builds a balanced tree of n points
+func BenchmarkBuild(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		points := benchPoints(n)
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			buf := buffer.Adopt(points)
+			b.StartTimer()
+			_, _ = kdtree.Build(2, buf)
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkFind finds the nearest neighbor of a point among n points
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		points := benchPoints(n)
+		tree, _ := kdtree.Build(2, buffer.Adopt(points))
+		targets := benchPoints(1024)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = tree.NearestNeighbor(targets[i%len(targets)], 1)
+		}
+	})
+}
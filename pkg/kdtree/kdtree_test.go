@@ -0,0 +1,229 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package kdtree provides a non-concurrent-safe k-dimensional tree.
+package kdtree_test
+
+import (
+	"errors"
+	"math/rand"
+	"sort"
+	"testing"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	kdtree "github.com/pzaino/gods/pkg/kdtree"
+)
+
+const (
+	errExpectedNoError = "expected no error, got %v"
+	errExpectedSize    = "expected size %d, got %d"
+)
+
+func randomPoints(n int) []kdtree.Point2D {
+	r := rand.New(rand.NewSource(42))
+	points := make([]kdtree.Point2D, n)
+	for i := range points {
+		points[i] = kdtree.Point2D{r.Float64() * 100, r.Float64() * 100}
+	}
+	return points
+}
+
+func bruteForceNearest(points []kdtree.Point2D, target kdtree.Point2D, k int) []kdtree.Point2D {
+	sorted := append([]kdtree.Point2D{}, points...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return kdtree.Distance(sorted[i], target) < kdtree.Distance(sorted[j], target)
+	})
+	return sorted[:k]
+}
+
+func TestNew(t *testing.T) {
+	if _, err := kdtree.New[kdtree.Point2D](0); !errors.Is(err, kdtree.ErrBadDimensions) {
+		t.Fatalf("expected error with 0 dimensions")
+	}
+	tree, err := kdtree.New[kdtree.Point2D](2)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if !tree.IsEmpty() || tree.Dimensions() != 2 {
+		t.Fatalf("expected an empty 2D tree")
+	}
+	if _, err := tree.NearestNeighbor(kdtree.Point2D{}, 1); !errors.Is(err, kdtree.ErrEmpty) {
+		t.Fatalf("expected error on empty tree")
+	}
+}
+
+func TestInsertAndContains(t *testing.T) {
+	tree, _ := kdtree.New[kdtree.Point2D](2)
+	points := randomPoints(100)
+	for _, p := range points {
+		if err := tree.Insert(p); err != nil {
+			t.Fatalf(errExpectedNoError, err)
+		}
+	}
+	if tree.Size() != 100 {
+		t.Fatalf(errExpectedSize, 100, tree.Size())
+	}
+	for _, p := range points {
+		if !tree.Contains(p) {
+			t.Fatalf("expected tree to contain %v", p)
+		}
+	}
+	if tree.Contains(kdtree.Point2D{-1, -1}) {
+		t.Fatalf("expected tree not to contain (-1, -1)")
+	}
+	if len(tree.ToSlice()) != 100 {
+		t.Fatalf(errExpectedSize, 100, len(tree.ToSlice()))
+	}
+
+	tree.Clear()
+	if !tree.IsEmpty() {
+		t.Fatalf("expected tree to be empty")
+	}
+}
+
+func TestDimensionMismatch(t *testing.T) {
+	tree, _ := kdtree.New[kdtree.Point3D](2)
+	if err := tree.Insert(kdtree.Point3D{1, 2, 3}); !errors.Is(err, kdtree.ErrSizeMismatch) {
+		t.Fatalf("expected dimension mismatch error")
+	}
+	if _, err := tree.RangeSearch(kdtree.Point3D{}, 1); !errors.Is(err, kdtree.ErrSizeMismatch) {
+		t.Fatalf("expected dimension mismatch error")
+	}
+
+	b := buffer.New[kdtree.Point3D]()
+	_ = b.Append(kdtree.Point3D{1, 2, 3})
+	if _, err := kdtree.Build(2, b); !errors.Is(err, kdtree.ErrSizeMismatch) {
+		t.Fatalf("expected dimension mismatch error")
+	}
+}
+
+func TestBuildAndNearestNeighbor(t *testing.T) {
+	points := randomPoints(500)
+	b := buffer.New[kdtree.Point2D]()
+	_ = b.PushN(points...)
+
+	tree, err := kdtree.Build(2, b)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if tree.Size() != 500 {
+		t.Fatalf(errExpectedSize, 500, tree.Size())
+	}
+	// Build must not reorder the source buffer
+	if v, _ := b.Get(0); v != points[0] {
+		t.Fatalf("expected the source buffer to be untouched")
+	}
+
+	for _, target := range randomPoints(20) {
+		target[0], target[1] = target[1], target[0]
+		got, err := tree.NearestNeighbor(target, 5)
+		if err != nil {
+			t.Fatalf(errExpectedNoError, err)
+		}
+		expected := bruteForceNearest(points, target, 5)
+		for i := range expected {
+			if got[i] != expected[i] {
+				t.Fatalf("neighbor %d: expected %v, got %v", i, expected[i], got[i])
+			}
+		}
+	}
+}
+
+func TestNearestNeighborMoreThanSize(t *testing.T) {
+	tree, _ := kdtree.New[kdtree.Point3D](3)
+	_ = tree.Insert(kdtree.Point3D{0, 0, 0})
+	_ = tree.Insert(kdtree.Point3D{5, 5, 5})
+	_ = tree.Insert(kdtree.Point3D{1, 1, 1})
+
+	got, err := tree.NearestNeighbor(kdtree.Point3D{0, 0, 0}, 10)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	expected := []kdtree.Point3D{{0, 0, 0}, {1, 1, 1}, {5, 5, 5}}
+	if len(got) != len(expected) {
+		t.Fatalf(errExpectedSize, len(expected), len(got))
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Fatalf("neighbor %d: expected %v, got %v", i, expected[i], got[i])
+		}
+	}
+
+	got, _ = tree.NearestNeighbor(kdtree.Point3D{}, 0)
+	if len(got) != 0 {
+		t.Fatalf(errExpectedSize, 0, len(got))
+	}
+}
+
+func TestDuplicateCoordinates(t *testing.T) {
+	points := []kdtree.Point2D{{1, 0}, {1, 5}, {1, 3}, {1, 7}, {1, 9}, {0, 4}, {2, 4}, {1, 4}, {1, 4}}
+	tree, err := kdtree.Build(2, buffer.Adopt(points))
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+
+	for _, p := range points {
+		if !tree.Contains(p) {
+			t.Errorf("expected the tree to contain %v", p)
+		}
+	}
+
+	got, err := tree.RangeSearch(kdtree.Point2D{2, 0}, 1)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if len(got) != 1 || got[0] != (kdtree.Point2D{1, 0}) {
+		t.Errorf("expected [[1 0]], got %v", got)
+	}
+
+	got, _ = tree.RangeSearch(kdtree.Point2D{1, 4}, 0)
+	if len(got) != 2 {
+		t.Errorf("expected the 2 copies of [1 4], got %v", got)
+	}
+
+	nearest, _ := tree.NearestNeighbor(kdtree.Point2D{1.1, 7}, 1)
+	if len(nearest) != 1 || nearest[0] != (kdtree.Point2D{1, 7}) {
+		t.Errorf("expected [[1 7]], got %v", nearest)
+	}
+}
+
+func TestRangeSearch(t *testing.T) {
+	points := randomPoints(500)
+	tree, _ := kdtree.New[kdtree.Point2D](2)
+	for _, p := range points {
+		_ = tree.Insert(p)
+	}
+
+	center := kdtree.Point2D{50, 50}
+	radius := 15.0
+	got, err := tree.RangeSearch(center, radius)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+
+	expected := 0
+	for _, p := range points {
+		if kdtree.Distance(p, center) <= radius*radius {
+			expected++
+		}
+	}
+	if len(got) != expected {
+		t.Fatalf(errExpectedSize, expected, len(got))
+	}
+	for _, p := range got {
+		if kdtree.Distance(p, center) > radius*radius {
+			t.Fatalf("point %v is outside the radius", p)
+		}
+	}
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lfQueue provides a bounded, lock-free, multi-producer multi-consumer
+// FIFO queue based on Dmitry Vyukov's array queue with per-cell sequence numbers.
+//
+// Memory ordering contract: all the synchronization is done with sync/atomic
+// operations, which in Go are sequentially consistent. A successful Enqueue
+// of a value happens before the Dequeue that returns it, so everything the
+// producer wrote before calling Enqueue is visible to the consumer after
+// Dequeue returns. There is no ordering guarantee between values enqueued
+// concurrently by different producers, values enqueued by the same producer
+// are dequeued in the order they were enqueued.
+//
+// Size is only a snapshot and may be stale by the time it is returned.
+package lfQueue
+
+import (
+	"errors"
+	"runtime"
+	"sync/atomic"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the LFQueue methods (use errors.Is to check for them)
+var (
+	ErrEmpty           = errors.New("queue is empty")
+	ErrFull            = errors.New("queue is full")
+	ErrInvalidCapacity = errors.New("invalid capacity")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureConcurrent)
+}
+
+// cacheLinePad keeps the producer and consumer indexes on different cache lines
+type cacheLinePad [64]byte
+
+// cell is a slot of the ring, seq tells producers and consumers whose turn it is:
+// seq == pos means the cell is free for the producer of pos, seq == pos+1 means
+// it holds the value for the consumer of pos
+type cell[T any] struct {
+	seq   atomic.Uint64
+	value T
+}
+
+// LFQueue is a bounded lock-free MPMC queue
+type LFQueue[T any] struct {
+	_       cacheLinePad
+	enqueue atomic.Uint64
+	_       cacheLinePad
+	dequeue atomic.Uint64
+	_       cacheLinePad
+	mask    uint64
+	cells   []cell[T]
+}
+
+// New creates a new LFQueue that can hold capacity elements, capacity is
+// rounded up to the next power of two and must be at least 2
+func New[T any](capacity uint64) (*LFQueue[T], error) {
+	if capacity < 2 || capacity > 1<<62 {
+		return nil, ErrInvalidCapacity
+	}
+	size := uint64(1)
+	for size < capacity {
+		size <<= 1
+	}
+
+	q := &LFQueue[T]{mask: size - 1, cells: make([]cell[T], size)}
+	for i := range q.cells {
+		q.cells[i].seq.Store(uint64(i))
+	}
+	return q, nil
+}
+
+// Capacity returns the number of elements the queue can hold
+func (q *LFQueue[T]) Capacity() uint64 {
+	return q.mask + 1
+}
+
+// Enqueue adds an element to the back of the queue, it returns ErrFull
+// without blocking if the queue is full
+func (q *LFQueue[T]) Enqueue(value T) error {
+	pos := q.enqueue.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		seq := c.seq.Load()
+		switch diff := int64(seq - pos); {
+		case diff == 0:
+			// The cell is free, try to claim it
+			if q.enqueue.CompareAndSwap(pos, pos+1) {
+				c.value = value
+				c.seq.Store(pos + 1) // publish the value to the consumer
+				return nil
+			}
+			pos = q.enqueue.Load()
+		case diff < 0:
+			// The cell still holds a value from the previous lap
+			return ErrFull
+		default:
+			// Another producer claimed the cell, catch up
+			pos = q.enqueue.Load()
+		}
+	}
+}
+
+// Dequeue removes and returns the element at the front of the queue, it
+// returns ErrEmpty without blocking if the queue is empty
+func (q *LFQueue[T]) Dequeue() (T, error) {
+	pos := q.dequeue.Load()
+	for {
+		c := &q.cells[pos&q.mask]
+		seq := c.seq.Load()
+		switch diff := int64(seq - (pos + 1)); {
+		case diff == 0:
+			// The cell holds a value, try to claim it
+			if q.dequeue.CompareAndSwap(pos, pos+1) {
+				value := c.value
+				var zero T
+				c.value = zero                // let the GC collect the value
+				c.seq.Store(pos + q.mask + 1) // free the cell for the next lap
+				return value, nil
+			}
+			pos = q.dequeue.Load()
+		case diff < 0:
+			// The producer of this cell has not published yet
+			var rVal T
+			return rVal, ErrEmpty
+		default:
+			// Another consumer claimed the cell, catch up
+			pos = q.dequeue.Load()
+		}
+	}
+}
+
+// EnqueueWait adds an element to the back of the queue, spinning (and
+// yielding the processor) while the queue is full
+func (q *LFQueue[T]) EnqueueWait(value T) {
+	for q.Enqueue(value) != nil {
+		runtime.Gosched()
+	}
+}
+
+// DequeueWait removes and returns the element at the front of the queue,
+// spinning (and yielding the processor) while the queue is empty
+func (q *LFQueue[T]) DequeueWait() T {
+	for {
+		value, err := q.Dequeue()
+		if err == nil {
+			return value
+		}
+		runtime.Gosched()
+	}
+}
+
+// Size returns an approximation of the number of elements in the queue
+func (q *LFQueue[T]) Size() uint64 {
+	for {
+		dequeue := q.dequeue.Load()
+		enqueue := q.enqueue.Load()
+		// Make sure the two loads are consistent with each other
+		if dequeue == q.dequeue.Load() {
+			switch {
+			case enqueue < dequeue:
+				return 0
+			case enqueue-dequeue > q.mask+1:
+				return q.mask + 1
+			}
+			return enqueue - dequeue
+		}
+	}
+}
+
+// IsEmpty checks if the queue is (approximately) empty
+func (q *LFQueue[T]) IsEmpty() bool {
+	return q.Size() == 0
+}
@@ -0,0 +1,59 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lfQueue_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	lfQueue "github.com/pzaino/gods/pkg/lfQueue"
+)
+
+// BenchmarkEnqueue enqueues n elements in a queue of capacity n
+func BenchmarkEnqueue(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		q, _ := lfQueue.New[int](uint64(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for v := 0; v < n; v++ {
+				_ = q.Enqueue(v)
+			}
+			b.StopTimer()
+			for !q.IsEmpty() {
+				_, _ = q.Dequeue()
+			}
+			b.StartTimer()
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkDequeue dequeues n elements from a full queue
+func BenchmarkDequeue(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		q, _ := lfQueue.New[int](uint64(n))
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			for v := 0; v < n; v++ {
+				_ = q.Enqueue(v)
+			}
+			b.StartTimer()
+			for v := 0; v < n; v++ {
+				_, _ = q.Dequeue()
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
@@ -0,0 +1,157 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lfQueue_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	csBuffer "github.com/pzaino/gods/pkg/csBuffer"
+	lfQueue "github.com/pzaino/gods/pkg/lfQueue"
+)
+
+const benchCapacity = 1024
+
+func TestNew(t *testing.T) {
+	if _, err := lfQueue.New[int](1); !errors.Is(err, lfQueue.ErrInvalidCapacity) {
+		t.Errorf("expected %v, got %v", lfQueue.ErrInvalidCapacity, err)
+	}
+
+	q, err := lfQueue.New[int](100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q.Capacity() != 128 {
+		t.Errorf("expected capacity 128, got %d", q.Capacity())
+	}
+	if !q.IsEmpty() {
+		t.Error("expected new queue to be empty")
+	}
+}
+
+func TestEnqueueDequeue(t *testing.T) {
+	q, _ := lfQueue.New[int](4)
+	if _, err := q.Dequeue(); !errors.Is(err, lfQueue.ErrEmpty) {
+		t.Errorf("expected %v, got %v", lfQueue.ErrEmpty, err)
+	}
+
+	// Go around the ring a few times to exercise the sequence numbers
+	for lap := 0; lap < 3; lap++ {
+		for i := 0; i < 4; i++ {
+			if err := q.Enqueue(lap*10 + i); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		}
+		if err := q.Enqueue(99); !errors.Is(err, lfQueue.ErrFull) {
+			t.Errorf("expected %v, got %v", lfQueue.ErrFull, err)
+		}
+		if q.Size() != 4 {
+			t.Errorf("expected size 4, got %d", q.Size())
+		}
+		for i := 0; i < 4; i++ {
+			v, err := q.Dequeue()
+			if err != nil || v != lap*10+i {
+				t.Errorf("expected %d, got %d (%v)", lap*10+i, v, err)
+			}
+		}
+		if !q.IsEmpty() {
+			t.Errorf("expected empty queue, got size %d", q.Size())
+		}
+	}
+}
+
+func TestConcurrentProducersConsumers(t *testing.T) {
+	const producers, consumers, perProducer = 4, 4, 10000
+	q, _ := lfQueue.New[int](64)
+
+	var wg sync.WaitGroup
+	for p := 0; p < producers; p++ {
+		wg.Add(1)
+		go func(p int) {
+			defer wg.Done()
+			for i := 0; i < perProducer; i++ {
+				q.EnqueueWait(p*perProducer + i)
+			}
+		}(p)
+	}
+
+	results := make([][]int, consumers)
+	var cwg sync.WaitGroup
+	for c := 0; c < consumers; c++ {
+		cwg.Add(1)
+		go func(c int) {
+			defer cwg.Done()
+			for i := 0; i < producers*perProducer/consumers; i++ {
+				results[c] = append(results[c], q.DequeueWait())
+			}
+		}(c)
+	}
+	wg.Wait()
+	cwg.Wait()
+
+	seen := make([]bool, producers*perProducer)
+	for _, res := range results {
+		last := make(map[int]int) // per producer FIFO order
+		for _, v := range res {
+			if seen[v] {
+				t.Fatalf("value %d dequeued twice", v)
+			}
+			seen[v] = true
+			p := v / perProducer
+			if prev, ok := last[p]; ok && prev > v {
+				t.Fatalf("values of producer %d out of order: %d after %d", p, v, prev)
+			}
+			last[p] = v
+		}
+	}
+	for v, ok := range seen {
+		if !ok {
+			t.Fatalf("value %d lost", v)
+		}
+	}
+}
+
+// BenchmarkLFQueue measures parallel enqueue/dequeue pairs on the lock-free queue.
+func BenchmarkLFQueue(b *testing.B) {
+	q, _ := lfQueue.New[int](benchCapacity)
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%2 == 0 {
+				_ = q.Enqueue(i)
+			} else {
+				_, _ = q.Dequeue()
+			}
+			i++
+		}
+	})
+}
+
+// BenchmarkCSBuffer measures the same workload on a mutex protected csBuffer.
+func BenchmarkCSBuffer(b *testing.B) {
+	cb := csBuffer.NewWithCapacity[int](benchCapacity)
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if i%2 == 0 {
+				_ = cb.Append(i)
+			} else {
+				_, _ = cb.PopN(1)
+			}
+			i++
+		}
+	})
+}
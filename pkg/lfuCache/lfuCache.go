@@ -0,0 +1,324 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package lfuCache provides a generic least-frequently-used cache: when the
+// cache is full, Put evicts the entry that was used the fewest times, and the
+// least recently used one among those used as many times. Get, Put and Delete
+// are O(1): the entries are kept in a list of frequency buckets (Shah,
+// Mitra and Matani, "An O(1) algorithm for implementing the LFU cache
+// eviction scheme").
+//
+// The cache is not concurrency-safe, use a lock if it's shared by goroutines
+// (Get changes the cache too).
+package lfuCache
+
+import (
+	"errors"
+	"fmt"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the Cache methods (use errors.Is to check for them)
+var (
+	ErrInvalidCapacity = errors.New("invalid capacity")
+	ErrCorrupted       = errors.New("cache is corrupted")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureInvariants)
+}
+
+// entry is a key/value pair of the cache, linked in the list of its bucket
+type entry[K comparable, V any] struct {
+	key        K
+	value      V
+	bucket     *bucket[K, V]
+	prev, next *entry[K, V]
+}
+
+// bucket holds the entries used freq times, from the most recently used
+// (head) to the least recently used (tail)
+type bucket[K comparable, V any] struct {
+	freq       uint64
+	head, tail *entry[K, V]
+	prev, next *bucket[K, V]
+}
+
+// Cache is a least-frequently-used cache from keys of type K to values of
+// type V with a fixed capacity
+type Cache[K comparable, V any] struct {
+	entries  map[K]*entry[K, V]
+	buckets  *bucket[K, V] // buckets in increasing frequency order
+	capacity uint64
+	onEvict  func(key K, value V)
+}
+
+// New creates a new empty Cache that holds up to capacity entries
+func New[K comparable, V any](capacity uint64) (*Cache[K, V], error) {
+	if capacity == 0 {
+		return nil, ErrInvalidCapacity
+	}
+	return &Cache[K, V]{entries: make(map[K]*entry[K, V]), capacity: capacity}, nil
+}
+
+// OnEvict registers a function called with the entries Put and SetCapacity
+// evict to make room (nil removes it). It's not called by Delete and Clear
+func (c *Cache[K, V]) OnEvict(f func(key K, value V)) {
+	c.onEvict = f
+}
+
+// Get returns the value associated to key and true, or the zero value and
+// false if the key is not in the cache. It counts as a use of the key
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		var rVal V
+		return rVal, false
+	}
+	c.touch(e)
+	return e.value, true
+}
+
+// Peek returns the value associated to key like Get, without counting it as
+// a use of the key
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		var rVal V
+		return rVal, false
+	}
+	return e.value, true
+}
+
+// Contains returns true if the key is in the cache (it's not a use of the key)
+func (c *Cache[K, V]) Contains(key K) bool {
+	_, ok := c.entries[key]
+	return ok
+}
+
+// Frequency returns the number of uses of key (the Put that added it, then
+// every Get and Put), or false if the key is not in the cache
+func (c *Cache[K, V]) Frequency(key K) (uint64, bool) {
+	e, ok := c.entries[key]
+	if !ok {
+		return 0, false
+	}
+	return e.bucket.freq, true
+}
+
+// Put associates value to key. If the key is already in the cache its value
+// is replaced and it counts as a use of the key, otherwise, when the cache is
+// full, the least frequently used entry is evicted first
+func (c *Cache[K, V]) Put(key K, value V) {
+	if e, ok := c.entries[key]; ok {
+		e.value = value
+		c.touch(e)
+		return
+	}
+
+	if uint64(len(c.entries)) >= c.capacity {
+		c.evict()
+	}
+	e := &entry[K, V]{key: key, value: value}
+	c.entries[key] = e
+	b := c.buckets
+	if b == nil || b.freq != 1 {
+		b = c.newBucket(nil, 1)
+	}
+	b.pushFront(e)
+}
+
+// Delete removes key from the cache, it returns true if the key was there
+func (c *Cache[K, V]) Delete(key K) bool {
+	e, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	c.remove(e)
+	return true
+}
+
+// Len returns the number of entries in the cache
+func (c *Cache[K, V]) Len() uint64 {
+	return uint64(len(c.entries))
+}
+
+// IsEmpty checks if the cache is empty
+func (c *Cache[K, V]) IsEmpty() bool {
+	return len(c.entries) == 0
+}
+
+// Capacity returns the maximum number of entries of the cache
+func (c *Cache[K, V]) Capacity() uint64 {
+	return c.capacity
+}
+
+// SetCapacity changes the maximum number of entries of the cache, evicting the
+// least frequently used entries if it holds more than capacity
+func (c *Cache[K, V]) SetCapacity(capacity uint64) error {
+	if capacity == 0 {
+		return ErrInvalidCapacity
+	}
+	c.capacity = capacity
+	for uint64(len(c.entries)) > capacity {
+		c.evict()
+	}
+	return nil
+}
+
+// Clear removes all the entries from the cache
+func (c *Cache[K, V]) Clear() {
+	clear(c.entries)
+	c.buckets = nil
+}
+
+// Keys returns the keys in eviction order: from the least frequently used to
+// the most frequently used, and from the least recently used to the most
+// recently used among the ones with the same frequency
+func (c *Cache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.entries))
+	for b := c.buckets; b != nil; b = b.next {
+		for e := b.tail; e != nil; e = e.prev {
+			keys = append(keys, e.key)
+		}
+	}
+	return keys
+}
+
+// CheckInvariants verifies the internal consistency of the cache (the buckets
+// are not empty and in increasing frequency order, their lists are linked both
+// ways and hold all the entries of the map), it returns an error wrapping
+// ErrCorrupted describing the first violation found
+func (c *Cache[K, V]) CheckInvariants() error {
+	count := 0
+	var prevBucket *bucket[K, V]
+	for b := c.buckets; b != nil; b = b.next {
+		if b.prev != prevBucket {
+			return fmt.Errorf("%w: broken bucket prev link", ErrCorrupted)
+		}
+		if b.head == nil || b.tail == nil {
+			return fmt.Errorf("%w: empty bucket", ErrCorrupted)
+		}
+		if prevBucket != nil && prevBucket.freq >= b.freq {
+			return fmt.Errorf("%w: buckets out of frequency order", ErrCorrupted)
+		}
+		var prev *entry[K, V]
+		for e := b.head; e != nil; e = e.next {
+			if e.prev != prev {
+				return fmt.Errorf("%w: broken entry prev link", ErrCorrupted)
+			}
+			if e.bucket != b || c.entries[e.key] != e {
+				return fmt.Errorf("%w: entry not in its bucket or in the map", ErrCorrupted)
+			}
+			prev = e
+			count++
+		}
+		if b.tail != prev {
+			return fmt.Errorf("%w: tail does not match the last entry", ErrCorrupted)
+		}
+		prevBucket = b
+	}
+	if count != len(c.entries) {
+		return fmt.Errorf("%w: the buckets and the map hold different entries", ErrCorrupted)
+	}
+	return nil
+}
+
+// touch moves e to the bucket of the next frequency, as the most recently
+// used entry
+func (c *Cache[K, V]) touch(e *entry[K, V]) {
+	b := e.bucket
+	next := b.next
+	if next == nil || next.freq != b.freq+1 {
+		next = c.newBucket(b, b.freq+1)
+	}
+	c.unlink(e)
+	next.pushFront(e)
+}
+
+// evict removes the least recently used entry of the lowest frequency and
+// calls the OnEvict function with it
+func (c *Cache[K, V]) evict() {
+	e := c.buckets.tail
+	c.remove(e)
+	if c.onEvict != nil {
+		c.onEvict(e.key, e.value)
+	}
+}
+
+// remove removes e from its bucket and from the map
+func (c *Cache[K, V]) remove(e *entry[K, V]) {
+	c.unlink(e)
+	delete(c.entries, e.key)
+}
+
+// unlink removes e from its bucket, and the bucket if it's left empty
+func (c *Cache[K, V]) unlink(e *entry[K, V]) {
+	b := e.bucket
+	if e.prev != nil {
+		e.prev.next = e.next
+	} else {
+		b.head = e.next
+	}
+	if e.next != nil {
+		e.next.prev = e.prev
+	} else {
+		b.tail = e.prev
+	}
+	e.prev, e.next, e.bucket = nil, nil, nil
+
+	if b.head != nil {
+		return
+	}
+	if b.prev != nil {
+		b.prev.next = b.next
+	} else {
+		c.buckets = b.next
+	}
+	if b.next != nil {
+		b.next.prev = b.prev
+	}
+}
+
+// newBucket creates an empty bucket of frequency freq after prev (at the
+// front of the list if prev is nil)
+func (c *Cache[K, V]) newBucket(prev *bucket[K, V], freq uint64) *bucket[K, V] {
+	b := &bucket[K, V]{freq: freq, prev: prev}
+	if prev != nil {
+		b.next = prev.next
+		prev.next = b
+	} else {
+		b.next = c.buckets
+		c.buckets = b
+	}
+	if b.next != nil {
+		b.next.prev = b
+	}
+	return b
+}
+
+// pushFront adds e to the bucket as its most recently used entry
+func (b *bucket[K, V]) pushFront(e *entry[K, V]) {
+	e.bucket = b
+	e.next = b.head
+	if b.head != nil {
+		b.head.prev = e
+	} else {
+		b.tail = e
+	}
+	b.head = e
+}
@@ -0,0 +1,67 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lfuCache_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	lfuCache "github.com/pzaino/gods/pkg/lfuCache"
+)
+
+// newBenchCache returns a full cache from keys to themselves
+func newBenchCache(keys []int) *lfuCache.Cache[int, int] {
+	c, _ := lfuCache.New[int, int](uint64(len(keys)))
+	for _, k := range keys {
+		c.Put(k, k)
+	}
+	return c
+}
+
+// BenchmarkPut puts n keys in a cache of capacity n
+func BenchmarkPut(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		keys := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = newBenchCache(keys)
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkEvict puts a new key in a full cache of n keys, evicting one
+func BenchmarkEvict(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		c := newBenchCache(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			c.Put(n+i, i)
+		}
+	})
+}
+
+// BenchmarkFind gets a key (moving it to the next frequency) from a cache of
+// n keys
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		keys := bench.Ints(n)
+		c := newBenchCache(keys)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = c.Get(keys[i%n])
+		}
+	})
+}
@@ -0,0 +1,190 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lfuCache_test
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"testing"
+
+	common "github.com/pzaino/gods/pkg/common"
+	lfuCache "github.com/pzaino/gods/pkg/lfuCache"
+)
+
+const errUnexpectedErr = "unexpected error: %v"
+
+// newCache creates a cache of the given capacity, failing the test on error
+func newCache(t *testing.T, capacity uint64) *lfuCache.Cache[string, int] {
+	t.Helper()
+	c, err := lfuCache.New[string, int](capacity)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	return c
+}
+
+// checkKeys checks the keys of the cache in eviction order and its invariants
+func checkKeys(t *testing.T, c *lfuCache.Cache[string, int], expected ...string) {
+	t.Helper()
+	if keys := c.Keys(); !slices.Equal(keys, expected) {
+		t.Errorf("expected keys %v, got %v", expected, keys)
+	}
+	if err := c.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := lfuCache.New[string, int](0); !errors.Is(err, lfuCache.ErrInvalidCapacity) {
+		t.Errorf("expected %v, got %v", lfuCache.ErrInvalidCapacity, err)
+	}
+	c := newCache(t, 3)
+	if !c.IsEmpty() || c.Len() != 0 || c.Capacity() != 3 {
+		t.Errorf("expected an empty cache of capacity 3, got %d/%d", c.Len(), c.Capacity())
+	}
+	if !lfuCache.Features().Has(common.FeatureInvariants) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureInvariants, lfuCache.Features())
+	}
+}
+
+func TestGetPut(t *testing.T) {
+	c := newCache(t, 3)
+	if _, ok := c.Get("a"); ok {
+		t.Error("expected a to be missing")
+	}
+
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("a", 3)
+	if v, ok := c.Get("a"); !ok || v != 3 {
+		t.Errorf("expected 3, got %d (%v)", v, ok)
+	}
+	if f, ok := c.Frequency("a"); !ok || f != 3 {
+		t.Errorf("expected a used 3 times, got %d (%v)", f, ok)
+	}
+	if v, ok := c.Peek("b"); !ok || v != 2 {
+		t.Errorf("expected 2, got %d (%v)", v, ok)
+	}
+	if f, _ := c.Frequency("b"); f != 1 || !c.Contains("b") {
+		t.Errorf("expected Peek and Contains not to use b, got frequency %d", f)
+	}
+	if _, ok := c.Frequency("z"); ok || c.Contains("z") {
+		t.Error("expected z to be missing")
+	}
+	checkKeys(t, c, "b", "a")
+}
+
+func TestEviction(t *testing.T) {
+	c := newCache(t, 3)
+	var evicted []string
+	c.OnEvict(func(key string, value int) {
+		evicted = append(evicted, key)
+	})
+
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	c.Get("a")
+	c.Get("a")
+	c.Get("c")
+	checkKeys(t, c, "b", "c", "a")
+
+	// b is the least frequently used
+	c.Put("d", 4)
+	checkKeys(t, c, "d", "c", "a")
+
+	// d and c are used twice now, c is the least recently used
+	c.Get("d")
+	c.Put("e", 5)
+	checkKeys(t, c, "e", "d", "a")
+
+	if err := c.SetCapacity(0); !errors.Is(err, lfuCache.ErrInvalidCapacity) {
+		t.Errorf("expected %v, got %v", lfuCache.ErrInvalidCapacity, err)
+	}
+	if err := c.SetCapacity(1); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	checkKeys(t, c, "a")
+
+	if !c.Delete("a") || c.Delete("a") {
+		t.Error("expected Delete to report whether the key was there")
+	}
+	c.Put("f", 6)
+	c.Clear()
+	checkKeys(t, c)
+	if expected := []string{"b", "c", "e", "d"}; !slices.Equal(evicted, expected) {
+		t.Errorf("expected %v evicted, got %v", expected, evicted)
+	}
+}
+
+// TestRandomOperations compares the cache with a simple model that looks for
+// the entry to evict among all of them
+func TestRandomOperations(t *testing.T) {
+	type use struct{ freq, last int }
+	const capacity = 16
+	c, _ := lfuCache.New[int, int](capacity)
+	model := make(map[int]use)
+	var evicted, expected []int
+	c.OnEvict(func(key, value int) {
+		evicted = append(evicted, key)
+	})
+
+	rnd := rand.New(rand.NewSource(42))
+	for tick := 0; tick < 20000; tick++ {
+		key := rnd.Intn(40)
+		switch rnd.Intn(4) {
+		case 0:
+			c.Delete(key)
+			delete(model, key)
+		case 1:
+			if _, ok := c.Get(key); ok {
+				model[key] = use{model[key].freq + 1, tick}
+			}
+		default:
+			if u, ok := model[key]; ok {
+				model[key] = use{u.freq + 1, tick}
+			} else {
+				if len(model) == capacity {
+					victim, best := 0, use{-1, 0}
+					for k, u := range model {
+						if best.freq < 0 || u.freq < best.freq || (u.freq == best.freq && u.last < best.last) {
+							victim, best = k, u
+						}
+					}
+					delete(model, victim)
+					expected = append(expected, victim)
+				}
+				model[key] = use{1, tick}
+			}
+			c.Put(key, tick)
+		}
+
+		if c.Len() != uint64(len(model)) {
+			t.Fatalf("tick %d: expected %d entries, got %d", tick, len(model), c.Len())
+		}
+		for k, u := range model {
+			if f, ok := c.Frequency(k); !ok || f != uint64(u.freq) {
+				t.Fatalf("tick %d: expected %d used %d times, got %d (%v)", tick, k, u.freq, f, ok)
+			}
+		}
+	}
+	if !slices.Equal(evicted, expected) {
+		t.Errorf("expected %d evictions, got %d (or in a different order)", len(expected), len(evicted))
+	}
+	if err := c.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
@@ -15,28 +15,158 @@
 // Package linkList provides a non-concurrent-safe linked list.
 package linkList
 
-import "errors"
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
 
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
 const (
 	ErrIndexOutOfBound = "index out of bounds"
 	ErrValueNotFound   = "value not found"
 )
 
-// Node represents a node in the linked list
-type Node[T comparable] struct {
-	Value T
-	Next  *Node[T]
+// Sentinel errors returned by the LinkList methods (use errors.Is to check for them)
+var (
+	ErrOutOfBounds  = errors.New(ErrIndexOutOfBound)
+	ErrEmpty        = errors.New("list is empty")
+	ErrNotFound     = errors.New(ErrValueNotFound)
+	ErrInvalidRange = errors.New("start index cannot be greater than end index")
+	ErrSameList     = errors.New("cannot splice a list into itself")
+	ErrCorrupted    = errors.New("list is corrupted")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureHasher, common.FeatureInvariants, common.FeatureDeepCopy, common.FeatureJSON)
+}
+
+// Node represents a node in the linked list. Its link is private so only
+// the list can change the structure, use the accessors to walk the list
+type Node[T any] struct {
+	value T
+	next  *Node[T]
+}
+
+// Value returns the value held by the node
+func (n *Node[T]) Value() T {
+	return n.value
+}
+
+// SetValue replaces the value held by the node (the observers of the list
+// are not notified)
+func (n *Node[T]) SetValue(value T) {
+	n.value = value
+}
+
+// Next returns the node after n, nil if n is the last node of its list
+func (n *Node[T]) Next() *Node[T] {
+	return n.next
 }
 
-// LinkList represents a linked list
-type LinkList[T comparable] struct {
-	Head *Node[T]
-	size uint64
+// LinkList represents a linked list, tail is its last node so appending is O(1)
+type LinkList[T any] struct {
+	head   *Node[T]
+	tail   *Node[T]
+	size   uint64
+	equals common.EqualFunc[T]
+	key    common.KeyFunc[T]         // nil when values can only be compared with equals
+	hasher common.Hasher[T]          // nil unless the list was created with NewWithHasher
+	pool   *common.FreeList[Node[T]] // nil unless the list was created with NewWithPool
 }
 
+// poolSize is the maximum number of released nodes kept by a list created with NewWithPool
+const poolSize = 4096
+
 // New creates a new LinkList
 func New[T comparable]() *LinkList[T] {
-	return &LinkList[T]{}
+	return &LinkList[T]{equals: common.Equal[T], key: common.Key[T]}
+}
+
+// NewWithComparator creates a new LinkList that compares its values with the given
+// function, so it can hold types that are not comparable (slices, maps, ...)
+func NewWithComparator[T any](equals func(a, b T) bool) *LinkList[T] {
+	return &LinkList[T]{equals: equals}
+}
+
+// NewWithHasher creates a new LinkList that compares its values with the given
+// hasher, which also speeds up ContainsAll, ContainsAny and Deduplicate for
+// types that are not comparable (the hashes are compared first)
+func NewWithHasher[T any](hasher common.Hasher[T]) *LinkList[T] {
+	return &LinkList[T]{equals: hasher.Equals, hasher: hasher}
+}
+
+// NewWithPool creates a new LinkList that reuses the nodes of the deleted values
+// (up to 4096 of them) for the values inserted later, instead of allocating a
+// new node for each one. It reduces the allocations and the GC work of
+// workloads that keep inserting and deleting values, at the cost of keeping
+// the released nodes in memory for as long as the list is alive. A deleted
+// node is reset and reused, so a *Node returned by Find, GetAt, ... must not
+// be used after its value is deleted
+func NewWithPool[T comparable]() *LinkList[T] {
+	l := New[T]()
+	l.pool = common.NewFreeList[Node[T]](poolSize)
+	return l
+}
+
+// newEmpty creates a new empty list that compares its values like l
+func (l *LinkList[T]) newEmpty() *LinkList[T] {
+	return &LinkList[T]{equals: l.equals, key: l.key, hasher: l.hasher}
+}
+
+// seen returns a new Seen set that compares the values like l
+func (l *LinkList[T]) seen() *common.Seen[T] {
+	if l.hasher != nil {
+		return common.NewHashSeen(l.hasher)
+	}
+	return common.NewSeen(l.key, l.equals)
+}
+
+// lookup returns a new Lookup for items that compares the values like l
+func (l *LinkList[T]) lookup(items []T) *common.Lookup[T] {
+	if l.hasher != nil {
+		return common.NewHashLookup(l.hasher, items)
+	}
+	return common.NewLookup(l.key, l.equals, items)
+}
+
+// equal compares two values with the list comparator
+func (l *LinkList[T]) equal(x, y T) bool {
+	return common.Equals(l.equals, x, y)
+}
+
+// newNode returns a node holding value, taken from the pool when possible
+func (l *LinkList[T]) newNode(value T) *Node[T] {
+	node := l.pool.Get()
+	node.value = value
+	return node
+}
+
+// deleteNext unlinks the node after prev (the head if prev is nil) and
+// releases it to the pool, the caller updates the size
+func (l *LinkList[T]) deleteNext(prev *Node[T]) {
+	var node *Node[T]
+	if prev == nil {
+		node = l.head
+		l.head = node.next
+	} else {
+		node = prev.next
+		prev.next = node.next
+	}
+	if node == l.tail {
+		l.tail = prev
+	}
+	l.pool.Put(node)
 }
 
 // NewFromSlice creates a new LinkList from a slice
@@ -50,105 +180,224 @@ func NewFromSlice[T comparable](items []T) *LinkList[T] {
 
 // Append adds a new node to the end of the list
 func (l *LinkList[T]) Append(value T) {
-	newNode := &Node[T]{Value: value}
-
-	if l.Head == nil {
-		l.Head = newNode
-		l.size++
-		return
-	}
+	newNode := l.newNode(value)
 
-	current := l.Head
-	for current.Next != nil {
-		current = current.Next
+	if l.head == nil {
+		l.head = newNode
+	} else {
+		l.tail.next = newNode
 	}
-
-	current.Next = newNode
+	l.tail = newNode
 	l.size++
 }
 
 // Prepend adds a new node to the beginning of the list
 func (l *LinkList[T]) Prepend(value T) {
-	newNode := &Node[T]{Value: value}
+	newNode := l.newNode(value)
 
-	newNode.Next = l.Head
-	l.Head = newNode
+	newNode.next = l.head
+	l.head = newNode
+	if l.tail == nil {
+		l.tail = newNode
+	}
 	l.size++
 }
 
+// AppendN adds new nodes with the given values to the end of the list, in order
+func (l *LinkList[T]) AppendN(values ...T) {
+	for _, value := range values {
+		l.Append(value)
+	}
+}
+
+// PrependN adds new nodes with the given values to the beginning of the list,
+// they end up at the head in the given order
+func (l *LinkList[T]) PrependN(values ...T) {
+	for i := len(values) - 1; i >= 0; i-- {
+		l.Prepend(values[i])
+	}
+}
+
 // DeleteWithValue deletes the first node with the given value
 func (l *LinkList[T]) DeleteWithValue(value T) {
-	if l.Head == nil {
+	if l.head == nil {
 		return
 	}
 
-	if l.Head.Value == value {
-		l.Head = l.Head.Next
+	if l.equal(l.head.value, value) {
+		l.deleteNext(nil)
 		l.size--
 		return
 	}
 
-	current := l.Head
-	for current.Next != nil {
-		if current.Next.Value == value {
-			current.Next = current.Next.Next
+	current := l.head
+	for current.next != nil {
+		if l.equal(current.next.value, value) {
+			l.deleteNext(current)
 			l.size--
 			return
 		}
-		current = current.Next
+		current = current.next
 		if current == nil {
 			return
 		}
 	}
 }
 
+// DeleteN deletes the first node with each of the given values (a value given
+// twice deletes two nodes) and returns how many nodes were deleted
+func (l *LinkList[T]) DeleteN(values ...T) uint64 {
+	size := l.size
+	for _, value := range values {
+		l.DeleteWithValue(value)
+	}
+	return size - l.size
+}
+
 // ToSlice returns the list as a slice
 func (l *LinkList[T]) ToSlice() []T {
 	var result []T
-	if l.Head == nil {
+	if l.head == nil {
 		return result
 	}
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		result = append(result, current.Value)
-		current = current.Next
+		result = append(result, current.value)
+		current = current.next
+	}
+
+	return result
+}
+
+// ToSliceRange converts the nodes in the range [start, end) of the list to a slice
+// (empty if start > end or end > size)
+func (l *LinkList[T]) ToSliceRange(start, end uint64) []T {
+	var result []T
+	if start >= end || end > l.size {
+		return result
+	}
+
+	result = make([]T, 0, end-start)
+	current := l.nodeAt(start)
+	for i := start; i < end; i++ {
+		result = append(result, current.value)
+		current = current.next
 	}
 
 	return result
 }
 
+// String returns a string representation of the list (elements are formatted with %v)
+func (l *LinkList[T]) String() string {
+	return l.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the list with every element formatted by f
+func (l *LinkList[T]) StringFunc(f func(T) string) string {
+	return common.FormatSlice(l.ToSlice(), f)
+}
+
+// EncodeJSONStream writes the values of the list to w as a JSON array, one
+// value at a time (the output of json.Marshal on ToSlice, without the copy)
+func (l *LinkList[T]) EncodeJSONStream(w io.Writer) error {
+	enc := common.NewJSONArrayEncoder[T](w)
+	for current := l.head; current != nil; current = current.next {
+		if err := enc.Encode(current.value); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// DecodeJSONStream appends the values of the JSON array read from dec, one
+// value at a time, without holding the whole array in memory (see
+// common.DecodeJSONArray). The values decoded before an error stay in the list
+func (l *LinkList[T]) DecodeJSONStream(dec *json.Decoder) error {
+	return common.DecodeJSONArray(dec, func(v T) error {
+		l.Append(v)
+		return nil
+	})
+}
+
 // IsEmpty checks if the list is empty
 func (l *LinkList[T]) IsEmpty() bool {
-	return l.Head == nil
+	return l.head == nil
 }
 
 // Find returns the first node with the given value
 func (l *LinkList[T]) Find(value T) (*Node[T], error) {
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if current.Value == value {
+		if l.equal(current.value, value) {
 			return current, nil
 		}
-		current = current.Next
+		current = current.next
 	}
 
-	return nil, errors.New(ErrValueNotFound)
+	return nil, ErrNotFound
+}
+
+// FindCtx returns the first node with the given value like Find, it checks ctx
+// every common.CtxCheckInterval nodes and returns its error when it's done
+func (l *LinkList[T]) FindCtx(ctx context.Context, value T) (*Node[T], error) {
+	var i uint64
+	for current := l.head; current != nil; current = current.next {
+		if err := common.CtxErr(ctx, i); err != nil {
+			return nil, err
+		}
+		if l.equal(current.value, value) {
+			return current, nil
+		}
+		i++
+	}
+	return nil, ErrNotFound
 }
 
 // Reverse reverses the list
 func (l *LinkList[T]) Reverse() {
 	var prev *Node[T]
-	current := l.Head
+	current := l.head
+	l.tail = current
 
 	for current != nil {
-		next := current.Next
-		current.Next = prev
+		next := current.next
+		current.next = prev
 		prev = current
 		current = next
 	}
 
-	l.Head = prev
+	l.head = prev
+}
+
+// RotateLeft rotates all the values to the left by n positions (the node at
+// index n becomes the head), n can be bigger than the size of the list. The
+// nodes are relinked in O(n) without allocations
+func (l *LinkList[T]) RotateLeft(n uint64) {
+	if l.size == 0 {
+		return
+	}
+	n = n % l.size
+	if n == 0 {
+		return
+	}
+
+	// the new tail is the node right before the new head
+	tail := l.nodeAt(n - 1)
+	l.tail.next = l.head
+	l.head = tail.next
+	l.tail = tail
+	tail.next = nil
+}
+
+// RotateRight rotates all the values to the right by n positions (the last n
+// nodes are moved to the head), n can be bigger than the size of the list.
+// The list is singly linked, so the nodes are relinked in O(size - n)
+func (l *LinkList[T]) RotateRight(n uint64) {
+	if l.size == 0 {
+		return
+	}
+	l.RotateLeft(l.size - n%l.size)
 }
 
 // Size returns the number of nodes in the list
@@ -159,22 +408,44 @@ func (l *LinkList[T]) Size() uint64 {
 // CheckSize recalculates the size of the list
 func (l *LinkList[T]) CheckSize() {
 	var size uint64
-	current := l.Head
+	current := l.head
 	for current != nil {
 		size++
-		current = current.Next
+		current = current.next
 	}
 
 	l.size = size
 }
 
-// Values returns all the values in the list
+// CheckInvariants verifies the internal consistency of the list (the number of
+// nodes matches the size, the last node ends the list and it's the tail), it
+// returns an error wrapping ErrCorrupted describing the first violation found
+func (l *LinkList[T]) CheckInvariants() error {
+	var count uint64
+	var last *Node[T]
+	for current := l.head; current != nil; current = current.next {
+		count++
+		if count > l.size {
+			return fmt.Errorf("%w: more nodes than its size %d (or a cycle)", ErrCorrupted, l.size)
+		}
+		last = current
+	}
+	if count != l.size {
+		return fmt.Errorf("%w: %d nodes but its size is %d", ErrCorrupted, count, l.size)
+	}
+	if l.tail != last {
+		return fmt.Errorf("%w: the tail is not the last node", ErrCorrupted)
+	}
+	return nil
+}
+
+// GetFirst returns the first node in the list
 func (l *LinkList[T]) GetFirst() *Node[T] {
 	if l == nil {
 		return nil
 	}
 
-	return l.Head
+	return l.head
 }
 
 // GetLast returns the last node in the list
@@ -183,47 +454,52 @@ func (l *LinkList[T]) GetLast() *Node[T] {
 		return nil
 	}
 
-	if l.Head == nil {
-		return nil
-	}
-
-	current := l.Head
-	for current.Next != nil {
-		current = current.Next
-	}
-
-	return current
+	return l.tail
 }
 
 // GetAt returns the node at the given index
 func (l *LinkList[T]) GetAt(index uint64) (*Node[T], error) {
-	if index > l.size {
-		return nil, errors.New(ErrIndexOutOfBound)
-	}
-
 	if l == nil {
-		return nil, nil
+		return nil, common.NewIndexError(ErrOutOfBounds, index, 0)
 	}
+	if err := l.checkIndex(index); err != nil {
+		return nil, err
+	}
+	return l.nodeAt(index), nil
+}
 
-	current := l.Head
-	for i := uint64(0); i < index; i++ {
-		if current == nil {
-			return nil, errors.New(ErrIndexOutOfBound)
-		}
-		current = current.Next
+// checkIndex returns an IndexError unless index is the position of a node
+// (index < size), every method addressing a node uses it
+func (l *LinkList[T]) checkIndex(index uint64) error {
+	if index >= l.size {
+		return common.NewIndexError(ErrOutOfBounds, index, l.size)
 	}
+	return nil
+}
 
-	if current == nil {
-		return nil, errors.New(ErrIndexOutOfBound)
+// checkPosition returns an IndexError unless index is a valid insertion
+// point (index <= size, size means after the last node)
+func (l *LinkList[T]) checkPosition(index uint64) error {
+	if index > l.size {
+		return common.NewIndexError(ErrOutOfBounds, index, l.size)
 	}
+	return nil
+}
 
-	return current, nil
+// nodeAt returns the node at the given index (index must be smaller than the
+// size of the list)
+func (l *LinkList[T]) nodeAt(index uint64) *Node[T] {
+	current := l.head
+	for i := uint64(0); i < index; i++ {
+		current = current.next
+	}
+	return current
 }
 
 // InsertAt inserts a new node at the given index
 func (l *LinkList[T]) InsertAt(index uint64, value T) error {
-	if index > l.size {
-		return errors.New(ErrIndexOutOfBound)
+	if err := l.checkPosition(index); err != nil {
+		return err
 	}
 
 	if index == 0 {
@@ -231,53 +507,28 @@ func (l *LinkList[T]) InsertAt(index uint64, value T) error {
 		return nil
 	}
 
-	current := l.Head
-	for i := uint64(0); i < index-1; i++ {
-		if current == nil {
-			return errors.New(ErrIndexOutOfBound)
-		}
-		current = current.Next
-	}
-
-	if current == nil {
-		return errors.New(ErrIndexOutOfBound)
+	current := l.nodeAt(index - 1)
+	newNode := l.newNode(value)
+	newNode.next = current.next
+	current.next = newNode
+	if current == l.tail {
+		l.tail = newNode
 	}
-
-	newNode := &Node[T]{Value: value}
-	newNode.Next = current.Next
-	current.Next = newNode
-
+	l.size++
 	return nil
 }
 
 // DeleteAt deletes the node at the given index
 func (l *LinkList[T]) DeleteAt(index uint64) error {
-	if index >= l.size {
-		return errors.New(ErrIndexOutOfBound)
-	}
-
-	if index == 0 {
-		if l.Head == nil {
-			return errors.New(ErrIndexOutOfBound)
-		}
-		l.Head = l.Head.Next
-		l.size--
-		return nil
-	}
-
-	current := l.Head
-	for i := uint64(0); i < index-1; i++ {
-		if current == nil {
-			return errors.New(ErrIndexOutOfBound)
-		}
-		current = current.Next
+	if err := l.checkIndex(index); err != nil {
+		return err
 	}
 
-	if current == nil || current.Next == nil {
-		return errors.New(ErrIndexOutOfBound)
+	var prev *Node[T] // nil deletes the head
+	if index > 0 {
+		prev = l.nodeAt(index - 1)
 	}
-
-	current.Next = current.Next.Next
+	l.deleteNext(prev)
 	l.size--
 
 	return nil
@@ -290,85 +541,256 @@ func (l *LinkList[T]) Remove(value T) {
 
 // Clear removes all nodes from the list
 func (l *LinkList[T]) Clear() {
-	l.Head = nil
+	// Release the nodes until the pool is full, the rest is left to the GC
+	for l.pool != nil && l.head != nil && l.pool.Len() < poolSize {
+		l.deleteNext(nil)
+	}
+	l.reset()
+}
+
+// Wipe overwrites the value of every node with the zero value and unlinks the
+// nodes before emptying the list, so sensitive data (tokens, keys, ...)
+// doesn't stay reachable from nodes still referenced elsewhere (a *Node
+// returned by Find, GetAt, ... is wiped too)
+func (l *LinkList[T]) Wipe() {
+	for l.head != nil {
+		node := l.head
+		l.head = node.next
+		var zero T
+		node.value = zero
+		node.next = nil
+		l.pool.Put(node)
+	}
+	l.reset()
+}
+
+// reset empties the list without releasing its nodes to the pool, it's used
+// when the nodes have been moved to another list
+func (l *LinkList[T]) reset() {
+	l.head = nil
+	l.tail = nil
 	l.size = 0
 }
 
-// Copy returns a copy of the list
+// Copy returns a copy of the list. The copy is shallow: the values are
+// copied by assignment, so pointers, slices and maps inside them are shared
+// with the original (use CloneWith for a deep copy)
 func (l *LinkList[T]) Copy() *LinkList[T] {
-	newList := New[T]()
+	newList := l.newEmpty()
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		newList.Append(current.Value)
-		current = current.Next
+		newList.Append(current.value)
+		current = current.next
 	}
 
 	return newList
 }
 
+// CopyRange returns a new list with the nodes in the range [start, end) of the original one
+// (empty if start > end or end > size), the copy is shallow like Copy
+func (l *LinkList[T]) CopyRange(start, end uint64) *LinkList[T] {
+	newList := l.newEmpty()
+	if start >= end || end > l.size {
+		return newList
+	}
+
+	current := l.nodeAt(start)
+	for i := start; i < end; i++ {
+		newList.Append(current.value)
+		current = current.next
+	}
+
+	return newList
+}
+
+// CloneWith returns a copy of the list with a copy of every value made by
+// copier, to deep copy values that hold pointers, slices or maps
+func (l *LinkList[T]) CloneWith(copier func(T) T) *LinkList[T] {
+	newList := l.Copy()
+	newList.ForEach(func(v *T) {
+		*v = copier(*v)
+	})
+	return newList
+}
+
+// fromSlice returns a new list, comparing its values like l, with the given values
+func (l *LinkList[T]) fromSlice(items []T) *LinkList[T] {
+	newList := l.newEmpty()
+	var tail *Node[T]
+	for _, item := range items {
+		node := &Node[T]{value: item}
+		if tail == nil {
+			newList.head = node
+		} else {
+			tail.next = node
+		}
+		tail = node
+	}
+	newList.tail = tail
+	newList.size = uint64(len(items))
+	return newList
+}
+
+// Chunk splits the values of the list in consecutive new lists of size values
+// (the last one may be shorter). It returns nil if size is 0 or the list is empty
+func (l *LinkList[T]) Chunk(size uint64) []*LinkList[T] {
+	if size == 0 || l.size == 0 {
+		return nil
+	}
+	items := l.ToSlice()
+	chunks := make([]*LinkList[T], 0, (l.size+size-1)/size)
+	for start := uint64(0); start < l.size; start += size {
+		chunks = append(chunks, l.fromSlice(items[start:min(start+size, l.size)]))
+	}
+	return chunks
+}
+
+// Windows returns all the sliding windows of size consecutive values of the
+// list as new lists, in order ([0, size), [1, size+1), ...). It returns nil
+// if size is 0 or greater than the size of the list
+func (l *LinkList[T]) Windows(size uint64) []*LinkList[T] {
+	if size == 0 || size > l.size {
+		return nil
+	}
+	items := l.ToSlice()
+	windows := make([]*LinkList[T], 0, l.size-size+1)
+	for start := uint64(0); start+size <= l.size; start++ {
+		windows = append(windows, l.fromSlice(items[start:start+size]))
+	}
+	return windows
+}
+
 // Merge appends all the nodes from another list to the current list
 func (l *LinkList[T]) Merge(list *LinkList[T]) {
-	current := list.Head
+	current := list.head
 	for current != nil {
-		l.Append(current.Value)
-		current = current.Next
+		l.Append(current.value)
+		current = current.next
 	}
 
 	// Clear the list
 	list.Clear()
 }
 
+// SplitAt splits the list in two: left gets the nodes in [0, index) and right
+// the nodes in [index, size). The nodes are moved (not copied), so the list is
+// left empty
+func (l *LinkList[T]) SplitAt(index uint64) (*LinkList[T], *LinkList[T], error) {
+	if err := l.checkPosition(index); err != nil {
+		return nil, nil, err
+	}
+
+	left := &LinkList[T]{size: index, equals: l.equals, key: l.key, hasher: l.hasher}
+	right := &LinkList[T]{size: l.size - index, equals: l.equals, key: l.key, hasher: l.hasher}
+	if index == 0 {
+		right.head = l.head
+	} else {
+		prev := l.nodeAt(index - 1)
+		left.head, left.tail = l.head, prev
+		right.head = prev.next
+		prev.next = nil
+	}
+	if right.head != nil {
+		right.tail = l.tail
+	}
+	l.reset()
+	return left, right, nil
+}
+
+// Splice moves all the nodes of the other list into this one, so that the
+// first of them ends up at the given index (use Size() to append them). The
+// nodes are re-linked rather than copied and the other list is left empty
+func (l *LinkList[T]) Splice(index uint64, list *LinkList[T]) error {
+	if list == l {
+		return ErrSameList
+	}
+	if err := l.checkPosition(index); err != nil {
+		return err
+	}
+	if list.head == nil {
+		return nil
+	}
+
+	if index == l.size {
+		l.tail = list.tail
+	}
+	if index == 0 {
+		list.tail.next = l.head
+		l.head = list.head
+	} else {
+		prev := l.nodeAt(index - 1)
+		list.tail.next = prev.next
+		prev.next = list.head
+	}
+	l.size += list.size
+	list.reset()
+	return nil
+}
+
 // Map generates a new list by applying the function to all the nodes in the list
 func (l *LinkList[T]) Map(f func(T) T) *LinkList[T] {
-	newList := New[T]()
-	current := l.Head
+	newList := l.newEmpty()
+	current := l.head
 	for current != nil {
-		newList.Append(f(current.Value))
-		current = current.Next
+		newList.Append(f(current.value))
+		current = current.next
 	}
 	return newList
 }
 
-// MapFrom generates a new list by applying the function to all the nodes in the list starting from the specified index
-func (l *LinkList[T]) MapFrom(start uint64, f func(T) T) (*LinkList[T], error) {
-	if start > l.size {
-		return nil, errors.New(ErrIndexOutOfBound)
-	}
-
-	newList := New[T]()
-	current, err := l.GetAt(start)
+// MapCtx generates a new list like Map, it checks ctx every
+// common.CtxCheckInterval nodes and returns its error (and no list) when it's
+// done
+func (l *LinkList[T]) MapCtx(ctx context.Context, f func(T) T) (*LinkList[T], error) {
+	newList := l.newEmpty()
+	err := l.ForEachCtx(ctx, func(v *T) {
+		newList.Append(f(*v))
+	})
 	if err != nil {
 		return nil, err
 	}
+	return newList, nil
+}
 
-	for current != nil {
-		newList.Append(f(current.Value))
-		current = current.Next
+// MapIndexed generates a new list by applying the function to all the values in
+// the list and their index
+func (l *LinkList[T]) MapIndexed(f func(uint64, T) T) *LinkList[T] {
+	items := make([]T, 0, l.size)
+	l.ForEachIndexed(func(i uint64, v *T) {
+		items = append(items, f(i, *v))
+	})
+	return l.fromSlice(items)
+}
+
+// MapFrom generates a new list by applying the function to all the nodes in the list starting from the specified index
+func (l *LinkList[T]) MapFrom(start uint64, f func(T) T) (*LinkList[T], error) {
+	if err := l.checkIndex(start); err != nil {
+		return nil, err
 	}
 
+	newList := l.newEmpty()
+	for current := l.nodeAt(start); current != nil; current = current.next {
+		newList.Append(f(current.value))
+	}
 	return newList, nil
 }
 
 // MapRange generates a new list by applying the function to all the nodes in the list within the specified range
 func (l *LinkList[T]) MapRange(start, end uint64, f func(T) T) (*LinkList[T], error) {
 	if start > end {
-		return nil, errors.New("start index cannot be greater than end index")
+		return nil, ErrInvalidRange
 	}
 
-	if end >= l.size {
-		return nil, errors.New(ErrIndexOutOfBound)
-	}
-
-	newList := New[T]()
-	current, err := l.GetAt(start)
-	if err != nil {
+	if err := l.checkIndex(end); err != nil {
 		return nil, err
 	}
 
+	newList := l.newEmpty()
+	current := l.nodeAt(start)
 	for i := start; i <= end; i++ {
-		newList.Append(f(current.Value))
-		current = current.Next
+		newList.Append(f(current.value))
+		current = current.next
 	}
 
 	return newList, nil
@@ -377,23 +799,80 @@ func (l *LinkList[T]) MapRange(start, end uint64, f func(T) T) (*LinkList[T], er
 // Filter removes nodes from the list that don't match the predicate
 func (l *LinkList[T]) Filter(f func(T) bool) {
 	// If the list is empty, return
-	if l.Head == nil {
+	if l.head == nil {
 		return
 	}
 
 	// Move the head to the first node that matches the predicate
-	for l.Head != nil && !f(l.Head.Value) {
-		l.Head = l.Head.Next
+	for l.head != nil && !f(l.head.value) {
+		l.deleteNext(nil)
+		l.size--
 	}
 
 	// Proceed with the rest of the list
-	current := l.Head
-	for current != nil && current.Next != nil {
-		if !f(current.Next.Value) {
-			current.Next = current.Next.Next
+	current := l.head
+	for current != nil && current.next != nil {
+		if !f(current.next.value) {
+			l.deleteNext(current)
+			l.size--
+		} else {
+			current = current.next
+		}
+	}
+}
+
+// RemoveIf removes the nodes that match the predicate (the opposite of
+// Filter) and returns how many were removed
+func (l *LinkList[T]) RemoveIf(predicate func(T) bool) uint64 {
+	size := l.size
+	l.Filter(func(v T) bool { return !predicate(v) })
+	return size - l.size
+}
+
+// RemoveAll removes all the nodes equal to value and returns how many were removed
+func (l *LinkList[T]) RemoveAll(value T) uint64 {
+	return l.RemoveIf(func(v T) bool { return l.equal(v, value) })
+}
+
+// RemoveFirstN removes the first n nodes equal to value (from the start of
+// the list) and returns how many were removed
+func (l *LinkList[T]) RemoveFirstN(value T, n uint64) uint64 {
+	removed := uint64(0)
+	return l.RemoveIf(func(v T) bool {
+		if removed < n && l.equal(v, value) {
+			removed++
+			return true
+		}
+		return false
+	})
+}
+
+// Unique removes consecutive duplicate values, keeping the first node of each run
+func (l *LinkList[T]) Unique() {
+	for current := l.head; current != nil && current.next != nil; {
+		if l.equal(current.value, current.next.value) {
+			l.deleteNext(current)
+			l.size--
+		} else {
+			current = current.next
+		}
+	}
+}
+
+// Deduplicate removes all duplicate values, keeping the first occurrence of each one
+func (l *LinkList[T]) Deduplicate() {
+	if l.head == nil {
+		return
+	}
+
+	seen := l.seen()
+	seen.Add(l.head.value)
+	for current := l.head; current.next != nil; {
+		if !seen.Add(current.next.value) {
+			l.deleteNext(current)
 			l.size--
 		} else {
-			current = current.Next
+			current = current.next
 		}
 	}
 }
@@ -402,42 +881,132 @@ func (l *LinkList[T]) Filter(f func(T) bool) {
 func (l *LinkList[T]) Reduce(f func(T, T) T, initial T) T {
 	result := initial
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		result = f(result, current.Value)
-		current = current.Next
+		result = f(result, current.value)
+		current = current.next
 	}
 
 	return result
 }
 
+// ReduceInto reduces the list to a value of a different type, starting from
+// initial and applying fn to each value from the head to the end of the list
+func ReduceInto[T, A any](l *LinkList[T], initial A, fn func(A, T) A) A {
+	result := initial
+	for current := l.head; current != nil; current = current.next {
+		result = fn(result, current.value)
+	}
+	return result
+}
+
+// ReduceRight reduces the list to a value like ReduceInto, applying fn to each
+// value from the end of the list to the head. The list is singly linked, so
+// its values are collected in a slice first (use a dlinkList to fold from the
+// tail without allocating)
+func ReduceRight[T, A any](l *LinkList[T], initial A, fn func(A, T) A) A {
+	values := make([]T, 0, l.size)
+	for current := l.head; current != nil; current = current.next {
+		values = append(values, current.value)
+	}
+
+	result := initial
+	for i := len(values) - 1; i >= 0; i-- {
+		result = fn(result, values[i])
+	}
+	return result
+}
+
+// MapTo returns a new list with the result of fn applied to every value of src,
+// in the same order. Unlike Map the result can hold a different type, e.g. to
+// extract the IDs of a list of structs. Its values are compared with == like
+// the ones of New, use MapToWithComparator when U is not comparable
+func MapTo[T any, U comparable](src *LinkList[T], fn func(T) U) *LinkList[U] {
+	return mapInto(src, fn, New[U]())
+}
+
+// MapToWithComparator is like MapTo, the new list compares its values with
+// the given function
+func MapToWithComparator[T, U any](src *LinkList[T], fn func(T) U, equals func(a, b U) bool) *LinkList[U] {
+	return mapInto(src, fn, NewWithComparator(equals))
+}
+
+// mapInto fills result with fn applied to every element of src
+func mapInto[T, U any](src *LinkList[T], fn func(T) U, result *LinkList[U]) *LinkList[U] {
+	for current := src.head; current != nil; current = current.next {
+		result.Append(fn(current.value))
+	}
+	return result
+}
+
 // ForEach applies the function to all the nodes in the list
+// (f must not add or remove nodes, use ForEachSafe to remove them while iterating)
 func (l *LinkList[T]) ForEach(f func(*T)) {
-	current := l.Head
+	current := l.head
 	for current != nil {
-		f(&current.Value)
-		current = current.Next
+		f(&current.value)
+		current = current.next
+	}
+}
+
+// ForEachSafe calls fn with every value of the list, from the head to the
+// tail, and removes the nodes for which it returns true (e.g. to expire entries
+// while scanning). The traversal keeps its position when the current node is
+// removed, unlike removing it from a ForEach callback. fn must not modify the
+// list itself
+func (l *LinkList[T]) ForEachSafe(fn func(v T) (remove bool)) {
+	l.Filter(func(v T) bool { return !fn(v) })
+}
+
+// ForEachIndexed applies the function to all the values in the list and their index
+func (l *LinkList[T]) ForEachIndexed(f func(uint64, *T)) {
+	i := uint64(0)
+	l.ForEach(func(v *T) {
+		f(i, v)
+		i++
+	})
+}
+
+// ForEachErr applies the function to all the nodes in the list, it stops at the
+// first error returned by the function and returns it
+func (l *LinkList[T]) ForEachErr(f func(*T) error) error {
+	for current := l.head; current != nil; current = current.next {
+		if err := f(&current.value); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// ForEachCtx applies the function to all the nodes in the list like ForEach,
+// it checks ctx every common.CtxCheckInterval nodes and stops, returning its
+// error, when it's done
+func (l *LinkList[T]) ForEachCtx(ctx context.Context, f func(*T)) error {
+	var i uint64
+	return l.ForEachErr(func(v *T) error {
+		if err := common.CtxErr(ctx, i); err != nil {
+			return err
+		}
+		i++
+		f(v)
+		return nil
+	})
 }
 
 // ForRange applies the function to all the nodes in the list within the specified range
 func (l *LinkList[T]) ForRange(start, end uint64, f func(*T)) error {
 	if start > end {
-		return errors.New("start index cannot be greater than end index")
+		return ErrInvalidRange
 	}
 
-	if end >= l.size {
-		return errors.New(ErrIndexOutOfBound)
-	}
-
-	current, err := l.GetAt(start)
-	if err != nil {
+	if err := l.checkIndex(end); err != nil {
 		return err
 	}
 
+	current := l.nodeAt(start)
 	for i := start; i <= end; i++ {
-		f(&current.Value)
-		current = current.Next
+		f(&current.value)
+		current = current.next
 		if current == nil {
 			break
 		}
@@ -448,18 +1017,14 @@ func (l *LinkList[T]) ForRange(start, end uint64, f func(*T)) error {
 
 // ForFrom applies the function to all the nodes in the list starting from the specified index
 func (l *LinkList[T]) ForFrom(start uint64, f func(*T)) error {
-	if start > l.size {
-		return errors.New(ErrIndexOutOfBound)
-	}
-
-	current, err := l.GetAt(start)
-	if err != nil {
+	if err := l.checkIndex(start); err != nil {
 		return err
 	}
 
+	current := l.nodeAt(start)
 	for current != nil {
-		f(&current.Value)
-		current = current.Next
+		f(&current.value)
+		current = current.next
 		if current == nil {
 			break
 		}
@@ -470,12 +1035,12 @@ func (l *LinkList[T]) ForFrom(start uint64, f func(*T)) error {
 
 // Any checks if any node in the list matches the predicate
 func (l *LinkList[T]) Any(f func(T) bool) bool {
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if f(current.Value) {
+		if f(current.value) {
 			return true
 		}
-		current = current.Next
+		current = current.next
 	}
 
 	return false
@@ -486,115 +1051,158 @@ func (l *LinkList[T]) All(f func(T) bool) bool {
 	if l == nil {
 		return false
 	}
-	if l.Head == nil {
+	if l.head == nil {
 		return false
 	}
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if !f(current.Value) {
+		if !f(current.value) {
 			return false
 		}
-		current = current.Next
+		current = current.next
 	}
 	return true
 }
 
 // Contains checks if the list contains the given value
 func (l *LinkList[T]) Contains(value T) bool {
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if current.Value == value {
+		if l.equal(current.value, value) {
 			return true
 		}
-		current = current.Next
+		current = current.next
 	}
 
 	return false
 }
 
+// ContainsAll returns true if the list contains all the given values (true
+// when none is given), they are all checked in a single pass over the list
+func (l *LinkList[T]) ContainsAll(values ...T) bool {
+	if len(values) == 0 {
+		return true
+	}
+
+	lookup := l.lookup(values)
+	for n := l.head; n != nil; n = n.next {
+		if lookup.Match(n.value) && lookup.Remaining() == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsAny returns true if the list contains at least one of the given
+// values, they are all checked in a single pass over the list
+func (l *LinkList[T]) ContainsAny(values ...T) bool {
+	if len(values) == 0 {
+		return false
+	}
+
+	lookup := l.lookup(values)
+	for n := l.head; n != nil; n = n.next {
+		if lookup.Match(n.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountOf returns the number of values in the list equal to the given value
+func (l *LinkList[T]) CountOf(value T) uint64 {
+	var count uint64
+	for n := l.head; n != nil; n = n.next {
+		if l.equal(n.value, value) {
+			count++
+		}
+	}
+	return count
+}
+
 // IndexOf returns the index of the first node with the given value
 func (l *LinkList[T]) IndexOf(value T) (uint64, error) {
-	current := l.Head
+	current := l.head
 	index := uint64(0)
 	for current != nil {
-		if current.Value == value {
+		if l.equal(current.value, value) {
 			return index, nil
 		}
-		current = current.Next
+		current = current.next
 		index++
 	}
 
-	return 0, errors.New(ErrValueNotFound)
+	return 0, ErrNotFound
 }
 
 // LastIndexOf returns the index of the last node with the given value
 func (l *LinkList[T]) LastIndexOf(value T) (uint64, error) {
-	current := l.Head
+	current := l.head
 	index := uint64(0)
 	i := uint64(0)
 	found := false
 	for current != nil {
-		if current.Value == value {
+		if l.equal(current.value, value) {
 			index = i
 			found = true
 		}
-		current = current.Next
+		current = current.next
 		i++
 	}
 
 	if !found {
-		return 0, errors.New(ErrValueNotFound)
+		return 0, ErrNotFound
 	}
 	return index, nil
 }
 
 // FindIndex returns the index of the first node that matches the predicate
 func (l *LinkList[T]) FindIndex(f func(T) bool) (uint64, error) {
-	current := l.Head
+	current := l.head
 	index := uint64(0)
 	for current != nil {
-		if f(current.Value) {
+		if f(current.value) {
 			return index, nil
 		}
-		current = current.Next
+		current = current.next
 		index++
 	}
 
-	return 0, errors.New(ErrValueNotFound)
+	return 0, ErrNotFound
 }
 
 // FindLastIndex returns the index of the last node that matches the predicate
 func (l *LinkList[T]) FindLastIndex(f func(T) bool) (uint64, error) {
-	current := l.Head
+	current := l.head
 	index := uint64(0)
 	i := uint64(0)
 	found := false
 	for current != nil {
-		if f(current.Value) {
+		if f(current.value) {
 			index = i
 			found = true
 		}
-		current = current.Next
+		current = current.next
 		i++
 	}
 
 	if !found {
-		return 0, errors.New(ErrValueNotFound)
+		return 0, ErrNotFound
 	}
 	return index, nil
 }
 
 // FindAll returns all nodes that match the predicate
 func (l *LinkList[T]) FindAll(f func(T) bool) *LinkList[T] {
-	newList := New[T]()
+	newList := l.newEmpty()
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if f(current.Value) {
-			newList.Append(current.Value)
+		if f(current.value) {
+			newList.Append(current.value)
 		}
-		current = current.Next
+		current = current.next
 	}
 
 	return newList
@@ -604,16 +1212,16 @@ func (l *LinkList[T]) FindAll(f func(T) bool) *LinkList[T] {
 func (l *LinkList[T]) FindLast(f func(T) bool) (*Node[T], error) {
 	var result *Node[T]
 
-	current := l.Head
+	current := l.head
 	for current != nil {
-		if f(current.Value) {
+		if f(current.value) {
 			result = current
 		}
-		current = current.Next
+		current = current.next
 	}
 
 	if result == nil {
-		return nil, errors.New(ErrValueNotFound)
+		return nil, ErrNotFound
 	}
 
 	return result, nil
@@ -623,15 +1231,174 @@ func (l *LinkList[T]) FindLast(f func(T) bool) (*Node[T], error) {
 func (l *LinkList[T]) FindAllIndexes(f func(T) bool) []uint64 {
 	var result []uint64
 
-	current := l.Head
+	current := l.head
 	index := uint64(0)
 	for current != nil {
-		if f(current.Value) {
+		if f(current.value) {
 			result = append(result, index)
 		}
-		current = current.Next
+		current = current.next
 		index++
 	}
 
 	return result
 }
+
+// Sort sorts the list according to the given function (using a stable merge sort)
+// for example, to sort a list of integers in ascending order, use:
+// list.Sort(func(a, b int) bool { return a < b })
+func (l *LinkList[T]) Sort(less func(T, T) bool) {
+	l.head = mergeSort(l.head, less)
+	for l.tail = l.head; l.tail != nil && l.tail.next != nil; {
+		l.tail = l.tail.next
+	}
+}
+
+// IsSorted returns true if the list is sorted according to the given function
+func (l *LinkList[T]) IsSorted(less func(T, T) bool) bool {
+	if l.head == nil {
+		return true
+	}
+	for current := l.head; current.next != nil; current = current.next {
+		if less(current.next.value, current.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare compares the list with other lexicographically (like bytes.Compare),
+// element by element from the head with compare, which returns a negative
+// number, zero or a positive number like cmp.Compare. It returns -1, 0 or +1,
+// a list that is a prefix of the other is the smaller one
+func (l *LinkList[T]) Compare(other *LinkList[T], compare func(a, b T) int) int {
+	a, o := l.head, other.head
+	for i := uint64(0); i < min(l.size, other.size); i++ {
+		if c := compare(a.value, o.value); c != 0 {
+			return cmp.Compare(c, 0)
+		}
+		a, o = a.next, o.next
+	}
+	return cmp.Compare(l.size, other.size)
+}
+
+// mergeSort sorts the nil-terminated chain of nodes starting at head and
+// returns the new head
+func mergeSort[T any](head *Node[T], less func(T, T) bool) *Node[T] {
+	if head == nil || head.next == nil {
+		return head
+	}
+
+	// find the middle of the chain (slow/fast pointers) and split it
+	slow, fast := head, head.next
+	for fast != nil && fast.next != nil {
+		slow = slow.next
+		fast = fast.next.next
+	}
+	right := slow.next
+	slow.next = nil
+
+	return merge(mergeSort(head, less), mergeSort(right, less), less)
+}
+
+// merge merges two sorted chains of nodes, on equal values the left one goes first
+func merge[T any](left, right *Node[T], less func(T, T) bool) *Node[T] {
+	var dummy Node[T]
+	tail := &dummy
+	for left != nil && right != nil {
+		if less(right.value, left.value) {
+			tail.next = right
+			right = right.next
+		} else {
+			tail.next = left
+			left = left.next
+		}
+		tail = tail.next
+	}
+	if left != nil {
+		tail.next = left
+	} else {
+		tail.next = right
+	}
+	return dummy.next
+}
+
+// Join returns a new list with the values of all the given lists in order
+// (nil lists are skipped), it's the inverse of Chunk. The new list compares
+// its values like the first list (with == if all of them are nil)
+func Join[T any](parts ...*LinkList[T]) *LinkList[T] {
+	var first *LinkList[T]
+	var items []T
+	for _, part := range parts {
+		if part != nil {
+			if first == nil {
+				first = part
+			}
+			items = append(items, part.ToSlice()...)
+		}
+	}
+	if first == nil {
+		return NewWithComparator[T](nil)
+	}
+	return first.fromSlice(items)
+}
+
+// Flatten returns a new list with the values of all the lists in l in order,
+// compared like the ones of the first list (see Join)
+func Flatten[T any](l *LinkList[*LinkList[T]]) *LinkList[T] {
+	return Join(l.ToSlice()...)
+}
+
+// Min returns the smallest element of the list according to less (the first
+// one if there are several), or ErrEmpty if the list is empty
+func Min[T any](l *LinkList[T], less func(T, T) bool) (T, error) {
+	if l.head == nil {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+
+	result := l.head.value
+	for current := l.head.next; current != nil; current = current.next {
+		if less(current.value, result) {
+			result = current.value
+		}
+	}
+	return result, nil
+}
+
+// Max returns the largest element of the list according to less (the first
+// one if there are several), or ErrEmpty if the list is empty
+func Max[T any](l *LinkList[T], less func(T, T) bool) (T, error) {
+	return Min(l, func(x, y T) bool { return less(y, x) })
+}
+
+// MinOrdered returns the smallest element of a list of an ordered type
+func MinOrdered[T cmp.Ordered](l *LinkList[T]) (T, error) {
+	return Min(l, cmp.Less[T])
+}
+
+// MaxOrdered returns the largest element of a list of an ordered type
+func MaxOrdered[T cmp.Ordered](l *LinkList[T]) (T, error) {
+	return Max(l, cmp.Less[T])
+}
+
+// Sum returns the sum of all the elements of a numeric list (0 if it's empty)
+func Sum[T common.Number](l *LinkList[T]) T {
+	return ReduceInto(l, T(0), func(acc, v T) T { return acc + v })
+}
+
+// Average returns the arithmetic mean of the elements of a numeric list
+// (computed in float64), or ErrEmpty if the list is empty
+func Average[T common.Number](l *LinkList[T]) (float64, error) {
+	if l.head == nil {
+		return 0, ErrEmpty
+	}
+
+	var sum float64
+	var count uint64
+	for current := l.head; current != nil; current = current.next {
+		sum += float64(current.value)
+		count++
+	}
+	return sum / float64(count), nil
+}
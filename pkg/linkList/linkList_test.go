@@ -16,9 +16,17 @@
 package linkList_test
 
 import (
+	"cmp"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
 	"testing"
 
+	ctxtest "github.com/pzaino/gods/internal/ctxtest"
+	common "github.com/pzaino/gods/pkg/common"
 	linkList "github.com/pzaino/gods/pkg/linkList"
 )
 
@@ -74,6 +82,25 @@ func TestPrepend(t *testing.T) {
 	}
 }
 
+func TestAppendNPrependNDeleteN(t *testing.T) {
+	l := linkList.New[int]()
+	l.AppendN()
+	l.AppendN(3, 4)
+	l.PrependN(1, 2)
+	l.AppendN(5)
+	if !slices.Equal(l.ToSlice(), []int{1, 2, 3, 4, 5}) || l.Size() != 5 {
+		t.Errorf("expected [1 2 3 4 5], got %v", l.ToSlice())
+	}
+
+	l.AppendN(1)
+	if n := l.DeleteN(1, 1, 1, 9, 4); n != 3 || !slices.Equal(l.ToSlice(), []int{2, 3, 5}) {
+		t.Errorf("expected 3 nodes deleted and [2 3 5] left, got %d and %v", n, l.ToSlice())
+	}
+	if err := l.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
 func TestRemove(t *testing.T) {
 	list := linkList.New[int]()
 	list.Append(1)
@@ -168,6 +195,38 @@ func TestReverse(t *testing.T) {
 	}
 }
 
+func TestRotateLeftAndRight(t *testing.T) {
+	values := []int{1, 2, 3, 4, 5}
+	for _, n := range []uint64{0, 1, 2, 4, 5, 7} {
+		k := int(n % uint64(len(values)))
+		left := append(slices.Clone(values[k:]), values[:k]...)
+		right := append(slices.Clone(values[len(values)-k:]), values[:len(values)-k]...)
+
+		l := linkList.NewFromSlice(values)
+		l.RotateLeft(n)
+		if !slices.Equal(l.ToSlice(), left) || l.CheckInvariants() != nil {
+			t.Errorf("expected RotateLeft(%d) to give %v, got %v (%v)", n, left, l.ToSlice(), l.CheckInvariants())
+		}
+		l.Append(6)
+		if last := l.ToSlice()[len(values)]; last != 6 {
+			t.Errorf("expected 6 to be appended after RotateLeft(%d), got %d", n, last)
+		}
+
+		r := linkList.NewFromSlice(values)
+		r.RotateRight(n)
+		if !slices.Equal(r.ToSlice(), right) || r.CheckInvariants() != nil {
+			t.Errorf("expected RotateRight(%d) to give %v, got %v (%v)", n, right, r.ToSlice(), r.CheckInvariants())
+		}
+	}
+
+	empty := linkList.New[int]()
+	empty.RotateLeft(3)
+	empty.RotateRight(3)
+	if !empty.IsEmpty() {
+		t.Error("expected the empty list to stay empty")
+	}
+}
+
 func TestReverseEmptyList(t *testing.T) {
 	list := linkList.New[int]()
 
@@ -207,8 +266,8 @@ func TestGetFirst(t *testing.T) {
 	first := list.GetFirst()
 	if first == nil {
 		t.Error("Expected first node to be non-nil")
-	} else if first.Value != 1 {
-		t.Errorf("Expected first node value to be 1, but got %v", first.Value)
+	} else if first.Value() != 1 {
+		t.Errorf("Expected first node value to be 1, but got %v", first.Value())
 	}
 }
 
@@ -221,8 +280,8 @@ func TestGetLast(t *testing.T) {
 	last := list.GetLast()
 	if last == nil {
 		t.Error("Expected last node to be non-nil")
-	} else if last.Value != 3 {
-		t.Errorf("Expected last node value to be 3, but got %v", last.Value)
+	} else if last.Value() != 3 {
+		t.Errorf("Expected last node value to be 3, but got %v", last.Value())
 	}
 }
 
@@ -246,8 +305,8 @@ func TestGetAt(t *testing.T) {
 	if err != nil {
 		t.Errorf(errExpectedNoError, err)
 	}
-	if node.Value != 2 {
-		t.Errorf(errExpectedNodeValue, 2, node.Value)
+	if node.Value() != 2 {
+		t.Errorf(errExpectedNodeValue, 2, node.Value())
 	}
 
 	// Test getting an index out of bounds
@@ -280,6 +339,10 @@ func TestInsertAt(t *testing.T) {
 			t.Errorf(errExpectedSliceElem, i, expected[i], slice[i])
 		}
 	}
+
+	if list.Size() != 4 {
+		t.Errorf("Expected list to have 4 items, but got %v", list.Size())
+	}
 }
 
 func TestInsertAtOutOfBoundsIndex(t *testing.T) {
@@ -467,6 +530,40 @@ func TestCopy(t *testing.T) {
 	}
 }
 
+func TestToSliceRangeAndCopyRange(t *testing.T) {
+	list := linkList.New[int]()
+	for _, v := range []int{1, 2, 3, 4, 5} {
+		list.Append(v)
+	}
+
+	tests := []struct {
+		start, end uint64
+		expected   []int
+	}{
+		{0, 5, []int{1, 2, 3, 4, 5}},
+		{1, 3, []int{2, 3}},
+		{4, 5, []int{5}},
+		{2, 2, []int{}},
+		{3, 2, []int{}},
+		{0, 6, []int{}},
+	}
+	for _, test := range tests {
+		if result := list.ToSliceRange(test.start, test.end); !slices.Equal(result, test.expected) {
+			t.Errorf("expected ToSliceRange(%d, %d) to return %v, got %v", test.start, test.end, test.expected, result)
+		}
+		c := list.CopyRange(test.start, test.end)
+		if !slices.Equal(c.ToSlice(), test.expected) || c.Size() != uint64(len(test.expected)) {
+			t.Errorf("expected CopyRange(%d, %d) to return %v, got %v", test.start, test.end, test.expected, c.ToSlice())
+		}
+	}
+
+	c := list.CopyRange(1, 3)
+	c.Append(6)
+	if list.Size() != 5 || !slices.Equal(c.ToSlice(), []int{2, 3, 6}) {
+		t.Errorf("expected the copy to be independent, got %v and %v", list.ToSlice(), c.ToSlice())
+	}
+}
+
 func TestMerge(t *testing.T) {
 	list1 := linkList.New[int]()
 	list1.Append(1)
@@ -583,6 +680,10 @@ func TestFilterCleanList(t *testing.T) {
 		}
 		t.Errorf(errExpectedSliceLength, 0, len(slice))
 	}
+
+	if list.Size() != 0 {
+		t.Errorf("Expected list to have 0 items, but got %v", list.Size())
+	}
 }
 
 func TestFilterEmptyList(t *testing.T) {
@@ -937,8 +1038,8 @@ func TestFindLast(t *testing.T) {
 	}
 	if node == nil {
 		t.Error("Expected node to be non-nil")
-	} else if node.Value != 2 {
-		t.Errorf(errExpectedNodeValue, 2, node.Value)
+	} else if node.Value() != 2 {
+		t.Errorf(errExpectedNodeValue, 2, node.Value())
 	}
 
 	// Test finding a value that doesn't exist
@@ -1005,8 +1106,8 @@ func TestFind(t *testing.T) {
 	if err != nil {
 		t.Errorf(errExpectedNoError, err)
 	}
-	if node.Value != 2 {
-		t.Errorf(errExpectedNodeValue, 2, node.Value)
+	if node.Value() != 2 {
+		t.Errorf(errExpectedNodeValue, 2, node.Value())
 	}
 
 	// Test finding a value that doesn't exist in the list
@@ -1242,3 +1343,986 @@ func TestCheckSize(t *testing.T) {
 		t.Errorf(errExpectedItems, 0, list.Size())
 	}
 }
+
+func TestSort(t *testing.T) {
+	less := func(a, b int) bool { return a < b }
+
+	list := linkList.New[int]()
+	list.Sort(less)
+	if !list.IsSorted(less) {
+		t.Errorf("Expected an empty list to be sorted")
+	}
+
+	list = linkList.NewFromSlice([]int{5, 3, 1, 4, 2, 3})
+	if list.IsSorted(less) {
+		t.Errorf("Expected list not to be sorted")
+	}
+	list.Sort(less)
+
+	expected := []int{1, 2, 3, 3, 4, 5}
+	slice := list.ToSlice()
+	if len(slice) != len(expected) {
+		t.Fatalf(errExpectedSliceLength, len(expected), len(slice))
+	}
+	for i, v := range expected {
+		if slice[i] != v {
+			t.Errorf(errExpectedSliceElem, i, v, slice[i])
+		}
+	}
+	if !list.IsSorted(less) {
+		t.Errorf("Expected list to be sorted")
+	}
+	if list.Size() != 6 {
+		t.Errorf(errExpectedItems, 6, list.Size())
+	}
+}
+
+func TestSortIsStable(t *testing.T) {
+	type item struct{ key, order int }
+	list := linkList.NewFromSlice([]item{{2, 0}, {1, 1}, {2, 2}, {1, 3}})
+	list.Sort(func(a, b item) bool { return a.key < b.key })
+
+	expected := []item{{1, 1}, {1, 3}, {2, 0}, {2, 2}}
+	for i, v := range list.ToSlice() {
+		if v != expected[i] {
+			t.Errorf(errExpectedNodeValue, expected[i], v)
+		}
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	list := linkList.NewFromSlice([]int{1, 2, 3})
+
+	_, err := list.GetAt(7)
+	if !errors.Is(err, linkList.ErrOutOfBounds) {
+		t.Errorf(errExpectedYesError, err)
+	}
+	var indexErr *common.IndexError
+	if !errors.As(err, &indexErr) || indexErr.Index != 7 || indexErr.Size != 3 {
+		t.Errorf("Expected an IndexError for index 7 and size 3, but got %v", err)
+	}
+	if err.Error() != linkList.ErrIndexOutOfBound {
+		t.Errorf(errExpectedNodeValue, linkList.ErrIndexOutOfBound, err.Error())
+	}
+
+	if _, err := list.Find(42); !errors.Is(err, linkList.ErrNotFound) {
+		t.Errorf(errExpectedYesError, err)
+	}
+	if err := list.ForRange(2, 1, func(*int) {}); !errors.Is(err, linkList.ErrInvalidRange) {
+		t.Errorf(errExpectedYesError, err)
+	}
+}
+
+func checkList(t *testing.T, list *linkList.LinkList[int], expected []int) {
+	t.Helper()
+	if list.Size() != uint64(len(expected)) {
+		t.Fatalf(errExpectedItems, len(expected), list.Size())
+	}
+	slice := list.ToSlice()
+	if len(slice) != len(expected) {
+		t.Fatalf(errExpectedSliceLength, len(expected), len(slice))
+	}
+	for i, v := range expected {
+		if slice[i] != v {
+			t.Fatalf(errExpectedSliceElem, i, v, slice[i])
+		}
+	}
+}
+
+func TestSplitAt(t *testing.T) {
+	tests := []struct {
+		index       uint64
+		left, right []int
+	}{
+		{0, nil, []int{1, 2, 3, 4}},
+		{1, []int{1}, []int{2, 3, 4}},
+		{3, []int{1, 2, 3}, []int{4}},
+		{4, []int{1, 2, 3, 4}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("index %d", tt.index), func(t *testing.T) {
+			list := linkList.NewFromSlice([]int{1, 2, 3, 4})
+			left, right, err := list.SplitAt(tt.index)
+			if err != nil {
+				t.Fatalf(errExpectedNoError, err)
+			}
+			checkList(t, left, tt.left)
+			checkList(t, right, tt.right)
+			if !list.IsEmpty() || list.Size() != 0 {
+				t.Fatalf(errListNotEmpty)
+			}
+		})
+	}
+
+	list := linkList.NewFromSlice([]int{1, 2})
+	_, _, err := list.SplitAt(3)
+	if !errors.Is(err, linkList.ErrOutOfBounds) {
+		t.Fatalf(errExpectedYesError, err)
+	}
+	checkList(t, list, []int{1, 2})
+}
+
+func TestSplice(t *testing.T) {
+	tests := []struct {
+		index    uint64
+		expected []int
+	}{
+		{0, []int{8, 9, 1, 2, 3}},
+		{1, []int{1, 8, 9, 2, 3}},
+		{3, []int{1, 2, 3, 8, 9}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("index %d", tt.index), func(t *testing.T) {
+			list := linkList.NewFromSlice([]int{1, 2, 3})
+			other := linkList.NewFromSlice([]int{8, 9})
+			if err := list.Splice(tt.index, other); err != nil {
+				t.Fatalf(errExpectedNoError, err)
+			}
+			checkList(t, list, tt.expected)
+			if !other.IsEmpty() {
+				t.Fatalf(errListNotEmpty)
+			}
+		})
+	}
+
+	// Into an empty list, and with an empty list
+	list := linkList.New[int]()
+	if err := list.Splice(0, linkList.NewFromSlice([]int{1, 2})); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	checkList(t, list, []int{1, 2})
+	if err := list.Splice(1, linkList.New[int]()); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	checkList(t, list, []int{1, 2})
+
+	if err := list.Splice(3, linkList.NewFromSlice([]int{5})); !errors.Is(err, linkList.ErrOutOfBounds) {
+		t.Fatalf(errExpectedYesError, err)
+	}
+	if err := list.Splice(0, list); !errors.Is(err, linkList.ErrSameList) {
+		t.Fatalf(errExpectedYesError, err)
+	}
+	checkList(t, list, []int{1, 2})
+}
+
+func TestSplitAtAndSplice(t *testing.T) {
+	list := linkList.New[int]()
+	for i := 0; i < 100; i++ {
+		list.Append(i)
+	}
+	left, right, err := list.SplitAt(50)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if err := left.Splice(left.Size(), right); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if left.Size() != 100 {
+		t.Fatalf(errExpectedItems, 100, left.Size())
+	}
+	for i, v := range left.ToSlice() {
+		if v != i {
+			t.Fatalf(errExpectedSliceElem, i, i, v)
+		}
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	l := linkList.NewWithComparator(slices.Equal[[]int])
+	l.Append([]int{1})
+	l.Append([]int{2, 3})
+	l.Append([]int{4})
+
+	if !l.Contains([]int{2, 3}) {
+		t.Error("expected list to contain [2 3]")
+	}
+	if index, err := l.IndexOf([]int{4}); err != nil || index != 2 {
+		t.Errorf("expected index 2, got %v (%v)", index, err)
+	}
+	if _, err := l.Find([]int{3, 2}); err == nil {
+		t.Error("expected an error for a missing value")
+	}
+
+	l.DeleteWithValue([]int{2, 3})
+	if l.Size() != 2 || l.Contains([]int{2, 3}) {
+		t.Errorf("expected [2 3] to be deleted, got size %d", l.Size())
+	}
+
+	c := l.Copy()
+	if !c.Contains([]int{4}) {
+		t.Error("expected the copy to keep the comparator")
+	}
+}
+
+func TestNewWithHasher(t *testing.T) {
+	hasher := common.NewHasher(func(v []int) uint64 { return uint64(len(v)) }, slices.Equal[[]int])
+	l := linkList.NewWithHasher(hasher)
+	for _, v := range [][]int{{1}, {2, 3}, {1}, {4}, {2, 3}} {
+		l.Append(v)
+	}
+
+	if !l.Contains([]int{4}) || l.Contains([]int{3, 2}) {
+		t.Error("expected the hasher equality to be used")
+	}
+	if !l.ContainsAll([]int{4}, []int{1}, []int{2, 3}) || l.ContainsAll([]int{1}, []int{5}) {
+		t.Error("expected ContainsAll to use the hasher")
+	}
+	if !l.ContainsAny([]int{5}, []int{2, 3}) || l.ContainsAny([]int{5}, []int{3, 2}) {
+		t.Error("expected ContainsAny to use the hasher")
+	}
+
+	c := l.Copy()
+	c.Deduplicate()
+	if c.Size() != 3 || l.Size() != 5 {
+		t.Errorf("expected 3 values left in the copy, got %d (original %d)", c.Size(), l.Size())
+	}
+	if !linkList.Features().Has(common.FeatureHasher) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureHasher, linkList.Features())
+	}
+}
+
+func TestReduceInto(t *testing.T) {
+	l := linkList.New[int]()
+	l.Append(1)
+	l.Append(2)
+	l.Append(3)
+	got := linkList.ReduceInto(l, []int{0}, func(acc []int, v int) []int {
+		return append(acc, v*10)
+	})
+	if !slices.Equal(got, []int{0, 10, 20, 30}) {
+		t.Errorf("expected [0 10 20 30], got %v", got)
+	}
+
+	if got := linkList.ReduceInto(linkList.New[int](), 42, func(acc int, _ int) int { return acc + 1 }); got != 42 {
+		t.Errorf("expected 42, got %d", got)
+	}
+}
+
+func TestMapTo(t *testing.T) {
+	type user struct {
+		id   int
+		tags []string
+	}
+	src := linkList.NewWithComparator(func(a, b user) bool { return a.id == b.id })
+	for _, u := range []user{{1, []string{"a"}}, {2, nil}, {3, []string{"b", "c"}}} {
+		src.Append(u)
+	}
+
+	ids := linkList.MapTo(src, func(u user) int { return u.id })
+	if !slices.Equal(ids.ToSlice(), []int{1, 2, 3}) || !ids.Contains(2) {
+		t.Errorf("expected the ids [1 2 3], got %v", ids.ToSlice())
+	}
+
+	parity := linkList.MapTo(src, func(u user) int { return u.id % 2 })
+	parity.Deduplicate()
+	if !slices.Equal(parity.ToSlice(), []int{1, 0}) {
+		t.Errorf("expected the deduplicated parities [1 0], got %v", parity.ToSlice())
+	}
+
+	tags := linkList.MapToWithComparator(src, func(u user) []string { return u.tags }, slices.Equal[[]string])
+	if tags.Size() != 3 || !tags.ContainsAll([]string{"b", "c"}) || tags.ContainsAll([]string{"c", "b"}) {
+		t.Errorf("expected the tags to be compared with the given function")
+	}
+
+	if empty := linkList.MapTo(linkList.New[int](), strconv.Itoa); !empty.IsEmpty() {
+		t.Errorf("expected an empty result, got %d values", empty.Size())
+	}
+}
+
+func TestReduceRight(t *testing.T) {
+	l := linkList.New[int]()
+	l.Append(1)
+	l.Append(2)
+	l.Append(3)
+	got := linkList.ReduceRight(l, []int{0}, func(acc []int, v int) []int {
+		return append(acc, v*10)
+	})
+	if !slices.Equal(got, []int{0, 30, 20, 10}) {
+		t.Errorf("expected [0 30 20 10], got %v", got)
+	}
+
+	// A right fold of a non-associative function
+	if got := linkList.ReduceRight(l, "", func(acc string, v int) string { return "(" + strconv.Itoa(v) + acc + ")" }); got != "(1(2(3)))" {
+		t.Errorf("expected (1(2(3))), got %s", got)
+	}
+	if got := linkList.ReduceRight(linkList.New[int](), 42, func(acc int, _ int) int { return acc + 1 }); got != 42 {
+		t.Errorf("expected 42, got %d", got)
+	}
+}
+
+func TestUniqueAndDeduplicate(t *testing.T) {
+	build := func(values ...int) *linkList.LinkList[int] {
+		l := linkList.New[int]()
+		for _, v := range values {
+			l.Append(v)
+		}
+		return l
+	}
+	values := func(l *linkList.LinkList[int]) []int {
+		return linkList.ReduceInto(l, []int(nil), func(acc []int, v int) []int { return append(acc, v) })
+	}
+
+	l := build(1, 1, 2, 2, 2, 3, 1, 1)
+	l.Unique()
+	if got := values(l); !slices.Equal(got, []int{1, 2, 3, 1}) || l.Size() != 4 {
+		t.Errorf("expected [1 2 3 1], got %v (size %d)", got, l.Size())
+	}
+
+	l = build(3, 1, 3, 2, 1, 4, 2, 2)
+	l.Deduplicate()
+	if got := values(l); !slices.Equal(got, []int{3, 1, 2, 4}) || l.Size() != 4 {
+		t.Errorf("expected [3 1 2 4], got %v (size %d)", got, l.Size())
+	}
+	l.Append(5)
+	if got := values(l); !slices.Equal(got, []int{3, 1, 2, 4, 5}) {
+		t.Errorf("expected [3 1 2 4 5] after Append, got %v", got)
+	}
+
+	custom := linkList.NewWithComparator(slices.Equal[[]int])
+	for _, v := range [][]int{{1}, {2}, {1}, {2}, {2}} {
+		custom.Append(v)
+	}
+	custom.Deduplicate()
+	if custom.Size() != 2 {
+		t.Errorf("expected size 2, got %d", custom.Size())
+	}
+
+	empty := linkList.New[int]()
+	empty.Unique()
+	empty.Deduplicate()
+	if empty.Size() != 0 {
+		t.Errorf("expected empty list, got size %d", empty.Size())
+	}
+}
+
+func TestString(t *testing.T) {
+	l := linkList.New[string]()
+	l.Append("a")
+	l.Append("b")
+
+	var s fmt.Stringer = l
+	if got := s.String(); got != "[a b]" {
+		t.Errorf("expected [a b], got %s", got)
+	}
+	if got := l.StringFunc(func(v string) string { return v + v }); got != "[aa bb]" {
+		t.Errorf("expected [aa bb], got %s", got)
+	}
+	if got := linkList.New[int]().String(); got != "[]" {
+		t.Errorf("expected [], got %s", got)
+	}
+}
+
+func TestForEachErr(t *testing.T) {
+	l := linkList.New[int]()
+	for i := 1; i <= 5; i++ {
+		l.Append(i)
+	}
+
+	errStop := fmt.Errorf("stop")
+	var visited []int
+	err := l.ForEachErr(func(v *int) error {
+		visited = append(visited, *v)
+		if *v == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if err != errStop || !slices.Equal(visited, []int{1, 2, 3}) {
+		t.Errorf("expected to stop at 3 with %v, got %v (%v)", errStop, visited, err)
+	}
+
+	if err := l.ForEachErr(func(v *int) error { *v *= 2; return nil }); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if !slices.Equal(l.ToSlice(), []int{2, 4, 6, 8, 10}) {
+		t.Errorf("expected [2 4 6 8 10], got %v", l.ToSlice())
+	}
+	if err := linkList.New[int]().ForEachErr(func(*int) error { return errStop }); err != nil {
+		t.Errorf("expected no error on an empty list, got %v", err)
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	c := linkList.NewFromSlice([]int{3, 1, 4, 1, 5})
+
+	if v, err := linkList.Min(c, func(a, b int) bool { return a < b }); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := linkList.Max(c, func(a, b int) bool { return a < b }); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v, err := linkList.MinOrdered(c); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := linkList.MaxOrdered(c); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v := linkList.Sum(c); v != 14 {
+		t.Errorf("expected sum 14, got %v", v)
+	}
+	if v, err := linkList.Average(c); err != nil || v != 2.8 {
+		t.Errorf("expected average 2.8, got %v (%v)", v, err)
+	}
+
+	e := linkList.New[int]()
+	if _, err := linkList.Min(e, func(a, b int) bool { return a < b }); err != linkList.ErrEmpty {
+		t.Errorf("expected %v, got %v", linkList.ErrEmpty, err)
+	}
+	if _, err := linkList.Average(e); err != linkList.ErrEmpty {
+		t.Errorf("expected %v, got %v", linkList.ErrEmpty, err)
+	}
+	if v := linkList.Sum(e); v != 0 {
+		t.Errorf("expected sum 0, got %v", v)
+	}
+}
+
+func TestNewWithPool(t *testing.T) {
+	list := linkList.NewWithPool[int]()
+	for i := 0; i < 10; i++ {
+		list.Append(i)
+	}
+	list.Filter(func(v int) bool { return v%2 == 0 })
+	list.DeleteWithValue(0)
+	if err := list.DeleteAt(0); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	list.Prepend(1)
+	list.Append(3)
+	if got := list.ToSlice(); !slices.Equal(got, []int{1, 4, 6, 8, 3}) {
+		t.Errorf("Expected [1 4 6 8 3], but got %v", got)
+	}
+
+	list.Clear()
+	if !list.IsEmpty() {
+		t.Error(errListNotEmpty)
+	}
+
+	// Once the pool holds some nodes, a delete/insert cycle doesn't allocate
+	list.Prepend(1)
+	list.Prepend(2)
+	list.DeleteWithValue(2)
+	allocs := testing.AllocsPerRun(100, func() {
+		list.Prepend(2)
+		_ = list.DeleteAt(0)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations, but got %v", allocs)
+	}
+	if got := list.ToSlice(); !slices.Equal(got, []int{1}) {
+		t.Errorf("Expected [1], but got %v", got)
+	}
+}
+
+func TestNewWithPoolMovedNodes(t *testing.T) {
+	list := linkList.NewWithPool[int]()
+	for i := 0; i < 4; i++ {
+		list.Append(i)
+	}
+	// The nodes moved to another list must not be released to the pool
+	left, right, err := list.SplitAt(2)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	other := linkList.NewWithPool[int]()
+	other.Append(9)
+	if err := left.Splice(1, other); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	list.Append(5)
+	other.Append(6)
+	if got := left.ToSlice(); !slices.Equal(got, []int{0, 9, 1}) {
+		t.Errorf("Expected [0 9 1], but got %v", got)
+	}
+	if got := right.ToSlice(); !slices.Equal(got, []int{2, 3}) {
+		t.Errorf("Expected [2 3], but got %v", got)
+	}
+}
+
+// benchmarkChurn prepends and deletes values at the head of a list of 1000 values
+func benchmarkChurn(b *testing.B, list *linkList.LinkList[int]) {
+	for i := 0; i < 1000; i++ {
+		list.Prepend(i)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list.Prepend(i)
+		_ = list.DeleteAt(0)
+	}
+}
+
+func BenchmarkChurn(b *testing.B) {
+	benchmarkChurn(b, linkList.New[int]())
+}
+
+func BenchmarkChurnWithPool(b *testing.B) {
+	benchmarkChurn(b, linkList.NewWithPool[int]())
+}
+
+func TestCheckInvariants(t *testing.T) {
+	list := linkList.NewFromSlice([]int{1, 2, 3})
+	if err := list.CheckInvariants(); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+
+	head, tail := list.GetFirst(), list.GetLast()
+	linkList.SetEnds(list, head.Next(), tail) // drop the first node behind the list's back
+	if err := list.CheckInvariants(); !errors.Is(err, linkList.ErrCorrupted) {
+		t.Errorf("Expected %v, but got %v", linkList.ErrCorrupted, err)
+	}
+	linkList.SetEnds(list, head, head.Next()) // the tail is no longer the last node
+	if err := list.CheckInvariants(); !errors.Is(err, linkList.ErrCorrupted) {
+		t.Errorf("Expected %v, but got %v", linkList.ErrCorrupted, err)
+	}
+}
+
+// FuzzInvariants applies a sequence of operations (one per input byte) and
+// checks the invariants of the lists after each of them
+func FuzzInvariants(f *testing.F) {
+	f.Add([]byte{0, 0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11})
+	f.Add([]byte{0, 0, 0, 3, 9, 0, 0, 9, 4, 4, 1, 8})
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		list, other := linkList.New[int](), linkList.New[int]()
+		for i, op := range ops {
+			v := int(op >> 4)
+			index := uint64(op>>4) % (list.Size() + 1)
+			switch op % 12 {
+			case 0:
+				list.Append(v)
+			case 1:
+				list.Prepend(v)
+			case 2:
+				_ = list.InsertAt(index, v)
+			case 3:
+				_ = list.DeleteAt(index)
+			case 4:
+				list.DeleteWithValue(v)
+			case 5:
+				list.Filter(func(x int) bool { return x%2 == 0 })
+			case 6:
+				list.Unique()
+			case 7:
+				list.Deduplicate()
+			case 8:
+				other.Append(v)
+				list.Merge(other)
+			case 9:
+				left, right, err := list.SplitAt(index)
+				if err == nil {
+					list = left
+					other = right
+				}
+			case 10:
+				_ = list.Splice(index, other)
+			case 11:
+				list.Reverse()
+				list.Sort(func(a, b int) bool { return a < b })
+			}
+			for _, l := range []*linkList.LinkList[int]{list, other} {
+				if err := l.CheckInvariants(); err != nil {
+					t.Fatalf("after operation %d (%d): %v", i, op%12, err)
+				}
+			}
+		}
+	})
+}
+
+func TestChunkWindows(t *testing.T) {
+	list := linkList.NewFromSlice([]int{1, 2, 3, 4, 5})
+
+	chunks := list.Chunk(2)
+	expected := [][]int{{1, 2}, {3, 4}, {5}}
+	if len(chunks) != len(expected) {
+		t.Fatalf("expected %d chunks, got %d", len(expected), len(chunks))
+	}
+	for i, c := range chunks {
+		if !slices.Equal(c.ToSlice(), expected[i]) {
+			t.Errorf("expected chunk %d to be %v, got %v", i, expected[i], c.ToSlice())
+		}
+		if err := c.CheckInvariants(); err != nil {
+			t.Error(err)
+		}
+	}
+
+	windows := list.Windows(3)
+	expected = [][]int{{1, 2, 3}, {2, 3, 4}, {3, 4, 5}}
+	if len(windows) != len(expected) {
+		t.Fatalf("expected %d windows, got %d", len(expected), len(windows))
+	}
+	for i, w := range windows {
+		if !slices.Equal(w.ToSlice(), expected[i]) {
+			t.Errorf("expected window %d to be %v, got %v", i, expected[i], w.ToSlice())
+		}
+	}
+
+	// The chunks are copies
+	chunks[0].Clear()
+	if list.Size() != 5 {
+		t.Errorf("expected size 5, got %d", list.Size())
+	}
+	if list.Chunk(0) != nil || list.Windows(0) != nil || list.Windows(6) != nil {
+		t.Error("expected nil for invalid sizes")
+	}
+}
+
+func TestJoinFlatten(t *testing.T) {
+	list := linkList.NewFromSlice([]int{1, 2, 3, 4, 5})
+	chunks := list.Chunk(2)
+
+	joined := linkList.Join(append(chunks, nil)...)
+	if !slices.Equal(joined.ToSlice(), []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected [1 2 3 4 5], got %v", joined.ToSlice())
+	}
+
+	nested := linkList.NewFromSlice(chunks)
+	flat := linkList.Flatten(nested)
+	if !slices.Equal(flat.ToSlice(), []int{1, 2, 3, 4, 5}) {
+		t.Errorf("expected [1 2 3 4 5], got %v", flat.ToSlice())
+	}
+	if !linkList.Join[int]().IsEmpty() {
+		t.Error("expected an empty result joining nothing")
+	}
+
+	// The result compares its values like the first part
+	a, b := linkList.NewWithComparator(slices.Equal[[]int]), linkList.NewWithComparator(slices.Equal[[]int])
+	a.Append([]int{1})
+	a.Append([]int{2})
+	b.Append([]int{3})
+	if all := linkList.Join(nil, a, b); all.Size() != 3 || !all.Contains([]int{3}) {
+		t.Errorf("expected 3 values compared with slices.Equal, got %v", all.ToSlice())
+	}
+}
+
+func TestIndexed(t *testing.T) {
+	list := linkList.NewFromSlice([]int{10, 20, 30})
+	list.ForEachIndexed(func(i uint64, v *int) {
+		*v += int(i)
+	})
+	if !slices.Equal(list.ToSlice(), []int{10, 21, 32}) {
+		t.Errorf("expected [10 21 32], got %v", list.ToSlice())
+	}
+
+	mapped := list.MapIndexed(func(i uint64, v int) int { return v * int(i) })
+	if !slices.Equal(mapped.ToSlice(), []int{0, 21, 64}) {
+		t.Errorf("expected [0 21 64], got %v", mapped.ToSlice())
+	}
+	if err := mapped.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := linkList.New[*int]()
+	c.Append(&a)
+	c.Append(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestRemoveIf(t *testing.T) {
+	c := linkList.NewFromSlice([]int{1, 2, 1, 3, 1, 2})
+
+	if n := c.RemoveFirstN(1, 2); n != 2 || !slices.Equal(c.ToSlice(), []int{2, 3, 1, 2}) {
+		t.Errorf("expected to remove 2 elements leaving 2, 3, 1, 2}, removed %d leaving %v", n, c.ToSlice())
+	}
+	if n := c.RemoveAll(2); n != 2 || !slices.Equal(c.ToSlice(), []int{3, 1}) {
+		t.Errorf("expected to remove 2 elements leaving 3, 1}, removed %d leaving %v", n, c.ToSlice())
+	}
+	if n := c.RemoveFirstN(1, 5); n != 1 {
+		t.Errorf("expected to remove 1 element, removed %d", n)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v > 10 }); n != 0 {
+		t.Errorf("expected to remove nothing, removed %d", n)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v == 3 }); n != 1 || c.Size() != 0 {
+		t.Errorf("expected to remove the last element, removed %d leaving %v", n, c.ToSlice())
+	}
+}
+
+func TestForEachSafe(t *testing.T) {
+	c := linkList.NewFromSlice([]int{1, 2, 3, 4, 5, 6})
+
+	var visited []int
+	c.ForEachSafe(func(v int) bool {
+		visited = append(visited, v)
+		return v%2 == 1 || v == 6
+	})
+	if !slices.Equal(visited, []int{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("expected to visit [1 2 3 4 5 6], got %v", visited)
+	}
+	if !slices.Equal(c.ToSlice(), []int{2, 4}) {
+		t.Errorf("expected [2 4], got %v", c.ToSlice())
+	}
+	if err := c.CheckInvariants(); err != nil {
+		t.Error(err)
+	}
+
+	c.ForEachSafe(func(int) bool { return true })
+	if !c.IsEmpty() {
+		t.Errorf("expected an empty list, got %v", c.ToSlice())
+	}
+}
+
+func TestContainsAllAny(t *testing.T) {
+	c := linkList.NewFromSlice([]int{1, 2, 1, 3})
+
+	if !c.ContainsAll(3, 1, 1) || !c.ContainsAll() || c.ContainsAll(1, 4) {
+		t.Error("expected ContainsAll to be true only when every element is present")
+	}
+	if !c.ContainsAny(4, 2) || c.ContainsAny(4, 5) || c.ContainsAny() {
+		t.Error("expected ContainsAny to be true only when an element is present")
+	}
+	if c.CountOf(1) != 2 || c.CountOf(3) != 1 || c.CountOf(4) != 0 {
+		t.Errorf("expected counts 2, 1 and 0, got %d, %d and %d", c.CountOf(1), c.CountOf(3), c.CountOf(4))
+	}
+}
+
+func TestContainsAllComparator(t *testing.T) {
+	c := linkList.NewWithComparator(func(a, b []int) bool { return slices.Equal(a, b) })
+	c.Append([]int{1})
+	c.Append([]int{2, 3})
+	if !c.ContainsAll([]int{2, 3}, []int{1}) || c.ContainsAll([]int{1}, []int{2}) {
+		t.Error("expected ContainsAll to compare the elements with the comparator")
+	}
+	if !c.ContainsAny([]int{4}, []int{1}) || c.CountOf([]int{2, 3}) != 1 {
+		t.Error("expected ContainsAny and CountOf to compare the elements with the comparator")
+	}
+}
+
+func TestBoundaryIndexes(t *testing.T) {
+	for size := 0; size <= 3; size++ {
+		items := make([]int, size)
+		for i := range items {
+			items[i] = i + 1
+		}
+		check := func(op string, index int, list *linkList.LinkList[int], err error, valid bool) {
+			t.Helper()
+			if valid && err != nil {
+				t.Errorf("size %d, %s(%d): "+errExpectedNoError, size, op, index, err)
+			}
+			if !valid && !errors.Is(err, linkList.ErrOutOfBounds) {
+				t.Errorf("size %d, %s(%d): "+errExpectedYesError, size, op, index, err)
+			}
+			if err := list.CheckInvariants(); err != nil {
+				t.Errorf("size %d, %s(%d): %v", size, op, index, err)
+			}
+		}
+
+		for index := 0; index <= size+1; index++ {
+			list := linkList.NewFromSlice(items)
+			_, err := list.GetAt(uint64(index))
+			check("GetAt", index, list, err, index < size)
+
+			list = linkList.NewFromSlice(items)
+			err = list.DeleteAt(uint64(index))
+			check("DeleteAt", index, list, err, index < size)
+			if err == nil && !slices.Equal(list.ToSlice(), slices.Delete(slices.Clone(items), index, index+1)) {
+				t.Errorf("size %d, DeleteAt(%d): got %v", size, index, list.ToSlice())
+			}
+
+			list = linkList.NewFromSlice(items)
+			err = list.InsertAt(uint64(index), 0)
+			check("InsertAt", index, list, err, index <= size)
+			if err == nil && !slices.Equal(list.ToSlice(), slices.Insert(slices.Clone(items), index, 0)) {
+				t.Errorf("size %d, InsertAt(%d): got %v", size, index, list.ToSlice())
+			}
+
+			list = linkList.NewFromSlice(items)
+			left, right, err := list.SplitAt(uint64(index))
+			check("SplitAt", index, list, err, index <= size)
+			if err == nil {
+				check("SplitAt", index, left, nil, true)
+				check("SplitAt", index, right, nil, true)
+			}
+
+			list = linkList.NewFromSlice(items)
+			err = list.Splice(uint64(index), linkList.NewFromSlice([]int{0}))
+			check("Splice", index, list, err, index <= size)
+		}
+	}
+}
+
+func TestDeleteAtSingleElement(t *testing.T) {
+	list := linkList.NewFromSlice([]int{1})
+	if err := list.DeleteAt(0); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if list.GetFirst() != nil || !list.IsEmpty() {
+		t.Errorf("expected the head to be nil, got %v", list.GetFirst())
+	}
+	if err := list.DeleteAt(0); !errors.Is(err, linkList.ErrOutOfBounds) {
+		t.Errorf(errExpectedYesError, err)
+	}
+
+	// The list must still be usable
+	list.Append(2)
+	checkList(t, list, []int{2})
+}
+
+func TestTail(t *testing.T) {
+	list := linkList.New[int]()
+	checkTail := func(expected int) {
+		t.Helper()
+		if err := list.CheckInvariants(); err != nil {
+			t.Fatal(err)
+		}
+		if list.GetLast() == nil || list.GetLast().Value() != expected {
+			t.Fatalf("Expected the last node to be %d, but got %v", expected, list.GetLast())
+		}
+	}
+
+	list.Prepend(1)
+	checkTail(1)
+	list.Append(2)
+	checkTail(2)
+	_ = list.InsertAt(2, 3)
+	checkTail(3)
+	list.Reverse()
+	checkTail(1)
+	list.Sort(func(a, b int) bool { return a < b })
+	checkTail(3)
+	_ = list.DeleteAt(2)
+	checkTail(2)
+	_ = list.Splice(list.Size(), linkList.NewFromSlice([]int{4, 5}))
+	checkTail(5)
+	list.Filter(func(v int) bool { return v != 5 })
+	checkTail(4)
+	list.DeleteWithValue(4)
+	checkTail(2)
+
+	left, right, _ := list.SplitAt(1)
+	if left.GetLast().Value() != 1 || right.GetLast().Value() != 2 || left.CheckInvariants() != nil || right.CheckInvariants() != nil {
+		t.Errorf("Expected the tails of the halves to be 1 and 2, but got %v and %v", left.GetLast(), right.GetLast())
+	}
+	if list.GetLast() != nil {
+		t.Errorf("Expected the split list to have no tail, but got %v", list.GetLast())
+	}
+
+	right.Clear()
+	if right.GetLast() != nil || right.CheckInvariants() != nil {
+		t.Error("Expected a cleared list to have no tail")
+	}
+}
+
+// BenchmarkAppend1M builds a list of 1M values with Append, which is O(1)
+// thanks to the Tail pointer (it used to walk the whole list)
+func BenchmarkAppend1M(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		list := linkList.New[int]()
+		for v := 0; v < 1_000_000; v++ {
+			list.Append(v)
+		}
+	}
+}
+
+func TestWipe(t *testing.T) {
+	list := linkList.New[string]()
+	for _, v := range []string{"key", "token", "secret"} {
+		list.Append(v)
+	}
+	first, _ := list.GetAt(0)
+	last := list.GetLast()
+
+	list.Wipe()
+	if !list.IsEmpty() || list.GetLast() != nil {
+		t.Errorf("Expected an empty list, got %v", list.ToSlice())
+	}
+	for _, node := range []*linkList.Node[string]{first, last} {
+		if node.Value() != "" || node.Next() != nil {
+			t.Errorf("Expected a wiped and unlinked node, got %q", node.Value())
+		}
+	}
+}
+
+func TestCtxVariants(t *testing.T) {
+	ctxtest.Run[*linkList.LinkList[int], *linkList.Node[int]](t, linkList.New[int], linkList.ErrNotFound)
+}
+
+func TestJSONStream(t *testing.T) {
+	if !linkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, linkList.Features())
+	}
+	if !linkList.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, linkList.Features())
+	}
+	l := linkList.New[int]()
+	for _, v := range []int{1, 2, 3} {
+		l.Append(v)
+	}
+
+	var sb strings.Builder
+	if err := l.EncodeJSONStream(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sb.String() != "[1,2,3]" {
+		t.Errorf("expected [1,2,3], got %s", sb.String())
+	}
+
+	got := linkList.New[int]()
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader(sb.String()))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader("[4] null"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got.ToSlice(), []int{1, 2, 3, 4}) {
+		t.Errorf("expected [1 2 3 4], got %v", got.ToSlice())
+	}
+
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader(`{"a": 5}`))); !errors.Is(err, common.ErrNotJSONArray) {
+		t.Errorf("expected %v, got %v", common.ErrNotJSONArray, err)
+	}
+
+	var empty strings.Builder
+	if err := linkList.New[int]().EncodeJSONStream(&empty); err != nil || empty.String() != "[]" {
+		t.Errorf("expected [], got %s (%v)", empty.String(), err)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *linkList.LinkList[int] {
+		l := linkList.New[int]()
+		for _, v := range values {
+			l.Append(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	// The result is normalized to -1, 0 or +1
+	if got := build(1).Compare(build(10), func(a, b int) int { return a - b }); got != -1 {
+		t.Errorf("expected -1, got %d", got)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package load provides helpers to fill a Buffer from CSV records or text
+// lines, and to write a Buffer back in the same formats, so the parsing glue
+// doesn't have to be written again for every project.
+package load
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+)
+
+// Sentinel errors of the loaders (use errors.Is to check for them)
+var (
+	// ErrSkip can be returned by a parse function to skip a record or a line
+	// (a header, a comment, ...) without stopping the loader
+	ErrSkip = errors.New("skip")
+	// ErrParse wraps the errors returned by a parse function, together with
+	// the number of the line that failed
+	ErrParse = errors.New("parse error")
+)
+
+// FromCSV reads the CSV records from r (see encoding/csv, every record must
+// have the same number of fields) and returns a new Buffer with the element
+// parse returns for each of them
+func FromCSV[T comparable](r io.Reader, parse func(record []string) (T, error)) (*buffer.Buffer[T], error) {
+	return fromCSV(buffer.New[T](), r, parse)
+}
+
+// FromCSVWithComparator is like FromCSV, the new Buffer compares its elements
+// with the given function, so T doesn't have to be comparable
+func FromCSVWithComparator[T any](r io.Reader, parse func(record []string) (T, error), equals func(a, b T) bool) (*buffer.Buffer[T], error) {
+	return fromCSV(buffer.NewWithComparator(equals), r, parse)
+}
+
+func fromCSV[T any](b *buffer.Buffer[T], r io.Reader, parse func(record []string) (T, error)) (*buffer.Buffer[T], error) {
+	cr := csv.NewReader(r)
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return b, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := parse(record)
+		if errors.Is(err, ErrSkip) {
+			continue
+		}
+		if err != nil {
+			line, _ := cr.FieldPos(0)
+			return nil, fmt.Errorf("%w at line %d: %w", ErrParse, line, err)
+		}
+		if err := b.Append(v); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// FromLines reads the lines of text from r (ending with "\n" or "\r\n", of
+// any length) and returns a new Buffer with the element parse returns for each
+// of them. Empty lines are passed to parse too, it can return ErrSkip for them
+func FromLines[T comparable](r io.Reader, parse func(line string) (T, error)) (*buffer.Buffer[T], error) {
+	return fromLines(buffer.New[T](), r, parse)
+}
+
+// FromLinesWithComparator is like FromLines, the new Buffer compares its
+// elements with the given function, so T doesn't have to be comparable
+func FromLinesWithComparator[T any](r io.Reader, parse func(line string) (T, error), equals func(a, b T) bool) (*buffer.Buffer[T], error) {
+	return fromLines(buffer.NewWithComparator(equals), r, parse)
+}
+
+func fromLines[T any](b *buffer.Buffer[T], r io.Reader, parse func(line string) (T, error)) (*buffer.Buffer[T], error) {
+	br := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if err == io.EOF && line == "" {
+			return b, nil
+		}
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		v, perr := parse(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		switch {
+		case errors.Is(perr, ErrSkip):
+		case perr != nil:
+			return nil, fmt.Errorf("%w at line %d: %w", ErrParse, n, perr)
+		default:
+			if aerr := b.Append(v); aerr != nil {
+				return nil, aerr
+			}
+		}
+
+		// The last line has no "\n"
+		if err == io.EOF {
+			return b, nil
+		}
+	}
+}
+
+// ToCSV writes the elements of b to w as CSV records, one per element as
+// returned by format (the reverse of FromCSV). An empty buffer writes nothing
+func ToCSV[T any](w io.Writer, b *buffer.Buffer[T], format func(v T) ([]string, error)) error {
+	cw := csv.NewWriter(w)
+	// ForEach returns ErrEmpty for an empty buffer
+	if !b.IsEmpty() {
+		err := b.ForEach(func(v *T) error {
+			record, err := format(*v)
+			if err != nil {
+				return err
+			}
+			return cw.Write(record)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ToLines writes the elements of b to w as lines of text, one per element as
+// returned by format and ending with "\n" (the reverse of FromLines). An empty
+// buffer writes nothing
+func ToLines[T any](w io.Writer, b *buffer.Buffer[T], format func(v T) (string, error)) error {
+	bw := bufio.NewWriter(w)
+	// ForEach returns ErrEmpty for an empty buffer
+	if !b.IsEmpty() {
+		err := b.ForEach(func(v *T) error {
+			line, err := format(*v)
+			if err != nil {
+				return err
+			}
+			if _, err := bw.WriteString(line); err != nil {
+				return err
+			}
+			return bw.WriteByte('\n')
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
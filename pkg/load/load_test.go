@@ -0,0 +1,171 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package load_test
+
+import (
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	load "github.com/pzaino/gods/pkg/load"
+)
+
+const errUnexpectedErr = "unexpected error: %v"
+
+type user struct {
+	ID   int
+	Name string
+}
+
+func parseUser(record []string) (user, error) {
+	if record[0] == "id" {
+		return user{}, load.ErrSkip
+	}
+	id, err := strconv.Atoi(record[0])
+	return user{id, record[1]}, err
+}
+
+func formatUser(u user) ([]string, error) {
+	return []string{strconv.Itoa(u.ID), u.Name}, nil
+}
+
+func TestCSV(t *testing.T) {
+	in := "id,name\n1,alice\n2,\"bob, jr\"\n"
+	b, err := load.FromCSV(strings.NewReader(in), parseUser)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	want := []user{{1, "alice"}, {2, "bob, jr"}}
+	if !slices.Equal(b.ToSlice(), want) {
+		t.Errorf("expected %v, got %v", want, b.ToSlice())
+	}
+
+	var sb strings.Builder
+	if err := load.ToCSV(&sb, b, formatUser); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if sb.String() != "1,alice\n2,\"bob, jr\"\n" {
+		t.Errorf("unexpected CSV output %q", sb.String())
+	}
+
+	_, err = load.FromCSV(strings.NewReader("id,name\n1,alice\nx,bob\n"), parseUser)
+	if !errors.Is(err, load.ErrParse) || !errors.Is(err, strconv.ErrSyntax) || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected a parse error at line 3, got %v", err)
+	}
+	if _, err := load.FromCSV(strings.NewReader("1,alice\n2\n"), parseUser); err == nil {
+		t.Error("expected an error for a record with a missing field")
+	}
+}
+
+func TestLines(t *testing.T) {
+	parse := func(line string) (int, error) {
+		if line == "" || strings.HasPrefix(line, "#") {
+			return 0, load.ErrSkip
+		}
+		return strconv.Atoi(line)
+	}
+
+	tests := []struct {
+		in   string
+		want []int
+	}{
+		{"1\n2\n3\n", []int{1, 2, 3}},
+		{"1\r\n2\r\n3", []int{1, 2, 3}},
+		{"# numbers\n\n1\n\n2\n", []int{1, 2}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		b, err := load.FromLines(strings.NewReader(tt.in), parse)
+		if err != nil {
+			t.Fatalf(errUnexpectedErr, err)
+		}
+		if !slices.Equal(b.ToSlice(), tt.want) {
+			t.Errorf("%q: expected %v, got %v", tt.in, tt.want, b.ToSlice())
+		}
+	}
+
+	// Lines longer than the default bufio buffer
+	long := strings.Repeat("9", 100000)
+	b, err := load.FromLines(strings.NewReader(long+"\n"), func(line string) (string, error) { return line, nil })
+	if err != nil || b.Size() != 1 {
+		t.Fatalf("expected a single long line, got %d lines (%v)", b.Size(), err)
+	}
+
+	_, err = load.FromLines(strings.NewReader("1\ntwo\n"), parse)
+	if !errors.Is(err, load.ErrParse) || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected a parse error at line 2, got %v", err)
+	}
+}
+
+func TestToLines(t *testing.T) {
+	b := buffer.New[int]()
+	for i := 1; i <= 3; i++ {
+		_ = b.Append(i)
+	}
+
+	var sb strings.Builder
+	if err := load.ToLines(&sb, b, func(v int) (string, error) { return strconv.Itoa(v), nil }); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if sb.String() != "1\n2\n3\n" {
+		t.Errorf("unexpected output %q", sb.String())
+	}
+
+	var empty strings.Builder
+	if err := load.ToLines(&empty, buffer.New[int](), func(v int) (string, error) { return strconv.Itoa(v), nil }); err != nil || empty.Len() != 0 {
+		t.Errorf("expected no output and no error for an empty buffer, got %q (%v)", empty.String(), err)
+	}
+	if err := load.ToCSV(&empty, buffer.New[user](), formatUser); err != nil || empty.Len() != 0 {
+		t.Errorf("expected no output and no error for an empty buffer, got %q (%v)", empty.String(), err)
+	}
+
+	errFormat := errors.New("format")
+	err := load.ToLines(&sb, b, func(int) (string, error) { return "", errFormat })
+	if !errors.Is(err, errFormat) {
+		t.Errorf("expected %v, got %v", errFormat, err)
+	}
+}
+
+func TestWithComparator(t *testing.T) {
+	type tagged struct {
+		ID   int
+		Tags []string
+	}
+	byID := func(a, b tagged) bool { return a.ID == b.ID }
+
+	b, err := load.FromCSVWithComparator(strings.NewReader("1,a b\n2,c\n"), func(record []string) (tagged, error) {
+		id, err := strconv.Atoi(record[0])
+		return tagged{id, strings.Fields(record[1])}, err
+	}, byID)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if b.Size() != 2 || !b.Contains(tagged{ID: 2}) {
+		t.Errorf("expected 2 records compared by ID, got %v", b.ToSlice())
+	}
+
+	l, err := load.FromLinesWithComparator(strings.NewReader("a b\n\nc\n"), func(line string) ([]string, error) {
+		return strings.Fields(line), nil
+	}, slices.Equal[[]string])
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if l.Size() != 3 || !l.Contains([]string{"c"}) {
+		t.Errorf("expected 3 lines compared with slices.Equal, got %v", l.ToSlice())
+	}
+}
@@ -0,0 +1,312 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package matrix provides a non-concurrent-safe 2D matrix stored in
+// row-major order in a flat Buffer (useful for image-like data).
+package matrix
+
+import (
+	"errors"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the Matrix methods (use errors.Is to check for them)
+var (
+	ErrInvalidSize   = errors.New("invalid matrix size")
+	ErrOutOfBounds   = errors.New("index out of bounds")
+	ErrSizeMismatch  = errors.New("matrix sizes do not match")
+	ErrInvalidRegion = errors.New("invalid region")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureDeepCopy)
+}
+
+// Matrix is a rows x cols matrix backed by a flat Buffer, the element at
+// (row, col) is stored at index row*cols + col
+type Matrix[T any] struct {
+	rows  uint64
+	cols  uint64
+	data  *buffer.Buffer[T]
+	alloc func(size uint64) *buffer.Buffer[T] // creates the buffers of new matrices
+}
+
+// New creates a new rows x cols matrix filled with zero values
+func New[T comparable](rows, cols uint64) (*Matrix[T], error) {
+	return newMatrix(rows, cols, buffer.NewWithSize[T])
+}
+
+// NewWithComparator creates a new rows x cols matrix filled with zero values
+// that compares its elements with the given function, so it can hold types
+// that are not comparable (slices, maps, ...)
+func NewWithComparator[T any](rows, cols uint64, equals func(a, b T) bool) (*Matrix[T], error) {
+	return newMatrix(rows, cols, func(size uint64) *buffer.Buffer[T] {
+		return buffer.AdoptWithComparator(make([]T, size), equals)
+	})
+}
+
+// newMatrix creates a new rows x cols matrix backed by a buffer made by alloc
+func newMatrix[T any](rows, cols uint64, alloc func(uint64) *buffer.Buffer[T]) (*Matrix[T], error) {
+	if rows == 0 || cols == 0 {
+		return nil, ErrInvalidSize
+	}
+	return &Matrix[T]{rows: rows, cols: cols, data: alloc(rows * cols), alloc: alloc}, nil
+}
+
+// newLike creates a new rows x cols matrix that compares its elements like m
+func (m *Matrix[T]) newLike(rows, cols uint64) *Matrix[T] {
+	result, _ := newMatrix(rows, cols, m.alloc)
+	return result
+}
+
+// NewFromSlice creates a new rows x cols matrix with a copy of the given
+// elements (in row-major order)
+func NewFromSlice[T comparable](rows, cols uint64, items []T) (*Matrix[T], error) {
+	m, err := New[T](rows, cols)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(items)) != rows*cols {
+		return nil, ErrSizeMismatch
+	}
+	copy(m.values(), items)
+	return m, nil
+}
+
+// NewFromBuffer creates a rows x cols matrix view over an existing buffer
+// (elements are not copied), the buffer must not be resized while the
+// matrix is in use
+func NewFromBuffer[T comparable](rows, cols uint64, b *buffer.Buffer[T]) (*Matrix[T], error) {
+	if rows == 0 || cols == 0 {
+		return nil, ErrInvalidSize
+	}
+	if b == nil || b.Size() != rows*cols {
+		return nil, ErrSizeMismatch
+	}
+	return &Matrix[T]{rows: rows, cols: cols, data: b, alloc: buffer.NewWithSize[T]}, nil
+}
+
+// values returns the backing slice of the matrix
+func (m *Matrix[T]) values() []T {
+	return m.data.UnsafeSlice()
+}
+
+// index returns the position of (row, col) in the backing buffer
+func (m *Matrix[T]) index(row, col uint64) (uint64, error) {
+	if row >= m.rows || col >= m.cols {
+		return 0, ErrOutOfBounds
+	}
+	return row*m.cols + col, nil
+}
+
+// Rows returns the number of rows of the matrix
+func (m *Matrix[T]) Rows() uint64 {
+	return m.rows
+}
+
+// Cols returns the number of columns of the matrix
+func (m *Matrix[T]) Cols() uint64 {
+	return m.cols
+}
+
+// Size returns the number of elements of the matrix (rows * cols)
+func (m *Matrix[T]) Size() uint64 {
+	return m.rows * m.cols
+}
+
+// Buffer returns the buffer backing the matrix (not a copy)
+func (m *Matrix[T]) Buffer() *buffer.Buffer[T] {
+	return m.data
+}
+
+// At returns the element at (row, col)
+func (m *Matrix[T]) At(row, col uint64) (T, error) {
+	i, err := m.index(row, col)
+	if err != nil {
+		var rVal T
+		return rVal, err
+	}
+	return m.values()[i], nil
+}
+
+// Set sets the element at (row, col)
+func (m *Matrix[T]) Set(row, col uint64, value T) error {
+	i, err := m.index(row, col)
+	if err != nil {
+		return err
+	}
+	m.values()[i] = value
+	return nil
+}
+
+// Row returns a copy of the given row
+func (m *Matrix[T]) Row(row uint64) ([]T, error) {
+	if row >= m.rows {
+		return nil, ErrOutOfBounds
+	}
+	items := make([]T, m.cols)
+	copy(items, m.values()[row*m.cols:(row+1)*m.cols])
+	return items, nil
+}
+
+// Col returns a copy of the given column
+func (m *Matrix[T]) Col(col uint64) ([]T, error) {
+	if col >= m.cols {
+		return nil, ErrOutOfBounds
+	}
+	values := m.values()
+	items := make([]T, m.rows)
+	for r := uint64(0); r < m.rows; r++ {
+		items[r] = values[r*m.cols+col]
+	}
+	return items, nil
+}
+
+// SubMatrix returns a copy of the rows x cols region starting at (row, col)
+func (m *Matrix[T]) SubMatrix(row, col, rows, cols uint64) (*Matrix[T], error) {
+	// row+rows could wrap around, so the sizes are checked against what's
+	// left of the matrix after (row, col)
+	if rows == 0 || cols == 0 || row >= m.rows || rows > m.rows-row || col >= m.cols || cols > m.cols-col {
+		return nil, ErrInvalidRegion
+	}
+
+	sub := m.newLike(rows, cols)
+	src, dst := m.values(), sub.values()
+	for r := uint64(0); r < rows; r++ {
+		start := (row+r)*m.cols + col
+		copy(dst[r*cols:(r+1)*cols], src[start:start+cols])
+	}
+	return sub, nil
+}
+
+// Transpose returns a new cols x rows matrix with the rows and columns swapped
+func (m *Matrix[T]) Transpose() *Matrix[T] {
+	t := m.newLike(m.cols, m.rows)
+	src, dst := m.values(), t.values()
+	for r := uint64(0); r < m.rows; r++ {
+		for c := uint64(0); c < m.cols; c++ {
+			dst[c*m.rows+r] = src[r*m.cols+c]
+		}
+	}
+	return t
+}
+
+// Map returns a new matrix with the results of applying the function to all the elements
+func (m *Matrix[T]) Map(f func(T) T) *Matrix[T] {
+	result := m.newLike(m.rows, m.cols)
+	src, dst := m.values(), result.values()
+	for i := range src {
+		dst[i] = f(src[i])
+	}
+	return result
+}
+
+// Fill sets all the elements of the matrix to value
+func (m *Matrix[T]) Fill(value T) {
+	values := m.values()
+	for i := range values {
+		values[i] = value
+	}
+}
+
+// Copy returns a copy of the matrix. The copy is shallow: the elements are
+// copied by assignment, so pointers, slices and maps inside them are shared
+// with the original (use CloneWith for a deep copy)
+func (m *Matrix[T]) Copy() *Matrix[T] {
+	return &Matrix[T]{rows: m.rows, cols: m.cols, data: m.data.Copy(), alloc: m.alloc}
+}
+
+// CloneWith returns a copy of the matrix with a copy of every element made by
+// copier, to deep copy elements that hold pointers, slices or maps
+func (m *Matrix[T]) CloneWith(copier func(T) T) *Matrix[T] {
+	return &Matrix[T]{rows: m.rows, cols: m.cols, data: m.data.CloneWith(copier), alloc: m.alloc}
+}
+
+// Equal returns true if the two matrices have the same size and elements
+func (m *Matrix[T]) Equal(other *Matrix[T]) bool {
+	return m.rows == other.rows && m.cols == other.cols && m.data.Equals(other.data)
+}
+
+// ToSlice returns a copy of the matrix as a slice of rows
+func (m *Matrix[T]) ToSlice() [][]T {
+	rows := make([][]T, m.rows)
+	for r := range rows {
+		rows[r], _ = m.Row(uint64(r))
+	}
+	return rows
+}
+
+// String returns a string representation of the matrix as a list of rows
+// (elements are formatted with %v)
+func (m *Matrix[T]) String() string {
+	return m.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the matrix as a list of rows
+// with every element formatted by f
+func (m *Matrix[T]) StringFunc(f func(T) string) string {
+	return common.FormatSlice(m.ToSlice(), func(row []T) string {
+		return common.FormatSlice(row, f)
+	})
+}
+
+// Blit combines/overwrites all the elements of the matrix with the elements of
+// another matrix of the same size using a function (large matrices are processed
+// in parallel by buffer.Blit, see SetBlitParallelism)
+func (m *Matrix[T]) Blit(other *Matrix[T], f func(T, T) T) error {
+	if m.rows != other.rows || m.cols != other.cols {
+		return ErrSizeMismatch
+	}
+	return m.data.Blit(other.data, f)
+}
+
+// BlitRegion combines/overwrites the region of the matrix starting at (row, col)
+// with all the elements of src using a function, src must fit in the matrix.
+// Large regions are processed in parallel like Blit (see SetBlitParallelism)
+func (m *Matrix[T]) BlitRegion(row, col uint64, src *Matrix[T], f func(T, T) T) error {
+	if row >= m.rows || src.rows > m.rows-row || col >= m.cols || src.cols > m.cols-col {
+		return ErrInvalidRegion
+	}
+
+	// Every chunk is a range of the elements of src, that can start and end
+	// in the middle of a row
+	dst, values := m.values(), src.values()
+	m.data.BlitInChunks(0, src.rows*src.cols, func(from, to uint64) {
+		r, c := from/src.cols, from%src.cols
+		for i := from; i < to; i++ {
+			d := (row+r)*m.cols + col + c
+			dst[d] = f(dst[d], values[i])
+			if c++; c == src.cols {
+				r, c = r+1, 0
+			}
+		}
+	})
+	return nil
+}
+
+// SetBlitParallelism sets how Blit and BlitRegion split the work (see
+// buffer.SetBlitParallelism)
+func (m *Matrix[T]) SetBlitParallelism(workers int, threshold uint64) {
+	m.data.SetBlitParallelism(workers, threshold)
+}
+
+// BlitParallelism returns the number of goroutines and the threshold used by
+// Blit and BlitRegion (see SetBlitParallelism)
+func (m *Matrix[T]) BlitParallelism() (workers int, threshold uint64) {
+	return m.data.BlitParallelism()
+}
@@ -0,0 +1,54 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matrix_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	matrix "github.com/pzaino/gods/pkg/matrix"
+)
+
+// benchCols is the number of columns of the benchmarked matrices, they have
+// n/benchCols rows
+const benchCols = 1000
+
+// BenchmarkSet sets the n elements of a matrix
+func BenchmarkSet(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		rows := uint64(n / benchCols)
+		m, _ := matrix.New[int](rows, benchCols)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			for r := uint64(0); r < rows; r++ {
+				for c := uint64(0); c < benchCols; c++ {
+					_ = m.Set(r, c, i)
+				}
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkTranspose transposes a matrix of n elements
+func BenchmarkTranspose(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		m, _ := matrix.NewFromSlice(uint64(n/benchCols), benchCols, bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = m.Transpose()
+		}
+	})
+}
@@ -0,0 +1,285 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package matrix_test
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	matrix "github.com/pzaino/gods/pkg/matrix"
+)
+
+const errUnexpectedErr = "unexpected error: %v"
+
+// newMatrix creates a 2x3 matrix with the values 1..6
+func newMatrix(t *testing.T) *matrix.Matrix[int] {
+	m, err := matrix.NewFromSlice(2, 3, []int{1, 2, 3, 4, 5, 6})
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	return m
+}
+
+func TestNew(t *testing.T) {
+	if _, err := matrix.New[int](0, 3); !errors.Is(err, matrix.ErrInvalidSize) {
+		t.Errorf("expected %v, got %v", matrix.ErrInvalidSize, err)
+	}
+	if _, err := matrix.NewFromSlice(2, 2, []int{1, 2, 3}); !errors.Is(err, matrix.ErrSizeMismatch) {
+		t.Errorf("expected %v, got %v", matrix.ErrSizeMismatch, err)
+	}
+
+	m, err := matrix.New[int](2, 3)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if m.Rows() != 2 || m.Cols() != 3 || m.Size() != 6 {
+		t.Errorf("expected a 2x3 matrix, got %dx%d", m.Rows(), m.Cols())
+	}
+}
+
+func TestNewFromBuffer(t *testing.T) {
+	b := buffer.NewWithSize[int](4)
+	m, err := matrix.NewFromBuffer(2, 2, b)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if err := m.Set(1, 0, 7); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if v, _ := b.Get(2); v != 7 {
+		t.Errorf("expected the matrix to write through to the buffer, got %d", v)
+	}
+	if _, err := matrix.NewFromBuffer(3, 2, b); !errors.Is(err, matrix.ErrSizeMismatch) {
+		t.Errorf("expected %v, got %v", matrix.ErrSizeMismatch, err)
+	}
+}
+
+func TestAtSet(t *testing.T) {
+	m := newMatrix(t)
+	if v, err := m.At(1, 2); err != nil || v != 6 {
+		t.Errorf("expected 6, got %d (%v)", v, err)
+	}
+	if err := m.Set(0, 1, 20); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if v, _ := m.At(0, 1); v != 20 {
+		t.Errorf("expected 20, got %d", v)
+	}
+	if _, err := m.At(2, 0); !errors.Is(err, matrix.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", matrix.ErrOutOfBounds, err)
+	}
+	if err := m.Set(0, 3, 1); !errors.Is(err, matrix.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", matrix.ErrOutOfBounds, err)
+	}
+}
+
+func TestRowCol(t *testing.T) {
+	m := newMatrix(t)
+	if row, err := m.Row(1); err != nil || !reflect.DeepEqual(row, []int{4, 5, 6}) {
+		t.Errorf("expected [4 5 6], got %v (%v)", row, err)
+	}
+	if col, err := m.Col(1); err != nil || !reflect.DeepEqual(col, []int{2, 5}) {
+		t.Errorf("expected [2 5], got %v (%v)", col, err)
+	}
+	if _, err := m.Row(2); !errors.Is(err, matrix.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", matrix.ErrOutOfBounds, err)
+	}
+	if _, err := m.Col(3); !errors.Is(err, matrix.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", matrix.ErrOutOfBounds, err)
+	}
+
+	// Rows are copies
+	row, _ := m.Row(0)
+	row[0] = 100
+	if v, _ := m.At(0, 0); v != 1 {
+		t.Errorf("expected 1, got %d", v)
+	}
+}
+
+func TestSubMatrix(t *testing.T) {
+	m := newMatrix(t)
+	sub, err := m.SubMatrix(0, 1, 2, 2)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !reflect.DeepEqual(sub.ToSlice(), [][]int{{2, 3}, {5, 6}}) {
+		t.Errorf("expected [[2 3] [5 6]], got %v", sub.ToSlice())
+	}
+	if _, err := m.SubMatrix(1, 1, 2, 2); !errors.Is(err, matrix.ErrInvalidRegion) {
+		t.Errorf("expected %v, got %v", matrix.ErrInvalidRegion, err)
+	}
+	// Offsets that would wrap around row+rows and col+cols
+	if _, err := m.SubMatrix(math.MaxUint64, 0, 2, 1); !errors.Is(err, matrix.ErrInvalidRegion) {
+		t.Errorf("expected %v, got %v", matrix.ErrInvalidRegion, err)
+	}
+	if _, err := m.SubMatrix(0, 1, 1, math.MaxUint64); !errors.Is(err, matrix.ErrInvalidRegion) {
+		t.Errorf("expected %v, got %v", matrix.ErrInvalidRegion, err)
+	}
+}
+
+func TestTransposeMap(t *testing.T) {
+	m := newMatrix(t)
+	tr := m.Transpose()
+	if tr.Rows() != 3 || tr.Cols() != 2 {
+		t.Fatalf("expected a 3x2 matrix, got %dx%d", tr.Rows(), tr.Cols())
+	}
+	if !reflect.DeepEqual(tr.ToSlice(), [][]int{{1, 4}, {2, 5}, {3, 6}}) {
+		t.Errorf("expected [[1 4] [2 5] [3 6]], got %v", tr.ToSlice())
+	}
+	if !tr.Transpose().Equal(m) {
+		t.Error("expected the transpose of the transpose to be the original matrix")
+	}
+
+	doubled := m.Map(func(v int) int { return v * 2 })
+	if !reflect.DeepEqual(doubled.ToSlice(), [][]int{{2, 4, 6}, {8, 10, 12}}) {
+		t.Errorf("expected [[2 4 6] [8 10 12]], got %v", doubled.ToSlice())
+	}
+	if v, _ := m.At(0, 0); v != 1 {
+		t.Errorf("expected Map not to modify the matrix, got %d", v)
+	}
+}
+
+func TestBlit(t *testing.T) {
+	m := newMatrix(t)
+	other := m.Copy()
+	other.Fill(10)
+	if err := m.Blit(other, func(a, b int) int { return a + b }); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !reflect.DeepEqual(m.ToSlice(), [][]int{{11, 12, 13}, {14, 15, 16}}) {
+		t.Errorf("expected [[11 12 13] [14 15 16]], got %v", m.ToSlice())
+	}
+
+	small, _ := matrix.New[int](2, 2)
+	if err := m.Blit(small, func(a, b int) int { return b }); !errors.Is(err, matrix.ErrSizeMismatch) {
+		t.Errorf("expected %v, got %v", matrix.ErrSizeMismatch, err)
+	}
+}
+
+func TestBlitRegion(t *testing.T) {
+	m, _ := matrix.New[int](3, 3)
+	src, _ := matrix.NewFromSlice(2, 2, []int{1, 2, 3, 4})
+	if err := m.BlitRegion(1, 1, src, func(_, b int) int { return b }); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	expected := [][]int{{0, 0, 0}, {0, 1, 2}, {0, 3, 4}}
+	if !reflect.DeepEqual(m.ToSlice(), expected) {
+		t.Errorf("expected %v, got %v", expected, m.ToSlice())
+	}
+	if err := m.BlitRegion(2, 0, src, func(_, b int) int { return b }); !errors.Is(err, matrix.ErrInvalidRegion) {
+		t.Errorf("expected %v, got %v", matrix.ErrInvalidRegion, err)
+	}
+	if err := m.BlitRegion(math.MaxUint64, 0, src, func(_, b int) int { return b }); !errors.Is(err, matrix.ErrInvalidRegion) {
+		t.Errorf("expected %v, got %v", matrix.ErrInvalidRegion, err)
+	}
+	if err := m.BlitRegion(0, math.MaxUint64, src, func(_, b int) int { return b }); !errors.Is(err, matrix.ErrInvalidRegion) {
+		t.Errorf("expected %v, got %v", matrix.ErrInvalidRegion, err)
+	}
+}
+
+func TestBlitLarge(t *testing.T) {
+	m, _ := matrix.New[int](64, 64)
+	other, _ := matrix.New[int](64, 64)
+	other.Fill(1)
+	if err := m.Blit(other, func(a, b int) int { return a + b }); err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	sum := buffer.ReduceInto(m.Buffer(), 0, func(acc, v int) int { return acc + v })
+	if sum != 64*64 {
+		t.Errorf("expected %d, got %d", 64*64, sum)
+	}
+}
+
+func TestString(t *testing.T) {
+	m := newMatrix(t)
+	if s := m.String(); s != "[[1 2 3] [4 5 6]]" {
+		t.Errorf("expected [[1 2 3] [4 5 6]], got %s", s)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c, _ := matrix.NewFromSlice(1, 2, []*int{&a, &b})
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice()[0] {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	m, err := matrix.NewWithComparator(2, 2, slices.Equal[[]int])
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	_ = m.Set(0, 1, []int{1, 2})
+
+	// Matrices derived from m keep the comparator
+	tr := m.Transpose()
+	if v, _ := tr.At(1, 0); !slices.Equal(v, []int{1, 2}) {
+		t.Errorf("expected [1 2], got %v", v)
+	}
+	if !tr.Transpose().Equal(m) || tr.Equal(m) {
+		t.Error("unexpected Equal results")
+	}
+}
+
+func TestBlitRegionParallel(t *testing.T) {
+	// Chunks of 7 elements start and end in the middle of the rows of src
+	for _, workers := range []int{1, 3, 0} {
+		m, _ := matrix.New[int](20, 30)
+		m.SetBlitParallelism(workers, 1)
+		src, _ := matrix.New[int](13, 11)
+		for i := range uint64(13 * 11) {
+			_ = src.Set(i/11, i%11, int(i)+1)
+		}
+		if err := m.BlitRegion(5, 17, src, func(a, b int) int { return a + b }); err != nil {
+			t.Fatalf(errUnexpectedErr, err)
+		}
+		for r := range uint64(20) {
+			for c := range uint64(30) {
+				expected := 0
+				if r >= 5 && r < 18 && c >= 17 && c < 28 {
+					expected = int((r-5)*11+c-17) + 1
+				}
+				if v, _ := m.At(r, c); v != expected {
+					t.Fatalf("workers %d: expected %d at (%d, %d), got %d", workers, expected, r, c, v)
+				}
+			}
+		}
+	}
+
+	m, _ := matrix.New[int](2, 2)
+	m.SetBlitParallelism(3, 10)
+	if w, th := m.BlitParallelism(); w != 3 || th != 10 {
+		t.Errorf("expected 3 workers and threshold 10, got %d and %d", w, th)
+	}
+}
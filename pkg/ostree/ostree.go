@@ -0,0 +1,416 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ostree provides a non-concurrent-safe order-statistics tree, an
+// AVL tree whose nodes know the size of their subtree so it can answer rank
+// and select queries (and so running percentiles) in O(log n).
+package ostree
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"math"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Sentinel errors returned by the Tree methods (use errors.Is to check for them)
+var (
+	ErrEmpty             = errors.New("tree is empty")
+	ErrOutOfBounds       = errors.New("index out of bounds")
+	ErrInvalidPercentile = errors.New("percentile must be between 0 and 100")
+	ErrCorrupted         = errors.New("tree is corrupted")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureInvariants)
+}
+
+// node is a node of the tree, equal values share a node and count is their
+// number, size is the number of values in the subtree (counting duplicates)
+type node[T any] struct {
+	value  T
+	count  uint64
+	size   uint64
+	height int
+	left   *node[T]
+	right  *node[T]
+}
+
+// size returns the number of values in the subtree of n (0 for nil)
+func size[T any](n *node[T]) uint64 {
+	if n == nil {
+		return 0
+	}
+	return n.size
+}
+
+// height returns the height of the subtree of n (0 for nil)
+func height[T any](n *node[T]) int {
+	if n == nil {
+		return 0
+	}
+	return n.height
+}
+
+// update recomputes the size and the height of n from its children
+func (n *node[T]) update() {
+	n.size = size(n.left) + n.count + size(n.right)
+	n.height = 1 + max(height(n.left), height(n.right))
+}
+
+// balance returns the difference between the heights of the subtrees of n
+func (n *node[T]) balance() int {
+	return height(n.left) - height(n.right)
+}
+
+// Tree is an order-statistics tree, it's a sorted multiset: a value can be
+// inserted more than once and every copy is counted by the queries
+type Tree[T any] struct {
+	root *node[T]
+	less func(a, b T) bool
+}
+
+// New creates a new empty Tree ordering its values with the < operator
+func New[T cmp.Ordered]() *Tree[T] {
+	return NewWithLess(cmp.Less[T])
+}
+
+// NewWithLess creates a new empty Tree ordering its values with less, two
+// values are equal if neither is less than the other
+func NewWithLess[T any](less func(a, b T) bool) *Tree[T] {
+	return &Tree[T]{less: less}
+}
+
+// Size returns the number of values in the tree (counting duplicates)
+func (t *Tree[T]) Size() uint64 {
+	return size(t.root)
+}
+
+// IsEmpty returns true if the tree has no values
+func (t *Tree[T]) IsEmpty() bool {
+	return t.root == nil
+}
+
+// Clear removes all the values from the tree
+func (t *Tree[T]) Clear() {
+	t.root = nil
+}
+
+// rotateRight rotates the subtree of n to the right and returns its new root
+func rotateRight[T any](n *node[T]) *node[T] {
+	l := n.left
+	n.left = l.right
+	l.right = n
+	n.update()
+	l.update()
+	return l
+}
+
+// rotateLeft rotates the subtree of n to the left and returns its new root
+func rotateLeft[T any](n *node[T]) *node[T] {
+	r := n.right
+	n.right = r.left
+	r.left = n
+	n.update()
+	r.update()
+	return r
+}
+
+// rebalance updates n and restores the AVL property of its subtree, it
+// returns the new root of the subtree
+func rebalance[T any](n *node[T]) *node[T] {
+	n.update()
+	switch b := n.balance(); {
+	case b > 1:
+		if n.left.balance() < 0 {
+			n.left = rotateLeft(n.left)
+		}
+		return rotateRight(n)
+	case b < -1:
+		if n.right.balance() > 0 {
+			n.right = rotateRight(n.right)
+		}
+		return rotateLeft(n)
+	}
+	return n
+}
+
+// Insert adds a value to the tree
+func (t *Tree[T]) Insert(value T) {
+	t.root = t.insert(t.root, value)
+}
+
+// insert adds value to the subtree of n and returns its new root
+func (t *Tree[T]) insert(n *node[T], value T) *node[T] {
+	switch {
+	case n == nil:
+		return &node[T]{value: value, count: 1, size: 1, height: 1}
+	case t.less(value, n.value):
+		n.left = t.insert(n.left, value)
+	case t.less(n.value, value):
+		n.right = t.insert(n.right, value)
+	default:
+		n.count++
+	}
+	return rebalance(n)
+}
+
+// Delete removes one copy of value from the tree, it returns false if the
+// value is not in the tree
+func (t *Tree[T]) Delete(value T) bool {
+	var found bool
+	t.root, found = t.delete(t.root, value)
+	return found
+}
+
+// delete removes one copy of value from the subtree of n and returns its new root
+func (t *Tree[T]) delete(n *node[T], value T) (*node[T], bool) {
+	if n == nil {
+		return nil, false
+	}
+
+	var found bool
+	switch {
+	case t.less(value, n.value):
+		n.left, found = t.delete(n.left, value)
+	case t.less(n.value, value):
+		n.right, found = t.delete(n.right, value)
+	default:
+		found = true
+		if n.count > 1 {
+			n.count--
+			break
+		}
+		if n.left == nil {
+			return n.right, true
+		}
+		if n.right == nil {
+			return n.left, true
+		}
+		// Replace n with its successor, the leftmost node of the right subtree
+		var succ *node[T]
+		n.right, succ = removeMin(n.right)
+		succ.left, succ.right = n.left, n.right
+		n = succ
+	}
+	return rebalance(n), found
+}
+
+// removeMin detaches the leftmost node of the subtree of n, it returns the
+// new root of the subtree and the detached node
+func removeMin[T any](n *node[T]) (*node[T], *node[T]) {
+	if n.left == nil {
+		return n.right, n
+	}
+	var m *node[T]
+	n.left, m = removeMin(n.left)
+	return rebalance(n), m
+}
+
+// find returns the node holding value, or nil
+func (t *Tree[T]) find(value T) *node[T] {
+	n := t.root
+	for n != nil {
+		switch {
+		case t.less(value, n.value):
+			n = n.left
+		case t.less(n.value, value):
+			n = n.right
+		default:
+			return n
+		}
+	}
+	return nil
+}
+
+// Contains returns true if the tree holds value
+func (t *Tree[T]) Contains(value T) bool {
+	return t.find(value) != nil
+}
+
+// Count returns the number of copies of value in the tree
+func (t *Tree[T]) Count(value T) uint64 {
+	if n := t.find(value); n != nil {
+		return n.count
+	}
+	return 0
+}
+
+// Rank returns the number of values in the tree that are less than value
+// (the index value would have in the sorted tree)
+func (t *Tree[T]) Rank(value T) uint64 {
+	var rank uint64
+	n := t.root
+	for n != nil {
+		switch {
+		case t.less(value, n.value):
+			n = n.left
+		case t.less(n.value, value):
+			rank += size(n.left) + n.count
+			n = n.right
+		default:
+			return rank + size(n.left)
+		}
+	}
+	return rank
+}
+
+// Select returns the k-th smallest value in the tree (k starts from 0),
+// duplicates occupy consecutive positions
+func (t *Tree[T]) Select(k uint64) (T, error) {
+	if k >= t.Size() {
+		var rVal T
+		return rVal, ErrOutOfBounds
+	}
+
+	n := t.root
+	for {
+		left := size(n.left)
+		switch {
+		case k < left:
+			n = n.left
+		case k < left+n.count:
+			return n.value, nil
+		default:
+			k -= left + n.count
+			n = n.right
+		}
+	}
+}
+
+// CountRange returns the number of values in the range [from, to)
+func (t *Tree[T]) CountRange(from, to T) uint64 {
+	if !t.less(from, to) {
+		return 0
+	}
+	return t.Rank(to) - t.Rank(from)
+}
+
+// Percentile returns the value at the given percentile (between 0 and 100)
+// with the nearest-rank method: the smallest value such that at least p% of
+// the values are less than or equal to it (0 returns the minimum)
+func (t *Tree[T]) Percentile(p float64) (T, error) {
+	var rVal T
+	if math.IsNaN(p) || p < 0 || p > 100 {
+		return rVal, ErrInvalidPercentile
+	}
+	if t.IsEmpty() {
+		return rVal, ErrEmpty
+	}
+
+	k := uint64(math.Ceil(p / 100 * float64(t.Size())))
+	if k > 0 {
+		k--
+	}
+	return t.Select(k)
+}
+
+// Min returns the smallest value in the tree
+func (t *Tree[T]) Min() (T, error) {
+	if t.IsEmpty() {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+	return t.Select(0)
+}
+
+// Max returns the largest value in the tree
+func (t *Tree[T]) Max() (T, error) {
+	if t.IsEmpty() {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+	return t.Select(t.Size() - 1)
+}
+
+// Ascend calls fn for every value in ascending order (duplicates included)
+// until fn returns false
+func (t *Tree[T]) Ascend(fn func(T) bool) {
+	ascend(t.root, fn)
+}
+
+// ascend visits the subtree of n in order, it returns false if fn stopped it
+func ascend[T any](n *node[T], fn func(T) bool) bool {
+	if n == nil {
+		return true
+	}
+	if !ascend(n.left, fn) {
+		return false
+	}
+	for i := uint64(0); i < n.count; i++ {
+		if !fn(n.value) {
+			return false
+		}
+	}
+	return ascend(n.right, fn)
+}
+
+// ToSlice returns the values of the tree in ascending order (duplicates included)
+func (t *Tree[T]) ToSlice() []T {
+	items := make([]T, 0, t.Size())
+	t.Ascend(func(v T) bool {
+		items = append(items, v)
+		return true
+	})
+	return items
+}
+
+// Height returns the height of the tree (0 when empty)
+func (t *Tree[T]) Height() int {
+	return height(t.root)
+}
+
+// CheckInvariants verifies the internal consistency of the tree (the order
+// of the values, the subtree sizes and heights and the AVL balance), it
+// returns an error wrapping ErrCorrupted describing the first violation found
+func (t *Tree[T]) CheckInvariants() error {
+	_, err := t.check(t.root, nil, nil)
+	return err
+}
+
+// check verifies the subtree of n, whose values must be in (lo, hi), and returns its size
+func (t *Tree[T]) check(n *node[T], lo, hi *T) (uint64, error) {
+	if n == nil {
+		return 0, nil
+	}
+	if (lo != nil && !t.less(*lo, n.value)) || (hi != nil && !t.less(n.value, *hi)) {
+		return 0, fmt.Errorf("%w: the value %v is out of order", ErrCorrupted, n.value)
+	}
+	if n.count == 0 {
+		return 0, fmt.Errorf("%w: the node of %v has no values", ErrCorrupted, n.value)
+	}
+
+	left, err := t.check(n.left, lo, &n.value)
+	if err != nil {
+		return 0, err
+	}
+	right, err := t.check(n.right, &n.value, hi)
+	if err != nil {
+		return 0, err
+	}
+	if total := left + n.count + right; total != n.size {
+		return 0, fmt.Errorf("%w: the node of %v has size %d but holds %d values", ErrCorrupted, n.value, n.size, total)
+	}
+	if h := 1 + max(height(n.left), height(n.right)); h != n.height {
+		return 0, fmt.Errorf("%w: the node of %v has height %d instead of %d", ErrCorrupted, n.value, n.height, h)
+	}
+	if b := n.balance(); b > 1 || b < -1 {
+		return 0, fmt.Errorf("%w: the node of %v is unbalanced (%d)", ErrCorrupted, n.value, b)
+	}
+	return n.size, nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ostree_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	ostree "github.com/pzaino/gods/pkg/ostree"
+)
+
+// newBenchTree returns a tree holding values
+func newBenchTree(values []int) *ostree.Tree[int] {
+	tree := ostree.New[int]()
+	for _, v := range values {
+		tree.Insert(v)
+	}
+	return tree
+}
+
+// BenchmarkInsert inserts n shuffled values
+func BenchmarkInsert(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = newBenchTree(values)
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkDelete deletes a value from a tree of n values (and inserts it
+// back, so the size stays n)
+func BenchmarkDelete(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		tree := newBenchTree(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			v := values[i%n]
+			tree.Delete(v)
+			tree.Insert(v)
+		}
+	})
+}
+
+// BenchmarkFind looks for a value in a tree of n values
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		tree := newBenchTree(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = tree.Contains(values[i%n])
+		}
+	})
+}
+
+// BenchmarkSelect finds the k-th smallest of n values
+func BenchmarkSelect(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		tree := newBenchTree(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = tree.Select(uint64(i % n))
+		}
+	})
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ostree_test
+
+import (
+	"errors"
+	"math/rand"
+	"slices"
+	"sort"
+	"testing"
+
+	ostree "github.com/pzaino/gods/pkg/ostree"
+)
+
+func checkTree[T any](t *testing.T, tree *ostree.Tree[T]) {
+	t.Helper()
+	if err := tree.CheckInvariants(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestEmpty(t *testing.T) {
+	tree := ostree.New[int]()
+	if !tree.IsEmpty() || tree.Size() != 0 || tree.Height() != 0 {
+		t.Error("expected an empty tree")
+	}
+	if _, err := tree.Select(0); !errors.Is(err, ostree.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", ostree.ErrOutOfBounds, err)
+	}
+	if _, err := tree.Min(); !errors.Is(err, ostree.ErrEmpty) {
+		t.Errorf("expected %v, got %v", ostree.ErrEmpty, err)
+	}
+	if _, err := tree.Percentile(50); !errors.Is(err, ostree.ErrEmpty) {
+		t.Errorf("expected %v, got %v", ostree.ErrEmpty, err)
+	}
+	if tree.Rank(5) != 0 || tree.Delete(5) {
+		t.Error("expected rank 0 and no deletion on an empty tree")
+	}
+}
+
+func TestRankSelect(t *testing.T) {
+	tree := ostree.New[int]()
+	for _, v := range []int{50, 20, 80, 20, 10, 60, 20} {
+		tree.Insert(v)
+	}
+	checkTree(t, tree)
+
+	if got := tree.ToSlice(); !slices.Equal(got, []int{10, 20, 20, 20, 50, 60, 80}) {
+		t.Errorf("expected the sorted values, got %v", got)
+	}
+	if tree.Count(20) != 3 || tree.Count(30) != 0 || !tree.Contains(60) {
+		t.Error("expected 3 copies of 20, none of 30 and 60 in the tree")
+	}
+	for value, rank := range map[int]uint64{5: 0, 10: 0, 20: 1, 30: 4, 50: 4, 80: 6, 90: 7} {
+		if got := tree.Rank(value); got != rank {
+			t.Errorf("Rank(%d): expected %d, got %d", value, rank, got)
+		}
+	}
+	for k, value := range []int{10, 20, 20, 20, 50, 60, 80} {
+		if got, err := tree.Select(uint64(k)); err != nil || got != value {
+			t.Errorf("Select(%d): expected %d, got %d (%v)", k, value, got, err)
+		}
+	}
+	if _, err := tree.Select(7); !errors.Is(err, ostree.ErrOutOfBounds) {
+		t.Errorf("expected %v, got %v", ostree.ErrOutOfBounds, err)
+	}
+
+	if n := tree.CountRange(20, 60); n != 4 {
+		t.Errorf("expected 4 values in [20, 60), got %d", n)
+	}
+	if n := tree.CountRange(60, 20); n != 0 {
+		t.Errorf("expected 0 values in an empty range, got %d", n)
+	}
+
+	if !tree.Delete(20) || tree.Count(20) != 2 || tree.Delete(30) {
+		t.Error("expected Delete to remove a single copy of an existing value")
+	}
+	checkTree(t, tree)
+}
+
+func TestPercentile(t *testing.T) {
+	tree := ostree.New[int]()
+	for i := 1; i <= 100; i++ {
+		tree.Insert(i)
+	}
+	for p, expected := range map[float64]int{0: 1, 1: 1, 50: 50, 90: 90, 99.5: 100, 100: 100} {
+		if got, err := tree.Percentile(p); err != nil || got != expected {
+			t.Errorf("Percentile(%v): expected %d, got %d (%v)", p, expected, got, err)
+		}
+	}
+	if _, err := tree.Percentile(101); !errors.Is(err, ostree.ErrInvalidPercentile) {
+		t.Errorf("expected %v, got %v", ostree.ErrInvalidPercentile, err)
+	}
+	if v, _ := tree.Max(); v != 100 {
+		t.Errorf("expected 100, got %d", v)
+	}
+}
+
+func TestRandomized(t *testing.T) {
+	tree := ostree.NewWithLess(func(a, b int) bool { return a > b }) // descending
+	var reference []int
+	for i := 0; i < 5000; i++ {
+		v := rand.Intn(500)
+		if rand.Intn(3) == 0 {
+			idx := slices.Index(reference, v)
+			if deleted := tree.Delete(v); deleted != (idx >= 0) {
+				t.Fatalf("Delete(%d): expected %v, got %v", v, idx >= 0, deleted)
+			}
+			if idx >= 0 {
+				reference = slices.Delete(reference, idx, idx+1)
+			}
+		} else {
+			tree.Insert(v)
+			reference = append(reference, v)
+		}
+	}
+	checkTree(t, tree)
+
+	sort.Sort(sort.Reverse(sort.IntSlice(reference)))
+	if !slices.Equal(tree.ToSlice(), reference) {
+		t.Fatal("expected the tree to hold the reference values")
+	}
+	for k := 0; k < len(reference); k += 37 {
+		if v, _ := tree.Select(uint64(k)); v != reference[k] {
+			t.Fatalf("Select(%d): expected %d, got %d", k, reference[k], v)
+		}
+		first := slices.Index(reference, reference[k])
+		if r := tree.Rank(reference[k]); r != uint64(first) {
+			t.Fatalf("Rank(%d): expected %d, got %d", reference[k], first, r)
+		}
+	}
+}
+
+func TestAscend(t *testing.T) {
+	tree := ostree.New[string]()
+	for _, v := range []string{"c", "a", "b", "a"} {
+		tree.Insert(v)
+	}
+	var visited []string
+	tree.Ascend(func(v string) bool {
+		visited = append(visited, v)
+		return len(visited) < 3
+	})
+	if !slices.Equal(visited, []string{"a", "a", "b"}) {
+		t.Errorf("expected [a a b], got %v", visited)
+	}
+	tree.Clear()
+	if !tree.IsEmpty() {
+		t.Error("expected Clear to empty the tree")
+	}
+}
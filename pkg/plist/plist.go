@@ -0,0 +1,366 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plist provides a persistent (immutable) singly linked list.
+// Every operation returns a new list that shares as much structure as
+// possible with the original, so lists can be shared across goroutines
+// without locks.
+package plist
+
+import (
+	"errors"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
+const (
+	ErrListIsEmpty     = "list is empty"
+	ErrIndexOutOfBound = "index out of bounds"
+	ErrValueNotFound   = "value not found"
+)
+
+// Sentinel errors returned by the List methods (use errors.Is to check for them)
+var (
+	ErrEmpty       = errors.New(ErrListIsEmpty)
+	ErrOutOfBounds = errors.New(ErrIndexOutOfBound)
+	ErrNotFound    = errors.New(ErrValueNotFound)
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator)
+}
+
+// node is an immutable list cell
+type node[T any] struct {
+	value T
+	next  *node[T]
+}
+
+// List is a persistent singly linked list, the zero value is an empty list
+// that compares its elements with ==
+type List[T any] struct {
+	head   *node[T]
+	size   uint64
+	equals common.EqualFunc[T] // inherited by the lists derived from this one
+}
+
+// New creates a new empty list
+func New[T comparable]() *List[T] {
+	return &List[T]{equals: common.Equal[T]}
+}
+
+// NewWithComparator creates a new empty list that compares its elements with
+// the given function, so it can hold types that are not comparable (slices,
+// maps, ...). The lists derived from it use the same function
+func NewWithComparator[T any](equals func(a, b T) bool) *List[T] {
+	return &List[T]{equals: equals}
+}
+
+// NewFromSlice creates a new list with the items of the slice (in order)
+func NewFromSlice[T comparable](items []T) *List[T] {
+	return New[T]().fromSlice(items)
+}
+
+// fromSlice creates a new list with the items of the slice that compares its
+// elements like l
+func (l *List[T]) fromSlice(items []T) *List[T] {
+	var head *node[T]
+	for i := len(items) - 1; i >= 0; i-- {
+		head = &node[T]{value: items[i], next: head}
+	}
+	return l.derive(head, uint64(len(items)))
+}
+
+// derive creates a new list starting at head that compares its elements like l
+func (l *List[T]) derive(head *node[T], size uint64) *List[T] {
+	result := &List[T]{head: head, size: size}
+	if l != nil {
+		result.equals = l.equals
+	}
+	return result
+}
+
+// equal compares two elements with the list comparator
+func (l *List[T]) equal(a, b T) bool {
+	if l == nil {
+		return common.Equals(nil, a, b)
+	}
+	return common.Equals(l.equals, a, b)
+}
+
+// IsEmpty checks if the list is empty
+func (l *List[T]) IsEmpty() bool {
+	return l == nil || l.head == nil
+}
+
+// Size returns the number of elements in the list
+func (l *List[T]) Size() uint64 {
+	if l == nil {
+		return 0
+	}
+	return l.size
+}
+
+// Cons returns a new list with value in front of the list (O(1), the whole list is shared)
+func (l *List[T]) Cons(value T) *List[T] {
+	return l.derive(&node[T]{value: value, next: l.headNode()}, l.Size()+1)
+}
+
+// Head returns the first element of the list
+func (l *List[T]) Head() (T, error) {
+	if l.IsEmpty() {
+		var zero T
+		return zero, ErrEmpty
+	}
+	return l.head.value, nil
+}
+
+// Tail returns the list without its first element (O(1), the rest of the list is shared)
+func (l *List[T]) Tail() (*List[T], error) {
+	if l.IsEmpty() {
+		return nil, ErrEmpty
+	}
+	return l.derive(l.head.next, l.size-1), nil
+}
+
+// Drop returns the list without its first n elements (the rest of the list is shared)
+func (l *List[T]) Drop(n uint64) *List[T] {
+	if n >= l.Size() {
+		return l.derive(nil, 0)
+	}
+	current := l.head
+	for i := uint64(0); i < n; i++ {
+		current = current.next
+	}
+	return l.derive(current, l.size-n)
+}
+
+// Take returns a new list with the first n elements of the list
+func (l *List[T]) Take(n uint64) *List[T] {
+	if n >= l.Size() {
+		return l
+	}
+	return l.copyPrefix(n, nil)
+}
+
+// Append returns a new list with value at the end of the list
+// (O(n), all the nodes need to be copied)
+func (l *List[T]) Append(value T) *List[T] {
+	return l.copyPrefix(l.Size(), l.derive(&node[T]{value: value}, 1))
+}
+
+// Concat returns a new list with the elements of other after the elements
+// of the list (other is shared)
+func (l *List[T]) Concat(other *List[T]) *List[T] {
+	if l.IsEmpty() {
+		return other
+	}
+	if other.IsEmpty() {
+		return l
+	}
+	return l.copyPrefix(l.size, other)
+}
+
+// Get returns the element at the given index
+func (l *List[T]) Get(index uint64) (T, error) {
+	if index >= l.Size() {
+		var zero T
+		return zero, ErrOutOfBounds
+	}
+	current := l.head
+	for i := uint64(0); i < index; i++ {
+		current = current.next
+	}
+	return current.value, nil
+}
+
+// Set returns a new list with the element at the given index replaced
+// (the nodes after the index are shared)
+func (l *List[T]) Set(index uint64, value T) (*List[T], error) {
+	if index >= l.Size() {
+		return nil, ErrOutOfBounds
+	}
+	rest := l.Drop(index + 1)
+	return l.copyPrefix(index, rest.Cons(value)), nil
+}
+
+// IndexOf returns the index of the first element with the given value
+func (l *List[T]) IndexOf(value T) (uint64, error) {
+	var index uint64
+	for current := l.headNode(); current != nil; current = current.next {
+		if l.equal(current.value, value) {
+			return index, nil
+		}
+		index++
+	}
+	return 0, ErrNotFound
+}
+
+// Contains checks if the list contains the given value
+func (l *List[T]) Contains(value T) bool {
+	_, err := l.IndexOf(value)
+	return err == nil
+}
+
+// Reverse returns a new list with the elements in reverse order
+func (l *List[T]) Reverse() *List[T] {
+	var head *node[T]
+	for current := l.headNode(); current != nil; current = current.next {
+		head = &node[T]{value: current.value, next: head}
+	}
+	return l.derive(head, l.Size())
+}
+
+// Map returns a new list with the function applied to all the elements
+func (l *List[T]) Map(f func(T) T) *List[T] {
+	items := make([]T, 0, l.Size())
+	for current := l.headNode(); current != nil; current = current.next {
+		items = append(items, f(current.value))
+	}
+	return l.fromSlice(items)
+}
+
+// Filter returns a new list with the elements that match the predicate
+// (the longest suffix of matching elements is shared with the original list)
+func (l *List[T]) Filter(f func(T) bool) *List[T] {
+	var kept []T
+	var suffix *node[T]
+	var suffixSize uint64
+	for current := l.headNode(); current != nil; current = current.next {
+		if !f(current.value) {
+			// Everything matched so far (since the last rejected element)
+			// can't be shared anymore
+			for n := suffix; n != nil && n != current; n = n.next {
+				kept = append(kept, n.value)
+			}
+			suffix, suffixSize = nil, 0
+			continue
+		}
+		if suffix == nil {
+			suffix = current
+		}
+		suffixSize++
+	}
+
+	rest := l.derive(suffix, suffixSize)
+	for i := len(kept) - 1; i >= 0; i-- {
+		rest = rest.Cons(kept[i])
+	}
+	return rest
+}
+
+// Reduce reduces the list to a single value
+func (l *List[T]) Reduce(f func(T, T) T, initial T) T {
+	acc := initial
+	for current := l.headNode(); current != nil; current = current.next {
+		acc = f(acc, current.value)
+	}
+	return acc
+}
+
+// ForEach calls the function for all the elements of the list
+func (l *List[T]) ForEach(f func(T)) {
+	for current := l.headNode(); current != nil; current = current.next {
+		f(current.value)
+	}
+}
+
+// Any checks if any element of the list matches the predicate
+func (l *List[T]) Any(f func(T) bool) bool {
+	for current := l.headNode(); current != nil; current = current.next {
+		if f(current.value) {
+			return true
+		}
+	}
+	return false
+}
+
+// All checks if all the elements of the list match the predicate
+func (l *List[T]) All(f func(T) bool) bool {
+	for current := l.headNode(); current != nil; current = current.next {
+		if !f(current.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equals checks if two lists contain the same elements in the same order
+func (l *List[T]) Equals(other *List[T]) bool {
+	if l.Size() != other.Size() {
+		return false
+	}
+	a, b := l.headNode(), other.headNode()
+	for a != nil {
+		if a == b {
+			// Shared structure from here on
+			return true
+		}
+		if !l.equal(a.value, b.value) {
+			return false
+		}
+		a, b = a.next, b.next
+	}
+	return true
+}
+
+// ToSlice returns the list as a slice
+func (l *List[T]) ToSlice() []T {
+	items := make([]T, 0, l.Size())
+	for current := l.headNode(); current != nil; current = current.next {
+		items = append(items, current.value)
+	}
+	return items
+}
+
+// String returns a string representation of the list (elements are formatted with %v)
+func (l *List[T]) String() string {
+	return l.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the list with every element formatted by f
+func (l *List[T]) StringFunc(f func(T) string) string {
+	return common.FormatSlice(l.ToSlice(), f)
+}
+
+func (l *List[T]) headNode() *node[T] {
+	if l == nil {
+		return nil
+	}
+	return l.head
+}
+
+// copyPrefix returns a new list made by copies of the first n nodes of l,
+// followed by rest (which is shared)
+func (l *List[T]) copyPrefix(n uint64, rest *List[T]) *List[T] {
+	result := l.derive(rest.headNode(), rest.Size()+n)
+	if n == 0 {
+		return result
+	}
+	first := &node[T]{value: l.head.value}
+	last := first
+	for i, current := uint64(1), l.head.next; i < n; i, current = i+1, current.next {
+		last.next = &node[T]{value: current.value}
+		last = last.next
+	}
+	last.next = rest.headNode()
+	result.head = first
+	return result
+}
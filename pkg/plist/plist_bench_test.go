@@ -0,0 +1,60 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plist_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	plist "github.com/pzaino/gods/pkg/plist"
+)
+
+// BenchmarkCons builds a list of n elements by prepending them
+func BenchmarkCons(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		for i := 0; i < b.N; i++ {
+			l := plist.New[int]()
+			for v := 0; v < n; v++ {
+				l = l.Cons(v)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkSet returns a new version of a list of n elements with the
+// element in the middle replaced (the nodes before it are copied)
+func BenchmarkSet(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		l := plist.NewFromSlice(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = l.Set(uint64(n/2), i)
+		}
+	})
+}
+
+// BenchmarkFind looks for the value in the middle of n elements
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		target := values[n/2]
+		l := plist.NewFromSlice(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = l.Contains(target)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plist provides a persistent (immutable) singly linked list.
+package plist_test
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	plist "github.com/pzaino/gods/pkg/plist"
+)
+
+const (
+	errExpectedNoError = "Expected no error, but got %v"
+	errExpectedError   = "Expected an error, but got nil"
+	errExpectedSlice   = "Expected %v, but got %v"
+	errExpectedSize    = "Expected size %d, but got %d"
+)
+
+func checkList(t *testing.T, l *plist.List[int], expected []int) {
+	t.Helper()
+	if l.Size() != uint64(len(expected)) {
+		t.Errorf(errExpectedSize, len(expected), l.Size())
+	}
+	if !reflect.DeepEqual(l.ToSlice(), expected) {
+		t.Errorf(errExpectedSlice, expected, l.ToSlice())
+	}
+}
+
+func TestNew(t *testing.T) {
+	l := plist.New[int]()
+	if !l.IsEmpty() {
+		t.Errorf("Expected list to be empty")
+	}
+	if _, err := l.Head(); !errors.Is(err, plist.ErrEmpty) {
+		t.Errorf(errExpectedError)
+	}
+	if _, err := l.Tail(); !errors.Is(err, plist.ErrEmpty) {
+		t.Errorf(errExpectedError)
+	}
+
+	var zero plist.List[int]
+	checkList(t, zero.Cons(1), []int{1})
+}
+
+func TestConsHeadTail(t *testing.T) {
+	base := plist.NewFromSlice([]int{2, 3})
+	l := base.Cons(1)
+	checkList(t, l, []int{1, 2, 3})
+	checkList(t, base, []int{2, 3})
+
+	head, err := l.Head()
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if head != 1 {
+		t.Errorf(errExpectedSlice, 1, head)
+	}
+	tail, err := l.Tail()
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	if !tail.Equals(base) {
+		t.Errorf(errExpectedSlice, base.ToSlice(), tail.ToSlice())
+	}
+}
+
+func TestAppendAndConcat(t *testing.T) {
+	base := plist.NewFromSlice([]int{1, 2})
+	appended := base.Append(3)
+	checkList(t, appended, []int{1, 2, 3})
+	checkList(t, base, []int{1, 2})
+	checkList(t, plist.New[int]().Append(1), []int{1})
+
+	other := plist.NewFromSlice([]int{3, 4})
+	checkList(t, base.Concat(other), []int{1, 2, 3, 4})
+	checkList(t, base.Concat(plist.New[int]()), []int{1, 2})
+	checkList(t, plist.New[int]().Concat(other), []int{3, 4})
+	checkList(t, base, []int{1, 2})
+	checkList(t, other, []int{3, 4})
+}
+
+func TestTakeDropSetGet(t *testing.T) {
+	l := plist.NewFromSlice([]int{1, 2, 3, 4, 5})
+	checkList(t, l.Take(2), []int{1, 2})
+	checkList(t, l.Take(10), []int{1, 2, 3, 4, 5})
+	checkList(t, l.Drop(2), []int{3, 4, 5})
+	checkList(t, l.Drop(10), []int{})
+
+	updated, err := l.Set(2, 30)
+	if err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	checkList(t, updated, []int{1, 2, 30, 4, 5})
+	checkList(t, l, []int{1, 2, 3, 4, 5})
+	if _, err := l.Set(5, 0); !errors.Is(err, plist.ErrOutOfBounds) {
+		t.Errorf(errExpectedError)
+	}
+
+	v, err := l.Get(4)
+	if err != nil || v != 5 {
+		t.Errorf(errExpectedSlice, 5, v)
+	}
+	if _, err := l.Get(5); !errors.Is(err, plist.ErrOutOfBounds) {
+		t.Errorf(errExpectedError)
+	}
+
+	if i, err := l.IndexOf(3); err != nil || i != 2 {
+		t.Errorf(errExpectedSlice, 2, i)
+	}
+	if l.Contains(42) {
+		t.Errorf("Expected list not to contain 42")
+	}
+}
+
+func TestFunctionalPipeline(t *testing.T) {
+	l := plist.NewFromSlice([]int{1, 2, 3, 4, 5, 6})
+
+	evens := l.Filter(func(v int) bool { return v%2 == 0 })
+	checkList(t, evens, []int{2, 4, 6})
+	checkList(t, l.Filter(func(v int) bool { return v > 3 }), []int{4, 5, 6})
+	checkList(t, l.Filter(func(v int) bool { return v < 3 }), []int{1, 2})
+	checkList(t, l.Filter(func(int) bool { return false }), []int{})
+
+	doubled := evens.Map(func(v int) int { return v * 2 })
+	checkList(t, doubled, []int{4, 8, 12})
+	checkList(t, doubled.Reverse(), []int{12, 8, 4})
+
+	sum := doubled.Reduce(func(a, b int) int { return a + b }, 0)
+	if sum != 24 {
+		t.Errorf(errExpectedSlice, 24, sum)
+	}
+	if !doubled.All(func(v int) bool { return v%4 == 0 }) {
+		t.Errorf("Expected all elements to be multiples of 4")
+	}
+	if doubled.Any(func(v int) bool { return v > 12 }) {
+		t.Errorf("Expected no element greater than 12")
+	}
+
+	count := 0
+	l.ForEach(func(int) { count++ })
+	if count != 6 {
+		t.Errorf(errExpectedSize, 6, count)
+	}
+	checkList(t, l, []int{1, 2, 3, 4, 5, 6})
+}
+
+func TestEquals(t *testing.T) {
+	a := plist.NewFromSlice([]int{1, 2, 3})
+	b := plist.NewFromSlice([]int{1, 2, 3})
+	if !a.Equals(b) || !a.Equals(a.Cons(0).Drop(1)) {
+		t.Errorf("Expected lists to be equal")
+	}
+	if a.Equals(a.Append(4)) || a.Equals(plist.NewFromSlice([]int{1, 2, 4})) {
+		t.Errorf("Expected lists not to be equal")
+	}
+}
+
+func TestConcurrentSharing(t *testing.T) {
+	base := plist.NewFromSlice([]int{1, 2, 3})
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			l := base.Cons(i).Append(i).Filter(func(v int) bool { return v != 2 })
+			if l.Size() != 4 && i != 2 {
+				t.Errorf(errExpectedSize, 4, l.Size())
+			}
+		}(i)
+	}
+	wg.Wait()
+	checkList(t, base, []int{1, 2, 3})
+}
+
+func TestString(t *testing.T) {
+	l := plist.NewFromSlice([]int{1, 2, 3})
+	if s := l.String(); s != "[1 2 3]" {
+		t.Errorf("expected [1 2 3], got %s", s)
+	}
+	if s := plist.New[int]().StringFunc(nil); s != "[]" {
+		t.Errorf("expected [], got %s", s)
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	l := plist.NewWithComparator(slices.Equal[[]string]).Cons([]string{"b"}).Cons([]string{"a"})
+
+	// The derived lists keep the comparator
+	derived := l.Reverse().Append([]string{"c"})
+	if !derived.Contains([]string{"a"}) || derived.Contains([]string{"z"}) {
+		t.Errorf("unexpected Contains results on %v", derived)
+	}
+	if index, err := derived.IndexOf([]string{"c"}); err != nil || index != 2 {
+		t.Errorf("expected index 2, got %d (%v)", index, err)
+	}
+	if !l.Equals(derived.Reverse().Drop(1)) {
+		t.Error("expected the lists to be equal")
+	}
+}
@@ -17,7 +17,9 @@ package pqueue
 
 import (
 	"errors"
-	"strings"
+	"fmt"
+
+	common "github.com/pzaino/gods/pkg/common"
 )
 
 const (
@@ -26,16 +28,23 @@ const (
 	ErrValueNotFound   = "value not found"
 )
 
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureDeepCopy)
+}
+
 // Element represents an element in the priority queue with a value and a priority.
-type Element[T comparable] struct {
+type Element[T any] struct {
 	Value    T
 	Priority int
 }
 
 // PriorityQueue is a priority queue data structure
-type PriorityQueue[T comparable] struct {
-	data []Element[T]
-	size uint64
+type PriorityQueue[T any] struct {
+	data   []Element[T]
+	size   uint64
+	equals common.EqualFunc[T]
 }
 
 // Helper functions for heap operations
@@ -77,7 +86,23 @@ func (pq *PriorityQueue[T]) downHeap(index uint64) {
 
 // New creates a new PriorityQueue
 func New[T comparable]() *PriorityQueue[T] {
-	return &PriorityQueue[T]{}
+	return &PriorityQueue[T]{equals: common.Equal[T]}
+}
+
+// NewWithComparator creates a new PriorityQueue that compares its values with
+// the given function, so it can hold types that are not comparable (slices, maps, ...)
+func NewWithComparator[T any](equals func(a, b T) bool) *PriorityQueue[T] {
+	return &PriorityQueue[T]{equals: equals}
+}
+
+// newEmpty creates a new empty priority queue that compares its values like pq
+func (pq *PriorityQueue[T]) newEmpty() *PriorityQueue[T] {
+	return &PriorityQueue[T]{equals: pq.equals}
+}
+
+// equal compares two values with the priority queue comparator
+func (pq *PriorityQueue[T]) equal(a, b T) bool {
+	return common.Equals(pq.equals, a, b)
 }
 
 // IsEmpty returns true if the priority queue is empty
@@ -157,7 +182,7 @@ func (pq *PriorityQueue[T]) UpdatePriority(value T, newPriority int) error {
 	}
 
 	for i, e := range pq.data {
-		if e.Value == value {
+		if pq.equal(e.Value, value) {
 			pq.data[i].Priority = newPriority
 			pq.upHeap(uint64(i))
 			pq.downHeap(uint64(i))
@@ -174,7 +199,7 @@ func (pq *PriorityQueue[T]) UpdateValue(value T, newValue T) error {
 	}
 
 	for i, e := range pq.data {
-		if e.Value == value {
+		if pq.equal(e.Value, value) {
 			pq.data[i].Value = newValue
 			return nil
 		}
@@ -223,7 +248,7 @@ func (pq *PriorityQueue[T]) Contains(value T) bool {
 	}
 
 	for _, e := range pq.data {
-		if e.Value == value {
+		if pq.equal(e.Value, value) {
 			return true
 		}
 	}
@@ -236,21 +261,33 @@ func (pq *PriorityQueue[T]) Equals(other *PriorityQueue[T]) bool {
 		return false
 	}
 	for i, e := range pq.data {
-		if e.Value != other.data[i].Value || e.Priority != other.data[i].Priority {
+		if !pq.equal(e.Value, other.data[i].Value) || e.Priority != other.data[i].Priority {
 			return false
 		}
 	}
 	return true
 }
 
-// Copy returns a copy of the priority queue
+// Copy returns a copy of the priority queue. The copy is shallow: the elements are
+// copied by assignment, so pointers, slices and maps inside them are shared
+// with the original (use CloneWith for a deep copy)
 func (pq *PriorityQueue[T]) Copy() *PriorityQueue[T] {
-	copy := New[T]()
+	copy := pq.newEmpty()
 	copy.data = append(copy.data, pq.data...)
 	copy.size = pq.size
 	return copy
 }
 
+// CloneWith returns a copy of the priority queue with a copy of every element
+// made by copier, to deep copy elements that hold pointers, slices or maps
+func (pq *PriorityQueue[T]) CloneWith(copier func(T) T) *PriorityQueue[T] {
+	clone := pq.Copy()
+	for i := range clone.data {
+		clone.data[i].Value = copier(clone.data[i].Value)
+	}
+	return clone
+}
+
 // Merge merges two priority queues (it considers the priority)
 func (pq *PriorityQueue[T]) Merge(other *PriorityQueue[T]) {
 	// Merge the two slices considering the priority
@@ -261,27 +298,24 @@ func (pq *PriorityQueue[T]) Merge(other *PriorityQueue[T]) {
 	other.Clear()
 }
 
-// String returns a string representation of the priority queue
-func (pq *PriorityQueue[T]) String(f func(T) string) string {
-	return pq.dataString(f)
+// String returns a string representation of the priority queue (in heap
+// order, elements are formatted with %v)
+func (pq *PriorityQueue[T]) String() string {
+	return pq.StringFunc(nil)
 }
 
-func (pq *PriorityQueue[T]) dataString(f func(T) string) string {
-	var sb strings.Builder
-	sb.WriteString("[")
-	for i, e := range pq.data {
-		sb.WriteString(f(e.Value))
-		if i < len(pq.data)-1 {
-			sb.WriteString(", ")
-		}
+// StringFunc returns a string representation of the priority queue (in heap
+// order) with every element formatted by f
+func (pq *PriorityQueue[T]) StringFunc(f func(T) string) string {
+	if f == nil {
+		f = func(v T) string { return fmt.Sprint(v) }
 	}
-	sb.WriteString("]")
-	return sb.String()
+	return common.FormatSlice(pq.data[:pq.size], func(e Element[T]) string { return f(e.Value) })
 }
 
 // Map creates a new priority queue with the results of applying the function to each element
 func (pq *PriorityQueue[T]) Map(f func(T) T) *PriorityQueue[T] {
-	newQueue := New[T]()
+	newQueue := pq.newEmpty()
 	for i := 0; i < len(pq.data); i++ {
 		newQueue.Enqueue(f(pq.data[i].Value), pq.data[i].Priority)
 	}
@@ -357,7 +391,7 @@ func (pq *PriorityQueue[T]) All(f func(T) bool) bool {
 // IndexOf returns the index of the first element with the given value
 func (pq *PriorityQueue[T]) IndexOf(value T) (uint64, error) {
 	for i := uint64(0); i < pq.size; i++ {
-		if pq.data[i].Value == value {
+		if pq.equal(pq.data[i].Value, value) {
 			return i, nil
 		}
 	}
@@ -369,7 +403,7 @@ func (pq *PriorityQueue[T]) LastIndexOf(value T) (uint64, error) {
 	index := uint64(0)
 	found := false
 	for i := uint64(0); i < pq.size; i++ {
-		if pq.data[i].Value == value {
+		if pq.equal(pq.data[i].Value, value) {
 			index = i
 			found = true
 		}
@@ -408,7 +442,7 @@ func (pq *PriorityQueue[T]) FindLastIndex(f func(T) bool) (uint64, error) {
 
 // FindAll returns all elements that match the predicate
 func (pq *PriorityQueue[T]) FindAll(f func(T) bool) *PriorityQueue[T] {
-	newQueue := New[T]()
+	newQueue := pq.newEmpty()
 	for i := uint64(0); i < pq.size; i++ {
 		if f(pq.data[i].Value) {
 			newQueue.Enqueue(pq.data[i].Value, pq.data[i].Priority)
@@ -0,0 +1,70 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pqueue_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	pqueue "github.com/pzaino/gods/pkg/pqueue"
+)
+
+// newBenchQueue returns a priority queue holding values, with their value
+// as priority
+func newBenchQueue(values []int) *pqueue.PriorityQueue[int] {
+	pq := pqueue.New[int]()
+	for _, v := range values {
+		pq.Enqueue(v, v)
+	}
+	return pq
+}
+
+// BenchmarkEnqueue enqueues n elements
+func BenchmarkEnqueue(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = newBenchQueue(values)
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkDequeue dequeues the highest priority element of n (and enqueues
+// it back, so the size stays n)
+func BenchmarkDequeue(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		pq := newBenchQueue(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			v, _ := pq.Dequeue()
+			pq.Enqueue(v, v)
+		}
+	})
+}
+
+// BenchmarkFind looks for the value in the middle of n elements
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		target := values[n/2]
+		pq := newBenchQueue(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = pq.Contains(target)
+		}
+	})
+}
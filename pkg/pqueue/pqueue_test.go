@@ -17,6 +17,7 @@ package pqueue_test
 
 import (
 	"fmt"
+	"slices"
 	"testing"
 
 	"github.com/pzaino/gods/pkg/pqueue"
@@ -123,13 +124,16 @@ func TestCopy(t *testing.T) {
 func TestString(t *testing.T) {
 	pq := pqueue.New[int]()
 	pq.Enqueue(10, 1)
-	str := pq.String(func(val int) string {
-		return fmt.Sprintf("%d", val)
+	str := pq.StringFunc(func(val int) string {
+		return fmt.Sprintf("<%d>", val)
 	})
-	expected := "[10]"
+	expected := "[<10>]"
 	if str != expected {
 		t.Fatalf("Expected string representation to be %s, got %s", expected, str)
 	}
+	if str = pq.String(); str != "[10]" {
+		t.Fatalf("Expected string representation to be [10], got %s", str)
+	}
 }
 
 func TestMap(t *testing.T) {
@@ -480,3 +484,56 @@ func TestCheckSize(t *testing.T) {
 		t.Fatal("Expected priority queue size to be 3 after calling CheckSize")
 	}
 }
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := pqueue.New[*int]()
+	c.Enqueue(&a, 1)
+	c.Enqueue(&b, 2)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range dequeueAll(clone) {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+// dequeueAll removes and returns all the elements of the priority queue
+func dequeueAll[T comparable](pq *pqueue.PriorityQueue[T]) []T {
+	var items []T
+	for !pq.IsEmpty() {
+		v, _ := pq.Dequeue()
+		items = append(items, v)
+	}
+	return items
+}
+
+func TestNewWithComparator(t *testing.T) {
+	pq := pqueue.NewWithComparator(slices.Equal[[]string])
+	pq.Enqueue([]string{"low"}, 1)
+	pq.Enqueue([]string{"high"}, 10)
+
+	if err := pq.UpdatePriority([]string{"low"}, 20); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if v, err := pq.Peek(); err != nil || !slices.Equal(v, []string{"low"}) {
+		t.Errorf("expected [low], got %v (%v)", v, err)
+	}
+	if !pq.Contains([]string{"high"}) || pq.Contains([]string{"none"}) {
+		t.Error("unexpected Contains results")
+	}
+	if !pq.Equals(pq.Copy()) {
+		t.Error("expected the copy to be equal to the original queue")
+	}
+}
@@ -16,56 +16,399 @@
 package queue
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
 	"errors"
-	"strings"
+	"io"
+	"math/rand"
+	"sort"
+
+	common "github.com/pzaino/gods/pkg/common"
 )
 
+// Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
 const (
 	ErrQueueIsEmpty  = "queue is empty"
 	ErrValueNotFound = "value not found"
 )
 
-// Queue is a FIFO data structure
-type Queue[T comparable] struct {
-	data []T
-	size uint64
+// Sentinel errors returned by the Queue methods (use errors.Is to check for them)
+var (
+	ErrEmpty       = errors.New(ErrQueueIsEmpty)
+	ErrNotFound    = errors.New(ErrValueNotFound)
+	ErrFull        = errors.New("queue is full")
+	ErrOutOfBounds = errors.New("index out of bounds")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureObservers, common.FeatureDeepCopy, common.FeatureJSON)
+}
+
+// OverflowPolicy tells a bounded queue what to do when an element is enqueued
+// while the queue is full
+type OverflowPolicy uint8
+
+const (
+	// ErrorOnFull rejects the new element and returns ErrFull (default)
+	ErrorOnFull OverflowPolicy = iota
+	// DropOldest removes the element at the front of the queue to make room
+	DropOldest
+	// DropNewest silently discards the new element
+	DropNewest
+)
+
+// minRingSize is the number of slots allocated by the first Enqueue, the ring
+// is never shrunk below it
+const minRingSize = 16
+
+// Queue is a FIFO data structure, the elements are stored in a ring (data is
+// used circularly starting at head) so Enqueue and Dequeue are amortized O(1)
+type Queue[T any] struct {
+	data     []T
+	head     uint64
+	size     uint64
+	capacity uint64 // 0 means unbounded
+	policy   OverflowPolicy
+	dropped  uint64
+	equals   common.EqualFunc[T]
+	obs      common.Observers[T]
 }
 
 // New creates a new Queue
 func New[T comparable]() *Queue[T] {
-	return &Queue[T]{}
+	return &Queue[T]{equals: common.Equal[T]}
+}
+
+// NewWithComparator creates a new Queue that compares its elements with the
+// given function, so it can hold types that are not comparable (slices, maps, ...)
+func NewWithComparator[T any](equals func(a, b T) bool) *Queue[T] {
+	return &Queue[T]{equals: equals}
+}
+
+// NewWithCapacity creates a new bounded Queue that holds at most capacity
+// elements (0 means unbounded), when full Enqueue returns ErrFull
+func NewWithCapacity[T comparable](capacity uint64) *Queue[T] {
+	return &Queue[T]{capacity: capacity, equals: common.Equal[T]}
+}
+
+// NewWithPolicy creates a new bounded Queue with the given overflow policy
+func NewWithPolicy[T comparable](capacity uint64, policy OverflowPolicy) *Queue[T] {
+	return &Queue[T]{capacity: capacity, policy: policy, equals: common.Equal[T]}
+}
+
+// Adopt creates a new unbounded Queue that uses items (from the front to the
+// back) as its backing storage, the slice is not copied and the caller must
+// not use it afterwards
+func Adopt[T comparable](items []T) *Queue[T] {
+	q := New[T]()
+	q.data = items
+	q.size = uint64(len(items))
+	return q
+}
+
+// AdoptWithComparator is like Adopt for a Queue that compares its elements
+// with the given function (see NewWithComparator)
+func AdoptWithComparator[T any](items []T, equals func(a, b T) bool) *Queue[T] {
+	q := NewWithComparator(equals)
+	q.data = items
+	q.size = uint64(len(items))
+	return q
+}
+
+// newEmpty creates a new empty queue that compares its elements like q
+func (q *Queue[T]) newEmpty() *Queue[T] {
+	return &Queue[T]{equals: q.equals}
+}
+
+// equal compares two elements with the queue comparator
+func (q *Queue[T]) equal(x, y T) bool {
+	return common.Equals(q.equals, x, y)
+}
+
+// slot returns the position in data of the i-th element from the front
+func (q *Queue[T]) slot(i uint64) uint64 {
+	j := q.head + i
+	if n := uint64(len(q.data)); j >= n {
+		j -= n
+	}
+	return j
+}
+
+// at returns the i-th element from the front
+func (q *Queue[T]) at(i uint64) T {
+	return q.data[q.slot(i)]
+}
+
+// checkRange returns an error wrapping ErrOutOfBounds in a common.IndexError
+// unless start <= end <= size
+func (q *Queue[T]) checkRange(start, end uint64) error {
+	if end > q.size {
+		return common.NewIndexError(ErrOutOfBounds, end, q.size)
+	}
+	if start > end {
+		return common.NewIndexError(ErrOutOfBounds, start, q.size)
+	}
+	return nil
+}
+
+// resize moves the elements to a new ring of n slots (n must be >= size),
+// with the front of the queue at the start of the ring
+func (q *Queue[T]) resize(n uint64) {
+	data := make([]T, n)
+	if q.head+q.size <= uint64(len(q.data)) {
+		copy(data, q.data[q.head:q.head+q.size])
+	} else {
+		k := copy(data, q.data[q.head:])
+		copy(data[k:], q.data[:q.size-uint64(k)])
+	}
+	q.data = data
+	q.head = 0
+}
+
+// items returns the elements (from the front to the back) as a slice of the
+// ring, making them contiguous first if they wrap around
+func (q *Queue[T]) items() []T {
+	if q.head+q.size > uint64(len(q.data)) {
+		q.resize(uint64(len(q.data)))
+	}
+	return q.data[q.head : q.head+q.size]
+}
+
+// push adds an element to the back of the ring, growing it if it's full
+func (q *Queue[T]) push(elem T) {
+	if q.size == uint64(len(q.data)) {
+		q.resize(max(2*q.size, minRingSize))
+	}
+	q.data[q.slot(q.size)] = elem
+	q.size++
+}
+
+// pop removes and returns the element at the front of the ring (the queue must
+// not be empty), the ring is halved when less than a quarter of it is used
+func (q *Queue[T]) pop() T {
+	var zero T
+	elem := q.data[q.head]
+	q.data[q.head] = zero // let the GC collect the element
+	q.head = q.slot(1)
+	q.size--
+	if q.size == 0 {
+		q.head = 0
+	}
+	if n := uint64(len(q.data)); n > minRingSize && q.size < n/4 {
+		q.resize(n / 2)
+	}
+	return elem
+}
+
+// OnInsert registers a function called after an element is added to the queue
+// (nil removes it). The index is counted from the front (see common.Observers)
+func (q *Queue[T]) OnInsert(f func(index uint64, v T)) {
+	q.obs.Insert = f
+}
+
+// OnRemove registers a function called after an element is removed from the
+// queue, including the elements dropped by the DropOldest policy (nil removes it)
+func (q *Queue[T]) OnRemove(f func(index uint64, v T)) {
+	q.obs.Remove = f
+}
+
+// OnClear registers a function called after all the elements are removed at
+// once by Clear or Detach (nil removes it)
+func (q *Queue[T]) OnClear(f func()) {
+	q.obs.Clear = f
+}
+
+// Capacity returns the maximum number of elements of the queue (0 means unbounded)
+func (q *Queue[T]) Capacity() uint64 {
+	return q.capacity
+}
+
+// SetCapacity sets the maximum number of elements of the queue (0 means
+// unbounded). If the queue holds more elements than the new capacity, the
+// oldest ones are dropped
+func (q *Queue[T]) SetCapacity(capacity uint64) {
+	q.capacity = capacity
+	if capacity != 0 && q.size > capacity {
+		n := q.size - capacity
+		dropped := make([]T, n)
+		for i := range dropped {
+			dropped[i] = q.pop()
+		}
+		q.dropped += n
+		q.obs.Removed(0, dropped...)
+	}
+}
+
+// OverflowPolicy returns the policy applied when the queue is full
+func (q *Queue[T]) OverflowPolicy() OverflowPolicy {
+	return q.policy
+}
+
+// SetOverflowPolicy sets the policy applied when the queue is full
+func (q *Queue[T]) SetOverflowPolicy(policy OverflowPolicy) {
+	q.policy = policy
+}
+
+// IsFull returns true if the queue is bounded and has reached its capacity
+func (q *Queue[T]) IsFull() bool {
+	return q.capacity != 0 && q.size >= q.capacity
+}
+
+// Dropped returns the number of elements discarded so far by the DropOldest
+// and DropNewest policies
+func (q *Queue[T]) Dropped() uint64 {
+	return q.dropped
 }
 
 // IsEmpty returns true if the queue is empty
 func (q *Queue[T]) IsEmpty() bool {
-	return len(q.data) == 0
+	return q.size == 0
 }
 
-// Enqueue adds an element to the end of the queue
-func (q *Queue[T]) Enqueue(elem T) {
-	q.data = append(q.data, elem)
-	q.size++
+// Enqueue adds an element to the end of the queue, if the queue is full the
+// overflow policy is applied (only ErrorOnFull returns an error)
+func (q *Queue[T]) Enqueue(elem T) error {
+	if q.IsFull() {
+		switch q.policy {
+		case DropOldest:
+			oldest := q.pop()
+			q.dropped++
+			q.obs.Removed(0, oldest)
+		case DropNewest:
+			q.dropped++
+			return nil
+		default:
+			return ErrFull
+		}
+	}
+	q.push(elem)
+	q.obs.Inserted(q.size-1, elem)
+	return nil
 }
 
 // Dequeue removes and returns the first element in the queue
 func (q *Queue[T]) Dequeue() (T, error) {
 	if q.IsEmpty() {
 		var rVal T
-		return rVal, errors.New(ErrQueueIsEmpty)
+		return rVal, ErrEmpty
 	}
-	elem := q.data[0]
-	q.data = q.data[1:]
-	q.size--
+	elem := q.pop()
+	q.obs.Removed(0, elem)
 	return elem, nil
 }
 
+// EnqueueN adds multiple elements to the end of the queue (in the given order),
+// the overflow policy is applied to each element. With ErrorOnFull either all
+// the elements are added or none is (and ErrFull is returned)
+func (q *Queue[T]) EnqueueN(items ...T) error {
+	n := uint64(len(items))
+	if q.capacity == 0 || q.size+n <= q.capacity {
+		start := q.size
+		if need := q.size + n; need > uint64(len(q.data)) {
+			q.resize(max(need, 2*uint64(len(q.data)), minRingSize))
+		}
+		for _, item := range items {
+			q.push(item)
+		}
+		q.obs.Inserted(start, items...)
+		return nil
+	}
+	if q.policy == ErrorOnFull {
+		return ErrFull
+	}
+	for _, item := range items {
+		_ = q.Enqueue(item) // drop policies never fail
+	}
+	return nil
+}
+
+// TryDequeue removes and returns the first element in the queue
+// it returns false (and the zero value) instead of an error when the queue is empty
+func (q *Queue[T]) TryDequeue() (T, bool) {
+	if q.IsEmpty() {
+		var rVal T
+		return rVal, false
+	}
+	elem := q.pop()
+	q.obs.Removed(0, elem)
+	return elem, true
+}
+
+// DequeueN removes and returns up to n elements from the front of the queue
+// (in FIFO order). It returns an error only if the queue is empty.
+func (q *Queue[T]) DequeueN(n uint64) ([]T, error) {
+	if q.IsEmpty() {
+		return nil, ErrEmpty
+	}
+	if n > q.size {
+		n = q.size
+	}
+	items := make([]T, n)
+	for i := range items {
+		items[i] = q.pop()
+	}
+	q.obs.Removed(0, items...)
+	return items, nil
+}
+
+// Drain returns a channel that receives the elements of the queue in FIFO
+// order, each element is dequeued only once it has been received. The channel
+// is closed when the queue is empty or ctx is done, since the queue is not
+// concurrent-safe it must not be used until then
+func (q *Queue[T]) Drain(ctx context.Context) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for !q.IsEmpty() {
+			select {
+			case out <- q.data[q.head]:
+				_, _ = q.Dequeue()
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
 // Peek returns the first element in the queue without removing it
 func (q *Queue[T]) Peek() (T, error) {
 	if q.IsEmpty() {
 		var rVal T
-		return rVal, errors.New(ErrQueueIsEmpty)
+		return rVal, ErrEmpty
+	}
+	return q.data[q.head], nil
+}
+
+// PeekAt returns the i-th element from the front of the queue (0 is the one
+// Peek returns) without removing it, the error wraps ErrOutOfBounds in a
+// common.IndexError if there are not enough elements
+func (q *Queue[T]) PeekAt(i uint64) (T, error) {
+	if q.IsEmpty() {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+	if i >= q.size {
+		var rVal T
+		return rVal, common.NewIndexError(ErrOutOfBounds, i, q.size)
+	}
+	return q.at(i), nil
+}
+
+// PeekBack returns the last element in the queue (the most recently
+// enqueued) without removing it
+func (q *Queue[T]) PeekBack() (T, error) {
+	if q.IsEmpty() {
+		var rVal T
+		return rVal, ErrEmpty
 	}
-	return q.data[0], nil
+	return q.at(q.size - 1), nil
 }
 
 // Size returns the number of elements in the queue
@@ -75,13 +418,108 @@ func (q *Queue[T]) Size() uint64 {
 
 // Clear removes all elements from the queue
 func (q *Queue[T]) Clear() {
-	q.data = []T{}
+	q.data = nil
+	q.head = 0
+	q.size = 0
+	q.obs.Cleared()
+}
+
+// Wipe overwrites every element of the queue, and every unused slot of its
+// storage, with the zero value and then releases the storage, so sensitive
+// data (tokens, keys, ...) doesn't stay reachable. The copies left behind
+// when the storage was reallocated to grow are out of reach and can't be
+// wiped: create the queue with enough capacity to avoid them
+func (q *Queue[T]) Wipe() {
+	clear(q.data[:cap(q.data)])
+	q.data = nil
+	q.head = 0
 	q.size = 0
+	q.obs.Cleared()
 }
 
-// Values returns all elements in the queue
+// Detach empties the queue and returns its backing slice (from the front to
+// the back) without copying it, the queue no longer references the returned slice
+func (q *Queue[T]) Detach() []T {
+	if q.size == 0 {
+		return nil
+	}
+	items := q.items()
+	q.data = nil
+	q.head = 0
+	q.size = 0
+	q.obs.Cleared()
+	return items
+}
+
+// DrainAll removes and returns all elements (from the front to the back) in a
+// new slice in one O(n) operation, useful for batch flushes. The queue keeps
+// its storage so it can be refilled without reallocating (use Detach to take
+// the storage instead)
+func (q *Queue[T]) DrainAll() []T {
+	items := q.Values()
+	if items == nil {
+		return nil
+	}
+	clear(q.data)
+	q.head = 0
+	q.size = 0
+	q.obs.Cleared()
+	return items
+}
+
+// Values returns a copy of all elements in the queue from the front to the
+// back (the order they are dequeued in), use ValuesReverse for the opposite order
 func (q *Queue[T]) Values() []T {
-	return q.data
+	if q.size == 0 {
+		return nil
+	}
+
+	items := make([]T, q.size)
+	for i := range items {
+		items[i] = q.at(uint64(i))
+	}
+	return items
+}
+
+// ToSlice returns a copy of all elements in the queue from the front to the
+// back (same as Values)
+func (q *Queue[T]) ToSlice() []T {
+	return q.Values()
+}
+
+// EncodeJSONStream writes the elements of the queue to w as a JSON array, one
+// element at a time (the output of json.Marshal on ToSlice, without the copy)
+func (q *Queue[T]) EncodeJSONStream(w io.Writer) error {
+	enc := common.NewJSONArrayEncoder[T](w)
+	for i := uint64(0); i < q.size; i++ {
+		if err := enc.Encode(q.at(i)); err != nil {
+			return err
+		}
+	}
+	return enc.Close()
+}
+
+// DecodeJSONStream appends the elements of the JSON array read from dec, one
+// element at a time, without holding the whole array in memory (see
+// common.DecodeJSONArray). The elements are enqueued with Enqueue, so a bounded
+// queue applies its overflow policy. The elements decoded before an error stay
+// in the queue
+func (q *Queue[T]) DecodeJSONStream(dec *json.Decoder) error {
+	return common.DecodeJSONArray(dec, q.Enqueue)
+}
+
+// ValuesReverse returns a copy of all elements in the queue from the back to
+// the front (the most recently enqueued first)
+func (q *Queue[T]) ValuesReverse() []T {
+	if q.size == 0 {
+		return nil
+	}
+
+	items := make([]T, q.size)
+	for i := uint64(0); i < q.size; i++ {
+		items[i] = q.at(q.size - 1 - i)
+	}
+	return items
 }
 
 // Contains returns true if the queue contains the given element
@@ -91,13 +529,56 @@ func (q *Queue[T]) Contains(elem T) bool {
 	}
 
 	for i := uint64(0); i < q.size; i++ {
-		if q.data[i] == elem {
+		if q.equal(q.at(i), elem) {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsAll returns true if the queue contains all the given elements (true
+// when none is given), they are all checked in a single pass over the queue
+func (q *Queue[T]) ContainsAll(elements ...T) bool {
+	if len(elements) == 0 {
+		return true
+	}
+
+	lookup := common.NewLookup(nil, q.equals, elements)
+	for i := uint64(0); i < q.size; i++ {
+		if lookup.Match(q.at(i)) && lookup.Remaining() == 0 {
 			return true
 		}
 	}
 	return false
 }
 
+// ContainsAny returns true if the queue contains at least one of the given
+// elements, they are all checked in a single pass over the queue
+func (q *Queue[T]) ContainsAny(elements ...T) bool {
+	if len(elements) == 0 {
+		return false
+	}
+
+	lookup := common.NewLookup(nil, q.equals, elements)
+	for i := uint64(0); i < q.size; i++ {
+		if lookup.Match(q.at(i)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountOf returns the number of elements in the queue equal to the given element
+func (q *Queue[T]) CountOf(element T) uint64 {
+	var count uint64
+	for i := uint64(0); i < q.size; i++ {
+		if q.equal(q.at(i), element) {
+			count++
+		}
+	}
+	return count
+}
+
 // Equals returns true if the queue is equal to another queue
 func (q *Queue[T]) Equals(other *Queue[T]) bool {
 	if q.Size() != other.Size() {
@@ -105,43 +586,46 @@ func (q *Queue[T]) Equals(other *Queue[T]) bool {
 	}
 
 	for i := uint64(0); i < q.size; i++ {
-		if q.data[i] != other.data[i] {
+		if !q.equal(q.at(i), other.at(i)) {
 			return false
 		}
 	}
 	return true
 }
 
-// Copy returns a copy of the queue
+// Copy returns a copy of the queue. The copy is shallow: the elements are
+// copied by assignment, so pointers, slices and maps inside them are shared
+// with the original (use CloneWith for a deep copy)
 func (q *Queue[T]) Copy() *Queue[T] {
-	copy := New[T]()
+	copy := &Queue[T]{capacity: q.capacity, policy: q.policy, equals: q.equals}
 	if q.IsEmpty() {
 		return copy
 	}
-	copy.data = append(copy.data, q.data...)
+	copy.data = append(copy.data, q.items()...)
 	copy.size = q.size
 	return copy
 }
 
-// String returns a string representation of the queue
-func (q *Queue[T]) String(f func(T) string) string {
-	if q.IsEmpty() {
-		return "[]"
+// CloneWith returns a copy of the queue with a copy of every element made by
+// copier, to deep copy elements that hold pointers, slices or maps
+func (q *Queue[T]) CloneWith(copier func(T) T) *Queue[T] {
+	clone := q.Copy()
+	for i := range clone.data {
+		clone.data[i] = copier(clone.data[i])
 	}
-	return q.dataString(f)
+	return clone
 }
 
-func (q *Queue[T]) dataString(f func(T) string) string {
-	var sb strings.Builder
-	sb.WriteString("[")
-	for i, e := range q.data {
-		sb.WriteString(f(e))
-		if i < len(q.data)-1 {
-			sb.WriteString(", ")
-		}
-	}
-	sb.WriteString("]")
-	return sb.String()
+// String returns a string representation of the queue, from the front to the back
+// (elements are formatted with %v)
+func (q *Queue[T]) String() string {
+	return q.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the queue, from the front to the
+// back, with every element formatted by f
+func (q *Queue[T]) StringFunc(f func(T) string) string {
+	return common.FormatSlice(q.items(), f)
 }
 
 // Map creates a new queue with the results of applying the function to all elements in the queue
@@ -149,21 +633,32 @@ func (q *Queue[T]) Map(f func(T) T) (*Queue[T], error) {
 	return q.MapRange(0, q.size, f)
 }
 
+// MapIndexed creates a new queue with the results of applying the function to
+// all elements in the queue and their index (0 is the front of the queue)
+func (q *Queue[T]) MapIndexed(f func(uint64, T) T) (*Queue[T], error) {
+	i := uint64(0)
+	return q.Map(func(v T) T {
+		result := f(i, v)
+		i++
+		return result
+	})
+}
+
 // MapFrom creates a new queue with the results of applying the function to all elements in the queue starting from the given index
 func (q *Queue[T]) MapFrom(start uint64, f func(T) T) (*Queue[T], error) {
 	return q.MapRange(start, q.size, f)
 }
 
-// MapRange creates a new queue with the results of applying the function to all elements in the queue within the given range
+// MapRange creates a new queue with the results of applying the function to all elements in the queue within the given range,
+// the error wraps ErrOutOfBounds unless start <= end <= size
 func (q *Queue[T]) MapRange(start, end uint64, f func(T) T) (*Queue[T], error) {
-	newQueue := New[T]()
-
-	if q.IsEmpty() {
-		return newQueue, nil
+	if err := q.checkRange(start, end); err != nil {
+		return nil, err
 	}
 
+	newQueue := q.newEmpty()
 	for i := start; i < end; i++ {
-		newQueue.Enqueue(f(q.data[i]))
+		newQueue.Enqueue(f(q.at(i)))
 	}
 	return newQueue, nil
 }
@@ -175,44 +670,139 @@ func (q *Queue[T]) Filter(f func(T) bool) {
 	}
 	var newData []T
 	var size uint64
+	items := q.items()
+	var removed []common.Removal[T]
 	for i := uint64(0); i < q.size; i++ {
-		if f(q.data[i]) {
-			newData = append(newData, q.data[i])
+		if f(items[i]) {
+			newData = append(newData, items[i])
 			size++
+		} else if q.obs.Remove != nil {
+			removed = append(removed, common.Removal[T]{Index: size, Value: items[i]})
 		}
 	}
 	q.data = newData
+	q.head = 0
 	q.size = size
+	q.obs.RemovedAll(removed)
+}
+
+// RemoveIf removes the elements that match the predicate (the opposite of
+// Filter) and returns how many were removed
+func (q *Queue[T]) RemoveIf(predicate func(T) bool) uint64 {
+	size := q.size
+	q.Filter(func(v T) bool { return !predicate(v) })
+	return size - q.size
+}
+
+// RemoveAll removes all the elements equal to value and returns how many were removed
+func (q *Queue[T]) RemoveAll(value T) uint64 {
+	return q.RemoveIf(func(v T) bool { return q.equal(v, value) })
+}
+
+// RemoveFirstN removes the first n elements equal to value (from the front of
+// the queue) and returns how many were removed
+func (q *Queue[T]) RemoveFirstN(value T, n uint64) uint64 {
+	removed := uint64(0)
+	return q.RemoveIf(func(v T) bool {
+		if removed < n && q.equal(v, value) {
+			removed++
+			return true
+		}
+		return false
+	})
 }
 
 // Reduce reduces the queue to a single value
 func (q *Queue[T]) Reduce(f func(T, T) T, initial T) T {
 	result := initial
 	for i := uint64(0); i < q.size; i++ {
-		result = f(result, q.data[i])
+		result = f(result, q.at(i))
 	}
 	return result
 }
 
-// ForEach applies the function to all the elements in the queue
+// ReduceInto reduces the queue to a value of a different type, starting from
+// initial and applying fn to each element from the front to the back
+func ReduceInto[T, A any](q *Queue[T], initial A, fn func(A, T) A) A {
+	result := initial
+	for i := uint64(0); i < q.size; i++ {
+		result = fn(result, q.at(i))
+	}
+	return result
+}
+
+// MapTo returns a new queue with the result of fn applied to every element of
+// src, keeping their order from the front to the back. Unlike Map the result
+// can hold a different type, e.g. to extract the IDs of a queue of structs. Its
+// elements are compared with == like the ones of New, use MapToWithComparator
+// when U is not comparable
+func MapTo[T any, U comparable](src *Queue[T], fn func(T) U) *Queue[U] {
+	return mapInto(src, fn, New[U]())
+}
+
+// MapToWithComparator is like MapTo, the new queue compares its elements with
+// the given function
+func MapToWithComparator[T, U any](src *Queue[T], fn func(T) U, equals func(a, b U) bool) *Queue[U] {
+	return mapInto(src, fn, NewWithComparator(equals))
+}
+
+// mapInto fills result with fn applied to every element of src
+func mapInto[T, U any](src *Queue[T], fn func(T) U, result *Queue[U]) *Queue[U] {
+	if src.size == 0 {
+		return result
+	}
+	result.data = make([]U, src.size)
+	for i := uint64(0); i < src.size; i++ {
+		result.data[i] = fn(src.at(i))
+	}
+	result.size = src.size
+	return result
+}
+
+// ForEach applies the function to all the elements in the queue from the
+// front to the back (the order they are dequeued in)
 func (q *Queue[T]) ForEach(f func(*T) error) error {
 	return q.ForRange(0, q.size, f)
 }
 
+// ForEachIndexed applies the function to all the elements in the queue and
+// their index (0 is the front of the queue)
+func (q *Queue[T]) ForEachIndexed(f func(uint64, *T) error) error {
+	i := uint64(0)
+	return q.ForEach(func(v *T) error {
+		err := f(i, v)
+		i++
+		return err
+	})
+}
+
+// ForEachReverse applies the function to all the elements in the queue from
+// the back to the front (the most recently enqueued first), without copying
+// them. It stops at the first error returned by the function
+func (q *Queue[T]) ForEachReverse(f func(*T) error) error {
+	for i := q.size; i > 0; i-- {
+		if err := f(&q.data[q.slot(i-1)]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ForFrom applies the function to all the elements in the queue starting from the given index
 func (q *Queue[T]) ForFrom(start uint64, f func(*T) error) error {
 	return q.ForRange(start, q.size, f)
 }
 
-// ForRange applies the function to all the elements in the queue within the given range
+// ForRange applies the function to all the elements in the queue within the given range,
+// the error wraps ErrOutOfBounds unless start <= end <= size
 func (q *Queue[T]) ForRange(start, end uint64, f func(*T) error) error {
-	if q.IsEmpty() {
-		return nil
+	if err := q.checkRange(start, end); err != nil {
+		return err
 	}
 
 	var err error
 	for i := start; i < end; i++ {
-		err = f(&q.data[i])
+		err = f(&q.data[q.slot(i)])
 		if err != nil {
 			break
 		}
@@ -226,7 +816,7 @@ func (q *Queue[T]) Any(f func(T) bool) bool {
 		return false
 	}
 	for i := uint64(0); i < q.size; i++ {
-		if f(q.data[i]) {
+		if f(q.at(i)) {
 			return true
 		}
 	}
@@ -239,7 +829,7 @@ func (q *Queue[T]) All(f func(T) bool) bool {
 		return false
 	}
 	for i := uint64(0); i < q.size; i++ {
-		if !f(q.data[i]) {
+		if !f(q.at(i)) {
 			return false
 		}
 	}
@@ -249,33 +839,33 @@ func (q *Queue[T]) All(f func(T) bool) bool {
 // IndexOf returns the index of the first element with the given value
 func (q *Queue[T]) IndexOf(value T) (uint64, error) {
 	if q.size == 0 {
-		return 0, errors.New(ErrQueueIsEmpty)
+		return 0, ErrEmpty
 	}
 
 	for i := uint64(0); i < q.size; i++ {
-		if q.data[i] == value {
+		if q.equal(q.at(i), value) {
 			return i, nil
 		}
 	}
-	return 0, errors.New(ErrValueNotFound)
+	return 0, ErrNotFound
 }
 
 // LastIndexOf returns the index of the last element with the given value
 func (q *Queue[T]) LastIndexOf(value T) (uint64, error) {
 	if q.size == 0 {
-		return 0, errors.New(ErrQueueIsEmpty)
+		return 0, ErrEmpty
 	}
 
 	index := uint64(0)
 	found := false
 	for i := uint64(0); i < q.size; i++ {
-		if q.data[i] == value {
+		if q.equal(q.at(i), value) {
 			index = i
 			found = true
 		}
 	}
 	if !found {
-		return 0, errors.New(ErrValueNotFound)
+		return 0, ErrNotFound
 	}
 	return index, nil
 }
@@ -283,43 +873,43 @@ func (q *Queue[T]) LastIndexOf(value T) (uint64, error) {
 // FindIndex returns the index of the first element that matches the predicate
 func (q *Queue[T]) FindIndex(f func(T) bool) (uint64, error) {
 	if q.size == 0 {
-		return 0, errors.New(ErrQueueIsEmpty)
+		return 0, ErrEmpty
 	}
 
 	for i := uint64(0); i < q.size; i++ {
-		if f(q.data[i]) {
+		if f(q.at(i)) {
 			return i, nil
 		}
 	}
-	return 0, errors.New(ErrValueNotFound)
+	return 0, ErrNotFound
 }
 
 // FindLastIndex returns the index of the last element that matches the predicate
 func (q *Queue[T]) FindLastIndex(f func(T) bool) (uint64, error) {
 	if q.size == 0 {
-		return 0, errors.New(ErrQueueIsEmpty)
+		return 0, ErrEmpty
 	}
 
 	index := uint64(0)
 	found := false
 	for i := uint64(0); i < q.size; i++ {
-		if f(q.data[i]) {
+		if f(q.at(i)) {
 			index = i
 			found = true
 		}
 	}
 	if !found {
-		return 0, errors.New(ErrValueNotFound)
+		return 0, ErrNotFound
 	}
 	return index, nil
 }
 
 // FindAll returns all elements that match the predicate
 func (q *Queue[T]) FindAll(f func(T) bool) *Queue[T] {
-	newQueue := New[T]()
+	newQueue := q.newEmpty()
 	for i := uint64(0); i < q.size; i++ {
-		if f(q.data[i]) {
-			newQueue.Enqueue(q.data[i])
+		if f(q.at(i)) {
+			newQueue.Enqueue(q.at(i))
 		}
 	}
 	return newQueue
@@ -329,17 +919,17 @@ func (q *Queue[T]) FindAll(f func(T) bool) *Queue[T] {
 func (q *Queue[T]) FindLast(f func(T) bool) (T, error) {
 	var result T
 	if q.size == 0 {
-		return result, errors.New(ErrQueueIsEmpty)
+		return result, ErrEmpty
 	}
 	found := false
 	for i := uint64(0); i < q.size; i++ {
-		if f(q.data[i]) {
-			result = q.data[i]
+		if f(q.at(i)) {
+			result = q.at(i)
 			found = true
 		}
 	}
 	if !found {
-		return result, errors.New(ErrValueNotFound)
+		return result, ErrNotFound
 	}
 	return result, nil
 }
@@ -348,9 +938,118 @@ func (q *Queue[T]) FindLast(f func(T) bool) (T, error) {
 func (q *Queue[T]) FindAllIndexes(f func(T) bool) []uint64 {
 	var result []uint64
 	for i := uint64(0); i < q.size; i++ {
-		if f(q.data[i]) {
+		if f(q.at(i)) {
 			result = append(result, i)
 		}
 	}
 	return result
 }
+
+// Sort sorts the queue according to the given function (the first element
+// after sorting is the next one to be dequeued)
+func (q *Queue[T]) Sort(less func(T, T) bool) {
+	items := q.items()
+	sort.Slice(items, func(i, j int) bool { return less(items[i], items[j]) })
+}
+
+// SortStable sorts the queue according to the given function keeping the
+// original order of equal elements
+func (q *Queue[T]) SortStable(less func(T, T) bool) {
+	items := q.items()
+	sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+}
+
+// Shuffle randomly reorders the elements of the queue using rng (if rng is
+// nil the global math/rand source is used)
+func (q *Queue[T]) Shuffle(rng *rand.Rand) {
+	common.Shuffle(rng, q.items())
+}
+
+// Sample returns a new (unbounded) queue with n elements chosen at random
+// (without replacement) using rng (if rng is nil the global math/rand source
+// is used). If n is greater than the size of the queue, all the elements are
+// returned in random order
+func (q *Queue[T]) Sample(n uint64, rng *rand.Rand) *Queue[T] {
+	if n > q.size {
+		n = q.size
+	}
+	items := make([]T, q.size)
+	copy(items, q.items())
+	common.Sample(rng, items, int(n))
+
+	result := q.newEmpty()
+	result.data = items[:n:n]
+	result.size = n
+	return result
+}
+
+// IsSorted returns true if the queue is sorted according to the given function
+func (q *Queue[T]) IsSorted(less func(T, T) bool) bool {
+	for i := uint64(1); i < q.size; i++ {
+		if less(q.at(i), q.at(i-1)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Compare compares the queue with other lexicographically (like bytes.Compare),
+// element by element from the front with compare, which returns a negative
+// number, zero or a positive number like cmp.Compare. It returns -1, 0 or +1,
+// a queue that is a prefix of the other is the smaller one
+func (q *Queue[T]) Compare(other *Queue[T], compare func(a, b T) int) int {
+	for i := uint64(0); i < min(q.size, other.size); i++ {
+		if c := compare(q.at(i), other.at(i)); c != 0 {
+			return cmp.Compare(c, 0)
+		}
+	}
+	return cmp.Compare(q.size, other.size)
+}
+
+// Min returns the smallest element of the queue according to less (the first
+// one if there are several), or ErrEmpty if the queue is empty
+func Min[T any](q *Queue[T], less func(T, T) bool) (T, error) {
+	if q.IsEmpty() {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+
+	result := q.at(0)
+	for i := uint64(1); i < q.size; i++ {
+		if less(q.at(i), result) {
+			result = q.at(i)
+		}
+	}
+	return result, nil
+}
+
+// Max returns the largest element of the queue according to less (the first
+// one if there are several), or ErrEmpty if the queue is empty
+func Max[T any](q *Queue[T], less func(T, T) bool) (T, error) {
+	return Min(q, func(x, y T) bool { return less(y, x) })
+}
+
+// MinOrdered returns the smallest element of a queue of an ordered type
+func MinOrdered[T cmp.Ordered](q *Queue[T]) (T, error) {
+	return Min(q, cmp.Less[T])
+}
+
+// MaxOrdered returns the largest element of a queue of an ordered type
+func MaxOrdered[T cmp.Ordered](q *Queue[T]) (T, error) {
+	return Max(q, cmp.Less[T])
+}
+
+// Sum returns the sum of all the elements of a numeric queue (0 if it's empty)
+func Sum[T common.Number](q *Queue[T]) T {
+	return ReduceInto(q, T(0), func(acc, v T) T { return acc + v })
+}
+
+// Average returns the arithmetic mean of the elements of a numeric queue
+// (computed in float64), or ErrEmpty if the queue is empty
+func Average[T common.Number](q *Queue[T]) (float64, error) {
+	if q.IsEmpty() {
+		return 0, ErrEmpty
+	}
+	sum := ReduceInto(q, 0.0, func(acc float64, v T) float64 { return acc + float64(v) })
+	return sum / float64(q.size), nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queue_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	queue "github.com/pzaino/gods/pkg/queue"
+)
+
+// newBenchQueue returns a queue holding values
+func newBenchQueue(values []int) *queue.Queue[int] {
+	q := queue.New[int]()
+	_ = q.EnqueueN(values...)
+	return q
+}
+
+// BenchmarkEnqueue enqueues n elements
+func BenchmarkEnqueue(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		for i := 0; i < b.N; i++ {
+			q := queue.New[int]()
+			for v := 0; v < n; v++ {
+				_ = q.Enqueue(v)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkDequeue dequeues an element from a queue of n elements (and
+// enqueues it back, so the size stays n)
+func BenchmarkDequeue(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		q := newBenchQueue(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			v, _ := q.Dequeue()
+			_ = q.Enqueue(v)
+		}
+	})
+}
+
+// BenchmarkFind looks for the value in the middle of n elements
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		target := values[n/2]
+		q := newBenchQueue(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = q.Contains(target)
+		}
+	})
+}
+
+// BenchmarkSort sorts n shuffled elements
+func BenchmarkSort(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			q := newBenchQueue(values)
+			b.StartTimer()
+			q.Sort(func(x, y int) bool { return x < y })
+		}
+	})
+}
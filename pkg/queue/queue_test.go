@@ -16,9 +16,17 @@
 package queue_test
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"slices"
 	"strconv"
+	"strings"
 	"testing"
 
+	common "github.com/pzaino/gods/pkg/common"
 	queue "github.com/pzaino/gods/pkg/queue"
 )
 
@@ -279,20 +287,23 @@ func TestString(t *testing.T) {
 	q.Enqueue(2)
 	q.Enqueue(3)
 
-	expected := "[1, 2, 3]"
-	result := q.String(func(elem int) string {
-		return strconv.Itoa(elem)
+	expected := "[1 2 3]"
+	if result := q.String(); result != expected {
+		t.Errorf("String returned incorrect result, got: %s, want: %s", result, expected)
+	}
+
+	expected = "[#1 #2 #3]"
+	result := q.StringFunc(func(elem int) string {
+		return "#" + strconv.Itoa(elem)
 	})
 
 	if result != expected {
-		t.Errorf("String returned incorrect result, got: %s, want: %s", result, expected)
+		t.Errorf("StringFunc returned incorrect result, got: %s, want: %s", result, expected)
 	}
 
 	q.Clear()
 	expected = "[]"
-	result = q.String(func(elem int) string {
-		return strconv.Itoa(elem)
-	})
+	result = q.String()
 
 	if result != expected {
 		t.Errorf("String returned incorrect result, got: %s, want: %s", result, expected)
@@ -820,3 +831,872 @@ func TestMapFrom(t *testing.T) {
 		t.Errorf("Mapped queue should have value 6 at index 1")
 	}
 }
+
+func TestEnqueueN(t *testing.T) {
+	q := queue.New[int]()
+	q.EnqueueN(1, 2, 3)
+	q.EnqueueN()
+
+	if q.Size() != 3 {
+		t.Errorf("Queue should have 3 elements")
+	}
+	item, err := q.Dequeue()
+	if err != nil {
+		t.Errorf(errExpectedNoError, err)
+	}
+	if item != 1 {
+		t.Errorf(errDeqShouldReturn, 1)
+	}
+}
+
+func TestTryDequeue(t *testing.T) {
+	q := queue.New[int]()
+	if _, ok := q.TryDequeue(); ok {
+		t.Errorf("TryDequeue should fail on an empty queue")
+	}
+
+	q.EnqueueN(1, 2)
+	item, ok := q.TryDequeue()
+	if !ok || item != 1 {
+		t.Errorf(errDeqShouldReturn, 1)
+	}
+	if q.Size() != 1 {
+		t.Errorf("Queue should have 1 element")
+	}
+}
+
+func TestDequeueN(t *testing.T) {
+	q := queue.New[int]()
+	if _, err := q.DequeueN(2); err == nil {
+		t.Errorf("DequeueN should return an error when the queue is empty")
+	}
+
+	q.EnqueueN(1, 2, 3, 4, 5)
+	items, err := q.DequeueN(2)
+	if err != nil {
+		t.Errorf(errExpectedNoError, err)
+	}
+	if len(items) != 2 || items[0] != 1 || items[1] != 2 {
+		t.Errorf("DequeueN should return [1 2], got %v", items)
+	}
+	if q.Size() != 3 {
+		t.Errorf("Queue should have 3 elements")
+	}
+
+	// Asking for more than available returns what's left
+	items, err = q.DequeueN(10)
+	if err != nil {
+		t.Errorf(errExpectedNoError, err)
+	}
+	if len(items) != 3 || items[0] != 3 || items[2] != 5 {
+		t.Errorf("DequeueN should return [3 4 5], got %v", items)
+	}
+	if !q.IsEmpty() || q.Size() != 0 {
+		t.Errorf(errExpectedQueueEmpty)
+	}
+}
+
+func TestSort(t *testing.T) {
+	q := queue.New[int]()
+	less := func(a, b int) bool { return a < b }
+	if !q.IsSorted(less) {
+		t.Errorf("An empty queue should be sorted")
+	}
+
+	q.EnqueueN(3, 1, 2)
+	if q.IsSorted(less) {
+		t.Errorf("Queue should not be sorted")
+	}
+	q.Sort(less)
+	if !q.IsSorted(less) {
+		t.Errorf("Queue should be sorted")
+	}
+	for _, expected := range []int{1, 2, 3} {
+		item, _ := q.Dequeue()
+		if item != expected {
+			t.Errorf(errDeqShouldReturn, expected)
+		}
+	}
+}
+
+func TestSortStable(t *testing.T) {
+	type item struct{ key, order int }
+	q := queue.New[item]()
+	q.EnqueueN(item{2, 0}, item{1, 1}, item{2, 2}, item{1, 3})
+	q.SortStable(func(a, b item) bool { return a.key < b.key })
+
+	expected := []item{{1, 1}, {1, 3}, {2, 0}, {2, 2}}
+	for i, v := range q.Values() {
+		if v != expected[i] {
+			t.Errorf("Expected %v at index %d, got %v", expected[i], i, v)
+		}
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	q := queue.New[int]()
+	if _, err := q.Dequeue(); !errors.Is(err, queue.ErrEmpty) {
+		t.Errorf("Expected %v, got %v", queue.ErrEmpty, err)
+	}
+	q.Enqueue(1)
+	if _, err := q.IndexOf(2); !errors.Is(err, queue.ErrNotFound) {
+		t.Errorf("Expected %v, got %v", queue.ErrNotFound, err)
+	}
+}
+
+func checkQueue(t *testing.T, q *queue.Queue[int], expected []int) {
+	t.Helper()
+	values := q.Values()
+	if q.Size() != uint64(len(expected)) || len(values) != len(expected) {
+		t.Fatalf("expected %v, got %v (size %d)", expected, values, q.Size())
+	}
+	for i := range expected {
+		if values[i] != expected[i] {
+			t.Fatalf("expected %v, got %v", expected, values)
+		}
+	}
+}
+
+func TestBoundedQueueErrorOnFull(t *testing.T) {
+	q := queue.NewWithCapacity[int](3)
+	if q.Capacity() != 3 || q.OverflowPolicy() != queue.ErrorOnFull {
+		t.Fatalf("expected capacity 3 and ErrorOnFull, got %d and %d", q.Capacity(), q.OverflowPolicy())
+	}
+	for i := 1; i <= 3; i++ {
+		if err := q.Enqueue(i); err != nil {
+			t.Fatalf(errExpectedNoError, err)
+		}
+	}
+	if !q.IsFull() {
+		t.Fatalf("expected queue to be full")
+	}
+	if err := q.Enqueue(4); !errors.Is(err, queue.ErrFull) {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+	checkQueue(t, q, []int{1, 2, 3})
+
+	// Dequeuing makes room again
+	_, _ = q.Dequeue()
+	if err := q.Enqueue(4); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	checkQueue(t, q, []int{2, 3, 4})
+
+	// EnqueueN is all or nothing
+	_, _ = q.Dequeue()
+	if err := q.EnqueueN(5, 6); !errors.Is(err, queue.ErrFull) {
+		t.Fatalf("expected ErrFull, got %v", err)
+	}
+	checkQueue(t, q, []int{3, 4})
+	if err := q.EnqueueN(5); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	checkQueue(t, q, []int{3, 4, 5})
+	if q.Dropped() != 0 {
+		t.Fatalf("expected no dropped elements, got %d", q.Dropped())
+	}
+}
+
+func TestBoundedQueueDropOldest(t *testing.T) {
+	q := queue.NewWithPolicy[int](3, queue.DropOldest)
+	for i := 1; i <= 5; i++ {
+		if err := q.Enqueue(i); err != nil {
+			t.Fatalf(errExpectedNoError, err)
+		}
+	}
+	checkQueue(t, q, []int{3, 4, 5})
+	if err := q.EnqueueN(6, 7, 8, 9); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	checkQueue(t, q, []int{7, 8, 9})
+	if q.Dropped() != 6 {
+		t.Fatalf("expected 6 dropped elements, got %d", q.Dropped())
+	}
+}
+
+func TestBoundedQueueDropNewest(t *testing.T) {
+	q := queue.NewWithPolicy[int](3, queue.DropNewest)
+	for i := 1; i <= 5; i++ {
+		if err := q.Enqueue(i); err != nil {
+			t.Fatalf(errExpectedNoError, err)
+		}
+	}
+	checkQueue(t, q, []int{1, 2, 3})
+	if err := q.EnqueueN(6, 7); err != nil {
+		t.Fatalf(errExpectedNoError, err)
+	}
+	checkQueue(t, q, []int{1, 2, 3})
+	if q.Dropped() != 4 {
+		t.Fatalf("expected 4 dropped elements, got %d", q.Dropped())
+	}
+}
+
+func TestQueueSetCapacity(t *testing.T) {
+	q := queue.New[int]()
+	if q.Capacity() != 0 || q.IsFull() {
+		t.Fatalf("expected an unbounded queue")
+	}
+	_ = q.EnqueueN(1, 2, 3, 4, 5)
+
+	q.SetCapacity(2)
+	checkQueue(t, q, []int{4, 5})
+	if q.Dropped() != 3 {
+		t.Fatalf("expected 3 dropped elements, got %d", q.Dropped())
+	}
+
+	q.SetOverflowPolicy(queue.DropOldest)
+	_ = q.Enqueue(6)
+	checkQueue(t, q, []int{5, 6})
+
+	// Copies keep the capacity and the policy
+	c := q.Copy()
+	_ = c.Enqueue(7)
+	checkQueue(t, c, []int{6, 7})
+	checkQueue(t, q, []int{5, 6})
+
+	q.SetCapacity(0)
+	_ = q.Enqueue(7)
+	checkQueue(t, q, []int{5, 6, 7})
+}
+
+func TestNewWithComparator(t *testing.T) {
+	q := queue.NewWithComparator(slices.Equal[[]int])
+	q.Enqueue([]int{1})
+	q.Enqueue([]int{2, 3})
+	q.Enqueue([]int{1})
+
+	if !q.Contains([]int{2, 3}) {
+		t.Error("expected queue to contain [2 3]")
+	}
+	if index, err := q.IndexOf([]int{1}); err != nil || index != 0 {
+		t.Errorf("expected index 0, got %v (%v)", index, err)
+	}
+	if index, err := q.LastIndexOf([]int{1}); err != nil || index != 2 {
+		t.Errorf("expected index 2, got %v (%v)", index, err)
+	}
+	if _, err := q.IndexOf([]int{3, 2}); err == nil {
+		t.Error("expected an error for a missing element")
+	}
+
+	if c := q.Copy(); !q.Equals(c) {
+		t.Error("expected the copy to be equal to the original queue")
+	}
+}
+
+func TestReduceInto(t *testing.T) {
+	q := queue.New[int]()
+	q.EnqueueN(1, 2, 3)
+	got := queue.ReduceInto(q, "", func(acc string, v int) string {
+		return acc + strconv.Itoa(v)
+	})
+	if got != "123" {
+		t.Errorf("expected 123, got %s", got)
+	}
+
+	if got := queue.ReduceInto(queue.New[int](), "empty", func(acc string, _ int) string { return "" }); got != "empty" {
+		t.Errorf("expected empty, got %s", got)
+	}
+}
+
+func TestMapTo(t *testing.T) {
+	type user struct {
+		id   int
+		tags []string
+	}
+	src := queue.NewWithComparator(func(a, b user) bool { return a.id == b.id })
+	_ = src.EnqueueN([]user{{1, []string{"a"}}, {2, nil}, {3, []string{"b", "c"}}}...)
+
+	ids := queue.MapTo(src, func(u user) int { return u.id })
+	if !slices.Equal(ids.Values(), []int{1, 2, 3}) || !ids.Contains(2) {
+		t.Errorf("expected the ids [1 2 3], got %v", ids.Values())
+	}
+
+	tags := queue.MapToWithComparator(src, func(u user) []string { return u.tags }, slices.Equal[[]string])
+	if tags.Size() != 3 || !tags.ContainsAll([]string{"b", "c"}) || tags.ContainsAll([]string{"c", "b"}) {
+		t.Errorf("expected the tags to be compared with the given function")
+	}
+
+	if empty := queue.MapTo(queue.New[int](), strconv.Itoa); !empty.IsEmpty() {
+		t.Errorf("expected an empty result, got %d values", empty.Size())
+	}
+}
+
+func TestShuffleAndSample(t *testing.T) {
+	q := queue.New[int]()
+	_ = q.EnqueueN(1, 2, 3, 4, 5, 6, 7, 8)
+
+	q.Shuffle(rand.New(rand.NewSource(42)))
+	if q.Size() != 8 {
+		t.Fatalf("expected size 8, got %d", q.Size())
+	}
+	for i := 1; i <= 8; i++ {
+		if !q.Contains(i) {
+			t.Errorf("expected shuffled queue to contain %d", i)
+		}
+	}
+
+	sample := q.Sample(3, rand.New(rand.NewSource(42)))
+	if sample.Size() != 3 {
+		t.Fatalf("expected size 3, got %d", sample.Size())
+	}
+	seen := make(map[int]bool)
+	for !sample.IsEmpty() {
+		v, _ := sample.Dequeue()
+		if seen[v] || !q.Contains(v) {
+			t.Errorf("unexpected sampled value %v", v)
+		}
+		seen[v] = true
+	}
+	if q.Size() != 8 {
+		t.Errorf("expected Sample not to modify the queue, got size %d", q.Size())
+	}
+}
+
+func TestAdoptDetach(t *testing.T) {
+	q := queue.Adopt([]int{1, 2, 3})
+	if v, err := q.Dequeue(); err != nil || v != 1 {
+		t.Errorf("expected 1, got %v (%v)", v, err)
+	}
+
+	items := q.Detach()
+	if !q.IsEmpty() || !slices.Equal(items, []int{2, 3}) {
+		t.Errorf("expected an empty queue and [2 3], got size %d and %v", q.Size(), items)
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	c := queue.Adopt([]int{3, 1, 4, 1, 5})
+
+	if v, err := queue.Min(c, func(a, b int) bool { return a < b }); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := queue.Max(c, func(a, b int) bool { return a < b }); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v, err := queue.MinOrdered(c); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := queue.MaxOrdered(c); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v := queue.Sum(c); v != 14 {
+		t.Errorf("expected sum 14, got %v", v)
+	}
+	if v, err := queue.Average(c); err != nil || v != 2.8 {
+		t.Errorf("expected average 2.8, got %v (%v)", v, err)
+	}
+
+	e := queue.New[int]()
+	if _, err := queue.Min(e, func(a, b int) bool { return a < b }); err != queue.ErrEmpty {
+		t.Errorf("expected %v, got %v", queue.ErrEmpty, err)
+	}
+	if _, err := queue.Average(e); err != queue.ErrEmpty {
+		t.Errorf("expected %v, got %v", queue.ErrEmpty, err)
+	}
+	if v := queue.Sum(e); v != 0 {
+		t.Errorf("expected sum 0, got %v", v)
+	}
+}
+
+// mirror applies the change notifications of a container to a slice
+type mirror struct {
+	t     *testing.T
+	items []int
+}
+
+func (m *mirror) insert(index uint64, v int) {
+	m.items = slices.Insert(m.items, int(index), v)
+}
+
+func (m *mirror) remove(index uint64, v int) {
+	if index >= uint64(len(m.items)) || m.items[index] != v {
+		m.t.Fatalf("unexpected removal of %d at %d from %v", v, index, m.items)
+	}
+	m.items = slices.Delete(m.items, int(index), int(index)+1)
+}
+
+func (m *mirror) clear() {
+	m.items = nil
+}
+
+func TestObservers(t *testing.T) {
+	q := queue.NewWithPolicy[int](4, queue.DropOldest)
+	m := &mirror{t: t}
+	q.OnInsert(m.insert)
+	q.OnRemove(m.remove)
+	q.OnClear(m.clear)
+
+	check := func(op string) {
+		t.Helper()
+		if !slices.Equal(m.items, q.Values()) {
+			t.Fatalf("after %s: expected %v, got %v", op, q.Values(), m.items)
+		}
+	}
+
+	_ = q.Enqueue(1)
+	_ = q.EnqueueN(2, 3)
+	check("Enqueue/EnqueueN")
+	_ = q.EnqueueN(4, 5, 6) // drops the oldest elements
+	check("DropOldest")
+	_, _ = q.Dequeue()
+	_, _ = q.TryDequeue()
+	check("Dequeue/TryDequeue")
+	_ = q.EnqueueN(7, 8)
+	q.Filter(func(v int) bool { return v != 7 })
+	check("Filter")
+	q.SetCapacity(2)
+	check("SetCapacity")
+	_, _ = q.DequeueN(1)
+	check("DequeueN")
+	q.Clear()
+	check("Clear")
+}
+
+func TestRingWrapAround(t *testing.T) {
+	q := queue.New[int]()
+	var expected []int
+	next := 0
+	// Interleave enqueues and dequeues so the elements wrap around the ring
+	for round := 0; round < 50; round++ {
+		for i := 0; i < 7; i++ {
+			_ = q.Enqueue(next)
+			expected = append(expected, next)
+			next++
+		}
+		for i := 0; i < 5; i++ {
+			v, err := q.Dequeue()
+			if err != nil || v != expected[0] {
+				t.Fatalf("expected %d, got %d (%v)", expected[0], v, err)
+			}
+			expected = expected[1:]
+		}
+		if q.Size() != uint64(len(expected)) {
+			t.Fatalf("expected size %d, got %d", len(expected), q.Size())
+		}
+	}
+
+	// Reads must see the elements in FIFO order even when they wrap around
+	if idx, err := q.IndexOf(expected[3]); err != nil || idx != 3 {
+		t.Errorf("expected index 3, got %d (%v)", idx, err)
+	}
+	if !q.IsSorted(func(a, b int) bool { return a < b }) {
+		t.Error("expected the queue to be sorted")
+	}
+	copied := q.Copy()
+	if !copied.Equals(q) {
+		t.Error("expected the copy to be equal to the queue")
+	}
+	checkQueue(t, q, expected)
+
+	// Draining the queue shrinks the ring without losing elements
+	for len(expected) > 0 {
+		v, _ := q.Dequeue()
+		if v != expected[0] {
+			t.Fatalf("expected %d, got %d", expected[0], v)
+		}
+		expected = expected[1:]
+		if uint64(len(expected)) != q.Size() {
+			t.Fatalf("expected size %d, got %d", len(expected), q.Size())
+		}
+	}
+	if _, err := q.Dequeue(); !errors.Is(err, queue.ErrEmpty) {
+		t.Errorf("expected %v, got %v", queue.ErrEmpty, err)
+	}
+	if copied.Size() != 100 {
+		t.Errorf("expected the copy to keep its 100 elements, got %d", copied.Size())
+	}
+}
+
+func BenchmarkEnqueueDequeue(b *testing.B) {
+	q := queue.New[int]()
+	for i := 0; i < b.N; i++ {
+		_ = q.Enqueue(i)
+		_, _ = q.Dequeue()
+	}
+}
+
+// BenchmarkEnqueueDequeue10M runs 10 million enqueue/dequeue cycles on a
+// queue that keeps 1000 elements, so the ring wraps around continuously
+func BenchmarkEnqueueDequeue10M(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		q := queue.New[int]()
+		for i := 0; i < 1000; i++ {
+			_ = q.Enqueue(i)
+		}
+		for i := 0; i < 10_000_000; i++ {
+			_ = q.Enqueue(i)
+			_, _ = q.Dequeue()
+		}
+	}
+}
+
+func BenchmarkEnqueueThenDequeue(b *testing.B) {
+	for n := 0; n < b.N; n++ {
+		q := queue.New[int]()
+		for i := 0; i < 100_000; i++ {
+			_ = q.Enqueue(i)
+		}
+		for !q.IsEmpty() {
+			_, _ = q.Dequeue()
+		}
+	}
+}
+
+func TestDrain(t *testing.T) {
+	q := queue.New[int]()
+	_ = q.EnqueueN(1, 2, 3, 4)
+
+	var got []int
+	for v := range q.Drain(context.Background()) {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []int{1, 2, 3, 4}) || !q.IsEmpty() {
+		t.Errorf("expected [1 2 3 4] and an empty queue, got %v (size %d)", got, q.Size())
+	}
+
+	// Cancelling the context stops the drain without losing elements
+	_ = q.EnqueueN(5, 6, 7)
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := q.Drain(ctx)
+	if v := <-ch; v != 5 {
+		t.Errorf("expected 5, got %d", v)
+	}
+	cancel()
+	for range ch {
+	}
+	if q.Size() != 2 {
+		t.Fatalf("expected 2 elements left, got %d", q.Size())
+	}
+	if v, _ := q.Peek(); v != 6 {
+		t.Errorf("expected 6 at the front, got %d", v)
+	}
+}
+
+func TestIndexed(t *testing.T) {
+	q := queue.New[int]()
+	for _, v := range []int{10, 20, 30} {
+		q.Enqueue(v)
+	}
+	if err := q.ForEachIndexed(func(i uint64, v *int) error {
+		*v += int(i)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(q.Values(), []int{10, 21, 32}) {
+		t.Errorf("expected [10 21 32], got %v", q.Values())
+	}
+
+	mapped, err := q.MapIndexed(func(i uint64, v int) int { return v * int(i) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(mapped.Values(), []int{0, 21, 64}) {
+		t.Errorf("expected [0 21 64], got %v", mapped.Values())
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := queue.New[*int]()
+	c.Enqueue(&a)
+	c.Enqueue(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.Values() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestRemoveIf(t *testing.T) {
+	c := queue.New[int]()
+	for _, v := range []int{1, 2, 1, 3, 1, 2} {
+		c.Enqueue(v)
+	}
+
+	if n := c.RemoveFirstN(1, 2); n != 2 || !slices.Equal(c.Values(), []int{2, 3, 1, 2}) {
+		t.Errorf("expected to remove 2 elements leaving 2, 3, 1, 2}, removed %d leaving %v", n, c.Values())
+	}
+	if n := c.RemoveAll(2); n != 2 || !slices.Equal(c.Values(), []int{3, 1}) {
+		t.Errorf("expected to remove 2 elements leaving 3, 1}, removed %d leaving %v", n, c.Values())
+	}
+	if n := c.RemoveFirstN(1, 5); n != 1 {
+		t.Errorf("expected to remove 1 element, removed %d", n)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v > 10 }); n != 0 {
+		t.Errorf("expected to remove nothing, removed %d", n)
+	}
+	if n := c.RemoveIf(func(v int) bool { return v == 3 }); n != 1 || c.Size() != 0 {
+		t.Errorf("expected to remove the last element, removed %d leaving %v", n, c.Values())
+	}
+}
+
+func TestContainsAllAny(t *testing.T) {
+	c := queue.New[int]()
+	for _, v := range []int{1, 2, 1, 3} {
+		c.Enqueue(v)
+	}
+
+	if !c.ContainsAll(3, 1, 1) || !c.ContainsAll() || c.ContainsAll(1, 4) {
+		t.Error("expected ContainsAll to be true only when every element is present")
+	}
+	if !c.ContainsAny(4, 2) || c.ContainsAny(4, 5) || c.ContainsAny() {
+		t.Error("expected ContainsAny to be true only when an element is present")
+	}
+	if c.CountOf(1) != 2 || c.CountOf(3) != 1 || c.CountOf(4) != 0 {
+		t.Errorf("expected counts 2, 1 and 0, got %d, %d and %d", c.CountOf(1), c.CountOf(3), c.CountOf(4))
+	}
+}
+
+func TestReverseIteration(t *testing.T) {
+	q := queue.New[int]()
+	if q.ValuesReverse() != nil {
+		t.Error("expected nil for an empty queue")
+	}
+
+	// Make the ring wrap around
+	for i := 0; i < 6; i++ {
+		q.Enqueue(i)
+	}
+	for i := 0; i < 4; i++ {
+		_, _ = q.Dequeue()
+	}
+	for i := 6; i < 10; i++ {
+		q.Enqueue(i)
+	}
+
+	expected := []int{9, 8, 7, 6, 5, 4}
+	if got := q.ValuesReverse(); !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	var visited []int
+	_ = q.ForEachReverse(func(v *int) error {
+		visited = append(visited, *v)
+		*v *= 10
+		return nil
+	})
+	if !slices.Equal(visited, expected) {
+		t.Errorf("expected %v, got %v", expected, visited)
+	}
+	if v, _ := q.Peek(); v != 40 {
+		t.Errorf("expected ForEachReverse to update the elements in place, got %d", v)
+	}
+
+	stop := errors.New("stop")
+	visited = nil
+	err := q.ForEachReverse(func(v *int) error {
+		visited = append(visited, *v)
+		if len(visited) == 2 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) || len(visited) != 2 {
+		t.Errorf("expected to stop after 2 elements with %v, got %d (%v)", stop, len(visited), err)
+	}
+}
+
+func TestDrainAll(t *testing.T) {
+	q := queue.New[int]()
+	if q.DrainAll() != nil {
+		t.Error("expected nil from an empty queue")
+	}
+
+	// Wrap the ring around so the elements are not contiguous
+	for i := 0; i < 6; i++ {
+		q.Enqueue(i)
+	}
+	for i := 0; i < 4; i++ {
+		_, _ = q.Dequeue()
+	}
+	for i := 6; i < 10; i++ {
+		q.Enqueue(i)
+	}
+
+	values := q.Values()
+	values[0] = 100 // Values is a copy
+	if v, _ := q.Peek(); v != 4 {
+		t.Errorf("expected Values not to expose the queue storage, got front %d", v)
+	}
+
+	cleared := 0
+	q.OnClear(func() { cleared++ })
+	items := q.DrainAll()
+	if !slices.Equal(items, []int{4, 5, 6, 7, 8, 9}) || !q.IsEmpty() || cleared != 1 {
+		t.Errorf("expected [4 5 6 7 8 9], an empty queue and a clear, got %v (size %d, %d clears)", items, q.Size(), cleared)
+	}
+
+	// The queue is still usable
+	q.Enqueue(1)
+	if !slices.Equal(q.ToSlice(), []int{1}) {
+		t.Errorf("expected [1], got %v", q.ToSlice())
+	}
+}
+
+func TestWipe(t *testing.T) {
+	items := make([]string, 3, 5)
+	copy(items, []string{"key", "token", "secret"})
+	q := queue.Adopt(items)
+	_, _ = q.Dequeue()
+	_ = q.Enqueue("password")
+
+	q.Wipe()
+	if !q.IsEmpty() {
+		t.Errorf("Expected an empty queue, got %v", q.ToSlice())
+	}
+	for i, v := range items[:cap(items)] {
+		if v != "" {
+			t.Errorf("Expected slot %d to be wiped, but it holds %q", i, v)
+		}
+	}
+}
+
+func TestPeekAtPeekBack(t *testing.T) {
+	q := queue.NewWithCapacity[int](4)
+	if _, err := q.PeekBack(); !errors.Is(err, queue.ErrEmpty) {
+		t.Errorf("Expected %v, got %v", queue.ErrEmpty, err)
+	}
+	if _, err := q.PeekAt(0); !errors.Is(err, queue.ErrEmpty) {
+		t.Errorf("Expected %v, got %v", queue.ErrEmpty, err)
+	}
+
+	// Wrap the elements around the end of the ring
+	for i := 1; i <= 4; i++ {
+		_ = q.Enqueue(i)
+	}
+	_, _ = q.Dequeue()
+	_, _ = q.Dequeue()
+	_ = q.Enqueue(5)
+	_ = q.Enqueue(6)
+
+	for i := uint64(0); i < 4; i++ {
+		if v, err := q.PeekAt(i); err != nil || v != int(i)+3 {
+			t.Errorf("Expected %d at %d, got %d (%v)", i+3, i, v, err)
+		}
+	}
+	if v, err := q.PeekBack(); err != nil || v != 6 {
+		t.Errorf("Expected 6, got %d (%v)", v, err)
+	}
+	if _, err := q.PeekAt(4); !errors.Is(err, queue.ErrOutOfBounds) {
+		t.Errorf("Expected %v, got %v", queue.ErrOutOfBounds, err)
+	}
+	if q.Size() != 4 {
+		t.Errorf("Expected peeking to leave 4 elements, got %d", q.Size())
+	}
+}
+
+func TestJSONStream(t *testing.T) {
+	if !queue.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, queue.Features())
+	}
+	if !queue.Features().Has(common.FeatureJSON) {
+		t.Errorf("expected the %s feature, got %v", common.FeatureJSON, queue.Features())
+	}
+	l := queue.New[int]()
+	for _, v := range []int{1, 2, 3} {
+		l.Enqueue(v)
+	}
+
+	var sb strings.Builder
+	if err := l.EncodeJSONStream(&sb); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if sb.String() != "[1,2,3]" {
+		t.Errorf("expected [1,2,3], got %s", sb.String())
+	}
+
+	got := queue.New[int]()
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader(sb.String()))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader("[4] null"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(got.ToSlice(), []int{1, 2, 3, 4}) {
+		t.Errorf("expected [1 2 3 4], got %v", got.ToSlice())
+	}
+
+	if err := got.DecodeJSONStream(json.NewDecoder(strings.NewReader(`{"a": 5}`))); !errors.Is(err, common.ErrNotJSONArray) {
+		t.Errorf("expected %v, got %v", common.ErrNotJSONArray, err)
+	}
+
+	bounded := queue.NewWithPolicy[int](2, queue.DropOldest)
+	if err := bounded.DecodeJSONStream(json.NewDecoder(strings.NewReader("[1, 2, 3]"))); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(bounded.ToSlice(), []int{2, 3}) {
+		t.Errorf("expected [2 3], got %v", bounded.ToSlice())
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *queue.Queue[int] {
+		l := queue.New[int]()
+		for _, v := range values {
+			_ = l.Enqueue(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	// The result is normalized to -1, 0 or +1
+	if got := build(1).Compare(build(10), func(a, b int) int { return a - b }); got != -1 {
+		t.Errorf("expected -1, got %d", got)
+	}
+}
+
+func TestRangeBounds(t *testing.T) {
+	q := queue.New[int]()
+	_ = q.EnqueueN(1, 2, 3)
+	double := func(v int) int { return v * 2 }
+	noop := func(*int) error { return nil }
+
+	for _, r := range [][2]uint64{{0, 4}, {2, 1}, {4, 4}} {
+		if _, err := q.MapRange(r[0], r[1], double); !errors.Is(err, queue.ErrOutOfBounds) {
+			t.Errorf("MapRange(%d, %d): expected %v, got %v", r[0], r[1], queue.ErrOutOfBounds, err)
+		}
+		if err := q.ForRange(r[0], r[1], noop); !errors.Is(err, queue.ErrOutOfBounds) {
+			t.Errorf("ForRange(%d, %d): expected %v, got %v", r[0], r[1], queue.ErrOutOfBounds, err)
+		}
+	}
+	if _, err := q.MapFrom(4, double); !errors.Is(err, queue.ErrOutOfBounds) {
+		t.Errorf("MapFrom(4): expected %v, got %v", queue.ErrOutOfBounds, err)
+	}
+
+	// Empty ranges are fine, even at the end of the queue
+	if m, err := q.MapRange(3, 3, double); err != nil || !m.IsEmpty() {
+		t.Errorf("expected an empty queue, got %v (%v)", m, err)
+	}
+	if err := queue.New[int]().ForFrom(0, noop); err != nil {
+		t.Errorf(errExpectedNoError, err)
+	}
+}
@@ -17,23 +17,39 @@ package ringBuffer
 
 import (
 	"errors"
+
+	common "github.com/pzaino/gods/pkg/common"
 )
 
 const (
 	ErrCircularBufferEmpty = "ring buffer is empty"
 )
 
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator)
+}
+
 // CircularBuffer represents a circular buffer data structure.
-type CircularBuffer[T comparable] struct {
+type CircularBuffer[T any] struct {
 	data     []T
 	capacity uint64
 	head     uint64
 	tail     uint64
 	size     uint64
+	equals   common.EqualFunc[T]
 }
 
 // New creates a new CircularBuffer with a given capacity.
 func New[T comparable](capacity uint64) *CircularBuffer[T] {
+	return NewWithComparator(capacity, common.Equal[T])
+}
+
+// NewWithComparator creates a new CircularBuffer with a given capacity that
+// compares its elements with the given function, so it can hold types that
+// are not comparable (slices, maps, ...).
+func NewWithComparator[T any](capacity uint64, equals func(a, b T) bool) *CircularBuffer[T] {
 	// Capacity should be a power of two for optimal performance with bitwise operations
 	return &CircularBuffer[T]{
 		data:     make([]T, capacity),
@@ -41,6 +57,7 @@ func New[T comparable](capacity uint64) *CircularBuffer[T] {
 		head:     0,
 		tail:     0,
 		size:     0,
+		equals:   equals,
 	}
 }
 
@@ -75,7 +92,7 @@ func (cb *CircularBuffer[T]) Get(index uint64) (T, error) {
 		var zero T
 		return zero, errors.New(ErrCircularBufferEmpty)
 	}
-	pos := (cb.head + index) & (cb.capacity - 1)
+	pos := (cb.head + index) % cb.capacity
 	return cb.data[pos], nil
 }
 
@@ -118,6 +135,18 @@ func (cb *CircularBuffer[T]) ToSlice() []T {
 	return result
 }
 
+// String returns a string representation of the buffer, from the oldest to the
+// newest element (elements are formatted with %v)
+func (cb *CircularBuffer[T]) String() string {
+	return cb.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the buffer, from the oldest to
+// the newest element, with every element formatted by f
+func (cb *CircularBuffer[T]) StringFunc(f func(T) string) string {
+	return common.FormatSlice(cb.ToSlice(), f)
+}
+
 // ForEach applies a function to all elements in the buffer from oldest to newest.
 func (cb *CircularBuffer[T]) ForEach(f func(T)) {
 	for i := uint64(0); i < cb.size; i++ {
@@ -128,7 +157,7 @@ func (cb *CircularBuffer[T]) ForEach(f func(T)) {
 // Contains checks if the buffer contains a given value.
 func (cb *CircularBuffer[T]) Contains(value T) bool {
 	for i := uint64(0); i < cb.size; i++ {
-		if cb.data[(cb.head+i)%cb.capacity] == value {
+		if common.Equals(cb.equals, cb.data[(cb.head+i)%cb.capacity], value) {
 			return true
 		}
 	}
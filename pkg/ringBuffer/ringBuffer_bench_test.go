@@ -0,0 +1,70 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ringBuffer_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	ringBuffer "github.com/pzaino/gods/pkg/ringBuffer"
+)
+
+// newBenchRing returns a full ring buffer holding values
+func newBenchRing(values []int) *ringBuffer.CircularBuffer[int] {
+	rb := ringBuffer.New[int](uint64(len(values)))
+	for _, v := range values {
+		rb.Append(v)
+	}
+	return rb
+}
+
+// BenchmarkAppend appends n elements to a ring buffer of capacity n
+func BenchmarkAppend(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		for i := 0; i < b.N; i++ {
+			rb := ringBuffer.New[int](uint64(n))
+			for v := 0; v < n; v++ {
+				rb.Append(v)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkRemove removes the oldest of n elements (and appends one, so the
+// size stays n)
+func BenchmarkRemove(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		rb := newBenchRing(bench.Ints(n))
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			v, _ := rb.Remove()
+			rb.Append(v)
+		}
+	})
+}
+
+// BenchmarkFind looks for the value in the middle of n elements
+func BenchmarkFind(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		target := values[n/2]
+		rb := newBenchRing(values)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = rb.Contains(target)
+		}
+	})
+}
@@ -1,6 +1,7 @@
 package ringBuffer_test
 
 import (
+	"slices"
 	"testing"
 
 	cBuf "github.com/pzaino/gods/pkg/ringBuffer"
@@ -144,6 +145,17 @@ func TestGet(t *testing.T) {
 	if err == nil {
 		t.Errorf("Expected error when accessing out of range index")
 	}
+
+	// The index wraps around capacities that are not a power of two too
+	odd := cBuf.New[int](3)
+	for i := 1; i <= 5; i++ {
+		odd.Append(i)
+	}
+	for i := uint64(0); i < 3; i++ {
+		if val, err := odd.Get(i); err != nil || val != int(i)+3 {
+			t.Errorf("Expected value to be %d, got %d (%v)", i+3, val, err)
+		}
+	}
 }
 
 func TestToSlice(t *testing.T) {
@@ -271,3 +283,27 @@ func TestContains(t *testing.T) {
 		t.Errorf("Expected buffer to not contain value 1 after overwrite")
 	}
 }
+
+func TestString(t *testing.T) {
+	cb := cBuf.New[int](2)
+	cb.Append(1)
+	cb.Append(2)
+	cb.Append(3)
+	if s := cb.String(); s != "[2 3]" {
+		t.Errorf("expected [2 3], got %s", s)
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	cb := cBuf.NewWithComparator(2, slices.Equal[[]string])
+	cb.Append([]string{"a"})
+	cb.Append([]string{"b", "c"})
+	cb.Append([]string{"d"}) // overwrites [a]
+
+	if !cb.Contains([]string{"b", "c"}) || !cb.Contains([]string{"d"}) {
+		t.Error("expected buffer to contain [b c] and [d]")
+	}
+	if cb.Contains([]string{"a"}) {
+		t.Error("expected the overwritten element to be gone")
+	}
+}
@@ -16,12 +16,20 @@
 package stack
 
 import (
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
+
+	common "github.com/pzaino/gods/pkg/common"
 )
 
 // Error messages
+//
+// Deprecated: compare the returned errors with errors.Is and the Err* sentinel
+// variables instead of comparing these strings with err.Error()
 const (
 	ErrItemNotFound  = "item not found"
 	ErrStackIsEmpty  = "stack is empty"
@@ -30,15 +38,54 @@ const (
 	ErrSIndexGreater = "start index is greater than end index"
 )
 
+// Sentinel errors returned by the Stack methods (use errors.Is to check for them)
+var (
+	ErrNotFound        = errors.New(ErrItemNotFound)
+	ErrEmpty           = errors.New(ErrStackIsEmpty)
+	ErrStartOutOfRange = errors.New(ErrStartIndexOOR)
+	ErrEndOutOfRange   = errors.New(ErrEndIndexOOR)
+	ErrInvalidRange    = errors.New(ErrSIndexGreater)
+	ErrFull            = errors.New("stack is full")
+	ErrNotEnoughItems  = errors.New("Stack has less items than requested")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureComparator, common.FeatureObservers, common.FeatureDeepCopy)
+}
+
 // Stack is a non-concurrent-safe stack.
-type Stack[T comparable] struct {
-	items []T
-	size  uint64
+//
+// The items are stored in a slice, from the bottom to the top. It grows like
+// any slice appended to (so Push is amortized O(1)) and it never shrinks on
+// its own: popped slots are zeroed so the GC can collect the popped items,
+// but the storage stays allocated to be reused by the next pushes. Use
+// Reserve to allocate the storage in advance and ShrinkToFit to release the
+// storage that is not in use.
+type Stack[T any] struct {
+	items    []T
+	size     uint64
+	capacity uint64 // 0 means unbounded
+	equals   common.EqualFunc[T]
+	obs      common.Observers[T]
 }
 
 // New creates a new Stack.
 func New[T comparable]() *Stack[T] {
-	return &Stack[T]{}
+	return &Stack[T]{equals: common.Equal[T]}
+}
+
+// NewWithComparator creates a new Stack that compares its items with the given
+// function, so it can hold types that are not comparable (slices, maps, ...).
+func NewWithComparator[T any](equals func(a, b T) bool) *Stack[T] {
+	return &Stack[T]{equals: equals}
+}
+
+// NewWithCapacity creates a new bounded Stack that holds at most capacity items
+// (0 means unbounded), Push fails with ErrFull when the stack is full.
+func NewWithCapacity[T comparable](capacity uint64) *Stack[T] {
+	return &Stack[T]{capacity: capacity, equals: common.Equal[T]}
 }
 
 // NewWithSize creates a new Stack with the given size.
@@ -56,10 +103,125 @@ func NewFromSlice[T comparable](items []T) *Stack[T] {
 	return stack
 }
 
-// Push adds an item to the stack.
-func (s *Stack[T]) Push(item T) {
+// Adopt creates a new Stack that uses items (from bottom to top) as its backing
+// storage, the slice is not copied and the caller must not use it afterwards.
+func Adopt[T comparable](items []T) *Stack[T] {
+	s := New[T]()
+	s.items = items
+	s.size = uint64(len(items))
+	return s
+}
+
+// AdoptWithComparator is like Adopt for a Stack that compares its items with
+// the given function (see NewWithComparator).
+func AdoptWithComparator[T any](items []T, equals func(a, b T) bool) *Stack[T] {
+	s := NewWithComparator(equals)
+	s.items = items
+	s.size = uint64(len(items))
+	return s
+}
+
+// newEmpty creates a new empty stack that compares its items like s.
+func (s *Stack[T]) newEmpty() *Stack[T] {
+	return &Stack[T]{equals: s.equals}
+}
+
+// equal compares two items with the stack comparator.
+func (s *Stack[T]) equal(x, y T) bool {
+	return common.Equals(s.equals, x, y)
+}
+
+// OnInsert registers a function called after an item is pushed on the stack
+// (nil removes it). The index is counted from the top, like in Get, so it's 0
+// for every push (see common.Observers).
+func (s *Stack[T]) OnInsert(f func(index uint64, v T)) {
+	s.obs.Insert = f
+}
+
+// OnRemove registers a function called after an item is removed from the stack
+// (nil removes it). The index is counted from the top, like in Get.
+func (s *Stack[T]) OnRemove(f func(index uint64, v T)) {
+	s.obs.Remove = f
+}
+
+// OnClear registers a function called after all the items are removed at once
+// by Clear or Detach (nil removes it).
+func (s *Stack[T]) OnClear(f func()) {
+	s.obs.Clear = f
+}
+
+// Capacity returns the maximum number of items of the stack (0 means unbounded).
+func (s *Stack[T]) Capacity() uint64 {
+	return s.capacity
+}
+
+// SetCapacity sets the maximum number of items of the stack (0 means unbounded).
+// If the stack holds more items than the new capacity, the oldest ones (at the
+// bottom of the stack) are dropped.
+func (s *Stack[T]) SetCapacity(capacity uint64) {
+	s.capacity = capacity
+	if capacity == 0 || s.size <= capacity {
+		return
+	}
+
+	n := s.size - capacity
+	var removed []common.Removal[T]
+	if s.obs.Remove != nil {
+		for i := uint64(0); i < n; i++ {
+			removed = append(removed, common.Removal[T]{Index: s.size - i - 1, Value: s.items[i]})
+		}
+	}
+	s.items = append(s.items[:0], s.items[n:]...)
+	clear(s.items[capacity:s.size]) // let the GC collect the dropped items
+	s.size = capacity
+	s.obs.RemovedAll(removed)
+}
+
+// Reserve makes sure the stack can take n more items without reallocating its
+// storage (bounded stacks reserve at most the room left to their capacity).
+func (s *Stack[T]) Reserve(n uint64) {
+	if s.capacity != 0 {
+		n = min(n, s.capacity-min(s.size, s.capacity))
+	}
+	s.items = slices.Grow(s.items, int(n))
+}
+
+// ShrinkToFit reallocates the storage of the stack to the exact number of
+// items it holds, releasing the memory left by the popped items (it's O(n)).
+func (s *Stack[T]) ShrinkToFit() {
+	if cap(s.items) == len(s.items) {
+		return
+	}
+	if len(s.items) == 0 {
+		s.items = nil
+		return
+	}
+	s.items = slices.Clip(slices.Clone(s.items))
+}
+
+// IsFull returns true if the stack is bounded and has reached its capacity.
+func (s *Stack[T]) IsFull() bool {
+	return s.capacity != 0 && s.size >= s.capacity
+}
+
+// Push adds an item to the stack, it fails with ErrFull if the stack is full.
+func (s *Stack[T]) Push(item T) error {
+	if s.IsFull() {
+		return ErrFull
+	}
 	s.items = append(s.items, item)
 	s.size++
+	s.obs.Inserted(0, item)
+	return nil
+}
+
+// PushIfAbsent pushes the item if the stack doesn't contain it yet, it returns
+// false if the item was already there or the stack is full.
+func (s *Stack[T]) PushIfAbsent(item T) bool {
+	if s.Contains(item) {
+		return false
+	}
+	return s.Push(item) == nil
 }
 
 // IsEmpty checks if the stack is empty.
@@ -73,16 +235,20 @@ func (s *Stack[T]) IsEmpty() bool {
 // Pop removes and returns the top item from the stack.
 func (s *Stack[T]) Pop() (*T, error) {
 	if s.IsEmpty() {
-		return nil, errors.New(ErrStackIsEmpty)
+		return nil, ErrEmpty
 	}
 
 	item := s.items[len(s.items)-1]
+	var zero T
+	s.items[len(s.items)-1] = zero // let the GC collect the popped item
 	s.items = s.items[:len(s.items)-1]
 	s.size--
+	s.obs.Removed(0, item)
 	return &item, nil
 }
 
-// ToSlice returns the stack as a slice.
+// ToSlice returns a copy of the items of the stack from the top to the
+// bottom (the order they are popped in).
 func (s *Stack[T]) ToSlice() []T {
 	if s.IsEmpty() {
 		return nil
@@ -111,7 +277,7 @@ func (s *Stack[T]) Reverse() {
 // Swap swaps the top two items on the stack.
 func (s *Stack[T]) Swap() error {
 	if s.IsEmpty() || s.size < 2 {
-		return errors.New("Stack has less than 2 items")
+		return ErrNotEnoughItems
 	}
 
 	s.items[len(s.items)-1], s.items[len(s.items)-2] = s.items[len(s.items)-2], s.items[len(s.items)-1]
@@ -121,7 +287,7 @@ func (s *Stack[T]) Swap() error {
 // Top returns the top item from the stack without removing it.
 func (s *Stack[T]) Top() (*T, error) {
 	if s.IsEmpty() {
-		return nil, errors.New(ErrStackIsEmpty)
+		return nil, ErrEmpty
 	}
 
 	item := s.items[len(s.items)-1]
@@ -133,6 +299,56 @@ func (s *Stack[T]) Peek() (*T, error) {
 	return s.Top()
 }
 
+// Iterator returns a channel that receives the items of the stack from the top
+// to the bottom, as they were when Iterator was called (the stack is not
+// modified). The channel is closed after the last item or when ctx is done
+func (s *Stack[T]) Iterator(ctx context.Context) <-chan T {
+	items := s.ToSlice()
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		for _, item := range items {
+			select {
+			case out <- item:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// PeekN returns the top n items from the stack without removing them.
+// The items are returned in pop order (the top of the stack first).
+func (s *Stack[T]) PeekN(n uint64) ([]T, error) {
+	if s.IsEmpty() {
+		return nil, ErrEmpty
+	}
+	if s.size < n {
+		return nil, ErrNotEnoughItems
+	}
+
+	items := make([]T, n)
+	for i := uint64(0); i < n; i++ {
+		items[i] = s.items[s.size-i-1]
+	}
+	return items, nil
+}
+
+// Get returns the item at the given index without removing it.
+// Please note: index 0 is the top of the stack.
+func (s *Stack[T]) Get(index uint64) (*T, error) {
+	if s.IsEmpty() {
+		return nil, ErrEmpty
+	}
+	if index >= s.size {
+		return nil, common.NewIndexError(ErrStartOutOfRange, index, s.size)
+	}
+
+	item := s.items[s.size-index-1]
+	return &item, nil
+}
+
 // Size returns the number of items in the stack.
 func (s *Stack[T]) Size() uint64 {
 	if s.IsEmpty() {
@@ -151,8 +367,51 @@ func (s *Stack[T]) CheckSize() {
 
 // Clear removes all items from the stack.
 func (s *Stack[T]) Clear() {
+	clear(s.items) // let the GC collect the items
 	s.items = s.items[:0]
 	s.size = 0
+	s.obs.Cleared()
+}
+
+// Wipe overwrites every item of the stack, and every unused slot of its
+// storage, with the zero value and then releases the storage, so sensitive
+// data (tokens, keys, ...) doesn't stay reachable. The copies left behind
+// when the storage was reallocated to grow are out of reach and can't be
+// wiped: Reserve the storage in advance to avoid them.
+func (s *Stack[T]) Wipe() {
+	clear(s.items[:cap(s.items)])
+	s.items = nil
+	s.size = 0
+	s.obs.Cleared()
+}
+
+// Detach empties the stack and returns its backing slice (from bottom to top)
+// without copying it, the stack no longer references the returned slice.
+func (s *Stack[T]) Detach() []T {
+	if s.IsEmpty() {
+		return nil
+	}
+	items := s.items[:s.size]
+	s.items = nil
+	s.size = 0
+	s.obs.Cleared()
+	return items
+}
+
+// DrainAll removes and returns all items (from the top to the bottom, like
+// PopAll) in a new slice in one O(n) operation, useful for batch flushes. The
+// stack keeps its storage so it can be refilled without reallocating (use
+// Detach to take the storage instead). Observers see a single clear.
+func (s *Stack[T]) DrainAll() []T {
+	items := s.ToSlice()
+	if items == nil {
+		return nil
+	}
+	clear(s.items)
+	s.items = s.items[:0]
+	s.size = 0
+	s.obs.Cleared()
+	return items
 }
 
 // Contains checks if the stack contains an item.
@@ -161,12 +420,11 @@ func (s *Stack[T]) Contains(item T) bool {
 		return false
 	}
 
-	if s.items[0] == item {
+	if s.equal(s.items[0], item) {
 		return true
 	}
-	fmt.Printf("s.size: %d\n", s.size)
 	for i := s.size - 1; i > 0; i-- {
-		if s.items[i] == item {
+		if s.equal(s.items[i], item) {
 			return true
 		}
 	}
@@ -174,9 +432,55 @@ func (s *Stack[T]) Contains(item T) bool {
 	return false
 }
 
+// ContainsAll returns true if the stack contains all the given items (true
+// when none is given), they are all checked in a single pass over the stack
+func (s *Stack[T]) ContainsAll(items ...T) bool {
+	if len(items) == 0 {
+		return true
+	}
+
+	lookup := common.NewLookup(nil, s.equals, items)
+	for i := uint64(0); i < s.size; i++ {
+		if lookup.Match(s.items[i]) && lookup.Remaining() == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// ContainsAny returns true if the stack contains at least one of the given
+// items, they are all checked in a single pass over the stack
+func (s *Stack[T]) ContainsAny(items ...T) bool {
+	if len(items) == 0 {
+		return false
+	}
+
+	lookup := common.NewLookup(nil, s.equals, items)
+	for i := uint64(0); i < s.size; i++ {
+		if lookup.Match(s.items[i]) {
+			return true
+		}
+	}
+	return false
+}
+
+// CountOf returns the number of items in the stack equal to the given item
+func (s *Stack[T]) CountOf(item T) uint64 {
+	var count uint64
+	for i := uint64(0); i < s.size; i++ {
+		if s.equal(s.items[i], item) {
+			count++
+		}
+	}
+	return count
+}
+
 // Copy returns a new Stack with the same items.
+// The copy is shallow: the items are copied by assignment, so pointers, slices
+// and maps inside them are shared with the original (use CloneWith for a deep copy).
 func (s *Stack[T]) Copy() *Stack[T] {
-	stack := New[T]()
+	stack := s.newEmpty()
+	stack.capacity = s.capacity
 	if s.IsEmpty() {
 		return stack
 	}
@@ -187,6 +491,16 @@ func (s *Stack[T]) Copy() *Stack[T] {
 	return stack
 }
 
+// CloneWith returns a new Stack with a copy of every item made by copier,
+// to deep copy items that hold pointers, slices or maps.
+func (s *Stack[T]) CloneWith(copier func(T) T) *Stack[T] {
+	stack := s.Copy()
+	for i := uint64(0); i < stack.size; i++ {
+		stack.items[i] = copier(stack.items[i])
+	}
+	return stack
+}
+
 // Equal checks if two stacks are equal.
 func (s *Stack[T]) Equal(other *Stack[T]) bool {
 	if s == nil && other == nil {
@@ -204,33 +518,50 @@ func (s *Stack[T]) Equal(other *Stack[T]) bool {
 	if s.size == 0 && other.size == 0 {
 		return true
 	}
-	if s.items[0] != other.items[0] {
+	if !s.equal(s.items[0], other.items[0]) {
 		return false
 	}
 
 	for i := s.size - 1; i > 0; i-- {
-		if s.items[i] != other.items[i] {
+		if !s.equal(s.items[i], other.items[i]) {
 			return false
 		}
 	}
 	return true
 }
 
-// String returns a string representation of the stack.
-func (s *Stack[T]) String() string {
-	if s.IsEmpty() {
-		return "[]"
+// Compare compares the stack with other lexicographically (like bytes.Compare),
+// element by element from the bottom with compare, which returns a negative
+// number, zero or a positive number like cmp.Compare. It returns -1, 0 or +1,
+// a stack that is a prefix of the other is the smaller one.
+func (s *Stack[T]) Compare(other *Stack[T], compare func(a, b T) int) int {
+	for i := uint64(0); i < min(s.size, other.size); i++ {
+		if c := compare(s.items[i], other.items[i]); c != 0 {
+			return cmp.Compare(c, 0)
+		}
 	}
-	return fmt.Sprintf("%v", s.items)
+	return cmp.Compare(s.size, other.size)
+}
+
+// String returns a string representation of the stack, from the bottom to the top
+// (items are formatted with %v).
+func (s *Stack[T]) String() string {
+	return s.StringFunc(nil)
+}
+
+// StringFunc returns a string representation of the stack, from the bottom to the top,
+// with every item formatted by f.
+func (s *Stack[T]) StringFunc(f func(T) string) string {
+	return common.FormatSlice(s.items[:s.size], f)
 }
 
 // PopN removes and returns the top n items from the stack.
 func (s *Stack[T]) PopN(n uint64) ([]T, error) {
 	if s.IsEmpty() {
-		return nil, errors.New(ErrStackIsEmpty)
+		return nil, ErrEmpty
 	}
 	if s.size < n {
-		return nil, errors.New("Stack has less items than requested")
+		return nil, ErrNotEnoughItems
 	}
 
 	items := make([]T, n)
@@ -244,10 +575,9 @@ func (s *Stack[T]) PopN(n uint64) ([]T, error) {
 	return items, nil
 }
 
-// PushN adds multiple items to the stack.
-func (s *Stack[T]) PushN(items ...T) {
-	s.items = append(s.items, items...)
-	s.size += uint64(len(items))
+// PushN adds multiple items to the stack, see PushAll.
+func (s *Stack[T]) PushN(items ...T) error {
+	return s.PushAll(items)
 }
 
 // PopAll removes and returns all items from the stack.
@@ -256,32 +586,112 @@ func (s *Stack[T]) PopAll() []T {
 	for i := len(s.items) - 1; i >= 0; i-- {
 		items[len(s.items)-i-1] = s.items[i]
 	}
+	clear(s.items) // let the GC collect the popped items
 	s.items = s.items[:0]
 	s.size = 0
+	s.obs.Removed(0, items...)
 	return items
 }
 
-// PushAll adds multiple items to the stack.
-func (s *Stack[T]) PushAll(items []T) {
+// PopWhile pops the items from the top of the stack as long as they match the
+// predicate and returns them in pop order (the top of the stack first).
+func (s *Stack[T]) PopWhile(pred func(T) bool) []T {
+	var items []T
+	for len(s.items) > 0 && pred(s.items[len(s.items)-1]) {
+		item, _ := s.Pop()
+		items = append(items, *item)
+	}
+	return items
+}
+
+// PopUntil pops the items from the top of the stack until the top one matches
+// the predicate (it stays on the stack, the stack is emptied if no item
+// matches) and returns the popped ones in pop order.
+func (s *Stack[T]) PopUntil(pred func(T) bool) []T {
+	return s.PopWhile(func(item T) bool { return !pred(item) })
+}
+
+// PushAll adds multiple items to the stack (the last one ends up on top). If
+// they don't all fit in the capacity none is added and ErrFull is returned.
+func (s *Stack[T]) PushAll(items []T) error {
+	if s.capacity != 0 && s.size+uint64(len(items)) > s.capacity {
+		return ErrFull
+	}
 	s.items = append(s.items, items...)
 	s.size += uint64(len(items))
+	if s.obs.Insert != nil {
+		for _, item := range items {
+			s.obs.Insert(0, item)
+		}
+	}
+	return nil
 }
 
 // Filter removes items from the stack that don't match the predicate.
 func (s *Stack[T]) Filter(predicate func(T) bool) {
 	var items []T
 	var size uint64
+	var removed []common.Removal[T]
 	for _, item := range s.items {
 		if predicate(item) {
 			items = append(items, item)
 			size++
+		} else if s.obs.Remove != nil {
+			removed = append(removed, common.Removal[T]{Index: size, Value: item})
 		}
 	}
 	s.items = items
 	s.size = size
+
+	// Removals are reported from the top, like Pop, so the index of a removed
+	// item is the number of kept items above it
+	slices.Reverse(removed)
+	for i := range removed {
+		removed[i].Index = size - removed[i].Index
+	}
+	s.obs.RemovedAll(removed)
+}
+
+// RemoveIf removes the items that match the predicate (the opposite of Filter)
+// and returns how many were removed.
+func (s *Stack[T]) RemoveIf(predicate func(T) bool) uint64 {
+	size := s.size
+	s.Filter(func(item T) bool { return !predicate(item) })
+	return size - s.size
+}
+
+// RemoveAll removes all the items equal to value and returns how many were removed.
+func (s *Stack[T]) RemoveAll(value T) uint64 {
+	return s.RemoveIf(func(item T) bool { return s.equal(item, value) })
+}
+
+// RemoveFirstN removes the first n items equal to value and returns how many were
+// removed. Like for Get, the first items are the ones closest to the top of the stack.
+func (s *Stack[T]) RemoveFirstN(value T, n uint64) uint64 {
+	// Filter visits the items from the bottom, so keep the matches that are
+	// too far from the top
+	keep := uint64(0)
+	for i := uint64(0); i < s.size; i++ {
+		if s.equal(s.items[i], value) {
+			keep++
+		}
+	}
+	keep -= min(keep, n)
+	return s.RemoveIf(func(item T) bool {
+		if !s.equal(item, value) {
+			return false
+		}
+		if keep > 0 {
+			keep--
+			return false
+		}
+		return true
+	})
 }
 
 // Map creates a new stack with the results of applying the function to each item.
+// The function is called from the bottom to the top of the stack (use MapReverse for
+// the LIFO order), in both cases the new stack keeps the items in the same positions.
 func (s *Stack[T]) Map(fn func(T) T) (*Stack[T], error) {
 	return s.MapRange(0, s.size-1, fn)
 }
@@ -296,33 +706,67 @@ func (s *Stack[T]) MapFrom(start uint64, fn func(T) T) (*Stack[T], error) {
 // Please note: start and end are inclusive and on a stack this means that the start index is the top of the stack.
 func (s *Stack[T]) MapRange(start, end uint64, fn func(T) T) (*Stack[T], error) {
 	if start >= s.size {
-		return nil, errors.New(ErrStartIndexOOR)
+		return nil, ErrStartOutOfRange
 	}
 
 	if end >= s.size {
-		return nil, errors.New(ErrEndIndexOOR)
+		return nil, ErrEndOutOfRange
 	}
 
 	if start > end {
-		return nil, errors.New(ErrSIndexGreater)
+		return nil, ErrInvalidRange
 	}
 
 	// Convert the start and end index to the stack indexes
 	stackStart := (s.size - start) - 1
 	stackEnd := (s.size - end) - 1
 
-	stack := New[T]()
+	stack := s.newEmpty()
 	for i := stackEnd; i <= stackStart; i++ {
 		stack.Push(fn(s.items[i]))
 	}
 	return stack, nil
 }
 
+// MapReverse creates a new stack with the results of applying the function to each item.
+// Unlike Map, the function is called from the top to the bottom of the stack (LIFO order).
+func (s *Stack[T]) MapReverse(fn func(T) T) (*Stack[T], error) {
+	if s.IsEmpty() {
+		return nil, ErrStartOutOfRange
+	}
+
+	items := make([]T, s.size)
+	for i := s.size; i > 0; i-- {
+		items[i-1] = fn(s.items[i-1])
+	}
+	stack := s.newEmpty()
+	stack.items = items
+	stack.size = s.size
+	return stack, nil
+}
+
+// MapIndexed creates a new stack with the results of applying the function to each
+// item and its index (0 is the top of the stack, like in Get). Like Map, the function
+// is called from the bottom to the top of the stack.
+func (s *Stack[T]) MapIndexed(fn func(uint64, T) T) (*Stack[T], error) {
+	if s.IsEmpty() {
+		return nil, ErrStartOutOfRange
+	}
+
+	stack := s.newEmpty()
+	for i := uint64(0); i < s.size; i++ {
+		stack.Push(fn(s.size-i-1, s.items[i]))
+	}
+	return stack, nil
+}
+
 // Reduce reduces the stack to a single value.
+// The items are combined from the bottom to the top of the stack: fn(fn(bottom, next), ...).
+// Use ReduceRight to combine them in LIFO order.
 func (s *Stack[T]) Reduce(fn func(T, T) T) (T, error) {
 	if s.size == 0 {
 		var rVal T
-		return rVal, errors.New(ErrStackIsEmpty)
+		return rVal, ErrEmpty
 	}
 
 	result := s.items[0]
@@ -332,11 +776,89 @@ func (s *Stack[T]) Reduce(fn func(T, T) T) (T, error) {
 	return result, nil
 }
 
+// ReduceRight reduces the stack to a single value.
+// The items are combined from the top to the bottom of the stack: fn(fn(top, next), ...).
+func (s *Stack[T]) ReduceRight(fn func(T, T) T) (T, error) {
+	if s.size == 0 {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+
+	result := s.items[s.size-1]
+	for i := s.size - 1; i > 0; i-- {
+		result = fn(result, s.items[i-1])
+	}
+	return result, nil
+}
+
+// ReduceInto reduces the stack to a value of a different type, starting from
+// initial and applying fn to each item from the bottom to the top of the stack
+// (the same order used by Reduce).
+func ReduceInto[T, A any](s *Stack[T], initial A, fn func(A, T) A) A {
+	result := initial
+	for i := uint64(0); i < s.size; i++ {
+		result = fn(result, s.items[i])
+	}
+	return result
+}
+
+// MapTo returns a new stack with the result of fn applied to every item of src,
+// keeping their order from the bottom to the top. Unlike Map the result can
+// hold a different type, e.g. to extract the IDs of a stack of structs. Its
+// items are compared with == like the ones of New, use MapToWithComparator when
+// U is not comparable.
+func MapTo[T any, U comparable](src *Stack[T], fn func(T) U) *Stack[U] {
+	return mapInto(src, fn, New[U]())
+}
+
+// MapToWithComparator is like MapTo, the new stack compares its items with
+// the given function.
+func MapToWithComparator[T, U any](src *Stack[T], fn func(T) U, equals func(a, b U) bool) *Stack[U] {
+	return mapInto(src, fn, NewWithComparator(equals))
+}
+
+// mapInto fills result with fn applied to every element of src.
+func mapInto[T, U any](src *Stack[T], fn func(T) U, result *Stack[U]) *Stack[U] {
+	if src.size == 0 {
+		return result
+	}
+	result.items = make([]U, src.size)
+	for i := uint64(0); i < src.size; i++ {
+		result.items[i] = fn(src.items[i])
+	}
+	result.size = src.size
+	return result
+}
+
 // ForEach applies the function to each item in the stack.
+// The items are visited from the top to the bottom of the stack (LIFO order).
 func (s *Stack[T]) ForEach(fn func(*T) error) error {
 	return s.ForRange(0, s.size-1, fn)
 }
 
+// ForEachIndexed applies the function to each item in the stack and its index
+// (0 is the top of the stack, like in Get).
+// The items are visited from the top to the bottom of the stack (LIFO order).
+func (s *Stack[T]) ForEachIndexed(fn func(uint64, *T) error) error {
+	for i := uint64(0); i < s.size; i++ {
+		if err := fn(i, &s.items[s.size-i-1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ForEachReverse applies the function to each item in the stack.
+// The items are visited from the bottom to the top of the stack (the order they were pushed).
+func (s *Stack[T]) ForEachReverse(fn func(*T) error) error {
+	for i := uint64(0); i < s.size; i++ {
+		if err := fn(&s.items[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // ForRange applies the function to each item in the stack within the specified range.
 func (s *Stack[T]) ForRange(start, end uint64, fn func(*T) error) error {
 	if s.IsEmpty() {
@@ -344,15 +866,15 @@ func (s *Stack[T]) ForRange(start, end uint64, fn func(*T) error) error {
 	}
 
 	if start >= s.size {
-		return errors.New(ErrStartIndexOOR)
+		return ErrStartOutOfRange
 	}
 
 	if end >= s.size {
-		return errors.New(ErrEndIndexOOR)
+		return ErrEndOutOfRange
 	}
 
 	if start > end {
-		return errors.New(ErrSIndexGreater)
+		return ErrInvalidRange
 	}
 
 	// Convert the start and end index to the stack indexes
@@ -388,15 +910,15 @@ func (s *Stack[T]) ForFrom(start uint64, fn func(*T) error) error {
 // The function is executed in a separate goroutine for each item.
 func (s *Stack[T]) ConfinedForRange(start, end uint64, fn func(*T) error) error {
 	if start >= s.size {
-		return errors.New(ErrStartIndexOOR)
+		return ErrStartOutOfRange
 	}
 
 	if end >= s.size {
-		return errors.New(ErrEndIndexOOR)
+		return ErrEndOutOfRange
 	}
 
 	if start > end {
-		return errors.New(ErrSIndexGreater)
+		return ErrInvalidRange
 	}
 
 	// Convert the start and end index to the stack indexes
@@ -497,10 +1019,10 @@ func (s *Stack[T]) All(predicate func(T) bool) bool {
 // Find returns the first item that matches the predicate.
 func (s *Stack[T]) Find(predicate func(T) bool) (*T, error) {
 	if s == nil {
-		return nil, errors.New(ErrItemNotFound)
+		return nil, ErrNotFound
 	}
 	if len(s.items) == 0 {
-		return nil, errors.New(ErrItemNotFound)
+		return nil, ErrNotFound
 	}
 
 	for i := uint64(0); i < s.size; i++ {
@@ -508,7 +1030,7 @@ func (s *Stack[T]) Find(predicate func(T) bool) (*T, error) {
 			return &s.items[i], nil
 		}
 	}
-	return nil, errors.New(ErrItemNotFound)
+	return nil, ErrNotFound
 }
 
 // FindIndex returns the index of the first item that matches the predicate.
@@ -518,13 +1040,13 @@ func (s *Stack[T]) FindIndex(predicate func(T) bool) (uint64, error) {
 			return i, nil
 		}
 	}
-	return 0, errors.New(ErrItemNotFound)
+	return 0, ErrNotFound
 }
 
 // FindLast returns the last item that matches the predicate.
 func (s *Stack[T]) FindLast(predicate func(T) bool) (*T, error) {
 	if s.size == 0 {
-		return nil, errors.New(ErrItemNotFound)
+		return nil, ErrNotFound
 	}
 
 	for i := s.size - 1; i > 0; i-- {
@@ -536,13 +1058,13 @@ func (s *Stack[T]) FindLast(predicate func(T) bool) (*T, error) {
 		return &s.items[0], nil
 	}
 
-	return nil, errors.New(ErrItemNotFound)
+	return nil, ErrNotFound
 }
 
 // FindLastIndex returns the index of the last item that matches the predicate.
 func (s *Stack[T]) FindLastIndex(predicate func(T) bool) (uint64, error) {
 	if s.size == 0 {
-		return 0, errors.New(ErrItemNotFound)
+		return 0, ErrNotFound
 	}
 
 	for i := s.size - 1; i > 0; i-- {
@@ -554,7 +1076,7 @@ func (s *Stack[T]) FindLastIndex(predicate func(T) bool) (uint64, error) {
 		return 0, nil
 	}
 
-	return 0, errors.New(ErrItemNotFound)
+	return 0, ErrNotFound
 }
 
 // FindAll returns all items that match the predicate.
@@ -578,3 +1100,51 @@ func (s *Stack[T]) FindIndices(predicate func(T) bool) []uint64 {
 	}
 	return indices
 }
+
+// Min returns the smallest element of the stack according to less (the first
+// one if there are several), or ErrEmpty if the stack is empty.
+func Min[T any](s *Stack[T], less func(T, T) bool) (T, error) {
+	if s.IsEmpty() {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+
+	result := s.items[0]
+	for i := uint64(1); i < s.size; i++ {
+		if less(s.items[i], result) {
+			result = s.items[i]
+		}
+	}
+	return result, nil
+}
+
+// Max returns the largest element of the stack according to less (the first
+// one if there are several), or ErrEmpty if the stack is empty.
+func Max[T any](s *Stack[T], less func(T, T) bool) (T, error) {
+	return Min(s, func(x, y T) bool { return less(y, x) })
+}
+
+// MinOrdered returns the smallest element of a stack of an ordered type.
+func MinOrdered[T cmp.Ordered](s *Stack[T]) (T, error) {
+	return Min(s, cmp.Less[T])
+}
+
+// MaxOrdered returns the largest element of a stack of an ordered type.
+func MaxOrdered[T cmp.Ordered](s *Stack[T]) (T, error) {
+	return Max(s, cmp.Less[T])
+}
+
+// Sum returns the sum of all the elements of a numeric stack (0 if it's empty).
+func Sum[T common.Number](s *Stack[T]) T {
+	return ReduceInto(s, T(0), func(acc, v T) T { return acc + v })
+}
+
+// Average returns the arithmetic mean of the elements of a numeric stack
+// (computed in float64), or ErrEmpty if the stack is empty.
+func Average[T common.Number](s *Stack[T]) (float64, error) {
+	if s.IsEmpty() {
+		return 0, ErrEmpty
+	}
+	sum := ReduceInto(s, 0.0, func(acc float64, v T) float64 { return acc + float64(v) })
+	return sum / float64(s.size), nil
+}
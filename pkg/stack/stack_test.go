@@ -16,8 +16,12 @@
 package stack_test
 
 import (
+	"cmp"
+	"context"
+	"errors"
 	"fmt"
 	"reflect"
+	"slices"
 	"strconv"
 	"sync"
 	"testing"
@@ -559,6 +563,38 @@ func TestPopAll(t *testing.T) {
 	}
 }
 
+func TestPopWhileAndPopUntil(t *testing.T) {
+	s := stack.New[int]()
+	s.PushN(1, 2, 3, 4, 5) // 5 is the top
+
+	if items := s.PopWhile(func(v int) bool { return v > 3 }); !slices.Equal(items, []int{5, 4}) || s.Size() != 3 {
+		t.Errorf("expected [5 4] popped, got %v (size %d)", items, s.Size())
+	}
+	if items := s.PopWhile(func(v int) bool { return v > 3 }); items != nil {
+		t.Errorf("expected nothing popped, got %v", items)
+	}
+
+	if items := s.PopUntil(func(v int) bool { return v == 1 }); !slices.Equal(items, []int{3, 2}) {
+		t.Errorf("expected [3 2] popped, got %v", items)
+	}
+	if top, _ := s.Top(); *top != 1 {
+		t.Errorf("expected the matching item to stay on top, got %d", *top)
+	}
+	if items := s.PopUntil(func(v int) bool { return v == 9 }); !slices.Equal(items, []int{1}) || !s.IsEmpty() {
+		t.Errorf("expected the stack to be emptied, got %v (size %d)", items, s.Size())
+	}
+}
+
+func TestPushIfAbsent(t *testing.T) {
+	s := stack.NewWithCapacity[int](2)
+	if !s.PushIfAbsent(1) || s.PushIfAbsent(1) || !s.PushIfAbsent(2) {
+		t.Error("expected only the absent items to be pushed")
+	}
+	if s.PushIfAbsent(3) || s.Size() != 2 {
+		t.Errorf("expected a full stack to refuse the item, got size %d", s.Size())
+	}
+}
+
 func TestPushAll(t *testing.T) {
 	s := stack.New[int]()
 	items := []int{1, 2, 3}
@@ -1285,3 +1321,605 @@ func TestConfinedForFrom(t *testing.T) {
 		t.Errorf("Expected result to be either %v or %v, but got %v", expected1, expected2, result)
 	}
 }
+
+func TestPeekN(t *testing.T) {
+	s := stack.New[int]()
+	if _, err := s.PeekN(1); err == nil {
+		t.Errorf(errYesError)
+	}
+
+	s.PushN(1, 2, 3, 4)
+	items, err := s.PeekN(3)
+	if err != nil {
+		t.Errorf(errNoError, err)
+	}
+	expected := []int{4, 3, 2}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf(errExpectedResult, expected, items)
+	}
+	if s.Size() != 4 {
+		t.Errorf(errExpectedItemX, 4, s.Size())
+	}
+
+	if _, err := s.PeekN(5); err == nil {
+		t.Errorf(errYesError)
+	}
+
+	// The returned slice must not alias the stack
+	items[0] = 100
+	top, _ := s.Top()
+	if *top != 4 {
+		t.Errorf(errExpectedItemX, 4, *top)
+	}
+}
+
+func TestGet(t *testing.T) {
+	s := stack.New[int]()
+	if _, err := s.Get(0); err == nil {
+		t.Errorf(errYesError)
+	}
+
+	s.PushN(1, 2, 3)
+	for i, expected := range []int{3, 2, 1} {
+		item, err := s.Get(uint64(i))
+		if err != nil {
+			t.Errorf(errNoError, err)
+		}
+		if *item != expected {
+			t.Errorf(errExpectedXItemY, i, expected, *item)
+		}
+	}
+
+	if _, err := s.Get(3); err == nil {
+		t.Errorf(errYesError)
+	}
+	if s.Size() != 3 {
+		t.Errorf(errExpectedItemX, 3, s.Size())
+	}
+}
+
+func TestSentinelErrors(t *testing.T) {
+	s := stack.New[int]()
+	if _, err := s.Pop(); !errors.Is(err, stack.ErrEmpty) {
+		t.Errorf("expected %v, got %v", stack.ErrEmpty, err)
+	}
+
+	s.Push(1)
+	if err := s.Swap(); !errors.Is(err, stack.ErrNotEnoughItems) {
+		t.Errorf("expected %v, got %v", stack.ErrNotEnoughItems, err)
+	}
+	if _, err := s.PeekN(2); !errors.Is(err, stack.ErrNotEnoughItems) {
+		t.Errorf("expected %v, got %v", stack.ErrNotEnoughItems, err)
+	}
+	_, err := s.Get(3)
+	if !errors.Is(err, stack.ErrStartOutOfRange) {
+		t.Errorf("expected %v, got %v", stack.ErrStartOutOfRange, err)
+	}
+	if err.Error() != stack.ErrStartIndexOOR {
+		t.Errorf("expected %v, got %v", stack.ErrStartIndexOOR, err.Error())
+	}
+}
+
+func TestNewWithComparator(t *testing.T) {
+	s := stack.NewWithComparator(slices.Equal[[]string])
+	s.Push([]string{"a"})
+	s.Push([]string{"b", "c"})
+
+	if !s.Contains([]string{"a"}) || !s.Contains([]string{"b", "c"}) {
+		t.Error("expected stack to contain both items")
+	}
+	if s.Contains([]string{"c", "b"}) {
+		t.Error("expected stack not to contain [c b]")
+	}
+
+	c := s.Copy()
+	if !s.Equal(c) {
+		t.Error("expected the copy to be equal to the original stack")
+	}
+	c.Push([]string{"d"})
+	if s.Equal(c) {
+		t.Error("expected stacks with different items not to be equal")
+	}
+}
+
+func TestReduceInto(t *testing.T) {
+	s := stack.New[int]()
+	s.PushN(1, 2, 3)
+	got := stack.ReduceInto(s, "", func(acc string, v int) string {
+		return acc + strconv.Itoa(v)
+	})
+	if got != "123" {
+		t.Errorf("expected 123, got %s", got)
+	}
+
+	if got := stack.ReduceInto(stack.New[int](), "empty", func(acc string, _ int) string { return "" }); got != "empty" {
+		t.Errorf("expected empty, got %s", got)
+	}
+}
+
+func TestMapTo(t *testing.T) {
+	type user struct {
+		id   int
+		tags []string
+	}
+	src := stack.NewWithComparator(func(a, b user) bool { return a.id == b.id })
+	_ = src.PushAll([]user{{1, []string{"a"}}, {2, nil}, {3, []string{"b", "c"}}})
+
+	ids := stack.MapTo(src, func(u user) int { return u.id })
+	if !slices.Equal(ids.ToSlice(), []int{3, 2, 1}) || !ids.Contains(2) {
+		t.Errorf("expected the ids [1 2 3], got %v", ids.ToSlice())
+	}
+
+	tags := stack.MapToWithComparator(src, func(u user) []string { return u.tags }, slices.Equal[[]string])
+	if tags.Size() != 3 || !tags.ContainsAll([]string{"b", "c"}) || tags.ContainsAll([]string{"c", "b"}) {
+		t.Errorf("expected the tags to be compared with the given function")
+	}
+
+	if empty := stack.MapTo(stack.New[int](), strconv.Itoa); !empty.IsEmpty() {
+		t.Errorf("expected an empty result, got %d values", empty.Size())
+	}
+}
+
+func TestIterationOrder(t *testing.T) {
+	s := stack.New[int]()
+	s.PushN(1, 2, 3) // 3 is the top
+
+	var visited []int
+	_ = s.ForEach(func(v *int) error {
+		visited = append(visited, *v)
+		return nil
+	})
+	if !slices.Equal(visited, []int{3, 2, 1}) {
+		t.Errorf("ForEach: expected [3 2 1], got %v", visited)
+	}
+
+	visited = nil
+	_ = s.ForEachReverse(func(v *int) error {
+		visited = append(visited, *v)
+		return nil
+	})
+	if !slices.Equal(visited, []int{1, 2, 3}) {
+		t.Errorf("ForEachReverse: expected [1 2 3], got %v", visited)
+	}
+
+	concat := func(a, b string) string { return a + b }
+	strs := stack.New[string]()
+	strs.PushN("a", "b", "c")
+	if r, _ := strs.Reduce(concat); r != "abc" {
+		t.Errorf("Reduce: expected abc, got %s", r)
+	}
+	if r, _ := strs.ReduceRight(concat); r != "cba" {
+		t.Errorf("ReduceRight: expected cba, got %s", r)
+	}
+
+	visited = nil
+	mapped, err := s.MapReverse(func(v int) int {
+		visited = append(visited, v)
+		return v * 10
+	})
+	if err != nil {
+		t.Fatalf(errNoError, err)
+	}
+	if !slices.Equal(visited, []int{3, 2, 1}) {
+		t.Errorf("MapReverse: expected calls in order [3 2 1], got %v", visited)
+	}
+	if top, _ := mapped.Pop(); *top != 30 {
+		t.Errorf("MapReverse: expected top 30, got %d", *top)
+	}
+
+	visited = nil
+	_, _ = s.Map(func(v int) int {
+		visited = append(visited, v)
+		return v
+	})
+	if !slices.Equal(visited, []int{1, 2, 3}) {
+		t.Errorf("Map: expected calls in order [1 2 3], got %v", visited)
+	}
+}
+
+func TestReverseVariantsEmpty(t *testing.T) {
+	s := stack.New[int]()
+	if _, err := s.ReduceRight(func(a, b int) int { return a + b }); !errors.Is(err, stack.ErrEmpty) {
+		t.Errorf("expected %v, got %v", stack.ErrEmpty, err)
+	}
+	if _, err := s.MapReverse(func(v int) int { return v }); err == nil {
+		t.Errorf(errYesError)
+	}
+	if err := s.ForEachReverse(func(*int) error { return errors.New("called") }); err != nil {
+		t.Errorf(errNoError, err)
+	}
+}
+
+func TestStringFunc(t *testing.T) {
+	s := stack.New[int]()
+	s.PushN(1, 2)
+	result := s.StringFunc(func(v int) string { return strconv.Itoa(v * 10) })
+	if result != "[10 20]" {
+		t.Errorf("Expected string representation to be %q, but got %q", "[10 20]", result)
+	}
+}
+
+func TestAdoptDetach(t *testing.T) {
+	s := stack.Adopt([]int{1, 2, 3})
+	if top, err := s.Peek(); err != nil || *top != 3 {
+		t.Errorf("Expected top item to be 3, but got %v (%v)", top, err)
+	}
+
+	items := s.Detach()
+	if !s.IsEmpty() || !reflect.DeepEqual(items, []int{1, 2, 3}) {
+		t.Errorf("Expected an empty stack and [1 2 3], but got size %d and %v", s.Size(), items)
+	}
+}
+
+func TestAggregates(t *testing.T) {
+	c := stack.NewFromSlice([]int{3, 1, 4, 1, 5})
+
+	if v, err := stack.Min(c, func(a, b int) bool { return a < b }); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := stack.Max(c, func(a, b int) bool { return a < b }); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v, err := stack.MinOrdered(c); err != nil || v != 1 {
+		t.Errorf("expected min 1, got %v (%v)", v, err)
+	}
+	if v, err := stack.MaxOrdered(c); err != nil || v != 5 {
+		t.Errorf("expected max 5, got %v (%v)", v, err)
+	}
+	if v := stack.Sum(c); v != 14 {
+		t.Errorf("expected sum 14, got %v", v)
+	}
+	if v, err := stack.Average(c); err != nil || v != 2.8 {
+		t.Errorf("expected average 2.8, got %v (%v)", v, err)
+	}
+
+	e := stack.New[int]()
+	if _, err := stack.Min(e, func(a, b int) bool { return a < b }); err != stack.ErrEmpty {
+		t.Errorf("expected %v, got %v", stack.ErrEmpty, err)
+	}
+	if _, err := stack.Average(e); err != stack.ErrEmpty {
+		t.Errorf("expected %v, got %v", stack.ErrEmpty, err)
+	}
+	if v := stack.Sum(e); v != 0 {
+		t.Errorf("expected sum 0, got %v", v)
+	}
+}
+
+// mirror applies the change notifications of a container to a slice
+type mirror struct {
+	t     *testing.T
+	items []int
+}
+
+func (m *mirror) insert(index uint64, v int) {
+	m.items = slices.Insert(m.items, int(index), v)
+}
+
+func (m *mirror) remove(index uint64, v int) {
+	if index >= uint64(len(m.items)) || m.items[index] != v {
+		m.t.Fatalf("unexpected removal of %d at %d from %v", v, index, m.items)
+	}
+	m.items = slices.Delete(m.items, int(index), int(index)+1)
+}
+
+func (m *mirror) clear() {
+	m.items = nil
+}
+
+func TestObservers(t *testing.T) {
+	s := stack.New[int]()
+	m := &mirror{t: t}
+	s.OnInsert(m.insert)
+	s.OnRemove(m.remove)
+	s.OnClear(m.clear)
+
+	// The indexes are counted from the top, like ToSlice
+	check := func(op string) {
+		t.Helper()
+		if !slices.Equal(m.items, s.ToSlice()) {
+			t.Fatalf("after %s: expected %v, got %v", op, s.ToSlice(), m.items)
+		}
+	}
+
+	s.Push(1)
+	s.PushN(2, 3, 4, 5, 6)
+	check("Push/PushN")
+	_, _ = s.Pop()
+	_, _ = s.PopN(2)
+	check("Pop/PopN")
+	s.PushAll([]int{7, 8, 9})
+	s.Filter(func(v int) bool { return v%2 == 1 })
+	check("Filter")
+	s.PopAll()
+	check("PopAll")
+	s.Push(1)
+	s.Clear()
+	check("Clear")
+}
+
+func TestIterator(t *testing.T) {
+	s := stack.New[int]()
+	s.PushN(1, 2, 3)
+
+	var got []int
+	for v := range s.Iterator(context.Background()) {
+		got = append(got, v)
+	}
+	if !slices.Equal(got, []int{3, 2, 1}) || s.Size() != 3 {
+		t.Errorf("expected [3 2 1] and an unchanged stack, got %v (size %d)", got, s.Size())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := s.Iterator(ctx)
+	<-ch
+	cancel()
+	for range ch {
+	}
+}
+
+func TestIndexed(t *testing.T) {
+	s := stack.New[int]()
+	for _, v := range []int{10, 20, 30} {
+		s.Push(v)
+	}
+
+	// Index 0 is the top of the stack
+	var visited []int
+	if err := s.ForEachIndexed(func(i uint64, v *int) error {
+		visited = append(visited, *v)
+		*v += int(i)
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(visited, []int{30, 20, 10}) {
+		t.Errorf("expected to visit [30 20 10], got %v", visited)
+	}
+	if top, _ := s.Get(0); *top != 30 {
+		t.Errorf("expected 30, got %d", *top)
+	}
+	if bottom, _ := s.Get(2); *bottom != 12 {
+		t.Errorf("expected 12, got %d", *bottom)
+	}
+
+	mapped, err := s.MapIndexed(func(i uint64, v int) int { return int(i) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i := uint64(0); i < 3; i++ {
+		if v, _ := mapped.Get(i); *v != int(i) {
+			t.Errorf("expected %d at index %d, got %d", i, i, *v)
+		}
+	}
+	if _, err := stack.New[int]().MapIndexed(func(i uint64, v int) int { return v }); !errors.Is(err, stack.ErrStartOutOfRange) {
+		t.Errorf("expected %v, got %v", stack.ErrStartOutOfRange, err)
+	}
+}
+
+func TestCloneWith(t *testing.T) {
+	a, b := 1, 2
+	c := stack.New[*int]()
+	c.Push(&a)
+	c.Push(&b)
+	clone := c.CloneWith(func(p *int) *int {
+		v := *p
+		return &v
+	})
+
+	// The clone must not see the changes to the original elements
+	a, b = 10, 20
+	sum := 0
+	for _, p := range clone.ToSlice() {
+		if p == &a || p == &b {
+			t.Fatal("expected the clone not to share the elements")
+		}
+		sum += *p
+	}
+	if sum != 3 {
+		t.Errorf("expected the cloned elements to sum to 3, got %d", sum)
+	}
+}
+
+func TestRemoveIf(t *testing.T) {
+	s := stack.New[int]()
+	for _, v := range []int{1, 2, 1, 3, 1, 2} {
+		s.Push(v)
+	}
+
+	// The first items are the closest to the top (ToSlice starts from the top)
+	if n := s.RemoveFirstN(1, 2); n != 2 || !slices.Equal(s.ToSlice(), []int{2, 3, 2, 1}) {
+		t.Errorf("expected to remove 2 items leaving [2 3 2 1], removed %d leaving %v", n, s.ToSlice())
+	}
+	if n := s.RemoveAll(2); n != 2 || !slices.Equal(s.ToSlice(), []int{3, 1}) {
+		t.Errorf("expected to remove 2 items leaving [3 1], removed %d leaving %v", n, s.ToSlice())
+	}
+	if n := s.RemoveIf(func(v int) bool { return v > 0 }); n != 2 || !s.IsEmpty() {
+		t.Errorf("expected to remove 2 items, removed %d leaving %v", n, s.ToSlice())
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	s := stack.NewWithCapacity[int](3)
+	if s.Capacity() != 3 || s.IsFull() {
+		t.Fatalf("expected an empty stack of capacity 3, got capacity %d", s.Capacity())
+	}
+	for i := 1; i <= 3; i++ {
+		if err := s.Push(i); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if !s.IsFull() {
+		t.Error("expected the stack to be full")
+	}
+	if err := s.Push(4); !errors.Is(err, stack.ErrFull) {
+		t.Errorf("expected %v, got %v", stack.ErrFull, err)
+	}
+	if err := s.PushN(4, 5); !errors.Is(err, stack.ErrFull) || s.Size() != 3 {
+		t.Errorf("expected %v and nothing pushed, got %v (size %d)", stack.ErrFull, err, s.Size())
+	}
+	if c := s.Copy(); c.Capacity() != 3 {
+		t.Errorf("expected the copy to keep the capacity, got %d", c.Capacity())
+	}
+
+	// Shrinking drops the oldest items
+	var removed []int
+	s.OnRemove(func(index uint64, v int) { removed = append(removed, int(index)*10+v) })
+	s.SetCapacity(1)
+	if !slices.Equal(s.ToSlice(), []int{3}) {
+		t.Errorf("expected [3], got %v", s.ToSlice())
+	}
+	if !slices.Equal(removed, []int{21, 12}) {
+		t.Errorf("expected the removals (2, 1) and (1, 2), got %v", removed)
+	}
+
+	s.SetCapacity(0)
+	if err := s.PushN(4, 5, 6); err != nil || s.IsFull() {
+		t.Errorf("expected an unbounded stack, got %v", err)
+	}
+}
+
+func TestContainsAllAny(t *testing.T) {
+	c := stack.New[int]()
+	for _, v := range []int{1, 2, 1, 3} {
+		c.Push(v)
+	}
+
+	if !c.ContainsAll(3, 1, 1) || !c.ContainsAll() || c.ContainsAll(1, 4) {
+		t.Error("expected ContainsAll to be true only when every element is present")
+	}
+	if !c.ContainsAny(4, 2) || c.ContainsAny(4, 5) || c.ContainsAny() {
+		t.Error("expected ContainsAny to be true only when an element is present")
+	}
+	if c.CountOf(1) != 2 || c.CountOf(3) != 1 || c.CountOf(4) != 0 {
+		t.Errorf("expected counts 2, 1 and 0, got %d, %d and %d", c.CountOf(1), c.CountOf(3), c.CountOf(4))
+	}
+}
+
+func TestDrainAll(t *testing.T) {
+	s := stack.New[int]()
+	if s.DrainAll() != nil {
+		t.Error("expected nil from an empty stack")
+	}
+	s.Push(1)
+	s.Push(2)
+	s.Push(3)
+
+	cleared := 0
+	s.OnClear(func() { cleared++ })
+	items := s.DrainAll()
+	if !slices.Equal(items, []int{3, 2, 1}) || !s.IsEmpty() || cleared != 1 {
+		t.Errorf("expected [3 2 1], an empty stack and a clear, got %v (size %d, %d clears)", items, s.Size(), cleared)
+	}
+
+	s.Push(4)
+	if top, _ := s.Peek(); top == nil || *top != 4 {
+		t.Errorf("expected 4 on top, got %v", top)
+	}
+	if !slices.Equal(items, []int{3, 2, 1}) {
+		t.Errorf("expected the drained items not to share the stack storage, got %v", items)
+	}
+}
+
+func TestReserve(t *testing.T) {
+	s := stack.New[int]()
+	s.Reserve(100)
+	allocs := testing.AllocsPerRun(1, func() {
+		for i := 0; i < 100; i++ {
+			_ = s.Push(i)
+		}
+		s.Clear()
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocations after Reserve, but got %v", allocs)
+	}
+
+	// A bounded stack reserves at most the room left to its capacity
+	bounded := stack.NewWithCapacity[int](4)
+	_ = bounded.Push(1)
+	bounded.Reserve(1000)
+	if items := bounded.Detach(); cap(items) > 8 {
+		t.Errorf("Expected a small reservation, but got capacity %d", cap(items))
+	}
+}
+
+func TestShrinkToFit(t *testing.T) {
+	s := stack.New[int]()
+	for i := 0; i < 1000; i++ {
+		_ = s.Push(i)
+	}
+	_, _ = s.PopN(990)
+	s.ShrinkToFit()
+	if items := s.Detach(); len(items) != 10 || cap(items) != 10 {
+		t.Errorf("Expected 10 items with capacity 10, but got %d with capacity %d", len(items), cap(items))
+	}
+
+	s.ShrinkToFit() // no-op on an empty stack
+	if !s.IsEmpty() {
+		t.Error(errStackNotEmpty)
+	}
+}
+
+func TestPopReleasesItems(t *testing.T) {
+	a, b, c := 1, 2, 3
+	s := stack.NewWithComparator(func(x, y *int) bool { return x == y })
+	_ = s.PushAll([]*int{&a, &b, &c})
+	_, _ = s.Pop()
+	_, _ = s.Pop()
+
+	// The popped slots must not keep the items alive
+	items := s.Detach()
+	for i, p := range items[:cap(items)] {
+		if i > 0 && p != nil {
+			t.Errorf("Expected slot %d to be zeroed, but it holds %v", i, *p)
+		}
+	}
+}
+
+func TestWipe(t *testing.T) {
+	items := make([]string, 3, 5)
+	copy(items, []string{"key", "token", "secret"})
+	s := stack.Adopt(items)
+	_, _ = s.Pop()
+
+	s.Wipe()
+	if !s.IsEmpty() {
+		t.Error(errStackNotEmpty)
+	}
+	for i, v := range items[:cap(items)] {
+		if v != "" {
+			t.Errorf("Expected slot %d to be wiped, but it holds %q", i, v)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	build := func(values ...int) *stack.Stack[int] {
+		l := stack.New[int]()
+		for _, v := range values {
+			_ = l.Push(v)
+		}
+		return l
+	}
+
+	tests := []struct {
+		a, b []int
+		want int
+	}{
+		{nil, nil, 0},
+		{[]int{1, 2, 3}, []int{1, 2, 3}, 0},
+		{[]int{1, 2, 3}, []int{1, 3}, -1},
+		{[]int{2}, []int{1, 9, 9}, 1},
+		{[]int{1, 2}, []int{1, 2, 3}, -1},
+		{[]int{1, 2, 3}, nil, 1},
+	}
+	for _, tt := range tests {
+		if got := build(tt.a...).Compare(build(tt.b...), cmp.Compare[int]); got != tt.want {
+			t.Errorf("%v vs %v: expected %d, got %d", tt.a, tt.b, tt.want, got)
+		}
+	}
+
+	// The result is normalized to -1, 0 or +1
+	if got := build(1).Compare(build(10), func(a, b int) int { return a - b }); got != -1 {
+		t.Errorf("expected -1, got %d", got)
+	}
+}
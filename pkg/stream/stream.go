@@ -0,0 +1,412 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stream provides lazy, chainable pipelines (Map, Filter, Take, ...)
+// that can be sourced from and collected into any of the gods containers.
+// Elements are pulled one at a time through the whole pipeline, so no
+// intermediate containers are allocated (except for Sorted, which needs to
+// see all the elements before it can yield the first one).
+package stream
+
+import (
+	"sort"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	common "github.com/pzaino/gods/pkg/common"
+	linkList "github.com/pzaino/gods/pkg/linkList"
+	queue "github.com/pzaino/gods/pkg/queue"
+	stack "github.com/pzaino/gods/pkg/stack"
+)
+
+// Stream is a lazy sequence of elements. Streams are single use: once a
+// terminal operation (Reduce, ForEach, Collect, ...) has consumed it, the
+// stream is empty
+type Stream[T any] struct {
+	next func() (T, bool)
+}
+
+// New creates a stream that pulls its elements from next, until next returns false
+func New[T any](next func() (T, bool)) *Stream[T] {
+	return &Stream[T]{next: next}
+}
+
+// Empty returns an empty stream
+func Empty[T any]() *Stream[T] {
+	return New(func() (T, bool) {
+		var zero T
+		return zero, false
+	})
+}
+
+// FromSlice creates a stream over the elements of a slice
+func FromSlice[T any](items []T) *Stream[T] {
+	i := 0
+	return New(func() (T, bool) {
+		if i >= len(items) {
+			var zero T
+			return zero, false
+		}
+		i++
+		return items[i-1], true
+	})
+}
+
+// FromBuffer creates a stream over the elements of a buffer (from index 0)
+func FromBuffer[T any](b *buffer.Buffer[T]) *Stream[T] {
+	i := uint64(0)
+	return New(func() (T, bool) {
+		if i >= b.Size() {
+			var zero T
+			return zero, false
+		}
+		v, err := b.Get(i)
+		if err != nil {
+			return v, false
+		}
+		i++
+		return v, true
+	})
+}
+
+// FromList creates a stream over the elements of a linked list (from the head)
+func FromList[T any](l *linkList.LinkList[T]) *Stream[T] {
+	n := l.GetFirst()
+	return New(func() (T, bool) {
+		if n == nil {
+			var zero T
+			return zero, false
+		}
+		v := n.Value()
+		n = n.Next()
+		return v, true
+	})
+}
+
+// FromQueue creates a stream over the elements of a queue (from the front),
+// the queue is not modified
+func FromQueue[T any](q *queue.Queue[T]) *Stream[T] {
+	i := uint64(0)
+	return New(func() (T, bool) {
+		if i >= q.Size() {
+			var zero T
+			return zero, false
+		}
+		v, err := q.PeekAt(i)
+		if err != nil {
+			return v, false
+		}
+		i++
+		return v, true
+	})
+}
+
+// FromStack creates a stream over the elements of a stack (from the top),
+// the stack is not modified
+func FromStack[T any](s *stack.Stack[T]) *Stream[T] {
+	i := uint64(0)
+	return New(func() (T, bool) {
+		if i >= s.Size() {
+			var zero T
+			return zero, false
+		}
+		v, err := s.Get(i)
+		if err != nil {
+			var zero T
+			return zero, false
+		}
+		i++
+		return *v, true
+	})
+}
+
+// Next returns the next element of the stream, the boolean is false when the
+// stream is exhausted
+func (s *Stream[T]) Next() (T, bool) {
+	return s.next()
+}
+
+// Map returns a stream with f applied to every element
+func (s *Stream[T]) Map(f func(T) T) *Stream[T] {
+	return MapTo(s, f)
+}
+
+// MapTo returns a stream with f applied to every element of s, it's a function
+// rather than a method because the element type changes
+func MapTo[T, U any](s *Stream[T], f func(T) U) *Stream[U] {
+	return New(func() (U, bool) {
+		v, ok := s.next()
+		if !ok {
+			var zero U
+			return zero, false
+		}
+		return f(v), true
+	})
+}
+
+// Filter returns a stream with only the elements that match the predicate
+func (s *Stream[T]) Filter(f func(T) bool) *Stream[T] {
+	return New(func() (T, bool) {
+		for {
+			v, ok := s.next()
+			if !ok || f(v) {
+				return v, ok
+			}
+		}
+	})
+}
+
+// Take returns a stream with at most the first n elements
+func (s *Stream[T]) Take(n uint64) *Stream[T] {
+	return New(func() (T, bool) {
+		if n == 0 {
+			var zero T
+			return zero, false
+		}
+		n--
+		return s.next()
+	})
+}
+
+// TakeWhile returns a stream that stops at the first element that doesn't match the predicate
+func (s *Stream[T]) TakeWhile(f func(T) bool) *Stream[T] {
+	done := false
+	return New(func() (T, bool) {
+		var zero T
+		if done {
+			return zero, false
+		}
+		v, ok := s.next()
+		if !ok || !f(v) {
+			done = true
+			return zero, false
+		}
+		return v, true
+	})
+}
+
+// Skip returns a stream without the first n elements
+func (s *Stream[T]) Skip(n uint64) *Stream[T] {
+	return New(func() (T, bool) {
+		for ; n > 0; n-- {
+			if _, ok := s.next(); !ok {
+				var zero T
+				return zero, false
+			}
+		}
+		return s.next()
+	})
+}
+
+// Distinct returns a stream without duplicates (the first occurrence is kept).
+// Elements are compared with ==, so it panics on elements that are not
+// comparable (use DistinctFunc for them)
+func (s *Stream[T]) Distinct() *Stream[T] {
+	return s.distinct(common.NewSeen(func(v T) any { return v }, nil))
+}
+
+// DistinctFunc returns a stream without the elements equal (according to
+// equals) to an earlier one. It's O(n^2), prefer Distinct for comparable elements
+func (s *Stream[T]) DistinctFunc(equals func(a, b T) bool) *Stream[T] {
+	return s.distinct(common.NewSeen(nil, equals))
+}
+
+// distinct returns a stream with the elements never seen before
+func (s *Stream[T]) distinct(seen *common.Seen[T]) *Stream[T] {
+	return s.Filter(seen.Add)
+}
+
+// Sorted returns a stream with the elements sorted according to the given
+// function (the sort is stable). The whole upstream is consumed when the
+// first element is requested
+func (s *Stream[T]) Sorted(less func(T, T) bool) *Stream[T] {
+	var sorted *Stream[T]
+	return New(func() (T, bool) {
+		if sorted == nil {
+			items := s.ToSlice()
+			sort.SliceStable(items, func(i, j int) bool { return less(items[i], items[j]) })
+			sorted = FromSlice(items)
+		}
+		return sorted.next()
+	})
+}
+
+// Peek returns a stream that calls f on every element as it flows through
+// (useful for debugging a pipeline)
+func (s *Stream[T]) Peek(f func(T)) *Stream[T] {
+	return New(func() (T, bool) {
+		v, ok := s.next()
+		if ok {
+			f(v)
+		}
+		return v, ok
+	})
+}
+
+// Concat returns a stream with the elements of s followed by the elements of other
+func (s *Stream[T]) Concat(other *Stream[T]) *Stream[T] {
+	first := true
+	return New(func() (T, bool) {
+		if first {
+			if v, ok := s.next(); ok {
+				return v, true
+			}
+			first = false
+		}
+		return other.next()
+	})
+}
+
+// ForEach calls f on every element of the stream
+func (s *Stream[T]) ForEach(f func(T)) {
+	for v, ok := s.next(); ok; v, ok = s.next() {
+		f(v)
+	}
+}
+
+// Reduce reduces the stream to a single value
+func (s *Stream[T]) Reduce(f func(T, T) T, initial T) T {
+	acc := initial
+	s.ForEach(func(v T) {
+		acc = f(acc, v)
+	})
+	return acc
+}
+
+// Count returns the number of elements in the stream
+func (s *Stream[T]) Count() uint64 {
+	var n uint64
+	s.ForEach(func(T) { n++ })
+	return n
+}
+
+// First returns the first element of the stream, the boolean is false if the
+// stream is empty
+func (s *Stream[T]) First() (T, bool) {
+	return s.next()
+}
+
+// Any checks if any element matches the predicate (stops at the first match)
+func (s *Stream[T]) Any(f func(T) bool) bool {
+	_, ok := s.Filter(f).next()
+	return ok
+}
+
+// All checks if all elements match the predicate (stops at the first mismatch)
+func (s *Stream[T]) All(f func(T) bool) bool {
+	return !s.Any(func(v T) bool { return !f(v) })
+}
+
+// ToSlice collects the stream into a slice
+func (s *Stream[T]) ToSlice() []T {
+	return Collect(s, ToSlice[T]())
+}
+
+// ToList collects the stream into a new linked list, the list compares its
+// elements with == (use Collect with a custom Collector for other comparators)
+func (s *Stream[T]) ToList() *linkList.LinkList[T] {
+	items := s.ToSlice()
+	l := linkList.NewWithComparator[T](nil)
+	// Prepend from the end, so building the list is O(n)
+	for i := len(items) - 1; i >= 0; i-- {
+		l.Prepend(items[i])
+	}
+	return l
+}
+
+// Collector describes how to accumulate the elements of a stream into a container
+type Collector[T, C any] struct {
+	New func() C
+	Add func(C, T) C
+}
+
+// Collect consumes the stream and accumulates its elements with the given collector
+func Collect[T, C any](s *Stream[T], c Collector[T, C]) C {
+	acc := c.New()
+	s.ForEach(func(v T) {
+		acc = c.Add(acc, v)
+	})
+	return acc
+}
+
+// ToSlice returns a collector that appends the elements to a slice
+func ToSlice[T any]() Collector[T, []T] {
+	return Collector[T, []T]{
+		New: func() []T { return nil },
+		Add: func(items []T, v T) []T { return append(items, v) },
+	}
+}
+
+// ToBuffer returns a collector that appends the elements to a new buffer
+func ToBuffer[T comparable]() Collector[T, *buffer.Buffer[T]] {
+	return toBuffer(buffer.New[T])
+}
+
+// ToBufferWithComparator is like ToBuffer, the new buffer compares its
+// elements with the given function
+func ToBufferWithComparator[T any](equals func(a, b T) bool) Collector[T, *buffer.Buffer[T]] {
+	return toBuffer(func() *buffer.Buffer[T] { return buffer.NewWithComparator(equals) })
+}
+
+func toBuffer[T any](newBuffer func() *buffer.Buffer[T]) Collector[T, *buffer.Buffer[T]] {
+	return Collector[T, *buffer.Buffer[T]]{
+		New: newBuffer,
+		Add: func(b *buffer.Buffer[T], v T) *buffer.Buffer[T] {
+			_ = b.Append(v) // a buffer with no capacity limit never overflows
+			return b
+		},
+	}
+}
+
+// ToStack returns a collector that pushes the elements on a new stack (the
+// last element of the stream ends up on top)
+func ToStack[T comparable]() Collector[T, *stack.Stack[T]] {
+	return toStack(stack.New[T])
+}
+
+// ToStackWithComparator is like ToStack, the new stack compares its items
+// with the given function
+func ToStackWithComparator[T any](equals func(a, b T) bool) Collector[T, *stack.Stack[T]] {
+	return toStack(func() *stack.Stack[T] { return stack.NewWithComparator(equals) })
+}
+
+func toStack[T any](newStack func() *stack.Stack[T]) Collector[T, *stack.Stack[T]] {
+	return Collector[T, *stack.Stack[T]]{
+		New: newStack,
+		Add: func(s *stack.Stack[T], v T) *stack.Stack[T] {
+			s.Push(v)
+			return s
+		},
+	}
+}
+
+// ToQueue returns a collector that enqueues the elements in a new queue
+func ToQueue[T comparable]() Collector[T, *queue.Queue[T]] {
+	return toQueue(queue.New[T])
+}
+
+// ToQueueWithComparator is like ToQueue, the new queue compares its elements
+// with the given function
+func ToQueueWithComparator[T any](equals func(a, b T) bool) Collector[T, *queue.Queue[T]] {
+	return toQueue(func() *queue.Queue[T] { return queue.NewWithComparator(equals) })
+}
+
+func toQueue[T any](newQueue func() *queue.Queue[T]) Collector[T, *queue.Queue[T]] {
+	return Collector[T, *queue.Queue[T]]{
+		New: newQueue,
+		Add: func(q *queue.Queue[T], v T) *queue.Queue[T] {
+			q.Enqueue(v)
+			return q
+		},
+	}
+}
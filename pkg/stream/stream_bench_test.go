@@ -0,0 +1,48 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stream_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	stream "github.com/pzaino/gods/pkg/stream"
+)
+
+// BenchmarkMapFilter maps and filters n elements into a slice
+func BenchmarkMapFilter(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = stream.FromSlice(values).
+				Map(func(v int) int { return v * 3 }).
+				Filter(func(v int) bool { return v%2 == 0 }).
+				ToSlice()
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkSort sorts n shuffled elements into a slice
+func BenchmarkSort(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Ints(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = stream.FromSlice(values).Sorted(func(x, y int) bool { return x < y }).ToSlice()
+		}
+	})
+}
@@ -0,0 +1,294 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stream provides lazy, chainable pipelines over the gods containers.
+package stream_test
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	linkList "github.com/pzaino/gods/pkg/linkList"
+	queue "github.com/pzaino/gods/pkg/queue"
+	stack "github.com/pzaino/gods/pkg/stack"
+	stream "github.com/pzaino/gods/pkg/stream"
+)
+
+const (
+	errExpectedSlice = "Expected %v, but got %v"
+	errExpectedValue = "Expected %v, but got %v"
+	errExpectedSize  = "Expected size %d, but got %d"
+)
+
+func isEven(v int) bool { return v%2 == 0 }
+
+func less(a, b int) bool { return a < b }
+
+func TestFromSlice(t *testing.T) {
+	got := stream.FromSlice([]int{1, 2, 3}).ToSlice()
+	if !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf(errExpectedSlice, []int{1, 2, 3}, got)
+	}
+
+	if got := stream.FromSlice[int](nil).ToSlice(); got != nil {
+		t.Errorf(errExpectedSlice, nil, got)
+	}
+	if n := stream.Empty[int]().Count(); n != 0 {
+		t.Errorf(errExpectedSize, 0, n)
+	}
+}
+
+func TestFromBuffer(t *testing.T) {
+	b := buffer.New[int]()
+	for i := 1; i <= 5; i++ {
+		_ = b.Append(i)
+	}
+	got := stream.FromBuffer(b).Filter(isEven).ToSlice()
+	if !reflect.DeepEqual(got, []int{2, 4}) {
+		t.Errorf(errExpectedSlice, []int{2, 4}, got)
+	}
+	if b.Size() != 5 {
+		t.Errorf(errExpectedSize, 5, b.Size())
+	}
+}
+
+func TestFromList(t *testing.T) {
+	l := linkList.NewFromSlice([]int{3, 1, 2})
+	got := stream.FromList(l).Map(func(v int) int { return v * 10 }).ToSlice()
+	if !reflect.DeepEqual(got, []int{30, 10, 20}) {
+		t.Errorf(errExpectedSlice, []int{30, 10, 20}, got)
+	}
+
+	if n := stream.FromList(linkList.New[int]()).Count(); n != 0 {
+		t.Errorf(errExpectedSize, 0, n)
+	}
+}
+
+func TestFromQueue(t *testing.T) {
+	q := queue.New[int]()
+	q.EnqueueN(1, 2, 3)
+	got := stream.FromQueue(q).ToSlice()
+	if !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf(errExpectedSlice, []int{1, 2, 3}, got)
+	}
+	if q.Size() != 3 {
+		t.Errorf(errExpectedSize, 3, q.Size())
+	}
+
+	// the front of the queue is no longer at the start of its storage
+	_, _ = q.Dequeue()
+	q.EnqueueN(4, 5, 6, 7)
+	got = stream.FromQueue(q).Take(4).ToSlice()
+	if !reflect.DeepEqual(got, []int{2, 3, 4, 5}) {
+		t.Errorf(errExpectedSlice, []int{2, 3, 4, 5}, got)
+	}
+}
+
+func TestFromStack(t *testing.T) {
+	s := stack.NewFromSlice([]int{1, 2, 3})
+	got := stream.FromStack(s).ToSlice()
+	if !reflect.DeepEqual(got, s.ToSlice()) {
+		t.Errorf(errExpectedSlice, s.ToSlice(), got)
+	}
+	if s.Size() != 3 {
+		t.Errorf(errExpectedSize, 3, s.Size())
+	}
+}
+
+func TestMapTo(t *testing.T) {
+	got := stream.MapTo(stream.FromSlice([]int{1, 2, 3}), strconv.Itoa).ToSlice()
+	if !reflect.DeepEqual(got, []string{"1", "2", "3"}) {
+		t.Errorf(errExpectedSlice, []string{"1", "2", "3"}, got)
+	}
+}
+
+func TestTakeSkip(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+	tests := []struct {
+		name     string
+		s        *stream.Stream[int]
+		expected []int
+	}{
+		{"Take", stream.FromSlice(items).Take(2), []int{1, 2}},
+		{"TakeAll", stream.FromSlice(items).Take(10), items},
+		{"TakeZero", stream.FromSlice(items).Take(0), nil},
+		{"Skip", stream.FromSlice(items).Skip(3), []int{4, 5}},
+		{"SkipAll", stream.FromSlice(items).Skip(10), nil},
+		{"SkipTake", stream.FromSlice(items).Skip(1).Take(3), []int{2, 3, 4}},
+		{"TakeWhile", stream.FromSlice(items).TakeWhile(func(v int) bool { return v < 3 }), []int{1, 2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.s.ToSlice()
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf(errExpectedSlice, tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestLaziness(t *testing.T) {
+	// An infinite source must work as long as the pipeline is bounded
+	i := 0
+	naturals := stream.New(func() (int, bool) {
+		i++
+		return i, true
+	})
+	pulled := 0
+	got := naturals.Peek(func(int) { pulled++ }).Filter(isEven).Take(3).ToSlice()
+	if !reflect.DeepEqual(got, []int{2, 4, 6}) {
+		t.Errorf(errExpectedSlice, []int{2, 4, 6}, got)
+	}
+	if pulled != 6 {
+		t.Errorf("Expected 6 elements pulled from the source, but got %d", pulled)
+	}
+}
+
+func TestDistinct(t *testing.T) {
+	got := stream.FromSlice([]int{3, 1, 3, 2, 1}).Distinct().ToSlice()
+	if !reflect.DeepEqual(got, []int{3, 1, 2}) {
+		t.Errorf(errExpectedSlice, []int{3, 1, 2}, got)
+	}
+}
+
+func TestSorted(t *testing.T) {
+	got := stream.FromSlice([]int{5, 3, 4, 1, 2}).Sorted(less).Take(3).ToSlice()
+	if !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf(errExpectedSlice, []int{1, 2, 3}, got)
+	}
+
+	// Stability: order by tens only
+	byTens := func(a, b int) bool { return a/10 < b/10 }
+	got = stream.FromSlice([]int{21, 11, 22, 12}).Sorted(byTens).ToSlice()
+	if !reflect.DeepEqual(got, []int{11, 12, 21, 22}) {
+		t.Errorf(errExpectedSlice, []int{11, 12, 21, 22}, got)
+	}
+}
+
+func TestConcat(t *testing.T) {
+	got := stream.FromSlice([]int{1, 2}).Concat(stream.FromSlice([]int{3})).ToSlice()
+	if !reflect.DeepEqual(got, []int{1, 2, 3}) {
+		t.Errorf(errExpectedSlice, []int{1, 2, 3}, got)
+	}
+}
+
+func TestTerminalOperations(t *testing.T) {
+	items := []int{1, 2, 3, 4}
+	sum := func(a, b int) int { return a + b }
+
+	if v := stream.FromSlice(items).Reduce(sum, 0); v != 10 {
+		t.Errorf(errExpectedValue, 10, v)
+	}
+	if n := stream.FromSlice(items).Count(); n != 4 {
+		t.Errorf(errExpectedSize, 4, n)
+	}
+	if v, ok := stream.FromSlice(items).Filter(isEven).First(); !ok || v != 2 {
+		t.Errorf(errExpectedValue, 2, v)
+	}
+	if _, ok := stream.Empty[int]().First(); ok {
+		t.Error("Expected no first element on an empty stream")
+	}
+	if !stream.FromSlice(items).Any(isEven) {
+		t.Error("Expected Any to return true")
+	}
+	if stream.FromSlice(items).All(isEven) {
+		t.Error("Expected All to return false")
+	}
+	if !stream.FromSlice([]int{2, 4}).All(isEven) {
+		t.Error("Expected All to return true")
+	}
+
+	var seen []int
+	stream.FromSlice(items).ForEach(func(v int) { seen = append(seen, v) })
+	if !reflect.DeepEqual(seen, items) {
+		t.Errorf(errExpectedSlice, items, seen)
+	}
+}
+
+func TestSingleUse(t *testing.T) {
+	s := stream.FromSlice([]int{1, 2, 3})
+	_ = s.ToSlice()
+	if n := s.Count(); n != 0 {
+		t.Errorf(errExpectedSize, 0, n)
+	}
+}
+
+func TestCollect(t *testing.T) {
+	items := []int{1, 2, 3}
+
+	b := stream.Collect(stream.FromSlice(items), stream.ToBuffer[int]())
+	if !reflect.DeepEqual(b.ToSlice(), items) {
+		t.Errorf(errExpectedSlice, items, b.ToSlice())
+	}
+
+	s := stream.Collect(stream.FromSlice(items), stream.ToStack[int]())
+	if top, err := s.Top(); err != nil || *top != 3 {
+		t.Errorf(errExpectedValue, 3, top)
+	}
+	if s.Size() != 3 {
+		t.Errorf(errExpectedSize, 3, s.Size())
+	}
+
+	q := stream.Collect(stream.FromSlice(items), stream.ToQueue[int]())
+	if !reflect.DeepEqual(q.Values(), items) {
+		t.Errorf(errExpectedSlice, items, q.Values())
+	}
+
+	nested := [][]int{{1}, {2, 3}}
+	eq := slices.Equal[[]int]
+	nb := stream.Collect(stream.FromSlice(nested), stream.ToBufferWithComparator(eq))
+	ns := stream.Collect(stream.FromSlice(nested), stream.ToStackWithComparator(eq))
+	nq := stream.Collect(stream.FromSlice(nested), stream.ToQueueWithComparator(eq))
+	if !nb.Contains([]int{2, 3}) || !ns.Contains([]int{2, 3}) || !nq.Contains([]int{2, 3}) {
+		t.Error("expected the collected containers to compare their elements with the given function")
+	}
+
+	l := stream.FromSlice(items).ToList()
+	if !reflect.DeepEqual(l.ToSlice(), items) {
+		t.Errorf(errExpectedSlice, items, l.ToSlice())
+	}
+	if l.Size() != 3 {
+		t.Errorf(errExpectedSize, 3, l.Size())
+	}
+}
+
+func BenchmarkStreamPipeline(b *testing.B) {
+	buf := buffer.New[int]()
+	for i := 0; i < 10000; i++ {
+		_ = buf.Append(i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = stream.FromBuffer(buf).
+			Filter(isEven).
+			Map(func(v int) int { return v * 3 }).
+			Reduce(func(a, v int) int { return a + v }, 0)
+	}
+}
+
+func TestNonComparable(t *testing.T) {
+	items := [][]int{{1}, {2, 3}, {1}, {4}}
+	got := stream.FromSlice(items).DistinctFunc(slices.Equal[[]int]).ToSlice()
+	if !reflect.DeepEqual(got, [][]int{{1}, {2, 3}, {4}}) {
+		t.Errorf("expected [[1] [2 3] [4]], got %v", got)
+	}
+
+	lengths := stream.MapTo(stream.FromSlice(items), func(v []int) int { return len(v) }).Distinct().ToSlice()
+	if !reflect.DeepEqual(lengths, []int{1, 2}) {
+		t.Errorf("expected [1 2], got %v", lengths)
+	}
+}
@@ -0,0 +1,281 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package window provides non-concurrent-safe streaming statistics (count,
+// sum, mean, min, max, standard deviation and percentiles) over a sliding
+// window of the last N values, stored in a ring buffer (e.g. for moving
+// averages of telemetry samples).
+package window
+
+import (
+	"cmp"
+	"errors"
+	"math"
+
+	buffer "github.com/pzaino/gods/pkg/buffer"
+	common "github.com/pzaino/gods/pkg/common"
+	ringBuffer "github.com/pzaino/gods/pkg/ringBuffer"
+)
+
+// Sentinel errors returned by the Window methods (use errors.Is to check for them)
+var (
+	ErrInvalidSize       = errors.New("invalid window size")
+	ErrEmpty             = errors.New("window is empty")
+	ErrInvalidPercentile = errors.New("percentile must be between 0 and 100")
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures()
+}
+
+// entry is a value of the window with its sequence number (the number of
+// values pushed before it), used by the min and max deques
+type entry[T any] struct {
+	seq   uint64
+	value T
+}
+
+// extremes is a monotonic deque: the values of the window that can still
+// become its min (or max), in the order they were pushed
+type extremes[T any] struct {
+	entries []entry[T]
+	head    int
+	evict   func(back, v T) bool // true if back can never be the extreme again once v is pushed
+}
+
+// push adds v, dropping the values it makes useless (amortized O(1))
+func (e *extremes[T]) push(seq uint64, v T) {
+	for len(e.entries) > e.head && e.evict(e.entries[len(e.entries)-1].value, v) {
+		e.entries = e.entries[:len(e.entries)-1]
+	}
+	e.entries = append(e.entries, entry[T]{seq: seq, value: v})
+}
+
+// expire drops the values pushed before seq (they left the window)
+func (e *extremes[T]) expire(seq uint64) {
+	for e.head < len(e.entries) && e.entries[e.head].seq < seq {
+		e.head++
+	}
+	// Reclaim the space of the expired entries once they are the majority
+	if e.head > len(e.entries)/2 {
+		n := copy(e.entries, e.entries[e.head:])
+		e.entries = e.entries[:n]
+		e.head = 0
+	}
+}
+
+// front returns the current extreme, the deque must not be empty
+func (e *extremes[T]) front() T {
+	return e.entries[e.head].value
+}
+
+// reset empties the deque
+func (e *extremes[T]) reset() {
+	e.entries = e.entries[:0]
+	e.head = 0
+}
+
+// Window holds the last N values pushed and keeps their statistics up to
+// date, every Push is O(1) (amortized for Min and Max)
+type Window[T common.Number] struct {
+	ring    *ringBuffer.CircularBuffer[T]
+	pushed  uint64 // number of values pushed since the window was created or cleared
+	evicted uint64 // values evicted since the statistics were last recomputed
+	sum     T
+	mean    float64
+	m2      float64 // sum of the squared differences from the mean (Welford)
+	min     extremes[T]
+	max     extremes[T]
+}
+
+// New creates a new empty Window over the last size values
+func New[T common.Number](size uint64) (*Window[T], error) {
+	if size == 0 {
+		return nil, ErrInvalidSize
+	}
+	return &Window[T]{
+		ring: ringBuffer.New[T](size),
+		min:  extremes[T]{evict: func(back, v T) bool { return back >= v }},
+		max:  extremes[T]{evict: func(back, v T) bool { return back <= v }},
+	}, nil
+}
+
+// Push adds a value to the window, evicting the oldest one if the window is full
+func (w *Window[T]) Push(value T) {
+	if w.ring.IsFull() {
+		old, _ := w.ring.Remove()
+		w.remove(old)
+	}
+	w.ring.Append(value)
+	w.add(value)
+
+	w.min.push(w.pushed, value)
+	w.max.push(w.pushed, value)
+	w.pushed++
+	first := w.pushed - w.ring.Size() // sequence number of the oldest value
+	w.min.expire(first)
+	w.max.expire(first)
+
+	// The incremental updates accumulate rounding errors, recompute the
+	// statistics from scratch every time the whole window has been replaced
+	if w.evicted >= w.ring.Capacity() {
+		w.recompute()
+	}
+}
+
+// add updates the sum, mean and m2 for a new value
+func (w *Window[T]) add(value T) {
+	x := float64(value)
+	n := float64(w.ring.Size())
+	delta := x - w.mean
+	w.sum += value
+	w.mean += delta / n
+	w.m2 += delta * (x - w.mean)
+}
+
+// remove updates the sum, mean and m2 for an evicted value
+func (w *Window[T]) remove(value T) {
+	w.evicted++
+	w.sum -= value
+	n := float64(w.ring.Size())
+	if n == 0 {
+		w.mean, w.m2 = 0, 0
+		return
+	}
+	x := float64(value)
+	delta := x - w.mean
+	w.mean -= delta / n
+	w.m2 -= delta * (x - w.mean)
+}
+
+// recompute computes the sum, mean and m2 from the values in the window
+func (w *Window[T]) recompute() {
+	w.evicted = 0
+	w.sum, w.mean, w.m2 = 0, 0, 0
+	n := 0.0
+	w.ring.ForEach(func(value T) {
+		x := float64(value)
+		n++
+		delta := x - w.mean
+		w.sum += value
+		w.mean += delta / n
+		w.m2 += delta * (x - w.mean)
+	})
+}
+
+// Count returns the number of values in the window
+func (w *Window[T]) Count() uint64 {
+	return w.ring.Size()
+}
+
+// Size returns the maximum number of values in the window
+func (w *Window[T]) Size() uint64 {
+	return w.ring.Capacity()
+}
+
+// IsEmpty returns true if no value has been pushed since the window was
+// created or cleared
+func (w *Window[T]) IsEmpty() bool {
+	return w.ring.IsEmpty()
+}
+
+// IsFull returns true if the window holds Size values, so every Push evicts one
+func (w *Window[T]) IsFull() bool {
+	return w.ring.IsFull()
+}
+
+// Sum returns the sum of the values in the window (0 if it's empty)
+func (w *Window[T]) Sum() T {
+	return w.sum
+}
+
+// Mean returns the arithmetic mean of the values in the window
+func (w *Window[T]) Mean() (float64, error) {
+	if w.IsEmpty() {
+		return 0, ErrEmpty
+	}
+	return w.mean, nil
+}
+
+// Variance returns the population variance of the values in the window
+func (w *Window[T]) Variance() (float64, error) {
+	if w.IsEmpty() {
+		return 0, ErrEmpty
+	}
+	return math.Max(w.m2, 0) / float64(w.ring.Size()), nil
+}
+
+// StdDev returns the population standard deviation of the values in the window
+func (w *Window[T]) StdDev() (float64, error) {
+	variance, err := w.Variance()
+	if err != nil {
+		return 0, err
+	}
+	return math.Sqrt(variance), nil
+}
+
+// Min returns the smallest value in the window
+func (w *Window[T]) Min() (T, error) {
+	if w.IsEmpty() {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+	return w.min.front(), nil
+}
+
+// Max returns the largest value in the window
+func (w *Window[T]) Max() (T, error) {
+	if w.IsEmpty() {
+		var rVal T
+		return rVal, ErrEmpty
+	}
+	return w.max.front(), nil
+}
+
+// Percentile returns the value at the given percentile (between 0 and 100) of
+// the values in the window with the nearest-rank method: the smallest value
+// such that at least p% of the values are less than or equal to it. It's
+// computed on demand with a quickselect over a copy of the window, O(n) on
+// average
+func (w *Window[T]) Percentile(p float64) (T, error) {
+	var rVal T
+	if math.IsNaN(p) || p < 0 || p > 100 {
+		return rVal, ErrInvalidPercentile
+	}
+	if w.IsEmpty() {
+		return rVal, ErrEmpty
+	}
+
+	k := uint64(math.Ceil(p / 100 * float64(w.ring.Size())))
+	if k > 0 {
+		k--
+	}
+	return buffer.Adopt(w.ring.ToSlice()).SelectNth(k, cmp.Less[T])
+}
+
+// Values returns a copy of the values in the window, from the oldest to the newest
+func (w *Window[T]) Values() []T {
+	return w.ring.ToSlice()
+}
+
+// Clear removes all the values from the window
+func (w *Window[T]) Clear() {
+	w.ring.Clear()
+	w.pushed, w.evicted = 0, 0
+	w.sum, w.mean, w.m2 = 0, 0, 0
+	w.min.reset()
+	w.max.reset()
+}
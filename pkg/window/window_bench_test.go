@@ -0,0 +1,51 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	window "github.com/pzaino/gods/pkg/window"
+)
+
+// BenchmarkFill pushes n values in a window of size n
+func BenchmarkFill(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		values := bench.Float64s(n)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			w, _ := window.New[float64](uint64(n))
+			for _, v := range values {
+				w.Push(v)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkPercentile computes the median of a full window of size n
+func BenchmarkPercentile(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		w, _ := window.New[float64](uint64(n))
+		for _, v := range bench.Float64s(n) {
+			w.Push(v)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, _ = w.Percentile(50)
+		}
+	})
+}
@@ -0,0 +1,147 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package window_test
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+
+	window "github.com/pzaino/gods/pkg/window"
+)
+
+const errUnexpectedErr = "unexpected error: %v"
+
+func TestNew(t *testing.T) {
+	if _, err := window.New[int](0); !errors.Is(err, window.ErrInvalidSize) {
+		t.Errorf("expected %v, got %v", window.ErrInvalidSize, err)
+	}
+
+	w, err := window.New[int](3)
+	if err != nil {
+		t.Fatalf(errUnexpectedErr, err)
+	}
+	if !w.IsEmpty() || w.Size() != 3 || w.Sum() != 0 {
+		t.Errorf("expected an empty window of size 3, got %d values", w.Count())
+	}
+	if _, err := w.Mean(); !errors.Is(err, window.ErrEmpty) {
+		t.Errorf("expected %v, got %v", window.ErrEmpty, err)
+	}
+	if _, err := w.Min(); !errors.Is(err, window.ErrEmpty) {
+		t.Errorf("expected %v, got %v", window.ErrEmpty, err)
+	}
+	if _, err := w.Percentile(50); !errors.Is(err, window.ErrEmpty) {
+		t.Errorf("expected %v, got %v", window.ErrEmpty, err)
+	}
+}
+
+func TestSlidingStats(t *testing.T) {
+	w, _ := window.New[int](3)
+	for _, v := range []int{4, 8, 6, 2} {
+		w.Push(v)
+	}
+
+	// The window holds 8, 6, 2
+	if !w.IsFull() || w.Count() != 3 || !reflect.DeepEqual(w.Values(), []int{8, 6, 2}) {
+		t.Fatalf("expected [8 6 2], got %v", w.Values())
+	}
+	if w.Sum() != 16 {
+		t.Errorf("expected sum 16, got %d", w.Sum())
+	}
+	if mean, _ := w.Mean(); math.Abs(mean-16.0/3) > 1e-9 {
+		t.Errorf("expected mean %f, got %f", 16.0/3, mean)
+	}
+	if v, _ := w.Min(); v != 2 {
+		t.Errorf("expected min 2, got %d", v)
+	}
+	if v, _ := w.Max(); v != 8 {
+		t.Errorf("expected max 8, got %d", v)
+	}
+	if sd, _ := w.StdDev(); math.Abs(sd-math.Sqrt(56.0/9)) > 1e-9 {
+		t.Errorf("expected stddev %f, got %f", math.Sqrt(56.0/9), sd)
+	}
+	if v, _ := w.Percentile(50); v != 6 {
+		t.Errorf("expected median 6, got %d", v)
+	}
+	if v, _ := w.Percentile(0); v != 2 {
+		t.Errorf("expected 2, got %d", v)
+	}
+	if _, err := w.Percentile(101); !errors.Is(err, window.ErrInvalidPercentile) {
+		t.Errorf("expected %v, got %v", window.ErrInvalidPercentile, err)
+	}
+
+	w.Clear()
+	if !w.IsEmpty() || w.Sum() != 0 {
+		t.Errorf("expected an empty window, got %v", w.Values())
+	}
+	w.Push(1)
+	if v, _ := w.Max(); v != 1 {
+		t.Errorf("expected max 1, got %d", v)
+	}
+}
+
+// TestAgainstModel compares the statistics with the ones computed from scratch
+// over a slice holding the same values
+func TestAgainstModel(t *testing.T) {
+	const size = 7
+	rng := rand.New(rand.NewSource(1))
+	w, _ := window.New[float64](size)
+	var model []float64
+
+	for i := 0; i < 1000; i++ {
+		v := math.Round(rng.Float64()*1000) / 10
+		w.Push(v)
+		model = append(model, v)
+		if len(model) > size {
+			model = model[1:]
+		}
+
+		sum, sq := 0.0, 0.0
+		for _, x := range model {
+			sum += x
+		}
+		mean := sum / float64(len(model))
+		for _, x := range model {
+			sq += (x - mean) * (x - mean)
+		}
+
+		if got, _ := w.Mean(); math.Abs(got-mean) > 1e-6 {
+			t.Fatalf("step %d: expected mean %f, got %f", i, mean, got)
+		}
+		if got, _ := w.Variance(); math.Abs(got-sq/float64(len(model))) > 1e-6 {
+			t.Fatalf("step %d: expected variance %f, got %f", i, sq/float64(len(model)), got)
+		}
+		if got, _ := w.Min(); got != slices.Min(model) {
+			t.Fatalf("step %d: expected min %f, got %f", i, slices.Min(model), got)
+		}
+		if got, _ := w.Max(); got != slices.Max(model) {
+			t.Fatalf("step %d: expected max %f, got %f", i, slices.Max(model), got)
+		}
+		sorted := slices.Sorted(slices.Values(model))
+		if got, _ := w.Percentile(50); got != sorted[(len(sorted)+1)/2-1] {
+			t.Fatalf("step %d: expected median %f, got %f", i, sorted[(len(sorted)+1)/2-1], got)
+		}
+	}
+}
+
+func BenchmarkPush(b *testing.B) {
+	w, _ := window.New[float64](1024)
+	for i := 0; i < b.N; i++ {
+		w.Push(float64(i % 977))
+	}
+}
@@ -0,0 +1,185 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package wsDeque provides an unbounded, lock-free work-stealing deque (the
+// Chase-Lev deque), the building block of work-stealing schedulers: every
+// worker owns a deque, pushes and pops its tasks at the bottom (LIFO, good
+// for cache locality) and idle workers steal tasks from the top of the
+// deques of the others (FIFO, so they take the oldest and usually biggest
+// tasks).
+//
+// Concurrency contract: Push and Pop must only be called by the goroutine
+// that owns the deque, Steal, Size and IsEmpty can be called by any
+// goroutine. Every element is returned exactly once, by Pop or by Steal.
+//
+// The elements are boxed so that the slots can be read and written
+// atomically, which costs an allocation per Push.
+package wsDeque
+
+import (
+	"sync/atomic"
+
+	common "github.com/pzaino/gods/pkg/common"
+)
+
+// Features returns the optional features supported by the package (see
+// common.Feature)
+func Features() common.Features {
+	return common.NewFeatures(common.FeatureConcurrent)
+}
+
+// minCapacity is the capacity of the ring of a new deque
+const minCapacity = 16
+
+// cacheLinePad keeps the top and bottom indexes on different cache lines
+type cacheLinePad [64]byte
+
+// ring is a circular array of slots, index i is stored at i&mask
+type ring[T any] struct {
+	slots []atomic.Pointer[T]
+	mask  int64
+}
+
+// newRing creates a ring of capacity slots (a power of two)
+func newRing[T any](capacity int64) *ring[T] {
+	return &ring[T]{slots: make([]atomic.Pointer[T], capacity), mask: capacity - 1}
+}
+
+func (r *ring[T]) get(i int64) *T {
+	return r.slots[i&r.mask].Load()
+}
+
+func (r *ring[T]) put(i int64, v *T) {
+	r.slots[i&r.mask].Store(v)
+}
+
+// grow returns a ring twice as big with the elements in [top, bottom)
+func (r *ring[T]) grow(top, bottom int64) *ring[T] {
+	bigger := newRing[T](2 * int64(len(r.slots)))
+	for i := top; i < bottom; i++ {
+		bigger.put(i, r.get(i))
+	}
+	return bigger
+}
+
+// Deque is a work-stealing deque, the elements are in [top, bottom): the owner
+// works at the bottom and the thieves at the top. top only grows, so a thief
+// that read top can't be fooled by the owner reusing the slot (no ABA)
+type Deque[T any] struct {
+	_      cacheLinePad
+	top    atomic.Int64
+	_      cacheLinePad
+	bottom atomic.Int64
+	_      cacheLinePad
+	ring   atomic.Pointer[ring[T]]
+}
+
+// New creates a new empty Deque
+func New[T any]() *Deque[T] {
+	return NewWithCapacity[T](minCapacity)
+}
+
+// NewWithCapacity creates a new empty Deque with room for at least capacity
+// elements before it has to grow
+func NewWithCapacity[T any](capacity uint64) *Deque[T] {
+	size := int64(minCapacity)
+	for uint64(size) < capacity {
+		size <<= 1
+	}
+	d := &Deque[T]{}
+	d.ring.Store(newRing[T](size))
+	return d
+}
+
+// Push adds an element at the bottom of the deque, growing it if it's full.
+// Only the owner of the deque can call it
+func (d *Deque[T]) Push(value T) {
+	b := d.bottom.Load()
+	t := d.top.Load()
+	r := d.ring.Load()
+	if b-t >= int64(len(r.slots)) {
+		// Thieves still using the old ring read the same elements from it
+		r = r.grow(t, b)
+		d.ring.Store(r)
+	}
+	r.put(b, &value)
+	d.bottom.Store(b + 1) // publish the element to the thieves
+}
+
+// Pop removes and returns the element at the bottom of the deque (the last
+// pushed), the boolean is false if the deque is empty. Only the owner of the
+// deque can call it
+func (d *Deque[T]) Pop() (T, bool) {
+	var zero T
+	b := d.bottom.Load() - 1
+	r := d.ring.Load()
+	d.bottom.Store(b) // reserve the element before looking at top
+	t := d.top.Load()
+
+	if t > b {
+		// Empty
+		d.bottom.Store(b + 1)
+		return zero, false
+	}
+
+	v := r.get(b)
+	if t < b {
+		// More than one element, the thieves can't reach this one
+		r.put(b, nil) // let the GC collect the element
+		return *v, true
+	}
+
+	// Last element, race with the thieves for it
+	won := d.top.CompareAndSwap(t, t+1)
+	d.bottom.Store(b + 1)
+	if !won {
+		return zero, false
+	}
+	return *v, true
+}
+
+// Steal removes and returns the element at the top of the deque (the oldest),
+// the boolean is false if the deque is empty. It can be called by any
+// goroutine
+func (d *Deque[T]) Steal() (T, bool) {
+	for {
+		t := d.top.Load()
+		b := d.bottom.Load()
+		if t >= b {
+			var zero T
+			return zero, false
+		}
+
+		v := d.ring.Load().get(t)
+		if d.top.CompareAndSwap(t, t+1) {
+			return *v, true
+		}
+		// Another thief (or the owner popping the last element) won, retry
+	}
+}
+
+// Size returns an approximation of the number of elements in the deque
+func (d *Deque[T]) Size() uint64 {
+	b := d.bottom.Load()
+	t := d.top.Load()
+	if b <= t {
+		return 0
+	}
+	return uint64(b - t)
+}
+
+// IsEmpty checks if the deque is (approximately) empty
+func (d *Deque[T]) IsEmpty() bool {
+	return d.Size() == 0
+}
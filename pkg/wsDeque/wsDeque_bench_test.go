@@ -0,0 +1,53 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wsDeque_test
+
+import (
+	"testing"
+
+	bench "github.com/pzaino/gods/internal/bench"
+	wsDeque "github.com/pzaino/gods/pkg/wsDeque"
+)
+
+// BenchmarkPush pushes n elements
+func BenchmarkPush(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		for i := 0; i < b.N; i++ {
+			d := wsDeque.New[int]()
+			for v := 0; v < n; v++ {
+				d.Push(v)
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
+
+// BenchmarkPop pops (as the owner) n elements
+func BenchmarkPop(b *testing.B) {
+	bench.Run(b, func(b *testing.B, n int) {
+		d := wsDeque.NewWithCapacity[int](uint64(n))
+		for i := 0; i < b.N; i++ {
+			b.StopTimer()
+			for v := 0; v < n; v++ {
+				d.Push(v)
+			}
+			b.StartTimer()
+			for v := 0; v < n; v++ {
+				_, _ = d.Pop()
+			}
+		}
+		bench.PerElement(b, n)
+	})
+}
@@ -0,0 +1,134 @@
+// Copyright 2024 Paolo Fabio Zaino
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package wsDeque_test
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	wsDeque "github.com/pzaino/gods/pkg/wsDeque"
+)
+
+func TestPushPopSteal(t *testing.T) {
+	d := wsDeque.New[int]()
+	if _, ok := d.Pop(); ok {
+		t.Error("expected Pop to fail on an empty deque")
+	}
+	if _, ok := d.Steal(); ok {
+		t.Error("expected Steal to fail on an empty deque")
+	}
+
+	// Push past the initial capacity to exercise the growth
+	for i := 0; i < 100; i++ {
+		d.Push(i)
+	}
+	if d.Size() != 100 {
+		t.Errorf("expected size 100, got %d", d.Size())
+	}
+	if v, ok := d.Pop(); !ok || v != 99 {
+		t.Errorf("expected Pop to return 99, got %d (%v)", v, ok)
+	}
+	if v, ok := d.Steal(); !ok || v != 0 {
+		t.Errorf("expected Steal to return 0, got %d (%v)", v, ok)
+	}
+	for i := 98; i >= 1; i-- {
+		if v, ok := d.Pop(); !ok || v != i {
+			t.Fatalf("expected Pop to return %d, got %d (%v)", i, v, ok)
+		}
+	}
+	if !d.IsEmpty() {
+		t.Errorf("expected an empty deque, got size %d", d.Size())
+	}
+	if _, ok := d.Pop(); ok {
+		t.Error("expected Pop to fail on an empty deque")
+	}
+
+	// The deque is still usable after being emptied
+	d.Push(7)
+	if v, ok := d.Steal(); !ok || v != 7 {
+		t.Errorf("expected Steal to return 7, got %d (%v)", v, ok)
+	}
+}
+
+// TestStress checks that with an owner pushing and popping and many thieves
+// stealing no element is lost or returned twice
+func TestStress(t *testing.T) {
+	const n, thieves = 200000, 4
+	d := wsDeque.NewWithCapacity[int](4)
+	seen := make([]atomic.Int32, n)
+	var done atomic.Bool
+
+	var wg sync.WaitGroup
+	for i := 0; i < thieves; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !done.Load() || !d.IsEmpty() {
+				if v, ok := d.Steal(); ok {
+					seen[v].Add(1)
+				} else {
+					runtime.Gosched()
+				}
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		d.Push(i)
+		if i%3 == 0 {
+			if v, ok := d.Pop(); ok {
+				seen[v].Add(1)
+			}
+		}
+	}
+	for {
+		v, ok := d.Pop()
+		if !ok {
+			break
+		}
+		seen[v].Add(1)
+	}
+	done.Store(true)
+	wg.Wait()
+
+	for v := range seen {
+		if c := seen[v].Load(); c != 1 {
+			t.Fatalf("element %d returned %d times", v, c)
+		}
+	}
+}
+
+func BenchmarkPushPop(b *testing.B) {
+	d := wsDeque.New[int]()
+	for i := 0; i < b.N; i++ {
+		d.Push(i)
+		d.Pop()
+	}
+}
+
+func BenchmarkSteal(b *testing.B) {
+	d := wsDeque.New[int]()
+	for i := 0; i < b.N; i++ {
+		d.Push(i)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			d.Steal()
+		}
+	})
+}
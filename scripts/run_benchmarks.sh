@@ -0,0 +1,49 @@
+#!/bin/bash
+
+# Runs the benchmarks of all the packages (or the given ones) and compares
+# them with the baseline in doc/benchmarks_baseline.txt using benchstat
+# (go install golang.org/x/perf/cmd/benchstat@latest), see doc/benchmarks.md
+#
+# Usage: scripts/run_benchmarks.sh [-full] [-update] [-bench regexp] [packages]
+#   -full          also run the 10M elements sizes (slow, needs a few GB of RAM)
+#   -update        replace the baseline with the results (only the benchmark
+#                  lines and their goos/goarch/pkg/cpu headers are kept)
+#   -bench regexp  run only the matching benchmarks (default: all)
+#   -count n       run every benchmark n times (default: 5)
+
+set -e
+
+cd "$(dirname "$0")/.."
+
+baseline=doc/benchmarks_baseline.txt
+output=bench_output.txt
+short=-short
+update=0
+bench=.
+count=5
+packages=()
+
+while [ $# -gt 0 ]; do
+    case "$1" in
+        -full) short= ;;
+        -update) update=1 ;;
+        -bench) bench="$2"; shift ;;
+        -count) count="$2"; shift ;;
+        *) packages+=("$1") ;;
+    esac
+    shift
+done
+if [ ${#packages[@]} -eq 0 ]; then
+    packages=(./...)
+fi
+
+go test $short -run '^$' -bench "$bench" -benchmem -count "$count" -timeout 0 "${packages[@]}" | tee "$output"
+
+if [ "$update" -eq 1 ]; then
+    grep -E '^(goos|goarch|pkg|cpu|Benchmark)' "$output" > "$baseline"
+    echo "Baseline updated: $baseline"
+elif command -v benchstat > /dev/null; then
+    benchstat "$baseline" "$output"
+else
+    echo "benchstat not found, compare $output with $baseline manually"
+fi